@@ -1,23 +1,41 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"truadmin/internal/config"
 	"truadmin/internal/database"
+	"truadmin/internal/graphqlapi"
+	"truadmin/internal/grpcapi"
 	"truadmin/internal/handlers"
+	"truadmin/internal/logging"
+	"truadmin/internal/middleware"
+	"truadmin/internal/models"
 	"truadmin/internal/router"
 	"truadmin/internal/services"
+	"truadmin/internal/webui"
 )
 
 func main() {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Failed to load configuration:", err)
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Structured logging; the standard log package writes through it too
+	logger := logging.Setup(cfg.LogFormat, cfg.LogLevel)
+	if cfg.ConfigFile != "" {
+		log.Printf("Loaded configuration file %s", cfg.ConfigFile)
 	}
 
 	// Set Gin mode
@@ -41,31 +59,324 @@ func main() {
 		defer database.Close()
 	}
 
+	// Audit event sinks (optional)
+	var auditSinks []services.AuditSink
+	if cfg.AuditWebhookURL != "" {
+		auditSinks = append(auditSinks, services.NewWebhookAuditSink(cfg.AuditWebhookURL))
+	}
+	if cfg.AuditSyslogAddress != "" {
+		facility, err := services.ParseSyslogFacility(cfg.AuditSyslogFacility)
+		if err != nil {
+			log.Fatal("Invalid audit syslog configuration:", err)
+		}
+		sink, err := services.NewSyslogAuditSink(cfg.AuditSyslogAddress, facility, cfg.AuditSyslogAppName)
+		if err != nil {
+			log.Fatal("Invalid audit syslog configuration:", err)
+		}
+		auditSinks = append(auditSinks, sink)
+	}
+	if cfg.AuditFilePath != "" {
+		sink, err := services.NewFileAuditSink(cfg.AuditFilePath)
+		if err != nil {
+			log.Fatal("Invalid audit file configuration:", err)
+		}
+		auditSinks = append(auditSinks, sink)
+	}
+	auditService := services.NewAuditService(logger.With("service", "audit"), auditSinks...)
+	defer auditService.Close()
+
+	sharedStore, err := services.NewSharedStore(cfg.RedisURL, logger.With("service", "shared_store"))
+	if err != nil {
+		log.Fatal("Invalid Redis configuration:", err)
+	}
+	defer sharedStore.Close()
+	if err := sharedStore.Ping(); err != nil {
+		log.Printf("WARNING: Shared store %s unreachable: %v", sharedStore.Name(), err)
+	}
+
 	// Initialize services
-	authService := services.NewAuthService(os.Getenv("JWT_SECRET"))
-	connectionService := services.NewConnectionService()
-	connectionLogService := services.NewConnectionLogService()
-	userLogService := services.NewUserLogService()
-	roleLogService := services.NewRoleLogService()
-	queryService := services.NewQueryService(connectionService)
-	databaseService := services.NewDatabaseService(connectionService)
-	truETLService := services.NewTruETLService(connectionService)
-	truETLLogService := services.NewTruETLLogService()
-	hohAddressService := services.NewHohAddressService(connectionService)
-	hohAddressLogService := services.NewHohAddressLogService()
+	totpEncryptionKey := cfg.TOTPEncryptionKey
+	if totpEncryptionKey == "" {
+		totpEncryptionKey = cfg.JWTSecret
+	}
+	totpRequiredRoles := []models.UserRole{}
+	for _, role := range cfg.TOTPRequiredRoles {
+		if role != string(models.RoleAdmin) && role != string(models.RoleUser) {
+			log.Fatalf("Invalid TOTP_REQUIRED_ROLES: unknown role %q", role)
+		}
+		totpRequiredRoles = append(totpRequiredRoles, models.UserRole(role))
+	}
+	authService := services.NewAuthService(cfg.JWTSecret, sharedStore, services.LoginLockoutPolicy{
+		MaxAttempts:      cfg.LoginMaxAttempts,
+		MaxAttemptsPerIP: cfg.LoginMaxAttemptsPerIP,
+		LockoutDuration:  cfg.LoginLockoutDuration,
+		MaxLockout:       cfg.LoginMaxLockout,
+		Window:           cfg.LoginAttemptWindow,
+	}, services.TOTPPolicy{
+		Issuer:        cfg.TOTPIssuer,
+		RequiredRoles: totpRequiredRoles,
+		EncryptionKey: totpEncryptionKey,
+	}, logger.With("service", "auth"))
+	connectionPools := services.NewConnectionPoolManager(services.PoolConfig{
+		MaxOpenConns:    cfg.PoolMaxOpenConns,
+		MaxIdleConns:    cfg.PoolMaxIdleConns,
+		IdleTimeout:     cfg.PoolIdleTimeout,
+		ConnMaxLifetime: cfg.PoolConnMaxLifetime,
+	}, logger.With("service", "connection_pool"))
+	defer connectionPools.Close()
+	if err := services.SetIdentifierPolicy(services.IdentifierPolicy{
+		Quoting:         cfg.IdentifierQuoting,
+		Case:            cfg.IdentifierCase,
+		ValidateCatalog: cfg.IdentifierValidateCatalog,
+	}); err != nil {
+		log.Fatal("Invalid identifier policy:", err)
+	}
+	connectionService := services.NewConnectionService(connectionPools, logger.With("service", "connection"))
+	connectionLogService := services.NewConnectionLogService(auditService)
+	userLogService := services.NewUserLogService(auditService)
+	roleLogService := services.NewRoleLogService(auditService)
+	databaseService := services.NewDatabaseService(connectionService, services.QueryLimits{
+		MaxRows:       cfg.QueryMaxRows,
+		StreamMaxRows: cfg.QueryStreamMaxRows,
+		Timeout:       cfg.QueryTimeout,
+		MaxTimeout:    cfg.QueryMaxTimeout,
+	})
+	queryService := services.NewQueryService(connectionService, databaseService)
+	sqlHistoryService := services.NewSQLHistoryService(cfg.SQLHistoryLimit)
+	savedQueryService := services.NewSavedQueryService(databaseService)
+	truETLService := services.NewTruETLService(connectionService, logger.With("service", "truetl"))
+	truETLLogService := services.NewTruETLLogService(auditService)
+	tableRowLogService := services.NewTableRowLogService(auditService)
+	hohAddressService := services.NewHohAddressService(connectionService, tableRowLogService, logger.With("service", "hohaddress"))
+	hohAddressLogService := services.NewHohAddressLogService(auditService)
+	envSMTP := services.SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+		TLSMode:  cfg.SMTPTLSMode,
+	}
+	notificationService := services.NewNotificationService(cfg.NotifyWebhookURL, envSMTP, logger.With("service", "notification"))
+	accessGrantService := services.NewAccessGrantService(connectionService, notificationService, auditService, cfg.BreakGlassDuration, logger.With("service", "access_grant"))
+	partitionService := services.NewPartitionService(databaseService, notificationService, accessGrantService, logger.With("service", "partition"))
+	snapshotService := services.NewSnapshotService(databaseService, accessGrantService, cfg.SnapshotMaxBytes)
+	artifactSigningSecret := cfg.ArtifactSigningSecret
+	if artifactSigningSecret == "" {
+		artifactSigningSecret = cfg.JWTSecret
+	}
+	artifactStorage, err := services.NewArtifactStorage(services.ArtifactStorageConfig{
+		Backend:       cfg.ArtifactStorage,
+		LocalDir:      cfg.ArtifactLocalDir,
+		PublicURL:     cfg.ArtifactPublicURL,
+		SigningSecret: artifactSigningSecret,
+		S3Endpoint:    cfg.ArtifactS3Endpoint,
+		S3Region:      cfg.ArtifactS3Region,
+		S3Bucket:      cfg.ArtifactS3Bucket,
+		S3Prefix:      cfg.ArtifactS3Prefix,
+		S3AccessKey:   cfg.ArtifactS3AccessKey,
+		S3SecretKey:   cfg.ArtifactS3SecretKey,
+		S3PathStyle:   cfg.ArtifactS3PathStyle,
+	})
+	if err != nil {
+		log.Fatal("Invalid artifact storage configuration:", err)
+	}
+	artifactService := services.NewArtifactService(artifactStorage, cfg.ArtifactRetention, cfg.ArtifactURLTTL, logger.With("service", "artifact"))
+	// With several replicas only the leader runs the scheduler jobs
+	scheduler := services.NewSchedulerService(logger.With("service", "scheduler"))
+	clusterService := services.NewClusterService(cfg.ReplicaName, scheduler, cfg.LeaderElection, cfg.ReplicaHeartbeatInterval, cfg.ReplicaStaleAfter, logger.With("service", "cluster"))
+	defer clusterService.Stop()
+	scheduler.RequireLeader(clusterService.IsLeader)
+	exportService := services.NewExportService(databaseService, artifactService, clusterService.ReplicaID(), logger.With("service", "export"))
+	dataDictionaryService := services.NewDataDictionaryService(databaseService, artifactService, accessGrantService, logger.With("service", "data_dictionary"))
+	annotationService := services.NewAnnotationService()
+	terminationLogService := services.NewTerminationLogService(auditService, logger.With("service", "termination_log"))
+	monitoredDatabaseService := services.NewMonitoredDatabaseService(connectionService)
+	timeSeriesService := services.NewTimeSeriesService(connectionService, databaseService, monitoredDatabaseService, cfg.MetricsRetention, logger.With("service", "timeseries"))
+	deadlockHistoryService := services.NewDeadlockHistoryService(connectionService, databaseService, monitoredDatabaseService, cfg.DeadlockRetention, logger.With("service", "deadlock_history"))
+	settingsService := services.NewSettingsService(notificationService, envSMTP)
+	announcementService := services.NewAnnouncementService()
+	activityService := services.NewActivityService(cfg.UserActivityRetention, logger.With("service", "activity"))
+	defer activityService.Close()
+	usageService := services.NewUsageService(cfg.UsageFlushInterval, cfg.UsageRetention, logger.With("service", "usage"))
+	defer usageService.Close()
+	permissionService := services.NewPermissionService(sharedStore, logger.With("service", "permission"))
+	savedFilterService := services.NewSavedFilterService()
+	alertSilenceService := services.NewAlertSilenceService(connectionService, logger.With("service", "alert_silence"))
+	notificationService.SetSilencer(alertSilenceService)
+	bulkRunService := services.NewBulkRunService(databaseService, hohAddressService, services.BulkThrottle{
+		ChunkSize:          cfg.BulkChunkSize,
+		MaxActiveQueries:   cfg.BulkMaxActiveQueries,
+		MaxConnectionUsage: float64(cfg.BulkMaxConnectionPercent) / 100,
+		MaxWait:            cfg.BulkThrottleMaxWait,
+	}, logger.With("service", "bulk_run"))
+	customMonitoringService := services.NewCustomMonitoringService(databaseService, notificationService, logger.With("service", "custom_monitoring"))
+	sqlJobService := services.NewSQLJobService(databaseService, notificationService, clusterService.ReplicaID(), cfg.SQLJobWorkers, cfg.SQLJobTimeout, cfg.SQLJobRunRetention, logger.With("service", "sql_job"))
+	addressCheckUsageService := services.NewAddressCheckUsageService(cfg.AddressCheckDailyQuota, cfg.AddressCheckCallerQuotas, cfg.AddressCheckSLOLatency, cfg.AddressCheckSLOTarget, cfg.AddressCheckUsageRetention, logger.With("service", "address_check_usage"))
+	defer sqlJobService.Close()
+	widgetService := services.NewWidgetService(hohAddressService, sharedStore, cfg.JWTSecret, cfg.WidgetSessionTTL, cfg.WidgetRateLimit, cfg.WidgetIPRateLimit, logger.With("service", "widget"))
+	apiKeyService := services.NewAPIKeyService(sharedStore, cfg.APIKeyRateLimit, logger.With("service", "api_key"))
+	liveMonitorService := services.NewLiveMonitorService(databaseService, sharedStore, cfg.LiveMonitorInterval, cfg.LiveMonitorMinInterval, cfg.LiveMonitorMaxSubscribers, logger.With("service", "live_monitor"))
+	dashboardService := services.NewDashboardService(databaseService, snapshotService, annotationService, customMonitoringService, accessGrantService, partitionService, customMonitoringService)
+	digestService := services.NewDigestService(databaseService, notificationService, accessGrantService, logger.With("service", "digest"), partitionService, customMonitoringService)
+	capacityService := services.NewCapacityService(connectionService, databaseService, monitoredDatabaseService, accessGrantService)
+	overviewService := services.NewOverviewService(connectionService, databaseService, accessGrantService, cfg.OverviewCacheTTL, logger.With("service", "overview"), partitionService, customMonitoringService)
+	connectionHealthService := services.NewConnectionHealthService(connectionService, cfg.ConnectionHealthRetention)
+	auditBackfillService := services.NewAuditBackfillService(auditService)
+	frontend := webui.Resolve(cfg.FrontendBuildPath)
+	selfCheckService := services.NewSelfCheckService(cfg.JWTSecret, frontend, notificationService, auditService, artifactService, logger.With("service", "selfcheck"))
+
+	// Seed data for automated provisioning, applied before anything reads the settings
+	if database.IsConnected() && cfg.SeedFile != "" {
+		seed, err := services.LoadSeedFile(cfg.SeedFile)
+		if err != nil {
+			log.Fatal("Invalid seed file:", err)
+		}
+		if err := services.NewSeedService(authService, connectionService, settingsService).Apply(seed); err != nil {
+			log.Fatal("Failed to apply seed file:", err)
+		}
+	}
+
+	// SMTP settings saved by an admin override the environment
+	if database.IsConnected() {
+		if err := settingsService.ApplySMTPSettings(); err != nil {
+			log.Printf("WARNING: Failed to load SMTP settings: %v", err)
+		}
+		// Exports and SQL job runs cut off by a restart would otherwise stay running forever
+		clusterService.TrackInterrupted(exportService, sqlJobService)
+		if err := clusterService.RecoverInterrupted(); err != nil {
+			log.Printf("WARNING: %v", err)
+		}
+	}
+
+	// Startup self-check (also available to admins at /api/v1/system/selfcheck)
+	selfCheckService.LogReport(selfCheckService.Run())
+
+	// Background jobs (only when the internal database is available)
+	if database.IsConnected() {
+		clusterService.Start()
+		scheduler.Register("partition_maintenance", cfg.PartitionMaintenanceInterval, partitionService.RunDuePolicies)
+		scheduler.Register("activity_digest", cfg.DigestInterval, digestService.RunDueDigests)
+		scheduler.Register("capacity_sampling", cfg.CapacitySampleInterval, capacityService.RecordSamples)
+		scheduler.Register("connection_health", cfg.ConnectionHealthInterval, connectionHealthService.CheckAll)
+		scheduler.Register("metrics_sampling", cfg.MetricsSampleInterval, timeSeriesService.RecordSnapshots)
+		scheduler.Register("custom_monitoring", cfg.MetricsSampleInterval, customMonitoringService.RunAll)
+		scheduler.Register("metrics_downsampling", cfg.MetricsDownsampleInterval, timeSeriesService.Downsample)
+		scheduler.Register("deadlock_collection", cfg.DeadlockCollectInterval, deadlockHistoryService.CollectDeadlocks)
+		scheduler.Register("deadlock_pruning", 24*time.Hour, deadlockHistoryService.Prune)
+		scheduler.Register("activity_pruning", 24*time.Hour, activityService.Prune)
+		scheduler.Register("session_pruning", time.Hour, authService.PruneSessions)
+		scheduler.Register("usage_pruning", 24*time.Hour, usageService.Prune)
+		scheduler.Register("access_grant_reminders", time.Minute, accessGrantService.RunReminders)
+		scheduler.Register("artifact_cleanup", time.Hour, artifactService.PruneExpired)
+		scheduler.Register("data_dictionary_schedules", time.Hour, dataDictionaryService.RunDueSchedules)
+		scheduler.Register("sql_jobs", time.Minute, sqlJobService.RunDueJobs)
+		scheduler.Register("sql_job_run_pruning", 24*time.Hour, sqlJobService.PruneRuns)
+		scheduler.Register("address_check_usage_pruning", 24*time.Hour, addressCheckUsageService.Prune)
+		scheduler.Register("interrupted_work_recovery", time.Minute, clusterService.RecoverInterrupted)
+		scheduler.Register("cluster_replica_pruning", time.Hour, clusterService.PruneReplicas)
+		// Ships stored role and TruETL log entries to the audit sinks once; a no-op afterwards
+		scheduler.Register("audit_backfill", 10*time.Minute, auditBackfillService.Run)
+		scheduler.Start()
+		defer scheduler.Stop()
+	}
+
+	// gRPC API for internal services (optional, separate port)
+	if cfg.GRPCPort != "" {
+		callerAccess, err := grpcapi.ParseCallerAccess(cfg.GRPCCallerAccess)
+		if err != nil {
+			log.Fatal("Invalid gRPC configuration:", err)
+		}
+		grpcServer, err := grpcapi.NewServer(grpcapi.Config{
+			Port:         cfg.GRPCPort,
+			APIKeys:      cfg.GRPCAPIKeys,
+			CertFile:     cfg.GRPCCertFile,
+			KeyFile:      cfg.GRPCKeyFile,
+			ClientCAFile: cfg.GRPCClientCAFile,
+			CallerAccess: callerAccess,
+		}, grpcapi.Services{
+			Connections: connectionService,
+			Databases:   databaseService,
+			HohAddress:  hohAddressService,
+			Usage:       addressCheckUsageService,
+			History:     sqlHistoryService,
+			Audit:       auditService,
+		})
+		if err != nil {
+			log.Fatal("Invalid gRPC configuration:", err)
+		}
+		if err := grpcServer.Start(); err != nil {
+			log.Fatal("Failed to start gRPC server:", err)
+		}
+		defer grpcServer.Stop()
+	}
 
 	// Initialize handlers
-	healthHandler := handlers.NewHealthHandler()
-	authHandler := handlers.NewAuthHandler(authService, userLogService)
-	connHandler := handlers.NewConnectionHandler(connectionService, connectionLogService)
-	queryHandler := handlers.NewQueryHandler(queryService)
-	databaseHandler := handlers.NewDatabaseHandler(databaseService, roleLogService)
-	truETLHandler := handlers.NewTruETLHandler(truETLService, truETLLogService)
+	healthHandler := handlers.NewHealthHandler(clusterService)
+	authHandler := handlers.NewAuthHandler(authService, userLogService, activityService)
+	connHandler := handlers.NewConnectionHandler(connectionService, connectionLogService, connectionHealthService, accessGrantService)
+	queryHandler := handlers.NewQueryHandler(queryService, sqlHistoryService)
+	databaseHandler := handlers.NewDatabaseHandler(databaseService, roleLogService, terminationLogService, tableRowLogService, sqlHistoryService)
+	truETLHandler := handlers.NewTruETLHandler(truETLService, truETLLogService, terminationLogService)
 	hohAddressHandler := handlers.NewHohAddressHandler(hohAddressService, hohAddressLogService)
+	partitionHandler := handlers.NewPartitionHandler(partitionService)
+	snapshotHandler := handlers.NewSnapshotHandler(snapshotService)
+	dashboardHandler := handlers.NewDashboardHandler(dashboardService)
+	monitoringHandler := handlers.NewMonitoringHandler(databaseService, annotationService, terminationLogService, timeSeriesService, savedFilterService, alertSilenceService, deadlockHistoryService, customMonitoringService, monitoredDatabaseService)
+	digestHandler := handlers.NewDigestHandler(digestService)
+	capacityHandler := handlers.NewCapacityHandler(capacityService, overviewService)
+	systemHandler := handlers.NewSystemHandler(selfCheckService, connectionService, clusterService, auditBackfillService)
+	settingsHandler := handlers.NewSettingsHandler(settingsService)
+	announcementHandler := handlers.NewAnnouncementHandler(announcementService)
+	accessGrantHandler := handlers.NewAccessGrantHandler(accessGrantService)
+	bulkHandler := handlers.NewBulkHandler(bulkRunService)
+	artifactHandler := handlers.NewArtifactHandler(artifactService)
+	exportHandler := handlers.NewExportHandler(exportService)
+	usageHandler := handlers.NewUsageHandler(usageService)
+	permissionHandler := handlers.NewPermissionHandler(permissionService, authService)
+	dataDictionaryHandler := handlers.NewDataDictionaryHandler(dataDictionaryService)
+	liveMonitorHandler := handlers.NewLiveMonitorHandler(liveMonitorService, authService, apiKeyService, accessGrantService)
+	sqlJobHandler := handlers.NewSQLJobHandler(sqlJobService)
+	widgetHandler := handlers.NewWidgetHandler(widgetService)
+	sqlHistoryHandler := handlers.NewSQLHistoryHandler(sqlHistoryService, databaseService)
+	savedQueryHandler := handlers.NewSavedQueryHandler(savedQueryService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	var graphqlHandler *handlers.GraphQLHandler
+	if cfg.GraphQLEnabled {
+		schema, err := graphqlapi.NewSchema(graphqlapi.Services{
+			Connections:    connectionService,
+			Databases:      databaseService,
+			ConnectionLogs: connectionLogService,
+			RoleLogs:       roleLogService,
+			Permissions:    permissionService,
+			AccessGrants:   accessGrantService,
+		})
+		if err != nil {
+			log.Fatal("Failed to initialize GraphQL:", err)
+		}
+		graphqlHandler = handlers.NewGraphQLHandler(schema)
+	}
 
 	// Initialize router
-	r := router.NewRouter(healthHandler, authHandler, connHandler, queryHandler, databaseHandler, truETLHandler, hohAddressHandler)
-	r.SetupRoutes(authService)
+	r := router.NewRouter(healthHandler, authHandler, connHandler, queryHandler, databaseHandler, truETLHandler, hohAddressHandler, partitionHandler, snapshotHandler, dashboardHandler, monitoringHandler, digestHandler, capacityHandler, systemHandler, settingsHandler, announcementHandler, accessGrantHandler, bulkHandler, artifactHandler, exportHandler, usageHandler, permissionHandler, dataDictionaryHandler, liveMonitorHandler, sqlJobHandler, widgetHandler, sqlHistoryHandler, savedQueryHandler, apiKeyHandler, graphqlHandler)
+	if err := r.GetEngine().SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid trusted proxies:", err)
+	}
+	if cfg.CompressionEnabled {
+		// Registered before the routes so it applies to all of them
+		r.GetEngine().Use(middleware.Compression(middleware.CompressionConfig{
+			MinSize: cfg.CompressionMinBytes,
+			Level:   cfg.CompressionLevel,
+		}))
+	}
+	rateLimits := middleware.RateLimits{
+		LoginPerIP:          services.NewRateLimiter(cfg.RateLimitLoginPerIP),
+		QueryPerUser:        services.NewRateLimiter(cfg.RateLimitQueryPerUser),
+		CheckAddressPerUser: services.NewRateLimiter(cfg.RateLimitCheckAddressPerUser),
+		PerIP:               services.NewRateLimiter(cfg.RateLimitPerIP),
+	}
+	r.SetupRoutes(authService, apiKeyService, activityService, accessGrantService, usageService, permissionService, rateLimits, cfg.CORSAllowedOrigins, frontend, logger)
 
 	// Get port from environment or use default
 	port := cfg.ServerPort
@@ -73,10 +384,46 @@ func main() {
 		port = "8080"
 	}
 
+	// Requests run under requestCtx, which is cancelled once the shutdown timeout has passed
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	server := &http.Server{
+		Addr:        ":" + port,
+		Handler:     r.GetEngine(),
+		BaseContext: func(net.Listener) context.Context { return requestCtx },
+	}
+
 	// Start server
-	log.Printf("Server starting on port %s...", port)
-	if err := r.GetEngine().Run(":" + port); err != nil {
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on port %s...", port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	signals, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	select {
+	case err := <-serverErr:
 		log.Fatal("Failed to start server:", err)
-		os.Exit(1)
+	case <-signals.Done():
 	}
+	// A second signal kills the process right away
+	stopSignals()
+
+	// Stop accepting connections and let in-flight requests finish; queries still running after the
+	// timeout are cancelled with their request. WebSocket streams are not waited for.
+	log.Printf("Shutting down, waiting up to %s for in-flight requests...", cfg.ShutdownTimeout)
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("WARNING: Cancelling requests still running after %s: %v", cfg.ShutdownTimeout, err)
+	}
+	cancelRequests()
+	if err := server.Close(); err != nil {
+		log.Printf("WARNING: Failed to close server: %v", err)
+	}
+
+	// The deferred calls stop the gRPC server and the scheduler once their running calls and jobs
+	// finish, flush the activity and usage queues, then close the connection pools and the database
+	log.Println("Stopping background jobs and closing database connections...")
 }
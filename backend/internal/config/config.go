@@ -1,13 +1,20 @@
 package config
 
 import (
+	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds application configuration
 type Config struct {
+	ConfigFile string // YAML config file read at startup; empty when there was none
+
 	ServerPort string
 	GinMode    string
 	DBHost     string
@@ -15,14 +22,202 @@ type Config struct {
 	DBUsername string
 	DBPassword string
 	DBName     string
+	JWTSecret  string
+
+	// Structured logging
+	LogFormat string // text or json
+	LogLevel  string // debug, info, warn or error
+
+	// Frontend build directory; overrides the embedded build when set
+	FrontendBuildPath string
+
+	// Origins allowed to call the API from a browser; "*" allows any
+	CORSAllowedOrigins []string
+
+	// IPs or CIDRs of the reverse proxies whose X-Forwarded-For header gives the client IP; with none,
+	// the client IP is the peer address, so clients cannot pick the IP rate limits and lockouts count
+	TrustedProxies []string
+
+	// How long in-flight requests may run after SIGINT or SIGTERM before they are cancelled
+	ShutdownTimeout time.Duration
+
+	// YAML file with users, connections and settings created at startup when missing; none when empty
+	SeedFile string
+
+	// Background jobs and alerting
+	NotifyWebhookURL             string
+	PartitionMaintenanceInterval time.Duration
+	DigestInterval               time.Duration
+	CapacitySampleInterval       time.Duration
+	OverviewCacheTTL             time.Duration // How long the overview across all connections is reused; zero collects it on every request
+
+	// Monitoring time-series storage
+	MetricsSampleInterval     time.Duration
+	MetricsDownsampleInterval time.Duration
+	MetricsRetention          time.Duration // How long downsampled daily points are kept
+
+	// Deadlock reports collected from the server logs of PostgreSQL connections
+	DeadlockCollectInterval time.Duration
+	DeadlockRetention       time.Duration
+
+	// Background pings of saved connections
+	ConnectionHealthInterval  time.Duration
+	ConnectionHealthRetention time.Duration
+
+	// Scheduled SQL jobs
+	SQLJobWorkers      int
+	SQLJobTimeout      time.Duration // For jobs without a timeout of their own; zero means none
+	SQLJobRunRetention time.Duration
+
+	// Live monitoring pushed over WebSocket
+	LiveMonitorInterval       time.Duration // Default push interval; clients may ask for a slower or faster one
+	LiveMonitorMinInterval    time.Duration
+	LiveMonitorMaxSubscribers int // Per connection; zero means unlimited
+
+	// Per-user sign-in and API call history
+	UserActivityRetention time.Duration
+
+	// Per-module API usage counters
+	UsageFlushInterval time.Duration
+	UsageRetention     time.Duration
+
+	// Self-granted emergency access to connections; zero disables it
+	BreakGlassDuration time.Duration
+
+	// Load throttle for bulk grants, address checks and multi-database script runs
+	BulkChunkSize            int
+	BulkMaxConnectionPercent int // Pause while more of max_connections is in use; zero disables the check
+	BulkMaxActiveQueries     int // Pause while more queries are active; zero disables the check
+	BulkThrottleMaxWait      time.Duration
+
+	// Pools shared per connection and database by all services
+	PoolMaxOpenConns    int // Per database; zero means unlimited
+	PoolMaxIdleConns    int
+	PoolIdleTimeout     time.Duration // Closes idle connections, and pools unused for this long
+	PoolConnMaxLifetime time.Duration
+
+	// SMTP for email notifications and digests
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	SMTPTLSMode  string
+
+	// Query result snapshots
+	SnapshotMaxBytes int
+
+	// Row caps of user query results; zero means unlimited
+	QueryMaxRows       int
+	QueryStreamMaxRows int
+
+	// Timeouts of user queries; zero means none
+	QueryTimeout    time.Duration // Default of buffered queries
+	QueryMaxTimeout time.Duration // Longest timeout_ms a request may ask for
+
+	// Personal SQL history: unstarred entries kept per user; zero keeps every entry
+	SQLHistoryLimit int
+
+	// Schema, table and column names in generated SQL
+	IdentifierQuoting         string // always or needed
+	IdentifierCase            string // preserve or insensitive
+	IdentifierValidateCatalog bool   // Check names against the catalog and suggest close matches
+
+	// Storage for backups, exports and snapshots
+	ArtifactStorage       string // local or s3
+	ArtifactLocalDir      string
+	ArtifactPublicURL     string // Prefix of local download links; relative links when empty
+	ArtifactSigningSecret string // Signs local download links; defaults to the JWT secret
+	ArtifactS3Endpoint    string
+	ArtifactS3Region      string
+	ArtifactS3Bucket      string
+	ArtifactS3Prefix      string
+	ArtifactS3AccessKey   string
+	ArtifactS3SecretKey   string
+	ArtifactS3PathStyle   bool
+	ArtifactRetention     time.Duration // Zero keeps artifacts until they are deleted
+	ArtifactURLTTL        time.Duration
+
+	// Audit event shipping
+	AuditWebhookURL     string
+	AuditSyslogAddress  string
+	AuditSyslogFacility string
+	AuditSyslogAppName  string
+	AuditFilePath       string
+
+	// Response compression
+	CompressionEnabled  bool
+	CompressionMinBytes int
+	CompressionLevel    int
+
+	// Read-only GraphQL gateway at /api/graphql
+	GraphQLEnabled bool
+
+	// gRPC API for internal services (disabled when GRPCPort is empty)
+	GRPCPort         string
+	GRPCAPIKeys      []string
+	GRPCCertFile     string
+	GRPCKeyFile      string
+	GRPCClientCAFile string
+	GRPCCallerAccess map[string]string // "<sandbox|full>:<connection IDs separated by |, or *>" by caller
+
+	// Quotas and response-time SLO of the gRPC address check
+	AddressCheckDailyQuota     int            // Calls per caller and UTC day; zero is unlimited
+	AddressCheckCallerQuotas   map[string]int // Overrides by caller, e.g. "key:<fingerprint>"
+	AddressCheckSLOLatency     time.Duration
+	AddressCheckSLOTarget      float64 // Share of calls that must finish within AddressCheckSLOLatency
+	AddressCheckUsageRetention time.Duration
+
+	// Embeddable address check widget
+	WidgetSessionTTL  time.Duration
+	WidgetRateLimit   int // Checks per minute per widget token without its own limit; zero is unlimited
+	WidgetIPRateLimit int // Widget page loads and checks per minute per visitor IP; zero is unlimited
+
+	// API keys for machine-to-machine access
+	APIKeyRateLimit int // Requests per minute per key without a limit of its own; zero is unlimited
+
+	// Lockout of usernames and client IPs after failed logins
+	LoginMaxAttempts      int // Per username; zero disables the lockout
+	LoginMaxAttemptsPerIP int // Per client IP, across usernames; zero disables the lockout
+	LoginLockoutDuration  time.Duration
+	LoginMaxLockout       time.Duration
+	LoginAttemptWindow    time.Duration
+
+	// Two-factor authentication with authenticator apps (TOTP)
+	TOTPIssuer        string
+	TOTPRequiredRoles []string // Roles whose users must set it up before using anything else
+	TOTPEncryptionKey string   // Encrypts the secrets stored on users; defaults to the JWT secret
+
+	// Token bucket rate limits in requests per minute, per replica; zero is unlimited
+	RateLimitLoginPerIP          int
+	RateLimitQueryPerUser        int
+	RateLimitCheckAddressPerUser int
+	RateLimitPerIP               int // Query executions and address checks of all users behind one IP
+
+	// Replicas sharing the internal database
+	ReplicaName              string // Prefix of the replica ID; the hostname when empty
+	LeaderElection           bool   // Run scheduler jobs only on the replica holding the leader lock
+	ReplicaHeartbeatInterval time.Duration
+	ReplicaStaleAfter        time.Duration // Replicas without a heartbeat for this long are considered gone
+
+	// Shared store for rate limits, revoked tokens and pub/sub between replicas; in-memory when empty
+	RedisURL string
 }
 
-// Load loads configuration from environment variables
+// Load loads configuration from environment variables, then the .env file, then the YAML config
+// file; each fills in only what the ones before left unset
 func Load() (*Config, error) {
 	// Load .env file if it exists
 	_ = godotenv.Load()
 
-	return &Config{
+	configFile, err := loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		ConfigFile: configFile,
+
 		ServerPort: getEnv("SERVER_PORT", "80"),
 		GinMode:    getEnv("GIN_MODE", "release"),
 		DBHost:     getEnv("DB_HOST", "localhost"),
@@ -30,7 +225,199 @@ func Load() (*Config, error) {
 		DBUsername: getEnv("DB_USERNAME", "postgres"),
 		DBPassword: getEnv("DB_PASSWORD", ""),
 		DBName:     getEnv("DB_NAME", "truadmin"),
-	}, nil
+		JWTSecret:  getEnv("JWT_SECRET", ""),
+
+		LogFormat: getEnv("LOG_FORMAT", "text"),
+		LogLevel:  getEnv("LOG_LEVEL", "info"),
+
+		FrontendBuildPath: getEnv("FRONTEND_BUILD_PATH", ""),
+
+		CORSAllowedOrigins: getListEnv("CORS_ALLOWED_ORIGINS"),
+		TrustedProxies:     getListEnv("TRUSTED_PROXIES"),
+
+		ShutdownTimeout: getDurationEnv("SHUTDOWN_TIMEOUT", 30*time.Second),
+
+		SeedFile: getEnv("SEED_FILE", ""),
+
+		NotifyWebhookURL:             getEnv("NOTIFY_WEBHOOK_URL", ""),
+		PartitionMaintenanceInterval: getDurationEnv("PARTITION_MAINTENANCE_INTERVAL", time.Hour),
+		DigestInterval:               getDurationEnv("DIGEST_INTERVAL", time.Hour),
+		CapacitySampleInterval:       getDurationEnv("CAPACITY_SAMPLE_INTERVAL", 6*time.Hour),
+		OverviewCacheTTL:             getDurationEnv("OVERVIEW_CACHE_TTL", 30*time.Second),
+
+		MetricsSampleInterval:     getDurationEnv("METRICS_SAMPLE_INTERVAL", 5*time.Minute),
+		MetricsDownsampleInterval: getDurationEnv("METRICS_DOWNSAMPLE_INTERVAL", time.Hour),
+		MetricsRetention:          getDurationEnv("METRICS_RETENTION", 365*24*time.Hour),
+
+		DeadlockCollectInterval: getDurationEnv("DEADLOCK_COLLECT_INTERVAL", 5*time.Minute),
+		DeadlockRetention:       getDurationEnv("DEADLOCK_RETENTION", 90*24*time.Hour),
+
+		ConnectionHealthInterval:  getDurationEnv("CONNECTION_HEALTH_INTERVAL", time.Minute),
+		ConnectionHealthRetention: getDurationEnv("CONNECTION_HEALTH_RETENTION", 7*24*time.Hour),
+
+		SQLJobWorkers:      getIntEnv("SQL_JOB_WORKERS", 4),
+		SQLJobTimeout:      getDurationEnv("SQL_JOB_TIMEOUT", time.Hour),
+		SQLJobRunRetention: getDurationEnv("SQL_JOB_RUN_RETENTION", 90*24*time.Hour),
+
+		LiveMonitorInterval:       getDurationEnv("LIVE_MONITOR_INTERVAL", 5*time.Second),
+		LiveMonitorMinInterval:    getDurationEnv("LIVE_MONITOR_MIN_INTERVAL", time.Second),
+		LiveMonitorMaxSubscribers: getIntEnv("LIVE_MONITOR_MAX_SUBSCRIBERS", 20),
+
+		UserActivityRetention: getDurationEnv("USER_ACTIVITY_RETENTION", 90*24*time.Hour),
+
+		UsageFlushInterval: getDurationEnv("USAGE_FLUSH_INTERVAL", time.Minute),
+		UsageRetention:     getDurationEnv("USAGE_RETENTION", 400*24*time.Hour),
+
+		BreakGlassDuration: getDurationEnv("BREAK_GLASS_DURATION", time.Hour),
+
+		BulkChunkSize:            getIntEnv("BULK_CHUNK_SIZE", 10),
+		BulkMaxConnectionPercent: getIntEnv("BULK_MAX_CONNECTION_PERCENT", 80),
+		BulkMaxActiveQueries:     getIntEnv("BULK_MAX_ACTIVE_QUERIES", 0),
+		BulkThrottleMaxWait:      getDurationEnv("BULK_THROTTLE_MAX_WAIT", 10*time.Minute),
+
+		PoolMaxOpenConns:    getIntEnv("DB_POOL_MAX_OPEN_CONNS", 10),
+		PoolMaxIdleConns:    getIntEnv("DB_POOL_MAX_IDLE_CONNS", 2),
+		PoolIdleTimeout:     getDurationEnv("DB_POOL_IDLE_TIMEOUT", 10*time.Minute),
+		PoolConnMaxLifetime: getDurationEnv("DB_POOL_CONN_MAX_LIFETIME", 30*time.Minute),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", ""),
+		SMTPTLSMode:  getEnv("SMTP_TLS_MODE", "starttls"),
+
+		SnapshotMaxBytes: getIntEnv("SNAPSHOT_MAX_BYTES", 5*1024*1024),
+
+		QueryMaxRows:       getIntEnv("QUERY_MAX_ROWS", 10000),
+		QueryStreamMaxRows: getIntEnv("QUERY_STREAM_MAX_ROWS", 1000000),
+
+		QueryTimeout:    getDurationEnv("QUERY_TIMEOUT", 5*time.Minute),
+		QueryMaxTimeout: getDurationEnv("QUERY_MAX_TIMEOUT", time.Hour),
+
+		SQLHistoryLimit: getIntEnv("SQL_HISTORY_LIMIT", 500),
+
+		IdentifierQuoting:         getEnv("IDENTIFIER_QUOTING", "always"),
+		IdentifierCase:            getEnv("IDENTIFIER_CASE", "preserve"),
+		IdentifierValidateCatalog: getBoolEnv("IDENTIFIER_VALIDATE_CATALOG", true),
+
+		ArtifactStorage:       getEnv("ARTIFACT_STORAGE", "local"),
+		ArtifactLocalDir:      getEnv("ARTIFACT_LOCAL_DIR", "./data/artifacts"),
+		ArtifactPublicURL:     getEnv("ARTIFACT_PUBLIC_URL", ""),
+		ArtifactSigningSecret: getEnv("ARTIFACT_SIGNING_SECRET", ""),
+		ArtifactS3Endpoint:    getEnv("ARTIFACT_S3_ENDPOINT", "https://s3.amazonaws.com"),
+		ArtifactS3Region:      getEnv("ARTIFACT_S3_REGION", "us-east-1"),
+		ArtifactS3Bucket:      getEnv("ARTIFACT_S3_BUCKET", ""),
+		ArtifactS3Prefix:      getEnv("ARTIFACT_S3_PREFIX", ""),
+		ArtifactS3AccessKey:   getEnv("ARTIFACT_S3_ACCESS_KEY", ""),
+		ArtifactS3SecretKey:   getEnv("ARTIFACT_S3_SECRET_KEY", ""),
+		ArtifactS3PathStyle:   getBoolEnv("ARTIFACT_S3_PATH_STYLE", false),
+		ArtifactRetention:     getDurationEnv("ARTIFACT_RETENTION", 30*24*time.Hour),
+		ArtifactURLTTL:        getDurationEnv("ARTIFACT_URL_TTL", 15*time.Minute),
+
+		AuditWebhookURL:     getEnv("AUDIT_WEBHOOK_URL", ""),
+		AuditSyslogAddress:  getEnv("AUDIT_SYSLOG_ADDRESS", ""),
+		AuditSyslogFacility: getEnv("AUDIT_SYSLOG_FACILITY", "local0"),
+		AuditSyslogAppName:  getEnv("AUDIT_SYSLOG_APP_NAME", "truadmin"),
+		AuditFilePath:       getEnv("AUDIT_FILE_PATH", ""),
+
+		CompressionEnabled:  getBoolEnv("COMPRESSION_ENABLED", true),
+		CompressionMinBytes: getIntEnv("COMPRESSION_MIN_BYTES", 1024),
+		CompressionLevel:    getIntEnv("COMPRESSION_LEVEL", -1),
+
+		GraphQLEnabled: getBoolEnv("GRAPHQL_ENABLED", false),
+
+		GRPCPort:         getEnv("GRPC_PORT", ""),
+		GRPCAPIKeys:      getListEnv("GRPC_API_KEYS"),
+		GRPCCertFile:     getEnv("GRPC_TLS_CERT_FILE", ""),
+		GRPCKeyFile:      getEnv("GRPC_TLS_KEY_FILE", ""),
+		GRPCClientCAFile: getEnv("GRPC_TLS_CLIENT_CA_FILE", ""),
+		GRPCCallerAccess: getStringMapEnv("GRPC_CALLER_ACCESS"),
+
+		AddressCheckDailyQuota:     getIntEnv("ADDRESS_CHECK_DAILY_QUOTA", 0),
+		AddressCheckCallerQuotas:   getIntMapEnv("ADDRESS_CHECK_CALLER_QUOTAS"),
+		AddressCheckSLOLatency:     getDurationEnv("ADDRESS_CHECK_SLO_LATENCY", 500*time.Millisecond),
+		AddressCheckSLOTarget:      getFloatEnv("ADDRESS_CHECK_SLO_TARGET", 0.99),
+		AddressCheckUsageRetention: getDurationEnv("ADDRESS_CHECK_USAGE_RETENTION", 90*24*time.Hour),
+
+		WidgetSessionTTL:  getDurationEnv("WIDGET_SESSION_TTL", 30*time.Minute),
+		WidgetRateLimit:   getIntEnv("WIDGET_RATE_LIMIT", 60),
+		WidgetIPRateLimit: getIntEnv("WIDGET_IP_RATE_LIMIT", 20),
+
+		APIKeyRateLimit: getIntEnv("API_KEY_RATE_LIMIT", 120),
+
+		LoginMaxAttempts:      getIntEnv("LOGIN_MAX_ATTEMPTS", 5),
+		LoginMaxAttemptsPerIP: getIntEnv("LOGIN_MAX_ATTEMPTS_PER_IP", 20),
+		LoginLockoutDuration:  getDurationEnv("LOGIN_LOCKOUT_DURATION", time.Minute),
+		LoginMaxLockout:       getDurationEnv("LOGIN_MAX_LOCKOUT", time.Hour),
+		LoginAttemptWindow:    getDurationEnv("LOGIN_ATTEMPT_WINDOW", time.Hour),
+
+		TOTPIssuer:        getEnv("TOTP_ISSUER", "TruAdmin"),
+		TOTPRequiredRoles: getListEnv("TOTP_REQUIRED_ROLES"),
+		TOTPEncryptionKey: getEnv("TOTP_ENCRYPTION_KEY", ""),
+
+		RateLimitLoginPerIP:          getIntEnv("RATE_LIMIT_LOGIN_PER_IP", 10),
+		RateLimitQueryPerUser:        getIntEnv("RATE_LIMIT_QUERY_PER_USER", 60),
+		RateLimitCheckAddressPerUser: getIntEnv("RATE_LIMIT_CHECK_ADDRESS_PER_USER", 300),
+		RateLimitPerIP:               getIntEnv("RATE_LIMIT_PER_IP", 600),
+
+		ReplicaName:              getEnv("REPLICA_NAME", ""),
+		LeaderElection:           getBoolEnv("LEADER_ELECTION_ENABLED", true),
+		ReplicaHeartbeatInterval: getDurationEnv("REPLICA_HEARTBEAT_INTERVAL", 15*time.Second),
+		ReplicaStaleAfter:        getDurationEnv("REPLICA_STALE_AFTER", time.Minute),
+
+		RedisURL: getEnv("REDIS_URL", ""),
+	}
+	if len(cfg.CORSAllowedOrigins) == 0 {
+		cfg.CORSAllowedOrigins = []string{"*"}
+	}
+
+	if err := cfg.validate(); err != nil {
+		if cfg.ConfigFile != "" {
+			return nil, fmt.Errorf("%w (environment variables override %s)", err, cfg.ConfigFile)
+		}
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validate rejects settings the server cannot start with
+func (c *Config) validate() error {
+	for key, port := range map[string]string{"SERVER_PORT": c.ServerPort, "DB_PORT": c.DBPort} {
+		if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+			return fmt.Errorf("invalid %s %q: must be a port number between 1 and 65535", key, port)
+		}
+	}
+	if !oneOf(c.GinMode, "debug", "release", "test") {
+		return fmt.Errorf("invalid GIN_MODE %q: must be debug, release or test", c.GinMode)
+	}
+	if !oneOf(strings.ToLower(c.LogFormat), "text", "json") {
+		return fmt.Errorf("invalid LOG_FORMAT %q: must be text or json", c.LogFormat)
+	}
+	if !oneOf(strings.ToLower(strings.TrimSpace(c.LogLevel)), "debug", "info", "warn", "warning", "error") {
+		return fmt.Errorf("invalid LOG_LEVEL %q: must be debug, info, warn or error", c.LogLevel)
+	}
+	for _, origin := range c.CORSAllowedOrigins {
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("invalid CORS_ALLOWED_ORIGINS entry %q: must be \"*\" or start with http:// or https://", origin)
+		}
+	}
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("invalid TRUSTED_PROXIES entry %q: must be an IP address or CIDR", proxy)
+		}
+	}
+	return nil
+}
+
+// oneOf reports whether value is one of the allowed values
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
 }
 
 // getEnv retrieves an environment variable or returns a default value
@@ -40,3 +427,85 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getDurationEnv retrieves a duration environment variable (e.g. "30m") or returns a default value
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// getIntEnv retrieves an integer environment variable or returns a default value
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+// getFloatEnv retrieves a float environment variable or returns a default value
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+// getBoolEnv retrieves a boolean environment variable (true/false, 1/0) or returns a default value
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// getListEnv retrieves a comma-separated environment variable as a list, skipping empty items
+func getListEnv(key string) []string {
+	items := []string{}
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getStringMapEnv retrieves a semicolon-separated list of name=value pairs, skipping invalid items.
+// Semicolons separate the items because names such as certificate subjects contain commas, and
+// names may contain "=" themselves; the value follows the last one.
+func getStringMapEnv(key string) map[string]string {
+	items := map[string]string{}
+	for _, item := range strings.Split(os.Getenv(key), ";") {
+		idx := strings.LastIndex(item, "=")
+		if idx <= 0 {
+			continue
+		}
+		items[strings.TrimSpace(item[:idx])] = strings.TrimSpace(item[idx+1:])
+	}
+	return items
+}
+
+// getIntMapEnv retrieves a comma-separated list of name=integer pairs, skipping invalid items.
+// Names may contain "=" themselves; the value follows the last one.
+func getIntMapEnv(key string) map[string]int {
+	items := map[string]int{}
+	for _, item := range getListEnv(key) {
+		idx := strings.LastIndex(item, "=")
+		if idx <= 0 {
+			continue
+		}
+		if i, err := strconv.Atoi(strings.TrimSpace(item[idx+1:])); err == nil {
+			items[strings.TrimSpace(item[:idx])] = i
+		}
+	}
+	return items
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is read when CONFIG_FILE is not set; it is optional
+const defaultConfigFile = "truadmin.yaml"
+
+// configFile is the layout of the YAML config file. Each setting has an environment variable, which
+// wins over the file; env holds any other variable by name.
+type configFile struct {
+	Server   serverSettings    `yaml:"server"`
+	Logging  loggingSettings   `yaml:"logging"`
+	Database databaseSettings  `yaml:"database"`
+	JWT      jwtSettings       `yaml:"jwt"`
+	Features featureSettings   `yaml:"features"`
+	Env      map[string]string `yaml:"env"`
+}
+
+type serverSettings struct {
+	Port               *int     `yaml:"port"`
+	GinMode            string   `yaml:"gin_mode"`
+	FrontendBuildPath  string   `yaml:"frontend_build_path"`
+	CORSAllowedOrigins []string `yaml:"cors_allowed_origins"`
+	TrustedProxies     []string `yaml:"trusted_proxies"`
+}
+
+type loggingSettings struct {
+	Format string `yaml:"format"`
+	Level  string `yaml:"level"`
+}
+
+type databaseSettings struct {
+	Host     string `yaml:"host"`
+	Port     *int   `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+}
+
+type jwtSettings struct {
+	Secret string `yaml:"secret"`
+}
+
+type featureSettings struct {
+	GraphQL        *bool `yaml:"graphql"`
+	Compression    *bool `yaml:"compression"`
+	LeaderElection *bool `yaml:"leader_election"`
+}
+
+// variables returns the environment variables set by the file
+func (f *configFile) variables() map[string]string {
+	vars := map[string]string{}
+	setString := func(key, value string) {
+		if value != "" {
+			vars[key] = value
+		}
+	}
+	setInt := func(key string, value *int) {
+		if value != nil {
+			vars[key] = strconv.Itoa(*value)
+		}
+	}
+	setBool := func(key string, value *bool) {
+		if value != nil {
+			vars[key] = strconv.FormatBool(*value)
+		}
+	}
+
+	for key, value := range f.Env {
+		setString(key, value)
+	}
+
+	setInt("SERVER_PORT", f.Server.Port)
+	setString("GIN_MODE", f.Server.GinMode)
+	setString("FRONTEND_BUILD_PATH", f.Server.FrontendBuildPath)
+	setString("CORS_ALLOWED_ORIGINS", strings.Join(f.Server.CORSAllowedOrigins, ","))
+	setString("TRUSTED_PROXIES", strings.Join(f.Server.TrustedProxies, ","))
+	setString("LOG_FORMAT", f.Logging.Format)
+	setString("LOG_LEVEL", f.Logging.Level)
+	setString("DB_HOST", f.Database.Host)
+	setInt("DB_PORT", f.Database.Port)
+	setString("DB_USERNAME", f.Database.Username)
+	setString("DB_PASSWORD", f.Database.Password)
+	setString("DB_NAME", f.Database.Name)
+	setString("JWT_SECRET", f.JWT.Secret)
+	setBool("GRAPHQL_ENABLED", f.Features.GraphQL)
+	setBool("COMPRESSION_ENABLED", f.Features.Compression)
+	setBool("LEADER_ELECTION_ENABLED", f.Features.LeaderElection)
+	return vars
+}
+
+// loadConfigFile reads the YAML config file named by CONFIG_FILE, or truadmin.yaml when it exists, and
+// sets the variables it holds unless they are set already, the way the .env file is loaded.
+// ${VAR} references are replaced with environment variables, so secrets can stay out of the file.
+// It returns the path of the file read; empty when there is none.
+func loadConfigFile() (string, error) {
+	path := os.Getenv("CONFIG_FILE")
+	required := path != ""
+	if !required {
+		path = defaultConfigFile
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !required && errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file configFile
+	decoder := yaml.NewDecoder(strings.NewReader(os.ExpandEnv(string(data))))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	for key, value := range file.variables() {
+		if key == "" || strings.ContainsAny(key, "= ") {
+			return "", fmt.Errorf("invalid config file %s: %q is not an environment variable name", path, key)
+		}
+		if os.Getenv(key) != "" {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return "", fmt.Errorf("failed to apply config file %s: %w", path, err)
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Probing of the internal database while the server runs
+const (
+	availabilityCheckInterval = 5 * time.Second // Requests within this long of a probe reuse its result
+	availabilityCheckTimeout  = 2 * time.Second
+)
+
+var availability struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// Available reports whether the internal database is connected and answered its last probe. The
+// database is probed at most every availabilityCheckInterval, so it also notices outages after
+// startup and recovers on its own once the connection pool reconnects.
+func Available() bool {
+	return AvailabilityError() == nil
+}
+
+// AvailabilityError returns why the internal database is unavailable, or nil when it is available
+func AvailabilityError() error {
+	if !IsConnected() {
+		if DBError != nil {
+			return DBError
+		}
+		return fmt.Errorf("database is not connected")
+	}
+
+	availability.mu.Lock()
+	defer availability.mu.Unlock()
+
+	if time.Since(availability.checkedAt) < availabilityCheckInterval {
+		return availability.err
+	}
+
+	sqlDB, err := DB.DB()
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), availabilityCheckTimeout)
+		err = sqlDB.PingContext(ctx)
+		cancel()
+	}
+	if err != nil {
+		err = fmt.Errorf("database is unreachable: %w", err)
+	}
+
+	switch {
+	case err != nil && availability.err == nil:
+		slog.Warn("internal database unavailable", "error", err)
+	case err == nil && availability.err != nil:
+		slog.Info("internal database is available again")
+	}
+	availability.checkedAt = time.Now()
+	availability.err = err
+	return err
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -95,35 +96,124 @@ func InitDatabase(cfg DatabaseConfig) error {
 	return nil
 }
 
+// migrationLockKey is the PostgreSQL advisory lock held while migrating ("truadmig" in ASCII)
+const migrationLockKey int64 = 0x74727561646d6967
+
 // runMigrations runs automatic migrations for all models
 func runMigrations() error {
 	log.Println("Running database migrations...")
 
+	// Replicas starting together would otherwise race to create the same tables and columns
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+	ctx := context.Background()
+	lockConn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open migration lock session: %w", err)
+	}
+	defer lockConn.Close()
+	if _, err := lockConn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer lockConn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockKey)
+
+	for _, model := range migrationModels() {
+		if err := DB.AutoMigrate(model); err != nil {
+			return fmt.Errorf("failed to migrate model %T: %w", model, err)
+		}
+	}
+
+	if err := migrateTimeSeries(); err != nil {
+		return err
+	}
+
+	log.Println("Database migrations completed successfully")
+	return nil
+}
+
+// migrationModels lists all models that are migrated automatically
+func migrationModels() []interface{} {
 	// Add all models that need to be migrated here
-	models := []interface{}{
+	return []interface{}{
 		&models.Connection{},
 		&models.User{},
 		&models.TruETLDatabase{},
 		&models.TruETLSaveLog{},
+		&models.TruETLRun{},
 		&models.HohAddressDatabase{},
 		&models.HohAddressSaveLog{},
+		&models.HohAddressRowFilter{},
+		&models.HohAddressColumnRule{},
+		&models.HohAddressColumnViolation{},
+		&models.SchemaBootstrapLog{},
 		&models.ConnectionSaveLog{},
 		&models.UserSaveLog{},
 		&models.RoleSaveLog{},
+		&models.PartitionPolicy{},
+		&models.PartitionMaintenanceLog{},
+		&models.QueryResultSnapshot{},
+		&models.Dashboard{},
+		&models.Annotation{},
+		&models.DigestSubscription{},
+		&models.CapacitySample{},
+		&models.ConnectionRevision{},
+		&models.QueryTerminationLog{},
+		&models.TableRowEditLog{},
+		&models.SQLHistoryEntry{},
+		&models.SavedQuery{},
+		&models.ConnectionHealthCheck{},
+		&models.AuditBackfillState{},
+		&models.BrandingSettings{},
+		&models.SMTPSettings{},
+		&models.Announcement{},
+		&models.UserActivityEvent{},
+		&models.UserSession{},
+		&models.AccessGrant{},
+		&models.SavedFilter{},
+		&models.AlertSilence{},
+		&models.Artifact{},
+		&models.QueryExport{},
+		&models.UsageCounter{},
+		&models.UsageUser{},
+		&models.PermissionGroup{},
+		&models.UserPermissions{},
+		&models.DeadlockEvent{},
+		&models.DeadlockLogCursor{},
+		&models.CustomMonitoringQuery{},
+		&models.DataDictionarySchedule{},
+		&models.SQLJob{},
+		&models.SQLJobRun{},
+		&models.AddressCheckUsage{},
+		&models.AddressCheckFailure{},
+		&models.WidgetToken{},
+		&models.APIKey{},
+		&models.ClusterReplica{},
+		&models.MonitoredDatabase{},
 		// Add more models here as needed (scripts, etc.)
-		
 	}
+}
 
-	for _, model := range models {
-		if err := DB.AutoMigrate(model); err != nil {
-			return fmt.Errorf("failed to migrate model %T: %w", model, err)
-		}
+// MissingTables returns the tables of migrated models that do not exist in the internal database
+func MissingTables() ([]string, error) {
+	if !IsConnected() {
+		return nil, fmt.Errorf("database is not connected")
 	}
 
-	log.Println("Database migrations completed successfully")
-	return nil
+	missing := []string{}
+	for _, model := range migrationModels() {
+		if DB.Migrator().HasTable(model) {
+			continue
+		}
+		stmt := &gorm.Statement{DB: DB}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("failed to parse model %T: %w", model, err)
+		}
+		missing = append(missing, stmt.Schema.Table)
+	}
+	return missing, nil
 }
-
 // GetDB returns the database instance
 func GetDB() *gorm.DB {
 	return DB
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// migrateTimeSeries creates the range-partitioned metric_points table. GORM cannot declare
+// partitioned tables, so the parent is created here; monthly partitions are created on demand
+// by the time-series service.
+func migrateTimeSeries() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS metric_points (
+			connection_id varchar(36) NOT NULL,
+			database_name varchar(255) NOT NULL,
+			metric varchar(64) NOT NULL,
+			resolution varchar(8) NOT NULL,
+			bucket_start timestamptz NOT NULL,
+			value double precision NOT NULL,
+			min_value double precision NOT NULL,
+			max_value double precision NOT NULL,
+			sample_count integer NOT NULL
+		) PARTITION BY RANGE (bucket_start)`,
+		`CREATE INDEX IF NOT EXISTS idx_metric_points_series
+			ON metric_points (connection_id, database_name, metric, resolution, bucket_start)`,
+	}
+
+	for _, statement := range statements {
+		if err := DB.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to create metric_points: %w", err)
+		}
+	}
+
+	slog.Info("time-series storage is ready")
+	return nil
+}
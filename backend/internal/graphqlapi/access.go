@@ -0,0 +1,85 @@
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+)
+
+// Viewer is the authenticated caller a query is resolved for
+type Viewer struct {
+	UserID string
+	Role   models.UserRole
+	APIKey *models.APIKey // Set when the caller authenticated with an API key
+}
+
+// viewerKey is the context key of the Viewer
+type viewerKey struct{}
+
+// WithViewer returns a context carrying the caller whose permissions and access grants the
+// resolvers check
+func WithViewer(ctx context.Context, viewer Viewer) context.Context {
+	return context.WithValue(ctx, viewerKey{}, viewer)
+}
+
+// authorize checks that the caller holds a permission, the way RequirePermission does for REST
+// routes; API keys must also carry it as a scope
+func authorize(p graphql.ResolveParams, permissions *services.PermissionService, permission string) (Viewer, error) {
+	viewer, ok := p.Context.Value(viewerKey{}).(Viewer)
+	if !ok || viewer.UserID == "" {
+		return Viewer{}, fmt.Errorf("%w: not authenticated", services.ErrPermissionDenied)
+	}
+	if viewer.APIKey != nil && !viewer.APIKey.HasScope(permission) {
+		return Viewer{}, fmt.Errorf("API key lacks the %s scope", permission)
+	}
+
+	allowed, err := permissions.HasPermission(viewer.UserID, viewer.Role, permission)
+	if err != nil {
+		return Viewer{}, err
+	}
+	if !allowed {
+		return Viewer{}, fmt.Errorf("%w: %s required", services.ErrPermissionDenied, permission)
+	}
+	return viewer, nil
+}
+
+// authorizeConnection checks a permission and that the caller may read a connection requiring an
+// access grant, as ConnectionAccess does for the /connections/:id routes
+func authorizeConnection(p graphql.ResolveParams, svc Services, permission, connectionID string) error {
+	viewer, err := authorize(p, svc.Permissions, permission)
+	if err != nil {
+		return err
+	}
+	return svc.AccessGrants.CheckAccess(viewer.UserID, viewer.Role, connectionID, http.MethodGet, "/api/v1/connections/:id")
+}
+
+// withConnection wraps a resolver reading from a connection, which connectionOf picks from the
+// parent, so it first checks the caller's permission and access to that connection
+func withConnection(svc Services, connectionOf func(graphql.ResolveParams) string, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if err := authorizeConnection(p, svc, models.PermConnectionsRead, connectionOf(p)); err != nil {
+			return nil, err
+		}
+		return resolve(p)
+	}
+}
+
+// parentConnection is the connection of a field of a Connection
+func parentConnection(p graphql.ResolveParams) string {
+	return p.Source.(*models.Connection).ID
+}
+
+// parentSchemaConnection is the connection of a field of a Schema
+func parentSchemaConnection(p graphql.ResolveParams) string {
+	return p.Source.(schemaNode).connectionID
+}
+
+// parentRoleConnection is the connection of a field of a Role
+func parentRoleConnection(p graphql.ResolveParams) string {
+	return p.Source.(roleNode).connectionID
+}
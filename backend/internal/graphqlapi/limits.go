@@ -0,0 +1,96 @@
+package graphqlapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// maxQueryDepth caps how deeply fields may be nested; the deepest fields of the schema, such as the
+// privileges of a table, are at depth 4
+const maxQueryDepth = 6
+
+// maxQueryFields caps the fields a query selects, counting a fragment each time it is used. Nested
+// fields are resolved once per parent, so wide queries fan out into many database round trips.
+const maxQueryFields = 200
+
+// ErrQueryTooComplex is returned for queries beyond the depth or field limits
+var ErrQueryTooComplex = errors.New("query too complex")
+
+// CheckQueryLimits rejects a query nested deeper than maxQueryDepth or selecting more than
+// maxQueryFields fields before it is executed. Introspection fields are not counted, as they do not
+// reach any database. Queries that do not parse are left for the executor to report.
+func CheckQueryLimits(query string) error {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil
+	}
+
+	counter := &fieldCounter{fragments: map[string]*ast.FragmentDefinition{}, expanding: map[string]bool{}}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			counter.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	for _, definition := range document.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok {
+			if err := counter.count(operation.SelectionSet, 1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fieldCounter walks the selections of a query, expanding fragments where they are used
+type fieldCounter struct {
+	fragments map[string]*ast.FragmentDefinition
+	expanding map[string]bool // Fragments being walked, so a fragment that spreads itself ends the walk
+	fields    int
+}
+
+// count adds the fields of a selection set at the given depth and of everything nested in it
+func (f *fieldCounter) count(set *ast.SelectionSet, depth int) error {
+	if set == nil {
+		return nil
+	}
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			if depth > maxQueryDepth {
+				return fmt.Errorf("%w: fields may be nested at most %d levels deep", ErrQueryTooComplex, maxQueryDepth)
+			}
+			f.fields++
+			if f.fields > maxQueryFields {
+				return fmt.Errorf("%w: at most %d fields may be selected", ErrQueryTooComplex, maxQueryFields)
+			}
+			if err := f.count(s.SelectionSet, depth+1); err != nil {
+				return err
+			}
+		case *ast.InlineFragment:
+			if err := f.count(s.SelectionSet, depth); err != nil {
+				return err
+			}
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			fragment, ok := f.fragments[name]
+			if !ok || f.expanding[name] {
+				// Unknown and cyclic fragments fail validation
+				continue
+			}
+			f.expanding[name] = true
+			err := f.count(fragment.SelectionSet, depth)
+			delete(f.expanding, name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
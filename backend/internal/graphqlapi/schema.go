@@ -0,0 +1,375 @@
+// Package graphqlapi exposes read-only admin metadata (connections, roles,
+// privileges and logs) as a GraphQL schema backed by the existing services.
+package graphqlapi
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+)
+
+// defaultLogLimit is used when a logs field is queried without a limit
+const defaultLogLimit = 100
+
+// roleNode is a role together with the connection it was loaded from
+type roleNode struct {
+	connectionID string
+	role         *models.Role
+}
+
+// schemaNode is a schema together with the connection it was loaded from
+type schemaNode struct {
+	connectionID string
+	schema       *models.Schema
+}
+
+// Services holds the services resolvers read from
+type Services struct {
+	Connections    *services.ConnectionService
+	Databases      *services.DatabaseService
+	ConnectionLogs *services.ConnectionLogService
+	RoleLogs       *services.RoleLogService
+	Permissions    *services.PermissionService
+	AccessGrants   *services.AccessGrantService
+}
+
+// NewSchema builds the GraphQL schema. Resolvers check the permissions of the Viewer in the context
+// the way the REST routes do, and its access grants for every connection they read from.
+func NewSchema(svc Services) (graphql.Schema, error) {
+	databaseObjectType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "DatabaseObject",
+		Fields: graphql.Fields{
+			"name":       &graphql.Field{Type: graphql.String},
+			"schema":     &graphql.Field{Type: graphql.String},
+			"type":       &graphql.Field{Type: graphql.String},
+			"owner":      &graphql.Field{Type: graphql.String},
+			"privileges": &graphql.Field{Type: graphql.NewList(graphql.String)},
+		},
+	})
+
+	schemaType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Schema",
+		Fields: graphql.Fields{
+			"name":     &graphql.Field{Type: graphql.String, Resolve: schemaField(func(s *models.Schema) interface{} { return s.Name })},
+			"owner":    &graphql.Field{Type: graphql.String, Resolve: schemaField(func(s *models.Schema) interface{} { return s.Owner })},
+			"database": &graphql.Field{Type: graphql.String, Resolve: schemaField(func(s *models.Schema) interface{} { return s.Database })},
+			"tables": &graphql.Field{
+				Type: graphql.NewList(databaseObjectType),
+				Resolve: withConnection(svc, parentSchemaConnection, func(p graphql.ResolveParams) (interface{}, error) {
+					node := p.Source.(schemaNode)
+					return svc.Databases.GetTablesInSchema(p.Context, node.connectionID, node.schema.Database, node.schema.Name)
+				}),
+			},
+			"views": &graphql.Field{
+				Type: graphql.NewList(databaseObjectType),
+				Resolve: withConnection(svc, parentSchemaConnection, func(p graphql.ResolveParams) (interface{}, error) {
+					node := p.Source.(schemaNode)
+					return svc.Databases.GetViewsInSchema(p.Context, node.connectionID, node.schema.Database, node.schema.Name)
+				}),
+			},
+			"functions": &graphql.Field{
+				Type: graphql.NewList(databaseObjectType),
+				Resolve: withConnection(svc, parentSchemaConnection, func(p graphql.ResolveParams) (interface{}, error) {
+					node := p.Source.(schemaNode)
+					return svc.Databases.GetFunctionsInSchema(p.Context, node.connectionID, node.schema.Database, node.schema.Name)
+				}),
+			},
+		},
+	})
+
+	databaseType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Database",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.String},
+			"size": &graphql.Field{
+				Type: graphql.Float,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					db := p.Source.(*models.Database)
+					if db.Size == nil {
+						return nil, nil
+					}
+					return float64(*db.Size), nil
+				},
+			},
+			"tables_count": &graphql.Field{Type: graphql.Int},
+		},
+	})
+
+	membershipType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "RoleMembership",
+		Fields: graphql.Fields{
+			"role_oid":     &graphql.Field{Type: graphql.String},
+			"role_name":    &graphql.Field{Type: graphql.String},
+			"member_oid":   &graphql.Field{Type: graphql.String},
+			"member_name":  &graphql.Field{Type: graphql.String},
+			"admin_option": &graphql.Field{Type: graphql.Boolean},
+		},
+	})
+
+	privilegeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "RolePrivilege",
+		Fields: graphql.Fields{
+			"object_type":     &graphql.Field{Type: graphql.String},
+			"object_schema":   &graphql.Field{Type: graphql.String},
+			"object_name":     &graphql.Field{Type: graphql.String},
+			"object_database": &graphql.Field{Type: graphql.String},
+			"privileges":      &graphql.Field{Type: graphql.NewList(graphql.String)},
+		},
+	})
+
+	roleLogType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "RoleLog",
+		Fields: graphql.Fields{
+			"id":            &graphql.Field{Type: graphql.Int},
+			"connection_id": &graphql.Field{Type: graphql.String},
+			"role_id":       &graphql.Field{Type: graphql.String},
+			"user_id":       &graphql.Field{Type: graphql.String},
+			"operation":     &graphql.Field{Type: graphql.String},
+			"status":        &graphql.Field{Type: graphql.String},
+			"error_message": &graphql.Field{Type: graphql.String},
+			"created_at":    &graphql.Field{Type: graphql.DateTime},
+		},
+	})
+
+	roleType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Role",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.String, Resolve: roleField(func(r *models.Role) interface{} { return r.ID })},
+			"name":        &graphql.Field{Type: graphql.String, Resolve: roleField(func(r *models.Role) interface{} { return r.Name })},
+			"description": &graphql.Field{Type: graphql.String, Resolve: roleField(func(r *models.Role) interface{} { return r.Description })},
+			"permissions": &graphql.Field{Type: graphql.NewList(graphql.String), Resolve: roleField(func(r *models.Role) interface{} { return r.Permissions })},
+			"users":       &graphql.Field{Type: graphql.NewList(graphql.String), Resolve: roleField(func(r *models.Role) interface{} { return r.Users })},
+			"parent_roles": &graphql.Field{
+				Type: graphql.NewList(membershipType),
+				Resolve: withConnection(svc, parentRoleConnection, func(p graphql.ResolveParams) (interface{}, error) {
+					node := p.Source.(roleNode)
+					parents, _, err := svc.Databases.GetRoleMembership(p.Context, node.connectionID, node.role.ID)
+					return parents, err
+				}),
+			},
+			"child_roles": &graphql.Field{
+				Type: graphql.NewList(membershipType),
+				Resolve: withConnection(svc, parentRoleConnection, func(p graphql.ResolveParams) (interface{}, error) {
+					node := p.Source.(roleNode)
+					_, children, err := svc.Databases.GetRoleMembership(p.Context, node.connectionID, node.role.ID)
+					return children, err
+				}),
+			},
+			"privileges": &graphql.Field{
+				Type: graphql.NewList(privilegeType),
+				Resolve: withConnection(svc, parentRoleConnection, func(p graphql.ResolveParams) (interface{}, error) {
+					node := p.Source.(roleNode)
+					return svc.Databases.GetRolePrivileges(p.Context, node.connectionID, node.role.ID)
+				}),
+			},
+			"logs": &graphql.Field{
+				Type: graphql.NewList(roleLogType),
+				Args: graphql.FieldConfigArgument{"limit": &graphql.ArgumentConfig{Type: graphql.Int}},
+				Resolve: withConnection(svc, parentRoleConnection, func(p graphql.ResolveParams) (interface{}, error) {
+					node := p.Source.(roleNode)
+					return logsOnly(svc.RoleLogs.GetLogsByRole(node.connectionID, node.role.ID, pageArg(p)))
+				}),
+			},
+		},
+	})
+
+	changesSummaryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ConnectionChangesSummary",
+		Fields: graphql.Fields{
+			"created": &graphql.Field{Type: graphql.Int},
+			"updated": &graphql.Field{Type: graphql.Int},
+			"deleted": &graphql.Field{Type: graphql.Int},
+		},
+	})
+
+	connectionLogType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ConnectionLog",
+		Fields: graphql.Fields{
+			"id":              &graphql.Field{Type: graphql.Int},
+			"connection_id":   &graphql.Field{Type: graphql.String},
+			"user_id":         &graphql.Field{Type: graphql.String},
+			"operation":       &graphql.Field{Type: graphql.String},
+			"status":          &graphql.Field{Type: graphql.String},
+			"changes_summary": &graphql.Field{Type: changesSummaryType},
+			"error_message":   &graphql.Field{Type: graphql.String},
+			"created_at":      &graphql.Field{Type: graphql.DateTime},
+		},
+	})
+
+	connectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Connection",
+		Fields: graphql.Fields{
+			"id":         &graphql.Field{Type: graphql.String},
+			"name":       &graphql.Field{Type: graphql.String},
+			"type":       &graphql.Field{Type: graphql.String},
+			"host":       &graphql.Field{Type: graphql.String},
+			"port":       &graphql.Field{Type: graphql.Int},
+			"database":   &graphql.Field{Type: graphql.String},
+			"username":   &graphql.Field{Type: graphql.String},
+			"ssl_mode":   &graphql.Field{Type: graphql.String},
+			"created_at": &graphql.Field{Type: graphql.DateTime},
+			"updated_at": &graphql.Field{Type: graphql.DateTime},
+			"databases": &graphql.Field{
+				Type: graphql.NewList(databaseType),
+				Resolve: withConnection(svc, parentConnection, func(p graphql.ResolveParams) (interface{}, error) {
+					return svc.Databases.GetDatabases(p.Context, p.Source.(*models.Connection).ID)
+				}),
+			},
+			"schemas": &graphql.Field{
+				Type: graphql.NewList(schemaType),
+				Args: graphql.FieldConfigArgument{"database": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: withConnection(svc, parentConnection, func(p graphql.ResolveParams) (interface{}, error) {
+					connID := p.Source.(*models.Connection).ID
+					schemas, err := svc.Databases.GetSchemas(p.Context, connID, p.Args["database"].(string))
+					if err != nil {
+						return nil, err
+					}
+					nodes := make([]schemaNode, len(schemas))
+					for i, schema := range schemas {
+						nodes[i] = schemaNode{connectionID: connID, schema: schema}
+					}
+					return nodes, nil
+				}),
+			},
+			"roles": &graphql.Field{
+				Type: graphql.NewList(roleType),
+				Resolve: withConnection(svc, parentConnection, func(p graphql.ResolveParams) (interface{}, error) {
+					connID := p.Source.(*models.Connection).ID
+					roles, err := svc.Databases.GetRoles(p.Context, connID)
+					if err != nil {
+						return nil, err
+					}
+					nodes := make([]roleNode, len(roles))
+					for i, role := range roles {
+						nodes[i] = roleNode{connectionID: connID, role: role}
+					}
+					return nodes, nil
+				}),
+			},
+			"role": &graphql.Field{
+				Type: roleType,
+				Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: withConnection(svc, parentConnection, func(p graphql.ResolveParams) (interface{}, error) {
+					connID := p.Source.(*models.Connection).ID
+					role, err := svc.Databases.GetRole(p.Context, connID, p.Args["id"].(string))
+					if err != nil {
+						return nil, err
+					}
+					return roleNode{connectionID: connID, role: role}, nil
+				}),
+			},
+			"logs": &graphql.Field{
+				Type: graphql.NewList(connectionLogType),
+				Args: graphql.FieldConfigArgument{"limit": &graphql.ArgumentConfig{Type: graphql.Int}},
+				Resolve: withConnection(svc, parentConnection, func(p graphql.ResolveParams) (interface{}, error) {
+					return logsOnly(svc.ConnectionLogs.GetLogsByConnection(p.Source.(*models.Connection).ID, pageArg(p)))
+				}),
+			},
+			"role_logs": &graphql.Field{
+				Type: graphql.NewList(roleLogType),
+				Args: graphql.FieldConfigArgument{"limit": &graphql.ArgumentConfig{Type: graphql.Int}},
+				Resolve: withConnection(svc, parentConnection, func(p graphql.ResolveParams) (interface{}, error) {
+					return logsOnly(svc.RoleLogs.GetLogsByConnection(p.Source.(*models.Connection).ID, pageArg(p)))
+				}),
+			},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"connections": &graphql.Field{
+				Type: graphql.NewList(connectionType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					viewer, err := authorize(p, svc.Permissions, models.PermConnectionsRead)
+					if err != nil {
+						return nil, err
+					}
+					connections, err := svc.Connections.GetAllConnections()
+					if err != nil {
+						return nil, err
+					}
+					// Connections requiring a grant the caller lacks are left out
+					readable := make([]*models.Connection, 0, len(connections))
+					for _, conn := range connections {
+						allowed, err := svc.AccessGrants.CanReadConnection(viewer.UserID, conn.ID)
+						if err != nil {
+							return nil, err
+						}
+						if allowed {
+							readable = append(readable, conn)
+						}
+					}
+					return readable, nil
+				},
+			},
+			"connection": &graphql.Field{
+				Type: connectionType,
+				Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id := p.Args["id"].(string)
+					if err := authorizeConnection(p, svc, models.PermConnectionsRead, id); err != nil {
+						return nil, err
+					}
+					return svc.Connections.GetConnection(id)
+				},
+			},
+			"connection_logs": &graphql.Field{
+				Type: graphql.NewList(connectionLogType),
+				Args: graphql.FieldConfigArgument{"limit": &graphql.ArgumentConfig{Type: graphql.Int}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if _, err := authorize(p, svc.Permissions, models.PermConnectionsRead); err != nil {
+						return nil, err
+					}
+					return logsOnly(svc.ConnectionLogs.GetLogs(pageArg(p)))
+				},
+			},
+			"role_logs": &graphql.Field{
+				Type: graphql.NewList(roleLogType),
+				Args: graphql.FieldConfigArgument{"limit": &graphql.ArgumentConfig{Type: graphql.Int}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if _, err := authorize(p, svc.Permissions, models.PermConnectionsRead); err != nil {
+						return nil, err
+					}
+					return logsOnly(svc.RoleLogs.GetLogs(pageArg(p)))
+				},
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+	if err != nil {
+		return graphql.Schema{}, fmt.Errorf("failed to build GraphQL schema: %w", err)
+	}
+	return schema, nil
+}
+
+// roleField resolves a scalar field of a roleNode
+func roleField(get func(*models.Role) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(roleNode).role), nil
+	}
+}
+
+// schemaField resolves a scalar field of a schemaNode
+func schemaField(get func(*models.Schema) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(schemaNode).schema), nil
+	}
+}
+
+// pageArg returns the first page of the limit argument or the default size
+func pageArg(p graphql.ResolveParams) services.Page {
+	if limit, ok := p.Args["limit"].(int); ok && limit > 0 {
+		return services.Page{Limit: limit}
+	}
+	return services.Page{Limit: defaultLogLimit}
+}
+
+// logsOnly drops the total count returned by the paginated log queries
+func logsOnly[T any](logs []T, _ int64, err error) (interface{}, error) {
+	return logs, err
+}
@@ -0,0 +1,76 @@
+package grpcapi
+
+import (
+	"fmt"
+	"strings"
+
+	"truadmin/internal/models"
+)
+
+// CallerMode controls how ExecuteQuery runs a caller's queries
+type CallerMode string
+
+const (
+	CallerModeSandbox CallerMode = "sandbox" // Every query runs inside BEGIN ... ROLLBACK
+	CallerModeFull    CallerMode = "full"    // Queries may commit
+)
+
+// anyConnection in a caller's connection list matches every connection that does not require an
+// access grant; grant-only connections have to be listed by ID
+const anyConnection = "*"
+
+// CallerAccess limits the connections a caller reaches and how its queries run
+type CallerAccess struct {
+	Mode        CallerMode
+	Connections []string // Connection IDs or "*"
+}
+
+// defaultCallerAccess applies to callers without an entry of their own: sandbox queries on
+// connections that do not require an access grant
+var defaultCallerAccess = CallerAccess{Mode: CallerModeSandbox, Connections: []string{anyConnection}}
+
+// ParseCallerAccess parses caller access entries of the form "<mode>:<id>|<id>...", keyed by caller
+// ("key:<fingerprint>" or "cert:<subject>")
+func ParseCallerAccess(entries map[string]string) (map[string]CallerAccess, error) {
+	access := make(map[string]CallerAccess, len(entries))
+	for caller, entry := range entries {
+		mode, list, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid gRPC caller access for %s: expected <mode>:<connections>", caller)
+		}
+		mode = strings.ToLower(strings.TrimSpace(mode))
+		if mode != string(CallerModeSandbox) && mode != string(CallerModeFull) {
+			return nil, fmt.Errorf("invalid gRPC caller access for %s: mode must be sandbox or full", caller)
+		}
+
+		connections := []string{}
+		for _, id := range strings.Split(list, "|") {
+			if id = strings.TrimSpace(id); id != "" {
+				connections = append(connections, id)
+			}
+		}
+		if len(connections) == 0 {
+			return nil, fmt.Errorf("invalid gRPC caller access for %s: no connections listed", caller)
+		}
+		access[caller] = CallerAccess{Mode: CallerMode(mode), Connections: connections}
+	}
+	return access, nil
+}
+
+// accessFor returns the access of a caller
+func (a *api) accessFor(caller string) CallerAccess {
+	if access, ok := a.callerAccess[caller]; ok {
+		return access
+	}
+	return defaultCallerAccess
+}
+
+// allows reports whether the access covers a connection
+func (c CallerAccess) allows(conn *models.Connection) bool {
+	for _, id := range c.Connections {
+		if id == conn.ID || (id == anyConnection && !conn.RequiresGrant) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Client is a typed client for Go consumers of the gRPC API
+type Client struct {
+	conn   grpc.ClientConnInterface
+	apiKey string
+}
+
+// NewClient wraps a gRPC connection; apiKey may be empty when authenticating with a client certificate
+func NewClient(conn grpc.ClientConnInterface, apiKey string) *Client {
+	return &Client{conn: conn, apiKey: apiKey}
+}
+
+// CheckAddress calls AddressCheck/CheckAddress
+func (c *Client) CheckAddress(ctx context.Context, req *CheckAddressRequest) (*CheckAddressResponse, error) {
+	resp := new(CheckAddressResponse)
+	return resp, c.invoke(ctx, CheckAddressMethod, req, resp)
+}
+
+// GetUsage calls AddressCheck/GetUsage
+func (c *Client) GetUsage(ctx context.Context, req *GetUsageRequest) (*GetUsageResponse, error) {
+	resp := new(GetUsageResponse)
+	return resp, c.invoke(ctx, GetUsageMethod, req, resp)
+}
+
+// ListConnections calls ConnectionCatalog/ListConnections
+func (c *Client) ListConnections(ctx context.Context, req *ListConnectionsRequest) (*ListConnectionsResponse, error) {
+	resp := new(ListConnectionsResponse)
+	return resp, c.invoke(ctx, ListConnectionsMethod, req, resp)
+}
+
+// GetConnection calls ConnectionCatalog/GetConnection
+func (c *Client) GetConnection(ctx context.Context, req *GetConnectionRequest) (*CatalogConnection, error) {
+	resp := new(CatalogConnection)
+	return resp, c.invoke(ctx, GetConnectionMethod, req, resp)
+}
+
+// ExecuteQuery calls QueryExecution/ExecuteQuery
+func (c *Client) ExecuteQuery(ctx context.Context, req *ExecuteQueryRequest) (*ExecuteQueryResponse, error) {
+	resp := new(ExecuteQueryResponse)
+	return resp, c.invoke(ctx, ExecuteQueryMethod, req, resp)
+}
+
+// invoke performs a unary call with the JSON codec and API key metadata
+func (c *Client) invoke(ctx context.Context, method string, req, resp interface{}) error {
+	if c.apiKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, APIKeyHeader, c.apiKey)
+	}
+	return c.conn.Invoke(ctx, method, req, resp, grpc.CallContentSubtype(CodecName))
+}
@@ -0,0 +1,23 @@
+package grpcapi
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// CodecName is the gRPC content subtype used by this API (content-type application/grpc+json).
+// Messages are the Go structs in messages.go encoded as JSON, so no generated protobuf code is needed;
+// clients select the codec with grpc.CallContentSubtype(CodecName), which NewClient does.
+const CodecName = "json"
+
+// jsonCodec encodes gRPC messages as JSON
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                               { return CodecName }
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
@@ -0,0 +1,83 @@
+package grpcapi
+
+import (
+	"time"
+
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+)
+
+// CheckAddressRequest is the request of AddressCheck/CheckAddress
+type CheckAddressRequest struct {
+	DatabaseID  string `json:"database_id"` // HohAddress database ID
+	Address1    string `json:"address1"`
+	Address2    string `json:"address2"`
+	City        string `json:"city"`
+	State       string `json:"state"`
+	Zip         string `json:"zip"`
+	ProgramType string `json:"program_type"`
+}
+
+// CheckAddressResponse is the response of AddressCheck/CheckAddress
+type CheckAddressResponse = services.AddressCheckResult
+
+// GetUsageRequest is the request of AddressCheck/GetUsage
+type GetUsageRequest struct {
+	Days     int `json:"days,omitempty"`     // Report window including today; defaults to 7, at most 90
+	Failures int `json:"failures,omitempty"` // Recent failures to list; defaults to 20, at most 100
+}
+
+// GetUsageResponse is the response of AddressCheck/GetUsage
+type GetUsageResponse = models.AddressCheckUsageReport
+
+// ListConnectionsRequest is the request of ConnectionCatalog/ListConnections
+type ListConnectionsRequest struct {
+	Type string `json:"type,omitempty"` // Optional filter, e.g. "postgres"
+}
+
+// CatalogConnection is a connection without its credentials
+type CatalogConnection struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Host      string    `json:"host"`
+	Port      int       `json:"port"`
+	Database  string    `json:"database"`
+	SSLMode   string    `json:"ssl_mode"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListConnectionsResponse is the response of ConnectionCatalog/ListConnections
+type ListConnectionsResponse struct {
+	Connections []CatalogConnection `json:"connections"`
+}
+
+// GetConnectionRequest is the request of ConnectionCatalog/GetConnection
+type GetConnectionRequest struct {
+	ID string `json:"id"`
+}
+
+// ExecuteQueryRequest is the request of QueryExecution/ExecuteQuery
+type ExecuteQueryRequest struct {
+	ConnectionID string `json:"connection_id"`
+	Database     string `json:"database"`
+	Query        string `json:"query"`
+	Sandbox      bool   `json:"sandbox"` // Run inside BEGIN ... ROLLBACK
+}
+
+// ExecuteQueryResponse is the response of QueryExecution/ExecuteQuery
+type ExecuteQueryResponse = models.QueryResult
+
+// catalogConnection strips credentials from a connection
+func catalogConnection(conn *models.Connection) CatalogConnection {
+	return CatalogConnection{
+		ID:        conn.ID,
+		Name:      conn.Name,
+		Type:      conn.Type,
+		Host:      conn.Host,
+		Port:      conn.Port,
+		Database:  conn.Database,
+		SSLMode:   conn.SSLMode,
+		UpdatedAt: conn.UpdatedAt,
+	}
+}
@@ -0,0 +1,206 @@
+// Package grpcapi serves truadmin capabilities (address check, connection catalog
+// and query execution) over gRPC for internal service consumers.
+package grpcapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"truadmin/internal/services"
+)
+
+// APIKeyHeader is the metadata key carrying an API key
+const APIKeyHeader = "x-api-key"
+
+// Config holds the gRPC server settings
+type Config struct {
+	Port         string
+	APIKeys      []string // Accepted API keys
+	CertFile     string   // Server certificate (enables TLS)
+	KeyFile      string
+	ClientCAFile string // CA for client certificates (enables mTLS)
+
+	// Connections and query mode by caller; callers without an entry run sandbox queries on
+	// connections that do not require an access grant
+	CallerAccess map[string]CallerAccess
+}
+
+// Services holds the services the gRPC API is backed by
+type Services struct {
+	Connections *services.ConnectionService
+	Databases   *services.DatabaseService
+	HohAddress  *services.HohAddressService
+	Usage       *services.AddressCheckUsageService // Address check quotas and usage
+	History     *services.SQLHistoryService        // Records executed queries by caller
+	Audit       *services.AuditService
+}
+
+// Server is the gRPC server
+type Server struct {
+	config Config
+	server *grpc.Server
+}
+
+// NewServer creates a gRPC server. Callers must authenticate with a verified client
+// certificate (when ClientCAFile is set) or an API key, so at least one is required.
+// API keys are only accepted over TLS, so they never travel in plaintext.
+func NewServer(config Config, svc Services) (*Server, error) {
+	if len(config.APIKeys) == 0 && config.ClientCAFile == "" {
+		return nil, fmt.Errorf("gRPC requires API keys or a client CA for mTLS")
+	}
+	if len(config.APIKeys) > 0 && config.CertFile == "" {
+		return nil, fmt.Errorf("gRPC API keys require TLS with a server certificate and key")
+	}
+
+	opts := []grpc.ServerOption{grpc.UnaryInterceptor(authInterceptor(config.APIKeys))}
+
+	if config.CertFile != "" {
+		tlsConfig, err := serverTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	} else if config.ClientCAFile != "" {
+		return nil, fmt.Errorf("mTLS requires a server certificate and key")
+	}
+
+	server := grpc.NewServer(opts...)
+	impl := &api{
+		connectionService: svc.Connections,
+		databaseService:   svc.Databases,
+		hohAddressService: svc.HohAddress,
+		usageService:      svc.Usage,
+		history:           svc.History,
+		audit:             svc.Audit,
+		callerAccess:      config.CallerAccess,
+	}
+	for i := range serviceDescs {
+		server.RegisterService(&serviceDescs[i], impl)
+	}
+
+	return &Server{config: config, server: server}, nil
+}
+
+// Start listens on the configured port and serves in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", ":"+s.config.Port)
+	if err != nil {
+		return fmt.Errorf("failed to listen on gRPC port: %w", err)
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil {
+			log.Printf("ERROR: gRPC server stopped: %v", err)
+		}
+	}()
+
+	log.Printf("gRPC server starting on port %s...", s.config.Port)
+	return nil
+}
+
+// Stop finishes in-flight calls and stops the server
+func (s *Server) Stop() {
+	s.server.GracefulStop()
+}
+
+// serverTLSConfig loads the server certificate and, when configured, the client CA
+func serverTLSConfig(config Config) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gRPC certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if config.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gRPC client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in gRPC client CA file")
+		}
+		tlsConfig.ClientCAs = pool
+		// API key clients may connect without a certificate; the interceptor enforces one of both
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig, nil
+}
+
+// callerKey is the context key of the authenticated caller
+type callerKey struct{}
+
+// authInterceptor accepts calls from verified client certificates or with a valid API key and
+// stores the caller in the context
+func authInterceptor(apiKeys []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if subject, ok := verifiedClientCert(ctx); ok {
+			return handler(context.WithValue(ctx, callerKey{}, "cert:"+subject), req)
+		}
+		if key, ok := validAPIKey(ctx, apiKeys); ok {
+			return handler(context.WithValue(ctx, callerKey{}, "key:"+keyFingerprint(key)), req)
+		}
+		return nil, status.Error(codes.Unauthenticated, "client certificate or API key required")
+	}
+}
+
+// callerFromContext returns the caller stored by authInterceptor
+func callerFromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKey{}).(string)
+	return caller
+}
+
+// verifiedClientCert returns the subject of a peer certificate signed by the client CA
+func verifiedClientCert(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return tlsInfo.State.VerifiedChains[0][0].Subject.String(), true
+}
+
+// validAPIKey returns the accepted API key carried in the call metadata
+func validAPIKey(ctx context.Context, apiKeys []string) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, provided := range md.Get(APIKeyHeader) {
+		for _, key := range apiKeys {
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(key)) == 1 {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
+// keyFingerprint identifies an API key in usage records without storing the key
+func keyFingerprint(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
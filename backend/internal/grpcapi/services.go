@@ -0,0 +1,253 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+)
+
+// Full method names of the API
+const (
+	CheckAddressMethod    = "/truadmin.AddressCheck/CheckAddress"
+	GetUsageMethod        = "/truadmin.AddressCheck/GetUsage"
+	ListConnectionsMethod = "/truadmin.ConnectionCatalog/ListConnections"
+	GetConnectionMethod   = "/truadmin.ConnectionCatalog/GetConnection"
+	ExecuteQueryMethod    = "/truadmin.QueryExecution/ExecuteQuery"
+)
+
+// api implements the gRPC services on top of the shared service layer
+type api struct {
+	connectionService *services.ConnectionService
+	databaseService   *services.DatabaseService
+	hohAddressService *services.HohAddressService
+	usageService      *services.AddressCheckUsageService
+	history           *services.SQLHistoryService
+	audit             *services.AuditService
+	callerAccess      map[string]CallerAccess
+}
+
+// CheckAddress runs the step-by-step HohAddress status check. Each call counts against the
+// caller's daily quota and is recorded in its usage.
+func (a *api) CheckAddress(ctx context.Context, req *CheckAddressRequest) (*CheckAddressResponse, error) {
+	caller := callerFromContext(ctx)
+	if err := a.usageService.Reserve(caller); err != nil {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	started := time.Now()
+	result, err := a.checkAddress(ctx, req)
+	if err != nil {
+		a.usageService.Record(caller, req.DatabaseID, started, status.Code(err).String(), err)
+		return nil, err
+	}
+	a.usageService.Record(caller, req.DatabaseID, started, "", nil)
+	return result, nil
+}
+
+// checkAddress validates the request and runs the check
+func (a *api) checkAddress(ctx context.Context, req *CheckAddressRequest) (*CheckAddressResponse, error) {
+	if req.DatabaseID == "" || req.Address1 == "" || req.City == "" || req.State == "" || req.Zip == "" || req.ProgramType == "" {
+		return nil, status.Error(codes.InvalidArgument, "database_id, address1, city, state, zip and program_type are required")
+	}
+	result, err := a.hohAddressService.CheckAddressStatus(ctx, req.DatabaseID, req.Address1, req.Address2, req.City, req.State, req.Zip, req.ProgramType)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return result, nil
+}
+
+// GetUsage returns the calling key's address check usage, quota, SLO compliance and recent failures
+func (a *api) GetUsage(ctx context.Context, req *GetUsageRequest) (*GetUsageResponse, error) {
+	if a.usageService == nil {
+		return nil, status.Error(codes.Unavailable, "address check usage is not recorded")
+	}
+	report, err := a.usageService.GetReport(callerFromContext(ctx), req.Days, req.Failures)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return report, nil
+}
+
+// ListConnections returns the connections the caller may reach, without credentials
+func (a *api) ListConnections(ctx context.Context, req *ListConnectionsRequest) (*ListConnectionsResponse, error) {
+	connections, err := a.connectionService.GetAllConnections()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	access := a.accessFor(callerFromContext(ctx))
+	resp := &ListConnectionsResponse{Connections: []CatalogConnection{}}
+	for _, conn := range connections {
+		if (req.Type != "" && conn.Type != req.Type) || !access.allows(conn) {
+			continue
+		}
+		resp.Connections = append(resp.Connections, catalogConnection(conn))
+	}
+	return resp, nil
+}
+
+// GetConnection returns a single catalog entry
+func (a *api) GetConnection(ctx context.Context, req *GetConnectionRequest) (*CatalogConnection, error) {
+	if req.ID == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	conn, err := a.callerConnection(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	result := catalogConnection(conn)
+	return &result, nil
+}
+
+// ExecuteQuery runs a query against a connection's database. Callers limited to sandbox mode
+// must ask for a sandbox run; every query is recorded in the SQL history and the audit trail.
+func (a *api) ExecuteQuery(ctx context.Context, req *ExecuteQueryRequest) (*ExecuteQueryResponse, error) {
+	if req.ConnectionID == "" || req.Database == "" || req.Query == "" {
+		return nil, status.Error(codes.InvalidArgument, "connection_id, database and query are required")
+	}
+	if _, err := a.callerConnection(ctx, req.ConnectionID); err != nil {
+		return nil, err
+	}
+	caller := callerFromContext(ctx)
+	if !req.Sandbox && a.accessFor(caller).Mode != CallerModeFull {
+		return nil, status.Error(codes.PermissionDenied, "caller may only run sandbox queries")
+	}
+
+	started := time.Now()
+	var err error
+	var result *ExecuteQueryResponse
+	if req.Sandbox {
+		result, err = a.databaseService.ExecuteSandboxQuery(ctx, req.ConnectionID, req.Database, req.Query)
+	} else {
+		result, err = a.databaseService.ExecuteQuery(ctx, req.ConnectionID, req.Database, req.Query)
+	}
+	a.recordQuery(caller, req, started, result, err)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return result, nil
+}
+
+// callerConnection returns a connection the caller may reach; others are reported as not found
+// so the catalog does not reveal them
+func (a *api) callerConnection(ctx context.Context, id string) (*models.Connection, error) {
+	conn, err := a.connectionService.GetConnection(id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if !a.accessFor(callerFromContext(ctx)).allows(conn) {
+		return nil, status.Error(codes.NotFound, "connection not found")
+	}
+	return conn, nil
+}
+
+// recordQuery adds a query to the caller's SQL history, as the REST API does for users, and ships
+// it to the audit sinks since callers have no account to read their history with
+func (a *api) recordQuery(caller string, req *ExecuteQueryRequest, started time.Time, result *ExecuteQueryResponse, err error) {
+	var rowCount int64
+	message := ""
+	switch {
+	case err != nil:
+		message = err.Error()
+	case result.RowsAffected != nil:
+		rowCount = *result.RowsAffected
+		message = result.Error
+	default:
+		rowCount = int64(len(result.Rows))
+		message = result.Error
+	}
+
+	entry := &models.SQLHistoryEntry{
+		UserID:       caller,
+		ConnectionID: req.ConnectionID,
+		DatabaseName: req.Database,
+		Query:        req.Query,
+		Sandbox:      req.Sandbox,
+		Status:       models.AuditEventStatusSuccess,
+		ErrorMessage: message,
+		RowCount:     rowCount,
+		DurationMs:   time.Since(started).Milliseconds(),
+	}
+	if message != "" {
+		entry.Status = models.AuditEventStatusError
+	}
+	a.history.Record(entry)
+
+	auditMessage := req.Query
+	if message != "" {
+		auditMessage = message
+	}
+	a.audit.Record(models.AuditEvent{
+		Source:       "grpc",
+		Action:       "execute_query",
+		Status:       entry.Status,
+		ActorID:      caller,
+		ConnectionID: req.ConnectionID,
+		TargetID:     req.Database,
+		Message:      auditMessage,
+	})
+}
+
+// toStatus maps service errors to gRPC status codes
+func toStatus(err error) error {
+	if errors.Is(err, services.ErrAddressCheckQuotaExceeded) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// unaryHandler adapts a typed method to a grpc.MethodDesc handler
+func unaryHandler[Req any, Resp any](fullMethod string, call func(*api, context.Context, *Req) (*Resp, error)) grpc.MethodHandler {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := new(Req)
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		a := srv.(*api)
+		if interceptor == nil {
+			return call(a, ctx, req)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}
+		return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return call(a, ctx, req.(*Req))
+		})
+	}
+}
+
+// serviceDescs describe the gRPC services; HandlerType is *api for all of them
+var serviceDescs = []grpc.ServiceDesc{
+	{
+		ServiceName: "truadmin.AddressCheck",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "CheckAddress", Handler: unaryHandler(CheckAddressMethod, (*api).CheckAddress)},
+			{MethodName: "GetUsage", Handler: unaryHandler(GetUsageMethod, (*api).GetUsage)},
+		},
+	},
+	{
+		ServiceName: "truadmin.ConnectionCatalog",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "ListConnections", Handler: unaryHandler(ListConnectionsMethod, (*api).ListConnections)},
+			{MethodName: "GetConnection", Handler: unaryHandler(GetConnectionMethod, (*api).GetConnection)},
+		},
+	},
+	{
+		ServiceName: "truadmin.QueryExecution",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "ExecuteQuery", Handler: unaryHandler(ExecuteQueryMethod, (*api).ExecuteQuery)},
+		},
+	},
+}
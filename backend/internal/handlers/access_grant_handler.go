@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessGrantHandler handles HTTP requests for temporary connection access grants
+type AccessGrantHandler struct {
+	accessGrantService *services.AccessGrantService
+}
+
+// NewAccessGrantHandler creates a new access grant handler
+func NewAccessGrantHandler(accessGrantService *services.AccessGrantService) *AccessGrantHandler {
+	return &AccessGrantHandler{
+		accessGrantService: accessGrantService,
+	}
+}
+
+// CreateGrant handles POST /api/v1/access-grants (admin only)
+func (h *AccessGrantHandler) CreateGrant(c *gin.Context) {
+	var req models.AccessGrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	grant, err := h.accessGrantService.CreateGrant(&req, userIDStr)
+	if err != nil {
+		respondAccessGrantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, grant)
+}
+
+// GetGrants handles GET /api/v1/access-grants?connection_id=...&user_id=...&active=true (admin only)
+func (h *AccessGrantHandler) GetGrants(c *gin.Context) {
+	page := parsePage(c, 100)
+
+	grants, total, err := h.accessGrantService.GetGrants(c.Query("connection_id"), c.Query("user_id"), c.Query("active") == "true", page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"grants": grants, "pagination": setPageHeaders(c, page, total)})
+}
+
+// GetMyGrants handles GET /api/v1/access-grants/mine?active=true
+func (h *AccessGrantHandler) GetMyGrants(c *gin.Context) {
+	page := parsePage(c, 100)
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	grants, total, err := h.accessGrantService.GetGrants("", userIDStr, c.Query("active") == "true", page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"grants": grants, "pagination": setPageHeaders(c, page, total)})
+}
+
+// BreakGlass handles POST /api/v1/access-grants/break-glass.
+// Access is granted immediately; admins are alerted and the session is recorded in detail.
+func (h *AccessGrantHandler) BreakGlass(c *gin.Context) {
+	var req models.BreakGlassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	grant, err := h.accessGrantService.BreakGlass(&req, userIDStr)
+	if err != nil {
+		respondAccessGrantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, grant)
+}
+
+// RevokeGrant handles POST /api/v1/access-grants/:id/revoke (admin only)
+func (h *AccessGrantHandler) RevokeGrant(c *gin.Context) {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	grant, err := h.accessGrantService.RevokeGrant(c.Param("id"), userIDStr)
+	if err != nil {
+		respondAccessGrantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, grant)
+}
+
+// GetGrantActivity handles GET /api/v1/access-grants/:id/activity (admin only)
+func (h *AccessGrantHandler) GetGrantActivity(c *gin.Context) {
+	activity, err := h.accessGrantService.GetGrantActivity(c.Param("id"))
+	if err != nil {
+		respondAccessGrantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, activity)
+}
+
+// respondAccessGrantError maps access grant service errors to HTTP status codes
+func respondAccessGrantError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAccessGrant):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrBreakGlassDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "access grant not found", err.Error() == "connection not found", err.Error() == "user not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
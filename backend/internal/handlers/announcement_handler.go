@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnnouncementHandler handles HTTP requests for in-app announcement banners
+type AnnouncementHandler struct {
+	announcementService *services.AnnouncementService
+}
+
+// NewAnnouncementHandler creates a new announcement handler
+func NewAnnouncementHandler(announcementService *services.AnnouncementService) *AnnouncementHandler {
+	return &AnnouncementHandler{
+		announcementService: announcementService,
+	}
+}
+
+// GetActiveAnnouncements handles GET /api/v1/announcements/active.
+// The SPA polls it; responses carry an ETag so unchanged polls cost a 304.
+func (h *AnnouncementHandler) GetActiveAnnouncements(c *gin.Context) {
+	role, _ := c.Get("role")
+	roleValue, _ := role.(models.UserRole)
+
+	announcements, err := h.announcementService.GetActiveAnnouncements(roleValue)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, announcements)
+}
+
+// GetAnnouncements handles GET /api/v1/announcements (admin only)
+func (h *AnnouncementHandler) GetAnnouncements(c *gin.Context) {
+	page := parsePage(c, 100)
+
+	announcements, total, err := h.announcementService.GetAnnouncements(page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"announcements": announcements,
+		"pagination":    setPageHeaders(c, page, total),
+	})
+}
+
+// CreateAnnouncement handles POST /api/v1/announcements (admin only)
+func (h *AnnouncementHandler) CreateAnnouncement(c *gin.Context) {
+	var req models.AnnouncementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	announcement, err := h.announcementService.CreateAnnouncement(&req, userIDStr)
+	if err != nil {
+		respondAnnouncementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, announcement)
+}
+
+// UpdateAnnouncement handles PUT /api/v1/announcements/:id (admin only)
+func (h *AnnouncementHandler) UpdateAnnouncement(c *gin.Context) {
+	var req models.AnnouncementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	announcement, err := h.announcementService.UpdateAnnouncement(c.Param("id"), &req)
+	if err != nil {
+		respondAnnouncementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, announcement)
+}
+
+// DeleteAnnouncement handles DELETE /api/v1/announcements/:id (admin only)
+func (h *AnnouncementHandler) DeleteAnnouncement(c *gin.Context) {
+	if err := h.announcementService.DeleteAnnouncement(c.Param("id")); err != nil {
+		respondAnnouncementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// respondAnnouncementError maps announcement service errors to HTTP status codes
+func respondAnnouncementError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAnnouncementWindow):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "announcement not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ArtifactHandler handles HTTP requests for stored artifacts
+type ArtifactHandler struct {
+	artifactService *services.ArtifactService
+}
+
+// NewArtifactHandler creates a new artifact handler
+func NewArtifactHandler(artifactService *services.ArtifactService) *ArtifactHandler {
+	return &ArtifactHandler{
+		artifactService: artifactService,
+	}
+}
+
+// UploadArtifact handles POST /api/v1/artifacts (multipart field "file", form field "kind")
+func (h *ArtifactHandler) UploadArtifact(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	kind := models.ArtifactKind(c.DefaultPostForm("kind", string(models.ArtifactExport)))
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	artifact, err := h.artifactService.Store(c.Request.Context(), kind, file.Filename, userIDStr, file.Header.Get("Content-Type"), f, file.Size)
+	if err != nil {
+		respondArtifactError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, artifact)
+}
+
+// GetArtifacts handles GET /api/v1/artifacts (optional ?kind=backup|export|snapshot)
+func (h *ArtifactHandler) GetArtifacts(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	artifacts, err := h.artifactService.GetArtifacts(userIDStr, isAdmin(c), c.Query("kind"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, artifacts)
+}
+
+// GetArtifact handles GET /api/v1/artifacts/:id
+func (h *ArtifactHandler) GetArtifact(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	artifact, err := h.artifactService.GetArtifact(id, userIDStr, isAdmin(c))
+	if err != nil {
+		respondArtifactError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, artifact)
+}
+
+// GetDownloadURL handles GET /api/v1/artifacts/:id/download-url
+func (h *ArtifactHandler) GetDownloadURL(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	download, err := h.artifactService.DownloadURL(id, userIDStr, isAdmin(c))
+	if err != nil {
+		respondArtifactError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, download)
+}
+
+// Download handles GET /api/v1/artifacts/download (public; authorized by the link signature)
+func (h *ArtifactHandler) Download(c *gin.Context) {
+	filename := c.Query("filename")
+
+	body, err := h.artifactService.OpenSigned(c.Request.Context(), c.Query("key"), filename, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		respondArtifactError(c, err)
+		return
+	}
+	defer body.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Header("Content-Type", contentType)
+
+	// Files of the local backend are seekable, so Range requests can resume interrupted downloads
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		var modTime time.Time
+		if stater, ok := body.(interface{ Stat() (os.FileInfo, error) }); ok {
+			if info, err := stater.Stat(); err == nil {
+				modTime = info.ModTime()
+			}
+		}
+		http.ServeContent(c.Writer, c.Request, filename, modTime, seeker)
+		return
+	}
+
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, body)
+}
+
+// DeleteArtifact handles DELETE /api/v1/artifacts/:id
+func (h *ArtifactHandler) DeleteArtifact(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	if err := h.artifactService.DeleteArtifact(id, userIDStr, isAdmin(c)); err != nil {
+		respondArtifactError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// respondArtifactError maps artifact service errors to HTTP status codes
+func respondArtifactError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrArtifactForbidden), errors.Is(err, services.ErrInvalidArtifactSignature):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "artifact not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "invalid artifact"), err.Error() == "artifact name is required":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -12,15 +16,17 @@ import (
 
 // AuthHandler handles authentication HTTP requests
 type AuthHandler struct {
-	authService *services.AuthService
-	logService  *services.UserLogService
+	authService     *services.AuthService
+	logService      *services.UserLogService
+	activityService *services.ActivityService
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(authService *services.AuthService, logService *services.UserLogService) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, logService *services.UserLogService, activityService *services.ActivityService) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		logService:  logService,
+		authService:     authService,
+		logService:      logService,
+		activityService: activityService,
 	}
 }
 
@@ -61,15 +67,121 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.Login(req.Username, req.Password)
+	response, err := h.authService.Login(req.Username, req.Password, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
+		var locked *services.LoginLockedError
+		var failed *services.LoginFailedError
+		switch {
+		case errors.As(err, &locked):
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		case errors.As(err, &failed) && failed.UserID != "" && h.logService != nil:
+			// Attempts on existing users go to the user logs; unknown usernames are not recorded
+			h.logService.LogOperation(c.Request.Context(), failed.UserID, "", "login_failed", models.UserSaveStatusError, failed.Detail())
+			if failed.LockedFor > 0 {
+				h.logService.LogOperation(c.Request.Context(), failed.UserID, "", "lockout", models.UserSaveStatusSuccess,
+					fmt.Sprintf("locked for %s after %d failed attempts", failed.LockedFor.Round(time.Second), failed.Attempts))
+			}
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Users with two-factor authentication are logged in once they send their code
+	if response.Token != "" {
+		h.activityService.RecordLogin(response.User.ID)
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
+// LoginTOTP handles POST /api/v1/auth/login/totp, the second step of a login with two-factor authentication
+func (h *AuthHandler) LoginTOTP(c *gin.Context) {
+	var req models.TOTPLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.authService.LoginWithTOTP(req.Challenge, req.Code, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		var locked *services.LoginLockedError
+		var failed *services.LoginFailedError
+		switch {
+		case errors.As(err, &locked):
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		case errors.As(err, &failed) && h.logService != nil:
+			h.logService.LogOperation(c.Request.Context(), failed.UserID, "", "login_failed", models.UserSaveStatusError, failed.Detail())
+			if failed.LockedFor > 0 {
+				h.logService.LogOperation(c.Request.Context(), failed.UserID, "", "lockout", models.UserSaveStatusSuccess,
+					fmt.Sprintf("locked for %s after %d failed attempts", failed.LockedFor.Round(time.Second), failed.Attempts))
+			}
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.activityService.RecordLogin(response.User.ID)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// StartTOTPEnrollment handles POST /api/v1/auth/me/totp; the returned secret is enabled by ConfirmTOTPEnrollment
+func (h *AuthHandler) StartTOTPEnrollment(c *gin.Context) {
+	enrollment, err := h.authService.StartTOTPEnrollment(c.GetString("userID"))
+	if err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTOTPEnrollment handles POST /api/v1/auth/me/totp/confirm
+func (h *AuthHandler) ConfirmTOTPEnrollment(c *gin.Context) {
+	var req models.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("userID")
+	if err := h.authService.ConfirmTOTPEnrollment(userID, req.Code); err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+
+	if h.logService != nil {
+		h.logService.LogOperation(c.Request.Context(), userID, userID, "totp_enable", models.UserSaveStatusSuccess, "")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled"})
+}
+
+// DisableTOTP handles DELETE /api/v1/auth/me/totp, confirmed with a current code
+func (h *AuthHandler) DisableTOTP(c *gin.Context) {
+	var req models.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("userID")
+	if err := h.authService.DisableTOTP(userID, req.Code); err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+
+	if h.logService != nil {
+		h.logService.LogOperation(c.Request.Context(), userID, userID, "totp_disable", models.UserSaveStatusSuccess, "")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
 // CreateUser handles POST /api/v1/users (admin only)
 func (h *AuthHandler) CreateUser(c *gin.Context) {
 	var req models.CreateUserRequest
@@ -89,7 +201,7 @@ func (h *AuthHandler) CreateUser(c *gin.Context) {
 	if err != nil {
 		// Log error
 		if h.logService != nil {
-			h.logService.LogOperation("", changedByID, "create", models.UserSaveStatusError, err.Error())
+			h.logService.LogOperation(c.Request.Context(), "", changedByID, "create", models.UserSaveStatusError, err.Error())
 		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -97,7 +209,7 @@ func (h *AuthHandler) CreateUser(c *gin.Context) {
 
 	// Log success
 	if h.logService != nil {
-		h.logService.LogOperation(user.ID, changedByID, "create", models.UserSaveStatusSuccess, "")
+		h.logService.LogOperation(c.Request.Context(), user.ID, changedByID, "create", models.UserSaveStatusSuccess, "")
 	}
 
 	c.JSON(http.StatusCreated, user)
@@ -105,13 +217,16 @@ func (h *AuthHandler) CreateUser(c *gin.Context) {
 
 // GetUsers handles GET /api/v1/users (admin only)
 func (h *AuthHandler) GetUsers(c *gin.Context) {
+	page := parsePage(c, 0)
+
 	users, err := h.authService.GetAllUsers()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	setPageHeaders(c, page, int64(len(users)))
+	c.JSON(http.StatusOK, services.PageSlice(users, page))
 }
 
 // DeleteUser handles DELETE /api/v1/users/:id (admin only)
@@ -128,7 +243,7 @@ func (h *AuthHandler) DeleteUser(c *gin.Context) {
 	if err := h.authService.DeleteUser(userID); err != nil {
 		// Log error
 		if h.logService != nil {
-			h.logService.LogOperation(userID, changedByIDStr, "delete", models.UserSaveStatusError, err.Error())
+			h.logService.LogOperation(c.Request.Context(), userID, changedByIDStr, "delete", models.UserSaveStatusError, err.Error())
 		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -136,7 +251,7 @@ func (h *AuthHandler) DeleteUser(c *gin.Context) {
 
 	// Log success
 	if h.logService != nil {
-		h.logService.LogOperation(userID, changedByIDStr, "delete", models.UserSaveStatusSuccess, "")
+		h.logService.LogOperation(c.Request.Context(), userID, changedByIDStr, "delete", models.UserSaveStatusSuccess, "")
 	}
 
 	c.JSON(http.StatusNoContent, nil)
@@ -161,6 +276,24 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 	})
 }
 
+// Logout handles POST /api/v1/auth/logout; the token of the request is revoked
+func (h *AuthHandler) Logout(c *gin.Context) {
+	tokenID := c.GetString("tokenID")
+	expiresAt := c.GetTime("tokenExpiresAt")
+	if tokenID == "" {
+		// Issued before tokens carried an ID; it expires on its own
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token cannot be revoked"})
+		return
+	}
+
+	if err := h.authService.RevokeToken(tokenID, expiresAt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
 // ChangePassword handles PUT /api/v1/users/:id/password (admin only)
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	userID := c.Param("id")
@@ -181,7 +314,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	if err := h.authService.ChangePassword(userID, req.NewPassword); err != nil {
 		// Log error
 		if h.logService != nil {
-			h.logService.LogOperation(userID, changedByIDStr, "change_password", models.UserSaveStatusError, err.Error())
+			h.logService.LogOperation(c.Request.Context(), userID, changedByIDStr, "change_password", models.UserSaveStatusError, err.Error())
 		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -189,7 +322,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 
 	// Log success
 	if h.logService != nil {
-		h.logService.LogOperation(userID, changedByIDStr, "change_password", models.UserSaveStatusSuccess, "")
+		h.logService.LogOperation(c.Request.Context(), userID, changedByIDStr, "change_password", models.UserSaveStatusSuccess, "")
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
@@ -220,7 +353,7 @@ func (h *AuthHandler) ToggleBlockUser(c *gin.Context) {
 	if err := h.authService.ToggleBlockUser(userID, req.IsBlocked); err != nil {
 		// Log error
 		if h.logService != nil {
-			h.logService.LogOperation(userID, changedByIDStr, operation, models.UserSaveStatusError, err.Error())
+			h.logService.LogOperation(c.Request.Context(), userID, changedByIDStr, operation, models.UserSaveStatusError, err.Error())
 		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -228,11 +361,68 @@ func (h *AuthHandler) ToggleBlockUser(c *gin.Context) {
 
 	// Log success
 	if h.logService != nil {
-		h.logService.LogOperation(userID, changedByIDStr, operation, models.UserSaveStatusSuccess, "")
+		h.logService.LogOperation(c.Request.Context(), userID, changedByIDStr, operation, models.UserSaveStatusSuccess, "")
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User status updated successfully"})
 }
+
+// UnlockUser handles DELETE /api/v1/users/:id/lockout (admin only)
+func (h *AuthHandler) UnlockUser(c *gin.Context) {
+	userID := c.Param("id")
+
+	// Get user ID from context
+	changedByID, _ := c.Get("userID")
+	changedByIDStr := ""
+	if changedByID != nil {
+		changedByIDStr = changedByID.(string)
+	}
+
+	if err := h.authService.UnlockUser(userID); err != nil {
+		if h.logService != nil {
+			h.logService.LogOperation(c.Request.Context(), userID, changedByIDStr, "unlock", models.UserSaveStatusError, err.Error())
+		}
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.logService != nil {
+		h.logService.LogOperation(c.Request.Context(), userID, changedByIDStr, "unlock", models.UserSaveStatusSuccess, "")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked successfully"})
+}
+
+// ResetUserTOTP handles DELETE /api/v1/users/:id/totp (admin only); the user sets it up again
+func (h *AuthHandler) ResetUserTOTP(c *gin.Context) {
+	userID := c.Param("id")
+
+	// Get user ID from context
+	changedByID, _ := c.Get("userID")
+	changedByIDStr := ""
+	if changedByID != nil {
+		changedByIDStr = changedByID.(string)
+	}
+
+	if err := h.authService.ResetTOTP(userID); err != nil {
+		if h.logService != nil {
+			h.logService.LogOperation(c.Request.Context(), userID, changedByIDStr, "totp_reset", models.UserSaveStatusError, err.Error())
+		}
+		respondTOTPError(c, err)
+		return
+	}
+
+	if h.logService != nil {
+		h.logService.LogOperation(c.Request.Context(), userID, changedByIDStr, "totp_reset", models.UserSaveStatusSuccess, "")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset successfully"})
+}
+
 // ChangeOwnPassword handles PUT /api/v1/auth/change-password (authenticated users)
 func (h *AuthHandler) ChangeOwnPassword(c *gin.Context) {
 	userID, _ := c.Get("userID")
@@ -253,29 +443,145 @@ func (h *AuthHandler) ChangeOwnPassword(c *gin.Context) {
 
 // GetUserLogs handles GET /api/v1/admin/users/logs
 func (h *AuthHandler) GetUserLogs(c *gin.Context) {
-	// Get optional limit and user_id query parameters
-	limit := 100 // default
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
-	}
+	page := parsePage(c, 100)
 
 	userID := c.Query("user_id")
 
 	var logs []models.UserSaveLog
+	var total int64
 	var err error
 
 	if userID != "" {
-		logs, err = h.logService.GetLogsByUser(userID, limit)
+		logs, total, err = h.logService.GetLogsByUser(userID, page)
 	} else {
-		logs, err = h.logService.GetLogs(limit)
+		logs, total, err = h.logService.GetLogs(page)
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": logs, "pagination": setPageHeaders(c, page, total)})
+}
+
+// ExportUserLogs handles GET /api/v1/users/logs/export?format=csv|json&user_id=...&changed_by_id=...&from=...&to=...&operation=...&status=... (admin only)
+func (h *AuthHandler) ExportUserLogs(c *gin.Context) {
+	filter, err := parseLogExportFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
+	header := []string{"id", "created_at", "user_id", "changed_by_id", "operation", "status", "error_message"}
+	writeLogExport(c, "user-logs", header,
+		func(entry *models.UserSaveLog) []string {
+			return []string{
+				strconv.Itoa(entry.ID), logTime(entry.CreatedAt), entry.UserID, entry.ChangedByID, entry.Operation,
+				string(entry.Status), entry.ErrorMessage,
+			}
+		},
+		func(fn func(*models.UserSaveLog) error) error {
+			return h.logService.ExportLogs(c.Query("changed_by_id"), filter, fn)
+		})
+}
+
+// GetUserSessions handles GET /api/v1/users/:id/sessions (admin only)
+func (h *AuthHandler) GetUserSessions(c *gin.Context) {
+	sessions, err := h.authService.GetUserSessions(c.Param("id"))
 	if err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"logs": logs})
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession handles DELETE /api/v1/sessions/:id (admin only); the user of the session is logged out
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	// Get user ID from context
+	changedByID, _ := c.Get("userID")
+	changedByIDStr := ""
+	if changedByID != nil {
+		changedByIDStr = changedByID.(string)
+	}
+
+	session, err := h.authService.RevokeSession(c.Param("id"))
+	if err != nil {
+		if err.Error() == "session not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.logService != nil {
+		h.logService.LogOperation(c.Request.Context(), session.UserID, changedByIDStr, "force_logout", models.UserSaveStatusSuccess,
+			fmt.Sprintf("session %s from %s", session.ID, session.ClientIP))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session ended successfully"})
+}
+
+// GetUserActivity handles GET /api/v1/users/:id/activity?days=30 (admin only)
+func (h *AuthHandler) GetUserActivity(c *gin.Context) {
+	h.respondActivity(c, c.Param("id"))
+}
+
+// GetMyActivity handles GET /api/v1/auth/me/activity?days=30
+func (h *AuthHandler) GetMyActivity(c *gin.Context) {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	h.respondActivity(c, userIDStr)
+}
+
+// respondActivity writes the activity summary of a user over the requested number of days
+func (h *AuthHandler) respondActivity(c *gin.Context, userID string) {
+	days := 30
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > 365 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+			return
+		}
+		days = parsed
+	}
+
+	summary, err := h.activityService.GetSummary(userID, days)
+	if err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// respondTOTPError maps two-factor authentication errors to HTTP status codes
+func respondTOTPError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "user not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "two-factor authentication is already enabled":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "invalid two-factor code", "no two-factor enrollment in progress", "two-factor authentication is not enabled":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case "two-factor authentication is required for your role":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
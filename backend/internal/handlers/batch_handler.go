@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"truadmin/internal/middleware"
+	"truadmin/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchRequests limits the number of sub-requests in one batch
+const maxBatchRequests = 20
+
+// batchPath is the path of the batch endpoint, which may not be nested
+const batchPath = "/api/v1/batch"
+
+// BatchHandler executes several read-only API calls in one round trip
+type BatchHandler struct {
+	engine http.Handler
+}
+
+// NewBatchHandler creates a new batch handler dispatching sub-requests to engine
+func NewBatchHandler(engine http.Handler) *BatchHandler {
+	return &BatchHandler{
+		engine: engine,
+	}
+}
+
+// Batch handles POST /api/v1/batch
+// Sub-requests are GET calls run concurrently with the caller's credentials, a JWT or an API key.
+func (h *BatchHandler) Batch(c *gin.Context) {
+	var req models.BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Requests) > maxBatchRequests {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("a batch may contain at most %d requests", maxBatchRequests)})
+		return
+	}
+	for _, sub := range req.Requests {
+		if !strings.HasPrefix(sub.Path, "/api/v1/") || strings.HasPrefix(sub.Path, batchPath) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid path for request %s: %s", sub.ID, sub.Path)})
+			return
+		}
+	}
+
+	response := models.BatchResponse{
+		Responses: make([]models.BatchSubResponse, len(req.Requests)),
+	}
+
+	var wg sync.WaitGroup
+	for i, sub := range req.Requests {
+		wg.Add(1)
+		go func(i int, sub models.BatchSubRequest) {
+			defer wg.Done()
+			response.Responses[i] = h.execute(c.Request, sub)
+		}(i, sub)
+	}
+	wg.Wait()
+
+	c.JSON(http.StatusOK, response)
+}
+
+// execute runs one sub-request through the router, forwarding the caller's credentials so that it is
+// authenticated, scoped and rate limited as a direct call would be
+func (h *BatchHandler) execute(parent *http.Request, sub models.BatchSubRequest) models.BatchSubResponse {
+	result := models.BatchSubResponse{ID: sub.ID}
+
+	subReq, err := http.NewRequestWithContext(parent.Context(), http.MethodGet, sub.Path, nil)
+	if err != nil {
+		result.Status = http.StatusBadRequest
+		result.Body = errorBody(err.Error())
+		return result
+	}
+	for _, header := range []string{"Authorization", middleware.APIKeyHeader} {
+		if value := parent.Header.Get(header); value != "" {
+			subReq.Header.Set(header, value)
+		}
+	}
+	subReq.Header.Set("Accept", "application/json")
+	subReq.RemoteAddr = parent.RemoteAddr
+
+	recorder := httptest.NewRecorder()
+	h.engine.ServeHTTP(recorder, subReq)
+
+	result.Status = recorder.Code
+	body := recorder.Body.Bytes()
+	switch {
+	case len(body) == 0:
+		result.Body = json.RawMessage("null")
+	case json.Valid(body):
+		result.Body = json.RawMessage(body)
+	default:
+		encoded, _ := json.Marshal(string(body))
+		result.Body = json.RawMessage(encoded)
+	}
+	return result
+}
+
+// errorBody encodes an error message the way handlers return errors
+func errorBody(message string) json.RawMessage {
+	encoded, _ := json.Marshal(gin.H{"error": message})
+	return json.RawMessage(encoded)
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BulkHandler handles HTTP requests for throttled bulk operations
+type BulkHandler struct {
+	bulkRunService *services.BulkRunService
+}
+
+// NewBulkHandler creates a new bulk operation handler
+func NewBulkHandler(bulkRunService *services.BulkRunService) *BulkHandler {
+	return &BulkHandler{
+		bulkRunService: bulkRunService,
+	}
+}
+
+// BulkGrant handles POST /api/v1/connections/:id/roles/bulk-grant
+func (h *BulkHandler) BulkGrant(c *gin.Context) {
+	var req models.BulkGrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	run := h.bulkRunService.StartBulkGrant(c.Param("id"), userIDStr, &req)
+	c.JSON(http.StatusAccepted, run)
+}
+
+// BulkAlterRoles handles POST /api/v1/connections/:id/roles/bulk-alter
+// With dry_run the planned ALTER ROLE statements are returned; otherwise a run executes them.
+func (h *BulkHandler) BulkAlterRoles(c *gin.Context) {
+	var req models.BulkRoleAlterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.DryRun {
+		preview, err := h.bulkRunService.PreviewRoleAlter(c.Request.Context(), c.Param("id"), &req)
+		if err != nil {
+			respondRoleAlterError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, preview)
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	run, err := h.bulkRunService.StartRoleAlter(c.Request.Context(), c.Param("id"), userIDStr, &req)
+	if err != nil {
+		respondRoleAlterError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, run)
+}
+
+// respondRoleAlterError maps bulk role change errors to HTTP status codes
+func respondRoleAlterError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRoleAlter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "connection not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// CheckAddresses handles POST /api/v1/hohaddress/databases/:id/check-addresses
+func (h *BulkHandler) CheckAddresses(c *gin.Context) {
+	var req models.BulkAddressCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	run, err := h.bulkRunService.StartAddressChecks(c.Param("id"), userIDStr, &req)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, run)
+}
+
+// RunScript handles POST /api/v1/connections/:id/script-runs
+func (h *BulkHandler) RunScript(c *gin.Context) {
+	var req models.ScriptRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	run := h.bulkRunService.StartScriptRun(c.Param("id"), userIDStr, &req)
+	c.JSON(http.StatusAccepted, run)
+}
+
+// GetRuns handles GET /api/v1/bulk-runs (admins see every run)
+func (h *BulkHandler) GetRuns(c *gin.Context) {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": h.bulkRunService.GetRuns(userIDStr, isAdmin(c))})
+}
+
+// GetRun handles GET /api/v1/bulk-runs/:id
+func (h *BulkHandler) GetRun(c *gin.Context) {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	run, err := h.bulkRunService.GetRun(c.Param("id"), userIDStr, isAdmin(c))
+	if err != nil {
+		respondBulkRunError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// CancelRun handles POST /api/v1/bulk-runs/:id/cancel
+func (h *BulkHandler) CancelRun(c *gin.Context) {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	run, err := h.bulkRunService.CancelRun(c.Param("id"), userIDStr, isAdmin(c))
+	if err != nil {
+		respondBulkRunError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// respondBulkRunError maps bulk run service errors to HTTP status codes
+func respondBulkRunError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrBulkRunForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "bulk run not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CapacityHandler handles HTTP requests for capacity snapshots and the overview across all connections
+type CapacityHandler struct {
+	capacityService *services.CapacityService
+	overviewService *services.OverviewService
+}
+
+// NewCapacityHandler creates a new capacity handler
+func NewCapacityHandler(capacityService *services.CapacityService, overviewService *services.OverviewService) *CapacityHandler {
+	return &CapacityHandler{
+		capacityService: capacityService,
+		overviewService: overviewService,
+	}
+}
+
+// GetSnapshot handles GET /api/v1/capacity
+func (h *CapacityHandler) GetSnapshot(c *gin.Context) {
+	snapshot, err := h.capacityService.GetSnapshot(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// GetOverview handles GET /api/v1/overview?refresh=true; without refresh a recently collected overview is returned
+func (h *CapacityHandler) GetOverview(c *gin.Context) {
+	overview, err := h.overviewService.GetOverview(c.GetString("userID"), c.Query("refresh") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
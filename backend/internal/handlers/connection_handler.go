@@ -11,15 +11,19 @@ import (
 
 // ConnectionHandler handles HTTP requests for database connections
 type ConnectionHandler struct {
-	connectionService *services.ConnectionService
-	logService        *services.ConnectionLogService
+	connectionService  *services.ConnectionService
+	logService         *services.ConnectionLogService
+	healthService      *services.ConnectionHealthService
+	accessGrantService *services.AccessGrantService
 }
 
 // NewConnectionHandler creates a new connection handler
-func NewConnectionHandler(connService *services.ConnectionService, logService *services.ConnectionLogService) *ConnectionHandler {
+func NewConnectionHandler(connService *services.ConnectionService, logService *services.ConnectionLogService, healthService *services.ConnectionHealthService, accessGrantService *services.AccessGrantService) *ConnectionHandler {
 	return &ConnectionHandler{
-		connectionService: connService,
-		logService:        logService,
+		connectionService:  connService,
+		logService:         logService,
+		healthService:      healthService,
+		accessGrantService: accessGrantService,
 	}
 }
 
@@ -38,14 +42,14 @@ func (h *ConnectionHandler) CreateConnection(c *gin.Context) {
 		userIDStr = userID.(string)
 	}
 
-	conn, err := h.connectionService.CreateConnection(&req)
+	conn, err := h.connectionService.CreateConnection(&req, userIDStr)
 	if err != nil {
 		// Log error
 		if h.logService != nil {
 			changesSummary := models.ConnectionChangesSummary{
 				Created: 1,
 			}
-			h.logService.LogOperation("", userIDStr, "create", models.ConnectionSaveStatusError, changesSummary, err.Error())
+			h.logService.LogOperation(c.Request.Context(), "", userIDStr, "create", models.ConnectionSaveStatusError, changesSummary, err.Error())
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -56,21 +60,62 @@ func (h *ConnectionHandler) CreateConnection(c *gin.Context) {
 		changesSummary := models.ConnectionChangesSummary{
 			Created: 1,
 		}
-		h.logService.LogOperation(conn.ID, userIDStr, "create", models.ConnectionSaveStatusSuccess, changesSummary, "")
+		h.logService.LogOperation(c.Request.Context(), conn.ID, userIDStr, "create", models.ConnectionSaveStatusSuccess, changesSummary, "")
 	}
 
-	c.JSON(http.StatusCreated, conn)
+	c.JSON(http.StatusCreated, conn.Masked())
 }
 
-// GetConnections handles GET /api/v1/connections
+// ParseDSN handles POST /api/v1/connections/parse-dsn. It returns the connection fields found in a
+// connection URL, with the password masked, so clients can prefill the connection form.
+func (h *ConnectionHandler) ParseDSN(c *gin.Context) {
+	var req models.ParseDSNRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	parsed, err := services.ParseDSN(req.DSN)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if parsed.HasPassword {
+		parsed.Password = models.MaskedValue
+	}
+
+	c.JSON(http.StatusOK, parsed)
+}
+
+// GetConnections handles GET /api/v1/connections. Non-admins only see the connections they may read:
+// those requiring an access grant are left out unless the user holds an active one.
 func (h *ConnectionHandler) GetConnections(c *gin.Context) {
-	connections, err := h.connectionService.GetAllConnections()
+	page := parsePage(c, 0)
+
+	all, err := h.connectionService.GetAllConnections()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, connections)
+	admin := isAdmin(c)
+	connections := make([]*models.Connection, 0, len(all))
+	for _, conn := range all {
+		if !admin {
+			allowed, err := h.accessGrantService.CanReadConnection(c.GetString("userID"), conn.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if !allowed {
+				continue
+			}
+		}
+		connections = append(connections, conn.Masked())
+	}
+
+	setPageHeaders(c, page, int64(len(connections)))
+	c.JSON(http.StatusOK, services.PageSlice(connections, page))
 }
 
 // GetConnection handles GET /api/v1/connections/:id
@@ -83,7 +128,7 @@ func (h *ConnectionHandler) GetConnection(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, conn)
+	c.JSON(http.StatusOK, conn.Masked())
 }
 
 // DeleteConnection handles DELETE /api/v1/connections/:id
@@ -97,13 +142,13 @@ func (h *ConnectionHandler) DeleteConnection(c *gin.Context) {
 		userIDStr = userID.(string)
 	}
 
-	if err := h.connectionService.DeleteConnection(id); err != nil {
+	if err := h.connectionService.DeleteConnection(id, userIDStr); err != nil {
 		// Log error
 		if h.logService != nil {
 			changesSummary := models.ConnectionChangesSummary{
 				Deleted: 1,
 			}
-			h.logService.LogOperation(id, userIDStr, "delete", models.ConnectionSaveStatusError, changesSummary, err.Error())
+			h.logService.LogOperation(c.Request.Context(), id, userIDStr, "delete", models.ConnectionSaveStatusError, changesSummary, err.Error())
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -114,7 +159,7 @@ func (h *ConnectionHandler) DeleteConnection(c *gin.Context) {
 		changesSummary := models.ConnectionChangesSummary{
 			Deleted: 1,
 		}
-		h.logService.LogOperation(id, userIDStr, "delete", models.ConnectionSaveStatusSuccess, changesSummary, "")
+		h.logService.LogOperation(c.Request.Context(), id, userIDStr, "delete", models.ConnectionSaveStatusSuccess, changesSummary, "")
 	}
 
 	c.JSON(http.StatusNoContent, nil)
@@ -137,14 +182,22 @@ func (h *ConnectionHandler) UpdateConnection(c *gin.Context) {
 		userIDStr = userID.(string)
 	}
 
-	conn, err := h.connectionService.UpdateConnection(id, &req)
+	// Only admins may change whether a connection requires an access grant
+	if !isAdmin(c) {
+		if current, err := h.connectionService.GetConnection(id); err == nil && current.RequiresGrant != req.RequiresGrant {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only admins can change requires_grant"})
+			return
+		}
+	}
+
+	conn, err := h.connectionService.UpdateConnection(id, &req, userIDStr)
 	if err != nil {
 		// Log error
 		if h.logService != nil {
 			changesSummary := models.ConnectionChangesSummary{
 				Updated: 1,
 			}
-			h.logService.LogOperation(id, userIDStr, "update", models.ConnectionSaveStatusError, changesSummary, err.Error())
+			h.logService.LogOperation(c.Request.Context(), id, userIDStr, "update", models.ConnectionSaveStatusError, changesSummary, err.Error())
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -155,37 +208,163 @@ func (h *ConnectionHandler) UpdateConnection(c *gin.Context) {
 		changesSummary := models.ConnectionChangesSummary{
 			Updated: 1,
 		}
-		h.logService.LogOperation(id, userIDStr, "update", models.ConnectionSaveStatusSuccess, changesSummary, "")
+		h.logService.LogOperation(c.Request.Context(), id, userIDStr, "update", models.ConnectionSaveStatusSuccess, changesSummary, "")
 	}
 
-	c.JSON(http.StatusOK, conn)
+	c.JSON(http.StatusOK, conn.Masked())
 }
 
 // GetLogs handles GET /api/v1/connections/logs
 func (h *ConnectionHandler) GetLogs(c *gin.Context) {
-	// Get optional limit and connection_id query parameters
-	limit := 100 // default
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
-	}
+	page := parsePage(c, 100)
 
 	connectionID := c.Query("connection_id")
 
 	var logs []models.ConnectionSaveLog
+	var total int64
 	var err error
 
 	if connectionID != "" {
-		logs, err = h.logService.GetLogsByConnection(connectionID, limit)
+		logs, total, err = h.logService.GetLogsByConnection(connectionID, page)
 	} else {
-		logs, err = h.logService.GetLogs(limit)
+		logs, total, err = h.logService.GetLogs(page)
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": logs, "pagination": setPageHeaders(c, page, total)})
+}
+
+// ExportLogs handles GET /api/v1/connections/logs/export?format=csv|json&connection_id=...&from=...&to=...&user_id=...&operation=...&status=...
+func (h *ConnectionHandler) ExportLogs(c *gin.Context) {
+	filter, err := parseLogExportFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	header := []string{"id", "created_at", "connection_id", "user_id", "operation", "status", "created", "updated", "deleted", "error_message"}
+	writeLogExport(c, "connection-logs", header,
+		func(entry *models.ConnectionSaveLog) []string {
+			return []string{
+				strconv.Itoa(entry.ID), logTime(entry.CreatedAt), entry.ConnectionID, entry.UserID, entry.Operation, string(entry.Status),
+				strconv.Itoa(entry.ChangesSummary.Created), strconv.Itoa(entry.ChangesSummary.Updated), strconv.Itoa(entry.ChangesSummary.Deleted),
+				entry.ErrorMessage,
+			}
+		},
+		func(fn func(*models.ConnectionSaveLog) error) error {
+			return h.logService.ExportLogs(c.Query("connection_id"), filter, fn)
+		})
+}
+
+// GetStaleConnections handles GET /api/v1/connections/stale?days=90
+func (h *ConnectionHandler) GetStaleConnections(c *gin.Context) {
+	days := 90
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > 3650 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 3650"})
+			return
+		}
+		days = parsed
+	}
+
+	connections, err := h.connectionService.GetStaleConnections(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"days": days, "connections": connections})
+}
+
+// GetHealth handles GET /api/v1/connections/health
+func (h *ConnectionHandler) GetHealth(c *gin.Context) {
+	health, err := h.healthService.GetHealth()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"connections": health})
+}
+
+// GetHealthHistory handles GET /api/v1/connections/:id/health?from=...&to=...
+func (h *ConnectionHandler) GetHealthHistory(c *gin.Context) {
+	page := parsePage(c, 100)
+
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	checks, total, err := h.healthService.GetHistory(c.Param("id"), from, to, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"checks": checks, "pagination": setPageHeaders(c, page, total)})
+}
+
+// GetRevisions handles GET /api/v1/connections/:id/revisions
+func (h *ConnectionHandler) GetRevisions(c *gin.Context) {
+	id := c.Param("id")
+
+	revisions, err := h.connectionService.GetRevisions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// RestoreRevision handles POST /api/v1/connections/:id/revisions/:revision/restore
+func (h *ConnectionHandler) RestoreRevision(c *gin.Context) {
+	id := c.Param("id")
+
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision"})
+		return
 	}
 
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	conn, err := h.connectionService.RestoreRevision(id, revision, userIDStr)
 	if err != nil {
+		// Log error
+		if h.logService != nil {
+			changesSummary := models.ConnectionChangesSummary{
+				Updated: 1,
+			}
+			h.logService.LogOperation(c.Request.Context(), id, userIDStr, "restore", models.ConnectionSaveStatusError, changesSummary, err.Error())
+		}
+		if err.Error() == "revision not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"logs": logs})
+	// Log success
+	if h.logService != nil {
+		changesSummary := models.ConnectionChangesSummary{
+			Updated: 1,
+		}
+		h.logService.LogOperation(c.Request.Context(), id, userIDStr, "restore", models.ConnectionSaveStatusSuccess, changesSummary, "")
+	}
+
+	c.JSON(http.StatusOK, conn.Masked())
 }
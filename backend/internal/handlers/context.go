@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"truadmin/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isAdmin reports whether the authenticated user has the admin role
+func isAdmin(c *gin.Context) bool {
+	role, exists := c.Get("role")
+	return exists && role == models.RoleAdmin
+}
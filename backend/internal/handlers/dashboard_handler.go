@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DashboardHandler handles HTTP requests for pinned monitoring dashboards
+type DashboardHandler struct {
+	dashboardService *services.DashboardService
+}
+
+// NewDashboardHandler creates a new dashboard handler
+func NewDashboardHandler(dashboardService *services.DashboardService) *DashboardHandler {
+	return &DashboardHandler{
+		dashboardService: dashboardService,
+	}
+}
+
+// CreateDashboard handles POST /api/v1/dashboards
+func (h *DashboardHandler) CreateDashboard(c *gin.Context) {
+	var req models.DashboardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	dashboard, err := h.dashboardService.CreateDashboard(&req, userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dashboard)
+}
+
+// GetDashboards handles GET /api/v1/dashboards?connection_id=...
+func (h *DashboardHandler) GetDashboards(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	dashboards, err := h.dashboardService.GetDashboards(userIDStr, c.Query("connection_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dashboards)
+}
+
+// GetDashboard handles GET /api/v1/dashboards/:id
+func (h *DashboardHandler) GetDashboard(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	dashboard, err := h.dashboardService.GetDashboard(id, userIDStr, isAdmin(c))
+	if err != nil {
+		respondDashboardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dashboard)
+}
+
+// UpdateDashboard handles PUT /api/v1/dashboards/:id
+func (h *DashboardHandler) UpdateDashboard(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.DashboardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	dashboard, err := h.dashboardService.UpdateDashboard(id, userIDStr, isAdmin(c), &req)
+	if err != nil {
+		respondDashboardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dashboard)
+}
+
+// DeleteDashboard handles DELETE /api/v1/dashboards/:id
+func (h *DashboardHandler) DeleteDashboard(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	if err := h.dashboardService.DeleteDashboard(id, userIDStr, isAdmin(c)); err != nil {
+		respondDashboardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetDashboardData handles GET /api/v1/dashboards/:id/data
+func (h *DashboardHandler) GetDashboardData(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	data, err := h.dashboardService.GetDashboardData(id, userIDStr, isAdmin(c))
+	if err != nil {
+		respondDashboardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+// respondDashboardError maps dashboard service errors to HTTP status codes
+func respondDashboardError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrDashboardForbidden), errors.Is(err, services.ErrAccessGrantRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "dashboard not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DataDictionaryHandler handles HTTP requests for generated data dictionaries
+type DataDictionaryHandler struct {
+	dataDictionaryService *services.DataDictionaryService
+}
+
+// NewDataDictionaryHandler creates a new data dictionary handler
+func NewDataDictionaryHandler(dataDictionaryService *services.DataDictionaryService) *DataDictionaryHandler {
+	return &DataDictionaryHandler{
+		dataDictionaryService: dataDictionaryService,
+	}
+}
+
+// GetDataDictionary handles GET /api/v1/connections/:id/databases/:dbName/data-dictionary
+// (optional ?schemas=a,b); returns the dictionary as JSON without storing an artifact
+func (h *DataDictionaryHandler) GetDataDictionary(c *gin.Context) {
+	var schemas []string
+	if param := c.Query("schemas"); param != "" {
+		for _, schema := range strings.Split(param, ",") {
+			if schema = strings.TrimSpace(schema); schema != "" {
+				schemas = append(schemas, schema)
+			}
+		}
+	}
+
+	dictionary, err := h.dataDictionaryService.BuildDictionary(c.Param("id"), c.Param("dbName"), schemas)
+	if err != nil {
+		respondDataDictionaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dictionary)
+}
+
+// GenerateDataDictionary handles POST /api/v1/connections/:id/databases/:dbName/data-dictionary
+func (h *DataDictionaryHandler) GenerateDataDictionary(c *gin.Context) {
+	var req models.DataDictionaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	artifact, err := h.dataDictionaryService.GenerateArtifact(c.Param("id"), c.Param("dbName"), userIDStr, &req)
+	if err != nil {
+		respondDataDictionaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, artifact)
+}
+
+// CreateSchedule handles POST /api/v1/data-dictionary/schedules
+func (h *DataDictionaryHandler) CreateSchedule(c *gin.Context) {
+	var req models.DataDictionaryScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	schedule, err := h.dataDictionaryService.CreateSchedule(userIDStr, &req)
+	if err != nil {
+		respondDataDictionaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// GetSchedules handles GET /api/v1/data-dictionary/schedules
+func (h *DataDictionaryHandler) GetSchedules(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	schedules, err := h.dataDictionaryService.GetSchedules(userIDStr, isAdmin(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// DeleteSchedule handles DELETE /api/v1/data-dictionary/schedules/:id
+func (h *DataDictionaryHandler) DeleteSchedule(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	if err := h.dataDictionaryService.DeleteSchedule(c.Param("id"), userIDStr, isAdmin(c)); err != nil {
+		respondDataDictionaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// respondDataDictionaryError maps data dictionary errors to HTTP status codes
+func respondDataDictionaryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidDataDictionary), errors.Is(err, services.ErrUnsupportedDialect):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAccessGrantRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "schedule not found", err.Error() == "connection not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,25 +1,39 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 	"truadmin/internal/models"
 	"truadmin/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 // DatabaseHandler handles HTTP requests for database operations
 type DatabaseHandler struct {
 	databaseService *services.DatabaseService
 	logService      *services.RoleLogService
+	terminationLogs *services.TerminationLogService
+	rowLogs         *services.TableRowLogService
+	history         *services.SQLHistoryService
 }
 
 // NewDatabaseHandler creates a new database handler
-func NewDatabaseHandler(dbService *services.DatabaseService, logService *services.RoleLogService) *DatabaseHandler {
+func NewDatabaseHandler(dbService *services.DatabaseService, logService *services.RoleLogService, terminationLogs *services.TerminationLogService, rowLogs *services.TableRowLogService, history *services.SQLHistoryService) *DatabaseHandler {
 	return &DatabaseHandler{
 		databaseService: dbService,
 		logService:      logService,
+		terminationLogs: terminationLogs,
+		rowLogs:         rowLogs,
+		history:         history,
 	}
 }
 
@@ -27,7 +41,7 @@ func NewDatabaseHandler(dbService *services.DatabaseService, logService *service
 func (h *DatabaseHandler) GetDatabases(c *gin.Context) {
 	connectionID := c.Param("id")
 
-	databases, err := h.databaseService.GetDatabases(connectionID)
+	databases, err := h.databaseService.GetDatabases(c.Request.Context(), connectionID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -40,13 +54,16 @@ func (h *DatabaseHandler) GetDatabases(c *gin.Context) {
 func (h *DatabaseHandler) GetRoles(c *gin.Context) {
 	connectionID := c.Param("id")
 
-	roles, err := h.databaseService.GetRoles(connectionID)
+	page := parsePage(c, 0)
+
+	roles, err := h.databaseService.GetRoles(c.Request.Context(), connectionID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, roles)
+	setPageHeaders(c, page, int64(len(roles)))
+	c.JSON(http.StatusOK, services.PageSlice(roles, page))
 }
 
 // GetRole handles GET /api/v1/connections/:id/roles/:roleId
@@ -54,7 +71,7 @@ func (h *DatabaseHandler) GetRole(c *gin.Context) {
 	connectionID := c.Param("id")
 	roleID := c.Param("roleId")
 
-	role, err := h.databaseService.GetRole(connectionID, roleID)
+	role, err := h.databaseService.GetRole(c.Request.Context(), connectionID, roleID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -84,7 +101,7 @@ func (h *DatabaseHandler) CreateRole(c *gin.Context) {
 	if err != nil {
 		// Log error
 		if h.logService != nil {
-			h.logService.LogOperation(connectionID, "", userIDStr, "create", models.RoleSaveStatusError, err.Error())
+			h.logService.LogOperation(c.Request.Context(), connectionID, "", userIDStr, "create", models.RoleSaveStatusError, err.Error())
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -92,7 +109,7 @@ func (h *DatabaseHandler) CreateRole(c *gin.Context) {
 
 	// Log success
 	if h.logService != nil {
-		h.logService.LogOperation(connectionID, role.ID, userIDStr, "create", models.RoleSaveStatusSuccess, "")
+		h.logService.LogOperation(c.Request.Context(), connectionID, role.ID, userIDStr, "create", models.RoleSaveStatusSuccess, "")
 	}
 
 	c.JSON(http.StatusCreated, role)
@@ -120,7 +137,7 @@ func (h *DatabaseHandler) UpdateRole(c *gin.Context) {
 	if err != nil {
 		// Log error
 		if h.logService != nil {
-			h.logService.LogOperation(connectionID, roleID, userIDStr, "update", models.RoleSaveStatusError, err.Error())
+			h.logService.LogOperation(c.Request.Context(), connectionID, roleID, userIDStr, "update", models.RoleSaveStatusError, err.Error())
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -128,7 +145,7 @@ func (h *DatabaseHandler) UpdateRole(c *gin.Context) {
 
 	// Log success
 	if h.logService != nil {
-		h.logService.LogOperation(connectionID, roleID, userIDStr, "update", models.RoleSaveStatusSuccess, "")
+		h.logService.LogOperation(c.Request.Context(), connectionID, roleID, userIDStr, "update", models.RoleSaveStatusSuccess, "")
 	}
 
 	c.JSON(http.StatusOK, role)
@@ -149,7 +166,7 @@ func (h *DatabaseHandler) DeleteRole(c *gin.Context) {
 	if err := h.databaseService.DeleteRole(connectionID, roleID); err != nil {
 		// Log error
 		if h.logService != nil {
-			h.logService.LogOperation(connectionID, roleID, userIDStr, "delete", models.RoleSaveStatusError, err.Error())
+			h.logService.LogOperation(c.Request.Context(), connectionID, roleID, userIDStr, "delete", models.RoleSaveStatusError, err.Error())
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -157,7 +174,7 @@ func (h *DatabaseHandler) DeleteRole(c *gin.Context) {
 
 	// Log success
 	if h.logService != nil {
-		h.logService.LogOperation(connectionID, roleID, userIDStr, "delete", models.RoleSaveStatusSuccess, "")
+		h.logService.LogOperation(c.Request.Context(), connectionID, roleID, userIDStr, "delete", models.RoleSaveStatusSuccess, "")
 	}
 
 	c.JSON(http.StatusNoContent, nil)
@@ -169,7 +186,7 @@ func (h *DatabaseHandler) GetActiveQueries(c *gin.Context) {
 	dbName := c.Param("dbName")
 	onlyActive := c.DefaultQuery("only_active", "true") == "true"
 
-	queries, err := h.databaseService.GetActiveQueries(connectionID, dbName, onlyActive)
+	queries, err := h.databaseService.GetActiveQueries(c.Request.Context(), connectionID, dbName, onlyActive)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -183,7 +200,7 @@ func (h *DatabaseHandler) GetDeadlocks(c *gin.Context) {
 	connectionID := c.Param("id")
 	dbName := c.Param("dbName")
 
-	deadlocks, err := h.databaseService.GetDeadlocks(connectionID, dbName)
+	deadlocks, err := h.databaseService.GetDeadlocks(c.Request.Context(), connectionID, dbName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -198,7 +215,7 @@ func (h *DatabaseHandler) GetLocks(c *gin.Context) {
 	dbName := c.Param("dbName")
 	showSystem := c.Query("show_system") == "true"
 
-	locks, err := h.databaseService.GetLocks(connectionID, dbName, showSystem)
+	locks, err := h.databaseService.GetLocks(c.Request.Context(), connectionID, dbName, showSystem)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -212,16 +229,33 @@ func (h *DatabaseHandler) TerminateQueries(c *gin.Context) {
 	connectionID := c.Param("id")
 	dbName := c.Param("dbName")
 
-	var req struct {
-		PIDs []string `json:"pids" binding:"required"`
-	}
-
+	var req models.TerminateQueriesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	terminated, err := h.databaseService.TerminateQueries(connectionID, dbName, req.PIDs)
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	entries, err := h.databaseService.TerminateQueries(c.Request.Context(), connectionID, dbName, req.PIDs)
+
+	terminated := 0
+	for _, entry := range entries {
+		entry.UserID = userIDStr
+		entry.Reason = req.Reason
+		if entry.Terminated {
+			terminated++
+		}
+		if h.terminationLogs != nil {
+			h.terminationLogs.LogTermination(entry)
+		}
+	}
+
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -235,7 +269,7 @@ func (h *DatabaseHandler) GetQueryHistory(c *gin.Context) {
 	connectionID := c.Param("id")
 	dbName := c.Param("dbName")
 
-	statements, err := h.databaseService.GetQueryHistory(connectionID, dbName)
+	statements, err := h.databaseService.GetQueryHistory(c.Request.Context(), connectionID, dbName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -245,6 +279,7 @@ func (h *DatabaseHandler) GetQueryHistory(c *gin.Context) {
 }
 
 // ExecuteQuery handles POST /api/v1/connections/:id/databases/:dbName/query
+// (?format=ndjson streams the result instead of returning it in one response)
 func (h *DatabaseHandler) ExecuteQuery(c *gin.Context) {
 	connectionID := c.Param("id")
 	dbName := c.Param("dbName")
@@ -255,8 +290,175 @@ func (h *DatabaseHandler) ExecuteQuery(c *gin.Context) {
 		return
 	}
 
-	result, err := h.databaseService.ExecuteQuery(connectionID, dbName, req.Query)
+	paginated := req.PageSize > 0 || req.Cursor != ""
+	if c.Query("format") == "ndjson" && (req.Sandbox || paginated) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "streaming cannot be combined with sandbox or pagination"})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	ctx, done, err := h.databaseService.TrackQuery(c.Request.Context(), connectionID, userIDStr, &req)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	defer done()
+
+	started := time.Now()
+	if c.Query("format") == "ndjson" {
+		summary := h.streamQuery(c, ctx, connectionID, dbName, req.Query)
+		if summary != nil {
+			recordSQLHistory(h.history, c, connectionID, dbName, &req, started, summary.RowCount, summary.Error)
+		}
+		return
+	}
+
+	var result *models.QueryResult
+	switch {
+	case req.Sandbox:
+		result, err = h.databaseService.ExecuteSandboxQuery(ctx, connectionID, dbName, req.Query)
+	case paginated:
+		result, err = h.databaseService.ExecuteQueryPage(ctx, connectionID, dbName, &req)
+	default:
+		result, err = h.databaseService.ExecuteQuery(ctx, connectionID, dbName, req.Query)
+	}
+	recordQueryResult(h.history, c, connectionID, dbName, &req, started, result, err)
+	if err != nil {
+		if errors.Is(err, services.ErrQueryNotPageable) || errors.Is(err, services.ErrInvalidQueryCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExecuteQueryAsRole handles POST /api/v1/connections/:id/databases/:dbName/query-as-role
+func (h *DatabaseHandler) ExecuteQueryAsRole(c *gin.Context) {
+	connectionID := c.Param("id")
+	dbName := c.Param("dbName")
+	var req models.QueryAsRoleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	queryReq := &models.QueryRequest{Query: req.Query, QueryID: req.QueryID, TimeoutMs: req.TimeoutMs}
+	ctx, done, err := h.databaseService.TrackQuery(c.Request.Context(), connectionID, userIDStr, queryReq)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	defer done()
+
+	started := time.Now()
+	result, err := h.databaseService.ExecuteQueryAsRole(ctx, connectionID, dbName, req.Role, req.Query)
+	recordQueryResult(h.history, c, connectionID, dbName, queryReq, started, result, err)
+	if err != nil {
+		if errors.Is(err, services.ErrRunAsRoleFailed) || errors.Is(err, services.ErrUnsupportedDialect) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// streamQuery writes a query result as newline-delimited JSON: a {"columns": [...]} line, one
+// {"row": {...}} line per row and a final {"summary": {...}} line with the row count and any error.
+// It returns the summary, or nil when the query could not be started.
+func (h *DatabaseHandler) streamQuery(c *gin.Context, ctx context.Context, connectionID, dbName, query string) *models.QueryStreamSummary {
+	encoder := json.NewEncoder(c.Writer)
+	rows := 0
+
+	summary, err := h.databaseService.StreamQuery(ctx, connectionID, dbName, query,
+		func(columns []string) error {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(http.StatusOK)
+			return encoder.Encode(gin.H{"columns": columns})
+		},
+		func(row map[string]any) error {
+			if err := encoder.Encode(gin.H{"row": row}); err != nil {
+				return err
+			}
+			// Flush regularly so clients see progress and the response is not buffered in full
+			if rows++; rows%100 == 0 {
+				c.Writer.Flush()
+			}
+			return nil
+		})
+	if err != nil {
+		if !c.Writer.Written() {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return nil
+	}
+
+	if !c.Writer.Written() {
+		// The query failed before returning columns
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+	}
+	encoder.Encode(gin.H{"summary": summary})
+	c.Writer.Flush()
+	return summary
+}
+
+// ProbeDDL handles POST /api/v1/connections/:id/databases/:dbName/ddl-probe
+func (h *DatabaseHandler) ProbeDDL(c *gin.Context) {
+	connectionID := c.Param("id")
+	dbName := c.Param("dbName")
+	var req models.DDLProbeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.databaseService.ProbeDDL(c.Request.Context(), connectionID, dbName, req.Statement)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Explain handles POST /api/v1/connections/:id/databases/:dbName/explain
+func (h *DatabaseHandler) Explain(c *gin.Context) {
+	connectionID := c.Param("id")
+	dbName := c.Param("dbName")
+	var req models.ExplainRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.databaseService.Explain(c.Request.Context(), connectionID, dbName, &req)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidExplain) || errors.Is(err, services.ErrUnsupportedDialect) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -269,7 +471,7 @@ func (h *DatabaseHandler) GetDetailedRole(c *gin.Context) {
 	connectionID := c.Param("id")
 	roleID := c.Param("roleId")
 
-	role, err := h.databaseService.GetDetailedRole(connectionID, roleID)
+	role, err := h.databaseService.GetDetailedRole(c.Request.Context(), connectionID, roleID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -283,7 +485,7 @@ func (h *DatabaseHandler) GetRoleMembership(c *gin.Context) {
 	connectionID := c.Param("id")
 	roleID := c.Param("roleId")
 
-	parentRoles, childRoles, err := h.databaseService.GetRoleMembership(connectionID, roleID)
+	parentRoles, childRoles, err := h.databaseService.GetRoleMembership(c.Request.Context(), connectionID, roleID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -300,7 +502,7 @@ func (h *DatabaseHandler) GetSchemas(c *gin.Context) {
 	connectionID := c.Param("id")
 	dbName := c.Param("dbName")
 
-	schemas, err := h.databaseService.GetSchemas(connectionID, dbName)
+	schemas, err := h.databaseService.GetSchemas(c.Request.Context(), connectionID, dbName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -315,7 +517,7 @@ func (h *DatabaseHandler) GetTablesInSchema(c *gin.Context) {
 	dbName := c.Param("dbName")
 	schemaName := c.Param("schemaName")
 
-	tables, err := h.databaseService.GetTablesInSchema(connectionID, dbName, schemaName)
+	tables, err := h.databaseService.GetTablesInSchema(c.Request.Context(), connectionID, dbName, schemaName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -324,13 +526,202 @@ func (h *DatabaseHandler) GetTablesInSchema(c *gin.Context) {
 	c.JSON(http.StatusOK, tables)
 }
 
+// BrowseTableRows handles GET /api/v1/connections/:id/databases/:dbName/schemas/:schemaName/tables/:table/rows
+// ?columns=a,b&filter=...&sort_by=...&sort_order=asc|desc with limit/offset, or pagination=keyset and cursor
+func (h *DatabaseHandler) BrowseTableRows(c *gin.Context) {
+	filter, err := parseFilterExpression(c)
+	if err != nil {
+		respondTableRowsError(c, err)
+		return
+	}
+
+	page := parsePage(c, 100)
+	req := &models.TableRowsRequest{
+		Filter:    filter,
+		SortBy:    c.Query("sort_by"),
+		SortOrder: c.Query("sort_order"),
+		Limit:     page.Limit,
+		Offset:    page.Offset,
+		Keyset:    c.Query("pagination") == "keyset",
+		Cursor:    c.Query("cursor"),
+	}
+	if columns := c.Query("columns"); columns != "" {
+		for _, column := range strings.Split(columns, ",") {
+			req.Columns = append(req.Columns, strings.TrimSpace(column))
+		}
+	}
+
+	rows, err := h.databaseService.BrowseTableRows(c.Request.Context(), c.Param("id"), c.Param("dbName"), c.Param("schemaName"), c.Param("table"), req)
+	if err != nil {
+		respondTableRowsError(c, err)
+		return
+	}
+
+	if rows.Total != nil {
+		setPageHeaders(c, page, *rows.Total)
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// respondTableRowsError maps errors of the table browser to HTTP status codes
+func respondTableRowsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidFilter), errors.Is(err, services.ErrInvalidQueryCursor), errors.Is(err, services.ErrUnsupportedDialect):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUnknownIdentifier), err.Error() == "table not found", strings.Contains(err.Error(), "connection not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// InsertTableRow handles POST /api/v1/connections/:id/databases/:dbName/schemas/:schemaName/tables/:table/rows
+func (h *DatabaseHandler) InsertTableRow(c *gin.Context) {
+	var req models.TableRowInsertRequest
+	if err := bindJSONExact(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	row, err := h.databaseService.InsertTableRow(c.Request.Context(), c.Param("id"), c.Param("dbName"), c.Param("schemaName"), c.Param("table"), req.Values)
+	entry := h.rowEditLog(c, models.RowEditInsert, nil, err)
+	if row != nil {
+		entry.NewValues = row.Values
+	}
+	h.logRowEdit(c, entry)
+	if err != nil {
+		respondRowEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, row)
+}
+
+// UpdateTableRow handles PUT /api/v1/connections/:id/databases/:dbName/schemas/:schemaName/tables/:table/rows
+func (h *DatabaseHandler) UpdateTableRow(c *gin.Context) {
+	var req models.TableRowUpdateRequest
+	if err := bindJSONExact(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	old, row, err := h.databaseService.UpdateTableRow(c.Request.Context(), c.Param("id"), c.Param("dbName"), c.Param("schemaName"), c.Param("table"), &req)
+	entry := h.rowEditLog(c, models.RowEditUpdate, req.Key, err)
+	if old != nil {
+		entry.OldValues = old.Values
+	}
+	if row != nil {
+		entry.NewValues = row.Values
+	}
+	h.logRowEdit(c, entry)
+	if err != nil {
+		respondRowEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, row)
+}
+
+// DeleteTableRow handles DELETE /api/v1/connections/:id/databases/:dbName/schemas/:schemaName/tables/:table/rows
+func (h *DatabaseHandler) DeleteTableRow(c *gin.Context) {
+	var req models.TableRowDeleteRequest
+	if err := bindJSONExact(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	old, err := h.databaseService.DeleteTableRow(c.Request.Context(), c.Param("id"), c.Param("dbName"), c.Param("schemaName"), c.Param("table"), &req)
+	entry := h.rowEditLog(c, models.RowEditDelete, req.Key, err)
+	if old != nil {
+		entry.OldValues = old.Values
+	}
+	h.logRowEdit(c, entry)
+	if err != nil {
+		respondRowEditError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetTableRowLogs handles GET /api/v1/connections/:id/databases/:dbName/schemas/:schemaName/tables/:table/rows/logs
+func (h *DatabaseHandler) GetTableRowLogs(c *gin.Context) {
+	page := parsePage(c, 100)
+
+	logs, total, err := h.rowLogs.GetLogs(c.Param("id"), c.Param("dbName"), c.Param("schemaName"), c.Param("table"), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": logs, "pagination": setPageHeaders(c, page, total)})
+}
+
+// rowEditLog starts the log entry of a row edit made by the current user
+func (h *DatabaseHandler) rowEditLog(c *gin.Context, operation string, key models.RowValues, err error) *models.TableRowEditLog {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	entry := &models.TableRowEditLog{
+		ConnectionID: c.Param("id"),
+		DatabaseName: c.Param("dbName"),
+		SchemaName:   c.Param("schemaName"),
+		Table:        c.Param("table"),
+		Operation:    operation,
+		RowKey:       key,
+		UserID:       userIDStr,
+		Status:       models.AuditEventStatusSuccess,
+	}
+	if err != nil {
+		entry.Status = models.AuditEventStatusError
+		entry.ErrorMessage = err.Error()
+	}
+	return entry
+}
+
+// logRowEdit stores the log entry of a row edit, failed attempts included
+func (h *DatabaseHandler) logRowEdit(c *gin.Context, entry *models.TableRowEditLog) {
+	if h.rowLogs != nil {
+		h.rowLogs.LogEdit(c.Request.Context(), entry)
+	}
+}
+
+// bindJSONExact binds a JSON request body keeping numbers exact, so large integer keys and
+// numeric values are not rounded through float64
+func bindJSONExact(c *gin.Context, req any) error {
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(req); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(req)
+}
+
+// respondRowEditError maps errors of the table row editor to HTTP status codes
+func respondRowEditError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRowEdit), errors.Is(err, services.ErrUnsupportedDialect):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrRowVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUnknownIdentifier), err.Error() == "table not found", err.Error() == "row not found", strings.Contains(err.Error(), "connection not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 // GetViewsInSchema handles GET /api/v1/connections/:id/databases/:dbName/schemas/:schemaName/views
 func (h *DatabaseHandler) GetViewsInSchema(c *gin.Context) {
 	connectionID := c.Param("id")
 	dbName := c.Param("dbName")
 	schemaName := c.Param("schemaName")
 
-	views, err := h.databaseService.GetViewsInSchema(connectionID, dbName, schemaName)
+	views, err := h.databaseService.GetViewsInSchema(c.Request.Context(), connectionID, dbName, schemaName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -345,7 +736,7 @@ func (h *DatabaseHandler) GetFunctionsInSchema(c *gin.Context) {
 	dbName := c.Param("dbName")
 	schemaName := c.Param("schemaName")
 
-	functions, err := h.databaseService.GetFunctionsInSchema(connectionID, dbName, schemaName)
+	functions, err := h.databaseService.GetFunctionsInSchema(c.Request.Context(), connectionID, dbName, schemaName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -359,7 +750,7 @@ func (h *DatabaseHandler) GetRolePrivileges(c *gin.Context) {
 	connectionID := c.Param("id")
 	roleID := c.Param("roleId")
 
-	privileges, err := h.databaseService.GetRolePrivileges(connectionID, roleID)
+	privileges, err := h.databaseService.GetRolePrivileges(c.Request.Context(), connectionID, roleID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -368,6 +759,33 @@ func (h *DatabaseHandler) GetRolePrivileges(c *gin.Context) {
 	c.JSON(http.StatusOK, privileges)
 }
 
+// CheckRolePrivilege handles POST /api/v1/connections/:id/roles/:roleId/check-privilege
+func (h *DatabaseHandler) CheckRolePrivilege(c *gin.Context) {
+	connectionID := c.Param("id")
+	roleID := c.Param("roleId")
+	var req models.PrivilegeCheckRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.databaseService.CheckRolePrivilege(c.Request.Context(), connectionID, roleID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPrivilegeCheck), errors.Is(err, services.ErrUnsupportedDialect):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "role not found", err.Error() == "object not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // GrantPrivileges handles POST /api/v1/connections/:id/roles/:roleId/grant
 func (h *DatabaseHandler) GrantPrivileges(c *gin.Context) {
 	connectionID := c.Param("id")
@@ -386,10 +804,14 @@ func (h *DatabaseHandler) GrantPrivileges(c *gin.Context) {
 		userIDStr = userID.(string)
 	}
 
-	if err := h.databaseService.GrantPrivileges(connectionID, roleID, &req); err != nil {
+	if err := h.databaseService.GrantPrivileges(c.Request.Context(), connectionID, roleID, &req); err != nil {
 		// Log error
 		if h.logService != nil {
-			h.logService.LogOperation(connectionID, roleID, userIDStr, "grant_privileges", models.RoleSaveStatusError, err.Error())
+			h.logService.LogOperation(c.Request.Context(), connectionID, roleID, userIDStr, "grant_privileges", models.RoleSaveStatusError, err.Error())
+		}
+		if errors.Is(err, services.ErrUnknownIdentifier) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -397,7 +819,7 @@ func (h *DatabaseHandler) GrantPrivileges(c *gin.Context) {
 
 	// Log success
 	if h.logService != nil {
-		h.logService.LogOperation(connectionID, roleID, userIDStr, "grant_privileges", models.RoleSaveStatusSuccess, "")
+		h.logService.LogOperation(c.Request.Context(), connectionID, roleID, userIDStr, "grant_privileges", models.RoleSaveStatusSuccess, "")
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Privileges granted successfully"})
@@ -421,10 +843,14 @@ func (h *DatabaseHandler) RevokePrivileges(c *gin.Context) {
 		userIDStr = userID.(string)
 	}
 
-	if err := h.databaseService.RevokePrivileges(connectionID, roleID, &req); err != nil {
+	if err := h.databaseService.RevokePrivileges(c.Request.Context(), connectionID, roleID, &req); err != nil {
 		// Log error
 		if h.logService != nil {
-			h.logService.LogOperation(connectionID, roleID, userIDStr, "revoke_privileges", models.RoleSaveStatusError, err.Error())
+			h.logService.LogOperation(c.Request.Context(), connectionID, roleID, userIDStr, "revoke_privileges", models.RoleSaveStatusError, err.Error())
+		}
+		if errors.Is(err, services.ErrUnknownIdentifier) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -432,7 +858,7 @@ func (h *DatabaseHandler) RevokePrivileges(c *gin.Context) {
 
 	// Log success
 	if h.logService != nil {
-		h.logService.LogOperation(connectionID, roleID, userIDStr, "revoke_privileges", models.RoleSaveStatusSuccess, "")
+		h.logService.LogOperation(c.Request.Context(), connectionID, roleID, userIDStr, "revoke_privileges", models.RoleSaveStatusSuccess, "")
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Privileges revoked successfully"})
@@ -456,10 +882,10 @@ func (h *DatabaseHandler) GrantMembership(c *gin.Context) {
 		userIDStr = userID.(string)
 	}
 
-	if err := h.databaseService.GrantMembership(connectionID, roleID, &req); err != nil {
+	if err := h.databaseService.GrantMembership(c.Request.Context(), connectionID, roleID, &req); err != nil {
 		// Log error
 		if h.logService != nil {
-			h.logService.LogOperation(connectionID, roleID, userIDStr, "grant_membership", models.RoleSaveStatusError, err.Error())
+			h.logService.LogOperation(c.Request.Context(), connectionID, roleID, userIDStr, "grant_membership", models.RoleSaveStatusError, err.Error())
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -467,7 +893,7 @@ func (h *DatabaseHandler) GrantMembership(c *gin.Context) {
 
 	// Log success
 	if h.logService != nil {
-		h.logService.LogOperation(connectionID, roleID, userIDStr, "grant_membership", models.RoleSaveStatusSuccess, "")
+		h.logService.LogOperation(c.Request.Context(), connectionID, roleID, userIDStr, "grant_membership", models.RoleSaveStatusSuccess, "")
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Membership granted successfully"})
@@ -491,10 +917,10 @@ func (h *DatabaseHandler) RevokeMembership(c *gin.Context) {
 		userIDStr = userID.(string)
 	}
 
-	if err := h.databaseService.RevokeMembership(connectionID, roleID, &req); err != nil {
+	if err := h.databaseService.RevokeMembership(c.Request.Context(), connectionID, roleID, &req); err != nil {
 		// Log error
 		if h.logService != nil {
-			h.logService.LogOperation(connectionID, roleID, userIDStr, "revoke_membership", models.RoleSaveStatusError, err.Error())
+			h.logService.LogOperation(c.Request.Context(), connectionID, roleID, userIDStr, "revoke_membership", models.RoleSaveStatusError, err.Error())
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -502,7 +928,7 @@ func (h *DatabaseHandler) RevokeMembership(c *gin.Context) {
 
 	// Log success
 	if h.logService != nil {
-		h.logService.LogOperation(connectionID, roleID, userIDStr, "revoke_membership", models.RoleSaveStatusSuccess, "")
+		h.logService.LogOperation(c.Request.Context(), connectionID, roleID, userIDStr, "revoke_membership", models.RoleSaveStatusSuccess, "")
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Membership revoked successfully"})
@@ -513,29 +939,231 @@ func (h *DatabaseHandler) GetRoleLogs(c *gin.Context) {
 	connectionID := c.Param("id")
 	roleID := c.Param("roleId")
 
-	// Get optional limit query parameter
-	limit := 100 // default
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
-	}
+	page := parsePage(c, 100)
 
 	var logs []models.RoleSaveLog
+	var total int64
 	var err error
 
 	if roleID != "" {
 		// Get logs for specific role
-		logs, err = h.logService.GetLogsByRole(connectionID, roleID, limit)
+		logs, total, err = h.logService.GetLogsByRole(connectionID, roleID, page)
 	} else {
 		// Get logs for connection
-		logs, err = h.logService.GetLogsByConnection(connectionID, limit)
+		logs, total, err = h.logService.GetLogsByConnection(connectionID, page)
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": logs, "pagination": setPageHeaders(c, page, total)})
+}
+
+// ExportRoleLogs handles GET /api/v1/connections/:id/roles/logs/export?format=csv|json&role_id=...&from=...&to=...&user_id=...&operation=...&status=...
+func (h *DatabaseHandler) ExportRoleLogs(c *gin.Context) {
+	connectionID := c.Param("id")
+
+	filter, err := parseLogExportFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	header := []string{"id", "created_at", "connection_id", "role_id", "user_id", "operation", "status", "error_message"}
+	writeLogExport(c, "role-logs", header,
+		func(entry *models.RoleSaveLog) []string {
+			return []string{
+				strconv.Itoa(entry.ID), logTime(entry.CreatedAt), entry.ConnectionID, entry.RoleID, entry.UserID, entry.Operation,
+				string(entry.Status), entry.ErrorMessage,
+			}
+		},
+		func(fn func(*models.RoleSaveLog) error) error {
+			return h.logService.ExportLogs(connectionID, c.Query("role_id"), filter, fn)
+		})
+}
+
+// ChangeOwner handles POST /api/v1/connections/:id/ownership
+func (h *DatabaseHandler) ChangeOwner(c *gin.Context) {
+	connectionID := c.Param("id")
+	var req models.OwnershipChangeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	result, err := h.databaseService.ChangeOwner(c.Request.Context(), connectionID, &req)
+	if err != nil {
+		if h.logService != nil {
+			h.logService.LogOperation(c.Request.Context(), connectionID, "", userIDStr, "change_owner", models.RoleSaveStatusError, err.Error())
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.logService != nil {
+		h.logService.LogOperation(c.Request.Context(), connectionID, "", userIDStr, "change_owner", models.RoleSaveStatusSuccess, "")
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ReassignSchemaOwnership handles POST /api/v1/connections/:id/databases/:dbName/schemas/:schemaName/reassign-owner
+func (h *DatabaseHandler) ReassignSchemaOwnership(c *gin.Context) {
+	connectionID := c.Param("id")
+	dbName := c.Param("dbName")
+	schemaName := c.Param("schemaName")
+	var req models.BulkOwnershipRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	result, err := h.databaseService.ReassignSchemaOwnership(c.Request.Context(), connectionID, dbName, schemaName, &req)
+	if err != nil {
+		if h.logService != nil {
+			h.logService.LogOperation(c.Request.Context(), connectionID, "", userIDStr, "reassign_owner", models.RoleSaveStatusError, err.Error())
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Dry runs change nothing, so only real reassignments are logged
+	if h.logService != nil && !req.DryRun {
+		h.logService.LogOperation(c.Request.Context(), connectionID, "", userIDStr, "reassign_owner", models.RoleSaveStatusSuccess, "")
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetLargeObjectReport handles GET /api/v1/connections/:id/databases/:dbName/large-objects
+func (h *DatabaseHandler) GetLargeObjectReport(c *gin.Context) {
+	connectionID := c.Param("id")
+	dbName := c.Param("dbName")
+
+	// Number of TOAST-heavy tables to return
+	limit := 20 // default
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	report, err := h.databaseService.GetLargeObjectReport(c.Request.Context(), connectionID, dbName, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetDatabaseStats handles GET /api/v1/connections/:id/databases/:dbName/stats?limit=...
+func (h *DatabaseHandler) GetDatabaseStats(c *gin.Context) {
+	connectionID := c.Param("id")
+	dbName := c.Param("dbName")
+
+	// Number of largest tables and indexes to return
+	limit := 50 // default
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	stats, err := h.databaseService.GetDatabaseStats(c.Request.Context(), connectionID, dbName, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedDialect) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// CleanupOrphanedLargeObjects handles POST /api/v1/connections/:id/databases/:dbName/large-objects/cleanup
+func (h *DatabaseHandler) CleanupOrphanedLargeObjects(c *gin.Context) {
+	connectionID := c.Param("id")
+	dbName := c.Param("dbName")
+	var req models.LargeObjectCleanupRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.databaseService.CleanupOrphanedLargeObjects(c.Request.Context(), connectionID, dbName, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
+	c.JSON(http.StatusOK, result)
+}
+
+// GetCollationAudit handles GET /api/v1/connections/:id/databases/:dbName/collation-audit
+func (h *DatabaseHandler) GetCollationAudit(c *gin.Context) {
+	connectionID := c.Param("id")
+	dbName := c.Param("dbName")
+
+	report, err := h.databaseService.GetCollationAudit(c.Request.Context(), connectionID, dbName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"logs": logs})
+	c.JSON(http.StatusOK, report)
+}
+
+// GetSecurityReport handles GET /api/v1/connections/:id/databases/:dbName/security-report?format=json|pdf
+func (h *DatabaseHandler) GetSecurityReport(c *gin.Context) {
+	connectionID := c.Param("id")
+	dbName := c.Param("dbName")
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or pdf"})
+		return
+	}
+
+	report, err := h.databaseService.GetSecurityReport(c.Request.Context(), connectionID, dbName)
+	if err != nil {
+		switch {
+		case err.Error() == "connection not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrUnsupportedDialect):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	filename := fmt.Sprintf("security-report-%s-%s.pdf", dbName, report.GeneratedAt.Format("20060102-150405"))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Data(http.StatusOK, "application/pdf", services.RenderSecurityReportPDF(report))
 }
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DigestHandler handles HTTP requests for activity digest subscriptions
+type DigestHandler struct {
+	digestService *services.DigestService
+}
+
+// NewDigestHandler creates a new digest handler
+func NewDigestHandler(digestService *services.DigestService) *DigestHandler {
+	return &DigestHandler{
+		digestService: digestService,
+	}
+}
+
+// Subscribe handles POST /api/v1/digests/subscriptions
+func (h *DigestHandler) Subscribe(c *gin.Context) {
+	var req models.DigestSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	subscription, err := h.digestService.Subscribe(userIDStr, &req)
+	if err != nil {
+		respondDigestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+// GetSubscriptions handles GET /api/v1/digests/subscriptions
+func (h *DigestHandler) GetSubscriptions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	subscriptions, err := h.digestService.GetSubscriptions(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// DeleteSubscription handles DELETE /api/v1/digests/subscriptions/:id
+func (h *DigestHandler) DeleteSubscription(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	if err := h.digestService.DeleteSubscription(id, userIDStr, isAdmin(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// SendDigest handles POST /api/v1/digests/subscriptions/:id/send
+func (h *DigestHandler) SendDigest(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	digest, err := h.digestService.SendDigest(id, userIDStr, isAdmin(c))
+	if err != nil {
+		respondDigestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, digest)
+}
+
+// PreviewDigest handles GET /api/v1/digests/preview?connection_id=...&frequency=daily|weekly
+func (h *DigestHandler) PreviewDigest(c *gin.Context) {
+	connectionID := c.Query("connection_id")
+	if connectionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "connection_id is required"})
+		return
+	}
+	frequency := models.DigestFrequency(c.DefaultQuery("frequency", string(models.DigestFrequencyDaily)))
+
+	digest, err := h.digestService.PreviewDigest(connectionID, c.GetString("userID"), frequency)
+	if err != nil {
+		respondDigestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, digest)
+}
+
+// respondDigestError maps digest service errors to HTTP status codes
+func respondDigestError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrAccessGrantRequired) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
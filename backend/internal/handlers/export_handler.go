@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler handles HTTP requests for CSV exports of query results
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// StartExport handles POST /api/v1/connections/:id/databases/:dbName/exports
+func (h *ExportHandler) StartExport(c *gin.Context) {
+	connectionID := c.Param("id")
+	dbName := c.Param("dbName")
+
+	var req models.QueryExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	export, err := h.exportService.StartExport(connectionID, dbName, userIDStr, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, export)
+}
+
+// GetExports handles GET /api/v1/exports
+func (h *ExportHandler) GetExports(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	exports, err := h.exportService.GetExports(userIDStr, isAdmin(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, exports)
+}
+
+// GetExport handles GET /api/v1/exports/:id
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	export, err := h.exportService.GetExport(id, userIDStr, isAdmin(c))
+	if err != nil {
+		respondExportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, export)
+}
+
+// GetDownloadURL handles GET /api/v1/exports/:id/download-url
+func (h *ExportHandler) GetDownloadURL(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	download, err := h.exportService.DownloadURL(id, userIDStr, isAdmin(c))
+	if err != nil {
+		respondExportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, download)
+}
+
+// respondExportError maps export service errors to HTTP status codes
+func respondExportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrExportForbidden), errors.Is(err, services.ErrArtifactForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrExportNotReady):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err.Error() == "export not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "export file has expired":
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+
+	"truadmin/internal/graphqlapi"
+	"truadmin/internal/models"
+)
+
+// GraphQLRequest represents a GraphQL query sent over HTTP
+type GraphQLRequest struct {
+	Query         string                 `json:"query" binding:"required"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// GraphQLHandler handles read-only GraphQL queries over admin metadata
+type GraphQLHandler struct {
+	schema graphql.Schema
+}
+
+// NewGraphQLHandler creates a new GraphQL handler
+func NewGraphQLHandler(schema graphql.Schema) *GraphQLHandler {
+	return &GraphQLHandler{
+		schema: schema,
+	}
+}
+
+// Query handles POST /api/graphql
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var req GraphQLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := graphqlapi.CheckQueryLimits(req.Query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Resolvers check the permissions and access grants of the caller
+	viewer := graphqlapi.Viewer{UserID: c.GetString("userID")}
+	if role, ok := c.Get("role"); ok {
+		viewer.Role, _ = role.(models.UserRole)
+	}
+	if apiKey, ok := c.Get("apiKey"); ok {
+		viewer.APIKey, _ = apiKey.(*models.APIKey)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        graphqlapi.WithViewer(c.Request.Context(), viewer),
+	})
+
+	c.JSON(http.StatusOK, result)
+}
@@ -5,20 +5,25 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"truadmin/internal/database"
+	"truadmin/internal/services"
 )
 
 // HealthHandler handles health check requests
-type HealthHandler struct{}
+type HealthHandler struct {
+	clusterService *services.ClusterService
+}
 
 // NewHealthHandler creates a new health handler
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+func NewHealthHandler(clusterService *services.ClusterService) *HealthHandler {
+	return &HealthHandler{
+		clusterService: clusterService,
+	}
 }
 
 // Health handles GET /health
 func (h *HealthHandler) Health(c *gin.Context) {
 	dbStatus := "connected"
-	if !database.IsConnected() {
+	if !database.Available() {
 		dbStatus = "disconnected"
 	}
 
@@ -26,13 +31,15 @@ func (h *HealthHandler) Health(c *gin.Context) {
 		"status":      "ok",
 		"service":     "truadmin-backend",
 		"database":    dbStatus,
+		"replica_id":  h.clusterService.ReplicaID(),
+		"leader":      h.clusterService.IsLeader(),
 	})
 }
 
 // DatabaseStatus handles GET /api/v1/database/status
 func (h *HealthHandler) DatabaseStatus(c *gin.Context) {
-	isConnected := database.IsConnected()
-	dbError := database.GetDBError()
+	dbError := database.AvailabilityError()
+	isConnected := dbError == nil
 	dbConfig := database.GetDBConfig()
 
 	response := gin.H{
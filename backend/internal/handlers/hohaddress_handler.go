@@ -1,9 +1,17 @@
 package handlers
 
 import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 	"truadmin/internal/models"
 	"truadmin/internal/services"
 
@@ -28,7 +36,7 @@ func NewHohAddressHandler(hohAddressService *services.HohAddressService, logServ
 func (h *HohAddressHandler) GetEligibleDatabases(c *gin.Context) {
 	connectionID := c.Param("connectionId")
 
-	databases, err := h.hohAddressService.GetEligibleDatabases(connectionID)
+	databases, err := h.hohAddressService.GetEligibleDatabases(c.Request.Context(), connectionID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -54,6 +62,72 @@ func (h *HohAddressHandler) AddDatabase(c *gin.Context) {
 	c.JSON(http.StatusCreated, database)
 }
 
+// InitializeSchema handles POST /api/v1/hohaddress/connections/:connectionId/schema
+func (h *HohAddressHandler) InitializeSchema(c *gin.Context) {
+	connectionID := c.Param("connectionId")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	var req models.SchemaBootstrapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.hohAddressService.InitializeSchema(c.Request.Context(), connectionID, &req, userIDStr)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSchemaAlreadyInitialized):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case err.Error() == "connection not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case entry != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "log": entry})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetSchemaLogs handles GET /api/v1/hohaddress/connections/:connectionId/schema/logs
+func (h *HohAddressHandler) GetSchemaLogs(c *gin.Context) {
+	connectionID := c.Param("connectionId")
+
+	page := parsePage(c, 100)
+
+	logs, total, err := h.hohAddressService.GetSchemaLogs(connectionID, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": logs, "pagination": setPageHeaders(c, page, total)})
+}
+
+// AddEligibleDatabases handles POST /api/v1/hohaddress/databases/bulk
+func (h *HohAddressHandler) AddEligibleDatabases(c *gin.Context) {
+	var req models.BulkDatabaseRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.hohAddressService.AddEligibleDatabases(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // GetDatabases handles GET /api/v1/hohaddress/databases
 func (h *HohAddressHandler) GetDatabases(c *gin.Context) {
 	databases, err := h.hohAddressService.GetDatabases()
@@ -109,12 +183,41 @@ func (h *HohAddressHandler) DeleteDatabase(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// RepointDatabase handles POST /api/v1/hohaddress/databases/:id/repoint
+func (h *HohAddressHandler) RepointDatabase(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.RepointDatabaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	database, err := h.hohAddressService.RepointDatabase(c.Request.Context(), id, &req)
+	if err != nil {
+		var mismatch *services.RepointSchemaError
+		switch {
+		case errors.As(err, &mismatch):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "missing": mismatch.Missing})
+		case err.Error() == "HohAddress database not found" || err.Error() == "connection not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case err.Error() == "database already added to HohAddress":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, database)
+}
+
 // GetTableColumns handles GET /api/v1/hohaddress/databases/:id/tables/:tableName/columns
 func (h *HohAddressHandler) GetTableColumns(c *gin.Context) {
 	id := c.Param("id")
 	tableName := c.Param("tableName")
 
-	columns, err := h.hohAddressService.GetTableColumns(id, tableName)
+	columns, err := h.hohAddressService.GetTableColumns(c.Request.Context(), id, tableName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -128,38 +231,31 @@ func (h *HohAddressHandler) GetStatusList(c *gin.Context) {
 	id := c.Param("id")
 
 	// Parse query parameters for filters
+	filter, err := parseFilterExpression(c)
+	if err != nil {
+		respondHohAddressListError(c, err)
+		return
+	}
 	filters := make(map[string]string)
-	whereClause := c.Query("where")
-	fmt.Printf("Handler received whereClause: %s\n", whereClause)
 	for key, values := range c.Request.URL.Query() {
-		if key != "limit" && key != "offset" && key != "where" && len(values) > 0 {
+		if key != "limit" && key != "offset" && key != "filter" && len(values) > 0 {
 			filters[key] = values[0]
 		}
 	}
 
 	// Parse pagination
-	limit := 100
-	offset := 0
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
-			limit = parsed
-		}
-	}
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if parsed, err := strconv.Atoi(offsetStr); err == nil && parsed >= 0 {
-			offset = parsed
-		}
-	}
+	page := parsePage(c, 100)
 
-	data, totalCount, err := h.hohAddressService.GetStatusList(id, filters, limit, offset, whereClause)
+	data, totalCount, err := h.hohAddressService.GetStatusList(c.Request.Context(), id, rowScope(c), filters, page.Limit, page.Offset, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondHohAddressListError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"data":       data,
 		"totalCount": totalCount,
+		"pagination": setPageHeaders(c, page, int64(totalCount)),
 	})
 }
 
@@ -168,43 +264,36 @@ func (h *HohAddressHandler) GetBlacklist(c *gin.Context) {
 	id := c.Param("id")
 
 	// Parse query parameters for filters
+	filter, err := parseFilterExpression(c)
+	if err != nil {
+		respondHohAddressListError(c, err)
+		return
+	}
 	filters := make(map[string]string)
-	whereClause := c.Query("where")
 	for key, values := range c.Request.URL.Query() {
-		if key != "limit" && key != "offset" && key != "sortBy" && key != "sortOrder" && key != "where" && len(values) > 0 {
+		if key != "limit" && key != "offset" && key != "sortBy" && key != "sortOrder" && key != "filter" && len(values) > 0 {
 			filters[key] = values[0]
 		}
 	}
 
 	// Parse pagination and sorting
-	limit := 100
-	offset := 0
+	page := parsePage(c, 100)
 	sortBy := c.Query("sortBy")
 	sortOrder := c.Query("sortOrder")
 	if sortOrder == "" {
 		sortOrder = "ASC"
 	}
 
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
-			limit = parsed
-		}
-	}
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if parsed, err := strconv.Atoi(offsetStr); err == nil && parsed >= 0 {
-			offset = parsed
-		}
-	}
-
-	data, totalCount, err := h.hohAddressService.GetBlacklist(id, filters, sortBy, sortOrder, limit, offset, whereClause)
+	data, totalCount, err := h.hohAddressService.GetBlacklist(c.Request.Context(), id, rowScope(c), filters, sortBy, sortOrder, page.Limit, page.Offset, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondHohAddressListError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"data":       data,
 		"totalCount": totalCount,
+		"pagination": setPageHeaders(c, page, int64(totalCount)),
 	})
 }
 
@@ -225,9 +314,14 @@ func (h *HohAddressHandler) CreateBlacklistRow(c *gin.Context) {
 		usernameStr = username.(string)
 	}
 
-	result, err := h.hohAddressService.CreateBlacklistRow(id, data, usernameStr)
+	data, ok := h.authorizeColumnEdits(c, id, "hohaddressblacklist", "", data)
+	if !ok {
+		return
+	}
+
+	result, err := h.hohAddressService.CreateBlacklistRow(c.Request.Context(), id, rowScope(c), data, usernameStr)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondHohAddressEditError(c, err)
 		return
 	}
 
@@ -252,9 +346,14 @@ func (h *HohAddressHandler) UpdateBlacklistRow(c *gin.Context) {
 		usernameStr = username.(string)
 	}
 
-	result, err := h.hohAddressService.UpdateBlacklistRow(id, rowID, data, usernameStr)
+	data, ok := h.authorizeColumnEdits(c, id, "hohaddressblacklist", rowID, data)
+	if !ok {
+		return
+	}
+
+	result, err := h.hohAddressService.UpdateBlacklistRow(c.Request.Context(), id, rowScope(c), rowID, data, usernameStr)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondHohAddressEditError(c, err)
 		return
 	}
 
@@ -266,8 +365,8 @@ func (h *HohAddressHandler) DeleteBlacklistRow(c *gin.Context) {
 	id := c.Param("id")
 	rowID := c.Param("rowId")
 
-	if err := h.hohAddressService.DeleteBlacklistRow(id, rowID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.hohAddressService.DeleteBlacklistRow(c.Request.Context(), id, rowScope(c), rowID); err != nil {
+		respondHohAddressEditError(c, err)
 		return
 	}
 
@@ -279,43 +378,36 @@ func (h *HohAddressHandler) GetWhitelist(c *gin.Context) {
 	id := c.Param("id")
 
 	// Parse query parameters for filters
+	filter, err := parseFilterExpression(c)
+	if err != nil {
+		respondHohAddressListError(c, err)
+		return
+	}
 	filters := make(map[string]string)
-	whereClause := c.Query("where")
 	for key, values := range c.Request.URL.Query() {
-		if key != "limit" && key != "offset" && key != "sortBy" && key != "sortOrder" && key != "where" && len(values) > 0 {
+		if key != "limit" && key != "offset" && key != "sortBy" && key != "sortOrder" && key != "filter" && len(values) > 0 {
 			filters[key] = values[0]
 		}
 	}
 
 	// Parse pagination and sorting
-	limit := 100
-	offset := 0
+	page := parsePage(c, 100)
 	sortBy := c.Query("sortBy")
 	sortOrder := c.Query("sortOrder")
 	if sortOrder == "" {
 		sortOrder = "ASC"
 	}
 
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
-			limit = parsed
-		}
-	}
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if parsed, err := strconv.Atoi(offsetStr); err == nil && parsed >= 0 {
-			offset = parsed
-		}
-	}
-
-	data, totalCount, err := h.hohAddressService.GetWhitelist(id, filters, sortBy, sortOrder, limit, offset, whereClause)
+	data, totalCount, err := h.hohAddressService.GetWhitelist(c.Request.Context(), id, rowScope(c), filters, sortBy, sortOrder, page.Limit, page.Offset, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondHohAddressListError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"data":       data,
 		"totalCount": totalCount,
+		"pagination": setPageHeaders(c, page, int64(totalCount)),
 	})
 }
 
@@ -336,9 +428,14 @@ func (h *HohAddressHandler) CreateWhitelistRow(c *gin.Context) {
 		usernameStr = username.(string)
 	}
 
-	result, err := h.hohAddressService.CreateWhitelistRow(id, data, usernameStr)
+	data, ok := h.authorizeColumnEdits(c, id, "hohaddresswhitelist", "", data)
+	if !ok {
+		return
+	}
+
+	result, err := h.hohAddressService.CreateWhitelistRow(c.Request.Context(), id, rowScope(c), data, usernameStr)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondHohAddressEditError(c, err)
 		return
 	}
 
@@ -363,9 +460,14 @@ func (h *HohAddressHandler) UpdateWhitelistRow(c *gin.Context) {
 		usernameStr = username.(string)
 	}
 
-	result, err := h.hohAddressService.UpdateWhitelistRow(id, rowID, data, usernameStr)
+	data, ok := h.authorizeColumnEdits(c, id, "hohaddresswhitelist", rowID, data)
+	if !ok {
+		return
+	}
+
+	result, err := h.hohAddressService.UpdateWhitelistRow(c.Request.Context(), id, rowScope(c), rowID, data, usernameStr)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondHohAddressEditError(c, err)
 		return
 	}
 
@@ -377,33 +479,508 @@ func (h *HohAddressHandler) DeleteWhitelistRow(c *gin.Context) {
 	id := c.Param("id")
 	rowID := c.Param("rowId")
 
-	if err := h.hohAddressService.DeleteWhitelistRow(id, rowID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.hohAddressService.DeleteWhitelistRow(c.Request.Context(), id, rowScope(c), rowID); err != nil {
+		respondHohAddressEditError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// ImportWhitelist handles POST /api/v1/hohaddress/databases/:id/whitelist/import (multipart field "file", CSV with a header row)
+func (h *HohAddressHandler) ImportWhitelist(c *gin.Context) {
+	h.importRows(c, "hohaddresswhitelist", h.hohAddressService.ImportWhitelist)
+}
+
+// ImportBlacklist handles POST /api/v1/hohaddress/databases/:id/blacklist/import (multipart field "file", CSV with a header row)
+func (h *HohAddressHandler) ImportBlacklist(c *gin.Context) {
+	h.importRows(c, "hohaddressblacklist", h.hohAddressService.ImportBlacklist)
+}
+
+// importRows reads the uploaded CSV file and returns the per-row import report. Header columns the
+// column rules keep the user from setting are recorded like those of a single row edit.
+func (h *HohAddressHandler) importRows(c *gin.Context, tableName string, importFn func(context.Context, string, services.RowScope, io.Reader, string) (*models.HohAddressImportReport, error)) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	// Get username from context
+	username, _ := c.Get("username")
+	usernameStr := ""
+	if username != nil {
+		usernameStr = username.(string)
+	}
+
+	id := c.Param("id")
+	report, err := importFn(c.Request.Context(), id, rowScope(c), f, usernameStr)
+	var deniedErr *services.ColumnEditDeniedError
+	if errors.As(err, &deniedErr) {
+		h.logColumnViolation(c, id, tableName, "", deniedErr.Columns, models.ColumnRuleActionReject)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "columns": deniedErr.Columns})
+		return
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidHohAddressImport) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(report.StrippedColumns) > 0 {
+		h.logColumnViolation(c, id, tableName, "", report.StrippedColumns, models.ColumnRuleActionStrip)
+		c.Header("X-Stripped-Columns", strings.Join(report.StrippedColumns, ","))
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
 // GetSaveLogs handles GET /api/v1/hohaddress/databases/:id/logs
 func (h *HohAddressHandler) GetSaveLogs(c *gin.Context) {
 	id := c.Param("id")
 	
-	// Get optional limit query parameter
-	limit := 100 // default
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
-	}
+	page := parsePage(c, 100)
 	
-	logs, err := h.logService.GetSaveLogs(id, limit)
+	logs, total, err := h.logService.GetSaveLogs(id, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	
-	c.JSON(http.StatusOK, gin.H{"logs": logs})
+	c.JSON(http.StatusOK, gin.H{"logs": logs, "pagination": setPageHeaders(c, page, total)})
+}
+
+// ExportSaveLogs handles GET /api/v1/hohaddress/databases/:id/logs/export?format=csv|json&from=...&to=...&user_id=...&status=...
+func (h *HohAddressHandler) ExportSaveLogs(c *gin.Context) {
+	id := c.Param("id")
+
+	filter, err := parseLogExportFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	header := []string{"id", "created_at", "hohaddress_database_id", "user_id", "status", "execution_time_ms", "changes_summary", "error_message", "sql_script"}
+	writeLogExport(c, "hohaddress-logs", header,
+		func(entry *models.HohAddressSaveLog) []string {
+			summary, _ := json.Marshal(entry.ChangesSummary)
+			return []string{
+				strconv.Itoa(entry.ID), logTime(entry.CreatedAt), entry.HohAddressDatabaseID, entry.UserID, string(entry.Status),
+				strconv.Itoa(entry.ExecutionTimeMs), string(summary), entry.ErrorMessage, entry.SQLScript,
+			}
+		},
+		func(fn func(*models.HohAddressSaveLog) error) error {
+			return h.logService.ExportSaveLogs(id, filter, fn)
+		})
+}
+
+// ExportStatusList handles GET /api/v1/hohaddress/databases/:id/statuslist/export?format=csv|xlsx (same filters as the list)
+func (h *HohAddressHandler) ExportStatusList(c *gin.Context) {
+	h.exportTable(c, "hohaddressstatuslist")
+}
+
+// ExportBlacklist handles GET /api/v1/hohaddress/databases/:id/blacklist/export?format=csv|xlsx (same filters and sorting as the list)
+func (h *HohAddressHandler) ExportBlacklist(c *gin.Context) {
+	h.exportTable(c, "hohaddressblacklist")
+}
+
+// ExportWhitelist handles GET /api/v1/hohaddress/databases/:id/whitelist/export?format=csv|xlsx (same filters and sorting as the list)
+func (h *HohAddressHandler) ExportWhitelist(c *gin.Context) {
+	h.exportTable(c, "hohaddresswhitelist")
+}
+
+// exportTable streams a HohAddress table as a CSV (default) or XLSX attachment. The response
+// starts once the query succeeds, so errors before it are still answered with an error status.
+func (h *HohAddressHandler) exportTable(c *gin.Context, tableName string) {
+	id := c.Param("id")
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+
+	// Parse query parameters for filters
+	filter, err := parseFilterExpression(c)
+	if err != nil {
+		respondHohAddressListError(c, err)
+		return
+	}
+	filters := make(map[string]string)
+	for key, values := range c.Request.URL.Query() {
+		if key != "format" && key != "sortBy" && key != "sortOrder" && key != "filter" && len(values) > 0 {
+			filters[key] = values[0]
+		}
+	}
+	sortBy := c.Query("sortBy")
+	sortOrder := c.DefaultQuery("sortOrder", "ASC")
+	if tableName == "hohaddressstatuslist" {
+		// The status list is always ordered by its first column
+		sortBy, sortOrder = "", "ASC"
+	}
+
+	csvWriter := csv.NewWriter(c.Writer)
+	var xlsx *xlsxWriter
+	started := false
+	rowCount := 0
+
+	err = h.hohAddressService.ExportTable(c.Request.Context(), id, tableName, rowScope(c), filters, filter, sortBy, sortOrder,
+		func(columns []string) error {
+			started = true
+			filename := fmt.Sprintf("%s-%s.%s", tableName, time.Now().UTC().Format("20060102-150405"), format)
+			c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+			if format == "xlsx" {
+				c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+				c.Status(http.StatusOK)
+				var err error
+				if xlsx, err = newXLSXWriter(c.Writer, tableName); err != nil {
+					return err
+				}
+				header := make([]interface{}, len(columns))
+				for i, col := range columns {
+					header[i] = col
+				}
+				return xlsx.WriteRow(header)
+			}
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Status(http.StatusOK)
+			return csvWriter.Write(columns)
+		},
+		func(values []interface{}) error {
+			if format == "xlsx" {
+				if err := xlsx.WriteRow(values); err != nil {
+					return err
+				}
+			} else {
+				record := make([]string, len(values))
+				for i, val := range values {
+					record[i] = exportCellText(val)
+				}
+				if err := csvWriter.Write(record); err != nil {
+					return err
+				}
+			}
+
+			// Flush regularly so large exports are not buffered in full
+			if rowCount++; rowCount%1000 == 0 {
+				csvWriter.Flush()
+				c.Writer.Flush()
+			}
+			return nil
+		})
+	if err != nil && !started {
+		respondHohAddressListError(c, err)
+		return
+	}
+	if err != nil {
+		// Headers are sent; the truncated file is all that can be returned
+		return
+	}
+
+	if xlsx != nil {
+		xlsx.Close()
+	}
+	csvWriter.Flush()
+	c.Writer.Flush()
+}
+
+// parseFilterExpression reads the filter expression of a list or export from the "filter" query
+// parameter; it is nil when there is none. The raw SQL "where" parameter of earlier versions is
+// rejected rather than ignored, so old clients do not silently get unfiltered results.
+func parseFilterExpression(c *gin.Context) (*models.FilterExpression, error) {
+	if c.Query("where") != "" {
+		return nil, fmt.Errorf("%w: the where parameter is no longer supported, send a filter expression in the filter parameter", services.ErrInvalidFilter)
+	}
+	raw := c.Query("filter")
+	if raw == "" {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+	var filter models.FilterExpression
+	if err := decoder.Decode(&filter); err != nil {
+		return nil, fmt.Errorf("%w: %v", services.ErrInvalidFilter, err)
+	}
+	return &filter, nil
+}
+
+// rowScope returns the row filter scope of the authenticated user for the HohAddress lists
+func rowScope(c *gin.Context) services.RowScope {
+	return services.RowScope{UserID: c.GetString("userID"), Admin: isAdmin(c)}
+}
+
+// GetRowFilters handles GET /api/v1/hohaddress/row-filters?user_id=...&group_id=... (admin only)
+func (h *HohAddressHandler) GetRowFilters(c *gin.Context) {
+	rowFilters, err := h.hohAddressService.GetRowFilters(c.Query("user_id"), c.Query("group_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rowFilters)
+}
+
+// CreateRowFilter handles POST /api/v1/hohaddress/row-filters (admin only)
+func (h *HohAddressHandler) CreateRowFilter(c *gin.Context) {
+	var req models.HohAddressRowFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	rowFilter, err := h.hohAddressService.CreateRowFilter(&req, userIDStr)
+	if err != nil {
+		respondRowFilterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rowFilter)
+}
+
+// UpdateRowFilter handles PUT /api/v1/hohaddress/row-filters/:id (admin only)
+func (h *HohAddressHandler) UpdateRowFilter(c *gin.Context) {
+	var req models.HohAddressRowFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rowFilter, err := h.hohAddressService.UpdateRowFilter(c.Param("id"), &req)
+	if err != nil {
+		respondRowFilterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rowFilter)
+}
+
+// DeleteRowFilter handles DELETE /api/v1/hohaddress/row-filters/:id (admin only)
+func (h *HohAddressHandler) DeleteRowFilter(c *gin.Context) {
+	if err := h.hohAddressService.DeleteRowFilter(c.Param("id")); err != nil {
+		respondRowFilterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// authorizeColumnEdits drops the columns of a row edit the user may not edit and records the
+// attempt. It answers 403 and returns false when a column rule rejects the whole edit.
+func (h *HohAddressHandler) authorizeColumnEdits(c *gin.Context, id, tableName, rowID string, data map[string]interface{}) (map[string]interface{}, bool) {
+	allowed, denied, err := h.hohAddressService.AuthorizeColumnEdits(id, tableName, rowScope(c), data)
+	if len(denied) > 0 {
+		action := models.ColumnRuleActionStrip
+		if err != nil {
+			action = models.ColumnRuleActionReject
+		}
+		h.logColumnViolation(c, id, tableName, rowID, denied, action)
+	}
+
+	var deniedErr *services.ColumnEditDeniedError
+	if errors.As(err, &deniedErr) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "columns": deniedErr.Columns})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if len(denied) > 0 {
+		c.Header("X-Stripped-Columns", strings.Join(denied, ","))
+	}
+	return allowed, true
+}
+
+// logColumnViolation records an edit that set columns the user may not edit
+func (h *HohAddressHandler) logColumnViolation(c *gin.Context, id, tableName, rowID string, columns []string, action string) {
+	h.logService.LogColumnViolation(c.Request.Context(), &models.HohAddressColumnViolation{
+		HohAddressDatabaseID: id,
+		Table:                tableName,
+		RowID:                rowID,
+		UserID:               c.GetString("userID"),
+		Columns:              columns,
+		Action:               action,
+	})
+}
+
+// GetColumnRules handles GET /api/v1/hohaddress/column-rules?hohaddress_database_id=... (admin only)
+func (h *HohAddressHandler) GetColumnRules(c *gin.Context) {
+	rules, err := h.hohAddressService.GetColumnRules(c.Query("hohaddress_database_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateColumnRule handles POST /api/v1/hohaddress/column-rules (admin only)
+func (h *HohAddressHandler) CreateColumnRule(c *gin.Context) {
+	var req models.HohAddressColumnRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	rule, err := h.hohAddressService.CreateColumnRule(&req, userIDStr)
+	if err != nil {
+		respondColumnRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateColumnRule handles PUT /api/v1/hohaddress/column-rules/:id (admin only)
+func (h *HohAddressHandler) UpdateColumnRule(c *gin.Context) {
+	var req models.HohAddressColumnRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.hohAddressService.UpdateColumnRule(c.Param("id"), &req)
+	if err != nil {
+		respondColumnRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteColumnRule handles DELETE /api/v1/hohaddress/column-rules/:id (admin only)
+func (h *HohAddressHandler) DeleteColumnRule(c *gin.Context) {
+	if err := h.hohAddressService.DeleteColumnRule(c.Param("id")); err != nil {
+		respondColumnRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetColumnViolations handles GET /api/v1/hohaddress/databases/:id/column-violations (admin only)
+func (h *HohAddressHandler) GetColumnViolations(c *gin.Context) {
+	page := parsePage(c, 100)
+
+	violations, total, err := h.logService.GetColumnViolations(c.Param("id"), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": violations, "pagination": setPageHeaders(c, page, total)})
+}
+
+// GetBlacklistAsOf handles GET /api/v1/hohaddress/databases/:id/blacklist/as-of?at=...&row_id=...&restore_sql=true (admin only)
+func (h *HohAddressHandler) GetBlacklistAsOf(c *gin.Context) {
+	h.tableAsOf(c, "hohaddressblacklist")
+}
+
+// GetWhitelistAsOf handles GET /api/v1/hohaddress/databases/:id/whitelist/as-of?at=...&row_id=...&restore_sql=true (admin only)
+func (h *HohAddressHandler) GetWhitelistAsOf(c *gin.Context) {
+	h.tableAsOf(c, "hohaddresswhitelist")
+}
+
+// tableAsOf returns a table or one of its rows as it was at the RFC3339 time in the at parameter
+func (h *HohAddressHandler) tableAsOf(c *gin.Context, tableName string) {
+	at, err := time.Parse(time.RFC3339, c.Query("at"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC3339 timestamp"})
+		return
+	}
+
+	snapshot, err := h.hohAddressService.GetTableAsOf(c.Request.Context(), c.Param("id"), tableName, at, c.Query("row_id"), c.Query("restore_sql") == "true")
+	if err != nil {
+		respondHohAddressListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// respondColumnRuleError maps column rule errors to HTTP status codes
+func respondColumnRuleError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "column rule not found", err.Error() == "permission group not found",
+		err.Error() == "HohAddress database not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "column rule for this column already exists":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err.Error() == "column rule column is required":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// respondRowFilterError maps row filter errors to HTTP status codes
+func respondRowFilterError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "row filter not found", err.Error() == "user not found",
+		err.Error() == "permission group not found", err.Error() == "HohAddress database not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidFilter), err.Error() == "a row filter needs either a user_id or a group_id":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// respondHohAddressListError maps errors of the HohAddress list, export and as-of endpoints to HTTP status codes
+func respondHohAddressListError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvalidFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// respondHohAddressEditError reports a failed row edit: rows outside the row filters of the editor
+// are not found, and edits that would move a row outside them are forbidden
+func respondHohAddressEditError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrHohAddressRowNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrOutsideRowFilter):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// exportCellText formats a database value for a CSV export
+func exportCellText(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
 }
 
 // CheckAddressStatus handles POST /api/v1/hohaddress/databases/:id/check-address
@@ -424,7 +1001,7 @@ func (h *HohAddressHandler) CheckAddressStatus(c *gin.Context) {
 		return
 	}
 
-	result, err := h.hohAddressService.CheckAddressStatus(id, req.Address1, req.Address2, req.City, req.State, req.Zip, req.ProgramType)
+	result, err := h.hohAddressService.CheckAddressStatus(c.Request.Context(), id, req.Address1, req.Address2, req.City, req.State, req.Zip, req.ProgramType)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
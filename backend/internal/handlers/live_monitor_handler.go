@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// liveMonitorWriteTimeout drops clients that stop reading
+const liveMonitorWriteTimeout = 10 * time.Second
+
+// liveMonitorRecheckInterval is how often an open socket checks again that its credentials are still
+// valid and its user may still see the connection
+const liveMonitorRecheckInterval = 15 * time.Second
+
+// LiveMonitorHandler handles WebSocket clients of the live monitor
+type LiveMonitorHandler struct {
+	liveMonitorService *services.LiveMonitorService
+	authService        *services.AuthService
+	apiKeyService      *services.APIKeyService
+	accessGrantService *services.AccessGrantService
+}
+
+// NewLiveMonitorHandler creates a new live monitor handler
+func NewLiveMonitorHandler(liveMonitorService *services.LiveMonitorService, authService *services.AuthService, apiKeyService *services.APIKeyService, accessGrantService *services.AccessGrantService) *LiveMonitorHandler {
+	return &LiveMonitorHandler{
+		liveMonitorService: liveMonitorService,
+		authService:        authService,
+		apiKeyService:      apiKeyService,
+		accessGrantService: accessGrantService,
+	}
+}
+
+// Monitor handles GET /api/v1/connections/:id/databases/:dbName/monitor (WebSocket; optional
+// ?topics=active_queries,locks,deadlocks and ?interval=10s). The client receives a snapshot
+// followed by deltas, and may send {"topics": [...], "interval": "..."} to change its subscription.
+func (h *LiveMonitorHandler) Monitor(c *gin.Context) {
+	var interval time.Duration
+	if param := c.Query("interval"); param != "" {
+		d, err := time.ParseDuration(param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid interval"})
+			return
+		}
+		interval = d
+	}
+
+	var topics []string
+	if param := c.Query("topics"); param != "" {
+		for _, topic := range strings.Split(param, ",") {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				topics = append(topics, topic)
+			}
+		}
+	}
+
+	sub, err := h.liveMonitorService.Subscribe(c.Param("id"), c.Param("dbName"), topics, interval)
+	if err != nil {
+		respondLiveMonitorError(c, err)
+		return
+	}
+	defer sub.Close()
+
+	// Authentication is the JWT checked by the middleware, so any origin may connect
+	check := h.accessCheck(c)
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		h.serve(ws, sub, check)
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// accessCheck returns a function that repeats the checks the middleware made on the request opening a
+// socket: the token or API key is neither expired nor revoked, the user is not blocked and an access
+// grant the connection requires is still active
+func (h *LiveMonitorHandler) accessCheck(c *gin.Context) func() error {
+	userID := c.GetString("userID")
+	role, _ := c.Get("role")
+	roleValue, _ := role.(models.UserRole)
+	tokenID := c.GetString("tokenID")
+	tokenExpiresAt := c.GetTime("tokenExpiresAt")
+	var apiKeyID string
+	if value, ok := c.Get("apiKey"); ok {
+		if apiKey, ok := value.(*models.APIKey); ok {
+			apiKeyID = apiKey.ID
+		}
+	}
+	connectionID, route := c.Param("id"), c.FullPath()
+
+	return func() error {
+		if apiKeyID != "" {
+			if err := h.apiKeyService.CheckKey(apiKeyID); err != nil {
+				return err
+			}
+		}
+		if err := h.authService.CheckSession(userID, tokenID, tokenExpiresAt); err != nil {
+			return err
+		}
+		return h.accessGrantService.CheckAccess(userID, roleValue, connectionID, http.MethodGet, route)
+	}
+}
+
+// serve pushes updates to the socket and applies the commands received on it until either side
+// closes the connection, the server shuts down or check fails
+func (h *LiveMonitorHandler) serve(ws *websocket.Conn, sub *services.LiveSubscription, check func() error) {
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			var data []byte
+			if err := websocket.Message.Receive(ws, &data); err != nil {
+				return
+			}
+
+			var cmd models.LiveMonitorCommand
+			err := json.Unmarshal(data, &cmd)
+			if err == nil {
+				err = sub.Update(&cmd)
+			}
+			if err != nil {
+				ws.SetWriteDeadline(time.Now().Add(liveMonitorWriteTimeout))
+				if websocket.JSON.Send(ws, &models.LiveMonitorMessage{
+					Type:      models.LiveMessageError,
+					Timestamp: time.Now().UTC(),
+					Error:     err.Error(),
+				}) != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	recheck := time.NewTicker(liveMonitorRecheckInterval)
+	defer recheck.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ws.Request().Context().Done():
+			return
+		case <-recheck.C:
+			if err := check(); err != nil {
+				ws.SetWriteDeadline(time.Now().Add(liveMonitorWriteTimeout))
+				websocket.JSON.Send(ws, &models.LiveMonitorMessage{
+					Type:      models.LiveMessageError,
+					Timestamp: time.Now().UTC(),
+					Error:     err.Error(),
+				})
+				return
+			}
+		case msg := <-sub.Updates:
+			ws.SetWriteDeadline(time.Now().Add(liveMonitorWriteTimeout))
+			if err := websocket.JSON.Send(ws, msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// respondLiveMonitorError maps live monitor errors to HTTP status codes
+func respondLiveMonitorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidLiveMonitor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrLiveMonitorBusy):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case err.Error() == "connection not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseLogExportFilter reads the from and to (RFC3339), user_id, operation and status query parameters of a log export
+func parseLogExportFilter(c *gin.Context) (models.LogExportFilter, error) {
+	filter := models.LogExportFilter{
+		UserID:    c.Query("user_id"),
+		Operation: c.Query("operation"),
+		Status:    c.Query("status"),
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from: %w", err)
+		}
+		filter.From = &from
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to: %w", err)
+		}
+		filter.To = &to
+	}
+
+	return filter, nil
+}
+
+// writeLogExport streams the entries of an operation log as a CSV (default) or JSON array
+// (?format=json) attachment. The response starts with the first entry, so errors before it
+// are still answered with an error status.
+func writeLogExport[T any](c *gin.Context, name string, header []string, record func(*T) []string, export func(func(*T) error) error) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or json"})
+		return
+	}
+
+	csvWriter := csv.NewWriter(c.Writer)
+	entries := 0
+	started := false
+	start := func() error {
+		started = true
+		filename := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102-150405"), format)
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		if format == "json" {
+			c.Header("Content-Type", "application/json")
+			c.Status(http.StatusOK)
+			_, err := c.Writer.WriteString("[")
+			return err
+		}
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		return csvWriter.Write(header)
+	}
+
+	err := export(func(entry *T) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if format == "json" {
+			raw, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if entries > 0 {
+				c.Writer.WriteString(",")
+			}
+			if _, err := c.Writer.Write(append([]byte("\n"), raw...)); err != nil {
+				return err
+			}
+		} else if err := csvWriter.Write(record(entry)); err != nil {
+			return err
+		}
+
+		// Flush regularly so large exports are not buffered in full
+		if entries++; entries%100 == 0 {
+			csvWriter.Flush()
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil && !started {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrLogFilterUnsupported) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		// Headers are sent; the truncated file is all that can be returned
+		return
+	}
+
+	if !started {
+		start()
+	}
+	if format == "json" {
+		c.Writer.WriteString("\n]\n")
+	}
+	csvWriter.Flush()
+	c.Writer.Flush()
+}
+
+// logTime formats the time of a log entry for CSV exports
+func logTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,625 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MonitoringHandler handles HTTP requests for monitoring metrics, timeline annotations,
+// saved view filters, alert silences, custom monitoring queries and monitored databases
+type MonitoringHandler struct {
+	databaseService   *services.DatabaseService
+	annotationService *services.AnnotationService
+	terminationLogs   *services.TerminationLogService
+	timeSeries        *services.TimeSeriesService
+	savedFilters      *services.SavedFilterService
+	alertSilences     *services.AlertSilenceService
+	deadlockHistory   *services.DeadlockHistoryService
+	customQueries     *services.CustomMonitoringService
+	monitored         *services.MonitoredDatabaseService
+}
+
+// NewMonitoringHandler creates a new monitoring handler
+func NewMonitoringHandler(databaseService *services.DatabaseService, annotationService *services.AnnotationService, terminationLogs *services.TerminationLogService, timeSeries *services.TimeSeriesService, savedFilters *services.SavedFilterService, alertSilences *services.AlertSilenceService, deadlockHistory *services.DeadlockHistoryService, customQueries *services.CustomMonitoringService, monitored *services.MonitoredDatabaseService) *MonitoringHandler {
+	return &MonitoringHandler{
+		databaseService:   databaseService,
+		annotationService: annotationService,
+		terminationLogs:   terminationLogs,
+		timeSeries:        timeSeries,
+		savedFilters:      savedFilters,
+		alertSilences:     alertSilences,
+		deadlockHistory:   deadlockHistory,
+		customQueries:     customQueries,
+		monitored:         monitored,
+	}
+}
+
+// GetMetrics handles GET /api/v1/connections/:id/databases/:dbName/metrics?from=...&to=...
+func (h *MonitoringHandler) GetMetrics(c *gin.Context) {
+	connectionID := c.Param("id")
+	dbName := c.Param("dbName")
+
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	metrics, err := h.databaseService.GetDatabaseMetrics(connectionID, dbName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	annotations, err := h.annotationService.GetAnnotations(connectionID, dbName, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MetricsWithAnnotations{
+		Metrics:     metrics,
+		Annotations: annotations,
+	})
+}
+
+// CreateAnnotation handles POST /api/v1/connections/:id/annotations
+func (h *MonitoringHandler) CreateAnnotation(c *gin.Context) {
+	connectionID := c.Param("id")
+
+	var req models.AnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+	username, _ := c.Get("username")
+	usernameStr := ""
+	if username != nil {
+		usernameStr = username.(string)
+	}
+
+	annotation, err := h.annotationService.CreateAnnotation(connectionID, userIDStr, usernameStr, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, annotation)
+}
+
+// GetAnnotations handles GET /api/v1/connections/:id/annotations?database=...&from=...&to=...
+func (h *MonitoringHandler) GetAnnotations(c *gin.Context) {
+	connectionID := c.Param("id")
+
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	annotations, err := h.annotationService.GetAnnotations(connectionID, c.Query("database"), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, annotations)
+}
+
+// DeleteAnnotation handles DELETE /api/v1/connections/:id/annotations/:annotationId
+func (h *MonitoringHandler) DeleteAnnotation(c *gin.Context) {
+	connectionID := c.Param("id")
+	annotationID := c.Param("annotationId")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	if err := h.annotationService.DeleteAnnotation(connectionID, annotationID, userIDStr, isAdmin(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// parseTimeRange reads optional RFC3339 from/to query parameters, defaulting to the last 24 hours
+func parseTimeRange(c *gin.Context) (time.Time, time.Time, error) {
+	to := time.Now()
+	from := to.Add(-24 * time.Hour)
+
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return from, to, err
+		}
+		to = parsed
+		from = to.Add(-24 * time.Hour)
+	}
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return from, to, err
+		}
+		from = parsed
+	}
+
+	return from, to, nil
+}
+
+// GetTerminationLogs handles GET /api/v1/connections/:id/monitoring/logs/terminations?database=...
+func (h *MonitoringHandler) GetTerminationLogs(c *gin.Context) {
+	connectionID := c.Param("id")
+	page := parsePage(c, 100)
+
+	logs, total, err := h.terminationLogs.GetLogs(connectionID, c.Query("database"), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": logs, "pagination": setPageHeaders(c, page, total)})
+}
+
+// GetDeadlockHistory handles GET /api/v1/connections/:id/databases/:dbName/deadlocks/history?from=...&to=...
+func (h *MonitoringHandler) GetDeadlockHistory(c *gin.Context) {
+	connectionID := c.Param("id")
+	dbName := c.Param("dbName")
+	page := parsePage(c, 100)
+
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, total, err := h.deadlockHistory.GetHistory(connectionID, dbName, from, to, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Tells an empty history apart from a server whose log cannot be read
+	collector, err := h.deadlockHistory.GetCollectorStatus(connectionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deadlocks": events, "collector": collector, "pagination": setPageHeaders(c, page, total)})
+}
+
+// GetMetricHistory handles GET /api/v1/connections/:id/databases/:dbName/metrics/history?metric=...&from=...&to=...&resolution=...&delta=...
+func (h *MonitoringHandler) GetMetricHistory(c *gin.Context) {
+	query, err := parseMetricQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series, err := h.timeSeries.Query(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// GetMetricHeatmap handles GET /api/v1/connections/:id/databases/:dbName/metrics/heatmap?metric=...&from=...&to=...&delta=...
+func (h *MonitoringHandler) GetMetricHeatmap(c *gin.Context) {
+	query, err := parseMetricQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	heatmap, err := h.timeSeries.Heatmap(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, heatmap)
+}
+
+// GetMetricForecast handles GET /api/v1/connections/:id/databases/:dbName/metrics/forecast?metric=...&from=...&to=...&horizon_days=...
+func (h *MonitoringHandler) GetMetricForecast(c *gin.Context) {
+	query, err := parseMetricQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Forecasts are fitted on 90 days of history unless a range is given
+	if c.Query("from") == "" {
+		query.From = query.To.AddDate(0, 0, -90)
+	}
+
+	horizonDays := 30
+	if horizonStr := c.Query("horizon_days"); horizonStr != "" {
+		parsed, err := strconv.Atoi(horizonStr)
+		if err != nil || parsed <= 0 || parsed > 365 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "horizon_days must be between 1 and 365"})
+			return
+		}
+		horizonDays = parsed
+	}
+
+	forecast, err := h.timeSeries.Forecast(query, horizonDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, forecast)
+}
+
+// parseMetricQuery reads the metric, time range, resolution and delta query parameters
+func parseMetricQuery(c *gin.Context) (models.MetricQuery, error) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		return models.MetricQuery{}, err
+	}
+
+	query := models.MetricQuery{
+		ConnectionID: c.Param("id"),
+		DatabaseName: c.Param("dbName"),
+		Metric:       c.Query("metric"),
+		From:         from,
+		To:           to,
+		Resolution:   models.MetricResolution(c.Query("resolution")),
+		Delta:        c.Query("delta") == "true",
+	}
+	if query.Metric == "" {
+		return query, fmt.Errorf("metric is required")
+	}
+	return query, nil
+}
+
+// GetSavedFilters handles GET /api/v1/monitoring/filters?view=active_queries&connection_id=...
+func (h *MonitoringHandler) GetSavedFilters(c *gin.Context) {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	filters, err := h.savedFilters.GetFilters(userIDStr, c.Query("view"), c.Query("connection_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, filters)
+}
+
+// CreateSavedFilter handles POST /api/v1/monitoring/filters
+func (h *MonitoringHandler) CreateSavedFilter(c *gin.Context) {
+	var req models.SavedFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	filter, err := h.savedFilters.CreateFilter(userIDStr, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, filter)
+}
+
+// UpdateSavedFilter handles PUT /api/v1/monitoring/filters/:filterId
+func (h *MonitoringHandler) UpdateSavedFilter(c *gin.Context) {
+	var req models.SavedFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	filter, err := h.savedFilters.UpdateFilter(c.Param("filterId"), userIDStr, isAdmin(c), &req)
+	if err != nil {
+		respondSavedFilterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, filter)
+}
+
+// DeleteSavedFilter handles DELETE /api/v1/monitoring/filters/:filterId
+func (h *MonitoringHandler) DeleteSavedFilter(c *gin.Context) {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	if err := h.savedFilters.DeleteFilter(c.Param("filterId"), userIDStr, isAdmin(c)); err != nil {
+		respondSavedFilterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// respondSavedFilterError maps saved filter service errors to HTTP status codes
+func respondSavedFilterError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSavedFilterForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "saved filter not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetAlertSilences handles GET /api/v1/connections/:id/alert-silences?include_expired=true
+func (h *MonitoringHandler) GetAlertSilences(c *gin.Context) {
+	silences, err := h.alertSilences.GetSilences(c.Param("id"), c.Query("include_expired") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, silences)
+}
+
+// CreateAlertSilence handles POST /api/v1/connections/:id/alert-silences
+func (h *MonitoringHandler) CreateAlertSilence(c *gin.Context) {
+	var req models.AlertSilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	silence, err := h.alertSilences.CreateSilence(c.Param("id"), userIDStr, &req)
+	if err != nil {
+		respondAlertSilenceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, silence)
+}
+
+// ExpireAlertSilence handles POST /api/v1/connections/:id/alert-silences/:silenceId/expire
+func (h *MonitoringHandler) ExpireAlertSilence(c *gin.Context) {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	silence, err := h.alertSilences.ExpireSilence(c.Param("id"), c.Param("silenceId"), userIDStr)
+	if err != nil {
+		respondAlertSilenceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, silence)
+}
+
+// respondAlertSilenceError maps alert silence service errors to HTTP status codes
+func respondAlertSilenceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAlertSilence):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "alert silence not found", err.Error() == "connection not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetCustomQueries handles GET /api/v1/connections/:id/monitoring/custom-queries
+func (h *MonitoringHandler) GetCustomQueries(c *gin.Context) {
+	queries, err := h.customQueries.GetQueries(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, queries)
+}
+
+// CreateCustomQuery handles POST /api/v1/connections/:id/monitoring/custom-queries
+func (h *MonitoringHandler) CreateCustomQuery(c *gin.Context) {
+	var req models.CustomMonitoringQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	query, err := h.customQueries.CreateQuery(c.Param("id"), &req, userIDStr)
+	if err != nil {
+		respondCustomQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, query)
+}
+
+// UpdateCustomQuery handles PUT /api/v1/connections/:id/monitoring/custom-queries/:queryId
+func (h *MonitoringHandler) UpdateCustomQuery(c *gin.Context) {
+	var req models.CustomMonitoringQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := h.customQueries.UpdateQuery(c.Param("id"), c.Param("queryId"), &req)
+	if err != nil {
+		respondCustomQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, query)
+}
+
+// DeleteCustomQuery handles DELETE /api/v1/connections/:id/monitoring/custom-queries/:queryId
+func (h *MonitoringHandler) DeleteCustomQuery(c *gin.Context) {
+	if err := h.customQueries.DeleteQuery(c.Param("id"), c.Param("queryId")); err != nil {
+		respondCustomQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// RunCustomQuery handles POST /api/v1/connections/:id/monitoring/custom-queries/:queryId/run
+func (h *MonitoringHandler) RunCustomQuery(c *gin.Context) {
+	query, err := h.customQueries.RunQuery(c.Param("id"), c.Param("queryId"))
+	if err != nil {
+		respondCustomQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, query)
+}
+
+// respondCustomQueryError maps custom monitoring query service errors to HTTP status codes
+func respondCustomQueryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCustomQuery):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "custom monitoring query not found", err.Error() == "connection not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetAllMonitoredDatabases handles GET /api/v1/monitoring/databases
+func (h *MonitoringHandler) GetAllMonitoredDatabases(c *gin.Context) {
+	databases, err := h.monitored.GetDatabases("")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, databases)
+}
+
+// GetMonitoredDatabases handles GET /api/v1/connections/:id/monitoring/databases
+func (h *MonitoringHandler) GetMonitoredDatabases(c *gin.Context) {
+	databases, err := h.monitored.GetDatabases(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, databases)
+}
+
+// CreateMonitoredDatabase handles POST /api/v1/connections/:id/monitoring/databases
+func (h *MonitoringHandler) CreateMonitoredDatabase(c *gin.Context) {
+	var req models.MonitoredDatabaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	monitored, err := h.monitored.CreateDatabase(c.Param("id"), userIDStr, &req)
+	if err != nil {
+		respondMonitoredDatabaseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, monitored)
+}
+
+// UpdateMonitoredDatabase handles PUT /api/v1/connections/:id/monitoring/databases/:monitoredId
+func (h *MonitoringHandler) UpdateMonitoredDatabase(c *gin.Context) {
+	var req models.MonitoredDatabaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	monitored, err := h.monitored.UpdateDatabase(c.Param("id"), c.Param("monitoredId"), &req)
+	if err != nil {
+		respondMonitoredDatabaseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, monitored)
+}
+
+// DeleteMonitoredDatabase handles DELETE /api/v1/connections/:id/monitoring/databases/:monitoredId
+func (h *MonitoringHandler) DeleteMonitoredDatabase(c *gin.Context) {
+	if err := h.monitored.DeleteDatabase(c.Param("id"), c.Param("monitoredId")); err != nil {
+		respondMonitoredDatabaseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// respondMonitoredDatabaseError maps monitored database service errors to HTTP status codes
+func respondMonitoredDatabaseError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMonitoredDatabase), errors.Is(err, services.ErrUnsupportedDialect):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMonitoredDatabaseExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err.Error() == "monitored database not found", err.Error() == "connection not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
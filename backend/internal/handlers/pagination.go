@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPageLimit caps the limit query parameter of list endpoints
+const maxPageLimit = 1000
+
+// PageInfo describes the page returned by a list endpoint
+type PageInfo struct {
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	Total  int64  `json:"total"`
+	Next   string `json:"next,omitempty"`
+	Prev   string `json:"prev,omitempty"`
+}
+
+// parsePage reads the limit and offset query parameters; defaultLimit 0 returns everything by default
+func parsePage(c *gin.Context, defaultLimit int) services.Page {
+	page := services.Page{Limit: defaultLimit}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			page.Limit = parsedLimit
+		}
+	}
+	if page.Limit > maxPageLimit {
+		page.Limit = maxPageLimit
+	}
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			page.Offset = parsedOffset
+		}
+	}
+	return page
+}
+
+// setPageHeaders describes the page with X-Total-Count and Link (rel next/prev) headers
+// and returns the same information for endpoints that include it in the body
+func setPageHeaders(c *gin.Context, page services.Page, total int64) PageInfo {
+	info := PageInfo{Limit: page.Limit, Offset: page.Offset, Total: total}
+
+	if page.Limit > 0 {
+		if int64(page.Offset+page.Limit) < total {
+			info.Next = pageURL(c, page.Limit, page.Offset+page.Limit)
+		}
+		if page.Offset > 0 {
+			prev := page.Offset - page.Limit
+			if prev < 0 {
+				prev = 0
+			}
+			info.Prev = pageURL(c, page.Limit, prev)
+		}
+	}
+
+	links := []string{}
+	if info.Next != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, info.Next))
+	}
+	if info.Prev != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, info.Prev))
+	}
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+
+	return info
+}
+
+// pageURL returns the request URL with the given limit and offset, keeping other query parameters
+func pageURL(c *gin.Context, limit, offset int) string {
+	query := url.Values{}
+	for key, values := range c.Request.URL.Query() {
+		query[key] = values
+	}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	return c.Request.URL.Path + "?" + query.Encode()
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PartitionHandler handles HTTP requests for partition maintenance policies
+type PartitionHandler struct {
+	partitionService *services.PartitionService
+}
+
+// NewPartitionHandler creates a new partition handler
+func NewPartitionHandler(partitionService *services.PartitionService) *PartitionHandler {
+	return &PartitionHandler{
+		partitionService: partitionService,
+	}
+}
+
+// CreatePolicy handles POST /api/v1/partition-policies
+func (h *PartitionHandler) CreatePolicy(c *gin.Context) {
+	var req models.PartitionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.partitionService.CreatePolicy(&req, c.GetString("userID"))
+	if err != nil {
+		respondPartitionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+// GetPolicies handles GET /api/v1/partition-policies
+func (h *PartitionHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.partitionService.GetPolicies(c.GetString("userID"))
+	if err != nil {
+		respondPartitionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+// GetPolicy handles GET /api/v1/partition-policies/:id
+func (h *PartitionHandler) GetPolicy(c *gin.Context) {
+	id := c.Param("id")
+
+	policy, err := h.partitionService.GetUserPolicy(id, c.GetString("userID"), false)
+	if err != nil {
+		respondPartitionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdatePolicy handles PUT /api/v1/partition-policies/:id
+func (h *PartitionHandler) UpdatePolicy(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.PartitionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.partitionService.UpdatePolicy(id, c.GetString("userID"), &req)
+	if err != nil {
+		respondPartitionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy handles DELETE /api/v1/partition-policies/:id
+func (h *PartitionHandler) DeletePolicy(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.partitionService.DeletePolicy(id, c.GetString("userID")); err != nil {
+		respondPartitionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetPlan handles GET /api/v1/partition-policies/:id/plan
+func (h *PartitionHandler) GetPlan(c *gin.Context) {
+	id := c.Param("id")
+
+	plan, err := h.partitionService.GetPlan(id, c.GetString("userID"))
+	if err != nil {
+		respondPartitionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// RunPolicy handles POST /api/v1/partition-policies/:id/run
+func (h *PartitionHandler) RunPolicy(c *gin.Context) {
+	id := c.Param("id")
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	entry, err := h.partitionService.RunPolicy(id, userIDStr, "manual")
+	if err != nil {
+		if entry == nil {
+			respondPartitionError(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "log": entry})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetLogs handles GET /api/v1/partition-policies/:id/logs
+func (h *PartitionHandler) GetLogs(c *gin.Context) {
+	id := c.Param("id")
+
+	page := parsePage(c, 100)
+
+	logs, total, err := h.partitionService.GetLogs(id, c.GetString("userID"), page)
+	if err != nil {
+		respondPartitionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": logs, "pagination": setPageHeaders(c, page, total)})
+}
+
+// respondPartitionError maps partition service errors to HTTP status codes
+func respondPartitionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAccessGrantRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "policy not found", strings.Contains(err.Error(), "connection not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionHandler handles HTTP requests for granular user permissions
+type PermissionHandler struct {
+	permissionService *services.PermissionService
+	authService       *services.AuthService
+}
+
+// NewPermissionHandler creates a new permission handler
+func NewPermissionHandler(permissionService *services.PermissionService, authService *services.AuthService) *PermissionHandler {
+	return &PermissionHandler{
+		permissionService: permissionService,
+		authService:       authService,
+	}
+}
+
+// GetCatalog handles GET /api/v1/permissions (admin only)
+func (h *PermissionHandler) GetCatalog(c *gin.Context) {
+	c.JSON(http.StatusOK, models.PermissionCatalog)
+}
+
+// GetMyPermissions handles GET /api/v1/permissions/me
+func (h *PermissionHandler) GetMyPermissions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	role, _ := c.Get("role")
+	roleValue, _ := role.(models.UserRole)
+
+	effective, err := h.permissionService.GetEffectivePermissions(userIDStr, roleValue)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, effective)
+}
+
+// GetUserPermissions handles GET /api/v1/permissions/users/:id (admin only)
+func (h *PermissionHandler) GetUserPermissions(c *gin.Context) {
+	user, err := h.authService.GetUserByID(c.Param("id"))
+	if err != nil {
+		respondPermissionError(c, err)
+		return
+	}
+
+	effective, err := h.permissionService.GetEffectivePermissions(user.ID, user.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, effective)
+}
+
+// SetUserPermissions handles PUT /api/v1/permissions/users/:id (admin only)
+func (h *PermissionHandler) SetUserPermissions(c *gin.Context) {
+	var req models.UserPermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	permissions, err := h.permissionService.SetUserPermissions(c.Param("id"), userIDStr, &req)
+	if err != nil {
+		respondPermissionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, permissions)
+}
+
+// ResetUserPermissions handles DELETE /api/v1/permissions/users/:id (admin only)
+func (h *PermissionHandler) ResetUserPermissions(c *gin.Context) {
+	if err := h.permissionService.ResetUserPermissions(c.Param("id")); err != nil {
+		respondPermissionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetGroups handles GET /api/v1/permissions/groups (admin only)
+func (h *PermissionHandler) GetGroups(c *gin.Context) {
+	groups, err := h.permissionService.GetGroups()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+// GetGroup handles GET /api/v1/permissions/groups/:id (admin only)
+func (h *PermissionHandler) GetGroup(c *gin.Context) {
+	group, err := h.permissionService.GetGroup(c.Param("id"))
+	if err != nil {
+		respondPermissionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// CreateGroup handles POST /api/v1/permissions/groups (admin only)
+func (h *PermissionHandler) CreateGroup(c *gin.Context) {
+	var req models.PermissionGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.permissionService.CreateGroup(&req)
+	if err != nil {
+		respondPermissionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// UpdateGroup handles PUT /api/v1/permissions/groups/:id (admin only)
+func (h *PermissionHandler) UpdateGroup(c *gin.Context) {
+	var req models.PermissionGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.permissionService.UpdateGroup(c.Param("id"), &req)
+	if err != nil {
+		respondPermissionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteGroup handles DELETE /api/v1/permissions/groups/:id (admin only)
+func (h *PermissionHandler) DeleteGroup(c *gin.Context) {
+	if err := h.permissionService.DeleteGroup(c.Param("id")); err != nil {
+		respondPermissionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// respondPermissionError maps permission service errors to HTTP status codes
+func respondPermissionError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "user not found", err.Error() == "permission group not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "permission group with this name already exists":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "invalid permission"), err.Error() == "permission group name is required":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
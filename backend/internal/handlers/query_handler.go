@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 	"truadmin/internal/models"
 	"truadmin/internal/services"
 
@@ -11,12 +13,14 @@ import (
 // QueryHandler handles HTTP requests for query execution
 type QueryHandler struct {
 	queryService *services.QueryService
+	history      *services.SQLHistoryService
 }
 
 // NewQueryHandler creates a new query handler
-func NewQueryHandler(queryService *services.QueryService) *QueryHandler {
+func NewQueryHandler(queryService *services.QueryService, history *services.SQLHistoryService) *QueryHandler {
 	return &QueryHandler{
 		queryService: queryService,
+		history:      history,
 	}
 }
 
@@ -30,22 +34,55 @@ func (h *QueryHandler) ExecuteQuery(c *gin.Context) {
 		return
 	}
 
-	result, err := h.queryService.ExecuteQuery(id, &req)
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	ctx, done, err := h.queryService.TrackQuery(c.Request.Context(), id, userIDStr, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondQueryError(c, err)
+		return
+	}
+	defer done()
+
+	started := time.Now()
+	result, err := h.queryService.ExecuteQuery(ctx, id, &req)
+	recordQueryResult(h.history, c, id, "", &req, started, result, err)
+	if err != nil {
+		respondQueryError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, result)
 }
 
+// CancelQuery handles DELETE /api/v1/connections/:id/queries/:queryId
+func (h *QueryHandler) CancelQuery(c *gin.Context) {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	if err := h.queryService.CancelQuery(c.Param("id"), c.Param("queryId"), userIDStr, isAdmin(c)); err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
 // GetTables handles GET /api/v1/connections/:id/tables
 func (h *QueryHandler) GetTables(c *gin.Context) {
 	id := c.Param("id")
 
 	tables, err := h.queryService.GetTables(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondQueryError(c, err)
 		return
 	}
 
@@ -59,7 +96,7 @@ func (h *QueryHandler) GetColumns(c *gin.Context) {
 
 	columns, err := h.queryService.GetColumns(id, table)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondQueryError(c, err)
 		return
 	}
 
@@ -77,3 +114,19 @@ func (h *QueryHandler) TestConnection(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"status": "connected"})
 }
+
+// respondQueryError maps query service errors to HTTP responses
+func respondQueryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUnsupportedDialect), errors.Is(err, services.ErrQueryNotPageable), errors.Is(err, services.ErrInvalidQueryCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrQueryIDInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrQueryCancelForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "connection not found", err.Error() == "table not found", err.Error() == "query not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
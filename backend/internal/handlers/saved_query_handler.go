@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SavedQueryHandler handles HTTP requests for saved queries
+type SavedQueryHandler struct {
+	savedQueries *services.SavedQueryService
+}
+
+// NewSavedQueryHandler creates a new saved query handler
+func NewSavedQueryHandler(savedQueries *services.SavedQueryService) *SavedQueryHandler {
+	return &SavedQueryHandler{
+		savedQueries: savedQueries,
+	}
+}
+
+// GetSavedQueries handles GET /api/v1/saved-queries?connection_id=...
+func (h *SavedQueryHandler) GetSavedQueries(c *gin.Context) {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	queries, err := h.savedQueries.GetQueries(userIDStr, c.Query("connection_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"queries": queries, "parameter_types": models.QueryParamTypes})
+}
+
+// GetSavedQuery handles GET /api/v1/saved-queries/:queryId
+func (h *SavedQueryHandler) GetSavedQuery(c *gin.Context) {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	saved, err := h.savedQueries.GetQuery(c.Param("queryId"), userIDStr, isAdmin(c))
+	if err != nil {
+		respondSavedQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// CreateSavedQuery handles POST /api/v1/saved-queries
+func (h *SavedQueryHandler) CreateSavedQuery(c *gin.Context) {
+	var req models.SavedQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	saved, err := h.savedQueries.CreateQuery(userIDStr, &req)
+	if err != nil {
+		respondSavedQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, saved)
+}
+
+// UpdateSavedQuery handles PUT /api/v1/saved-queries/:queryId
+func (h *SavedQueryHandler) UpdateSavedQuery(c *gin.Context) {
+	var req models.SavedQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	saved, err := h.savedQueries.UpdateQuery(c.Param("queryId"), userIDStr, isAdmin(c), &req)
+	if err != nil {
+		respondSavedQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteSavedQuery handles DELETE /api/v1/saved-queries/:queryId
+func (h *SavedQueryHandler) DeleteSavedQuery(c *gin.Context) {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	if err := h.savedQueries.DeleteQuery(c.Param("queryId"), userIDStr, isAdmin(c)); err != nil {
+		respondSavedQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// RunSavedQuery handles POST /api/v1/connections/:id/saved-queries/:queryId/run.
+// The route names the connection so its access grants apply; it must be the query's connection.
+func (h *SavedQueryHandler) RunSavedQuery(c *gin.Context) {
+	var req models.SavedQueryRunRequest
+	if c.Request.ContentLength != 0 {
+		if err := bindJSONExact(c, &req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	result, err := h.savedQueries.RunQuery(c.Request.Context(), c.Param("id"), c.Param("queryId"), userIDStr, isAdmin(c), req.Parameters)
+	if err != nil {
+		respondSavedQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// respondSavedQueryError maps saved query service errors to HTTP status codes
+func respondSavedQueryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidQueryParameters):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSavedQueryForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "saved query not found", strings.Contains(err.Error(), "connection not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
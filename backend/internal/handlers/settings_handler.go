@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SettingsHandler handles HTTP requests for deployment-wide settings
+type SettingsHandler struct {
+	settingsService *services.SettingsService
+}
+
+// NewSettingsHandler creates a new settings handler
+func NewSettingsHandler(settingsService *services.SettingsService) *SettingsHandler {
+	return &SettingsHandler{
+		settingsService: settingsService,
+	}
+}
+
+// GetBranding handles GET /api/v1/branding (public)
+func (h *SettingsHandler) GetBranding(c *gin.Context) {
+	branding, err := h.settingsService.GetBranding()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, branding)
+}
+
+// GetLogo handles GET /api/v1/branding/logo (public)
+func (h *SettingsHandler) GetLogo(c *gin.Context) {
+	data, contentType, err := h.settingsService.GetLogo()
+	if err != nil {
+		respondSettingsError(c, err)
+		return
+	}
+
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// UpdateBranding handles PUT /api/v1/settings/branding (admin only)
+func (h *SettingsHandler) UpdateBranding(c *gin.Context) {
+	var req models.BrandingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	branding, err := h.settingsService.UpdateBranding(&req, userIDStr)
+	if err != nil {
+		respondSettingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, branding)
+}
+
+// UploadLogo handles POST /api/v1/settings/branding/logo (admin only, multipart field "logo")
+func (h *SettingsHandler) UploadLogo(c *gin.Context) {
+	file, err := c.FormFile("logo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "logo file is required"})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	// Read one byte past the limit so oversized files are rejected by the service
+	data, err := io.ReadAll(io.LimitReader(f, services.MaxLogoBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	branding, err := h.settingsService.SetLogo(data, userIDStr)
+	if err != nil {
+		respondSettingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, branding)
+}
+
+// DeleteLogo handles DELETE /api/v1/settings/branding/logo (admin only)
+func (h *SettingsHandler) DeleteLogo(c *gin.Context) {
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	if err := h.settingsService.DeleteLogo(userIDStr); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// respondSettingsError maps settings service errors to HTTP status codes
+func respondSettingsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidSettings):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "logo not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetSMTPSettings handles GET /api/v1/settings/smtp (admin only)
+func (h *SettingsHandler) GetSMTPSettings(c *gin.Context) {
+	settings, err := h.settingsService.GetSMTPSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSMTPSettings handles PUT /api/v1/settings/smtp (admin only)
+func (h *SettingsHandler) UpdateSMTPSettings(c *gin.Context) {
+	var req models.SMTPSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	settings, err := h.settingsService.UpdateSMTPSettings(&req, userIDStr)
+	if err != nil {
+		respondSettingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// SendTestEmail handles POST /api/v1/settings/smtp/test (admin only)
+func (h *SettingsHandler) SendTestEmail(c *gin.Context) {
+	var req models.SMTPTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.settingsService.SendTestEmail(req.To); err != nil {
+		if errors.Is(err, services.ErrInvalidSettings) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Delivery failures are reported as a bad gateway so the SMTP error reaches the admin
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Test email sent", "to": req.To})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SnapshotHandler handles HTTP requests for query result snapshots
+type SnapshotHandler struct {
+	snapshotService *services.SnapshotService
+}
+
+// NewSnapshotHandler creates a new snapshot handler
+func NewSnapshotHandler(snapshotService *services.SnapshotService) *SnapshotHandler {
+	return &SnapshotHandler{
+		snapshotService: snapshotService,
+	}
+}
+
+// CreateSnapshot handles POST /api/v1/snapshots
+func (h *SnapshotHandler) CreateSnapshot(c *gin.Context) {
+	var req models.SnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	snapshot, err := h.snapshotService.CreateSnapshot(&req, userIDStr)
+	if err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, snapshot)
+}
+
+// GetSnapshots handles GET /api/v1/snapshots
+func (h *SnapshotHandler) GetSnapshots(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	snapshots, err := h.snapshotService.GetSnapshots(userIDStr, isAdmin(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshots)
+}
+
+// GetSnapshot handles GET /api/v1/snapshots/:id
+func (h *SnapshotHandler) GetSnapshot(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	snapshot, err := h.snapshotService.GetSnapshot(id, userIDStr, isAdmin(c))
+	if err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// GetSharedSnapshot handles GET /api/v1/snapshots/shared/:token
+func (h *SnapshotHandler) GetSharedSnapshot(c *gin.Context) {
+	token := c.Param("token")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	snapshot, err := h.snapshotService.GetSharedSnapshot(token, userIDStr, isAdmin(c))
+	if err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// ShareSnapshot handles POST /api/v1/snapshots/:id/share
+func (h *SnapshotHandler) ShareSnapshot(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.SnapshotShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	snapshot, err := h.snapshotService.ShareSnapshot(id, userIDStr, isAdmin(c), &req)
+	if err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"snapshot": snapshot,
+		"link":     "/api/v1/snapshots/shared/" + *snapshot.ShareToken,
+	})
+}
+
+// UnshareSnapshot handles DELETE /api/v1/snapshots/:id/share
+func (h *SnapshotHandler) UnshareSnapshot(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	if err := h.snapshotService.UnshareSnapshot(id, userIDStr, isAdmin(c)); err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// DeleteSnapshot handles DELETE /api/v1/snapshots/:id
+func (h *SnapshotHandler) DeleteSnapshot(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	if err := h.snapshotService.DeleteSnapshot(id, userIDStr, isAdmin(c)); err != nil {
+		respondSnapshotError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// respondSnapshotError maps snapshot service errors to HTTP status codes
+func respondSnapshotError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSnapshotForbidden), errors.Is(err, services.ErrAccessGrantRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "snapshot not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
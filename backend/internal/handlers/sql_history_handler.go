@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SQLHistoryHandler handles HTTP requests for the personal SQL history of the current user
+type SQLHistoryHandler struct {
+	history         *services.SQLHistoryService
+	databaseService *services.DatabaseService
+}
+
+// NewSQLHistoryHandler creates a new SQL history handler
+func NewSQLHistoryHandler(history *services.SQLHistoryService, databaseService *services.DatabaseService) *SQLHistoryHandler {
+	return &SQLHistoryHandler{
+		history:         history,
+		databaseService: databaseService,
+	}
+}
+
+// GetHistory handles GET /api/v1/sql-history?search=...&connection_id=...&starred=true
+func (h *SQLHistoryHandler) GetHistory(c *gin.Context) {
+	page := parsePage(c, 100)
+	filter := models.SQLHistoryFilter{
+		Search:       c.Query("search"),
+		ConnectionID: c.Query("connection_id"),
+		StarredOnly:  c.Query("starred") == "true",
+	}
+
+	entries, total, err := h.history.GetHistory(c.GetString("userID"), filter, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "pagination": setPageHeaders(c, page, total)})
+}
+
+// GetEntry handles GET /api/v1/sql-history/:entryId
+func (h *SQLHistoryHandler) GetEntry(c *gin.Context) {
+	entry, err := h.history.GetEntry(c.GetString("userID"), c.Param("entryId"))
+	if err != nil {
+		respondSQLHistoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// StarEntry handles PUT /api/v1/sql-history/:entryId/star
+func (h *SQLHistoryHandler) StarEntry(c *gin.Context) {
+	entry, err := h.history.SetStarred(c.GetString("userID"), c.Param("entryId"), true)
+	if err != nil {
+		respondSQLHistoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// UnstarEntry handles DELETE /api/v1/sql-history/:entryId/star
+func (h *SQLHistoryHandler) UnstarEntry(c *gin.Context) {
+	entry, err := h.history.SetStarred(c.GetString("userID"), c.Param("entryId"), false)
+	if err != nil {
+		respondSQLHistoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// DeleteEntry handles DELETE /api/v1/sql-history/:entryId
+func (h *SQLHistoryHandler) DeleteEntry(c *gin.Context) {
+	if err := h.history.DeleteEntry(c.GetString("userID"), c.Param("entryId")); err != nil {
+		respondSQLHistoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ClearHistory handles DELETE /api/v1/sql-history; starred entries are kept
+func (h *SQLHistoryHandler) ClearHistory(c *gin.Context) {
+	if err := h.history.ClearHistory(c.GetString("userID")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// RerunEntry handles POST /api/v1/connections/:id/sql-history/:entryId/run.
+// The route names the connection so its access grants apply; it must be the entry's connection.
+func (h *SQLHistoryHandler) RerunEntry(c *gin.Context) {
+	userID := c.GetString("userID")
+	entry, err := h.history.GetEntry(userID, c.Param("entryId"))
+	if err != nil {
+		respondSQLHistoryError(c, err)
+		return
+	}
+	if entry.ConnectionID != c.Param("id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "history entry not found"})
+		return
+	}
+
+	req := &models.QueryRequest{Query: entry.Query, Sandbox: entry.Sandbox}
+	ctx, done, err := h.databaseService.TrackQuery(c.Request.Context(), entry.ConnectionID, userID, req)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	defer done()
+
+	started := time.Now()
+	var result *models.QueryResult
+	if req.Sandbox {
+		result, err = h.databaseService.ExecuteSandboxQuery(ctx, entry.ConnectionID, entry.DatabaseName, req.Query)
+	} else {
+		result, err = h.databaseService.ExecuteQuery(ctx, entry.ConnectionID, entry.DatabaseName, req.Query)
+	}
+	recordQueryResult(h.history, c, entry.ConnectionID, entry.DatabaseName, req, started, result, err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// recordQueryResult adds a buffered query run by the current user to their SQL history.
+// Follow-up pages of a paginated query are not recorded again.
+func recordQueryResult(history *services.SQLHistoryService, c *gin.Context, connectionID, dbName string, req *models.QueryRequest, started time.Time, result *models.QueryResult, err error) {
+	if req.Cursor != "" {
+		return
+	}
+
+	var rowCount int64
+	message := ""
+	switch {
+	case err != nil:
+		message = err.Error()
+	case result.RowsAffected != nil:
+		rowCount = *result.RowsAffected
+		message = result.Error
+	default:
+		rowCount = int64(len(result.Rows))
+		message = result.Error
+	}
+	recordSQLHistory(history, c, connectionID, dbName, req, started, rowCount, message)
+}
+
+// recordSQLHistory adds a query run by the current user to their SQL history
+func recordSQLHistory(history *services.SQLHistoryService, c *gin.Context, connectionID, dbName string, req *models.QueryRequest, started time.Time, rowCount int64, errorMessage string) {
+	entry := &models.SQLHistoryEntry{
+		UserID:       c.GetString("userID"),
+		ConnectionID: connectionID,
+		DatabaseName: dbName,
+		Query:        req.Query,
+		Sandbox:      req.Sandbox,
+		Status:       models.AuditEventStatusSuccess,
+		ErrorMessage: errorMessage,
+		RowCount:     rowCount,
+		DurationMs:   time.Since(started).Milliseconds(),
+	}
+	if errorMessage != "" {
+		entry.Status = models.AuditEventStatusError
+	}
+	history.Record(entry)
+}
+
+// respondSQLHistoryError maps SQL history errors to HTTP status codes
+func respondSQLHistoryError(c *gin.Context, err error) {
+	if err.Error() == "history entry not found" {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
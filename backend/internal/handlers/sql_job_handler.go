@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SQLJobHandler handles HTTP requests for scheduled SQL jobs
+type SQLJobHandler struct {
+	sqlJobService *services.SQLJobService
+}
+
+// NewSQLJobHandler creates a new SQL job handler
+func NewSQLJobHandler(sqlJobService *services.SQLJobService) *SQLJobHandler {
+	return &SQLJobHandler{
+		sqlJobService: sqlJobService,
+	}
+}
+
+// GetJobs handles GET /api/v1/jobs (admin only)
+func (h *SQLJobHandler) GetJobs(c *gin.Context) {
+	jobs, err := h.sqlJobService.GetJobs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// GetJob handles GET /api/v1/jobs/:id (admin only)
+func (h *SQLJobHandler) GetJob(c *gin.Context) {
+	job, err := h.sqlJobService.GetJob(c.Param("id"))
+	if err != nil {
+		respondSQLJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// CreateJob handles POST /api/v1/jobs (admin only)
+func (h *SQLJobHandler) CreateJob(c *gin.Context) {
+	var req models.SQLJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	job, err := h.sqlJobService.CreateJob(&req, userIDStr)
+	if err != nil {
+		respondSQLJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, job)
+}
+
+// UpdateJob handles PUT /api/v1/jobs/:id (admin only)
+func (h *SQLJobHandler) UpdateJob(c *gin.Context) {
+	var req models.SQLJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.sqlJobService.UpdateJob(c.Param("id"), &req)
+	if err != nil {
+		respondSQLJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// DeleteJob handles DELETE /api/v1/jobs/:id (admin only)
+func (h *SQLJobHandler) DeleteJob(c *gin.Context) {
+	if err := h.sqlJobService.DeleteJob(c.Param("id")); err != nil {
+		respondSQLJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// RunJob handles POST /api/v1/jobs/:id/run (admin only); the run is queued and returned
+func (h *SQLJobHandler) RunJob(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	run, err := h.sqlJobService.RunJob(c.Param("id"), userIDStr)
+	if err != nil {
+		respondSQLJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, run)
+}
+
+// GetRuns handles GET /api/v1/jobs/:id/runs (admin only)
+func (h *SQLJobHandler) GetRuns(c *gin.Context) {
+	page := parsePage(c, 100)
+
+	runs, total, err := h.sqlJobService.GetRuns(c.Param("id"), page)
+	if err != nil {
+		respondSQLJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs, "pagination": setPageHeaders(c, page, total)})
+}
+
+// GetRun handles GET /api/v1/jobs/:id/runs/:runId (admin only)
+func (h *SQLJobHandler) GetRun(c *gin.Context) {
+	run, err := h.sqlJobService.GetRun(c.Param("id"), c.Param("runId"))
+	if err != nil {
+		respondSQLJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// respondSQLJobError maps SQL job errors to HTTP status codes
+func respondSQLJobError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidSQLJob):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSQLJobBusy):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err.Error() == "SQL job not found", err.Error() == "SQL job run not found", err.Error() == "connection not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SystemHandler handles HTTP requests about the server itself
+type SystemHandler struct {
+	selfCheckService  *services.SelfCheckService
+	connectionService *services.ConnectionService
+	clusterService    *services.ClusterService
+	auditBackfill     *services.AuditBackfillService
+}
+
+// NewSystemHandler creates a new system handler
+func NewSystemHandler(selfCheckService *services.SelfCheckService, connectionService *services.ConnectionService, clusterService *services.ClusterService, auditBackfill *services.AuditBackfillService) *SystemHandler {
+	return &SystemHandler{
+		selfCheckService:  selfCheckService,
+		connectionService: connectionService,
+		clusterService:    clusterService,
+		auditBackfill:     auditBackfill,
+	}
+}
+
+// SelfCheck handles GET /api/v1/system/selfcheck
+func (h *SystemHandler) SelfCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, h.selfCheckService.Run())
+}
+
+// GetConnectionPools handles GET /api/v1/system/connection-pools
+func (h *SystemHandler) GetConnectionPools(c *gin.Context) {
+	c.JSON(http.StatusOK, h.connectionService.PoolStats())
+}
+
+// GetReplicas handles GET /api/v1/system/replicas
+func (h *SystemHandler) GetReplicas(c *gin.Context) {
+	status, err := h.clusterService.GetStatus()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// GetAuditBackfill handles GET /api/v1/system/audit-backfill
+func (h *SystemHandler) GetAuditBackfill(c *gin.Context) {
+	states, err := h.auditBackfill.GetStates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sources": states})
+}
@@ -1,8 +1,15 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 	"truadmin/internal/models"
 	"truadmin/internal/services"
 
@@ -11,15 +18,17 @@ import (
 
 // TruETLHandler handles HTTP requests for TruETL databases
 type TruETLHandler struct {
-	truETLService *services.TruETLService
-	logService    *services.TruETLLogService
+	truETLService   *services.TruETLService
+	logService      *services.TruETLLogService
+	terminationLogs *services.TerminationLogService
 }
 
 // NewTruETLHandler creates a new TruETL handler
-func NewTruETLHandler(truETLService *services.TruETLService, logService *services.TruETLLogService) *TruETLHandler {
+func NewTruETLHandler(truETLService *services.TruETLService, logService *services.TruETLLogService, terminationLogs *services.TerminationLogService) *TruETLHandler {
 	return &TruETLHandler{
-		truETLService: truETLService,
-		logService:    logService,
+		truETLService:   truETLService,
+		logService:      logService,
+		terminationLogs: terminationLogs,
 	}
 }
 
@@ -27,7 +36,7 @@ func NewTruETLHandler(truETLService *services.TruETLService, logService *service
 func (h *TruETLHandler) GetEligibleDatabases(c *gin.Context) {
 	connectionID := c.Param("connectionId")
 
-	databases, err := h.truETLService.GetEligibleDatabases(connectionID)
+	databases, err := h.truETLService.GetEligibleDatabases(c.Request.Context(), connectionID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -53,6 +62,72 @@ func (h *TruETLHandler) AddDatabase(c *gin.Context) {
 	c.JSON(http.StatusCreated, database)
 }
 
+// InitializeSchema handles POST /api/v1/truetl/connections/:connectionId/schema
+func (h *TruETLHandler) InitializeSchema(c *gin.Context) {
+	connectionID := c.Param("connectionId")
+
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	var req models.SchemaBootstrapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.truETLService.InitializeSchema(c.Request.Context(), connectionID, &req, userIDStr)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSchemaAlreadyInitialized):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case err.Error() == "connection not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case entry != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "log": entry})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetSchemaLogs handles GET /api/v1/truetl/connections/:connectionId/schema/logs
+func (h *TruETLHandler) GetSchemaLogs(c *gin.Context) {
+	connectionID := c.Param("connectionId")
+
+	page := parsePage(c, 100)
+
+	logs, total, err := h.truETLService.GetSchemaLogs(connectionID, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": logs, "pagination": setPageHeaders(c, page, total)})
+}
+
+// AddEligibleDatabases handles POST /api/v1/truetl/databases/bulk
+func (h *TruETLHandler) AddEligibleDatabases(c *gin.Context) {
+	var req models.BulkDatabaseRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.truETLService.AddEligibleDatabases(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // GetDatabases handles GET /api/v1/truetl/databases
 func (h *TruETLHandler) GetDatabases(c *gin.Context) {
 	databases, err := h.truETLService.GetDatabases()
@@ -108,17 +183,314 @@ func (h *TruETLHandler) DeleteDatabase(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// RepointDatabase handles POST /api/v1/truetl/databases/:id/repoint
+func (h *TruETLHandler) RepointDatabase(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.RepointDatabaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	database, err := h.truETLService.RepointDatabase(c.Request.Context(), id, &req)
+	if err != nil {
+		var mismatch *services.RepointSchemaError
+		switch {
+		case errors.As(err, &mismatch):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "missing": mismatch.Missing})
+		case err.Error() == "TruETL database not found" || err.Error() == "connection not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case err.Error() == "database already added to TruETL":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, database)
+}
+
 // GetDMSTables handles GET /api/v1/truetl/databases/:id/tables
 func (h *TruETLHandler) GetDMSTables(c *gin.Context) {
 	id := c.Param("id")
 
-	tables, err := h.truETLService.GetDMSTables(id)
+	page := parsePage(c, 0)
+
+	tables, err := h.truETLService.GetDMSTables(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tables":     services.PageSlice(tables, page),
+		"pagination": setPageHeaders(c, page, int64(len(tables))),
+	})
+}
+
+// CheckTargetReadiness handles POST /api/v1/truetl/databases/:id/readiness
+func (h *TruETLHandler) CheckTargetReadiness(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.TruETLReadinessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.truETLService.CheckTargetReadiness(c.Request.Context(), id, &req)
+	if err != nil {
+		if err.Error() == "mapping table not found" || err.Error() == "connection not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ValidateMappings handles POST /api/v1/truetl/databases/:id/validate
+func (h *TruETLHandler) ValidateMappings(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.TruETLValidationRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	report, err := h.truETLService.ValidateMappings(c.Request.Context(), id, &req)
+	if err != nil {
+		if err.Error() == "TruETL database not found" || err.Error() == "connection not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportMappings handles GET /api/v1/truetl/databases/:id/mappings/export?format=json|yaml
+func (h *TruETLHandler) ExportMappings(c *gin.Context) {
+	id := c.Param("id")
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "yaml" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or yaml"})
+		return
+	}
+
+	doc, err := h.truETLService.ExportMappings(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "TruETL database not found" || err.Error() == "connection not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := services.EncodeMappingDocument(doc, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "application/json"
+	if format == "yaml" {
+		contentType = "application/yaml"
+	}
+	filename := fmt.Sprintf("truetl-mappings-%s.%s", doc.Database, format)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// ImportMappings handles POST /api/v1/truetl/databases/:id/mappings/import?mode=dry_run|apply&format=json|yaml
+func (h *TruETLHandler) ImportMappings(c *gin.Context) {
+	id := c.Param("id")
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = "json"
+		if strings.Contains(c.ContentType(), "yaml") {
+			format = "yaml"
+		}
+	}
+	if format != "json" && format != "yaml" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or yaml"})
+		return
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	doc, err := services.ParseMappingDocument(data, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mode := c.DefaultQuery("mode", models.MappingImportDryRun)
+	result, err := h.truETLService.ImportMappings(c.Request.Context(), id, userIDStr, mode, doc, h.logService)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidMappingDocument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "TruETL database not found" || err.Error() == "connection not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ReportRun handles POST /api/v1/truetl/databases/:id/runs
+func (h *TruETLHandler) ReportRun(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.TruETLRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	run, created, err := h.truETLService.ReportRun(id, &req, userIDStr)
+	if err != nil {
+		if errors.Is(err, services.ErrTruETLRunTableMismatch) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "TruETL database not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if created {
+		c.JSON(http.StatusCreated, run)
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// GetRuns handles GET /api/v1/truetl/databases/:id/runs
+func (h *TruETLHandler) GetRuns(c *gin.Context) {
+	id := c.Param("id")
+
+	filter := models.TruETLRunFilter{
+		ServiceName:     c.Query("service_name"),
+		SourceDbName:    c.Query("source_db_name"),
+		SourceTableName: c.Query("source_table_name"),
+		Status:          models.TruETLRunStatus(c.Query("status")),
+	}
+	page := parsePage(c, 100)
+
+	runs, total, err := h.truETLService.GetRuns(id, filter, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"tables": tables})
+	c.JSON(http.StatusOK, gin.H{"runs": runs, "pagination": setPageHeaders(c, page, total)})
+}
+
+// GetRunBoard handles GET /api/v1/truetl/databases/:id/runs/board
+func (h *TruETLHandler) GetRunBoard(c *gin.Context) {
+	id := c.Param("id")
+
+	hours := 24
+	if raw := c.Query("hours"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > 720 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "hours must be between 1 and 720"})
+			return
+		}
+		hours = parsed
+	}
+
+	board, err := h.truETLService.GetRunBoard(c.Request.Context(), id, time.Now().UTC().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		if err.Error() == "TruETL database not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+// GetLineage handles GET /api/v1/truetl/databases/:id/lineage
+func (h *TruETLHandler) GetLineage(c *gin.Context) {
+	id := c.Param("id")
+
+	graph, err := h.truETLService.GetLineage(c.Request.Context(), id, c.Query("service_name"))
+	if err != nil {
+		if err.Error() == "TruETL database not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
+
+// GetLineageImpact handles GET /api/v1/truetl/databases/:id/lineage/impact
+func (h *TruETLHandler) GetLineageImpact(c *gin.Context) {
+	id := c.Param("id")
+
+	dbName := c.Query("db_name")
+	tableName := c.Query("table_name")
+	columnName := c.Query("column_name")
+	if dbName == "" || tableName == "" || columnName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "db_name, table_name and column_name are required"})
+		return
+	}
+
+	impact, err := h.truETLService.GetLineageImpact(c.Request.Context(), id, dbName, c.Query("schema_name"), tableName, columnName)
+	if err != nil {
+		if err.Error() == "TruETL database not found" || err.Error() == "column not found in lineage" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, impact)
 }
 
 // GetDMSFields handles POST /api/v1/truetl/databases/:id/fields
@@ -134,7 +506,7 @@ func (h *TruETLHandler) GetDMSFields(c *gin.Context) {
 		return
 	}
 
-	fields, err := h.truETLService.GetDMSFields(id, req.TableIDs)
+	fields, err := h.truETLService.GetDMSFields(c.Request.Context(), id, req.TableIDs)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -157,7 +529,7 @@ func (h *TruETLHandler) SaveDMSFields(c *gin.Context) {
 		return
 	}
 
-	if err := h.truETLService.SaveDMSFields(id, req.TableKey, req.Changes); err != nil {
+	if err := h.truETLService.SaveDMSFields(c.Request.Context(), id, req.TableKey, req.Changes); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -191,7 +563,12 @@ func (h *TruETLHandler) SaveAllChanges(c *gin.Context) {
 		return
 	}
 
-	if err := h.truETLService.SaveAllChanges(id, userIDStr, &req, h.logService); err != nil {
+	if err := h.truETLService.SaveAllChanges(c.Request.Context(), id, userIDStr, &req, h.logService); err != nil {
+		var blocked *services.SaveBlockedError
+		if errors.As(err, &blocked) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "blockers": models.NonNil(blocked.Blockers)})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -199,23 +576,98 @@ func (h *TruETLHandler) SaveAllChanges(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "All changes saved successfully"})
 }
 
+// KillBlockersAndRetrySave handles POST /api/v1/truetl/databases/:id/save-all/kill-and-retry
+func (h *TruETLHandler) KillBlockersAndRetrySave(c *gin.Context) {
+	id := c.Param("id")
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	var req services.KillAndRetrySaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if h.logService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "log service is not initialized"})
+		return
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "terminated to retry a blocked TruETL save"
+	}
+
+	entries, err := h.truETLService.KillBlockersAndRetrySave(c.Request.Context(), id, userIDStr, req.PIDs, &req.Changes, h.logService)
+
+	terminated := []int{}
+	for _, entry := range entries {
+		entry.Reason = reason
+		if entry.Terminated {
+			terminated = append(terminated, entry.PID)
+		}
+		if h.terminationLogs != nil {
+			h.terminationLogs.LogTermination(entry)
+		}
+	}
+
+	if err != nil {
+		var blocked *services.SaveBlockedError
+		switch {
+		case errors.As(err, &blocked):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "blockers": models.NonNil(blocked.Blockers), "terminated": terminated})
+		case errors.Is(err, services.ErrNoBlockersToKill):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "terminated": terminated})
+		case err.Error() == "TruETL database not found" || err.Error() == "connection not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "terminated": terminated})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All changes saved successfully", "terminated": terminated})
+}
+
 // GetSaveLogs handles GET /api/v1/truetl/databases/:id/logs
 func (h *TruETLHandler) GetSaveLogs(c *gin.Context) {
 	id := c.Param("id")
 	
-	// Get optional limit query parameter
-	limit := 100 // default
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
-	}
+	page := parsePage(c, 100)
 	
-	logs, err := h.logService.GetSaveLogs(id, limit)
+	logs, total, err := h.logService.GetSaveLogs(id, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	
-	c.JSON(http.StatusOK, gin.H{"logs": logs})
+	c.JSON(http.StatusOK, gin.H{"logs": logs, "pagination": setPageHeaders(c, page, total)})
+}
+
+// ExportSaveLogs handles GET /api/v1/truetl/databases/:id/logs/export?format=csv|json&from=...&to=...&user_id=...&status=...
+func (h *TruETLHandler) ExportSaveLogs(c *gin.Context) {
+	id := c.Param("id")
+
+	filter, err := parseLogExportFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	header := []string{"id", "created_at", "truetl_database_id", "user_id", "status", "execution_time_ms", "changes_summary", "error_message", "sql_script"}
+	writeLogExport(c, "truetl-logs", header,
+		func(entry *models.TruETLSaveLog) []string {
+			summary, _ := json.Marshal(entry.ChangesSummary)
+			return []string{
+				strconv.Itoa(entry.ID), logTime(entry.CreatedAt), entry.TruETLDatabaseID, entry.UserID, string(entry.Status),
+				strconv.Itoa(entry.ExecutionTimeMs), string(summary), entry.ErrorMessage, entry.SQLScript,
+			}
+		},
+		func(fn func(*models.TruETLSaveLog) error) error {
+			return h.logService.ExportSaveLogs(id, filter, fn)
+		})
 }
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageHandler handles HTTP requests for module usage reports
+type UsageHandler struct {
+	usageService *services.UsageService
+}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler(usageService *services.UsageService) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+	}
+}
+
+// GetReport handles GET /api/v1/usage?days=30 (admin only)
+func (h *UsageHandler) GetReport(c *gin.Context) {
+	days, ok := parseUsageDays(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.usageService.GetReport(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Export handles GET /api/v1/usage/export?days=30&anonymized=true (admin only)
+func (h *UsageHandler) Export(c *gin.Context) {
+	days, ok := parseUsageDays(c)
+	if !ok {
+		return
+	}
+	anonymized := c.Query("anonymized") == "true"
+
+	export, err := h.usageService.Export(days, anonymized)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=usage-"+export.GeneratedAt.Format("20060102")+".json")
+	c.JSON(http.StatusOK, export)
+}
+
+// parseUsageDays reads the days query parameter, writing a 400 response when it is invalid
+func parseUsageDays(c *gin.Context) (int, bool) {
+	days := 30
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > 400 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 400"})
+			return 0, false
+		}
+		days = parsed
+	}
+	return days, true
+}
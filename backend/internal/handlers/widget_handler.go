@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WidgetSessionHeader carries the widget session with each check from the widget page
+const WidgetSessionHeader = "X-Widget-Session"
+
+// WidgetHandler handles HTTP requests for widget tokens and the embeddable address check widget
+type WidgetHandler struct {
+	widgetService *services.WidgetService
+}
+
+// NewWidgetHandler creates a new widget handler
+func NewWidgetHandler(widgetService *services.WidgetService) *WidgetHandler {
+	return &WidgetHandler{
+		widgetService: widgetService,
+	}
+}
+
+// GetTokens handles GET /api/v1/widget-tokens (admin only)
+func (h *WidgetHandler) GetTokens(c *gin.Context) {
+	tokens, err := h.widgetService.GetTokens()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateToken handles POST /api/v1/widget-tokens (admin only); the token is only returned here
+func (h *WidgetHandler) CreateToken(c *gin.Context) {
+	var req models.WidgetTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := c.Get("userID")
+	userIDStr := ""
+	if userID != nil {
+		userIDStr = userID.(string)
+	}
+
+	token, err := h.widgetService.CreateToken(&req, userIDStr)
+	if err != nil {
+		respondWidgetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// RevokeToken handles DELETE /api/v1/widget-tokens/:id (admin only)
+func (h *WidgetHandler) RevokeToken(c *gin.Context) {
+	if err := h.widgetService.RevokeToken(c.Param("id")); err != nil {
+		respondWidgetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// Page handles GET /api/v1/widget/check-address?token=... (public, authorized by the widget token).
+// It serves the self-contained check form to embed in an iframe; the form posts back to this
+// server, so the embedding site needs no CORS access.
+func (h *WidgetHandler) Page(c *gin.Context) {
+	nonce := widgetNonce()
+	data := gin.H{"Nonce": nonce}
+
+	session, err := h.widgetService.OpenSession(c.Query("token"), c.GetHeader("Referer"), c.ClientIP())
+	if err != nil {
+		// Error pages may be framed anywhere, so the embedding site shows why the widget is unavailable
+		data["Error"] = widgetErrorMessage(err)
+		renderWidgetPage(c, widgetStatus(err), nonce, []string{"*"}, data)
+		return
+	}
+
+	data["Session"] = session.Session
+	renderWidgetPage(c, http.StatusOK, nonce, session.Token.AllowedOrigins, data)
+}
+
+// Check handles POST /api/v1/widget/check-address (public, authorized by the X-Widget-Session header)
+func (h *WidgetHandler) Check(c *gin.Context) {
+	var req models.WidgetCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "address1, city, state, zip and programType are required"})
+		return
+	}
+
+	result, err := h.widgetService.Check(c.Request.Context(), c.GetHeader(WidgetSessionHeader), c.ClientIP(), &req)
+	if err != nil {
+		respondWidgetError(c, err)
+		return
+	}
+
+	// Only the outcome is returned; the steps carry details of the HohAddress database
+	c.JSON(http.StatusOK, gin.H{"success": result.Success, "finalMessage": result.FinalMessage})
+}
+
+// renderWidgetPage writes the widget page. The content security policy only allows the page's own
+// inline script and style, requests to this server and framing by the allowed origins.
+func renderWidgetPage(c *gin.Context, status int, nonce string, frameAncestors []string, data gin.H) {
+	ancestors := strings.Join(frameAncestors, " ")
+	if ancestors == "" {
+		ancestors = "'none'"
+	}
+	c.Header("Content-Security-Policy", "default-src 'none'; script-src 'nonce-"+nonce+"'; style-src 'nonce-"+nonce+
+		"'; connect-src 'self'; form-action 'self'; base-uri 'none'; frame-ancestors "+ancestors)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := widgetPageTemplate.Execute(c.Writer, data); err != nil {
+		c.Error(err)
+	}
+}
+
+// widgetNonce returns a random nonce for the inline script and style of the widget page
+func widgetNonce() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+// widgetStatus maps widget errors to HTTP status codes
+func widgetStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidWidgetToken):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrWidgetUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrWidgetOriginNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrWidgetRateLimited):
+		return http.StatusTooManyRequests
+	case err.Error() == "widget token not found", err.Error() == "HohAddress database not found":
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// widgetErrorMessage returns the message shown on the widget page; internal errors are not shown to visitors
+func widgetErrorMessage(err error) string {
+	if widgetStatus(err) == http.StatusInternalServerError {
+		return "The address check is not available right now."
+	}
+	return err.Error()
+}
+
+// respondWidgetError maps widget errors to HTTP status codes
+func respondWidgetError(c *gin.Context, err error) {
+	status := widgetStatus(err)
+	if status == http.StatusTooManyRequests {
+		c.Header("Retry-After", "60")
+	}
+	c.JSON(status, gin.H{"error": widgetErrorMessage(err)})
+}
+
+// widgetPageTemplate is the embeddable address check form
+var widgetPageTemplate = template.Must(template.New("widget").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Address check</title>
+<style nonce="{{.Nonce}}">
+body { font-family: system-ui, sans-serif; font-size: 14px; margin: 0; padding: 12px; color: #222; }
+form { display: grid; gap: 8px; max-width: 420px; }
+label { display: grid; gap: 2px; }
+input, select, button { font: inherit; padding: 6px; }
+.row { display: grid; grid-template-columns: 2fr 1fr 1fr; gap: 8px; }
+.result { margin-top: 12px; padding: 8px; border-radius: 4px; background: #f3f4f6; }
+.error { color: #b91c1c; }
+</style>
+</head>
+<body>
+{{if .Error}}
+<p class="error">{{.Error}}</p>
+{{else}}
+<form id="check">
+<label>Address <input name="address1" required autocomplete="address-line1"></label>
+<label>Address line 2 <input name="address2" autocomplete="address-line2"></label>
+<div class="row">
+<label>City <input name="city" required autocomplete="address-level2"></label>
+<label>State <input name="state" required maxlength="2" autocomplete="address-level1"></label>
+<label>ZIP <input name="zip" required autocomplete="postal-code"></label>
+</div>
+<label>Program <input name="programType" required></label>
+<button type="submit">Check address</button>
+</form>
+<div id="result" class="result" hidden></div>
+<script nonce="{{.Nonce}}">
+(function () {
+  var session = {{.Session}};
+  var form = document.getElementById("check");
+  var result = document.getElementById("result");
+  function show(text, isError) {
+    result.hidden = false;
+    result.className = isError ? "result error" : "result";
+    result.textContent = text;
+  }
+  form.addEventListener("submit", function (event) {
+    event.preventDefault();
+    var body = {};
+    new FormData(form).forEach(function (value, key) { body[key] = value; });
+    form.querySelector("button").disabled = true;
+    fetch(location.pathname, {
+      method: "POST",
+      headers: {"Content-Type": "application/json", "X-Widget-Session": session},
+      body: JSON.stringify(body)
+    }).then(function (response) {
+      return response.json().then(function (data) {
+        if (response.status === 401) {
+          show("Your session has expired, please reload the page.", true);
+        } else if (!response.ok) {
+          show(data.error || "The address check failed.", true);
+        } else {
+          show(data.finalMessage || (data.success ? "The address is eligible." : "The address is not eligible."), data.success !== 1);
+        }
+      });
+    }).catch(function () {
+      show("The address check failed.", true);
+    }).finally(function () {
+      form.querySelector("button").disabled = false;
+    });
+  });
+})();
+</script>
+{{end}}
+</body>
+</html>
+`))
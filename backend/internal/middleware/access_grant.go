@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+)
+
+// registeredDatabaseRoutes maps the route prefixes naming a TruETL or HohAddress database
+// registration by :id to the model the registration is stored as
+var registeredDatabaseRoutes = map[string]any{
+	"/api/v1/truetl/databases/:id":     &models.TruETLDatabase{},
+	"/api/v1/hohaddress/databases/:id": &models.HohAddressDatabase{},
+}
+
+// ConnectionAccess rejects requests of non-admin users on connections that require an access grant
+// unless the user holds an active grant whose scope covers the request. It covers the connection
+// routes, the TruETL and HohAddress routes naming a :connectionId and those naming a registered
+// database, whose connection is looked up; features that take the connection from the body or span
+// every connection check access in their services. Use it after AuthMiddleware.
+func ConnectionAccess(accessGrantService *services.AccessGrantService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		connectionID := c.Param("connectionId")
+		if strings.HasPrefix(route, "/api/v1/connections/:id") {
+			connectionID = c.Param("id")
+		}
+		for prefix, registration := range registeredDatabaseRoutes {
+			if route != prefix && !strings.HasPrefix(route, prefix+"/") {
+				continue
+			}
+			var err error
+			connectionID, err = accessGrantService.RegisteredConnectionID(registration, c.Param("id"))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				c.Abort()
+				return
+			}
+		}
+		if connectionID == "" {
+			c.Next()
+			return
+		}
+
+		role, _ := c.Get("role")
+		roleValue, _ := role.(models.UserRole)
+
+		err := accessGrantService.CheckAccess(c.GetString("userID"), roleValue, connectionID, c.Request.Method, route)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, services.ErrAccessGrantRequired) {
+				status = http.StatusForbidden
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
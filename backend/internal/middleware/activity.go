@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+)
+
+// maxRecordedBodyBytes caps the request body kept for a break-glass activity event
+const maxRecordedBodyBytes = 16 * 1024
+
+// Activity records authenticated API calls for the per-user activity summary.
+// Calls on a connection are always recorded; other calls only when they change something,
+// so polling endpoints do not drown out real actions. While a user holds break-glass access
+// every call is recorded together with its request body. Use it after AuthMiddleware.
+func Activity(activityService *services.ActivityService, accessGrantService *services.AccessGrantService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		enhanced := userID != "" && accessGrantService.InEmergency(userID)
+
+		detail := ""
+		if enhanced && c.Request.Body != nil && c.Request.Method != http.MethodGet &&
+			!strings.HasPrefix(c.ContentType(), "multipart/") {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxRecordedBodyBytes+1))
+			if err == nil {
+				// Hand the handler the full body: the part read so far followed by the rest
+				c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+				if len(body) > maxRecordedBodyBytes {
+					body = append(body[:maxRecordedBodyBytes], []byte("...")...)
+				}
+				detail = string(body)
+			}
+		}
+
+		c.Next()
+
+		route := c.FullPath()
+		if userID == "" || route == "" {
+			return
+		}
+
+		connectionID := ""
+		if strings.HasPrefix(route, "/api/v1/connections/:id") {
+			connectionID = c.Param("id")
+		}
+		if !enhanced && connectionID == "" && c.Request.Method == http.MethodGet {
+			return
+		}
+
+		activityService.Record(models.UserActivityEvent{
+			UserID:       userID,
+			Action:       c.Request.Method + " " + route,
+			ConnectionID: connectionID,
+			StatusCode:   c.Writer.Status(),
+			Detail:       detail,
+		})
+	}
+}
@@ -10,11 +10,23 @@ import (
 	"truadmin/internal/services"
 )
 
-// AuthMiddleware creates a middleware that validates JWT tokens
-func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
+// AuthMiddleware creates a middleware that validates JWT tokens, or API keys sent in the X-API-Key header
+// on the routes listed in apiKeyRoutes
+func AuthMiddleware(authService *services.AuthService, apiKeyService *services.APIKeyService, apiKeyRoutes APIKeyRoutes) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader(APIKeyHeader); key != "" && c.GetHeader("Authorization") == "" {
+			if authenticateAPIKey(c, authService, apiKeyService, apiKeyRoutes, key) {
+				c.Next()
+			}
+			return
+		}
+
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
+		// Browsers cannot set headers on WebSocket handshakes, so those may pass ?access_token= instead
+		if authHeader == "" && strings.EqualFold(c.GetHeader("Upgrade"), "websocket") && c.Query("access_token") != "" {
+			authHeader = "Bearer " + c.Query("access_token")
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
 			c.Abort()
@@ -53,15 +65,34 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 			return
 		}
 
+		// Until users whose role requires two-factor authentication set it up, they can only do that
+		if authService.TOTPEnrollmentRequired(user) && !totpEnrollmentRoute(c.FullPath()) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "two-factor authentication must be set up first", "totp_enrollment_required": true})
+			c.Abort()
+			return
+		}
+
+		authService.TouchSession(claims.ID, c.ClientIP())
+
 		// Set user info in context
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("tokenID", claims.ID)
+		if claims.ExpiresAt != nil {
+			c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+		}
 
 		c.Next()
 	}
 }
 
+// totpEnrollmentRoute reports whether a route stays available to users who still have to set up
+// two-factor authentication: their own account, its TOTP enrollment and logout
+func totpEnrollmentRoute(path string) bool {
+	return path == "/api/v1/auth/me" || strings.HasPrefix(path, "/api/v1/auth/me/totp") || path == "/api/v1/auth/logout"
+}
+
 // AdminOnlyMiddleware checks if the user is an admin
 func AdminOnlyMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
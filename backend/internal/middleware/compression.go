@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// CompressionConfig controls response compression
+type CompressionConfig struct {
+	MinSize int // Responses smaller than this many bytes are sent uncompressed
+	Level   int // gzip level (1-9); brotli uses an equivalent quality
+}
+
+// compressibleTypes are the content types worth compressing
+var compressibleTypes = []string{
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+	"text/",
+}
+
+// Compression compresses responses with brotli or gzip depending on the client's Accept-Encoding.
+// Only compressible content types at least MinSize bytes long are compressed.
+func Compression(config CompressionConfig) gin.HandlerFunc {
+	if config.Level < gzip.BestSpeed || config.Level > gzip.BestCompression {
+		config.Level = gzip.DefaultCompression
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		writer := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			config:         config,
+		}
+		c.Writer = writer
+		c.Header("Vary", "Accept-Encoding")
+
+		defer writer.finish()
+		c.Next()
+	}
+}
+
+// negotiateEncoding picks br or gzip from an Accept-Encoding header, honoring q=0
+func negotiateEncoding(header string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		accepted[name] = q > 0
+	}
+
+	switch {
+	case accepted["br"]:
+		return "br"
+	case accepted["gzip"]:
+		return "gzip"
+	}
+	return ""
+}
+
+// compressWriter buffers the start of a response until it knows whether compressing it pays off
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	config   CompressionConfig
+
+	buf     bytes.Buffer
+	encoder io.WriteCloser
+	decided bool
+}
+
+// Write buffers until MinSize bytes are collected, then commits to compressing or passing through
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf.Write(data)
+	if w.buf.Len() >= w.config.MinSize {
+		if err := w.commit(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// WriteString implements gin.ResponseWriter
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports whether any body bytes were accepted, including buffered ones
+func (w *compressWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Flush commits the buffered data so streamed responses are not held back
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.commit(true)
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack hands the connection to the caller without compression
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+// commit decides whether to compress and writes the buffered data
+func (w *compressWriter) commit(allowCompression bool) error {
+	w.decided = true
+
+	if allowCompression && w.shouldCompress() {
+		header := w.ResponseWriter.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+
+		if w.encoding == "br" {
+			w.encoder = brotli.NewWriterLevel(w.ResponseWriter, brotliQuality(w.config.Level))
+		} else {
+			encoder, err := gzip.NewWriterLevel(w.ResponseWriter, w.config.Level)
+			if err != nil {
+				return err
+			}
+			w.encoder = encoder
+		}
+	}
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	data := w.buf.Bytes()
+	w.buf.Reset()
+	if w.encoder != nil {
+		_, err := w.encoder.Write(data)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(data)
+	return err
+}
+
+// shouldCompress checks the status, existing encoding and content type of the response
+func (w *compressWriter) shouldCompress() bool {
+	status := w.ResponseWriter.Status()
+	if status < http.StatusOK || status >= http.StatusMultipleChoices ||
+		status == http.StatusNoContent || status == http.StatusPartialContent {
+		return false
+	}
+
+	header := w.ResponseWriter.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(w.buf.Bytes())
+	}
+	if strings.HasPrefix(contentType, "text/event-stream") {
+		return false
+	}
+	for _, t := range compressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// finish writes small buffered responses uncompressed and closes the encoder
+func (w *compressWriter) finish() {
+	if !w.decided {
+		w.commit(false)
+	}
+	if w.encoder != nil {
+		w.encoder.Close()
+	}
+}
+
+// brotliQuality maps a gzip level (1-9, -1 default) to a brotli quality (0-11)
+func brotliQuality(level int) int {
+	if level == gzip.DefaultCompression {
+		return 5
+	}
+	return level
+}
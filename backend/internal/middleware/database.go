@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"truadmin/internal/database"
+)
+
+// RequireDatabase answers 503 on routes backed by the internal database while it is unavailable,
+// rather than letting their handlers fail on a missing connection
+func RequireDatabase() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !database.Available() {
+			c.Header("Retry-After", "5")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "internal database unavailable"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag adds a weak ETag (hash of the response body) to successful GET responses
+// and answers 304 Not Modified when it matches the request's If-None-Match header.
+// Use it on frequently polled metadata endpoints.
+func ETag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		writer := &bufferedWriter{ResponseWriter: original}
+		c.Writer = writer
+		c.Next()
+		c.Writer = original
+
+		if original.Written() {
+			// The handler bypassed the buffer (e.g. hijacked the connection)
+			return
+		}
+
+		status := writer.Status()
+		body := writer.buf.Bytes()
+
+		if status == http.StatusOK {
+			sum := sha256.Sum256(body)
+			tag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+			original.Header().Set("ETag", tag)
+
+			if etagMatches(c.GetHeader("If-None-Match"), tag) {
+				original.Header().Del("Content-Type")
+				original.Header().Del("Content-Length")
+				original.WriteHeader(http.StatusNotModified)
+				original.WriteHeaderNow()
+				return
+			}
+		}
+
+		original.WriteHeader(status)
+		if len(body) > 0 {
+			original.Write(body)
+		} else {
+			original.WriteHeaderNow()
+		}
+	}
+}
+
+// etagMatches reports whether an If-None-Match header matches tag (weak comparison)
+func etagMatches(header, tag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter holds the whole response body so it can be hashed before sending
+type bufferedWriter struct {
+	gin.ResponseWriter
+	buf    bytes.Buffer
+	status int
+}
+
+// WriteHeader records the status without sending it
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+// WriteHeaderNow is deferred until the body is complete
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+// Write buffers the body
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.buf.Write(data)
+}
+
+// WriteString buffers the body
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+// Status returns the recorded status, defaulting to 200
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Size returns the number of buffered bytes
+func (w *bufferedWriter) Size() int {
+	return w.buf.Len()
+}
+
+// Written reports whether anything was buffered or a status was set
+func (w *bufferedWriter) Written() bool {
+	return w.buf.Len() > 0 || w.status != 0
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+)
+
+// RequirePermission rejects requests of users that lack the permission. Use it after AuthMiddleware.
+// Requests made with an API key also need the permission, or the scope of the route, among the key's scopes.
+func RequirePermission(permissionService *services.PermissionService, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value, ok := c.Get("apiKey"); ok {
+			apiKey := value.(*models.APIKey)
+			if !apiKey.HasScope(permission) && !apiKey.HasScope(c.GetString("apiKeyRouteScope")) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "API key lacks the " + permission + " scope"})
+				c.Abort()
+				return
+			}
+		}
+
+		role, _ := c.Get("role")
+		roleValue, _ := role.(models.UserRole)
+
+		allowed, err := permissionService.HasPermission(c.GetString("userID"), roleValue, permission)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": services.ErrPermissionDenied.Error() + ": " + permission + " required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"truadmin/internal/services"
+)
+
+// RateLimits holds the limiters of the rate limited endpoints; nil limiters allow everything
+type RateLimits struct {
+	LoginPerIP          *services.RateLimiter // Login attempts per client IP
+	QueryPerUser        *services.RateLimiter // Query executions per user
+	CheckAddressPerUser *services.RateLimiter // Address checks per user
+	PerIP               *services.RateLimiter // Query executions and address checks per client IP, across users
+}
+
+// RateLimit rejects requests with 429 once the client IP or, on protected routes, the user has used up
+// its tokens. perIP and perUser may be nil; the user is the one set by AuthMiddleware, so on public
+// routes only perIP applies.
+func RateLimit(perIP, perUser *services.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ok, wait := perIP.Allow(c.ClientIP()); !ok {
+			rejectRateLimited(c, wait)
+			return
+		}
+		if userID := c.GetString("userID"); userID != "" {
+			if ok, wait := perUser.Allow(userID); !ok {
+				rejectRateLimited(c, wait)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// rejectRateLimited aborts a request that exceeded a rate limit
+func rejectRateLimited(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded, try again later"})
+	c.Abort()
+}
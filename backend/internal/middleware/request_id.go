@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+	"truadmin/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength caps request IDs supplied by clients
+const maxRequestIDLength = 128
+
+// RequestID assigns each request an ID, taken from the X-Request-ID header when the client (or a
+// proxy) sent a usable one. The ID is returned in the response header, stored in the gin context
+// as "requestID" and in the request context for services, and logged with the completed request.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		c.Set("requestID", requestID)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+
+		started := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		userID, _ := c.Get("userID")
+		slog.InfoContext(c.Request.Context(), "request completed",
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.Int("status", c.Writer.Status()),
+			slog.Int64("duration_ms", time.Since(started).Milliseconds()),
+			slog.Any("user_id", userID),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
+
+// validRequestID accepts short IDs of printable ASCII without spaces, so they are safe to log and echo
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
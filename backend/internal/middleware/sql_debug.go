@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"strings"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SQLDebug captures the SQL a request sends to managed databases when an admin sets the
+// X-Debug-SQL header. The capture travels in the request context, so only statements that services
+// run with that context are recorded. JSON responses are wrapped as {"data": <response>, "debug": {...}};
+// the capture is also logged, and the statement count is sent in X-Debug-SQL-Count.
+// Must run after AuthMiddleware.
+func SQLDebug(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !sqlDebugRequested(c) {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		writer := &bufferedWriter{ResponseWriter: original}
+		c.Writer = writer
+		ctx, capture := services.StartSQLCapture(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+		info := capture.Stop()
+		c.Writer = original
+
+		userID, _ := c.Get("userID")
+		logger.InfoContext(c.Request.Context(), "SQL debug",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Any("user_id", userID),
+			slog.Int("status", writer.Status()),
+			slog.Any("sql", info),
+		)
+
+		if original.Written() {
+			// The handler bypassed the buffer (e.g. hijacked the connection)
+			return
+		}
+
+		body := writer.buf.Bytes()
+		original.Header().Set("X-Debug-SQL-Count", strconv.Itoa(info.StatementCount))
+		if strings.HasPrefix(original.Header().Get("Content-Type"), "application/json") && json.Valid(body) {
+			if wrapped, err := json.Marshal(models.SQLDebugEnvelope{Data: json.RawMessage(body), Debug: info}); err == nil {
+				body = wrapped
+				original.Header().Del("Content-Length")
+			}
+		}
+
+		original.WriteHeader(writer.Status())
+		if len(body) > 0 {
+			original.Write(body)
+		} else {
+			original.WriteHeaderNow()
+		}
+	}
+}
+
+// sqlDebugRequested reports whether an admin asked for SQL capture on this request
+func sqlDebugRequested(c *gin.Context) bool {
+	if c.GetHeader("Upgrade") != "" {
+		return false
+	}
+	if enabled, err := strconv.ParseBool(c.GetHeader(models.SQLDebugHeader)); err != nil || !enabled {
+		return false
+	}
+	role, exists := c.Get("role")
+	return exists && role == models.RoleAdmin
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"truadmin/internal/services"
+)
+
+// Usage meters calls per module and endpoint for the admin usage report. Only matched routes are
+// counted, by their route template, so the counters carry no IDs or other request data.
+func Usage(usageService *services.UsageService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			return
+		}
+		// The user ID is set by AuthMiddleware further down the chain of protected routes
+		usageService.Record(c.Request.Method+" "+route, c.GetString("userID"), c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// AccessGrantScope limits what a grantee may do on the connection
+type AccessGrantScope string
+
+const (
+	AccessScopeRead  AccessGrantScope = "read"  // GET requests only
+	AccessScopeQuery AccessGrantScope = "query" // Reads plus query execution and connection tests
+	AccessScopeFull  AccessGrantScope = "full"  // Every connection endpoint available to users
+)
+
+// AccessGrant represents time-boxed access of a user to a connection that requires a grant
+type AccessGrant struct {
+	ID             string           `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	ConnectionID   string           `gorm:"type:varchar(36);not null;index" json:"connection_id"`
+	UserID         string           `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	Scope          AccessGrantScope `gorm:"type:varchar(10);not null" json:"scope"`
+	Reason         string           `gorm:"type:text" json:"reason"`
+	Emergency      bool             `gorm:"column:emergency" json:"emergency"` // Self-granted break-glass access
+	GrantedBy      string           `gorm:"type:varchar(36)" json:"granted_by"`
+	StartsAt       time.Time        `gorm:"not null" json:"starts_at"`
+	ExpiresAt      time.Time        `gorm:"not null;index" json:"expires_at"`
+	RevokedAt      *time.Time       `json:"revoked_at,omitempty"`
+	RevokedBy      string           `gorm:"type:varchar(36)" json:"revoked_by,omitempty"`
+	ReminderSentAt *time.Time       `json:"reminder_sent_at,omitempty"` // Set when the expiry reminder was sent
+	ExpiryNotedAt  *time.Time       `json:"expiry_noted_at,omitempty"`  // Set when the expiry notification was sent
+	CreatedAt      time.Time        `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// IsActive reports whether the grant allows access at the given time
+func (g *AccessGrant) IsActive(at time.Time) bool {
+	return g.RevokedAt == nil && !at.Before(g.StartsAt) && at.Before(g.ExpiresAt)
+}
+
+// EndedAt returns when the grant window closed or will close
+func (g *AccessGrant) EndedAt() time.Time {
+	if g.RevokedAt != nil && g.RevokedAt.Before(g.ExpiresAt) {
+		return *g.RevokedAt
+	}
+	return g.ExpiresAt
+}
+
+// AccessGrantRequest represents the request to grant a user temporary access to a connection
+type AccessGrantRequest struct {
+	ConnectionID  string           `json:"connection_id" binding:"required"`
+	UserID        string           `json:"user_id" binding:"required"`
+	Scope         AccessGrantScope `json:"scope" binding:"omitempty,oneof=read query full"` // Defaults to query
+	DurationHours float64          `json:"duration_hours" binding:"required,gt=0"`
+	StartsAt      *time.Time       `json:"starts_at"` // Defaults to now
+	Reason        string           `json:"reason" binding:"required"`
+}
+
+// BreakGlassRequest represents a user's request for immediate emergency access to a connection
+type BreakGlassRequest struct {
+	ConnectionID  string `json:"connection_id" binding:"required"`
+	Justification string `json:"justification" binding:"required,min=20"`
+}
+
+// AccessGrantActivity represents what the grantee did on the connection during the grant window
+type AccessGrantActivity struct {
+	Grant  *AccessGrant        `json:"grant"`
+	Events []UserActivityEvent `json:"events"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// AddressCheckLatencyBucketsMs are the upper bounds of the address check latency histogram;
+// a last, unbounded bucket holds the slower calls
+var AddressCheckLatencyBucketsMs = []int64{25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// AddressCheckUsage counts the address check calls of one gRPC caller on one day
+type AddressCheckUsage struct {
+	Day            time.Time     `gorm:"type:date;primaryKey" json:"day"`
+	Caller         string        `gorm:"type:varchar(255);primaryKey" json:"caller"` // "key:<fingerprint>" or "cert:<subject>"
+	Requests       int64         `gorm:"not null;default:0" json:"requests"`         // Calls counted against the quota
+	Failures       int64         `gorm:"not null;default:0" json:"failures"`         // Counted calls that returned an error
+	Rejected       int64         `gorm:"not null;default:0" json:"rejected"`         // Calls refused because the quota was used up
+	WithinSLO      int64         `gorm:"column:within_slo;not null;default:0" json:"within_slo"`
+	DurationMs     int64         `gorm:"not null;default:0" json:"duration_ms"` // Total handling time of all counted calls
+	MaxDurationMs  int64         `gorm:"not null;default:0" json:"max_duration_ms"`
+	LatencyBuckets pq.Int64Array `gorm:"type:bigint[]" json:"latency_buckets"` // Counts per AddressCheckLatencyBucketsMs bucket
+}
+
+// TableName specifies the table name for GORM
+func (AddressCheckUsage) TableName() string {
+	return "address_check_usage"
+}
+
+// AddressCheckFailure represents a failed or rejected address check call
+type AddressCheckFailure struct {
+	ID         string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Caller     string    `gorm:"type:varchar(255);not null;index" json:"caller"`
+	Code       string    `gorm:"type:varchar(32);not null" json:"code"` // gRPC status code, e.g. NotFound or ResourceExhausted
+	Error      string    `gorm:"type:text" json:"error"`
+	DatabaseID string    `gorm:"type:varchar(36)" json:"database_id,omitempty"`
+	DurationMs int64     `gorm:"not null;default:0" json:"duration_ms"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (AddressCheckFailure) TableName() string {
+	return "address_check_failures"
+}
+
+// AddressCheckSLO represents response-time SLO compliance over a report window. Percentiles are
+// the upper bound of the histogram bucket they fall in, or the slowest call for the last bucket.
+type AddressCheckSLO struct {
+	LatencyThresholdMs int64   `json:"latency_threshold_ms"`
+	Target             float64 `json:"target"`     // Share of calls that must finish within the threshold
+	Compliance         float64 `json:"compliance"` // Share that did; 1 without calls
+	Met                bool    `json:"met"`
+	ErrorRate          float64 `json:"error_rate"`
+	AvgDurationMs      float64 `json:"avg_duration_ms"`
+	P50Ms              int64   `json:"p50_ms"`
+	P95Ms              int64   `json:"p95_ms"`
+	P99Ms              int64   `json:"p99_ms"`
+}
+
+// AddressCheckUsageReport represents a caller's address check usage, quota and recent failures
+type AddressCheckUsageReport struct {
+	Caller         string                `json:"caller"`
+	DailyQuota     int64                 `json:"daily_quota"`               // Zero is unlimited
+	UsedToday      int64                 `json:"used_today"`                // Calls counted today (UTC)
+	RemainingToday *int64                `json:"remaining_today,omitempty"` // Omitted when unlimited
+	From           time.Time             `json:"from"`
+	To             time.Time             `json:"to"`
+	SLO            AddressCheckSLO       `json:"slo"`
+	Daily          []AddressCheckUsage   `json:"daily"`
+	RecentFailures []AddressCheckFailure `json:"recent_failures"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Alert rules that can be silenced per connection; they match the notification event names
+const (
+	AlertRulePartitionMaintenanceFailed = "partition_maintenance_failed"
+	AlertRuleCustomQueryThreshold       = "custom_query_threshold" // A custom monitoring query crossed a threshold or failed
+	AlertRuleSQLJobFailed               = "sql_job_failed"         // A run of a scheduled SQL job failed
+	AlertRuleAll                        = "*"                      // Silences every rule of the connection
+)
+
+// AlertRules lists the rules accepted by alert silences
+var AlertRules = []string{AlertRulePartitionMaintenanceFailed, AlertRuleCustomQueryThreshold, AlertRuleSQLJobFailed, AlertRuleAll}
+
+// AlertSilence represents a time window in which an alert rule of a connection does not notify
+type AlertSilence struct {
+	ID           string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	ConnectionID string     `gorm:"type:varchar(36);not null;index" json:"connection_id"`
+	Rule         string     `gorm:"type:varchar(100);not null" json:"rule"`
+	Reason       string     `gorm:"type:text;not null" json:"reason"`
+	StartsAt     time.Time  `gorm:"not null" json:"starts_at"`
+	EndsAt       time.Time  `gorm:"not null;index" json:"ends_at"`
+	CreatedBy    string     `gorm:"type:varchar(36)" json:"created_by"`
+	ExpiredBy    string     `gorm:"type:varchar(36)" json:"expired_by,omitempty"` // Set when ended early
+	ExpiredAt    *time.Time `json:"expired_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// AlertSilenceRequest represents the request to silence an alert rule of a connection
+type AlertSilenceRequest struct {
+	Rule     string     `json:"rule" binding:"required"`
+	Reason   string     `json:"reason" binding:"required"`
+	StartsAt *time.Time `json:"starts_at"` // Defaults to now
+	EndsAt   time.Time  `json:"ends_at" binding:"required"`
+}
+
+// AlertSilenceList represents the silences of a connection
+type AlertSilenceList struct {
+	Silences []AlertSilence `json:"silences"`
+	Rules    []string       `json:"rules"` // Rules that can be silenced
+}
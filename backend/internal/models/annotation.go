@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Annotation represents a timestamped note on a connection's monitoring timeline
+type Annotation struct {
+	ID           string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	ConnectionID string    `gorm:"type:varchar(36);not null;index" json:"connection_id"`
+	DatabaseName string    `gorm:"type:varchar(255)" json:"database_name,omitempty"` // Empty applies to the whole connection
+	UserID       string    `gorm:"type:varchar(36);index" json:"user_id"`
+	Username     string    `gorm:"type:varchar(255)" json:"username"`
+	OccurredAt   time.Time `gorm:"not null;index" json:"occurred_at"`
+	Text         string    `gorm:"type:text;not null" json:"text"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// AnnotationRequest represents the request to add an annotation
+type AnnotationRequest struct {
+	DatabaseName string     `json:"database_name"`
+	OccurredAt   *time.Time `json:"occurred_at"` // Defaults to now
+	Text         string     `json:"text" binding:"required"`
+}
+
+// MetricsWithAnnotations represents metric data returned together with the annotations of the same period
+type MetricsWithAnnotations struct {
+	Metrics     interface{}  `json:"metrics"`
+	Annotations []Annotation `json:"annotations"`
+}
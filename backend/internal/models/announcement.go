@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// AnnouncementSeverity controls how an announcement banner is styled
+type AnnouncementSeverity string
+
+const (
+	AnnouncementInfo     AnnouncementSeverity = "info"
+	AnnouncementWarning  AnnouncementSeverity = "warning"
+	AnnouncementCritical AnnouncementSeverity = "critical"
+)
+
+// AnnouncementAudience selects which users see an announcement
+type AnnouncementAudience string
+
+const (
+	AudienceAll    AnnouncementAudience = "all"
+	AudienceAdmins AnnouncementAudience = "admins"
+	AudienceUsers  AnnouncementAudience = "users"
+)
+
+// Announcement represents an in-app banner broadcast by an administrator
+type Announcement struct {
+	ID        string               `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Message   string               `gorm:"type:text;not null" json:"message"`
+	Severity  AnnouncementSeverity `gorm:"type:varchar(10);not null" json:"severity"`
+	Audience  AnnouncementAudience `gorm:"type:varchar(10);not null" json:"audience"`
+	StartsAt  *time.Time           `gorm:"index" json:"starts_at"` // Null shows the banner immediately
+	EndsAt    *time.Time           `gorm:"index" json:"ends_at"`   // Null keeps the banner until it is deleted
+	CreatedBy string               `gorm:"type:varchar(36)" json:"created_by"`
+	CreatedAt time.Time            `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time            `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// AnnouncementRequest represents the request to create or update an announcement
+type AnnouncementRequest struct {
+	Message  string               `json:"message" binding:"required,max=2000"`
+	Severity AnnouncementSeverity `json:"severity" binding:"omitempty,oneof=info warning critical"` // Defaults to info
+	Audience AnnouncementAudience `json:"audience" binding:"omitempty,oneof=all admins users"`      // Defaults to all
+	StartsAt *time.Time           `json:"starts_at"`
+	EndsAt   *time.Time           `json:"ends_at"`
+}
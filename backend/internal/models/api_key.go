@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// ScopeHohAddressCheckAddress lets an API key check addresses without the rest of hohaddress:read
+const ScopeHohAddressCheckAddress = "hohaddress:check_address"
+
+// APIKeyScopeCatalog lists the scopes an API key can hold in display order: every permission, and
+// narrower scopes that only open single endpoints
+func APIKeyScopeCatalog() []PermissionInfo {
+	scopes := append([]PermissionInfo{}, PermissionCatalog...)
+	return append(scopes, PermissionInfo{ScopeHohAddressCheckAddress, "Check single addresses and address batches against HohAddress databases, nothing else"})
+}
+
+// IsAPIKeyScope reports whether name is a known API key scope
+func IsAPIKeyScope(name string) bool {
+	return name == ScopeHohAddressCheckAddress || IsPermission(name)
+}
+
+// APIKey represents a key for machine-to-machine access to the API, sent in the X-API-Key header.
+// Requests act as the key's user, limited to the key's scopes.
+type APIKey struct {
+	ID                 string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name               string     `gorm:"type:varchar(255);not null" json:"name"`          // Service or integration the key was issued to
+	UserID             string     `gorm:"type:varchar(36);not null;index" json:"user_id"`  // User the requests act as
+	KeyHash            string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`  // SHA-256 of the key; the key itself is shown once
+	KeyPrefix          string     `gorm:"type:varchar(16);not null" json:"key_prefix"`     // Identifies the key in listings
+	Scopes             StringList `gorm:"type:text" json:"scopes"`                         // Permissions or narrower scopes, see APIKeyScopeCatalog
+	RateLimitPerMinute int        `gorm:"not null;default:0" json:"rate_limit_per_minute"` // Zero uses the server default
+	CreatedBy          string     `gorm:"type:varchar(36)" json:"created_by"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt         *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt          time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// HasScope reports whether the key holds a scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, held := range k.Scopes {
+		if held == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyRequest represents the request to issue an API key
+type APIKeyRequest struct {
+	Name               string     `json:"name" binding:"required"`
+	UserID             string     `json:"user_id"` // Defaults to the issuing admin
+	Scopes             []string   `json:"scopes" binding:"required,min=1"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute"`
+	ExpiresAt          *time.Time `json:"expires_at"`
+}
+
+// IssuedAPIKey represents a newly issued API key with the key itself
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// ArtifactKind classifies a stored artifact
+type ArtifactKind string
+
+const (
+	ArtifactBackup   ArtifactKind = "backup"
+	ArtifactExport   ArtifactKind = "export"
+	ArtifactSnapshot ArtifactKind = "snapshot"
+)
+
+// Artifact is a large file such as a backup or export kept in the artifact storage backend.
+// Only its metadata lives in the internal database.
+type Artifact struct {
+	ID          string       `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Kind        ArtifactKind `gorm:"type:varchar(20);not null;index" json:"kind"`
+	Name        string       `gorm:"type:varchar(255);not null" json:"name"` // File name offered on download
+	StorageKey  string       `gorm:"type:varchar(1024);not null" json:"-"`
+	Backend     string       `gorm:"type:varchar(20);not null" json:"backend"`
+	ContentType string       `gorm:"type:varchar(255);not null" json:"content_type"`
+	SizeBytes   int64        `gorm:"not null" json:"size_bytes"`
+	OwnerID     string       `gorm:"type:varchar(36);not null;index" json:"owner_id"`
+	ExpiresAt   *time.Time   `gorm:"index" json:"expires_at,omitempty"` // Removed by the cleanup job after this time; nil keeps it
+	CreatedAt   time.Time    `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Artifact) TableName() string {
+	return "artifacts"
+}
+
+// ArtifactDownload is a time-limited download link for an artifact
+type ArtifactDownload struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// AuditBackfillState tracks the one-time shipping of a stored log table to the audit sinks.
+// Entries up to CutoffID predate the backfill; later entries are shipped when they are written.
+type AuditBackfillState struct {
+	Source      string     `gorm:"primaryKey;type:varchar(30)" json:"source"` // Audit event source: role or truetl
+	CutoffID    int        `gorm:"column:cutoff_id;not null" json:"cutoff_id"`
+	LastID      int        `gorm:"column:last_id;not null" json:"last_id"` // Last entry shipped
+	CompletedAt *time.Time `gorm:"column:completed_at" json:"completed_at,omitempty"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (AuditBackfillState) TableName() string {
+	return "audit_backfill_states"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// AuditEventStatus represents the outcome of an audited operation
+type AuditEventStatus string
+
+const (
+	AuditEventStatusSuccess AuditEventStatus = "success"
+	AuditEventStatusError   AuditEventStatus = "error"
+	AuditEventStatusPartial AuditEventStatus = "partial"
+)
+
+// AuditEvent represents a single audited operation shipped to the audit sinks
+type AuditEvent struct {
+	Timestamp    time.Time        `json:"timestamp"`
+	Source       string           `json:"source"` // connection, role, user, truetl, hohaddress
+	Action       string           `json:"action"`
+	Status       AuditEventStatus `json:"status"`
+	ActorID      string           `json:"actor_id,omitempty"`
+	ConnectionID string           `json:"connection_id,omitempty"`
+	TargetID     string           `json:"target_id,omitempty"`
+	Message      string           `json:"message,omitempty"`
+	RequestID    string           `json:"request_id,omitempty"`
+	Backfilled   bool             `json:"backfilled,omitempty"` // Shipped later from a stored log entry rather than when it happened
+}
@@ -0,0 +1,26 @@
+package models
+
+import "encoding/json"
+
+// BatchSubRequest represents a single API call inside a batch
+type BatchSubRequest struct {
+	ID   string `json:"id" binding:"required"`   // Caller-chosen key to match responses
+	Path string `json:"path" binding:"required"` // e.g. /api/v1/connections/:id/roles/:roleId
+}
+
+// BatchRequest represents the request to execute several API calls at once
+type BatchRequest struct {
+	Requests []BatchSubRequest `json:"requests" binding:"required,min=1,dive"`
+}
+
+// BatchSubResponse represents the response of a single call inside a batch
+type BatchSubResponse struct {
+	ID     string          `json:"id"`
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// BatchResponse represents the combined responses of a batch, in request order
+type BatchResponse struct {
+	Responses []BatchSubResponse `json:"responses"`
+}
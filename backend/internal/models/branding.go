@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// BrandingSettingsID is the primary key of the single branding settings row
+const BrandingSettingsID = "default"
+
+// BrandingPalette holds the colors applied to the SPA (CSS --color-primary* variables)
+type BrandingPalette struct {
+	Primary      string `gorm:"column:primary_color;type:varchar(7)" json:"primary" yaml:"primary"`
+	PrimaryHover string `gorm:"column:primary_hover_color;type:varchar(7)" json:"primary_hover" yaml:"primary_hover"`
+	PrimaryLight string `gorm:"column:primary_light_color;type:varchar(7)" json:"primary_light" yaml:"primary_light"`
+	PrimaryDark  string `gorm:"column:primary_dark_color;type:varchar(7)" json:"primary_dark" yaml:"primary_dark"`
+}
+
+// DefaultBrandingPalette is served until an administrator customizes the palette
+var DefaultBrandingPalette = BrandingPalette{
+	Primary:      "#3b82f6",
+	PrimaryHover: "#2563eb",
+	PrimaryLight: "#dbeafe",
+	PrimaryDark:  "#1e40af",
+}
+
+// BrandingSettings represents the deployment's product name, palette and logo
+type BrandingSettings struct {
+	ID              string          `gorm:"primaryKey;type:varchar(36)" json:"-"`
+	ProductName     string          `gorm:"type:varchar(100);not null" json:"product_name"`
+	Palette         BrandingPalette `gorm:"embedded" json:"palette"`
+	LogoData        []byte          `json:"-"`
+	LogoContentType string          `gorm:"type:varchar(50)" json:"-"`
+	UpdatedBy       string          `gorm:"type:varchar(36)" json:"-"`
+	UpdatedAt       time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Branding is the public view of the branding settings served to the SPA
+type Branding struct {
+	ProductName string          `json:"product_name"`
+	Palette     BrandingPalette `json:"palette"`
+	LogoURL     *string         `json:"logo_url"` // null when no logo was uploaded
+	UpdatedAt   *time.Time      `json:"updated_at,omitempty"`
+}
+
+// BrandingRequest represents the request to update the product name and palette
+type BrandingRequest struct {
+	ProductName string          `json:"product_name" binding:"required,max=100"`
+	Palette     BrandingPalette `json:"palette"`
+}
@@ -0,0 +1,131 @@
+package models
+
+import "time"
+
+// BulkRunState represents the progress state of a bulk operation
+type BulkRunState string
+
+const (
+	BulkRunRunning   BulkRunState = "running"
+	BulkRunPaused    BulkRunState = "paused" // Waiting for the target server load to drop
+	BulkRunCompleted BulkRunState = "completed"
+	BulkRunFailed    BulkRunState = "failed"
+	BulkRunCancelled BulkRunState = "cancelled"
+)
+
+// Bulk operation kinds
+const (
+	BulkKindGrant        = "bulk_grant"
+	BulkKindAddressCheck = "address_check"
+	BulkKindScriptRun    = "script_run"
+	BulkKindRoleAlter    = "role_alter"
+)
+
+// ServerLoad represents the connection usage of a target server sampled by the bulk throttle
+type ServerLoad struct {
+	ActiveQueries   int       `json:"active_queries"`
+	Connections     int       `json:"connections"`
+	MaxConnections  int       `json:"max_connections"`
+	ConnectionUsage float64   `json:"connection_usage"` // connections / max_connections
+	SampledAt       time.Time `json:"sampled_at"`
+}
+
+// BulkItemResult represents the outcome of one item of a bulk operation
+type BulkItemResult struct {
+	Item   string      `json:"item"`
+	Status string      `json:"status"` // success, skipped, error
+	Error  string      `json:"error,omitempty"`
+	Result interface{} `json:"result,omitempty"`
+}
+
+// BulkRun represents the progress of a throttled bulk operation
+type BulkRun struct {
+	ID           string           `json:"id"`
+	Kind         string           `json:"kind"`
+	ConnectionID string           `json:"connection_id"`
+	UserID       string           `json:"user_id"`
+	State        BulkRunState     `json:"state"`
+	Total        int              `json:"total"`
+	Done         int              `json:"done"`
+	Failed       int              `json:"failed"`
+	PausedReason string           `json:"paused_reason,omitempty"`
+	PausedMs     int64            `json:"paused_ms"` // Total time spent waiting for load to drop
+	Load         *ServerLoad      `json:"load,omitempty"`
+	Error        string           `json:"error,omitempty"`
+	Items        []BulkItemResult `json:"items"`
+	StartedAt    time.Time        `json:"started_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+	FinishedAt   *time.Time       `json:"finished_at,omitempty"`
+}
+
+// BulkGrantRequest represents the request to grant the same privileges to many roles
+type BulkGrantRequest struct {
+	RoleIDs []string     `json:"role_ids" binding:"required,min=1"`
+	Grant   GrantRequest `json:"grant" binding:"required"`
+}
+
+// AddressCheckInput represents one address of a batch address check
+type AddressCheckInput struct {
+	Address1    string `json:"address1" binding:"required"`
+	Address2    string `json:"address2"`
+	City        string `json:"city" binding:"required"`
+	State       string `json:"state" binding:"required"`
+	Zip         string `json:"zip" binding:"required"`
+	ProgramType string `json:"programType" binding:"required"`
+}
+
+// BulkAddressCheckRequest represents the request to check many addresses
+type BulkAddressCheckRequest struct {
+	Addresses []AddressCheckInput `json:"addresses" binding:"required,min=1,dive"`
+}
+
+// ScriptRunRequest represents the request to run one script in several databases of a connection
+type ScriptRunRequest struct {
+	Databases []string `json:"databases" binding:"required,min=1"`
+	Script    string   `json:"script" binding:"required"`
+}
+
+// RoleFilter selects the roles of a bulk role change; at least one criterion is required.
+// Built-in pg_* roles are never matched.
+type RoleFilter struct {
+	RoleIDs     []string `json:"role_ids"`     // Role OIDs
+	NamePattern string   `json:"name_pattern"` // SQL LIKE pattern, e.g. "app\_%"
+	CanLogin    *bool    `json:"can_login"`
+	MemberOf    string   `json:"member_of"` // Name of a group role the roles belong to
+}
+
+// RoleAttributeChanges represents the role attributes to set; omitted attributes are left unchanged
+type RoleAttributeChanges struct {
+	Login           *bool   `json:"login"`
+	Superuser       *bool   `json:"superuser"`
+	CreateDB        *bool   `json:"createdb"`
+	CreateRole      *bool   `json:"createrole"`
+	Inherit         *bool   `json:"inherit"`
+	Replication     *bool   `json:"replication"`
+	BypassRLS       *bool   `json:"bypassrls"`
+	ConnectionLimit *int    `json:"connection_limit"` // -1 = unlimited
+	ValidUntil      *string `json:"valid_until"`      // RFC3339 time or "infinity"
+}
+
+// BulkRoleAlterRequest represents the request to change attributes of every role matching a filter
+type BulkRoleAlterRequest struct {
+	Filter  RoleFilter           `json:"filter"`
+	Changes RoleAttributeChanges `json:"changes"`
+	DryRun  bool                 `json:"dry_run"` // Only return the planned ALTER ROLE statements
+}
+
+// RoleAlterPlan represents the planned change of one role of a bulk role change
+type RoleAlterPlan struct {
+	RoleID    string `json:"role_id"`
+	RoleName  string `json:"role_name"`
+	Statement string `json:"statement,omitempty"`
+	Skipped   string `json:"skipped,omitempty"` // Why the role is left alone, e.g. already matching
+}
+
+// BulkRoleAlterPreview represents the outcome of a dry run of a bulk role change
+type BulkRoleAlterPreview struct {
+	Roles   []RoleAlterPlan `json:"roles"`
+	Changed int             `json:"changed"` // Roles with a statement
+	Skipped int             `json:"skipped"`
+	DryRun  bool            `json:"dry_run"`
+}
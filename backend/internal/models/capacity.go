@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// CapacitySchemaVersion is bumped whenever the capacity snapshot format changes incompatibly
+const CapacitySchemaVersion = 1
+
+// CapacitySample represents a recorded size/connection measurement of a database
+type CapacitySample struct {
+	ID           int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ConnectionID string    `gorm:"column:connection_id;type:varchar(36);not null;index:idx_capacity_sample,priority:1" json:"connection_id"`
+	DatabaseName string    `gorm:"column:database_name;type:varchar(255);not null;index:idx_capacity_sample,priority:2" json:"database_name"`
+	SizeBytes    int64     `gorm:"column:size_bytes;not null" json:"size_bytes"`
+	Connections  int64     `gorm:"column:connections;not null" json:"connections"`
+	CollectedAt  time.Time `gorm:"column:collected_at;not null;index:idx_capacity_sample,priority:3" json:"collected_at"`
+}
+
+// TableName specifies the table name for GORM
+func (CapacitySample) TableName() string {
+	return "capacity_samples"
+}
+
+// CapacityGrowth represents growth rates derived from recorded samples (nil when no history exists)
+type CapacityGrowth struct {
+	BytesPerDay7d  *float64 `json:"bytes_per_day_7d"`
+	BytesPerDay30d *float64 `json:"bytes_per_day_30d"`
+}
+
+// DatabaseCapacity represents the capacity figures of a single database
+type DatabaseCapacity struct {
+	Name        string         `json:"name"`
+	SizeBytes   int64          `json:"size_bytes"`
+	Connections int64          `json:"connections"`
+	Growth      CapacityGrowth `json:"growth"`
+}
+
+// ConnectionCapacity represents the capacity figures of a connection's server
+type ConnectionCapacity struct {
+	ConnectionID   string             `json:"connection_id"`
+	Name           string             `json:"name"`
+	Type           string             `json:"type"`
+	Host           string             `json:"host"`
+	Port           int                `json:"port"`
+	Status         string             `json:"status"` // ok, error, unsupported
+	Error          string             `json:"error,omitempty"`
+	TotalSizeBytes int64              `json:"total_size_bytes"`
+	Connections    int64              `json:"connections"`
+	MaxConnections int64              `json:"max_connections"`
+	Databases      []DatabaseCapacity `json:"databases"`
+}
+
+// CapacitySnapshot represents capacity figures across all connections
+type CapacitySnapshot struct {
+	SchemaVersion int                  `json:"schema_version"`
+	GeneratedAt   time.Time            `json:"generated_at"`
+	Connections   []ConnectionCapacity `json:"connections"`
+}
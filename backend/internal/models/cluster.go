@@ -0,0 +1,83 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// States of background workers
+const (
+	WorkerStateIdle    = "idle"
+	WorkerStateRunning = "running"
+	WorkerStateStandby = "standby" // Waiting for this replica to become the leader
+)
+
+// WorkerStatus represents the health of a scheduler job on one replica
+type WorkerStatus struct {
+	Name            string     `json:"name"`
+	IntervalSeconds int64      `json:"interval_seconds"`
+	State           string     `json:"state"`
+	Runs            int64      `json:"runs"`
+	Failures        int64      `json:"failures"`
+	LastStartedAt   *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt  *time.Time `json:"last_finished_at,omitempty"`
+	LastDurationMs  int64      `json:"last_duration_ms"`
+	LastError       string     `json:"last_error,omitempty"` // Error of the last run; empty when it succeeded
+}
+
+// WorkerStatusList is a list of worker statuses stored as JSON
+type WorkerStatusList []WorkerStatus
+
+// Value implements driver.Valuer interface for JSON storage
+func (l WorkerStatusList) Value() (driver.Value, error) {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (l *WorkerStatusList) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, l)
+}
+
+// ClusterReplica represents a running truadmin process. Every replica refreshes its row on each
+// heartbeat; a replica whose heartbeat is older than the stale period is considered gone.
+type ClusterReplica struct {
+	ID              string           `gorm:"primaryKey;type:varchar(100)" json:"id"` // Replica name and a per-process suffix
+	Hostname        string           `gorm:"type:varchar(255)" json:"hostname"`
+	PID             int              `json:"pid"`
+	Leader          bool             `gorm:"not null;default:false" json:"leader"` // Holds the leader lock and runs the scheduler jobs
+	LeaderSince     *time.Time       `json:"leader_since,omitempty"`
+	Workers         WorkerStatusList `gorm:"type:text" json:"workers"`
+	StartedAt       time.Time        `json:"started_at"`
+	LastHeartbeatAt time.Time        `gorm:"index" json:"last_heartbeat_at"`
+	Healthy         bool             `gorm:"-" json:"healthy"` // Heartbeat is recent and no worker's last run failed
+}
+
+// TableName specifies the table name for GORM
+func (ClusterReplica) TableName() string {
+	return "cluster_replicas"
+}
+
+// ClusterStatus represents the replicas of the deployment as seen by one of them
+type ClusterStatus struct {
+	ReplicaID      string           `json:"replica_id"` // Replica that answered the request
+	LeaderElection bool             `json:"leader_election"`
+	LeaderID       string           `json:"leader_id,omitempty"`
+	Replicas       []ClusterReplica `json:"replicas"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Statuses of connection health checks
+const (
+	ConnectionHealthUp      = "up"
+	ConnectionHealthDown    = "down"
+	ConnectionHealthUnknown = "unknown" // Not checked yet
+)
+
+// ConnectionHealthCheck represents one background ping of a saved connection
+type ConnectionHealthCheck struct {
+	ID           int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ConnectionID string    `gorm:"column:connection_id;type:varchar(36);not null;index:idx_connection_health_check,priority:1" json:"connection_id"`
+	Status       string    `gorm:"column:status;type:varchar(10);not null" json:"status"`
+	LatencyMs    int64     `gorm:"column:latency_ms;not null" json:"latency_ms"`
+	Error        string    `gorm:"column:error;type:text" json:"error,omitempty"`
+	CheckedAt    time.Time `gorm:"column:checked_at;not null;index:idx_connection_health_check,priority:2" json:"checked_at"`
+}
+
+// TableName specifies the table name for GORM
+func (ConnectionHealthCheck) TableName() string {
+	return "connection_health_checks"
+}
+
+// ConnectionHealth represents the latest health of a saved connection
+type ConnectionHealth struct {
+	ConnectionID string     `json:"connection_id"`
+	Name         string     `json:"name"`
+	Type         string     `json:"type"`
+	Status       string     `json:"status"` // up, down or unknown
+	LatencyMs    int64      `json:"latency_ms"`
+	Error        string     `json:"error,omitempty"`
+	CheckedAt    *time.Time `json:"checked_at"`
+	StatusSince  *time.Time `json:"status_since"` // Earliest check of the current run of equal statuses, within the kept history
+	UptimeRatio  *float64   `json:"uptime_ratio"` // Share of checks in the last 24 hours that were up
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConnectionParams holds driver parameters of a connection that have no field of their own, e.g.
+// options, search_path, connect_timeout or target_session_attrs; they are stored as JSON
+type ConnectionParams map[string]string
+
+// Value implements driver.Valuer interface for JSON storage
+func (p ConnectionParams) Value() (driver.Value, error) {
+	if p == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (p *ConnectionParams) Scan(value interface{}) error {
+	if value == nil {
+		*p = ConnectionParams{}
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, p)
+}
+
+// Keys returns the parameter names in order
+func (p ConnectionParams) Keys() []string {
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String formats the parameters as "key=value" pairs in key order
+func (p ConnectionParams) String() string {
+	pairs := make([]string, 0, len(p))
+	for _, key := range p.Keys() {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, p[key]))
+	}
+	return strings.Join(pairs, ", ")
+}
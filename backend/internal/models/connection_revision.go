@@ -0,0 +1,129 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// MaskedValue replaces secrets in API responses and revision field changes; a request sending it
+// back leaves the stored secret unchanged
+const MaskedValue = "********"
+
+// ConnectionFieldChange represents the old and new value of a single connection field
+type ConnectionFieldChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+// ConnectionFieldChanges represents a list of field changes stored as JSON
+type ConnectionFieldChanges []ConnectionFieldChange
+
+// Value implements driver.Valuer interface for JSON storage
+func (c ConnectionFieldChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (c *ConnectionFieldChanges) Scan(value interface{}) error {
+	if value == nil {
+		*c = ConnectionFieldChanges{}
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+// ConnectionState represents the full configuration of a connection at a revision
+type ConnectionState struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Database string `json:"database"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	SSLMode  string `json:"ssl_mode"`
+	// ExtraParams is empty in revisions recorded before connections had extra parameters
+	ExtraParams ConnectionParams `json:"extra_params,omitempty"`
+	// RequiresGrant is nil in revisions recorded before grants existed; restoring them keeps the current value
+	RequiresGrant *bool `json:"requires_grant,omitempty"`
+}
+
+// Value implements driver.Valuer interface for JSON storage
+func (s ConnectionState) Value() (driver.Value, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (s *ConnectionState) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// ConnectionStateOf returns the revisioned state of a connection
+func ConnectionStateOf(conn *Connection) ConnectionState {
+	requiresGrant := conn.RequiresGrant
+	return ConnectionState{
+		Name:     conn.Name,
+		Type:     conn.Type,
+		Host:     conn.Host,
+		Port:     conn.Port,
+		Database: conn.Database,
+		Username: conn.Username,
+		Password: conn.Password,
+		SSLMode:  conn.SSLMode,
+
+		ExtraParams:   conn.ExtraParams,
+		RequiresGrant: &requiresGrant,
+	}
+}
+
+// ConnectionRevision represents a recorded change of a connection configuration
+type ConnectionRevision struct {
+	ID           int                    `gorm:"primaryKey;autoIncrement" json:"id"`
+	ConnectionID string                 `gorm:"column:connection_id;type:varchar(36);not null;uniqueIndex:idx_connection_revision" json:"connection_id"`
+	Revision     int                    `gorm:"column:revision;not null;uniqueIndex:idx_connection_revision" json:"revision"`
+	UserID       string                 `gorm:"column:user_id;type:varchar(36);index" json:"user_id"`
+	Operation    string                 `gorm:"column:operation;type:varchar(20);not null" json:"operation"` // create, update, delete, restore
+	RestoredFrom *int                   `gorm:"column:restored_from" json:"restored_from,omitempty"`
+	Changes      ConnectionFieldChanges `gorm:"column:changes;type:text" json:"changes"`
+	State        ConnectionState        `gorm:"column:state;type:text" json:"-"` // Configuration after the change, used for restore
+	CreatedAt    time.Time              `gorm:"column:created_at;autoCreateTime;index" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (ConnectionRevision) TableName() string {
+	return "connection_revisions"
+}
@@ -43,10 +43,11 @@ type ConnectionSaveLog struct {
 	ID             int                     `gorm:"primaryKey;autoIncrement" json:"id"`
 	ConnectionID   string                  `gorm:"column:connection_id;type:varchar(36);index" json:"connection_id"`
 	UserID         string                  `gorm:"column:user_id;type:varchar(36);index" json:"user_id"`
-	Operation      string                  `gorm:"column:operation;type:varchar(20);not null" json:"operation"` // create, update, delete
+	Operation      string                  `gorm:"column:operation;type:varchar(20);not null" json:"operation"` // create, update, delete, restore
 	Status         ConnectionSaveLogStatus `gorm:"column:status;type:varchar(20);not null" json:"status"`
 	ChangesSummary ConnectionChangesSummary `gorm:"column:changes_summary;type:text" json:"changes_summary"`
 	ErrorMessage   string                  `gorm:"column:error_message;type:text" json:"error_message,omitempty"`
+	RequestID      string                  `gorm:"column:request_id;type:varchar(128);index" json:"request_id,omitempty"`
 	CreatedAt      time.Time               `gorm:"column:created_at;autoCreateTime;index" json:"created_at"`
 }
 
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Threshold directions of custom monitoring queries
+const (
+	ThresholdAbove = "above" // Alert when the value is at or above a threshold
+	ThresholdBelow = "below" // Alert when the value is at or below a threshold
+)
+
+// Statuses of custom monitoring queries, also reported as alert statuses
+const (
+	CustomQueryStatusPending  = "pending"
+	CustomQueryStatusOK       = "ok"
+	CustomQueryStatusWarning  = "warning"
+	CustomQueryStatusCritical = "critical"
+	CustomQueryStatusFailing  = "failing"
+)
+
+// CustomQueryResult represents the stored result of the last run of a custom monitoring query
+type CustomQueryResult struct {
+	Columns   []string         `json:"columns"`
+	Rows      []map[string]any `json:"rows"`
+	Truncated bool             `json:"truncated,omitempty"`
+}
+
+// Value implements driver.Valuer interface for JSON storage
+func (r CustomQueryResult) Value() (driver.Value, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (r *CustomQueryResult) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, r)
+}
+
+// CustomMonitoringQuery represents read-only SQL registered by an admin that runs on every
+// monitoring sample. Its result is shown by custom_query widgets and, when thresholds are
+// set, its value is an alert source.
+type CustomMonitoringQuery struct {
+	ID                 string            `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	ConnectionID       string            `gorm:"type:varchar(36);not null;index" json:"connection_id"`
+	DatabaseName       string            `gorm:"type:varchar(255);not null" json:"database_name"`
+	Name               string            `gorm:"type:varchar(255);not null" json:"name"`
+	Description        string            `gorm:"type:text" json:"description"`
+	Query              string            `gorm:"type:text;not null" json:"query"`
+	ValueColumn        string            `gorm:"type:varchar(255)" json:"value_column"` // Column of the first row compared to the thresholds; the first column when empty
+	WarningThreshold   *float64          `json:"warning_threshold"`
+	CriticalThreshold  *float64          `json:"critical_threshold"`
+	ThresholdDirection string            `gorm:"type:varchar(10);not null;default:'above'" json:"threshold_direction"`
+	Enabled            bool              `gorm:"not null" json:"enabled"`
+	CreatedBy          string            `gorm:"type:varchar(36)" json:"created_by"`
+	LastRunAt          *time.Time        `json:"last_run_at"`
+	LastStatus         string            `gorm:"type:varchar(20);not null;default:'pending'" json:"last_status"`
+	LastValue          *float64          `json:"last_value"`
+	LastError          string            `gorm:"type:text" json:"last_error,omitempty"`
+	LastResult         CustomQueryResult `gorm:"type:text" json:"last_result"`
+	CreatedAt          time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (CustomMonitoringQuery) TableName() string {
+	return "custom_monitoring_queries"
+}
+
+// CustomMonitoringQueryRequest represents the request to create or update a custom monitoring query
+type CustomMonitoringQueryRequest struct {
+	Name               string   `json:"name" binding:"required"`
+	Description        string   `json:"description"`
+	DatabaseName       string   `json:"database_name" binding:"required"`
+	Query              string   `json:"query" binding:"required"`
+	ValueColumn        string   `json:"value_column"`
+	WarningThreshold   *float64 `json:"warning_threshold"`
+	CriticalThreshold  *float64 `json:"critical_threshold"`
+	ThresholdDirection string   `json:"threshold_direction"` // above (default) or below
+	Enabled            *bool    `json:"enabled"`             // Defaults to true
+}
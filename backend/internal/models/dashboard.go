@@ -0,0 +1,130 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Dashboard widget types
+const (
+	WidgetTypeActiveQueries = "active_queries"
+	WidgetTypeSnapshot      = "snapshot"     // Config: snapshot_id
+	WidgetTypeMetricChart   = "metric_chart" // Config: metric (optional, all metrics when empty)
+	WidgetTypeAlertStatus   = "alert_status"
+	WidgetTypeCustomQuery   = "custom_query" // Config: query_id of a custom monitoring query
+)
+
+// WidgetPosition represents the placement of a widget on the dashboard grid
+type WidgetPosition struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// DashboardWidget represents a single widget pinned to a dashboard
+type DashboardWidget struct {
+	ID           string            `json:"id"`
+	Type         string            `json:"type"`
+	Title        string            `json:"title"`
+	DatabaseName string            `json:"database_name,omitempty"`
+	Config       map[string]string `json:"config,omitempty"`
+	Position     WidgetPosition    `json:"position"`
+}
+
+// DashboardWidgets represents the widget list of a dashboard stored as JSON
+type DashboardWidgets []DashboardWidget
+
+// Value implements driver.Valuer interface for JSON storage
+func (w DashboardWidgets) Value() (driver.Value, error) {
+	if w == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(w)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (w *DashboardWidgets) Scan(value interface{}) error {
+	if value == nil {
+		*w = DashboardWidgets{}
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, w)
+}
+
+// Dashboard represents a user's pinned monitoring dashboard for a connection
+type Dashboard struct {
+	ID           string           `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name         string           `gorm:"type:varchar(255);not null" json:"name"`
+	ConnectionID string           `gorm:"type:varchar(36);not null;index" json:"connection_id"`
+	OwnerID      string           `gorm:"type:varchar(36);not null;index" json:"owner_id"`
+	Shared       bool             `gorm:"not null" json:"shared"` // Visible (read-only) to the whole team
+	Widgets      DashboardWidgets `gorm:"type:text" json:"widgets"`
+	CreatedAt    time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// DashboardRequest represents the request to create/update a dashboard
+type DashboardRequest struct {
+	Name         string            `json:"name" binding:"required"`
+	ConnectionID string            `json:"connection_id" binding:"required"`
+	Shared       bool              `json:"shared"`
+	Widgets      []DashboardWidget `json:"widgets"`
+}
+
+// WidgetData represents the resolved data of a single widget
+type WidgetData struct {
+	Type  string      `json:"type"`
+	Data  interface{} `json:"data"`
+	Error string      `json:"error,omitempty"`
+}
+
+// DashboardData represents the data of all widgets of a dashboard, keyed by widget ID
+type DashboardData struct {
+	DashboardID string                `json:"dashboard_id"`
+	GeneratedAt time.Time             `json:"generated_at"`
+	Widgets     map[string]WidgetData `json:"widgets"`
+}
+
+// DatabaseMetrics represents point-in-time activity metrics of a database from pg_stat_database
+type DatabaseMetrics struct {
+	DatabaseName  string    `json:"database_name"`
+	CollectedAt   time.Time `json:"collected_at"`
+	SizeBytes     int64     `json:"size_bytes"`
+	Connections   int64     `json:"connections"`
+	XactCommit    int64     `json:"xact_commit"`
+	XactRollback  int64     `json:"xact_rollback"`
+	BlksRead      int64     `json:"blks_read"`
+	BlksHit       int64     `json:"blks_hit"`
+	CacheHitRatio float64   `json:"cache_hit_ratio"`
+	TupReturned   int64     `json:"tup_returned"`
+	TupFetched    int64     `json:"tup_fetched"`
+	TupInserted   int64     `json:"tup_inserted"`
+	TupUpdated    int64     `json:"tup_updated"`
+	TupDeleted    int64     `json:"tup_deleted"`
+	Deadlocks     int64     `json:"deadlocks"`
+	TempBytes     int64     `json:"temp_bytes"`
+}
+
+// AlertStatus represents the current state of an alert source for a connection
+type AlertStatus struct {
+	Source    string     `json:"source"`
+	Name      string     `json:"name"`
+	Status    string     `json:"status"`
+	Message   string     `json:"message,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// Data dictionary formats
+const (
+	DataDictionaryMarkdown = "markdown"
+	DataDictionaryHTML     = "html"
+	DataDictionaryJSON     = "json"
+)
+
+// DataDictionaryColumn represents a column of a documented table
+type DataDictionaryColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+	Default  string `json:"default,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// DataDictionaryForeignKey represents a foreign key of a documented table
+type DataDictionaryForeignKey struct {
+	Name              string   `json:"name"`
+	Columns           []string `json:"columns"`
+	ReferencedSchema  string   `json:"referenced_schema"`
+	ReferencedTable   string   `json:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns"`
+	Definition        string   `json:"definition"`
+}
+
+// DataDictionaryIndex represents an index of a documented table
+type DataDictionaryIndex struct {
+	Name       string `json:"name"`
+	Primary    bool   `json:"primary"`
+	Unique     bool   `json:"unique"`
+	Definition string `json:"definition"`
+}
+
+// DataDictionaryTable represents a documented table, view or materialized view
+type DataDictionaryTable struct {
+	Name        string                     `json:"name"`
+	Kind        string                     `json:"kind"` // table, partitioned table, view, materialized view, foreign table
+	Comment     string                     `json:"comment,omitempty"`
+	Columns     []DataDictionaryColumn     `json:"columns"`
+	ForeignKeys []DataDictionaryForeignKey `json:"foreign_keys"`
+	Indexes     []DataDictionaryIndex      `json:"indexes"`
+}
+
+// DataDictionarySchema represents a documented schema
+type DataDictionarySchema struct {
+	Name    string                `json:"name"`
+	Comment string                `json:"comment,omitempty"`
+	Tables  []DataDictionaryTable `json:"tables"`
+}
+
+// DataDictionary represents the documented catalog of a database
+type DataDictionary struct {
+	ConnectionName string                 `json:"connection_name"`
+	DatabaseName   string                 `json:"database_name"`
+	GeneratedAt    time.Time              `json:"generated_at"`
+	Schemas        []DataDictionarySchema `json:"schemas"`
+}
+
+// DataDictionaryRequest represents the request to generate a data dictionary artifact
+type DataDictionaryRequest struct {
+	Format  string   `json:"format"`  // markdown (default), html or json
+	Schemas []string `json:"schemas"` // All non-system schemas when empty
+}
+
+// DataDictionarySchedule represents a data dictionary regenerated as an artifact on a schedule
+type DataDictionarySchedule struct {
+	ID             string          `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	OwnerID        string          `gorm:"type:varchar(36);not null;index" json:"owner_id"`
+	ConnectionID   string          `gorm:"type:varchar(36);not null;index" json:"connection_id"`
+	DatabaseName   string          `gorm:"type:varchar(255);not null" json:"database_name"`
+	Format         string          `gorm:"type:varchar(20);not null" json:"format"`
+	Schemas        StringList      `gorm:"type:text" json:"schemas"`
+	Frequency      DigestFrequency `gorm:"type:varchar(20);not null" json:"frequency"` // daily or weekly
+	LastRunAt      *time.Time      `json:"last_run_at"`
+	LastArtifactID *string         `gorm:"type:varchar(36)" json:"last_artifact_id,omitempty"`
+	LastError      string          `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt      time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (DataDictionarySchedule) TableName() string {
+	return "data_dictionary_schedules"
+}
+
+// DataDictionaryScheduleRequest represents the request to schedule a data dictionary
+type DataDictionaryScheduleRequest struct {
+	ConnectionID string          `json:"connection_id" binding:"required"`
+	DatabaseName string          `json:"database_name" binding:"required"`
+	Format       string          `json:"format"`
+	Schemas      []string        `json:"schemas"`
+	Frequency    DigestFrequency `json:"frequency" binding:"required"`
+}
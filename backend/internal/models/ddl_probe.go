@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// DDLProbeRequest represents a script whose lock requirements should be probed before it is run
+type DDLProbeRequest struct {
+	Statement string `json:"statement" binding:"required"`
+}
+
+// LockConflict represents an existing session holding a lock a probed statement had to wait for
+type LockConflict struct {
+	PID             int        `json:"pid"`
+	Username        string     `json:"username"`
+	ApplicationName string     `json:"application_name"`
+	ClientAddr      string     `json:"client_addr"`
+	State           string     `json:"state"`
+	Query           string     `json:"query"`
+	XactStart       *time.Time `json:"xact_start,omitempty"`
+	LockModes       string     `json:"lock_modes"` // Modes held on the relations the statement waited for
+	Relations       string     `json:"relations"`
+}
+
+// DDLProbeStatement represents the probe outcome of one statement
+type DDLProbeStatement struct {
+	Statement  string         `json:"statement"`
+	Status     string         `json:"status"` // ok, blocked, timeout, error, skipped
+	Error      string         `json:"error,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+	Conflicts  []LockConflict `json:"conflicts"`
+}
+
+// DDLProbeResult represents whether a script would block on locks held by other sessions
+type DDLProbeResult struct {
+	WouldBlock    bool                `json:"would_block"`
+	LockTimeoutMs int                 `json:"lock_timeout_ms"`
+	Statements    []DDLProbeStatement `json:"statements"`
+	ProbedAt      time.Time           `json:"probed_at"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// DeadlockProcess represents one process of a deadlock cycle as reported in the server log
+type DeadlockProcess struct {
+	PID       int    `json:"pid"`
+	WaitsFor  string `json:"waits_for,omitempty"` // e.g. "ShareLock on transaction 1234"
+	BlockedBy int    `json:"blocked_by,omitempty"`
+	Query     string `json:"query,omitempty"`
+}
+
+// DeadlockProcesses represents the processes of a deadlock stored as JSON
+type DeadlockProcesses []DeadlockProcess
+
+// Value implements driver.Valuer interface for JSON storage
+func (p DeadlockProcesses) Value() (driver.Value, error) {
+	if p == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (p *DeadlockProcesses) Scan(value interface{}) error {
+	if value == nil {
+		*p = DeadlockProcesses{}
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, p)
+}
+
+// DeadlockEvent represents a deadlock detected by the server and collected from its log
+type DeadlockEvent struct {
+	ID           int               `gorm:"primaryKey;autoIncrement" json:"id"`
+	ConnectionID string            `gorm:"column:connection_id;type:varchar(36);not null;uniqueIndex:idx_deadlock_events_fingerprint" json:"connection_id"`
+	DatabaseName string            `gorm:"column:database_name;type:varchar(255);index" json:"database_name"` // Empty when the log line prefix has no database
+	DetectedAt   time.Time         `gorm:"column:detected_at;not null;index" json:"detected_at"`
+	PID          int               `gorm:"column:pid" json:"pid"` // Process whose transaction was aborted
+	Processes    DeadlockProcesses `gorm:"column:processes;type:text" json:"processes"`
+	Statement    string            `gorm:"column:statement;type:text" json:"statement"` // Statement of the aborted process
+	Detail       string            `gorm:"column:detail;type:text" json:"detail"`
+	Fingerprint  string            `gorm:"column:fingerprint;type:varchar(64);not null;uniqueIndex:idx_deadlock_events_fingerprint" json:"-"`
+	CreatedAt    time.Time         `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (DeadlockEvent) TableName() string {
+	return "deadlock_events"
+}
+
+// DeadlockLogCursor records how far the server log of a connection has been read
+type DeadlockLogCursor struct {
+	ConnectionID string    `gorm:"column:connection_id;primaryKey;type:varchar(36)" json:"connection_id"`
+	LogFile      string    `gorm:"column:log_file;type:text" json:"log_file"`
+	Offset       int64     `gorm:"column:log_offset;not null" json:"offset"`
+	LastError    string    `gorm:"column:last_error;type:text" json:"last_error,omitempty"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (DeadlockLogCursor) TableName() string {
+	return "deadlock_log_cursors"
+}
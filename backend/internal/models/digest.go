@@ -0,0 +1,98 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// DigestFrequency represents how often a digest is sent
+type DigestFrequency string
+
+const (
+	DigestFrequencyDaily  DigestFrequency = "daily"
+	DigestFrequencyWeekly DigestFrequency = "weekly"
+)
+
+// SizeMap represents database sizes in bytes keyed by database name, stored as JSON
+type SizeMap map[string]int64
+
+// Value implements driver.Valuer interface for JSON storage
+func (m SizeMap) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (m *SizeMap) Scan(value interface{}) error {
+	if value == nil {
+		*m = SizeMap{}
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, m)
+}
+
+// DigestSubscription represents a user's subscription to a connection's activity digest
+type DigestSubscription struct {
+	ID           string          `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID       string          `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	ConnectionID string          `gorm:"type:varchar(36);not null;index" json:"connection_id"`
+	Email        string          `gorm:"type:varchar(255);not null" json:"email"`
+	Frequency    DigestFrequency `gorm:"type:varchar(20);not null" json:"frequency"`
+	LastSentAt   *time.Time      `json:"last_sent_at"`
+	LastSizes    SizeMap         `gorm:"type:text" json:"-"` // Database sizes at the last digest, used for growth
+	CreatedAt    time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// DigestSubscriptionRequest represents the request to subscribe to a digest
+type DigestSubscriptionRequest struct {
+	ConnectionID string          `json:"connection_id" binding:"required"`
+	Email        string          `json:"email" binding:"required,email"`
+	Frequency    DigestFrequency `json:"frequency" binding:"required"`
+}
+
+// DigestFailure represents a failed operation included in a digest
+type DigestFailure struct {
+	Source    string    `json:"source"`
+	Operation string    `json:"operation"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DigestStorageGrowth represents the size change of a database since the previous digest
+type DigestStorageGrowth struct {
+	DatabaseName  string `json:"database_name"`
+	SizeBytes     int64  `json:"size_bytes"`
+	PreviousBytes *int64 `json:"previous_bytes,omitempty"`
+	GrowthBytes   *int64 `json:"growth_bytes,omitempty"`
+}
+
+// ActivityDigest represents the activity summary of a connection over a period
+type ActivityDigest struct {
+	ConnectionID     string                `json:"connection_id"`
+	ConnectionName   string                `json:"connection_name"`
+	PeriodStart      time.Time             `json:"period_start"`
+	PeriodEnd        time.Time             `json:"period_end"`
+	TopQueries       []*QueryStatement     `json:"top_queries"`
+	NewRoles         []RoleSaveLog         `json:"new_roles"`
+	FailedOperations []DigestFailure       `json:"failed_operations"`
+	AlertCounts      map[string]int        `json:"alert_counts"` // Alert status -> count
+	StorageGrowth    []DigestStorageGrowth `json:"storage_growth"`
+	Errors           []string              `json:"errors,omitempty"` // Sections that could not be collected
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ExplainRequest represents a statement whose execution plan is requested
+type ExplainRequest struct {
+	Query     string `json:"query" binding:"required"`
+	Analyze   bool   `json:"analyze"`    // Runs the statement (inside a transaction that is rolled back) for actual timings
+	Buffers   bool   `json:"buffers"`    // Buffer usage; only with analyze
+	Verbose   bool   `json:"verbose"`    // Output columns and schema-qualified names
+	TimeoutMs int    `json:"timeout_ms"` // Statement timeout of an analyzed run; zero uses the server default
+}
+
+// PlanNode represents one node of an execution plan. The common properties are typed; the rest
+// of what PostgreSQL reports for the node is kept in Details under its original name.
+type PlanNode struct {
+	NodeType           string         `json:"node_type"`
+	ParentRelationship string         `json:"parent_relationship,omitempty"`
+	RelationName       string         `json:"relation_name,omitempty"`
+	Schema             string         `json:"schema,omitempty"`
+	Alias              string         `json:"alias,omitempty"`
+	IndexName          string         `json:"index_name,omitempty"`
+	JoinType           string         `json:"join_type,omitempty"`
+	StartupCost        float64        `json:"startup_cost"`
+	TotalCost          float64        `json:"total_cost"`
+	PlanRows           float64        `json:"plan_rows"`
+	PlanWidth          int            `json:"plan_width"`
+	ActualStartupMs    *float64       `json:"actual_startup_ms,omitempty"`
+	ActualTotalMs      *float64       `json:"actual_total_ms,omitempty"`
+	ActualRows         *float64       `json:"actual_rows,omitempty"`
+	ActualLoops        *float64       `json:"actual_loops,omitempty"`
+	ExclusiveMs        *float64       `json:"exclusive_ms,omitempty"`         // Time spent in this node without its children, over all loops
+	RowsEstimateFactor *float64       `json:"rows_estimate_factor,omitempty"` // Actual rows per estimated row; far from 1 points to stale statistics
+	Details            map[string]any `json:"details"`
+	Children           []PlanNode     `json:"children"`
+}
+
+// ExplainResult represents the execution plan of a statement
+type ExplainResult struct {
+	Query           string          `json:"query"`
+	Analyzed        bool            `json:"analyzed"`
+	Plan            PlanNode        `json:"plan"`
+	PlanningTimeMs  *float64        `json:"planning_time_ms,omitempty"`
+	ExecutionTimeMs *float64        `json:"execution_time_ms,omitempty"`
+	Triggers        []any           `json:"triggers,omitempty"`
+	Raw             json.RawMessage `json:"raw"` // EXPLAIN output as returned by PostgreSQL
+	ExplainedAt     time.Time       `json:"explained_at"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// FilterExpression represents a filter of a table listing, sent as JSON in the "filter" query
+// parameter. It is either a condition on one column (field, op and value) or a group joining
+// nested expressions with "and" or "or", for example
+//
+//	{"and": [{"field": "state", "op": "eq", "value": "TX"}, {"field": "city", "op": "contains", "value": "West"}]}
+//
+// Operators: eq, ne, lt, lte, gt, gte, contains, starts_with, ends_with, in, not_in (value is an
+// array), is_null and is_not_null (no value).
+type FilterExpression struct {
+	Field string             `json:"field,omitempty"`
+	Op    string             `json:"op,omitempty"`
+	Value interface{}        `json:"value,omitempty"`
+	And   []FilterExpression `json:"and,omitempty"`
+	Or    []FilterExpression `json:"or,omitempty"`
+}
+
+// StoredFilterExpression is a filter expression stored as JSON text; it reads and writes the same
+// JSON as the expression it wraps
+type StoredFilterExpression struct {
+	FilterExpression
+}
+
+// Value implements driver.Valuer interface for JSON storage
+func (f StoredFilterExpression) Value() (driver.Value, error) {
+	b, err := json.Marshal(f.FilterExpression)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (f *StoredFilterExpression) Scan(value interface{}) error {
+	if value == nil {
+		*f = StoredFilterExpression{}
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, &f.FilterExpression)
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// What happens to edits of a protected column by users who may not edit it
+const (
+	ColumnRuleActionStrip  = "strip"  // The column is dropped from the edit and the rest is saved
+	ColumnRuleActionReject = "reject" // The whole edit is refused
+)
+
+// HohAddressColumnRule limits who may set a column of the HohAddress blacklist or whitelist when
+// creating or updating rows. Admins and members of the listed permission groups may set it.
+type HohAddressColumnRule struct {
+	ID                   string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	HohAddressDatabaseID string     `gorm:"column:hohaddress_database_id;type:varchar(36);index" json:"hohaddress_database_id,omitempty"` // Empty applies to every database
+	Table                string     `gorm:"column:table_name;type:varchar(100);not null" json:"table_name"`
+	Column               string     `gorm:"column:column_name;type:varchar(255);not null" json:"column_name"`
+	Groups               StringList `gorm:"type:text" json:"groups"` // IDs of the permission groups whose members may edit the column
+	Action               string     `gorm:"type:varchar(20);not null;default:'reject'" json:"action"`
+	Description          string     `gorm:"type:text" json:"description"`
+	CreatedBy            string     `gorm:"type:varchar(36)" json:"created_by"`
+	CreatedAt            time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (HohAddressColumnRule) TableName() string {
+	return "hohaddress_column_rules"
+}
+
+// HohAddressColumnRuleRequest represents the request to create or update a column rule
+type HohAddressColumnRuleRequest struct {
+	HohAddressDatabaseID string   `json:"hohaddress_database_id"`
+	Table                string   `json:"table_name" binding:"required,oneof=hohaddressblacklist hohaddresswhitelist"`
+	Column               string   `json:"column_name" binding:"required,max=255"`
+	Groups               []string `json:"groups"`
+	Action               string   `json:"action" binding:"omitempty,oneof=strip reject"` // Defaults to reject
+	Description          string   `json:"description"`
+}
+
+// HohAddressColumnViolation records an edit that tried to set protected columns
+type HohAddressColumnViolation struct {
+	ID                   int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	HohAddressDatabaseID string     `gorm:"column:hohaddress_database_id;type:varchar(36);not null;index" json:"hohaddress_database_id"`
+	Table                string     `gorm:"column:table_name;type:varchar(100);not null" json:"table_name"`
+	RowID                string     `gorm:"column:row_id;type:varchar(255)" json:"row_id,omitempty"` // Empty for new rows
+	UserID               string     `gorm:"column:user_id;type:varchar(36);index" json:"user_id"`
+	Columns              StringList `gorm:"column:columns;type:text" json:"columns"`
+	Action               string     `gorm:"column:action;type:varchar(20);not null" json:"action"` // strip or reject, whichever was applied
+	RequestID            string     `gorm:"column:request_id;type:varchar(128);index" json:"request_id,omitempty"`
+	CreatedAt            time.Time  `gorm:"column:created_at;autoCreateTime;index" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (HohAddressColumnViolation) TableName() string {
+	return "hohaddress_column_violations"
+}
@@ -0,0 +1,29 @@
+package models
+
+// HohAddress import row statuses
+const (
+	HohAddressImportImported  = "imported"
+	HohAddressImportDuplicate = "duplicate" // Already in the table or earlier in the file
+	HohAddressImportError     = "error"
+)
+
+// HohAddressImportRow represents the outcome of one CSV row of a whitelist or blacklist import
+type HohAddressImportRow struct {
+	Line   int                    `json:"line"` // Line of the row in the CSV file
+	Status string                 `json:"status"`
+	Error  string                 `json:"error,omitempty"`
+	Row    map[string]interface{} `json:"row,omitempty"` // The inserted row
+}
+
+// HohAddressImportReport represents the per-row result of a whitelist or blacklist import
+type HohAddressImportReport struct {
+	Table      string                `json:"table"`
+	Total      int                   `json:"total"`
+	Imported   int                   `json:"imported"`
+	Duplicates int                   `json:"duplicates"`
+	Failed     int                   `json:"failed"`
+	Rows       []HohAddressImportRow `json:"rows"`
+
+	// File columns left out because column rules do not let the importing user set them
+	StrippedColumns []string `json:"stripped_columns,omitempty"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// HohAddressRowFilter limits the rows of the HohAddress lists a user, or the members of a permission
+// group, can see. Once any row filter applies to a user on a table they only see the rows matching
+// at least one of them; admins always see every row.
+type HohAddressRowFilter struct {
+	ID                   string                 `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID               string                 `gorm:"type:varchar(36);index" json:"user_id,omitempty"`                                              // Set for filters of a user
+	GroupID              string                 `gorm:"type:varchar(36);index" json:"group_id,omitempty"`                                             // Set for filters of a permission group
+	HohAddressDatabaseID string                 `gorm:"column:hohaddress_database_id;type:varchar(36);index" json:"hohaddress_database_id,omitempty"` // Empty applies to every database
+	Table                string                 `gorm:"column:table_name;type:varchar(100)" json:"table_name,omitempty"`                              // Empty applies to the status list, blacklist and whitelist
+	Filter               StoredFilterExpression `gorm:"type:text;not null" json:"filter"`
+	Description          string                 `gorm:"type:text" json:"description"`
+	CreatedBy            string                 `gorm:"type:varchar(36)" json:"created_by"`
+	CreatedAt            time.Time              `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time              `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (HohAddressRowFilter) TableName() string {
+	return "hohaddress_row_filters"
+}
+
+// HohAddressRowFilterRequest represents the request to create or update a row filter; exactly one of
+// UserID and GroupID is set
+type HohAddressRowFilterRequest struct {
+	UserID               string           `json:"user_id"`
+	GroupID              string           `json:"group_id"`
+	HohAddressDatabaseID string           `json:"hohaddress_database_id"`
+	Table                string           `json:"table_name" binding:"omitempty,oneof=hohaddressstatuslist hohaddressblacklist hohaddresswhitelist"`
+	Filter               FilterExpression `json:"filter"`
+	Description          string           `json:"description"`
+}
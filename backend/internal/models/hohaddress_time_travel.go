@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// HohAddressTableSnapshot represents a whitelist or blacklist, or one of its rows, as it was at a
+// point in time. It is rebuilt from the current table by undoing the row edits made since.
+type HohAddressTableSnapshot struct {
+	Table       string      `json:"table"`
+	AsOf        time.Time   `json:"as_of"`
+	RowID       string      `json:"row_id,omitempty"` // Primary key of the requested row; empty for the whole table
+	Rows        []RowValues `json:"rows"`             // Empty when the requested row did not exist
+	UndoneEdits int         `json:"undone_edits"`     // Row edits made after AsOf
+	ChangedRows int         `json:"changed_rows"`     // Rows that differ from the current table
+	RestoreSQL  string      `json:"restore_sql,omitempty"`
+
+	// Primary keys of rows changed after AsOf by writes the row edit log does not have, such as SQL
+	// run against the table directly. Their past values are unknown, so they are returned as they
+	// are now and no RestoreSQL is given.
+	UnloggedRows []RowValues `json:"unlogged_rows,omitempty"`
+}
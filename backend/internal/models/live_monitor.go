@@ -0,0 +1,45 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Live monitoring topics
+const (
+	LiveTopicActiveQueries = "active_queries"
+	LiveTopicLocks         = "locks"
+	LiveTopicDeadlocks     = "deadlocks"
+)
+
+// LiveTopics lists every live monitoring topic
+var LiveTopics = []string{LiveTopicActiveQueries, LiveTopicLocks, LiveTopicDeadlocks}
+
+// Live monitoring message types
+const (
+	LiveMessageSnapshot = "snapshot" // Full state, sent first and after the topics change
+	LiveMessageDelta    = "delta"    // Changes since the previous message
+	LiveMessageError    = "error"
+)
+
+// LiveTopicDelta holds the changes of one topic, keyed by item: the id of active queries and
+// deadlocks, and pid/lock type/relation/mode of locks
+type LiveTopicDelta struct {
+	Upserted map[string]json.RawMessage `json:"upserted"` // New and changed items
+	Removed  []string                   `json:"removed"`
+}
+
+// LiveMonitorMessage is pushed to WebSocket clients of the live monitor
+type LiveMonitorMessage struct {
+	Type      string                     `json:"type"`
+	Timestamp time.Time                  `json:"timestamp"`
+	Interval  string                     `json:"interval,omitempty"`
+	Topics    map[string]*LiveTopicDelta `json:"topics,omitempty"`
+	Error     string                     `json:"error,omitempty"`
+}
+
+// LiveMonitorCommand is sent by WebSocket clients to change their subscription
+type LiveMonitorCommand struct {
+	Topics   []string `json:"topics"`   // Replaces the subscribed topics when set
+	Interval string   `json:"interval"` // Duration such as "10s"; replaces the push interval when set
+}
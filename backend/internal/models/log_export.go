@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// LogExportFilter narrows an export of an operation log; empty fields match every entry
+type LogExportFilter struct {
+	From      *time.Time
+	To        *time.Time
+	UserID    string
+	Operation string
+	Status    string
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Background collectors a monitored database can enable
+const (
+	MonitoringCollectorMetrics   = "metrics"   // Activity, size and statement metrics (metrics_sampling)
+	MonitoringCollectorCapacity  = "capacity"  // Size and connection samples of the capacity report (capacity_sampling)
+	MonitoringCollectorDeadlocks = "deadlocks" // Deadlocks read from the server log of the connection (deadlock_collection)
+)
+
+// MonitoringCollectors lists the collectors accepted by monitored databases
+var MonitoringCollectors = []string{MonitoringCollectorMetrics, MonitoringCollectorCapacity, MonitoringCollectorDeadlocks}
+
+// MonitoredDatabase registers a database of a connection for background collection. While the
+// registry is empty every database of every PostgreSQL connection is collected; once a database
+// is registered, only the enabled registered databases are.
+type MonitoredDatabase struct {
+	ID              string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	ConnectionID    string     `gorm:"type:varchar(36);not null;uniqueIndex:idx_monitored_databases_target" json:"connection_id"`
+	DatabaseName    string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_monitored_databases_target" json:"database_name"`
+	IntervalSeconds int        `gorm:"not null" json:"interval_seconds"` // Minimum time between collections; 0 collects on every run of the collector jobs
+	Collectors      StringList `gorm:"type:text" json:"collectors"`
+	Enabled         bool       `gorm:"not null" json:"enabled"`
+	CreatedBy       string     `gorm:"type:varchar(36)" json:"created_by"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// MonitoredDatabaseRequest represents the request to register or change a monitored database
+type MonitoredDatabaseRequest struct {
+	DatabaseName    string   `json:"database_name" binding:"required"`
+	IntervalSeconds int      `json:"interval_seconds"`
+	Collectors      []string `json:"collectors"` // Defaults to every collector
+	Enabled         *bool    `json:"enabled"`    // Defaults to true
+}
+
+// MonitoredDatabaseList represents the registered databases with the collectors they can enable
+type MonitoredDatabaseList struct {
+	Databases  []MonitoredDatabase `json:"databases"`
+	Collectors []string            `json:"collectors"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Monitoring views that support saved filters
+const (
+	FilterViewActiveQueries = "active_queries"
+	FilterViewLocks         = "locks"
+)
+
+// FilterCriteria represents the field/value pairs of a saved filter, stored as JSON
+type FilterCriteria map[string]string
+
+// Value implements driver.Valuer interface for JSON storage
+func (f FilterCriteria) Value() (driver.Value, error) {
+	if f == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (f *FilterCriteria) Scan(value interface{}) error {
+	if value == nil {
+		*f = FilterCriteria{}
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, f)
+}
+
+// SavedFilter represents a named filter of the active-query or lock view
+type SavedFilter struct {
+	ID           string         `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID       string         `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	ConnectionID string         `gorm:"type:varchar(36);index" json:"connection_id,omitempty"` // Empty applies to every connection
+	View         string         `gorm:"type:varchar(30);not null" json:"view"`
+	Name         string         `gorm:"type:varchar(255);not null" json:"name"`
+	Criteria     FilterCriteria `gorm:"type:text" json:"criteria"`
+	Shared       bool           `gorm:"column:shared" json:"shared"` // Visible to every user
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// SavedFilterRequest represents the request to create or update a saved filter
+type SavedFilterRequest struct {
+	ConnectionID string         `json:"connection_id"`
+	View         string         `json:"view" binding:"required,oneof=active_queries locks"`
+	Name         string         `json:"name" binding:"required,max=255"`
+	Criteria     FilterCriteria `json:"criteria"`
+	Shared       bool           `json:"shared"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// ConnectionOverview represents the landing view figures of a connection's server
+type ConnectionOverview struct {
+	ConnectionID   string         `json:"connection_id"`
+	Name           string         `json:"name"`
+	Type           string         `json:"type"`
+	Host           string         `json:"host"`
+	Port           int            `json:"port"`
+	Status         string         `json:"status"` // ok, error, unsupported
+	Error          string         `json:"error,omitempty"`
+	ServerVersion  string         `json:"server_version,omitempty"`
+	StartedAt      *time.Time     `json:"started_at,omitempty"`
+	UptimeSeconds  int64          `json:"uptime_seconds"`
+	DatabaseCount  int64          `json:"database_count"`
+	TotalSizeBytes int64          `json:"total_size_bytes"`
+	ActiveSessions int64          `json:"active_sessions"` // Client sessions running a statement
+	AlertStatus    string         `json:"alert_status"`    // Worst status of the connection's alerts; ok when it has none
+	AlertCounts    map[string]int `json:"alert_counts"`    // Alerts by status
+}
+
+// Overview represents the landing view across all connections
+type Overview struct {
+	GeneratedAt time.Time            `json:"generated_at"`
+	Connections []ConnectionOverview `json:"connections"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// PartitionRunStatus represents the status of a partition maintenance run
+type PartitionRunStatus string
+
+const (
+	PartitionRunStatusSuccess PartitionRunStatus = "success"
+	PartitionRunStatusError   PartitionRunStatus = "error"
+)
+
+// PartitionMaintenanceLog represents a log entry for a partition maintenance run
+type PartitionMaintenanceLog struct {
+	ID              int                `gorm:"primaryKey;autoIncrement" json:"id"`
+	PolicyID        string             `gorm:"column:policy_id;type:varchar(36);not null;index" json:"policy_id"`
+	UserID          string             `gorm:"column:user_id;type:varchar(36);index" json:"user_id"`
+	Trigger         string             `gorm:"column:trigger;type:varchar(20);not null" json:"trigger"` // manual or scheduled
+	Status          PartitionRunStatus `gorm:"column:status;type:varchar(20);not null" json:"status"`
+	CreatedCount    int                `gorm:"column:created_count;not null;default:0" json:"created_count"`
+	ExpiredCount    int                `gorm:"column:expired_count;not null;default:0" json:"expired_count"`
+	SQLScript       string             `gorm:"column:sql_script;type:text" json:"sql_script,omitempty"`
+	ErrorMessage    string             `gorm:"column:error_message;type:text" json:"error_message,omitempty"`
+	ExecutionTimeMs int                `gorm:"column:execution_time_ms;not null;default:0" json:"execution_time_ms"`
+	CreatedAt       time.Time          `gorm:"column:created_at;autoCreateTime;index" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (PartitionMaintenanceLog) TableName() string {
+	return "partition_maintenance_logs"
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// PartitionInterval represents the range covered by a single partition
+type PartitionInterval string
+
+const (
+	PartitionIntervalDay   PartitionInterval = "day"
+	PartitionIntervalWeek  PartitionInterval = "week"
+	PartitionIntervalMonth PartitionInterval = "month"
+	PartitionIntervalYear  PartitionInterval = "year"
+)
+
+// PartitionPolicy configures automatic maintenance of a range-partitioned table
+type PartitionPolicy struct {
+	ID              string            `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	ConnectionID    string            `gorm:"type:varchar(36);not null;index" json:"connection_id"`
+	DatabaseName    string            `gorm:"type:varchar(255);not null" json:"database_name"`
+	SchemaName      string            `gorm:"type:varchar(255);not null" json:"schema_name"`
+	TableName       string            `gorm:"type:varchar(255);not null" json:"table_name"`
+	Interval        PartitionInterval `gorm:"type:varchar(20);not null" json:"interval"`
+	Premake         int               `gorm:"not null" json:"premake"`                                            // Number of upcoming partitions to keep created
+	Retention       int               `gorm:"not null" json:"retention"`                                          // Number of past intervals to keep, 0 keeps everything
+	RetentionAction string            `gorm:"type:varchar(20);not null;default:'detach'" json:"retention_action"` // detach or drop
+	Enabled         bool              `gorm:"not null" json:"enabled"`
+	LastRunAt       *time.Time        `json:"last_run_at"`
+	LastStatus      string            `gorm:"type:varchar(20)" json:"last_status"`
+	CreatedAt       time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// PartitionPolicyRequest represents the request to create or update a partition policy
+type PartitionPolicyRequest struct {
+	ConnectionID    string            `json:"connection_id" binding:"required"`
+	DatabaseName    string            `json:"database_name" binding:"required"`
+	SchemaName      string            `json:"schema_name" binding:"required"`
+	TableName       string            `json:"table_name" binding:"required"`
+	Interval        PartitionInterval `json:"interval" binding:"required"`
+	Premake         int               `json:"premake"`
+	Retention       int               `json:"retention"`
+	RetentionAction string            `json:"retention_action"`
+	Enabled         *bool             `json:"enabled"`
+}
+
+// PartitionPlanItem represents a single partition affected by a maintenance run
+type PartitionPlanItem struct {
+	Name   string `json:"name"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Action string `json:"action"` // create, detach, drop
+}
+
+// PartitionPlan represents the changes a maintenance run would apply
+type PartitionPlan struct {
+	PolicyID   string              `json:"policy_id"`
+	Create     []PartitionPlanItem `json:"create"`
+	Expire     []PartitionPlanItem `json:"expire"`
+	Statements []string            `json:"statements"`
+}
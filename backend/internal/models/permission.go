@@ -0,0 +1,112 @@
+package models
+
+import "time"
+
+// Permissions granted to non-admin users. Admins hold every permission.
+const (
+	PermConnectionsRead  = "connections:read"
+	PermConnectionsWrite = "connections:write"
+	PermQueryExecute     = "query:execute"
+	PermQueryRunAsRole   = "query:run_as_role"
+	PermRolesManage      = "roles:manage"
+	PermMonitoringRead   = "monitoring:read"
+	PermMonitoringWrite  = "monitoring:write"
+	PermTruETLRead       = "truetl:read"
+	PermTruETLWrite      = "truetl:write"
+	PermHohAddressRead   = "hohaddress:read"
+	PermHohAddressWrite  = "hohaddress:write"
+	PermPartitionsRead   = "partitions:read"
+	PermPartitionsManage = "partitions:manage"
+)
+
+// PermissionInfo describes a permission for the permission catalog
+type PermissionInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// PermissionCatalog lists every permission in display order
+var PermissionCatalog = []PermissionInfo{
+	{PermConnectionsRead, "View connections and browse their databases, schemas, tables and roles"},
+	{PermConnectionsWrite, "Create, edit, delete and restore connections; generate data dictionaries and manage their schedules"},
+	{PermQueryExecute, "Run SQL queries, scripts, exports and snapshots"},
+	{PermQueryRunAsRole, "Run SQL queries as another database role of the server (SET ROLE)"},
+	{PermRolesManage, "Create, edit and delete database roles, grant privileges and change ownership"},
+	{PermMonitoringRead, "View active queries, locks, metrics and storage reports"},
+	{PermMonitoringWrite, "Terminate queries and manage annotations and alert silences"},
+	{PermTruETLRead, "View TruETL databases, mappings, runs and lineage"},
+	{PermTruETLWrite, "Edit TruETL databases and mappings and report runs"},
+	{PermHohAddressRead, "View HohAddress databases and lists and check addresses"},
+	{PermHohAddressWrite, "Edit HohAddress databases and black/white lists"},
+	{PermPartitionsRead, "View partition policies, plans and logs"},
+	{PermPartitionsManage, "Create, edit, delete and run partition policies"},
+}
+
+// IsPermission reports whether name is a known permission
+func IsPermission(name string) bool {
+	for _, info := range PermissionCatalog {
+		if info.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionGroup grants a set of permissions to its member users
+type PermissionGroup struct {
+	ID          string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name        string     `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
+	Description string     `gorm:"type:text" json:"description"`
+	Permissions StringList `gorm:"type:text" json:"permissions"`
+	Members     StringList `gorm:"type:text" json:"members"` // User IDs
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (PermissionGroup) TableName() string {
+	return "permission_groups"
+}
+
+// UserPermissions holds the permissions granted to a user directly
+type UserPermissions struct {
+	UserID      string     `gorm:"primaryKey;type:varchar(36)" json:"user_id"`
+	Permissions StringList `gorm:"type:text" json:"permissions"`
+	UpdatedBy   string     `gorm:"type:varchar(36)" json:"updated_by"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (UserPermissions) TableName() string {
+	return "user_permissions"
+}
+
+// PermissionGroupRequest represents the request to create or update a permission group
+type PermissionGroupRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	Members     []string `json:"members"`
+}
+
+// UserPermissionsRequest represents the request to set the direct permissions of a user
+type UserPermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+// Sources of the effective permissions of a user
+const (
+	PermissionSourceAdmin    = "admin"    // Admins hold every permission
+	PermissionSourceDefault  = "default"  // No direct permissions or groups: every permission, as before permissions existed
+	PermissionSourceAssigned = "assigned" // Direct permissions and groups
+)
+
+// EffectivePermissions represents the permissions a user holds and where they come from
+type EffectivePermissions struct {
+	UserID      string   `json:"user_id"`
+	Role        UserRole `json:"role"`
+	Source      string   `json:"source"`
+	Permissions []string `json:"permissions"`
+	Direct      []string `json:"direct"`
+	Groups      []string `json:"groups"` // Names of the groups the user belongs to
+}
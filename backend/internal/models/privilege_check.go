@@ -0,0 +1,34 @@
+package models
+
+// PrivilegeCheckRequest asks whether a role holds a privilege on an object
+type PrivilegeCheckRequest struct {
+	ObjectType     string `json:"object_type" binding:"required"` // database, schema, table, column, sequence or function
+	ObjectSchema   string `json:"object_schema"`                  // Required for tables, columns, sequences and functions
+	ObjectName     string `json:"object_name" binding:"required"` // Table name for columns; signature such as my_func(integer) for functions
+	ObjectDatabase string `json:"object_database"`                // Database where the object resides; the connection's default when empty
+	Column         string `json:"column"`                         // Required for columns
+	Privilege      string `json:"privilege" binding:"required"`   // e.g. SELECT, USAGE, CONNECT, EXECUTE
+}
+
+// PrivilegeGrant is an ACL entry of the checked object that applies to the role, with the chain of
+// role memberships that leads from the role to the grantee
+type PrivilegeGrant struct {
+	Path            []string `json:"path"` // Checked role first, grantee last; ends in PUBLIC for grants to everyone
+	Grantee         string   `json:"grantee"`
+	Grantor         string   `json:"grantor"`
+	Level           string   `json:"level"` // object, or table for column checks satisfied by a grant on the whole table
+	WithGrantOption bool     `json:"with_grant_option"`
+	Inherited       bool     `json:"inherited"` // False when a membership on the path does not inherit privileges, so the grant needs SET ROLE
+}
+
+// PrivilegeCheckResult answers a privilege check without running anything as the role
+type PrivilegeCheckResult struct {
+	Role       string           `json:"role"`
+	ObjectType string           `json:"object_type"`
+	Object     string           `json:"object"`
+	Privilege  string           `json:"privilege"`
+	Allowed    bool             `json:"allowed"`
+	Superuser  bool             `json:"superuser"`
+	Reason     string           `json:"reason"`
+	Grants     []PrivilegeGrant `json:"grants"`
+}
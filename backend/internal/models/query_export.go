@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// QueryExportStatus represents the progress of a query export
+type QueryExportStatus string
+
+const (
+	QueryExportRunning   QueryExportStatus = "running"
+	QueryExportCompleted QueryExportStatus = "completed"
+	QueryExportFailed    QueryExportStatus = "failed"
+)
+
+// QueryExport is a query result written to a CSV artifact in the background.
+// Once completed it is downloaded through a signed link instead of re-running the query.
+type QueryExport struct {
+	ID           string            `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name         string            `gorm:"type:varchar(255);not null" json:"name"`
+	ConnectionID string            `gorm:"type:varchar(36);not null;index" json:"connection_id"`
+	DatabaseName string            `gorm:"type:varchar(255);not null" json:"database_name"`
+	Query        string            `gorm:"type:text;not null" json:"query"`
+	OwnerID      string            `gorm:"type:varchar(36);not null;index" json:"owner_id"`
+	Status       QueryExportStatus `gorm:"type:varchar(20);not null" json:"status"`
+	RowCount     int64             `gorm:"not null;default:0" json:"row_count"`
+	Truncated    bool              `gorm:"not null;default:false" json:"truncated"` // Stopped at the streaming row cap
+	Error        string            `gorm:"type:text" json:"error,omitempty"`
+	ArtifactID   *string           `gorm:"type:varchar(36)" json:"artifact_id,omitempty"`
+	ReplicaID    string            `gorm:"type:varchar(100);not null;default:''" json:"replica_id"` // Replica running the export
+	CreatedAt    time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	CompletedAt  *time.Time        `json:"completed_at,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (QueryExport) TableName() string {
+	return "query_exports"
+}
+
+// QueryExportRequest represents the request to export a query result as CSV
+type QueryExportRequest struct {
+	Name  string `json:"name"` // File name of the export; defaults to export-<timestamp>.csv
+	Query string `json:"query" binding:"required"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// QueryTerminationLog represents a backend process terminated from the active queries view
+type QueryTerminationLog struct {
+	ID           int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ConnectionID string    `gorm:"column:connection_id;type:varchar(36);not null;index" json:"connection_id"`
+	DatabaseName string    `gorm:"column:database_name;type:varchar(255);not null" json:"database_name"`
+	PID          int       `gorm:"column:pid;not null" json:"pid"`
+	Query        string    `gorm:"column:query;type:text" json:"query"`                     // Query text from pg_stat_activity at termination time
+	DBUsername   string    `gorm:"column:db_username;type:varchar(255)" json:"db_username"` // Database user running the query
+	UserID       string    `gorm:"column:user_id;type:varchar(36);index" json:"user_id"`    // TruAdmin user who terminated it
+	Reason       string    `gorm:"column:reason;type:text" json:"reason,omitempty"`
+	Terminated   bool      `gorm:"column:terminated;not null" json:"terminated"` // false when the backend had already exited
+	ErrorMessage string    `gorm:"column:error_message;type:text" json:"error_message,omitempty"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime;index" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (QueryTerminationLog) TableName() string {
+	return "query_termination_logs"
+}
+
+// TerminateQueriesRequest represents the request to terminate backend processes
+type TerminateQueriesRequest struct {
+	PIDs   []string `json:"pids" binding:"required"`
+	Reason string   `json:"reason"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// JSON responses follow one policy for collections: a list field is always
+// encoded as an array, [] when empty and never null. omitempty is reserved for
+// optional scalars and for lists that only exist in some modes of a response
+// (for example QueryResult.Statements, which is set for sandbox runs only).
+
+// NonNil returns items, or an empty slice when items is nil, so that it encodes as []
+func NonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -21,6 +21,7 @@ type RoleSaveLog struct {
 	Operation    string            `gorm:"column:operation;type:varchar(30);not null" json:"operation"` // create, update, delete, grant_privileges, revoke_privileges, grant_membership, revoke_membership
 	Status       RoleSaveLogStatus `gorm:"column:status;type:varchar(20);not null" json:"status"`
 	ErrorMessage string            `gorm:"column:error_message;type:text" json:"error_message,omitempty"`
+	RequestID    string            `gorm:"column:request_id;type:varchar(128);index" json:"request_id,omitempty"`
 	CreatedAt    time.Time         `gorm:"column:created_at;autoCreateTime;index" json:"created_at"`
 }
 
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Types of saved query parameters
+const (
+	QueryParamText      = "text"
+	QueryParamInteger   = "integer"
+	QueryParamNumber    = "number"
+	QueryParamBoolean   = "boolean"
+	QueryParamDate      = "date"      // YYYY-MM-DD
+	QueryParamTimestamp = "timestamp" // RFC 3339
+)
+
+// QueryParamTypes lists the accepted types of saved query parameters
+var QueryParamTypes = []string{QueryParamText, QueryParamInteger, QueryParamNumber, QueryParamBoolean, QueryParamDate, QueryParamTimestamp}
+
+// QueryParameter declares a template variable of a saved query. The query refers to it as
+// {{name}}; each reference is sent to the database as a bind parameter, never spliced into the SQL.
+type QueryParameter struct {
+	Name          string   `json:"name"`
+	Label         string   `json:"label,omitempty"` // Prompt shown to the user; the name when empty
+	Type          string   `json:"type"`
+	Default       any      `json:"default,omitempty"`        // Used when a run gives no value
+	Required      bool     `json:"required,omitempty"`       // A run must give a value unless there is a default
+	AllowedValues []string `json:"allowed_values,omitempty"` // Offered as a pick list; other values are rejected
+}
+
+// QueryParameters represents the parameters of a saved query stored as JSON
+type QueryParameters []QueryParameter
+
+// Value implements driver.Valuer interface for JSON storage
+func (p QueryParameters) Value() (driver.Value, error) {
+	if p == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (p *QueryParameters) Scan(value interface{}) error {
+	if value == nil {
+		*p = QueryParameters{}
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, p)
+}
+
+// SavedQuery represents a named, reusable query of a connection, optionally with typed parameters
+type SavedQuery struct {
+	ID           string          `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID       string          `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	ConnectionID string          `gorm:"type:varchar(36);not null;index" json:"connection_id"`
+	DatabaseName string          `gorm:"type:varchar(255)" json:"database_name,omitempty"` // Empty for the connection's default database
+	Name         string          `gorm:"type:varchar(255);not null" json:"name"`
+	Description  string          `gorm:"type:text" json:"description"`
+	Query        string          `gorm:"type:text;not null" json:"query"`
+	Parameters   QueryParameters `gorm:"type:text" json:"parameters"`
+	Shared       bool            `gorm:"column:shared" json:"shared"` // Visible to and runnable by every user
+	CreatedAt    time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// SavedQueryRequest represents the request to create or update a saved query
+type SavedQueryRequest struct {
+	ConnectionID string           `json:"connection_id" binding:"required"`
+	DatabaseName string           `json:"database_name"`
+	Name         string           `json:"name" binding:"required,max=255"`
+	Description  string           `json:"description"`
+	Query        string           `json:"query" binding:"required"`
+	Parameters   []QueryParameter `json:"parameters"`
+	Shared       bool             `json:"shared"`
+}
+
+// SavedQueryRunRequest represents the parameter values of a run of a saved query, by parameter name
+type SavedQueryRunRequest struct {
+	Parameters map[string]any `json:"parameters"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// SchemaBootstrapStatus represents the status of a schema initialization
+type SchemaBootstrapStatus string
+
+const (
+	SchemaBootstrapStatusSuccess SchemaBootstrapStatus = "success"
+	SchemaBootstrapStatusError   SchemaBootstrapStatus = "error"
+	SchemaBootstrapStatusDryRun  SchemaBootstrapStatus = "dry_run"
+)
+
+// SchemaBootstrapRequest represents the request to create the TruETL or HohAddress schema in a database
+type SchemaBootstrapRequest struct {
+	DatabaseName string `json:"database_name" binding:"required"`
+	DryRun       bool   `json:"dry_run"` // Only return the script
+}
+
+// SchemaBootstrapLog represents a log entry for a schema initialization; the script holds every
+// statement that was run, up to the failing one
+type SchemaBootstrapLog struct {
+	ID              int                   `gorm:"primaryKey;autoIncrement" json:"id"`
+	ConnectionID    string                `gorm:"column:connection_id;type:varchar(36);not null;index" json:"connection_id"`
+	DatabaseName    string                `gorm:"column:database_name;type:varchar(255);not null" json:"database_name"`
+	Module          string                `gorm:"column:module;type:varchar(20);not null" json:"module"` // truetl or hohaddress
+	UserID          string                `gorm:"column:user_id;type:varchar(36);index" json:"user_id"`
+	Status          SchemaBootstrapStatus `gorm:"column:status;type:varchar(20);not null" json:"status"`
+	Migrations      StringList            `gorm:"column:migrations;type:text" json:"migrations"` // Embedded scripts, in the order run
+	SQLScript       string                `gorm:"column:sql_script;type:text" json:"sql_script,omitempty"`
+	ErrorMessage    string                `gorm:"column:error_message;type:text" json:"error_message,omitempty"`
+	ExecutionTimeMs int                   `gorm:"column:execution_time_ms;not null;default:0" json:"execution_time_ms"`
+	RequestID       string                `gorm:"column:request_id;type:varchar(128);index" json:"request_id,omitempty"`
+	CreatedAt       time.Time             `gorm:"column:created_at;autoCreateTime;index" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (SchemaBootstrapLog) TableName() string {
+	return "schema_bootstrap_logs"
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Security check outcomes
+const (
+	SecurityCheckPass    = "pass"
+	SecurityCheckWarn    = "warn"
+	SecurityCheckFail    = "fail"
+	SecurityCheckSkipped = "skipped" // Could not be checked, e.g. for lack of privileges; not scored
+)
+
+// SecurityFinding represents the outcome of one check of a security posture report
+type SecurityFinding struct {
+	Check          string   `json:"check"`
+	Title          string   `json:"title"`
+	Status         string   `json:"status"`
+	Weight         int      `json:"weight"` // Points the check is worth; a warning earns half
+	Message        string   `json:"message"`
+	Details        []string `json:"details,omitempty"` // Offending roles, grants or pg_hba lines
+	Recommendation string   `json:"recommendation,omitempty"`
+}
+
+// SecurityReport represents the scored security posture of a PostgreSQL server and one of its databases
+type SecurityReport struct {
+	ConnectionID   string            `json:"connection_id"`
+	ConnectionName string            `json:"connection_name"`
+	DatabaseName   string            `json:"database_name"`
+	ServerVersion  string            `json:"server_version"`
+	GeneratedAt    time.Time         `json:"generated_at"`
+	Score          int               `json:"score"` // 0-100, from the checks that could be run
+	Grade          string            `json:"grade"` // A to F
+	Findings       []SecurityFinding `json:"findings"`
+}
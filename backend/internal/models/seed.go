@@ -0,0 +1,52 @@
+package models
+
+// SeedFile is the declarative seed applied at startup (SEED_FILE). Everything in it is created
+// only when missing, so the file can stay in place across restarts without undoing later changes.
+type SeedFile struct {
+	Users       []SeedUser       `yaml:"users"`
+	Connections []SeedConnection `yaml:"connections"`
+	Settings    SeedSettings     `yaml:"settings"`
+}
+
+// SeedUser is a user created when no user with the username exists
+type SeedUser struct {
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	Role     UserRole `yaml:"role"` // admin or user; user when empty
+}
+
+// SeedConnection is a connection created when no connection with the name exists
+type SeedConnection struct {
+	Name          string            `yaml:"name"`
+	Type          string            `yaml:"type"`
+	Host          string            `yaml:"host"`
+	Port          int               `yaml:"port"`
+	Database      string            `yaml:"database"`
+	Username      string            `yaml:"username"`
+	Password      string            `yaml:"password"`
+	SSLMode       string            `yaml:"ssl_mode"`
+	ExtraParams   map[string]string `yaml:"extra_params"`
+	RequiresGrant bool              `yaml:"requires_grant"`
+}
+
+// SeedSettings are deployment settings saved only when an administrator has not saved them yet
+type SeedSettings struct {
+	Branding *SeedBranding `yaml:"branding"`
+	SMTP     *SeedSMTP     `yaml:"smtp"`
+}
+
+// SeedBranding is the seeded product name and palette
+type SeedBranding struct {
+	ProductName string          `yaml:"product_name"`
+	Palette     BrandingPalette `yaml:"palette"`
+}
+
+// SeedSMTP is the seeded SMTP configuration
+type SeedSMTP struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+	TLSMode  string `yaml:"tls_mode"`
+}
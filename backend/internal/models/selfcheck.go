@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// SelfCheckStatus represents the outcome of a configuration check
+type SelfCheckStatus string
+
+const (
+	SelfCheckPass SelfCheckStatus = "pass"
+	SelfCheckWarn SelfCheckStatus = "warn"
+	SelfCheckFail SelfCheckStatus = "fail"
+)
+
+// SelfCheckItem represents the result of a single configuration check
+type SelfCheckItem struct {
+	Name    string          `json:"name"`
+	Status  SelfCheckStatus `json:"status"`
+	Message string          `json:"message"`
+}
+
+// SelfCheckReport represents the results of all configuration checks; Status is the worst item status
+type SelfCheckReport struct {
+	Status    SelfCheckStatus `json:"status"`
+	CheckedAt time.Time       `json:"checked_at"`
+	Checks    []SelfCheckItem `json:"checks"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// SMTPSettingsID is the primary key of the single SMTP settings row
+const SMTPSettingsID = "default"
+
+// SMTPSettings represents the runtime SMTP configuration used for alert and digest emails.
+// When saved it overrides the SMTP_* environment variables.
+type SMTPSettings struct {
+	ID        string    `gorm:"primaryKey;type:varchar(36)" json:"-"`
+	Host      string    `gorm:"type:varchar(255)" json:"host"`
+	Port      string    `gorm:"type:varchar(5)" json:"port"`
+	Username  string    `gorm:"type:varchar(255)" json:"username"`
+	Password  string    `gorm:"type:text" json:"-"`
+	From      string    `gorm:"column:from_address;type:varchar(255)" json:"from"`
+	TLSMode   string    `gorm:"type:varchar(10)" json:"tls_mode"`
+	UpdatedBy string    `gorm:"type:varchar(36)" json:"-"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// SMTPSettingsResponse is the admin view of the SMTP settings with the password masked
+type SMTPSettingsResponse struct {
+	Host        string     `json:"host"`
+	Port        string     `json:"port"`
+	Username    string     `json:"username"`
+	Password    string     `json:"password"` // MaskedValue when a password is set, empty otherwise
+	PasswordSet bool       `json:"password_set"`
+	From        string     `json:"from"`
+	TLSMode     string     `json:"tls_mode"`
+	Source      string     `json:"source"` // "settings" when saved through the API, "environment" otherwise
+	Enabled     bool       `json:"enabled"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// SMTPSettingsRequest represents the request to update the SMTP settings.
+// A nil or masked password keeps the stored one; an empty string clears it.
+type SMTPSettingsRequest struct {
+	Host     string  `json:"host" binding:"max=255"`
+	Port     string  `json:"port"`
+	Username string  `json:"username" binding:"max=255"`
+	Password *string `json:"password"`
+	From     string  `json:"from" binding:"omitempty,email"`
+	TLSMode  string  `json:"tls_mode" binding:"omitempty,oneof=starttls tls none"`
+}
+
+// SMTPTestRequest represents the request to send a test email with the saved settings
+type SMTPTestRequest struct {
+	To string `json:"to" binding:"required,email"`
+}
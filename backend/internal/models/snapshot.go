@@ -0,0 +1,87 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// StringList represents a list of strings stored as JSON
+type StringList []string
+
+// Value implements driver.Valuer interface for JSON storage
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (l *StringList) Scan(value interface{}) error {
+	if value == nil {
+		*l = StringList{}
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, l)
+}
+
+// Contains reports whether the list contains s
+func (l StringList) Contains(s string) bool {
+	for _, item := range l {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// QueryResultSnapshot represents a stored, compressed query result set
+type QueryResultSnapshot struct {
+	ID             string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name           string     `gorm:"type:varchar(255);not null" json:"name"`
+	ConnectionID   string     `gorm:"type:varchar(36);not null;index" json:"connection_id"`
+	DatabaseName   string     `gorm:"type:varchar(255);not null" json:"database_name"`
+	Query          string     `gorm:"type:text;not null" json:"query"`
+	OwnerID        string     `gorm:"type:varchar(36);not null;index" json:"owner_id"`
+	RowCount       int        `gorm:"not null" json:"row_count"`
+	OriginalSize   int        `gorm:"not null" json:"original_size"`   // Size of the JSON result in bytes
+	CompressedSize int        `gorm:"not null" json:"compressed_size"` // Size of the stored gzip data in bytes
+	Data           []byte     `gorm:"not null" json:"-"`               // gzip-compressed JSON of the QueryResult
+	ShareToken     *string    `gorm:"type:varchar(64);uniqueIndex" json:"share_token,omitempty"`
+	SharedWith     StringList `gorm:"type:text" json:"shared_with"` // User IDs allowed to open the link; empty means all users
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// SnapshotRequest represents the request to save a query result as a snapshot
+type SnapshotRequest struct {
+	Name         string `json:"name" binding:"required"`
+	ConnectionID string `json:"connection_id" binding:"required"`
+	DatabaseName string `json:"database_name" binding:"required"`
+	Query        string `json:"query" binding:"required"`
+}
+
+// SnapshotShareRequest represents the request to share a snapshot
+type SnapshotShareRequest struct {
+	UserIDs []string `json:"user_ids"` // Empty shares with every authenticated user
+}
+
+// SnapshotWithResult includes the decompressed result set
+type SnapshotWithResult struct {
+	QueryResultSnapshot
+	Result *QueryResult `json:"result"`
+}
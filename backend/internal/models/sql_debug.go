@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// SQLDebugHeader turns on SQL capture for a request when an admin sets it to true
+const SQLDebugHeader = "X-Debug-SQL"
+
+// CapturedSQLStatement represents one statement sent to a managed database during a request
+type CapturedSQLStatement struct {
+	Statement  string    `json:"statement"`
+	Args       []string  `json:"args,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs float64   `json:"duration_ms"` // Until the server answered; reading rows is not included
+	Error      string    `json:"error,omitempty"`
+}
+
+// SQLDebugInfo represents the SQL captured during a request
+type SQLDebugInfo struct {
+	Statements      []CapturedSQLStatement `json:"statements"`
+	StatementCount  int                    `json:"statement_count"`
+	TotalDurationMs float64                `json:"total_duration_ms"`
+	Truncated       bool                   `json:"truncated,omitempty"` // More statements ran than were kept
+}
+
+// SQLDebugEnvelope wraps a JSON response when SQL capture is on
+type SQLDebugEnvelope struct {
+	Data  interface{}   `json:"data"`
+	Debug *SQLDebugInfo `json:"debug"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// SQLHistoryEntry represents a query a user ran from the SQL editor. Unlike the audit log it is
+// private to the user: it keeps their recent queries for search and re-running, and starred
+// entries are kept when the history is trimmed to its size limit.
+type SQLHistoryEntry struct {
+	ID           string           `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID       string           `gorm:"column:user_id;type:varchar(255);not null;index:idx_sql_history_user_created" json:"user_id"` // A user ID, or the caller of the gRPC API
+	ConnectionID string           `gorm:"column:connection_id;type:varchar(36);not null" json:"connection_id"`
+	DatabaseName string           `gorm:"column:database_name;type:varchar(255)" json:"database_name,omitempty"` // Empty for the connection's default database
+	Query        string           `gorm:"column:query;type:text;not null" json:"query"`
+	Sandbox      bool             `gorm:"column:sandbox;not null" json:"sandbox,omitempty"`
+	Status       AuditEventStatus `gorm:"column:status;type:varchar(20);not null" json:"status"`
+	ErrorMessage string           `gorm:"column:error_message;type:text" json:"error_message,omitempty"`
+	RowCount     int64            `gorm:"column:row_count" json:"row_count"` // Rows returned, or affected by statements that return none
+	DurationMs   int64            `gorm:"column:duration_ms" json:"duration_ms"`
+	Starred      bool             `gorm:"column:starred;not null;index" json:"starred"`
+	CreatedAt    time.Time        `gorm:"column:created_at;autoCreateTime;index:idx_sql_history_user_created" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (SQLHistoryEntry) TableName() string {
+	return "sql_history_entries"
+}
+
+// SQLHistoryFilter selects entries of a user's SQL history
+type SQLHistoryFilter struct {
+	Search       string // Case-insensitive text the query contains
+	ConnectionID string
+	StarredOnly  bool
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// SQLJobRunStatus represents the progress of a run of a scheduled SQL job
+type SQLJobRunStatus string
+
+const (
+	SQLJobRunQueued    SQLJobRunStatus = "queued"
+	SQLJobRunRunning   SQLJobRunStatus = "running"
+	SQLJobRunSucceeded SQLJobRunStatus = "succeeded"
+	SQLJobRunFailed    SQLJobRunStatus = "failed"
+)
+
+// What started a run of a scheduled SQL job
+const (
+	SQLJobTriggerSchedule = "schedule"
+	SQLJobTriggerManual   = "manual"
+)
+
+// SQLJobResult represents the stored result of a job run: the rows of the last statement that
+// returned any, capped, and the rows affected by the other statements
+type SQLJobResult struct {
+	Columns      []string         `json:"columns"`
+	Rows         []map[string]any `json:"rows"`
+	Truncated    bool             `json:"truncated,omitempty"`
+	RowsAffected int64            `json:"rows_affected"`
+}
+
+// Value implements driver.Valuer interface for JSON storage
+func (r SQLJobResult) Value() (driver.Value, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (r *SQLJobResult) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, r)
+}
+
+// SQLJob represents SQL registered by an admin that the server runs on a cron schedule
+type SQLJob struct {
+	ID              string           `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name            string           `gorm:"type:varchar(255);not null" json:"name"`
+	Description     string           `gorm:"type:text" json:"description"`
+	ConnectionID    string           `gorm:"type:varchar(36);not null;index" json:"connection_id"`
+	DatabaseName    string           `gorm:"type:varchar(255);not null" json:"database_name"`
+	Query           string           `gorm:"type:text;not null" json:"query"`
+	Schedule        string           `gorm:"type:varchar(100);not null" json:"schedule"`              // Cron expression (minute hour day-of-month month day-of-week) or @hourly, @daily, ...
+	Timezone        string           `gorm:"type:varchar(64);not null;default:'UTC'" json:"timezone"` // Time zone the schedule is read in
+	TimeoutSeconds  int              `gorm:"not null;default:0" json:"timeout_seconds"`               // Zero uses the server default
+	Enabled         bool             `gorm:"not null" json:"enabled"`
+	NotifyOnFailure bool             `gorm:"not null" json:"notify_on_failure"`
+	CreatedBy       string           `gorm:"type:varchar(36)" json:"created_by"`
+	NextRunAt       *time.Time       `gorm:"index" json:"next_run_at"`
+	LastRunAt       *time.Time       `json:"last_run_at"`
+	LastStatus      *SQLJobRunStatus `gorm:"type:varchar(20)" json:"last_status"`
+	CreatedAt       time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (SQLJob) TableName() string {
+	return "sql_jobs"
+}
+
+// SQLJobRun represents one execution of a scheduled SQL job
+type SQLJobRun struct {
+	ID          string          `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	JobID       string          `gorm:"type:varchar(36);not null;index" json:"job_id"`
+	Trigger     string          `gorm:"type:varchar(20);not null" json:"trigger"` // schedule or manual
+	TriggeredBy string          `gorm:"type:varchar(36)" json:"triggered_by,omitempty"`
+	Status      SQLJobRunStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Result      SQLJobResult    `gorm:"type:text" json:"result"`
+	Error       string          `gorm:"type:text" json:"error,omitempty"`
+	DurationMs  int64           `gorm:"not null;default:0" json:"duration_ms"`
+	ReplicaID   string          `gorm:"type:varchar(100);not null;default:''" json:"replica_id"` // Replica whose workers run it
+	CreatedAt   time.Time       `gorm:"autoCreateTime;index" json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (SQLJobRun) TableName() string {
+	return "sql_job_runs"
+}
+
+// SQLJobRequest represents the request to create or update a scheduled SQL job
+type SQLJobRequest struct {
+	Name            string `json:"name" binding:"required"`
+	Description     string `json:"description"`
+	ConnectionID    string `json:"connection_id" binding:"required"`
+	DatabaseName    string `json:"database_name" binding:"required"`
+	Query           string `json:"query" binding:"required"`
+	Schedule        string `json:"schedule" binding:"required"`
+	Timezone        string `json:"timezone"` // Defaults to UTC
+	TimeoutSeconds  int    `json:"timeout_seconds"`
+	Enabled         *bool  `json:"enabled"`           // Defaults to true
+	NotifyOnFailure *bool  `json:"notify_on_failure"` // Defaults to true
+}
@@ -0,0 +1,132 @@
+package models
+
+import "time"
+
+// LargeObjectInfo represents a single large object in pg_largeobject_metadata
+type LargeObjectInfo struct {
+	OID   int64  `json:"oid"`
+	Owner string `json:"owner"`
+}
+
+// ToastUsage represents TOAST storage used by a table
+type ToastUsage struct {
+	SchemaName string  `json:"schema_name"`
+	TableName  string  `json:"table_name"`
+	TableBytes int64   `json:"table_bytes"`
+	ToastBytes int64   `json:"toast_bytes"`
+	ToastRatio float64 `json:"toast_ratio"` // TOAST size relative to the main heap
+}
+
+// LargeObjectReport represents large object and TOAST usage for a database
+type LargeObjectReport struct {
+	DatabaseName     string            `json:"database_name"`
+	LargeObjectCount int64             `json:"large_object_count"`
+	LargeObjectBytes int64             `json:"large_object_bytes"` // Total size of pg_largeobject
+	ReferenceColumns []string          `json:"reference_columns"`  // oid/lo columns scanned for references
+	OrphanedCount    int64             `json:"orphaned_count"`
+	OrphanedObjects  []LargeObjectInfo `json:"orphaned_objects"` // Capped sample of orphaned objects
+	ToastHeavyTables []ToastUsage      `json:"toast_heavy_tables"`
+}
+
+// LargeObjectCleanupRequest represents a request to unlink orphaned large objects.
+// ExpectedCount must match the current number of orphans, guarding against acting on a stale report.
+type LargeObjectCleanupRequest struct {
+	ExpectedCount int64 `json:"expected_count" binding:"required"`
+	DryRun        bool  `json:"dry_run"`
+}
+
+// LargeObjectCleanupResult represents the outcome of an orphaned large object cleanup
+type LargeObjectCleanupResult struct {
+	Orphaned int64 `json:"orphaned"`
+	Unlinked int64 `json:"unlinked"`
+	DryRun   bool  `json:"dry_run"`
+}
+
+// DatabaseEncoding represents encoding and locale settings of a database
+type DatabaseEncoding struct {
+	Name     string `json:"name"`
+	Encoding string `json:"encoding"`
+	Collate  string `json:"collate"`
+	Ctype    string `json:"ctype"`
+}
+
+// CollationMismatch represents a collation whose recorded version differs from the one provided by the OS/ICU
+type CollationMismatch struct {
+	SchemaName      string `json:"schema_name"`
+	Name            string `json:"name"`
+	Provider        string `json:"provider"`
+	RecordedVersion string `json:"recorded_version"`
+	ActualVersion   string `json:"actual_version"`
+}
+
+// CollationAffectedIndex represents an index that depends on a mismatched collation
+type CollationAffectedIndex struct {
+	SchemaName string `json:"schema_name"`
+	TableName  string `json:"table_name"`
+	IndexName  string `json:"index_name"`
+	Collation  string `json:"collation"`
+}
+
+// CollationAuditReport represents the collation and encoding audit of a database
+type CollationAuditReport struct {
+	DatabaseName             string                   `json:"database_name"`
+	ServerVersionNum         int                      `json:"server_version_num"`
+	Databases                []DatabaseEncoding       `json:"databases"`
+	DatabaseCollationVersion *string                  `json:"database_collation_version"` // PostgreSQL 15+
+	ActualCollationVersion   *string                  `json:"actual_collation_version"`   // PostgreSQL 15+
+	DatabaseVersionMismatch  bool                     `json:"database_version_mismatch"`
+	MismatchedCollations     []CollationMismatch      `json:"mismatched_collations"`
+	AffectedIndexes          []CollationAffectedIndex `json:"affected_indexes"`
+	ReindexPlan              []string                 `json:"reindex_plan"`
+}
+
+// TableSizeStats represents the size, tuple, scan and cache statistics of a table.
+// Ratios are nil when the counters they divide are all zero.
+type TableSizeStats struct {
+	SchemaName          string     `json:"schema_name"`
+	TableName           string     `json:"table_name"`
+	TableBytes          int64      `json:"table_bytes"` // Main heap only
+	IndexBytes          int64      `json:"index_bytes"`
+	TotalBytes          int64      `json:"total_bytes"` // Heap, indexes and TOAST
+	LiveTuples          int64      `json:"live_tuples"`
+	DeadTuples          int64      `json:"dead_tuples"`
+	DeadTupleRatio      *float64   `json:"dead_tuple_ratio"`
+	EstimatedBloatBytes *int64     `json:"estimated_bloat_bytes"` // Heap beyond the size its live rows need; nil until the table is analyzed
+	BloatRatio          *float64   `json:"bloat_ratio"`           // Estimated bloat relative to the heap size
+	SeqScans            int64      `json:"seq_scans"`
+	IndexScans          int64      `json:"index_scans"`
+	IndexScanRatio      *float64   `json:"index_scan_ratio"` // Index scans out of all scans
+	CacheHitRatio       *float64   `json:"cache_hit_ratio"`  // Heap blocks found in shared buffers
+	LastVacuum          *time.Time `json:"last_vacuum"`
+	LastAutovacuum      *time.Time `json:"last_autovacuum"`
+}
+
+// IndexSizeStats represents the size, scan and cache statistics of an index
+type IndexSizeStats struct {
+	SchemaName    string   `json:"schema_name"`
+	TableName     string   `json:"table_name"`
+	IndexName     string   `json:"index_name"`
+	IndexBytes    int64    `json:"index_bytes"`
+	Scans         int64    `json:"scans"`
+	CacheHitRatio *float64 `json:"cache_hit_ratio"`
+}
+
+// DatabaseSizeStats represents the size and bloat statistics of a database: totals over every
+// user table and the largest tables and indexes
+type DatabaseSizeStats struct {
+	DatabaseName        string           `json:"database_name"`
+	DatabaseBytes       int64            `json:"database_bytes"`
+	TableBytes          int64            `json:"table_bytes"`
+	IndexBytes          int64            `json:"index_bytes"`
+	LiveTuples          int64            `json:"live_tuples"`
+	DeadTuples          int64            `json:"dead_tuples"`
+	DeadTupleRatio      *float64         `json:"dead_tuple_ratio"`
+	EstimatedBloatBytes int64            `json:"estimated_bloat_bytes"` // Sum over the listed tables
+	SeqScans            int64            `json:"seq_scans"`
+	IndexScans          int64            `json:"index_scans"`
+	IndexScanRatio      *float64         `json:"index_scan_ratio"`
+	CacheHitRatio       *float64         `json:"cache_hit_ratio"`       // Heap blocks of user tables
+	IndexCacheHitRatio  *float64         `json:"index_cache_hit_ratio"` // Index blocks of user tables
+	Tables              []TableSizeStats `json:"tables"`                // Largest first
+	Indexes             []IndexSizeStats `json:"indexes"`               // Largest first
+}
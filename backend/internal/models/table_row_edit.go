@@ -0,0 +1,90 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Operations of the table row editor
+const (
+	RowEditInsert = "insert"
+	RowEditUpdate = "update"
+	RowEditDelete = "delete"
+)
+
+// RowValues represents the column values of a table row
+type RowValues map[string]any
+
+// Value implements driver.Valuer interface for JSON storage
+func (v RowValues) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner interface for JSON retrieval
+func (v *RowValues) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	default:
+		return nil
+	}
+}
+
+// TableRow represents a row of the table row editor with its version. The version is a hash of
+// every column of the row; an update or delete is only applied while the row still has it.
+type TableRow struct {
+	Values  RowValues `json:"values"`
+	Version string    `json:"version"`
+}
+
+// TableRowInsertRequest represents the request to insert a row; columns left out get their defaults
+type TableRowInsertRequest struct {
+	Values RowValues `json:"values" binding:"required"`
+}
+
+// TableRowUpdateRequest represents the request to change columns of a row
+type TableRowUpdateRequest struct {
+	Key     RowValues `json:"key" binding:"required"`     // Primary key values of the row
+	Version string    `json:"version" binding:"required"` // Version of the row the change was made to
+	Values  RowValues `json:"values" binding:"required"`  // Changed columns
+}
+
+// TableRowDeleteRequest represents the request to delete a row
+type TableRowDeleteRequest struct {
+	Key     RowValues `json:"key" binding:"required"`
+	Version string    `json:"version" binding:"required"`
+}
+
+// TableRowEditLog represents an insert, update or delete made through the table row editor or the
+// HohAddress whitelist and blacklist routes
+type TableRowEditLog struct {
+	ID           int              `gorm:"primaryKey;autoIncrement" json:"id"`
+	ConnectionID string           `gorm:"column:connection_id;type:varchar(36);not null;index" json:"connection_id"`
+	DatabaseName string           `gorm:"column:database_name;type:varchar(255);not null" json:"database_name"`
+	SchemaName   string           `gorm:"column:schema_name;type:varchar(255);not null" json:"schema_name"`
+	Table        string           `gorm:"column:table_name;type:varchar(255);not null" json:"table_name"`
+	Operation    string           `gorm:"column:operation;type:varchar(10);not null" json:"operation"`
+	RowKey       RowValues        `gorm:"column:row_key;type:text" json:"row_key,omitempty"`       // Primary key values of the row
+	OldValues    RowValues        `gorm:"column:old_values;type:text" json:"old_values,omitempty"` // Row before an update or delete
+	NewValues    RowValues        `gorm:"column:new_values;type:text" json:"new_values,omitempty"` // Row after an insert or update
+	UserID       string           `gorm:"column:user_id;type:varchar(36);index" json:"user_id"`
+	Status       AuditEventStatus `gorm:"column:status;type:varchar(20);not null" json:"status"`
+	ErrorMessage string           `gorm:"column:error_message;type:text" json:"error_message,omitempty"`
+	RequestID    string           `gorm:"column:request_id;type:varchar(128);index" json:"request_id,omitempty"`
+	CreatedAt    time.Time        `gorm:"column:created_at;autoCreateTime;index" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (TableRowEditLog) TableName() string {
+	return "table_row_edit_logs"
+}
@@ -0,0 +1,25 @@
+package models
+
+// TableRowsRequest selects a page of the rows of a table. Pages are read by offset, or by keyset
+// when Keyset is set or a Cursor is given: keyset pages continue after the sort and primary key
+// values of the previous page's last row, which stays fast deep into large tables.
+type TableRowsRequest struct {
+	Columns   []string          // Columns to return; every column when empty
+	Filter    *FilterExpression // Rows to return; every row when nil
+	SortBy    string            // Sort column; the primary key when empty
+	SortOrder string            // ASC or DESC
+	Limit     int
+	Offset    int    // Rows to skip, for offset pagination
+	Keyset    bool   // Read the first keyset page
+	Cursor    string // next_cursor of the previous keyset page
+}
+
+// TableRowsPage is a page of the rows of a table
+type TableRowsPage struct {
+	Columns    []string         `json:"columns"`
+	Rows       []map[string]any `json:"rows"`
+	KeyColumns []string         `json:"key_columns"`           // Primary key of the table; empty when it has none
+	Total      *int64           `json:"total,omitempty"`       // Rows matching the filter; only counted for offset pagination
+	NextCursor string           `json:"next_cursor,omitempty"` // Cursor of the next keyset page; empty on the last page
+	Versions   []string         `json:"versions,omitempty"`    // Row versions for the row editor, by row; only when every column is read
+}
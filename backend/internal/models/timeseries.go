@@ -0,0 +1,102 @@
+package models
+
+import "time"
+
+// MetricResolution is the bucket width of stored metric points
+type MetricResolution string
+
+const (
+	MetricResolutionRaw  MetricResolution = "raw"
+	MetricResolutionHour MetricResolution = "1h"
+	MetricResolutionDay  MetricResolution = "1d"
+	MetricResolutionAuto MetricResolution = "" // Picked from the queried time range
+)
+
+// Metric names recorded by the monitoring snapshot job.
+// Counter metrics are cumulative; query them with delta=true to get per-bucket increments.
+const (
+	MetricSizeBytes            = "size_bytes"
+	MetricConnections          = "connections"
+	MetricXactCommit           = "xact_commit"   // counter
+	MetricXactRollback         = "xact_rollback" // counter
+	MetricCacheHitRatio        = "cache_hit_ratio"
+	MetricDeadlocks            = "deadlocks"  // counter
+	MetricTempBytes            = "temp_bytes" // counter
+	MetricActivityActive       = "activity_active"
+	MetricActivityIdle         = "activity_idle"
+	MetricActivityIdleInTx     = "activity_idle_in_transaction"
+	MetricStatementsCalls      = "statements_calls"        // counter
+	MetricStatementsExecTimeMs = "statements_exec_time_ms" // counter
+)
+
+// MetricPoint is one bucket of a metric series stored in the partitioned metric_points table.
+// Raw points have a sample count of 1; downsampled points average the samples of their bucket.
+type MetricPoint struct {
+	ConnectionID string           `gorm:"column:connection_id;type:varchar(36);not null" json:"-"`
+	DatabaseName string           `gorm:"column:database_name;type:varchar(255);not null" json:"-"`
+	Metric       string           `gorm:"column:metric;type:varchar(64);not null" json:"-"`
+	Resolution   MetricResolution `gorm:"column:resolution;type:varchar(8);not null" json:"-"`
+	BucketStart  time.Time        `gorm:"column:bucket_start;not null" json:"t"`
+	Value        float64          `gorm:"column:value;not null" json:"value"`
+	MinValue     float64          `gorm:"column:min_value;not null" json:"min"`
+	MaxValue     float64          `gorm:"column:max_value;not null" json:"max"`
+	SampleCount  int              `gorm:"column:sample_count;not null" json:"samples"`
+}
+
+// TableName specifies the table name for GORM
+func (MetricPoint) TableName() string {
+	return "metric_points"
+}
+
+// MetricQuery selects a metric series of a database
+type MetricQuery struct {
+	ConnectionID string
+	DatabaseName string
+	Metric       string
+	From         time.Time
+	To           time.Time
+	Resolution   MetricResolution
+	Delta        bool // Return increments between consecutive points instead of values
+}
+
+// MetricSeries represents a stored metric series over a time range
+type MetricSeries struct {
+	ConnectionID string           `json:"connection_id"`
+	DatabaseName string           `json:"database_name"`
+	Metric       string           `json:"metric"`
+	Resolution   MetricResolution `json:"resolution"`
+	Delta        bool             `json:"delta"`
+	From         time.Time        `json:"from"`
+	To           time.Time        `json:"to"`
+	Points       []MetricPoint    `json:"points"`
+}
+
+// MetricHeatmapCell is the average of a metric within one weekday/hour slot
+type MetricHeatmapCell struct {
+	Weekday int     `json:"weekday"` // 0 = Sunday
+	Hour    int     `json:"hour"`    // 0-23, UTC
+	Value   float64 `json:"value"`
+	Samples int     `json:"samples"`
+}
+
+// MetricHeatmap represents a metric folded into a weekday x hour grid
+type MetricHeatmap struct {
+	ConnectionID string              `json:"connection_id"`
+	DatabaseName string              `json:"database_name"`
+	Metric       string              `json:"metric"`
+	Delta        bool                `json:"delta"`
+	From         time.Time           `json:"from"`
+	To           time.Time           `json:"to"`
+	Cells        []MetricHeatmapCell `json:"cells"`
+}
+
+// MetricForecast represents a linear projection of a metric fitted on daily points
+type MetricForecast struct {
+	ConnectionID string        `json:"connection_id"`
+	DatabaseName string        `json:"database_name"`
+	Metric       string        `json:"metric"`
+	FittedFrom   time.Time     `json:"fitted_from"`
+	FittedTo     time.Time     `json:"fitted_to"`
+	SlopePerDay  float64       `json:"slope_per_day"`
+	Points       []MetricPoint `json:"points"` // Projected daily values after FittedTo
+}
@@ -0,0 +1,77 @@
+package models
+
+import "encoding/json"
+
+// DMSTable represents a row of meta.dms_tables: one field mapping of a source table to its target
+type DMSTable struct {
+	ID               int    `json:"id"`
+	ServiceName      string `json:"service_name"`
+	SourceDbName     string `json:"source_db_name"`
+	SourceDbType     string `json:"source_db_type"`
+	SourceSchemaName string `json:"source_schema_name"`
+	SourceTableName  string `json:"source_table_name"`
+	SourceFieldName  string `json:"source_field_name"`
+	SourceFieldType  string `json:"source_field_type"`
+	TargetDbName     string `json:"target_db_name"`
+	TargetDbType     string `json:"target_db_type"`
+	TargetSchemaName string `json:"target_schema_name"`
+	TargetTableName  string `json:"target_table_name"`
+	TargetFieldName  string `json:"target_field_name"`
+	TargetFieldType  string `json:"target_field_type"`
+	TargetFieldValue string `json:"target_field_value"`
+	IsID             int    `json:"is_id"`
+	RowNum           int    `json:"row_num"`
+	// Extra holds columns of the table that are not mapped above, keyed by column name
+	Extra map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON encodes the row as a flat object including the extra columns
+func (t DMSTable) MarshalJSON() ([]byte, error) {
+	type plain DMSTable
+	return marshalWithExtra(plain(t), t.Extra)
+}
+
+// DMSField represents a row of meta.dms_fields
+type DMSField struct {
+	ID           int    `json:"id"`
+	TableID      int    `json:"table_id"`
+	SourceField  string `json:"source_field"`
+	SourceType   string `json:"source_type"`
+	TargetField  string `json:"target_field"`
+	TargetType   string `json:"target_type"`
+	TargetValue  string `json:"target_value"`
+	IsPrimaryKey bool   `json:"is_primary_key"`
+	RowOrder     int    `json:"row_order"`
+	// Extra holds columns of the table that are not mapped above, keyed by column name
+	Extra map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON encodes the row as a flat object including the extra columns
+func (f DMSField) MarshalJSON() ([]byte, error) {
+	type plain DMSField
+	return marshalWithExtra(plain(f), f.Extra)
+}
+
+// marshalWithExtra encodes v and adds the extra keys that v does not already define
+func marshalWithExtra(v interface{}, extra map[string]interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return b, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		if _, ok := fields[key]; ok {
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = raw
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,43 @@
+package models
+
+// LineageNode is a column that appears as a source or target of TruETL mappings
+type LineageNode struct {
+	ID         string `json:"id"` // db.schema.table.column
+	DbType     string `json:"db_type,omitempty"`
+	DbName     string `json:"db_name"`
+	SchemaName string `json:"schema_name"`
+	TableName  string `json:"table_name"`
+	ColumnName string `json:"column_name"`
+	ColumnType string `json:"column_type,omitempty"`
+}
+
+// LineageEdge is one field mapping from a source column to a target column
+type LineageEdge struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	ServiceName string `json:"service_name"`
+	MappingID   int    `json:"mapping_id"` // id of the meta.dms_tables row
+	TargetValue string `json:"target_value,omitempty"`
+}
+
+// LineageGraph is the column lineage of a TruETL database
+type LineageGraph struct {
+	Nodes []LineageNode `json:"nodes"`
+	Edges []LineageEdge `json:"edges"`
+}
+
+// LineageImpactEntry is a target column that breaks when the analysed column is dropped
+type LineageImpactEntry struct {
+	Node        LineageNode `json:"node"`
+	Depth       int         `json:"depth"` // 1 for direct targets, more when a target feeds further mappings
+	ServiceName string      `json:"service_name"`
+	Via         string      `json:"via"` // Column the target is mapped from
+}
+
+// LineageImpact lists what breaks downstream when a source column is dropped
+type LineageImpact struct {
+	Column   LineageNode          `json:"column"`
+	Affected []LineageImpactEntry `json:"affected"`
+	Tables   []string             `json:"tables"`   // Affected target tables as db.schema.table
+	Services []string             `json:"services"` // Services whose mappings break
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// TruETLMappingDocumentVersion is the format version of exported TruETL mapping documents
+const TruETLMappingDocumentVersion = 1
+
+// TruETL mapping import modes
+const (
+	MappingImportDryRun = "dry_run"
+	MappingImportApply  = "apply"
+)
+
+// TruETLMappingDocument is the whole meta.dms_tables mapping of a TruETL database as a versionable
+// JSON or YAML document. Rows are sorted and carry no IDs, so exports of equal mappings are identical.
+type TruETLMappingDocument struct {
+	Version    int             `json:"version" yaml:"version"`
+	Database   string          `json:"database,omitempty" yaml:"database,omitempty"` // Database the mapping was exported from; informational
+	ExportedAt *time.Time      `json:"exported_at,omitempty" yaml:"exported_at,omitempty"`
+	Mappings   []TruETLMapping `json:"mappings" yaml:"mappings"`
+}
+
+// TruETLMapping is one meta.dms_tables row of a mapping document. A row is identified by its service,
+// source database, source table, source field and target field.
+type TruETLMapping struct {
+	ServiceName      string `json:"service_name" yaml:"service_name"`
+	SourceDbName     string `json:"source_db_name" yaml:"source_db_name"`
+	SourceDbType     string `json:"source_db_type" yaml:"source_db_type"`
+	SourceSchemaName string `json:"source_schema_name" yaml:"source_schema_name"`
+	SourceTableName  string `json:"source_table_name" yaml:"source_table_name"`
+	SourceFieldName  string `json:"source_field_name" yaml:"source_field_name"`
+	SourceFieldType  string `json:"source_field_type" yaml:"source_field_type"`
+	TargetDbName     string `json:"target_db_name" yaml:"target_db_name"`
+	TargetDbType     string `json:"target_db_type" yaml:"target_db_type"`
+	TargetSchemaName string `json:"target_schema_name" yaml:"target_schema_name"`
+	TargetTableName  string `json:"target_table_name" yaml:"target_table_name"`
+	TargetFieldName  string `json:"target_field_name" yaml:"target_field_name"`
+	TargetFieldType  string `json:"target_field_type" yaml:"target_field_type"`
+	TargetFieldValue string `json:"target_field_value" yaml:"target_field_value"`
+	IsID             int    `json:"is_id" yaml:"is_id"`
+	RowNum           int    `json:"row_num" yaml:"row_num"`
+}
+
+// TruETLMappingChange is a row an import adds, updates or deletes
+type TruETLMappingChange struct {
+	Action  string        `json:"action"`       // add, update or delete
+	ID      int           `json:"id,omitempty"` // Existing row, for updates and deletes
+	Mapping TruETLMapping `json:"mapping"`      // The imported row, or the deleted one
+	Changed []string      `json:"changed,omitempty"`
+}
+
+// TruETLMappingImportResult reports the changes of a mapping import; with a dry run nothing is written
+type TruETLMappingImportResult struct {
+	Mode      string                `json:"mode"`
+	Added     int                   `json:"added"`
+	Updated   int                   `json:"updated"`
+	Deleted   int                   `json:"deleted"`
+	Unchanged int                   `json:"unchanged"`
+	Changes   []TruETLMappingChange `json:"changes"`
+}
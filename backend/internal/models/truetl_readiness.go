@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Target field readiness statuses
+const (
+	ReadinessOK           = "ok"
+	ReadinessMissing      = "missing"
+	ReadinessTypeMismatch = "type_mismatch"
+	ReadinessUnchecked    = "unchecked" // No target type is mapped
+)
+
+// TruETLReadinessRequest selects the mapping table whose target should be verified.
+// The target connection is routed from the mapping's target_db_type and target_db_name
+// unless TargetConnectionID is given.
+type TruETLReadinessRequest struct {
+	ServiceName        string `json:"service_name" binding:"required"`
+	SourceDbName       string `json:"source_db_name" binding:"required"`
+	SourceTableName    string `json:"source_table_name" binding:"required"`
+	TargetConnectionID string `json:"target_connection_id"`
+}
+
+// TruETLFieldReadiness represents the check of one mapped target field
+type TruETLFieldReadiness struct {
+	TargetFieldName string `json:"target_field_name"`
+	MappedType      string `json:"mapped_type"`
+	ActualType      string `json:"actual_type,omitempty"`
+	Nullable        *bool  `json:"nullable,omitempty"`
+	Status          string `json:"status"`
+}
+
+// TruETLReadinessReport represents whether the target of a mapping table exists with matching columns
+type TruETLReadinessReport struct {
+	ServiceName          string                 `json:"service_name"`
+	SourceDbName         string                 `json:"source_db_name"`
+	SourceTableName      string                 `json:"source_table_name"`
+	TargetDbType         string                 `json:"target_db_type"`
+	TargetDbName         string                 `json:"target_db_name"`
+	TargetSchemaName     string                 `json:"target_schema_name"`
+	TargetTableName      string                 `json:"target_table_name"`
+	TargetConnectionID   string                 `json:"target_connection_id,omitempty"`
+	TargetConnectionName string                 `json:"target_connection_name,omitempty"`
+	RoutedBy             string                 `json:"routed_by,omitempty"` // explicit, default_database, database_listing
+	DatabaseExists       bool                   `json:"database_exists"`
+	SchemaExists         bool                   `json:"schema_exists"`
+	TableExists          bool                   `json:"table_exists"`
+	Ready                bool                   `json:"ready"`
+	Fields               []TruETLFieldReadiness `json:"fields"`
+	ExtraColumns         []string               `json:"extra_columns"` // Target columns no field is mapped to
+	Issues               []string               `json:"issues"`
+	CheckedAt            time.Time              `json:"checked_at"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// TruETLRunStatus represents the status of a pipeline run reported by TruETL
+type TruETLRunStatus string
+
+const (
+	TruETLRunRunning TruETLRunStatus = "running"
+	TruETLRunSuccess TruETLRunStatus = "success"
+	TruETLRunFailed  TruETLRunStatus = "failed"
+)
+
+// TruETLRunNeverRun is the board status of a mapping without any reported run
+const TruETLRunNeverRun = "never_run"
+
+// TruETLRun is a run of a TruETL pipeline for one source table, as reported by the pipeline.
+// A pipeline reporting the same run_id again updates the run, e.g. from running to success.
+type TruETLRun struct {
+	ID               string          `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	TruETLDatabaseID string          `gorm:"column:truetl_database_id;type:varchar(36);not null;uniqueIndex:idx_truetl_runs_run_id;index:idx_truetl_runs_table" json:"truetl_database_id"`
+	RunID            string          `gorm:"type:varchar(255);not null;uniqueIndex:idx_truetl_runs_run_id" json:"run_id"`
+	ServiceName      string          `gorm:"type:varchar(255);not null;index:idx_truetl_runs_table" json:"service_name"`
+	SourceDbName     string          `gorm:"type:varchar(255);not null;index:idx_truetl_runs_table" json:"source_db_name"`
+	SourceTableName  string          `gorm:"type:varchar(255);not null;index:idx_truetl_runs_table" json:"source_table_name"`
+	Status           TruETLRunStatus `gorm:"type:varchar(20);not null" json:"status"`
+	RowsMoved        int64           `gorm:"not null;default:0" json:"rows_moved"`
+	DurationMs       int64           `gorm:"not null;default:0" json:"duration_ms"`
+	ErrorMessage     string          `gorm:"type:text" json:"error_message,omitempty"`
+	StartedAt        time.Time       `gorm:"not null;index" json:"started_at"`
+	FinishedAt       *time.Time      `json:"finished_at,omitempty"`
+	ReportedBy       string          `gorm:"type:varchar(36)" json:"reported_by"`
+	CreatedAt        time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (TruETLRun) TableName() string {
+	return "truetl_runs"
+}
+
+// TruETLRunRequest represents a run record reported by a pipeline
+type TruETLRunRequest struct {
+	RunID           string          `json:"run_id"` // Pipeline's own run identifier; generated when empty
+	ServiceName     string          `json:"service_name" binding:"required"`
+	SourceDbName    string          `json:"source_db_name" binding:"required"`
+	SourceTableName string          `json:"source_table_name" binding:"required"`
+	Status          TruETLRunStatus `json:"status" binding:"required,oneof=running success failed"`
+	RowsMoved       int64           `json:"rows_moved" binding:"min=0"`
+	DurationMs      int64           `json:"duration_ms" binding:"min=0"` // Derived from started_at and finished_at when omitted
+	ErrorMessage    string          `json:"error_message"`
+	StartedAt       *time.Time      `json:"started_at"` // Defaults to the time the run is first reported
+	FinishedAt      *time.Time      `json:"finished_at"`
+}
+
+// TruETLRunFilter narrows the run history of a TruETL database
+type TruETLRunFilter struct {
+	ServiceName     string
+	SourceDbName    string
+	SourceTableName string
+	Status          TruETLRunStatus
+}
+
+// TruETLBoardEntry is the status of one mapping table, or of a table that runs were reported for without a mapping
+type TruETLBoardEntry struct {
+	ServiceName      string     `json:"service_name"`
+	SourceDbName     string     `json:"source_db_name"`
+	SourceTableName  string     `json:"source_table_name"`
+	TargetDbName     string     `json:"target_db_name,omitempty"`
+	TargetSchemaName string     `json:"target_schema_name,omitempty"`
+	TargetTableName  string     `json:"target_table_name,omitempty"`
+	Mapped           bool       `json:"mapped"` // The table has a mapping in meta.dms_tables
+	Status           string     `json:"status"` // Status of the last run, or never_run
+	LastRun          *TruETLRun `json:"last_run,omitempty"`
+	LastSuccessAt    *time.Time `json:"last_success_at,omitempty"`
+	Runs             int64      `json:"runs"` // Counts and totals cover the board window
+	Failures         int64      `json:"failures"`
+	RowsMoved        int64      `json:"rows_moved"`
+	AvgDurationMs    int64      `json:"avg_duration_ms"` // Of successful runs
+}
+
+// TruETLRunBoard is the status board of the pipelines of a TruETL database
+type TruETLRunBoard struct {
+	TruETLDatabaseID string             `json:"truetl_database_id"`
+	Since            time.Time          `json:"since"`
+	StatusCounts     map[string]int     `json:"status_counts"`
+	Entries          []TruETLBoardEntry `json:"entries"`
+}
@@ -63,6 +63,7 @@ type TruETLSaveLog struct {
 	SQLScript        string              `gorm:"column:sql_script;type:text" json:"sql_script,omitempty"`
 	ErrorMessage     string              `gorm:"column:error_message;type:text" json:"error_message,omitempty"`
 	ExecutionTimeMs  int                 `gorm:"column:execution_time_ms;not null;default:0" json:"execution_time_ms"`
+	RequestID        string              `gorm:"column:request_id;type:varchar(128);index" json:"request_id,omitempty"`
 	CreatedAt        time.Time           `gorm:"column:created_at;autoCreateTime;index" json:"created_at"`
 }
 
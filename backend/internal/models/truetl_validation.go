@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Severities of TruETL mapping validation issues
+const (
+	ValidationError   = "error"
+	ValidationWarning = "warning"
+)
+
+// Codes of TruETL mapping validation issues
+const (
+	ValidationDatabaseUnrouted    = "database_unrouted"    // No saved connection has the database
+	ValidationDatabaseUnreachable = "database_unreachable" // The database could not be opened
+	ValidationTableMissing        = "table_missing"
+	ValidationColumnMissing       = "column_missing"
+	ValidationTypeMismatch        = "type_mismatch"
+	ValidationDuplicateTarget     = "duplicate_target_field" // Several rows of a target table map to the same field
+	ValidationMissingID           = "missing_is_id"          // No row of a mapping table is marked is_id
+	ValidationIncomplete          = "incomplete_mapping"     // A mapping row lacks a database, table or field name
+)
+
+// TruETLValidationRequest limits a mapping validation to one service; all rows are checked when empty
+type TruETLValidationRequest struct {
+	ServiceName string `json:"service_name"`
+}
+
+// TruETLValidationIssue is one problem found in a mapping row or table
+type TruETLValidationIssue struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Side     string `json:"side,omitempty"` // source or target, for issues found in one of the databases
+	Message  string `json:"message"`
+}
+
+// TruETLRowValidation is the validation result of one meta.dms_tables row
+type TruETLRowValidation struct {
+	ID              int                     `json:"id"`
+	ServiceName     string                  `json:"service_name"`
+	SourceDbName    string                  `json:"source_db_name"`
+	SourceTableName string                  `json:"source_table_name"`
+	SourceFieldName string                  `json:"source_field_name"`
+	TargetTableName string                  `json:"target_table_name"`
+	TargetFieldName string                  `json:"target_field_name"`
+	Valid           bool                    `json:"valid"` // No error issues; warnings are allowed
+	Issues          []TruETLValidationIssue `json:"issues"`
+}
+
+// TruETLTableValidation holds the issues of a mapping table as a whole
+type TruETLTableValidation struct {
+	ServiceName     string                  `json:"service_name"`
+	SourceDbName    string                  `json:"source_db_name"`
+	SourceTableName string                  `json:"source_table_name"`
+	Issues          []TruETLValidationIssue `json:"issues"`
+}
+
+// TruETLValidationReport cross-checks the mappings of meta.dms_tables against the source and target databases
+type TruETLValidationReport struct {
+	ServiceName  string                  `json:"service_name,omitempty"`
+	Valid        bool                    `json:"valid"`
+	RowCount     int                     `json:"row_count"`
+	ErrorCount   int                     `json:"error_count"`
+	WarningCount int                     `json:"warning_count"`
+	Rows         []TruETLRowValidation   `json:"rows"`
+	Tables       []TruETLTableValidation `json:"tables"` // Only mapping tables with issues
+	CheckedAt    time.Time               `json:"checked_at"`
+}
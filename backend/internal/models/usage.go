@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// UsageCounter counts the calls of one API endpoint on one day
+type UsageCounter struct {
+	Day        time.Time `gorm:"type:date;primaryKey" json:"day"`
+	Module     string    `gorm:"type:varchar(64);primaryKey" json:"module"`
+	Endpoint   string    `gorm:"type:varchar(255);primaryKey" json:"endpoint"` // "METHOD /api/v1/route/:param"
+	Requests   int64     `gorm:"not null;default:0" json:"requests"`
+	Errors     int64     `gorm:"not null;default:0" json:"errors"`      // Responses with a 5xx status
+	DurationMs int64     `gorm:"not null;default:0" json:"duration_ms"` // Total handling time of all requests
+}
+
+// TableName specifies the table name for GORM
+func (UsageCounter) TableName() string {
+	return "usage_counters"
+}
+
+// UsageUser counts the calls of one user to one module on one day, for active-user counts
+type UsageUser struct {
+	Day      time.Time `gorm:"type:date;primaryKey" json:"day"`
+	Module   string    `gorm:"type:varchar(64);primaryKey" json:"module"`
+	UserID   string    `gorm:"type:varchar(36);primaryKey" json:"user_id"`
+	Requests int64     `gorm:"not null;default:0" json:"requests"`
+}
+
+// TableName specifies the table name for GORM
+func (UsageUser) TableName() string {
+	return "usage_users"
+}
+
+// EndpointUsage represents the calls of one endpoint over a report window
+type EndpointUsage struct {
+	Endpoint      string  `json:"endpoint"`
+	Requests      int64   `json:"requests"`
+	Errors        int64   `json:"errors"`
+	AvgDurationMs float64 `json:"avg_duration_ms"`
+}
+
+// ModuleUsage represents the use of one module over a report window
+type ModuleUsage struct {
+	Module        string          `json:"module"`
+	Requests      int64           `json:"requests"`
+	Errors        int64           `json:"errors"`
+	ActiveUsers   int64           `json:"active_users"`
+	AvgDurationMs float64         `json:"avg_duration_ms"`
+	TopEndpoints  []EndpointUsage `json:"top_endpoints"`
+}
+
+// DailyModuleUsage represents the use of one module on one day
+type DailyModuleUsage struct {
+	Day         time.Time `json:"day"`
+	Module      string    `json:"module"`
+	Requests    int64     `json:"requests"`
+	ActiveUsers int64     `json:"active_users"`
+}
+
+// UsageReport represents module usage over the last days, most used modules first
+type UsageReport struct {
+	From    time.Time          `json:"from"`
+	To      time.Time          `json:"to"`
+	Modules []ModuleUsage      `json:"modules"`
+	Daily   []DailyModuleUsage `json:"daily"`
+}
+
+// UsageExportUser represents the calls of one user to one module on one day in a usage export.
+// User is the username, or a pseudonym stable within one anonymized export.
+type UsageExportUser struct {
+	Day      time.Time `json:"day"`
+	Module   string    `json:"module"`
+	User     string    `json:"user"`
+	Requests int64     `json:"requests"`
+}
+
+// UsageExport represents the raw usage counters of a window for analysis outside TruAdmin
+type UsageExport struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	From        time.Time         `json:"from"`
+	To          time.Time         `json:"to"`
+	Anonymized  bool              `json:"anonymized"`
+	Counters    []UsageCounter    `json:"counters"`
+	Users       []UsageExportUser `json:"users"`
+}
@@ -12,13 +12,15 @@ const (
 
 // User represents a user in the system
 type User struct {
-	ID        string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
-	Username  string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"username"`
-	Password  string    `gorm:"type:text;not null" json:"-"` // Never return password in JSON
-	Role      UserRole  `gorm:"type:varchar(50);not null;default:'user'" json:"role"`
-	IsBlocked bool      `gorm:"type:boolean;not null;default:false" json:"is_blocked"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID          string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Username    string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"username"`
+	Password    string    `gorm:"type:text;not null" json:"-"` // Never return password in JSON
+	Role        UserRole  `gorm:"type:varchar(50);not null;default:'user'" json:"role"`
+	IsBlocked   bool      `gorm:"type:boolean;not null;default:false" json:"is_blocked"`
+	TOTPSecret  string    `gorm:"column:totp_secret;type:text" json:"-"` // Encrypted; set while enrolling and once enrolled
+	TOTPEnabled bool      `gorm:"column:totp_enabled;not null;default:false" json:"totp_enabled"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 // LoginRequest represents the login request payload
@@ -32,10 +34,34 @@ type SetupRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 }
 
-// LoginResponse represents the login response
+// LoginResponse represents the login response. Users with two-factor authentication get a
+// challenge instead of a token, to be answered with a code at /auth/login/totp.
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  *User  `json:"user"`
+	Token                  string `json:"token,omitempty"`
+	User                   *User  `json:"user"`
+	TOTPRequired           bool   `json:"totp_required,omitempty"`
+	Challenge              string `json:"challenge,omitempty"`
+	TOTPEnrollmentRequired bool   `json:"totp_enrollment_required,omitempty"` // The role requires two-factor authentication the user has not set up
+}
+
+// TOTPLoginRequest represents the second step of a login with two-factor authentication
+type TOTPLoginRequest struct {
+	Challenge string `json:"challenge" binding:"required"`
+	Code      string `json:"code" binding:"required"`
+}
+
+// TOTPCodeRequest represents a request confirmed with a code of the authenticator app
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TOTPEnrollment represents the secret of a started enrollment. URL is the otpauth:// provisioning
+// URI that authenticator apps read from a QR code; QRCode is that QR code as a PNG data URI, ready
+// for an <img> src.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URL    string `json:"otpauth_url"`
+	QRCode string `json:"qr_code"`
 }
 
 // CreateUserRequest represents the request to create a new user (admin only)
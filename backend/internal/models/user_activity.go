@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// ActivityActionLogin is the action recorded for a successful sign-in
+const ActivityActionLogin = "login"
+
+// UserActivityEvent represents one sign-in or API call made by a user
+type UserActivityEvent struct {
+	ID           int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       string    `gorm:"column:user_id;type:varchar(36);not null;index" json:"user_id"`
+	Action       string    `gorm:"column:action;type:varchar(255);not null" json:"action"` // "login" or "METHOD /api/v1/route/:param"
+	ConnectionID string    `gorm:"column:connection_id;type:varchar(36);index" json:"connection_id,omitempty"`
+	StatusCode   int       `gorm:"column:status_code" json:"status_code,omitempty"`
+	Detail       string    `gorm:"column:detail;type:text" json:"detail,omitempty"` // Request body, recorded during break-glass access
+	CreatedAt    time.Time `gorm:"column:created_at;index" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (UserActivityEvent) TableName() string {
+	return "user_activity_events"
+}
+
+// ConnectionUsage represents how often a user worked with a connection
+type ConnectionUsage struct {
+	ConnectionID   string    `json:"connection_id"`
+	ConnectionName string    `json:"connection_name"` // Empty when the connection was deleted
+	Requests       int64     `json:"requests"`
+	LastUsedAt     time.Time `json:"last_used_at"`
+}
+
+// ActionUsage represents how often a user called an API action
+type ActionUsage struct {
+	Action string `json:"action"`
+	Count  int64  `json:"count"`
+}
+
+// UserActivitySummary represents the sign-in history and most used connections and actions of a user
+type UserActivitySummary struct {
+	UserID         string            `json:"user_id"`
+	Username       string            `json:"username"`
+	LastLoginAt    *time.Time        `json:"last_login_at"`
+	LastActiveAt   *time.Time        `json:"last_active_at"`
+	From           time.Time         `json:"from"` // Start of the window the counts cover
+	LoginCount     int64             `json:"login_count"`
+	RequestCount   int64             `json:"request_count"`
+	TopConnections []ConnectionUsage `json:"top_connections"`
+	TopActions     []ActionUsage     `json:"top_actions"`
+}
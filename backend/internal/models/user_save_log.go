@@ -17,9 +17,10 @@ type UserSaveLog struct {
 	ID          int               `gorm:"primaryKey;autoIncrement" json:"id"`
 	UserID      string            `gorm:"column:user_id;type:varchar(36);index" json:"user_id"`
 	ChangedByID string            `gorm:"column:changed_by_id;type:varchar(36);index" json:"changed_by_id"`
-	Operation   string            `gorm:"column:operation;type:varchar(20);not null" json:"operation"` // create, delete, change_password, block, unblock
+	Operation   string            `gorm:"column:operation;type:varchar(20);not null" json:"operation"` // create, delete, change_password, block, unblock, login_failed, lockout, unlock
 	Status      UserSaveLogStatus `gorm:"column:status;type:varchar(20);not null" json:"status"`
 	ErrorMessage string           `gorm:"column:error_message;type:text" json:"error_message,omitempty"`
+	RequestID   string            `gorm:"column:request_id;type:varchar(128);index" json:"request_id,omitempty"`
 	CreatedAt   time.Time         `gorm:"column:created_at;autoCreateTime;index" json:"created_at"`
 }
 
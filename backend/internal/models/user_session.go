@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// UserSession represents a token issued at login. Its ID is the ID claim of the token, so ending the
+// session revokes the token.
+type UserSession struct {
+	ID         string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID     string     `gorm:"column:user_id;type:varchar(36);not null;index" json:"user_id"`
+	ClientIP   string     `gorm:"column:client_ip;type:varchar(45)" json:"client_ip"` // Client IP at login
+	UserAgent  string     `gorm:"column:user_agent;type:text" json:"user_agent"`
+	LastSeenIP string     `gorm:"column:last_seen_ip;type:varchar(45)" json:"last_seen_ip"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at;index" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
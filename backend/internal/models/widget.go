@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// WidgetToken represents a token issued to a partner for embedding the address check widget.
+// It only grants address checks against one HohAddress database from the allowed origins.
+type WidgetToken struct {
+	ID                   string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name                 string     `gorm:"type:varchar(255);not null" json:"name"` // Partner or portal the token was issued to
+	HohAddressDatabaseID string     `gorm:"column:hohaddress_database_id;type:varchar(36);not null;index" json:"hohaddress_database_id"`
+	TokenHash            string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`  // SHA-256 of the token; the token itself is shown once
+	TokenPrefix          string     `gorm:"type:varchar(16);not null" json:"token_prefix"`   // Identifies the token in listings
+	AllowedOrigins       StringList `gorm:"type:text" json:"allowed_origins"`                // Pages allowed to embed the widget, e.g. https://portal.example.com
+	RateLimitPerMinute   int        `gorm:"not null;default:0" json:"rate_limit_per_minute"` // Checks per minute across all visitors; zero uses the server default
+	CreatedBy            string     `gorm:"type:varchar(36)" json:"created_by"`
+	ExpiresAt            *time.Time `json:"expires_at,omitempty"`
+	RevokedAt            *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt           *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt            time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (WidgetToken) TableName() string {
+	return "widget_tokens"
+}
+
+// WidgetTokenRequest represents the request to issue a widget token
+type WidgetTokenRequest struct {
+	Name                 string     `json:"name" binding:"required"`
+	HohAddressDatabaseID string     `json:"hohaddress_database_id" binding:"required"`
+	AllowedOrigins       []string   `json:"allowed_origins" binding:"required,min=1"`
+	RateLimitPerMinute   int        `json:"rate_limit_per_minute"`
+	ExpiresAt            *time.Time `json:"expires_at"`
+}
+
+// IssuedWidgetToken represents a newly issued widget token with the token itself and the path to embed
+type IssuedWidgetToken struct {
+	WidgetToken
+	Token     string `json:"token"`
+	EmbedPath string `json:"embed_path"` // Relative to the server URL; use as the src of an iframe
+}
+
+// WidgetSession represents a short-lived session of an embedded widget, opened when its page loads
+type WidgetSession struct {
+	Token     *WidgetToken
+	Session   string // Sent back by the widget with each check
+	ExpiresAt time.Time
+}
+
+// WidgetCheckRequest represents an address check submitted from the widget
+type WidgetCheckRequest struct {
+	Address1    string `json:"address1" binding:"required"`
+	Address2    string `json:"address2"`
+	City        string `json:"city" binding:"required"`
+	State       string `json:"state" binding:"required"`
+	Zip         string `json:"zip" binding:"required"`
+	ProgramType string `json:"programType" binding:"required"`
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+
+	"truadmin/internal/middleware"
+	"truadmin/internal/services"
+)
+
+// permissionRoutes registers routes that check a permission with middleware.RequirePermission.
+// These are the routes API keys may be used on, limited to the key's scopes, so each route is
+// also listed in apiKeyRoutes.
+type permissionRoutes struct {
+	group             *gin.RouterGroup
+	permissionService *services.PermissionService
+	apiKeyRoutes      middleware.APIKeyRoutes
+	scope             string
+}
+
+// withScope returns permissionRoutes whose routes also let API keys holding scope pass, see
+// middleware.APIKeyScope
+func (p permissionRoutes) withScope(scope string) permissionRoutes {
+	p.scope = scope
+	return p
+}
+
+// check returns a check of a further permission for routes requiring several
+func (p permissionRoutes) check(permission string) gin.HandlerFunc {
+	return middleware.RequirePermission(p.permissionService, permission)
+}
+
+func (p permissionRoutes) handle(method, relativePath, permission string, handlers []gin.HandlerFunc) {
+	chain := make([]gin.HandlerFunc, 0, len(handlers)+2)
+	if p.scope != "" {
+		chain = append(chain, middleware.APIKeyScope(p.scope))
+	}
+	chain = append(chain, p.check(permission))
+	p.group.Handle(method, relativePath, append(chain, handlers...)...)
+	p.apiKeyRoutes.Allow(method, path.Join(p.group.BasePath(), relativePath))
+}
+
+// GET registers a GET route requiring permission
+func (p permissionRoutes) GET(relativePath, permission string, handlers ...gin.HandlerFunc) {
+	p.handle(http.MethodGet, relativePath, permission, handlers)
+}
+
+// POST registers a POST route requiring permission
+func (p permissionRoutes) POST(relativePath, permission string, handlers ...gin.HandlerFunc) {
+	p.handle(http.MethodPost, relativePath, permission, handlers)
+}
+
+// PUT registers a PUT route requiring permission
+func (p permissionRoutes) PUT(relativePath, permission string, handlers ...gin.HandlerFunc) {
+	p.handle(http.MethodPut, relativePath, permission, handlers)
+}
+
+// PATCH registers a PATCH route requiring permission
+func (p permissionRoutes) PATCH(relativePath, permission string, handlers ...gin.HandlerFunc) {
+	p.handle(http.MethodPatch, relativePath, permission, handlers)
+}
+
+// DELETE registers a DELETE route requiring permission
+func (p permissionRoutes) DELETE(relativePath, permission string, handlers ...gin.HandlerFunc) {
+	p.handle(http.MethodDelete, relativePath, permission, handlers)
+}
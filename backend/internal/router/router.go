@@ -1,12 +1,14 @@
 package router
 
 import (
+	"io/fs"
+	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
 	"truadmin/internal/handlers"
 	"truadmin/internal/middleware"
+	"truadmin/internal/models"
 	"truadmin/internal/services"
+	"truadmin/internal/webui"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,6 +23,30 @@ type Router struct {
 	databaseHandler  *handlers.DatabaseHandler
 	truETLHandler    *handlers.TruETLHandler
 	hohAddressHandler *handlers.HohAddressHandler
+	partitionHandler  *handlers.PartitionHandler
+	snapshotHandler   *handlers.SnapshotHandler
+	dashboardHandler  *handlers.DashboardHandler
+	monitoringHandler *handlers.MonitoringHandler
+	digestHandler     *handlers.DigestHandler
+	capacityHandler   *handlers.CapacityHandler
+	systemHandler     *handlers.SystemHandler
+	settingsHandler   *handlers.SettingsHandler
+	announcementHandler *handlers.AnnouncementHandler
+	accessGrantHandler  *handlers.AccessGrantHandler
+	bulkHandler         *handlers.BulkHandler
+	artifactHandler     *handlers.ArtifactHandler
+	exportHandler       *handlers.ExportHandler
+	usageHandler        *handlers.UsageHandler
+	permissionHandler   *handlers.PermissionHandler
+	dataDictionaryHandler *handlers.DataDictionaryHandler
+	liveMonitorHandler    *handlers.LiveMonitorHandler
+	sqlJobHandler         *handlers.SQLJobHandler
+	widgetHandler         *handlers.WidgetHandler
+	sqlHistoryHandler     *handlers.SQLHistoryHandler
+	savedQueryHandler     *handlers.SavedQueryHandler
+	apiKeyHandler         *handlers.APIKeyHandler
+	batchHandler      *handlers.BatchHandler
+	graphqlHandler    *handlers.GraphQLHandler
 }
 
 // NewRouter creates a new router with all handlers
@@ -32,9 +58,34 @@ func NewRouter(
 	databaseHandler *handlers.DatabaseHandler,
 	truETLHandler *handlers.TruETLHandler,
 	hohAddressHandler *handlers.HohAddressHandler,
+	partitionHandler *handlers.PartitionHandler,
+	snapshotHandler *handlers.SnapshotHandler,
+	dashboardHandler *handlers.DashboardHandler,
+	monitoringHandler *handlers.MonitoringHandler,
+	digestHandler *handlers.DigestHandler,
+	capacityHandler *handlers.CapacityHandler,
+	systemHandler *handlers.SystemHandler,
+	settingsHandler *handlers.SettingsHandler,
+	announcementHandler *handlers.AnnouncementHandler,
+	accessGrantHandler *handlers.AccessGrantHandler,
+	bulkHandler *handlers.BulkHandler,
+	artifactHandler *handlers.ArtifactHandler,
+	exportHandler *handlers.ExportHandler,
+	usageHandler *handlers.UsageHandler,
+	permissionHandler *handlers.PermissionHandler,
+	dataDictionaryHandler *handlers.DataDictionaryHandler,
+	liveMonitorHandler *handlers.LiveMonitorHandler,
+	sqlJobHandler *handlers.SQLJobHandler,
+	widgetHandler *handlers.WidgetHandler,
+	sqlHistoryHandler *handlers.SQLHistoryHandler,
+	savedQueryHandler *handlers.SavedQueryHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	graphqlHandler *handlers.GraphQLHandler, // nil when the GraphQL endpoint is disabled
 ) *Router {
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
+	// gin trusts X-Forwarded-For from any peer by default; main trusts only the configured proxies
+	engine.SetTrustedProxies(nil)
 	engine.Use(gin.Recovery())
 
 	return &Router{
@@ -46,129 +97,374 @@ func NewRouter(
 		databaseHandler:   databaseHandler,
 		truETLHandler:     truETLHandler,
 		hohAddressHandler: hohAddressHandler,
+		partitionHandler:  partitionHandler,
+		snapshotHandler:   snapshotHandler,
+		dashboardHandler:  dashboardHandler,
+		monitoringHandler: monitoringHandler,
+		digestHandler:     digestHandler,
+		capacityHandler:   capacityHandler,
+		systemHandler:     systemHandler,
+		settingsHandler:   settingsHandler,
+		announcementHandler: announcementHandler,
+		accessGrantHandler:  accessGrantHandler,
+		bulkHandler:         bulkHandler,
+		artifactHandler:     artifactHandler,
+		exportHandler:       exportHandler,
+		usageHandler:        usageHandler,
+		permissionHandler:   permissionHandler,
+		dataDictionaryHandler: dataDictionaryHandler,
+		liveMonitorHandler:    liveMonitorHandler,
+		sqlJobHandler:         sqlJobHandler,
+		widgetHandler:         widgetHandler,
+		sqlHistoryHandler:     sqlHistoryHandler,
+		savedQueryHandler:     savedQueryHandler,
+		apiKeyHandler:         apiKeyHandler,
+		batchHandler:      handlers.NewBatchHandler(engine), // dispatches sub-requests back through this engine
+		graphqlHandler:    graphqlHandler,
 	}
 }
 
-// SetupRoutes configures all application routes
-func (r *Router) SetupRoutes(authService *services.AuthService) {
-	// Apply CORS middleware
-	r.engine.Use(middleware.CORS())
+// SetupRoutes configures all application routes and serves the frontend build
+func (r *Router) SetupRoutes(authService *services.AuthService, apiKeyService *services.APIKeyService, activityService *services.ActivityService, accessGrantService *services.AccessGrantService, usageService *services.UsageService, permissionService *services.PermissionService, rateLimits middleware.RateLimits, corsOrigins []string, frontend *webui.Frontend, logger *slog.Logger) {
+	// Apply request ID and CORS middleware
+	r.engine.Use(middleware.RequestID(), middleware.CORS(corsOrigins))
 
 	// Health check routes (public) - keep these before static files
 	r.engine.GET("/health", r.healthHandler.Health)
 	r.engine.GET("/api/health", r.healthHandler.Health)
 
+	// Routes API keys may be used on, filled in as they are registered
+	apiKeyRoutes := middleware.APIKeyRoutes{}
+
 	// API v1 routes - must be registered before static files
 	api := r.engine.Group("/api/v1")
+	api.Use(middleware.Usage(usageService))
 	{
 		// Public database status route (no authentication required)
 		api.GET("/database/status", r.healthHandler.DatabaseStatus)
 
+		// Every route registered below needs the internal database and answers 503 while it is down
+		api.Use(middleware.RequireDatabase())
+
+		// Public branding for the login page and SPA shell
+		api.GET("/branding", r.settingsHandler.GetBranding)
+		api.GET("/branding/logo", r.settingsHandler.GetLogo)
+
+		// Public artifact downloads, authorized by the signature of the link
+		api.GET("/artifacts/download", r.artifactHandler.Download)
+
+		// Public embeddable address check, authorized by widget tokens and sessions
+		api.GET("/widget/check-address", r.widgetHandler.Page)
+		api.POST("/widget/check-address", r.widgetHandler.Check)
+
 		// Public auth routes (no authentication required)
 		auth := api.Group("/auth")
 		{
 			auth.GET("/setup/status", r.authHandler.CheckSetup)
 			auth.POST("/setup", r.authHandler.InitialSetup)
-			auth.POST("/login", r.authHandler.Login)
+			auth.POST("/login", middleware.RateLimit(rateLimits.LoginPerIP, nil), r.authHandler.Login)
+			auth.POST("/login/totp", middleware.RateLimit(rateLimits.LoginPerIP, nil), r.authHandler.LoginTOTP)
 		}
 
 		// Protected routes (authentication required)
 		protected := api.Group("")
-		protected.Use(middleware.AuthMiddleware(authService))
+		// API keys (X-API-Key) are accepted on routes registered with require, limited to the key's scopes
+		protected.Use(middleware.AuthMiddleware(authService, apiKeyService, apiKeyRoutes), middleware.SQLDebug(logger.With("middleware", "sql_debug")), middleware.Activity(activityService, accessGrantService), middleware.ConnectionAccess(accessGrantService))
 		{
+			// Frequently polled metadata endpoints answer 304 when unchanged
+			etag := middleware.ETag()
+
+			// Granular permissions; admins hold every permission
+			require := permissionRoutes{group: protected, permissionService: permissionService, apiKeyRoutes: apiKeyRoutes}
+
+			// Token buckets per client IP and user in front of the backing databases
+			queryLimit := middleware.RateLimit(rateLimits.PerIP, rateLimits.QueryPerUser)
+			checkAddressLimit := middleware.RateLimit(rateLimits.PerIP, rateLimits.CheckAddressPerUser)
+
 			// Current user
 			protected.GET("/auth/me", r.authHandler.GetCurrentUser)
+			protected.GET("/auth/me/activity", r.authHandler.GetMyActivity)
+			protected.POST("/auth/logout", r.authHandler.Logout)
+
+			// Two-factor authentication of the current user
+			protected.POST("/auth/me/totp", r.authHandler.StartTOTPEnrollment)
+			protected.POST("/auth/me/totp/confirm", r.authHandler.ConfirmTOTPEnrollment)
+			protected.DELETE("/auth/me/totp", r.authHandler.DisableTOTP)
 
 			// Database connections
-			protected.POST("/connections", r.connHandler.CreateConnection)
-			protected.GET("/connections", r.connHandler.GetConnections)
-			protected.GET("/connections/:id", r.connHandler.GetConnection)
-			protected.PUT("/connections/:id", r.connHandler.UpdateConnection)
-			protected.DELETE("/connections/:id", r.connHandler.DeleteConnection)
-			protected.GET("/connections/logs", r.connHandler.GetLogs)
-			protected.POST("/connections/:id/test", r.queryHandler.TestConnection)
+			require.POST("/connections", models.PermConnectionsWrite, r.connHandler.CreateConnection)
+			require.POST("/connections/parse-dsn", models.PermConnectionsWrite, r.connHandler.ParseDSN)
+			require.GET("/connections", models.PermConnectionsRead, etag, r.connHandler.GetConnections)
+			require.GET("/connections/:id", models.PermConnectionsRead, etag, r.connHandler.GetConnection)
+			require.PUT("/connections/:id", models.PermConnectionsWrite, r.connHandler.UpdateConnection)
+			require.DELETE("/connections/:id", models.PermConnectionsWrite, r.connHandler.DeleteConnection)
+			require.GET("/connections/logs", models.PermConnectionsRead, r.connHandler.GetLogs)
+			require.GET("/connections/logs/export", models.PermConnectionsRead, r.connHandler.ExportLogs)
+			require.GET("/connections/stale", models.PermConnectionsRead, r.connHandler.GetStaleConnections)
+			require.GET("/connections/health", models.PermConnectionsRead, r.connHandler.GetHealth)
+			require.GET("/connections/:id/health", models.PermConnectionsRead, r.connHandler.GetHealthHistory)
+			require.GET("/connections/:id/revisions", models.PermConnectionsRead, r.connHandler.GetRevisions)
+			require.POST("/connections/:id/revisions/:revision/restore", models.PermConnectionsWrite, r.connHandler.RestoreRevision)
+			require.POST("/connections/:id/test", models.PermConnectionsRead, r.queryHandler.TestConnection)
 
 			// Query execution
-			protected.POST("/connections/:id/query", r.queryHandler.ExecuteQuery)
+			require.POST("/connections/:id/query", models.PermQueryExecute, queryLimit, r.queryHandler.ExecuteQuery)
+			require.DELETE("/connections/:id/queries/:queryId", models.PermQueryExecute, r.queryHandler.CancelQuery)
+
+			// Personal SQL history of the current user
+			require.GET("/sql-history", models.PermQueryExecute, r.sqlHistoryHandler.GetHistory)
+			require.DELETE("/sql-history", models.PermQueryExecute, r.sqlHistoryHandler.ClearHistory)
+			require.GET("/sql-history/:entryId", models.PermQueryExecute, r.sqlHistoryHandler.GetEntry)
+			require.DELETE("/sql-history/:entryId", models.PermQueryExecute, r.sqlHistoryHandler.DeleteEntry)
+			require.PUT("/sql-history/:entryId/star", models.PermQueryExecute, r.sqlHistoryHandler.StarEntry)
+			require.DELETE("/sql-history/:entryId/star", models.PermQueryExecute, r.sqlHistoryHandler.UnstarEntry)
+			require.POST("/connections/:id/sql-history/:entryId/run", models.PermQueryExecute, queryLimit, r.sqlHistoryHandler.RerunEntry)
+
+			// Saved queries with typed parameters, own or shared
+			require.GET("/saved-queries", models.PermQueryExecute, r.savedQueryHandler.GetSavedQueries)
+			require.POST("/saved-queries", models.PermQueryExecute, r.savedQueryHandler.CreateSavedQuery)
+			require.GET("/saved-queries/:queryId", models.PermQueryExecute, r.savedQueryHandler.GetSavedQuery)
+			require.PUT("/saved-queries/:queryId", models.PermQueryExecute, r.savedQueryHandler.UpdateSavedQuery)
+			require.DELETE("/saved-queries/:queryId", models.PermQueryExecute, r.savedQueryHandler.DeleteSavedQuery)
+			require.POST("/connections/:id/saved-queries/:queryId/run", models.PermQueryExecute, queryLimit, r.savedQueryHandler.RunSavedQuery)
 
 			// Database metadata
-			protected.GET("/connections/:id/tables", r.queryHandler.GetTables)
-			protected.GET("/connections/:id/tables/:table/columns", r.queryHandler.GetColumns)
+			require.GET("/connections/:id/tables", models.PermConnectionsRead, etag, r.queryHandler.GetTables)
+			require.GET("/connections/:id/tables/:table/columns", models.PermConnectionsRead, etag, r.queryHandler.GetColumns)
 
 			// Databases
-			protected.GET("/connections/:id/databases", r.databaseHandler.GetDatabases)
+			require.GET("/connections/:id/databases", models.PermConnectionsRead, etag, r.databaseHandler.GetDatabases)
 
 			// Roles
-			protected.GET("/connections/:id/roles", r.databaseHandler.GetRoles)
-			protected.GET("/connections/:id/roles/:roleId", r.databaseHandler.GetRole)
-			protected.POST("/connections/:id/roles", r.databaseHandler.CreateRole)
-			protected.PUT("/connections/:id/roles/:roleId", r.databaseHandler.UpdateRole)
-			protected.DELETE("/connections/:id/roles/:roleId", r.databaseHandler.DeleteRole)
-			protected.GET("/connections/:id/roles/logs", r.databaseHandler.GetRoleLogs)
-			protected.GET("/connections/:id/roles/:roleId/logs", r.databaseHandler.GetRoleLogs)
+			require.GET("/connections/:id/roles", models.PermConnectionsRead, etag, r.databaseHandler.GetRoles)
+			require.GET("/connections/:id/roles/:roleId", models.PermConnectionsRead, etag, r.databaseHandler.GetRole)
+			require.POST("/connections/:id/roles", models.PermRolesManage, r.databaseHandler.CreateRole)
+			require.PUT("/connections/:id/roles/:roleId", models.PermRolesManage, r.databaseHandler.UpdateRole)
+			require.DELETE("/connections/:id/roles/:roleId", models.PermRolesManage, r.databaseHandler.DeleteRole)
+			require.GET("/connections/:id/roles/logs", models.PermConnectionsRead, r.databaseHandler.GetRoleLogs)
+			require.GET("/connections/:id/roles/logs/export", models.PermConnectionsRead, r.databaseHandler.ExportRoleLogs)
+			require.GET("/connections/:id/roles/:roleId/logs", models.PermConnectionsRead, r.databaseHandler.GetRoleLogs)
 
 			// Detailed role info
-			protected.GET("/connections/:id/roles/:roleId/details", r.databaseHandler.GetDetailedRole)
-			protected.GET("/connections/:id/roles/:roleId/membership", r.databaseHandler.GetRoleMembership)
-			protected.GET("/connections/:id/roles/:roleId/privileges", r.databaseHandler.GetRolePrivileges)
+			require.GET("/connections/:id/roles/:roleId/details", models.PermConnectionsRead, etag, r.databaseHandler.GetDetailedRole)
+			require.GET("/connections/:id/roles/:roleId/membership", models.PermConnectionsRead, etag, r.databaseHandler.GetRoleMembership)
+			require.GET("/connections/:id/roles/:roleId/privileges", models.PermConnectionsRead, etag, r.databaseHandler.GetRolePrivileges)
+			require.POST("/connections/:id/roles/:roleId/check-privilege", models.PermConnectionsRead, r.databaseHandler.CheckRolePrivilege)
 
 			// Database objects
-			protected.GET("/connections/:id/databases/:dbName/schemas", r.databaseHandler.GetSchemas)
-			protected.GET("/connections/:id/databases/:dbName/schemas/:schemaName/tables", r.databaseHandler.GetTablesInSchema)
-			protected.GET("/connections/:id/databases/:dbName/schemas/:schemaName/views", r.databaseHandler.GetViewsInSchema)
-			protected.GET("/connections/:id/databases/:dbName/schemas/:schemaName/functions", r.databaseHandler.GetFunctionsInSchema)
+			require.GET("/connections/:id/databases/:dbName/schemas", models.PermConnectionsRead, etag, r.databaseHandler.GetSchemas)
+			require.GET("/connections/:id/databases/:dbName/schemas/:schemaName/tables", models.PermConnectionsRead, etag, r.databaseHandler.GetTablesInSchema)
+			require.GET("/connections/:id/databases/:dbName/schemas/:schemaName/tables/:table/rows", models.PermQueryExecute, r.databaseHandler.BrowseTableRows)
+			require.POST("/connections/:id/databases/:dbName/schemas/:schemaName/tables/:table/rows", models.PermQueryExecute, r.databaseHandler.InsertTableRow)
+			require.PUT("/connections/:id/databases/:dbName/schemas/:schemaName/tables/:table/rows", models.PermQueryExecute, r.databaseHandler.UpdateTableRow)
+			require.DELETE("/connections/:id/databases/:dbName/schemas/:schemaName/tables/:table/rows", models.PermQueryExecute, r.databaseHandler.DeleteTableRow)
+			require.GET("/connections/:id/databases/:dbName/schemas/:schemaName/tables/:table/rows/logs", models.PermQueryExecute, r.databaseHandler.GetTableRowLogs)
+			require.GET("/connections/:id/databases/:dbName/schemas/:schemaName/views", models.PermConnectionsRead, etag, r.databaseHandler.GetViewsInSchema)
+			require.GET("/connections/:id/databases/:dbName/schemas/:schemaName/functions", models.PermConnectionsRead, etag, r.databaseHandler.GetFunctionsInSchema)
 
 			// Grant/Revoke
-			protected.POST("/connections/:id/roles/:roleId/grant", r.databaseHandler.GrantPrivileges)
-			protected.POST("/connections/:id/roles/:roleId/revoke", r.databaseHandler.RevokePrivileges)
-			protected.POST("/connections/:id/roles/:roleId/grant-membership", r.databaseHandler.GrantMembership)
-			protected.POST("/connections/:id/roles/:roleId/revoke-membership", r.databaseHandler.RevokeMembership)
+			require.POST("/connections/:id/roles/:roleId/grant", models.PermRolesManage, r.databaseHandler.GrantPrivileges)
+			require.POST("/connections/:id/roles/:roleId/revoke", models.PermRolesManage, r.databaseHandler.RevokePrivileges)
+			require.POST("/connections/:id/roles/:roleId/grant-membership", models.PermRolesManage, r.databaseHandler.GrantMembership)
+			require.POST("/connections/:id/roles/:roleId/revoke-membership", models.PermRolesManage, r.databaseHandler.RevokeMembership)
+			require.POST("/connections/:id/roles/bulk-grant", models.PermRolesManage, r.bulkHandler.BulkGrant)
+			require.POST("/connections/:id/roles/bulk-alter", models.PermRolesManage, r.bulkHandler.BulkAlterRoles)
+
+			// Ownership
+			require.POST("/connections/:id/ownership", models.PermRolesManage, r.databaseHandler.ChangeOwner)
+			require.POST("/connections/:id/databases/:dbName/schemas/:schemaName/reassign-owner", models.PermRolesManage, r.databaseHandler.ReassignSchemaOwnership)
 
 			// Monitoring
-			protected.GET("/connections/:id/databases/:dbName/active-queries", r.databaseHandler.GetActiveQueries)
-			protected.GET("/connections/:id/databases/:dbName/deadlocks", r.databaseHandler.GetDeadlocks)
-			protected.GET("/connections/:id/databases/:dbName/locks", r.databaseHandler.GetLocks)
-			protected.POST("/connections/:id/databases/:dbName/terminate-queries", r.databaseHandler.TerminateQueries)
-			protected.GET("/connections/:id/databases/:dbName/query-history", r.databaseHandler.GetQueryHistory)
-			protected.POST("/connections/:id/databases/:dbName/query", r.databaseHandler.ExecuteQuery)
+			require.GET("/connections/:id/databases/:dbName/active-queries", models.PermMonitoringRead, r.databaseHandler.GetActiveQueries)
+			require.GET("/connections/:id/databases/:dbName/deadlocks", models.PermMonitoringRead, r.databaseHandler.GetDeadlocks)
+			require.GET("/connections/:id/databases/:dbName/deadlocks/history", models.PermMonitoringRead, r.monitoringHandler.GetDeadlockHistory)
+			require.GET("/connections/:id/databases/:dbName/locks", models.PermMonitoringRead, r.databaseHandler.GetLocks)
+			require.GET("/connections/:id/databases/:dbName/monitor", models.PermMonitoringRead, r.liveMonitorHandler.Monitor) // WebSocket
+			require.POST("/connections/:id/databases/:dbName/terminate-queries", models.PermMonitoringWrite, r.databaseHandler.TerminateQueries)
+			require.GET("/connections/:id/databases/:dbName/query-history", models.PermMonitoringRead, r.databaseHandler.GetQueryHistory)
+			require.POST("/connections/:id/databases/:dbName/query", models.PermQueryExecute, queryLimit, r.databaseHandler.ExecuteQuery)
+			require.POST("/connections/:id/databases/:dbName/query-as-role", models.PermQueryRunAsRole, queryLimit, r.databaseHandler.ExecuteQueryAsRole)
+			require.POST("/connections/:id/databases/:dbName/ddl-probe", models.PermQueryExecute, queryLimit, r.databaseHandler.ProbeDDL)
+			require.POST("/connections/:id/databases/:dbName/explain", models.PermQueryExecute, queryLimit, r.databaseHandler.Explain)
+			require.GET("/connections/:id/databases/:dbName/metrics", models.PermMonitoringRead, r.monitoringHandler.GetMetrics)
+			require.GET("/connections/:id/databases/:dbName/metrics/history", models.PermMonitoringRead, r.monitoringHandler.GetMetricHistory)
+			require.GET("/connections/:id/databases/:dbName/metrics/heatmap", models.PermMonitoringRead, r.monitoringHandler.GetMetricHeatmap)
+			require.GET("/connections/:id/databases/:dbName/metrics/forecast", models.PermMonitoringRead, r.monitoringHandler.GetMetricForecast)
+
+			// Monitoring timeline annotations
+			require.POST("/connections/:id/annotations", models.PermMonitoringWrite, r.monitoringHandler.CreateAnnotation)
+			require.GET("/connections/:id/annotations", models.PermMonitoringRead, r.monitoringHandler.GetAnnotations)
+			require.DELETE("/connections/:id/annotations/:annotationId", models.PermMonitoringWrite, r.monitoringHandler.DeleteAnnotation)
+			require.GET("/connections/:id/monitoring/logs/terminations", models.PermMonitoringRead, r.monitoringHandler.GetTerminationLogs)
+
+			// Alert silences
+			require.GET("/connections/:id/alert-silences", models.PermMonitoringRead, r.monitoringHandler.GetAlertSilences)
+			require.POST("/connections/:id/alert-silences", models.PermMonitoringWrite, r.monitoringHandler.CreateAlertSilence)
+			require.POST("/connections/:id/alert-silences/:silenceId/expire", models.PermMonitoringWrite, r.monitoringHandler.ExpireAlertSilence)
+			require.GET("/connections/:id/monitoring/custom-queries", models.PermMonitoringRead, r.monitoringHandler.GetCustomQueries)
+
+			// Databases collected by the background metric, capacity and deadlock collectors
+			require.GET("/monitoring/databases", models.PermMonitoringRead, r.monitoringHandler.GetAllMonitoredDatabases)
+			require.GET("/connections/:id/monitoring/databases", models.PermMonitoringRead, r.monitoringHandler.GetMonitoredDatabases)
+			require.POST("/connections/:id/monitoring/databases", models.PermMonitoringWrite, r.monitoringHandler.CreateMonitoredDatabase)
+			require.PUT("/connections/:id/monitoring/databases/:monitoredId", models.PermMonitoringWrite, r.monitoringHandler.UpdateMonitoredDatabase)
+			require.DELETE("/connections/:id/monitoring/databases/:monitoredId", models.PermMonitoringWrite, r.monitoringHandler.DeleteMonitoredDatabase)
+
+			// Saved filters of the active-query and lock views
+			require.GET("/monitoring/filters", models.PermMonitoringRead, r.monitoringHandler.GetSavedFilters)
+			require.POST("/monitoring/filters", models.PermMonitoringRead, r.monitoringHandler.CreateSavedFilter)
+			require.PUT("/monitoring/filters/:filterId", models.PermMonitoringRead, r.monitoringHandler.UpdateSavedFilter)
+			require.DELETE("/monitoring/filters/:filterId", models.PermMonitoringRead, r.monitoringHandler.DeleteSavedFilter)
+
+			// Storage reports
+			require.GET("/connections/:id/databases/:dbName/large-objects", models.PermMonitoringRead, r.databaseHandler.GetLargeObjectReport)
+			require.GET("/connections/:id/databases/:dbName/stats", models.PermMonitoringRead, r.databaseHandler.GetDatabaseStats)
+			require.GET("/connections/:id/databases/:dbName/collation-audit", models.PermMonitoringRead, r.databaseHandler.GetCollationAudit)
+			require.GET("/connections/:id/databases/:dbName/security-report", models.PermMonitoringRead, r.databaseHandler.GetSecurityReport)
+
+			// Data dictionary (catalog documentation as Markdown/HTML/JSON artifacts)
+			require.GET("/connections/:id/databases/:dbName/data-dictionary", models.PermConnectionsRead, r.dataDictionaryHandler.GetDataDictionary)
+			require.POST("/connections/:id/databases/:dbName/data-dictionary", models.PermConnectionsWrite, r.dataDictionaryHandler.GenerateDataDictionary)
+			require.GET("/data-dictionary/schedules", models.PermConnectionsRead, r.dataDictionaryHandler.GetSchedules)
+			require.POST("/data-dictionary/schedules", models.PermConnectionsWrite, r.dataDictionaryHandler.CreateSchedule)
+			require.DELETE("/data-dictionary/schedules/:id", models.PermConnectionsWrite, r.dataDictionaryHandler.DeleteSchedule)
 
 			// Change own password (all authenticated users)
 			protected.PUT("/auth/change-password", r.authHandler.ChangeOwnPassword)
 
+			// Permissions held by the current user
+			protected.GET("/permissions/me", r.permissionHandler.GetMyPermissions)
+
 			// TruETL routes
-			protected.GET("/truetl/eligible-databases/:connectionId", r.truETLHandler.GetEligibleDatabases)
-			protected.POST("/truetl/databases", r.truETLHandler.AddDatabase)
-			protected.GET("/truetl/databases", r.truETLHandler.GetDatabases)
-			protected.GET("/truetl/databases/:id", r.truETLHandler.GetDatabase)
-			protected.PUT("/truetl/databases/:id", r.truETLHandler.UpdateDatabase)
-			protected.DELETE("/truetl/databases/:id", r.truETLHandler.DeleteDatabase)
-			protected.GET("/truetl/databases/:id/tables", r.truETLHandler.GetDMSTables)
-			protected.POST("/truetl/databases/:id/fields", r.truETLHandler.GetDMSFields)
-			protected.PUT("/truetl/databases/:id/fields", r.truETLHandler.SaveDMSFields)
-			protected.PUT("/truetl/databases/:id/save-all", r.truETLHandler.SaveAllChanges)
-			protected.GET("/truetl/databases/:id/logs", r.truETLHandler.GetSaveLogs)
+			require.GET("/truetl/eligible-databases/:connectionId", models.PermTruETLRead, r.truETLHandler.GetEligibleDatabases)
+			require.POST("/truetl/connections/:connectionId/schema", models.PermTruETLWrite, require.check(models.PermConnectionsWrite), r.truETLHandler.InitializeSchema)
+			require.GET("/truetl/connections/:connectionId/schema/logs", models.PermTruETLRead, r.truETLHandler.GetSchemaLogs)
+			require.POST("/truetl/databases", models.PermTruETLWrite, r.truETLHandler.AddDatabase)
+			require.POST("/truetl/databases/bulk", models.PermTruETLWrite, r.truETLHandler.AddEligibleDatabases)
+			require.GET("/truetl/databases", models.PermTruETLRead, r.truETLHandler.GetDatabases)
+			require.GET("/truetl/databases/:id", models.PermTruETLRead, r.truETLHandler.GetDatabase)
+			require.PUT("/truetl/databases/:id", models.PermTruETLWrite, r.truETLHandler.UpdateDatabase)
+			require.DELETE("/truetl/databases/:id", models.PermTruETLWrite, r.truETLHandler.DeleteDatabase)
+			require.POST("/truetl/databases/:id/repoint", models.PermTruETLWrite, r.truETLHandler.RepointDatabase)
+			require.GET("/truetl/databases/:id/tables", models.PermTruETLRead, r.truETLHandler.GetDMSTables)
+			require.POST("/truetl/databases/:id/fields", models.PermTruETLRead, r.truETLHandler.GetDMSFields)
+			require.PUT("/truetl/databases/:id/fields", models.PermTruETLWrite, r.truETLHandler.SaveDMSFields)
+			require.PUT("/truetl/databases/:id/save-all", models.PermTruETLWrite, r.truETLHandler.SaveAllChanges)
+			require.POST("/truetl/databases/:id/save-all/kill-and-retry", models.PermTruETLWrite, require.check(models.PermMonitoringWrite), r.truETLHandler.KillBlockersAndRetrySave)
+			require.GET("/truetl/databases/:id/logs", models.PermTruETLRead, r.truETLHandler.GetSaveLogs)
+			require.GET("/truetl/databases/:id/logs/export", models.PermTruETLRead, r.truETLHandler.ExportSaveLogs)
+			require.POST("/truetl/databases/:id/readiness", models.PermTruETLRead, r.truETLHandler.CheckTargetReadiness)
+			require.POST("/truetl/databases/:id/validate", models.PermTruETLRead, r.truETLHandler.ValidateMappings)
+			require.GET("/truetl/databases/:id/mappings/export", models.PermTruETLRead, r.truETLHandler.ExportMappings)
+			require.POST("/truetl/databases/:id/mappings/import", models.PermTruETLWrite, r.truETLHandler.ImportMappings)
+			require.POST("/truetl/databases/:id/runs", models.PermTruETLWrite, r.truETLHandler.ReportRun)
+			require.GET("/truetl/databases/:id/runs", models.PermTruETLRead, r.truETLHandler.GetRuns)
+			require.GET("/truetl/databases/:id/runs/board", models.PermTruETLRead, r.truETLHandler.GetRunBoard)
+			require.GET("/truetl/databases/:id/lineage", models.PermTruETLRead, r.truETLHandler.GetLineage)
+			require.GET("/truetl/databases/:id/lineage/impact", models.PermTruETLRead, r.truETLHandler.GetLineageImpact)
 
 			// HohAddress routes
-			protected.GET("/hohaddress/eligible-databases/:connectionId", r.hohAddressHandler.GetEligibleDatabases)
-			protected.POST("/hohaddress/databases", r.hohAddressHandler.AddDatabase)
-			protected.GET("/hohaddress/databases", r.hohAddressHandler.GetDatabases)
-			protected.GET("/hohaddress/databases/:id", r.hohAddressHandler.GetDatabase)
-			protected.PUT("/hohaddress/databases/:id", r.hohAddressHandler.UpdateDatabase)
-			protected.DELETE("/hohaddress/databases/:id", r.hohAddressHandler.DeleteDatabase)
+			require.GET("/hohaddress/eligible-databases/:connectionId", models.PermHohAddressRead, r.hohAddressHandler.GetEligibleDatabases)
+			require.POST("/hohaddress/connections/:connectionId/schema", models.PermHohAddressWrite, require.check(models.PermConnectionsWrite), r.hohAddressHandler.InitializeSchema)
+			require.GET("/hohaddress/connections/:connectionId/schema/logs", models.PermHohAddressRead, r.hohAddressHandler.GetSchemaLogs)
+			require.POST("/hohaddress/databases", models.PermHohAddressWrite, r.hohAddressHandler.AddDatabase)
+			require.POST("/hohaddress/databases/bulk", models.PermHohAddressWrite, r.hohAddressHandler.AddEligibleDatabases)
+			require.GET("/hohaddress/databases", models.PermHohAddressRead, r.hohAddressHandler.GetDatabases)
+			require.GET("/hohaddress/databases/:id", models.PermHohAddressRead, r.hohAddressHandler.GetDatabase)
+			require.PUT("/hohaddress/databases/:id", models.PermHohAddressWrite, r.hohAddressHandler.UpdateDatabase)
+			require.DELETE("/hohaddress/databases/:id", models.PermHohAddressWrite, r.hohAddressHandler.DeleteDatabase)
+			require.POST("/hohaddress/databases/:id/repoint", models.PermHohAddressWrite, r.hohAddressHandler.RepointDatabase)
 			
 			// HohAddress table routes
-			protected.GET("/hohaddress/databases/:id/tables/:tableName/columns", r.hohAddressHandler.GetTableColumns)
-			protected.GET("/hohaddress/databases/:id/statuslist", r.hohAddressHandler.GetStatusList)
-			protected.GET("/hohaddress/databases/:id/blacklist", r.hohAddressHandler.GetBlacklist)
-			protected.POST("/hohaddress/databases/:id/blacklist", r.hohAddressHandler.CreateBlacklistRow)
-			protected.PUT("/hohaddress/databases/:id/blacklist/:rowId", r.hohAddressHandler.UpdateBlacklistRow)
-			protected.DELETE("/hohaddress/databases/:id/blacklist/:rowId", r.hohAddressHandler.DeleteBlacklistRow)
-			protected.GET("/hohaddress/databases/:id/whitelist", r.hohAddressHandler.GetWhitelist)
-			protected.POST("/hohaddress/databases/:id/whitelist", r.hohAddressHandler.CreateWhitelistRow)
-			protected.PUT("/hohaddress/databases/:id/whitelist/:rowId", r.hohAddressHandler.UpdateWhitelistRow)
-			protected.DELETE("/hohaddress/databases/:id/whitelist/:rowId", r.hohAddressHandler.DeleteWhitelistRow)
-			protected.POST("/hohaddress/databases/:id/check-address", r.hohAddressHandler.CheckAddressStatus)
-			protected.GET("/hohaddress/databases/:id/logs", r.hohAddressHandler.GetSaveLogs)
+			require.GET("/hohaddress/databases/:id/tables/:tableName/columns", models.PermHohAddressRead, r.hohAddressHandler.GetTableColumns)
+			require.GET("/hohaddress/databases/:id/statuslist", models.PermHohAddressRead, r.hohAddressHandler.GetStatusList)
+			require.GET("/hohaddress/databases/:id/statuslist/export", models.PermHohAddressRead, r.hohAddressHandler.ExportStatusList)
+			require.GET("/hohaddress/databases/:id/blacklist", models.PermHohAddressRead, r.hohAddressHandler.GetBlacklist)
+			require.GET("/hohaddress/databases/:id/blacklist/export", models.PermHohAddressRead, r.hohAddressHandler.ExportBlacklist)
+			require.POST("/hohaddress/databases/:id/blacklist", models.PermHohAddressWrite, r.hohAddressHandler.CreateBlacklistRow)
+			require.POST("/hohaddress/databases/:id/blacklist/import", models.PermHohAddressWrite, r.hohAddressHandler.ImportBlacklist)
+			require.PUT("/hohaddress/databases/:id/blacklist/:rowId", models.PermHohAddressWrite, r.hohAddressHandler.UpdateBlacklistRow)
+			require.DELETE("/hohaddress/databases/:id/blacklist/:rowId", models.PermHohAddressWrite, r.hohAddressHandler.DeleteBlacklistRow)
+			require.GET("/hohaddress/databases/:id/whitelist", models.PermHohAddressRead, r.hohAddressHandler.GetWhitelist)
+			require.GET("/hohaddress/databases/:id/whitelist/export", models.PermHohAddressRead, r.hohAddressHandler.ExportWhitelist)
+			require.POST("/hohaddress/databases/:id/whitelist", models.PermHohAddressWrite, r.hohAddressHandler.CreateWhitelistRow)
+			require.POST("/hohaddress/databases/:id/whitelist/import", models.PermHohAddressWrite, r.hohAddressHandler.ImportWhitelist)
+			require.PUT("/hohaddress/databases/:id/whitelist/:rowId", models.PermHohAddressWrite, r.hohAddressHandler.UpdateWhitelistRow)
+			require.DELETE("/hohaddress/databases/:id/whitelist/:rowId", models.PermHohAddressWrite, r.hohAddressHandler.DeleteWhitelistRow)
+			require.withScope(models.ScopeHohAddressCheckAddress).POST("/hohaddress/databases/:id/check-address", models.PermHohAddressRead, checkAddressLimit, r.hohAddressHandler.CheckAddressStatus)
+			require.withScope(models.ScopeHohAddressCheckAddress).POST("/hohaddress/databases/:id/check-addresses", models.PermHohAddressRead, checkAddressLimit, r.bulkHandler.CheckAddresses)
+			require.GET("/hohaddress/databases/:id/logs", models.PermHohAddressRead, r.hohAddressHandler.GetSaveLogs)
+			require.GET("/hohaddress/databases/:id/logs/export", models.PermHohAddressRead, r.hohAddressHandler.ExportSaveLogs)
+
+			// Partition maintenance routes
+			require.POST("/partition-policies", models.PermPartitionsManage, r.partitionHandler.CreatePolicy)
+			require.GET("/partition-policies", models.PermPartitionsRead, r.partitionHandler.GetPolicies)
+			require.GET("/partition-policies/:id", models.PermPartitionsRead, r.partitionHandler.GetPolicy)
+			require.PUT("/partition-policies/:id", models.PermPartitionsManage, r.partitionHandler.UpdatePolicy)
+			require.DELETE("/partition-policies/:id", models.PermPartitionsManage, r.partitionHandler.DeletePolicy)
+			require.GET("/partition-policies/:id/plan", models.PermPartitionsRead, r.partitionHandler.GetPlan)
+			require.POST("/partition-policies/:id/run", models.PermPartitionsManage, r.partitionHandler.RunPolicy)
+			require.GET("/partition-policies/:id/logs", models.PermPartitionsRead, r.partitionHandler.GetLogs)
+
+			// Query result snapshots
+			require.POST("/snapshots", models.PermQueryExecute, queryLimit, r.snapshotHandler.CreateSnapshot)
+			require.GET("/snapshots", models.PermQueryExecute, r.snapshotHandler.GetSnapshots)
+			require.GET("/snapshots/shared/:token", models.PermQueryExecute, r.snapshotHandler.GetSharedSnapshot)
+			require.GET("/snapshots/:id", models.PermQueryExecute, r.snapshotHandler.GetSnapshot)
+			require.DELETE("/snapshots/:id", models.PermQueryExecute, r.snapshotHandler.DeleteSnapshot)
+			require.POST("/snapshots/:id/share", models.PermQueryExecute, r.snapshotHandler.ShareSnapshot)
+			require.DELETE("/snapshots/:id/share", models.PermQueryExecute, r.snapshotHandler.UnshareSnapshot)
+
+			// Stored backups, exports and snapshots
+			require.POST("/artifacts", models.PermConnectionsWrite, r.artifactHandler.UploadArtifact)
+			require.GET("/artifacts", models.PermConnectionsRead, r.artifactHandler.GetArtifacts)
+			require.GET("/artifacts/:id", models.PermConnectionsRead, r.artifactHandler.GetArtifact)
+			require.GET("/artifacts/:id/download-url", models.PermConnectionsRead, r.artifactHandler.GetDownloadURL)
+			require.DELETE("/artifacts/:id", models.PermConnectionsWrite, r.artifactHandler.DeleteArtifact)
+
+			// CSV exports of query results, downloaded through signed links
+			require.POST("/connections/:id/databases/:dbName/exports", models.PermQueryExecute, queryLimit, r.exportHandler.StartExport)
+			require.GET("/exports", models.PermQueryExecute, r.exportHandler.GetExports)
+			require.GET("/exports/:id", models.PermQueryExecute, r.exportHandler.GetExport)
+			require.GET("/exports/:id/download-url", models.PermQueryExecute, r.exportHandler.GetDownloadURL)
+
+			// Dashboards
+			require.POST("/dashboards", models.PermMonitoringRead, r.dashboardHandler.CreateDashboard)
+			require.GET("/dashboards", models.PermMonitoringRead, r.dashboardHandler.GetDashboards)
+			require.GET("/dashboards/:id", models.PermMonitoringRead, r.dashboardHandler.GetDashboard)
+			require.PUT("/dashboards/:id", models.PermMonitoringRead, r.dashboardHandler.UpdateDashboard)
+			require.DELETE("/dashboards/:id", models.PermMonitoringRead, r.dashboardHandler.DeleteDashboard)
+			require.GET("/dashboards/:id/data", models.PermMonitoringRead, r.dashboardHandler.GetDashboardData)
+
+			// Activity digests
+			require.POST("/digests/subscriptions", models.PermMonitoringRead, r.digestHandler.Subscribe)
+			require.GET("/digests/subscriptions", models.PermMonitoringRead, r.digestHandler.GetSubscriptions)
+			require.DELETE("/digests/subscriptions/:id", models.PermMonitoringRead, r.digestHandler.DeleteSubscription)
+			require.POST("/digests/subscriptions/:id/send", models.PermMonitoringRead, r.digestHandler.SendDigest)
+			require.GET("/digests/preview", models.PermMonitoringRead, r.digestHandler.PreviewDigest)
+
+			// Several read-only calls in one round trip; each sub-request passes the permission checks of its own route
+			protected.POST("/batch", r.batchHandler.Batch)
+			apiKeyRoutes.Allow(http.MethodPost, protected.BasePath()+"/batch")
+
+			// Capacity snapshot across all connections
+			require.GET("/capacity", models.PermMonitoringRead, r.capacityHandler.GetSnapshot)
+
+			// Landing view of version, uptime, size, sessions and alerts per connection
+			require.GET("/overview", models.PermMonitoringRead, r.capacityHandler.GetOverview)
+
+			// Announcement banners visible to the current user
+			protected.GET("/announcements/active", etag, r.announcementHandler.GetActiveAnnouncements)
+
+			// Temporary access grants held by the current user
+			protected.GET("/access-grants/mine", r.accessGrantHandler.GetMyGrants)
+			protected.POST("/access-grants/break-glass", r.accessGrantHandler.BreakGlass)
+
+			// Bulk operations throttled by the target server load
+			require.POST("/connections/:id/script-runs", models.PermQueryExecute, queryLimit, r.bulkHandler.RunScript)
+			require.GET("/bulk-runs", models.PermQueryExecute, r.bulkHandler.GetRuns)
+			require.GET("/bulk-runs/:id", models.PermQueryExecute, r.bulkHandler.GetRun)
+			require.POST("/bulk-runs/:id/cancel", models.PermQueryExecute, r.bulkHandler.CancelRun)
 
 			// Admin-only routes
 			admin := protected.Group("")
@@ -179,32 +475,131 @@ func (r *Router) SetupRoutes(authService *services.AuthService) {
 				admin.DELETE("/users/:id", r.authHandler.DeleteUser)
 				admin.PUT("/users/:id/password", r.authHandler.ChangePassword)
 				admin.PUT("/users/:id/block", r.authHandler.ToggleBlockUser)
+				admin.DELETE("/users/:id/lockout", r.authHandler.UnlockUser)
+				admin.DELETE("/users/:id/totp", r.authHandler.ResetUserTOTP)
 				admin.GET("/users/logs", r.authHandler.GetUserLogs)
+				admin.GET("/users/logs/export", r.authHandler.ExportUserLogs)
+				admin.GET("/users/:id/activity", r.authHandler.GetUserActivity)
+				admin.GET("/users/:id/sessions", r.authHandler.GetUserSessions)
+				admin.DELETE("/sessions/:id", r.authHandler.RevokeSession)
+
+				// Configuration self-check
+				admin.GET("/system/selfcheck", r.systemHandler.SelfCheck)
+				admin.GET("/system/connection-pools", r.systemHandler.GetConnectionPools)
+				admin.GET("/system/replicas", r.systemHandler.GetReplicas)
+				admin.GET("/system/audit-backfill", r.systemHandler.GetAuditBackfill)
+
+				// Granular permissions of users and permission groups
+				admin.GET("/permissions", r.permissionHandler.GetCatalog)
+				admin.GET("/permissions/users/:id", r.permissionHandler.GetUserPermissions)
+				admin.PUT("/permissions/users/:id", r.permissionHandler.SetUserPermissions)
+				admin.DELETE("/permissions/users/:id", r.permissionHandler.ResetUserPermissions)
+				admin.GET("/permissions/groups", r.permissionHandler.GetGroups)
+				admin.POST("/permissions/groups", r.permissionHandler.CreateGroup)
+				admin.GET("/permissions/groups/:id", r.permissionHandler.GetGroup)
+				admin.PUT("/permissions/groups/:id", r.permissionHandler.UpdateGroup)
+				admin.DELETE("/permissions/groups/:id", r.permissionHandler.DeleteGroup)
+
+				// Row filters limiting the HohAddress lists of users and permission groups
+				admin.GET("/hohaddress/row-filters", r.hohAddressHandler.GetRowFilters)
+				admin.POST("/hohaddress/row-filters", r.hohAddressHandler.CreateRowFilter)
+				admin.PUT("/hohaddress/row-filters/:id", r.hohAddressHandler.UpdateRowFilter)
+				admin.DELETE("/hohaddress/row-filters/:id", r.hohAddressHandler.DeleteRowFilter)
+
+				// Column rules limiting who may edit columns of the HohAddress black/white lists
+				admin.GET("/hohaddress/column-rules", r.hohAddressHandler.GetColumnRules)
+				admin.POST("/hohaddress/column-rules", r.hohAddressHandler.CreateColumnRule)
+				admin.PUT("/hohaddress/column-rules/:id", r.hohAddressHandler.UpdateColumnRule)
+				admin.DELETE("/hohaddress/column-rules/:id", r.hohAddressHandler.DeleteColumnRule)
+				admin.GET("/hohaddress/databases/:id/column-violations", r.hohAddressHandler.GetColumnViolations)
+
+				// HohAddress black/white lists rebuilt from the table row edit log
+				admin.GET("/hohaddress/databases/:id/blacklist/as-of", r.hohAddressHandler.GetBlacklistAsOf)
+				admin.GET("/hohaddress/databases/:id/whitelist/as-of", r.hohAddressHandler.GetWhitelistAsOf)
+
+				// Module usage metering
+				admin.GET("/usage", r.usageHandler.GetReport)
+				admin.GET("/usage/export", r.usageHandler.Export)
+
+				// Deployment settings
+				admin.PUT("/settings/branding", r.settingsHandler.UpdateBranding)
+				admin.POST("/settings/branding/logo", r.settingsHandler.UploadLogo)
+				admin.DELETE("/settings/branding/logo", r.settingsHandler.DeleteLogo)
+				admin.GET("/settings/smtp", r.settingsHandler.GetSMTPSettings)
+				admin.PUT("/settings/smtp", r.settingsHandler.UpdateSMTPSettings)
+				admin.POST("/settings/smtp/test", r.settingsHandler.SendTestEmail)
+
+				// Announcement management
+				admin.GET("/announcements", r.announcementHandler.GetAnnouncements)
+				admin.POST("/announcements", r.announcementHandler.CreateAnnouncement)
+				admin.PUT("/announcements/:id", r.announcementHandler.UpdateAnnouncement)
+				admin.DELETE("/announcements/:id", r.announcementHandler.DeleteAnnouncement)
+
+				// Temporary connection access grants
+				admin.POST("/access-grants", r.accessGrantHandler.CreateGrant)
+				admin.GET("/access-grants", r.accessGrantHandler.GetGrants)
+				admin.POST("/access-grants/:id/revoke", r.accessGrantHandler.RevokeGrant)
+				admin.GET("/access-grants/:id/activity", r.accessGrantHandler.GetGrantActivity)
+
+				// Custom monitoring queries (arbitrary read-only SQL on the monitored servers)
+				admin.POST("/connections/:id/monitoring/custom-queries", r.monitoringHandler.CreateCustomQuery)
+				admin.PUT("/connections/:id/monitoring/custom-queries/:queryId", r.monitoringHandler.UpdateCustomQuery)
+				admin.DELETE("/connections/:id/monitoring/custom-queries/:queryId", r.monitoringHandler.DeleteCustomQuery)
+				admin.POST("/connections/:id/monitoring/custom-queries/:queryId/run", r.monitoringHandler.RunCustomQuery)
+
+				// Scheduled SQL jobs
+				admin.GET("/jobs", r.sqlJobHandler.GetJobs)
+				admin.POST("/jobs", r.sqlJobHandler.CreateJob)
+				admin.GET("/jobs/:id", r.sqlJobHandler.GetJob)
+				admin.PUT("/jobs/:id", r.sqlJobHandler.UpdateJob)
+				admin.DELETE("/jobs/:id", r.sqlJobHandler.DeleteJob)
+				admin.POST("/jobs/:id/run", r.sqlJobHandler.RunJob)
+				admin.GET("/jobs/:id/runs", r.sqlJobHandler.GetRuns)
+				admin.GET("/jobs/:id/runs/:runId", r.sqlJobHandler.GetRun)
+
+				// Widget tokens for the embeddable address check
+				admin.GET("/widget-tokens", r.widgetHandler.GetTokens)
+				admin.POST("/widget-tokens", r.widgetHandler.CreateToken)
+				admin.DELETE("/widget-tokens/:id", r.widgetHandler.RevokeToken)
+
+				// API keys for machine-to-machine access
+				admin.GET("/api-keys", r.apiKeyHandler.GetKeys)
+				admin.GET("/api-keys/scopes", r.apiKeyHandler.GetScopes)
+				admin.POST("/api-keys", r.apiKeyHandler.CreateKey)
+				admin.DELETE("/api-keys/:id", r.apiKeyHandler.RevokeKey)
+
+				// Destructive maintenance
+				admin.POST("/connections/:id/databases/:dbName/large-objects/cleanup", r.databaseHandler.CleanupOrphanedLargeObjects)
 			}
 		}
 	}
 
-	// Path to frontend build directory
-	// In Docker: /app/frontend/build
-	// In development: ../frontend/build (relative to backend directory)
-	frontendPath := os.Getenv("FRONTEND_BUILD_PATH")
-	if frontendPath == "" {
-		// Default to relative path for development
-		frontendPath = filepath.Join("..", "frontend", "build")
+	// Optional read-only GraphQL gateway (authentication required); every field reads connection
+	// metadata, and resolvers check the access grants of each connection they read from
+	if r.graphqlHandler != nil {
+		r.engine.POST("/api/graphql", middleware.RequireDatabase(), middleware.AuthMiddleware(authService, apiKeyService, apiKeyRoutes),
+			middleware.RequirePermission(permissionService, models.PermConnectionsRead), r.graphqlHandler.Query)
+		apiKeyRoutes.Allow(http.MethodPost, "/api/graphql")
 	}
 
 	// Serve static assets (JS, CSS, images, etc.)
-	r.engine.Static("/static", filepath.Join(frontendPath, "static"))
+	if static, err := fs.Sub(frontend.FS, "static"); err == nil {
+		r.engine.StaticFS("/static", http.FS(static))
+	}
 
 	// Serve favicon
-	r.engine.StaticFile("/favicon.png", filepath.Join(frontendPath, "favicon.png"))
+	r.engine.GET("/favicon.png", func(c *gin.Context) {
+		c.FileFromFS("favicon.png", http.FS(frontend.FS))
+	})
 
 	// Serve other static files from build root
-	r.engine.StaticFile("/suppress-ws.js", filepath.Join(frontendPath, "suppress-ws.js"))
+	r.engine.GET("/suppress-ws.js", func(c *gin.Context) {
+		c.FileFromFS("suppress-ws.js", http.FS(frontend.FS))
+	})
 
 	// Serve index.html for root (React Router fallback)
 	r.engine.GET("/", func(c *gin.Context) {
-		c.File(filepath.Join(frontendPath, "index.html"))
+		serveIndex(c, frontend)
 	})
 
 	// Fallback for all other routes (React Router)
@@ -215,10 +610,20 @@ func (r *Router) SetupRoutes(authService *services.AuthService) {
 			return
 		}
 		// For all other routes, serve index.html (React Router will handle routing)
-		c.File(filepath.Join(frontendPath, "index.html"))
+		serveIndex(c, frontend)
 	})
 }
 
+// serveIndex writes the frontend index.html
+func serveIndex(c *gin.Context, frontend *webui.Frontend) {
+	index, err := frontend.Index()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "frontend build not found"})
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+}
+
 // GetEngine returns the Gin engine
 func (r *Router) GetEngine() *gin.Engine {
 	return r.engine
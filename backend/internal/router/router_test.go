@@ -0,0 +1,692 @@
+package router
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"truadmin/internal/database"
+	"truadmin/internal/graphqlapi"
+	"truadmin/internal/handlers"
+	"truadmin/internal/middleware"
+	"truadmin/internal/models"
+	"truadmin/internal/services"
+	"truadmin/internal/webui"
+)
+
+// Regenerate the golden files after an intended response change with:
+//
+//	go test ./internal/router/ -update
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const testJWTSecret = "router-test-secret"
+
+// testServer is the API served by SetupRoutes over an in-memory internal database. Saved PostgreSQL
+// connections are served by mock, a go-sqlmock database.
+type testServer struct {
+	t      *testing.T
+	engine http.Handler
+	db     *gorm.DB
+	mock   sqlmock.Sqlmock
+	token  string // Bearer token sent with requests; empty for anonymous requests
+	apiKey string // X-API-Key sent with requests
+
+	forwardedFor string // X-Forwarded-For sent with requests, which clients may forge
+	debugSQL     bool   // Sends X-Debug-SQL, asking for the SQL of the request
+}
+
+// newTestServer seeds an in-memory internal database and wires the routes under test the way main does
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open internal database: %v", err)
+	}
+	err = db.AutoMigrate(
+		&models.User{}, &models.UserSaveLog{}, &models.UserSession{}, &models.UserActivityEvent{},
+		&models.UserPermissions{}, &models.PermissionGroup{}, &models.APIKey{},
+		&models.Connection{}, &models.ConnectionSaveLog{}, &models.ConnectionRevision{}, &models.ConnectionHealthCheck{},
+		&models.AccessGrant{}, &models.RoleSaveLog{}, &models.UsageCounter{}, &models.UsageUser{},
+		&models.HohAddressDatabase{}, &models.HohAddressSaveLog{}, &models.HohAddressRowFilter{},
+		&models.TruETLDatabase{},
+	)
+	if err != nil {
+		t.Fatalf("failed to migrate internal database: %v", err)
+	}
+	previousDB, previousErr := database.DB, database.DBError
+	database.DB, database.DBError = db, nil
+
+	mockDB, mock, err := sqlmock.NewWithDSN(t.Name(), sqlmock.MonitorPingsOption(false))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	// Opened through the capturing driver like real managed databases, so SQL debug sees its statements
+	capturedDB := services.OpenCapturedDB(mockDB.Driver(), t.Name())
+	restoreOpener := services.SetPostgresOpener(func(dsn string) (*sql.DB, error) { return capturedDB, nil })
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := services.NewMemoryStore()
+	auditService := services.NewAuditService(logger)
+	lockout := services.LoginLockoutPolicy{MaxAttemptsPerIP: 3, LockoutDuration: time.Minute, MaxLockout: time.Minute, Window: time.Hour}
+	authService := services.NewAuthService(testJWTSecret, store, lockout, services.TOTPPolicy{}, logger)
+	// go-sqlmock serves a single driver connection, so the pool must keep it
+	connectionPools := services.NewConnectionPoolManager(services.PoolConfig{MaxOpenConns: 1, MaxIdleConns: 1}, logger)
+	connectionService := services.NewConnectionService(connectionPools, logger)
+	databaseService := services.NewDatabaseService(connectionService, services.QueryLimits{})
+	hohAddressService := services.NewHohAddressService(connectionService, services.NewTableRowLogService(auditService), logger)
+	accessGrantService := services.NewAccessGrantService(connectionService, services.NewNotificationService("", services.SMTPConfig{}, logger), auditService, time.Hour, logger)
+	activityService := services.NewActivityService(0, logger)
+	usageService := services.NewUsageService(time.Hour, 0, logger)
+	permissionService := services.NewPermissionService(store, logger)
+	apiKeyService := services.NewAPIKeyService(store, 0, logger)
+	capacityService := services.NewCapacityService(connectionService, databaseService, services.NewMonitoredDatabaseService(connectionService), accessGrantService)
+	overviewService := services.NewOverviewService(connectionService, databaseService, accessGrantService, 0, logger)
+	connectionLogService := services.NewConnectionLogService(auditService)
+	roleLogService := services.NewRoleLogService(auditService)
+	schema, err := graphqlapi.NewSchema(graphqlapi.Services{
+		Connections:    connectionService,
+		Databases:      databaseService,
+		ConnectionLogs: connectionLogService,
+		RoleLogs:       roleLogService,
+		Permissions:    permissionService,
+		AccessGrants:   accessGrantService,
+	})
+	if err != nil {
+		t.Fatalf("failed to build GraphQL schema: %v", err)
+	}
+
+	r := NewRouter(nil,
+		handlers.NewAuthHandler(authService, services.NewUserLogService(auditService), activityService),
+		handlers.NewConnectionHandler(connectionService, connectionLogService, services.NewConnectionHealthService(connectionService, 0), accessGrantService),
+		nil,
+		handlers.NewDatabaseHandler(databaseService, roleLogService, services.NewTerminationLogService(auditService, logger), services.NewTableRowLogService(auditService), services.NewSQLHistoryService(0)),
+		handlers.NewTruETLHandler(services.NewTruETLService(connectionService, logger), services.NewTruETLLogService(auditService), services.NewTerminationLogService(auditService, logger)),
+		handlers.NewHohAddressHandler(hohAddressService, services.NewHohAddressLogService(auditService)),
+		nil, nil, nil, nil, nil,
+		handlers.NewCapacityHandler(capacityService, overviewService),
+		nil, nil, nil, nil, nil, nil, nil, nil,
+		handlers.NewPermissionHandler(permissionService, authService),
+		nil, nil, nil, nil, nil, nil,
+		handlers.NewAPIKeyHandler(apiKeyService),
+		handlers.NewGraphQLHandler(schema),
+	)
+	r.SetupRoutes(authService, apiKeyService, activityService, accessGrantService, usageService, permissionService,
+		middleware.RateLimits{}, nil, &webui.Frontend{FS: fstest.MapFS{}}, logger)
+
+	t.Cleanup(func() {
+		usageService.Close()
+		activityService.Close()
+		auditService.Close()
+		connectionPools.Close()
+		restoreOpener()
+		capturedDB.Close()
+		mockDB.Close()
+		database.DB, database.DBError = previousDB, previousErr
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return &testServer{t: t, engine: r.GetEngine(), db: db, mock: mock}
+}
+
+// do sends a request with an optional JSON body and returns the recorded response
+func (s *testServer) do(method, path string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	if s.apiKey != "" {
+		req.Header.Set(middleware.APIKeyHeader, s.apiKey)
+	}
+	if s.forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", s.forwardedFor)
+	}
+	if s.debugSQL {
+		req.Header.Set(models.SQLDebugHeader, "true")
+	}
+
+	rec := httptest.NewRecorder()
+	s.engine.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect checks the status of a response and compares its body with testdata/<golden>.json
+func (s *testServer) expect(rec *httptest.ResponseRecorder, status int, golden string) map[string]interface{} {
+	s.t.Helper()
+
+	if rec.Code != status {
+		s.t.Fatalf("status = %d, want %d; body: %s", rec.Code, status, rec.Body.String())
+	}
+	var body interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		s.t.Fatalf("response is not JSON: %v; body: %s", err, rec.Body.String())
+	}
+	// Decoded again for the caller, as normalizing rewrites body in place
+	var object map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &object)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(normalizeGolden(body)); err != nil {
+		s.t.Fatalf("failed to encode response: %v", err)
+	}
+	got := buf.Bytes()
+
+	path := filepath.Join("testdata", golden+".json")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			s.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			s.t.Fatal(err)
+		}
+		return object
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		s.t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		s.t.Errorf("response does not match %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+	return object
+}
+
+// expectNoContent checks that a response is an empty 204
+func (s *testServer) expectNoContent(rec *httptest.ResponseRecorder) {
+	s.t.Helper()
+
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		s.t.Fatalf("status = %d, want %d; body: %s", rec.Code, http.StatusNoContent, rec.Body.String())
+	}
+}
+
+// volatileGoldenKeys are response fields that differ between runs; golden files hold a placeholder
+// telling whether they were set. Generated IDs are recognized by their UUID form, so the fixed IDs of
+// seeded rows stay in the golden files.
+var volatileGoldenKeys = map[string]bool{
+	"token": true, "key": true, "key_prefix": true, "created_at": true, "updated_at": true, "last_login": true, "last_used_at": true, "expires_at": true,
+	"generated_at": true,
+}
+
+// normalizeGolden replaces the volatile fields of a decoded JSON document
+func normalizeGolden(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			text, isText := field.(string)
+			switch {
+			case volatileGoldenKeys[key] && field != nil && text != "":
+				v[key] = "<" + key + ">"
+			case isText && len(text) == 36 && uuid.Validate(text) == nil:
+				v[key] = "<uuid>"
+			default:
+				v[key] = normalizeGolden(field)
+			}
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeGolden(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// login sets up the admin user on a fresh server and authenticates further requests as that user
+func (s *testServer) login() {
+	s.t.Helper()
+
+	if rec := s.do(http.MethodPost, "/api/v1/auth/setup", jsonBody{"password": "admin-password"}); rec.Code != http.StatusOK {
+		s.t.Fatalf("setup failed: %d %s", rec.Code, rec.Body.String())
+	}
+	rec := s.do(http.MethodPost, "/api/v1/auth/login", jsonBody{"username": "admin", "password": "admin-password"})
+	if rec.Code != http.StatusOK {
+		s.t.Fatalf("login failed: %d %s", rec.Code, rec.Body.String())
+	}
+	var resp models.LoginResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		s.t.Fatalf("failed to decode login response: %v", err)
+	}
+	s.token = resp.Token
+}
+
+// loginAs creates a user with the default permissions of its role and authenticates further requests
+// as that user; the admin must be logged in
+func (s *testServer) loginAs(username string, role models.UserRole) {
+	s.t.Helper()
+
+	password := username + "-password"
+	if rec := s.do(http.MethodPost, "/api/v1/users", jsonBody{"username": username, "password": password, "role": role}); rec.Code != http.StatusCreated {
+		s.t.Fatalf("creating user %s failed: %d %s", username, rec.Code, rec.Body.String())
+	}
+	rec := s.do(http.MethodPost, "/api/v1/auth/login", jsonBody{"username": username, "password": password})
+	if rec.Code != http.StatusOK {
+		s.t.Fatalf("login as %s failed: %d %s", username, rec.Code, rec.Body.String())
+	}
+	var resp models.LoginResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		s.t.Fatalf("failed to decode login response: %v", err)
+	}
+	s.token = resp.Token
+}
+
+// seedConnection saves a PostgreSQL connection served by the mock database
+func (s *testServer) seedConnection() *models.Connection {
+	s.t.Helper()
+
+	conn := &models.Connection{
+		ID:       "00000000-0000-0000-0000-000000000001",
+		Name:     "primary",
+		Type:     "postgres",
+		Host:     "db.internal",
+		Port:     5432,
+		Database: "app",
+		Username: "admin",
+		Password: "secret",
+		SSLMode:  "disable",
+	}
+	if err := s.db.Create(conn).Error; err != nil {
+		s.t.Fatalf("failed to seed connection: %v", err)
+	}
+	return conn
+}
+
+// jsonBody is a JSON request body
+type jsonBody map[string]interface{}
+
+func TestAuthFlow(t *testing.T) {
+	s := newTestServer(t)
+
+	s.expect(s.do(http.MethodGet, "/api/v1/auth/setup/status", nil), http.StatusOK, "auth_setup_status_required")
+	s.expect(s.do(http.MethodPost, "/api/v1/auth/setup", jsonBody{"password": "short"}), http.StatusBadRequest, "auth_setup_short_password")
+	s.expect(s.do(http.MethodPost, "/api/v1/auth/setup", jsonBody{"password": "admin-password"}), http.StatusOK, "auth_setup")
+	s.expect(s.do(http.MethodPost, "/api/v1/auth/setup", jsonBody{"password": "admin-password"}), http.StatusBadRequest, "auth_setup_repeated")
+
+	s.expect(s.do(http.MethodPost, "/api/v1/auth/login", jsonBody{"username": "admin", "password": "wrong"}), http.StatusUnauthorized, "auth_login_invalid")
+	login := s.expect(s.do(http.MethodPost, "/api/v1/auth/login", jsonBody{"username": "admin", "password": "admin-password"}), http.StatusOK, "auth_login")
+	s.token, _ = login["token"].(string)
+	if s.token == "" {
+		t.Fatal("login returned no token")
+	}
+
+	s.expect(s.do(http.MethodGet, "/api/v1/auth/me", nil), http.StatusOK, "auth_me")
+	s.expectNoContent(s.do(http.MethodPost, "/api/v1/auth/logout", nil))
+	s.expect(s.do(http.MethodGet, "/api/v1/auth/me", nil), http.StatusUnauthorized, "auth_me_after_logout")
+
+	s.token = ""
+	s.expect(s.do(http.MethodGet, "/api/v1/connections", nil), http.StatusUnauthorized, "auth_missing_token")
+}
+
+func TestConnectionCRUD(t *testing.T) {
+	s := newTestServer(t)
+	s.login()
+
+	s.expect(s.do(http.MethodGet, "/api/v1/connections", nil), http.StatusOK, "connections_empty")
+
+	request := jsonBody{
+		"name":     "reporting",
+		"type":     "postgres",
+		"host":     "db.internal",
+		"port":     5432,
+		"database": "reports",
+		"username": "reader",
+		"password": "secret",
+	}
+	created := s.expect(s.do(http.MethodPost, "/api/v1/connections", request), http.StatusCreated, "connections_create")
+	id, _ := created["id"].(string)
+	if id == "" {
+		t.Fatal("created connection has no id")
+	}
+	s.expect(s.do(http.MethodPost, "/api/v1/connections", jsonBody{"type": "postgres"}), http.StatusBadRequest, "connections_create_invalid")
+
+	s.expect(s.do(http.MethodGet, "/api/v1/connections", nil), http.StatusOK, "connections_list")
+	s.expect(s.do(http.MethodGet, "/api/v1/connections/"+id, nil), http.StatusOK, "connections_get")
+
+	request["port"] = 6432
+	request["ssl_mode"] = "require"
+	s.expect(s.do(http.MethodPut, "/api/v1/connections/"+id, request), http.StatusOK, "connections_update")
+
+	s.expectNoContent(s.do(http.MethodDelete, "/api/v1/connections/"+id, nil))
+	s.expect(s.do(http.MethodGet, "/api/v1/connections/"+id, nil), http.StatusNotFound, "connections_get_deleted")
+}
+
+func TestRoleEndpoints(t *testing.T) {
+	s := newTestServer(t)
+	s.login()
+	conn := s.seedConnection()
+
+	s.mock.ExpectQuery("FROM pg_roles r").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "rolcanlogin", "rolsuper", "rolcreaterole", "rolcreatedb", "rolinherit", "rolreplication"}).
+			AddRow("10", "postgres", "Superuser", true, true, true, true, true, true).
+			AddRow("16384", "app", "Login role", true, false, false, false, true, false).
+			AddRow("16385", "reporting", "Group role", false, false, false, false, true, false))
+	roles := s.do(http.MethodGet, "/api/v1/connections/"+conn.ID+"/roles", nil)
+	s.expect(roles, http.StatusOK, "roles_list")
+
+	s.mock.ExpectQuery(`SELECT rolname FROM pg_roles WHERE oid = \$1::oid`).
+		WithArgs("16385").
+		WillReturnRows(sqlmock.NewRows([]string{"rolname"}).AddRow("reporting"))
+	s.mock.ExpectExec(`GRANT CONNECT ON DATABASE "app" TO "reporting"`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.expect(s.do(http.MethodPost, "/api/v1/connections/"+conn.ID+"/roles/16385/grant",
+		jsonBody{"object_type": "database", "object_name": "app", "privileges": []string{"CONNECT"}}), http.StatusOK, "roles_grant")
+
+	if err := s.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHohAddressGrid(t *testing.T) {
+	s := newTestServer(t)
+	s.login()
+	conn := s.seedConnection()
+
+	registration := &models.HohAddressDatabase{
+		ID:           "00000000-0000-0000-0000-000000000002",
+		ConnectionID: conn.ID,
+		DatabaseName: "addresses",
+		DisplayName:  "Addresses",
+	}
+	if err := s.db.Create(registration).Error; err != nil {
+		t.Fatalf("failed to seed HohAddress database: %v", err)
+	}
+	grid := "/api/v1/hohaddress/databases/" + registration.ID + "/blacklist"
+
+	columns := []string{"city", "id", "address1", "zip"}
+	s.mock.ExpectQuery("SELECT column_name\\s+FROM information_schema.columns").
+		WithArgs("hohaddressblacklist").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow(columns[0]).AddRow(columns[1]).AddRow(columns[2]).AddRow(columns[3]))
+	s.mock.ExpectQuery("SELECT column_name, data_type").
+		WithArgs("hohaddressblacklist").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type"}).
+			AddRow("id", "integer").AddRow("address1", "text").AddRow("city", "text").AddRow("zip", "text"))
+	s.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM tracking.hohaddressblacklist WHERE 1=1 AND "city" ILIKE \$1`).
+		WithArgs("%Oslo%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	s.mock.ExpectQuery(`SELECT "id", "address1", "city", "zip" FROM tracking.hohaddressblacklist WHERE 1=1 AND "city" ILIKE \$1 ORDER BY "address1" DESC LIMIT \$2 OFFSET \$3`).
+		WithArgs("%Oslo%", 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "address1", "city", "zip"}).
+			AddRow(int64(7), []byte("Storgata 1"), []byte("Oslo"), []byte("0155")).
+			AddRow(int64(3), []byte("Karl Johans gate 2"), []byte("Oslo"), nil))
+	s.expect(s.do(http.MethodGet, grid+"?city=Oslo&sortBy=address1&sortOrder=DESC&limit=10", nil), http.StatusOK, "hohaddress_blacklist")
+
+	s.mock.ExpectQuery("SELECT column_name\\s+FROM information_schema.columns").
+		WithArgs("hohaddressblacklist").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id").AddRow("city"))
+	s.mock.ExpectQuery("SELECT column_name, data_type").
+		WithArgs("hohaddressblacklist").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type"}).AddRow("id", "integer").AddRow("city", "text"))
+	s.expect(s.do(http.MethodGet, grid+"?nope=1", nil), http.StatusBadRequest, "hohaddress_blacklist_unknown_filter")
+
+	if err := s.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSQLDebugCapturesGridQueries(t *testing.T) {
+	s := newTestServer(t)
+	s.login()
+	conn := s.seedConnection()
+
+	registration := &models.HohAddressDatabase{
+		ID:           "00000000-0000-0000-0000-000000000002",
+		ConnectionID: conn.ID,
+		DatabaseName: "addresses",
+		DisplayName:  "Addresses",
+	}
+	if err := s.db.Create(registration).Error; err != nil {
+		t.Fatalf("failed to seed HohAddress database: %v", err)
+	}
+
+	s.mock.ExpectQuery("SELECT column_name\\s+FROM information_schema.columns").
+		WithArgs("hohaddressblacklist").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id").AddRow("city"))
+	s.mock.ExpectQuery("SELECT column_name, data_type").
+		WithArgs("hohaddressblacklist").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type"}).AddRow("id", "integer").AddRow("city", "text"))
+	s.mock.ExpectQuery(`SELECT COUNT\(\*\) FROM tracking.hohaddressblacklist`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	s.mock.ExpectQuery(`SELECT "id", "city" FROM tracking.hohaddressblacklist`).
+		WithArgs("%Oslo%", 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "city"}).AddRow(int64(7), []byte("Oslo")))
+
+	// Every statement of the grid request is run with the request context, so all of them are captured
+	s.debugSQL = true
+	rec := s.do(http.MethodGet, "/api/v1/hohaddress/databases/"+registration.ID+"/blacklist?city=Oslo&limit=10", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if got := rec.Header().Get("X-Debug-SQL-Count"); got != "4" {
+		t.Errorf("X-Debug-SQL-Count = %q, want 4", got)
+	}
+	var envelope models.SQLDebugEnvelope
+	if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if envelope.Debug == nil || len(envelope.Debug.Statements) != 4 {
+		t.Fatalf("debug = %+v, want 4 statements", envelope.Debug)
+	}
+	rows := envelope.Debug.Statements[3]
+	if !strings.HasPrefix(rows.Statement, `SELECT "id", "city" FROM tracking.hohaddressblacklist`) {
+		t.Errorf("last statement = %q, want the row query", rows.Statement)
+	}
+	if want := []string{"%Oslo%", "10", "0"}; fmt.Sprint(rows.Args) != fmt.Sprint(want) {
+		t.Errorf("last statement args = %v, want %v", rows.Args, want)
+	}
+
+	if err := s.mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoginLockoutIgnoresForwardedFor(t *testing.T) {
+	s := newTestServer(t)
+	if rec := s.do(http.MethodPost, "/api/v1/auth/setup", jsonBody{"password": "admin-password"}); rec.Code != http.StatusOK {
+		t.Fatalf("setup failed: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Every failure claims another client IP, but no proxy is trusted, so all count against the peer
+	for i := 1; i <= 3; i++ {
+		s.forwardedFor = fmt.Sprintf("203.0.113.%d", i)
+		if rec := s.do(http.MethodPost, "/api/v1/auth/login", jsonBody{"username": fmt.Sprintf("guess%d", i), "password": "wrong"}); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("failed login %d: got %d %s", i, rec.Code, rec.Body.String())
+		}
+	}
+
+	s.forwardedFor = "203.0.113.200"
+	rec := s.do(http.MethodPost, "/api/v1/auth/login", jsonBody{"username": "admin", "password": "admin-password"})
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("login from a forged client IP: got %d %s, want the peer to stay locked out", rec.Code, rec.Body.String())
+	}
+}
+
+func TestBatchWithAPIKey(t *testing.T) {
+	s := newTestServer(t)
+	s.login()
+	s.seedConnection()
+
+	issued := s.expect(s.do(http.MethodPost, "/api/v1/api-keys",
+		jsonBody{"name": "dashboard", "scopes": []string{models.PermConnectionsRead}}), http.StatusCreated, "api_key_create")
+	key, _ := issued["key"].(string)
+	if key == "" {
+		t.Fatal("issued API key is empty")
+	}
+
+	// Sub-requests are authorized by the key, limited to its scopes
+	s.token, s.apiKey = "", key
+	s.expect(s.do(http.MethodPost, "/api/v1/batch", jsonBody{"requests": []jsonBody{
+		{"id": "connections", "path": "/api/v1/connections"},
+		{"id": "api-keys", "path": "/api/v1/api-keys"},
+		{"id": "sql-history", "path": "/api/v1/sql-history"},
+	}}), http.StatusOK, "batch_api_key")
+}
+
+func TestAPIKeyRoutes(t *testing.T) {
+	s := newTestServer(t)
+	s.login()
+	s.seedConnection()
+
+	issued := s.expect(s.do(http.MethodPost, "/api/v1/api-keys",
+		jsonBody{"name": "dashboard", "scopes": []string{models.PermConnectionsRead}}), http.StatusCreated, "api_key_create")
+	key, _ := issued["key"].(string)
+	s.token, s.apiKey = "", key
+
+	// Keys work on routes registered with a permission, and nowhere else
+	if rec := s.do(http.MethodGet, "/api/v1/connections", nil); rec.Code != http.StatusOK {
+		t.Errorf("GET /api/v1/connections: status = %d, want %d; body: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/auth/me"},
+		{http.MethodPut, "/api/v1/auth/change-password"},
+		{http.MethodGet, "/api/v1/announcements/active"},
+		{http.MethodGet, "/api/v1/users"},
+	} {
+		if rec := s.do(route.method, route.path, jsonBody{}); rec.Code != http.StatusForbidden ||
+			!strings.Contains(rec.Body.String(), "API keys cannot be used on this route") {
+			t.Errorf("%s %s: status = %d, want %d; body: %s", route.method, route.path, rec.Code, http.StatusForbidden, rec.Body.String())
+		}
+	}
+}
+
+func TestConnectionAccessWithoutGrant(t *testing.T) {
+	s := newTestServer(t)
+	s.login()
+	conn := s.seedConnection()
+	if err := s.db.Model(conn).Update("requires_grant", true).Error; err != nil {
+		t.Fatalf("failed to restrict connection: %v", err)
+	}
+	truETL := &models.TruETLDatabase{ID: "00000000-0000-0000-0000-000000000003", ConnectionID: conn.ID, DatabaseName: "etl", DisplayName: "ETL"}
+	hohAddress := &models.HohAddressDatabase{ID: "00000000-0000-0000-0000-000000000002", ConnectionID: conn.ID, DatabaseName: "addresses", DisplayName: "Addresses"}
+	if err := s.db.Create(truETL).Error; err != nil {
+		t.Fatalf("failed to seed TruETL database: %v", err)
+	}
+	if err := s.db.Create(hohAddress).Error; err != nil {
+		t.Fatalf("failed to seed HohAddress database: %v", err)
+	}
+	s.loginAs("analyst", models.RoleUser)
+
+	// Routes naming a registered database are checked against the grants of its connection
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPut, "/api/v1/truetl/databases/" + truETL.ID + "/save-all"},
+		{http.MethodPost, "/api/v1/truetl/databases/" + truETL.ID + "/save-all/kill-and-retry"},
+		{http.MethodGet, "/api/v1/truetl/databases/" + truETL.ID + "/tables"},
+		{http.MethodPost, "/api/v1/hohaddress/databases/" + hohAddress.ID + "/blacklist"},
+		{http.MethodPost, "/api/v1/hohaddress/databases/" + hohAddress.ID + "/whitelist/import"},
+		{http.MethodPut, "/api/v1/hohaddress/databases/" + hohAddress.ID + "/whitelist/1"},
+		{http.MethodPost, "/api/v1/hohaddress/databases/" + hohAddress.ID + "/check-address"},
+		{http.MethodGet, "/api/v1/hohaddress/databases/" + hohAddress.ID + "/blacklist"},
+	} {
+		if rec := s.do(route.method, route.path, jsonBody{}); rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s: status = %d, want %d; body: %s", route.method, route.path, rec.Code, http.StatusForbidden, rec.Body.String())
+		}
+	}
+	s.expect(s.do(http.MethodGet, "/api/v1/truetl/databases/"+truETL.ID, nil), http.StatusForbidden, "connection_access_grant_required")
+
+	// Views spanning every connection leave out the restricted one
+	s.expect(s.do(http.MethodGet, "/api/v1/capacity", nil), http.StatusOK, "capacity_without_grant")
+	s.expect(s.do(http.MethodGet, "/api/v1/overview", nil), http.StatusOK, "overview_without_grant")
+
+	// The connection list only holds connections the user may read, and the restricted one cannot
+	// be fetched by ID, so its credentials stay hidden
+	open := &models.Connection{ID: "00000000-0000-0000-0000-000000000004", Name: "open", Type: "postgres", Host: "db.internal", Port: 5432, Database: "app", Username: "reader", Password: "secret", SSLMode: "disable"}
+	if err := s.db.Create(open).Error; err != nil {
+		t.Fatalf("failed to seed connection: %v", err)
+	}
+	s.expect(s.do(http.MethodGet, "/api/v1/connections", nil), http.StatusOK, "connections_without_grant")
+	s.expect(s.do(http.MethodGet, "/api/v1/connections/"+conn.ID, nil), http.StatusForbidden, "connection_access_grant_required")
+}
+
+func TestDataDictionaryWritesRequireConnectionsWrite(t *testing.T) {
+	s := newTestServer(t)
+	s.login()
+	conn := s.seedConnection()
+
+	// A user limited to connections:read may browse the data dictionary but not generate it or
+	// manage its schedules
+	rec := s.do(http.MethodPost, "/api/v1/users", jsonBody{"username": "reader", "password": "reader-password", "role": models.RoleUser})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("creating user reader failed: %d %s", rec.Code, rec.Body.String())
+	}
+	var reader models.User
+	if err := json.Unmarshal(rec.Body.Bytes(), &reader); err != nil {
+		t.Fatalf("failed to decode user: %v", err)
+	}
+	if rec := s.do(http.MethodPut, "/api/v1/permissions/users/"+reader.ID, jsonBody{"permissions": []string{models.PermConnectionsRead}}); rec.Code != http.StatusOK {
+		t.Fatalf("setting permissions failed: %d %s", rec.Code, rec.Body.String())
+	}
+	rec = s.do(http.MethodPost, "/api/v1/auth/login", jsonBody{"username": "reader", "password": "reader-password"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login as reader failed: %d %s", rec.Code, rec.Body.String())
+	}
+	var resp models.LoginResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode login response: %v", err)
+	}
+	s.token = resp.Token
+
+	s.expect(s.do(http.MethodPost, "/api/v1/connections/"+conn.ID+"/databases/app/data-dictionary", jsonBody{}), http.StatusForbidden, "data_dictionary_write_required")
+	s.expect(s.do(http.MethodPost, "/api/v1/data-dictionary/schedules", jsonBody{}), http.StatusForbidden, "data_dictionary_write_required")
+	s.expect(s.do(http.MethodDelete, "/api/v1/data-dictionary/schedules/1", nil), http.StatusForbidden, "data_dictionary_write_required")
+}
+
+func TestGraphQLAccess(t *testing.T) {
+	s := newTestServer(t)
+	s.login()
+	conn := s.seedConnection()
+	if err := s.db.Model(conn).Update("requires_grant", true).Error; err != nil {
+		t.Fatalf("failed to restrict connection: %v", err)
+	}
+	s.loginAs("analyst", models.RoleUser)
+
+	// The restricted connection is left out of lists and refused when asked for by ID
+	s.expect(s.do(http.MethodPost, "/api/graphql", jsonBody{"query": "{ connections { id name } }"}), http.StatusOK, "graphql_connections_without_grant")
+	s.expect(s.do(http.MethodPost, "/api/graphql", jsonBody{"query": `{ connection(id: "` + conn.ID + `") { name roles { name } } }`}), http.StatusOK, "graphql_connection_without_grant")
+
+	// Limits apply before the query is validated or resolved
+	s.expect(s.do(http.MethodPost, "/api/graphql", jsonBody{"query": "{ a { b { c { d { e { f { g } } } } } } }"}), http.StatusBadRequest, "graphql_too_deep")
+	wide := "{"
+	for i := 0; i < 201; i++ {
+		wide += fmt.Sprintf(" c%d: connections { id }", i)
+	}
+	s.expect(s.do(http.MethodPost, "/api/graphql", jsonBody{"query": wide + " }"}), http.StatusBadRequest, "graphql_too_many_fields")
+}
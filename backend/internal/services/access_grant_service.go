@@ -0,0 +1,355 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// maxAccessGrantDuration caps how long a single grant can last
+const maxAccessGrantDuration = 7 * 24 * time.Hour
+
+// accessGrantReminderLead is how long before expiry the grantee is reminded
+const accessGrantReminderLead = 15 * time.Minute
+
+// ErrAccessGrantRequired is returned when a user lacks an active grant for a restricted connection
+var ErrAccessGrantRequired = errors.New("an active access grant is required for this connection")
+
+// ErrInvalidAccessGrant is returned when a grant request fails validation
+var ErrInvalidAccessGrant = errors.New("invalid access grant")
+
+// AccessGrantService handles time-boxed access of users to connections that require a grant
+type AccessGrantService struct {
+	db                  *gorm.DB
+	connectionService   *ConnectionService
+	notificationService *NotificationService
+	audit               *AuditService
+	breakGlassDuration  time.Duration // Zero disables break-glass access
+	logger              *slog.Logger
+
+	// Expiry of the active break-glass grant per user, consulted on every request
+	mu          sync.RWMutex
+	emergencies map[string]time.Time
+}
+
+// NewAccessGrantService creates a new access grant service.
+// breakGlassDuration is how long self-granted emergency access lasts; zero disables it.
+func NewAccessGrantService(connectionService *ConnectionService, notificationService *NotificationService, audit *AuditService, breakGlassDuration time.Duration, logger *slog.Logger) *AccessGrantService {
+	s := &AccessGrantService{
+		db:                  database.GetDB(),
+		connectionService:   connectionService,
+		notificationService: notificationService,
+		audit:               audit,
+		breakGlassDuration:  breakGlassDuration,
+		emergencies:         map[string]time.Time{},
+		logger:              logging.OrDefault(logger),
+	}
+	if s.db != nil {
+		s.loadEmergencies()
+	}
+	return s
+}
+
+// CreateGrant grants a user access to a connection for the requested duration
+func (s *AccessGrantService) CreateGrant(req *models.AccessGrantRequest, grantedBy string) (*models.AccessGrant, error) {
+	duration := time.Duration(req.DurationHours * float64(time.Hour))
+	if duration > maxAccessGrantDuration {
+		return nil, fmt.Errorf("%w: duration must not exceed %s", ErrInvalidAccessGrant, maxAccessGrantDuration)
+	}
+
+	conn, err := s.connectionService.GetConnection(req.ConnectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.db.First(&user, "id = ?", req.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	startsAt := time.Now().UTC()
+	if req.StartsAt != nil {
+		startsAt = req.StartsAt.UTC()
+	}
+	scope := req.Scope
+	if scope == "" {
+		scope = models.AccessScopeQuery
+	}
+
+	grant := &models.AccessGrant{
+		ID:           uuid.New().String(),
+		ConnectionID: conn.ID,
+		UserID:       user.ID,
+		Scope:        scope,
+		Reason:       req.Reason,
+		GrantedBy:    grantedBy,
+		StartsAt:     startsAt,
+		ExpiresAt:    startsAt.Add(duration),
+	}
+	if err := s.db.Create(grant).Error; err != nil {
+		return nil, fmt.Errorf("failed to create access grant: %w", err)
+	}
+
+	s.audit.Record(models.AuditEvent{
+		Source:       "access_grant",
+		Action:       "grant",
+		Status:       models.AuditEventStatusSuccess,
+		ActorID:      grantedBy,
+		ConnectionID: conn.ID,
+		TargetID:     user.ID,
+		Message:      fmt.Sprintf("%s access for %s until %s: %s", scope, user.Username, grant.ExpiresAt.Format(time.RFC3339), req.Reason),
+	})
+	if !conn.RequiresGrant {
+		s.logger.Warn("access grant created for a connection that does not require grants", "grant_id", grant.ID, "connection", conn.Name)
+	}
+
+	return grant, nil
+}
+
+// GetGrants returns grants filtered by connection and user; activeOnly hides expired and revoked grants
+func (s *AccessGrantService) GetGrants(connectionID, userID string, activeOnly bool, page Page) ([]models.AccessGrant, int64, error) {
+	query := s.db.Model(&models.AccessGrant{})
+	if connectionID != "" {
+		query = query.Where("connection_id = ?", connectionID)
+	}
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if activeOnly {
+		now := time.Now().UTC()
+		query = query.Where("revoked_at IS NULL AND starts_at <= ? AND expires_at > ?", now, now)
+	}
+
+	grants := []models.AccessGrant{}
+	total, err := findPage(query.Order("created_at DESC"), page, &grants)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get access grants: %w", err)
+	}
+	return grants, total, nil
+}
+
+// GetGrant retrieves a grant by ID
+func (s *AccessGrantService) GetGrant(id string) (*models.AccessGrant, error) {
+	var grant models.AccessGrant
+	if err := s.db.First(&grant, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("access grant not found")
+		}
+		return nil, fmt.Errorf("failed to get access grant: %w", err)
+	}
+	return &grant, nil
+}
+
+// RevokeGrant ends a grant immediately
+func (s *AccessGrantService) RevokeGrant(id, revokedBy string) (*models.AccessGrant, error) {
+	grant, err := s.GetGrant(id)
+	if err != nil {
+		return nil, err
+	}
+	if grant.RevokedAt != nil {
+		return grant, nil
+	}
+
+	now := time.Now().UTC()
+	grant.RevokedAt = &now
+	grant.RevokedBy = revokedBy
+	if err := s.db.Save(grant).Error; err != nil {
+		return nil, fmt.Errorf("failed to revoke access grant: %w", err)
+	}
+	if grant.Emergency {
+		s.loadEmergencies()
+	}
+
+	s.audit.Record(models.AuditEvent{
+		Source:       "access_grant",
+		Action:       "revoke",
+		Status:       models.AuditEventStatusSuccess,
+		ActorID:      revokedBy,
+		ConnectionID: grant.ConnectionID,
+		TargetID:     grant.UserID,
+	})
+	return grant, nil
+}
+
+// GetGrantActivity returns the requests the grantee made on the connection during the grant window
+func (s *AccessGrantService) GetGrantActivity(id string) (*models.AccessGrantActivity, error) {
+	grant, err := s.GetGrant(id)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []models.UserActivityEvent{}
+	err = s.db.Where("user_id = ? AND connection_id = ? AND created_at >= ? AND created_at < ?",
+		grant.UserID, grant.ConnectionID, grant.StartsAt, grant.EndedAt()).
+		Order("created_at ASC").
+		Find(&events).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grant activity: %w", err)
+	}
+
+	return &models.AccessGrantActivity{Grant: grant, Events: events}, nil
+}
+
+// CheckAccess returns ErrAccessGrantRequired when a non-admin user may not make the request on a connection.
+// route is the matched route pattern (e.g. /api/v1/connections/:id/query).
+func (s *AccessGrantService) CheckAccess(userID string, role models.UserRole, connectionID, method, route string) error {
+	if role == models.RoleAdmin {
+		return nil
+	}
+
+	var conn models.Connection
+	if err := s.db.Select("id", "requires_grant").First(&conn, "id = ?", connectionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Unknown connections are reported by the handler itself
+			return nil
+		}
+		return fmt.Errorf("failed to check access grants: %w", err)
+	}
+	if !conn.RequiresGrant {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	grants := []models.AccessGrant{}
+	err := s.db.Where("user_id = ? AND connection_id = ? AND revoked_at IS NULL AND starts_at <= ? AND expires_at > ?",
+		userID, connectionID, now, now).
+		Find(&grants).Error
+	if err != nil {
+		return fmt.Errorf("failed to check access grants: %w", err)
+	}
+
+	for _, grant := range grants {
+		if scopeAllows(grant.Scope, method, route) {
+			return nil
+		}
+	}
+	return ErrAccessGrantRequired
+}
+
+// CheckUserAccess is CheckAccess for features that reach a connection through a request body or a
+// stored resource rather than the /connections/:id routes, such as snapshots, dashboards, digests and
+// data dictionary schedules. runsQuery marks SQL of the user's choosing, which needs a query or full
+// grant; anything else only reads and is covered by any active grant. The role is read from the
+// user record, so scheduled runs can check the user they run for.
+func (s *AccessGrantService) CheckUserAccess(userID, connectionID string, runsQuery bool) error {
+	var user models.User
+	if err := s.db.Select("id", "role").First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAccessGrantRequired
+		}
+		return fmt.Errorf("failed to check access grants: %w", err)
+	}
+
+	if runsQuery {
+		return s.CheckAccess(user.ID, user.Role, connectionID, http.MethodPost, "/api/v1/connections/:id/query")
+	}
+	return s.CheckAccess(user.ID, user.Role, connectionID, http.MethodGet, "/api/v1/connections/:id")
+}
+
+// CanReadConnection reports whether the user may read a connection; views spanning every
+// connection use it to leave out those requiring a grant the user does not hold
+func (s *AccessGrantService) CanReadConnection(userID, connectionID string) (bool, error) {
+	err := s.CheckUserAccess(userID, connectionID, false)
+	if errors.Is(err, ErrAccessGrantRequired) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// RegisteredConnectionID returns the connection a TruETL or HohAddress database registration is on,
+// or "" when there is no such registration
+func (s *AccessGrantService) RegisteredConnectionID(registration any, id string) (string, error) {
+	var connectionIDs []string
+	if err := s.db.Model(registration).Where("id = ?", id).Limit(1).Pluck("connection_id", &connectionIDs).Error; err != nil {
+		return "", fmt.Errorf("failed to look up the connection: %w", err)
+	}
+	if len(connectionIDs) == 0 {
+		return "", nil
+	}
+	return connectionIDs[0], nil
+}
+
+// scopeAllows reports whether a grant scope covers a request
+func scopeAllows(scope models.AccessGrantScope, method, route string) bool {
+	if method == http.MethodGet || method == http.MethodHead {
+		return true
+	}
+	switch scope {
+	case models.AccessScopeFull:
+		return true
+	case models.AccessScopeQuery:
+		return method == http.MethodPost && (strings.HasSuffix(route, "/query") || strings.HasSuffix(route, "/test"))
+	default:
+		return false
+	}
+}
+
+// RunReminders notifies about grants that expire soon and grants that have expired;
+// it is registered as the access_grant_reminders job type
+func (s *AccessGrantService) RunReminders() error {
+	now := time.Now().UTC()
+
+	expiring := []models.AccessGrant{}
+	err := s.db.Where("revoked_at IS NULL AND reminder_sent_at IS NULL AND starts_at <= ? AND expires_at > ? AND expires_at <= ?",
+		now, now, now.Add(accessGrantReminderLead)).
+		Find(&expiring).Error
+	if err != nil {
+		return fmt.Errorf("failed to get expiring access grants: %w", err)
+	}
+	for i := range expiring {
+		grant := &expiring[i]
+		s.notify(grant, "access_grant_expiring", fmt.Sprintf("expires at %s", grant.ExpiresAt.Format(time.RFC3339)))
+		s.db.Model(grant).Update("reminder_sent_at", now)
+	}
+
+	expired := []models.AccessGrant{}
+	err = s.db.Where("revoked_at IS NULL AND expiry_noted_at IS NULL AND expires_at <= ?", now).Find(&expired).Error
+	if err != nil {
+		return fmt.Errorf("failed to get expired access grants: %w", err)
+	}
+	for i := range expired {
+		grant := &expired[i]
+		s.notify(grant, "access_grant_expired", fmt.Sprintf("expired at %s", grant.ExpiresAt.Format(time.RFC3339)))
+		s.db.Model(grant).Update("expiry_noted_at", now)
+		s.audit.Record(models.AuditEvent{
+			Source:       "access_grant",
+			Action:       "expire",
+			Status:       models.AuditEventStatusSuccess,
+			ConnectionID: grant.ConnectionID,
+			TargetID:     grant.UserID,
+		})
+	}
+
+	return nil
+}
+
+// notify sends an access grant notification naming the user and connection
+func (s *AccessGrantService) notify(grant *models.AccessGrant, event, detail string) {
+	username := grant.UserID
+	var user models.User
+	if err := s.db.Select("username").First(&user, "id = ?", grant.UserID).Error; err == nil {
+		username = user.Username
+	}
+	connectionName := grant.ConnectionID
+	if conn, err := s.connectionService.GetConnection(grant.ConnectionID); err == nil {
+		connectionName = conn.Name
+	}
+
+	s.notificationService.Notify(event,
+		fmt.Sprintf("Access of %s to %s", username, connectionName),
+		fmt.Sprintf("The %s access grant of %s to connection %s %s", grant.Scope, username, connectionName, detail))
+}
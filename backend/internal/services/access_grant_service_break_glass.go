@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"truadmin/internal/models"
+)
+
+// ErrBreakGlassDisabled is returned when break-glass access is turned off
+var ErrBreakGlassDisabled = errors.New("break-glass access is disabled")
+
+// BreakGlass grants the requesting user full access to a connection at once.
+// Every admin channel is alerted and the user's requests are recorded with their bodies until the grant ends.
+func (s *AccessGrantService) BreakGlass(req *models.BreakGlassRequest, userID string) (*models.AccessGrant, error) {
+	if s.breakGlassDuration <= 0 {
+		return nil, ErrBreakGlassDisabled
+	}
+
+	conn, err := s.connectionService.GetConnection(req.ConnectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	grant := &models.AccessGrant{
+		ID:           uuid.New().String(),
+		ConnectionID: conn.ID,
+		UserID:       userID,
+		Scope:        models.AccessScopeFull,
+		Reason:       req.Justification,
+		Emergency:    true,
+		GrantedBy:    userID,
+		StartsAt:     now,
+		ExpiresAt:    now.Add(s.breakGlassDuration),
+	}
+	if err := s.db.Create(grant).Error; err != nil {
+		return nil, fmt.Errorf("failed to create break-glass grant: %w", err)
+	}
+	s.loadEmergencies()
+
+	s.audit.Record(models.AuditEvent{
+		Source:       "access_grant",
+		Action:       "break_glass",
+		Status:       models.AuditEventStatusSuccess,
+		ActorID:      userID,
+		ConnectionID: conn.ID,
+		TargetID:     userID,
+		Message:      req.Justification,
+	})
+	s.notify(grant, "break_glass_access", fmt.Sprintf("was opened as break-glass access until %s. Justification: %s",
+		grant.ExpiresAt.Format(time.RFC3339), req.Justification))
+
+	return grant, nil
+}
+
+// InEmergency reports whether a user currently holds break-glass access to any connection
+func (s *AccessGrantService) InEmergency(userID string) bool {
+	s.mu.RLock()
+	expiresAt, ok := s.emergencies[userID]
+	s.mu.RUnlock()
+	return ok && time.Now().Before(expiresAt)
+}
+
+// loadEmergencies refreshes the cached expiry of active break-glass grants
+func (s *AccessGrantService) loadEmergencies() {
+	now := time.Now().UTC()
+	grants := []models.AccessGrant{}
+	err := s.db.Select("user_id", "expires_at").
+		Where("emergency = ? AND revoked_at IS NULL AND starts_at <= ? AND expires_at > ?", true, now, now).
+		Find(&grants).Error
+	if err != nil {
+		s.logger.Error("failed to load break-glass grants", "error", err)
+		return
+	}
+
+	emergencies := map[string]time.Time{}
+	for _, grant := range grants {
+		if grant.ExpiresAt.After(emergencies[grant.UserID]) {
+			emergencies[grant.UserID] = grant.ExpiresAt
+		}
+	}
+
+	s.mu.Lock()
+	s.emergencies = emergencies
+	s.mu.Unlock()
+}
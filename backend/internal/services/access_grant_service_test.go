@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"truadmin/internal/models"
+)
+
+func TestCheckAccessDeniesWhenLookupFails(t *testing.T) {
+	db := newTestInternalDB(t, &models.Connection{}, &models.AccessGrant{})
+	svc := NewAccessGrantService(nil, nil, nil, 0, nil)
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get internal database: %v", err)
+	}
+	sqlDB.Close()
+
+	err = svc.CheckAccess("user-1", models.RoleUser, "conn-1", http.MethodGet, "/api/v1/connections/:id")
+	if err == nil {
+		t.Fatal("CheckAccess() granted access although the connection lookup failed")
+	}
+	if errors.Is(err, ErrAccessGrantRequired) {
+		t.Fatalf("CheckAccess() error = %v, want the lookup failure", err)
+	}
+}
+
+func TestCheckAccessIgnoresUnknownConnections(t *testing.T) {
+	newTestInternalDB(t, &models.Connection{}, &models.AccessGrant{})
+	svc := NewAccessGrantService(nil, nil, nil, 0, nil)
+
+	if err := svc.CheckAccess("user-1", models.RoleUser, "missing", http.MethodGet, "/api/v1/connections/:id"); err != nil {
+		t.Fatalf("CheckAccess() error = %v for an unknown connection", err)
+	}
+}
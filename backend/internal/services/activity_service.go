@@ -0,0 +1,231 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// activityQueueSize is the number of activity events buffered for writing before new events are dropped
+const activityQueueSize = 1000
+
+// activityBatchSize is the maximum number of activity events inserted in one statement
+const activityBatchSize = 100
+
+// activityTopN is the number of connections and actions listed in an activity summary
+const activityTopN = 10
+
+// ActivityService records sign-ins and API calls per user and summarizes them.
+// Events are written in the background so recording never delays the request.
+type ActivityService struct {
+	db        *gorm.DB
+	retention time.Duration
+	queue     chan *models.UserActivityEvent
+	wg        sync.WaitGroup
+	once      sync.Once
+	logger    *slog.Logger
+}
+
+// NewActivityService creates a new activity service and starts its writer.
+// Events older than retention are removed by Prune.
+func NewActivityService(retention time.Duration, logger *slog.Logger) *ActivityService {
+	s := &ActivityService{
+		db:        database.GetDB(),
+		retention: retention,
+		queue:     make(chan *models.UserActivityEvent, activityQueueSize),
+		logger:    logging.OrDefault(logger),
+	}
+
+	s.wg.Add(1)
+	go s.run()
+
+	return s
+}
+
+// Record queues an activity event; it never blocks the caller
+func (s *ActivityService) Record(event models.UserActivityEvent) {
+	if s == nil || s.db == nil || event.UserID == "" {
+		return
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	select {
+	case s.queue <- &event:
+	default:
+		s.logger.Warn("activity queue is full, dropping event", "action", event.Action)
+	}
+}
+
+// RecordLogin records a successful sign-in
+func (s *ActivityService) RecordLogin(userID string) {
+	s.Record(models.UserActivityEvent{UserID: userID, Action: models.ActivityActionLogin})
+}
+
+// Close writes queued events and stops the writer
+func (s *ActivityService) Close() {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		close(s.queue)
+		s.wg.Wait()
+	})
+}
+
+// run writes queued events in batches until the queue is closed
+func (s *ActivityService) run() {
+	defer s.wg.Done()
+	for event := range s.queue {
+		batch := []*models.UserActivityEvent{event}
+	drain:
+		for len(batch) < activityBatchSize {
+			select {
+			case next, ok := <-s.queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+
+		if err := s.db.Create(&batch).Error; err != nil {
+			s.logger.Error("failed to write activity events", "count", len(batch), "error", err)
+		}
+		s.recordConnectionUsage(batch)
+	}
+}
+
+// recordConnectionUsage adds the calls of a batch to the usage counters of their connections.
+// Rejected calls (denied or unknown connection) are not counted as use.
+func (s *ActivityService) recordConnectionUsage(batch []*models.UserActivityEvent) {
+	type usage struct {
+		count    int64
+		lastAt   time.Time
+		lastUser string
+	}
+	usages := map[string]*usage{}
+	for _, event := range batch {
+		if event.ConnectionID == "" || event.StatusCode == http.StatusForbidden || event.StatusCode == http.StatusNotFound {
+			continue
+		}
+		u, ok := usages[event.ConnectionID]
+		if !ok {
+			u = &usage{}
+			usages[event.ConnectionID] = u
+		}
+		u.count++
+		if !event.CreatedAt.Before(u.lastAt) {
+			u.lastAt = event.CreatedAt
+			u.lastUser = event.UserID
+		}
+	}
+
+	for connectionID, u := range usages {
+		err := s.db.Exec(`UPDATE connections SET
+				usage_count = COALESCE(usage_count, 0) + ?,
+				last_used_by = CASE WHEN last_used_at IS NULL OR last_used_at <= ? THEN ? ELSE last_used_by END,
+				last_used_at = GREATEST(COALESCE(last_used_at, ?), ?)
+			WHERE id = ?`,
+			u.count, u.lastAt, u.lastUser, u.lastAt, u.lastAt, connectionID).Error
+		if err != nil {
+			s.logger.Error("failed to update connection usage", "connection_id", connectionID, "error", err)
+		}
+	}
+}
+
+// GetSummary returns the activity summary of a user over the last days
+func (s *ActivityService) GetSummary(userID string, days int) (*models.UserActivitySummary, error) {
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	from := time.Now().UTC().AddDate(0, 0, -days)
+	summary := &models.UserActivitySummary{
+		UserID:         user.ID,
+		Username:       user.Username,
+		From:           from,
+		TopConnections: []models.ConnectionUsage{},
+		TopActions:     []models.ActionUsage{},
+	}
+
+	var totals struct {
+		LastLoginAt  *time.Time
+		LastActiveAt *time.Time
+		LoginCount   int64
+		RequestCount int64
+	}
+	err := s.db.Model(&models.UserActivityEvent{}).
+		Select(`MAX(CASE WHEN action = ? THEN created_at END) AS last_login_at,
+			MAX(CASE WHEN action <> ? THEN created_at END) AS last_active_at,
+			COUNT(CASE WHEN action = ? AND created_at >= ? THEN 1 END) AS login_count,
+			COUNT(CASE WHEN action <> ? AND created_at >= ? THEN 1 END) AS request_count`,
+			models.ActivityActionLogin, models.ActivityActionLogin,
+			models.ActivityActionLogin, from, models.ActivityActionLogin, from).
+		Where("user_id = ?", userID).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity totals: %w", err)
+	}
+	summary.LastLoginAt = totals.LastLoginAt
+	summary.LastActiveAt = totals.LastActiveAt
+	summary.LoginCount = totals.LoginCount
+	summary.RequestCount = totals.RequestCount
+
+	err = s.db.Table("user_activity_events e").
+		Select("e.connection_id, COALESCE(c.name, '') AS connection_name, COUNT(*) AS requests, MAX(e.created_at) AS last_used_at").
+		Joins("LEFT JOIN connections c ON c.id = e.connection_id").
+		Where("e.user_id = ? AND e.created_at >= ? AND e.connection_id <> ''", userID, from).
+		Group("e.connection_id, c.name").
+		Order("requests DESC").
+		Limit(activityTopN).
+		Scan(&summary.TopConnections).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection usage: %w", err)
+	}
+
+	err = s.db.Model(&models.UserActivityEvent{}).
+		Select("action, COUNT(*) AS count").
+		Where("user_id = ? AND created_at >= ? AND action <> ?", userID, from, models.ActivityActionLogin).
+		Group("action").
+		Order("count DESC").
+		Limit(activityTopN).
+		Scan(&summary.TopActions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get action usage: %w", err)
+	}
+
+	return summary, nil
+}
+
+// Prune removes activity events older than the retention period; it is registered as the activity_pruning job type
+func (s *ActivityService) Prune() error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	result := s.db.Where("created_at < ?", time.Now().UTC().Add(-s.retention)).Delete(&models.UserActivityEvent{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to prune activity events: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		s.logger.Info("pruned activity events", "count", result.RowsAffected, "retention", s.retention)
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// ErrAddressCheckQuotaExceeded is returned when a caller has used up its daily address check quota
+var ErrAddressCheckQuotaExceeded = errors.New("daily address check quota exceeded")
+
+// Limits of an address check usage report
+const (
+	addressCheckMaxReportDays     = 90
+	addressCheckMaxRecentFailures = 100
+	addressCheckMaxErrorLength    = 1000
+)
+
+// AddressCheckUsageService enforces daily quotas on the gRPC address check and records its usage,
+// latency and failures per caller. Unlike UsageService, calls are counted in the internal database
+// as they happen so that every server instance sees the same quota.
+type AddressCheckUsageService struct {
+	db            *gorm.DB
+	dailyQuota    int
+	callerQuotas  map[string]int
+	sloLatency    time.Duration
+	sloTarget     float64
+	retention     time.Duration
+	emptyBuckets  pq.Int64Array
+	bucketsLength int
+	logger        *slog.Logger
+}
+
+// NewAddressCheckUsageService creates a new address check usage service. dailyQuota applies to
+// callers without an entry in callerQuotas; zero is unlimited.
+func NewAddressCheckUsageService(dailyQuota int, callerQuotas map[string]int, sloLatency time.Duration, sloTarget float64, retention time.Duration, logger *slog.Logger) *AddressCheckUsageService {
+	if sloLatency <= 0 {
+		sloLatency = 500 * time.Millisecond
+	}
+	if sloTarget <= 0 || sloTarget > 1 {
+		sloTarget = 0.99
+	}
+	if callerQuotas == nil {
+		callerQuotas = map[string]int{}
+	}
+	bucketsLength := len(models.AddressCheckLatencyBucketsMs) + 1
+	return &AddressCheckUsageService{
+		db:            database.GetDB(),
+		dailyQuota:    dailyQuota,
+		callerQuotas:  callerQuotas,
+		sloLatency:    sloLatency,
+		sloTarget:     sloTarget,
+		retention:     retention,
+		emptyBuckets:  make(pq.Int64Array, bucketsLength),
+		bucketsLength: bucketsLength,
+		logger:        logging.OrDefault(logger),
+	}
+}
+
+// quota returns the daily quota of a caller; zero is unlimited
+func (s *AddressCheckUsageService) quota(caller string) int {
+	if quota, ok := s.callerQuotas[caller]; ok {
+		return quota
+	}
+	return s.dailyQuota
+}
+
+// Reserve counts a call against the caller's quota for today, or returns ErrAddressCheckQuotaExceeded
+// and records the rejection. Calls are let through when the internal database is unreachable.
+func (s *AddressCheckUsageService) Reserve(caller string) error {
+	if s == nil || s.db == nil || !database.IsConnected() {
+		return nil
+	}
+
+	day := time.Now().UTC().Format("2006-01-02")
+	quota := s.quota(caller)
+
+	query := `INSERT INTO address_check_usage (day, caller, requests, latency_buckets)
+		VALUES (?, ?, 1, ?)
+		ON CONFLICT (day, caller) DO UPDATE SET requests = address_check_usage.requests + 1`
+	args := []interface{}{day, caller, s.emptyBuckets}
+	if quota > 0 {
+		query += ` WHERE address_check_usage.requests < ?`
+		args = append(args, quota)
+	}
+	result := s.db.Exec(query, args...)
+	if result.Error != nil {
+		s.logger.Error("failed to count address check", "caller", caller, "error", result.Error)
+		return nil
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	err := fmt.Errorf("%w: %d calls per day", ErrAddressCheckQuotaExceeded, quota)
+	if dbErr := s.db.Exec(`UPDATE address_check_usage SET rejected = rejected + 1 WHERE day = ? AND caller = ?`, day, caller).Error; dbErr != nil {
+		s.logger.Error("failed to count rejected address check", "caller", caller, "error", dbErr)
+	}
+	s.recordFailure(caller, "ResourceExhausted", err, "", 0)
+	return err
+}
+
+// Record adds the outcome of a reserved call to the caller's usage. code is the gRPC status code
+// of a failed call and callErr its error; both are empty for a successful call.
+func (s *AddressCheckUsageService) Record(caller, databaseID string, started time.Time, code string, callErr error) {
+	if s == nil || s.db == nil || !database.IsConnected() {
+		return
+	}
+
+	duration := time.Since(started)
+	durationMs := duration.Milliseconds()
+	bucket := len(models.AddressCheckLatencyBucketsMs)
+	for i, bound := range models.AddressCheckLatencyBucketsMs {
+		if durationMs <= bound {
+			bucket = i
+			break
+		}
+	}
+	var failures, withinSLO int64
+	if callErr != nil {
+		failures = 1
+	}
+	if duration <= s.sloLatency {
+		withinSLO = 1
+	}
+
+	// Postgres arrays are 1-based
+	err := s.db.Exec(`UPDATE address_check_usage SET
+			failures = failures + ?,
+			within_slo = within_slo + ?,
+			duration_ms = duration_ms + ?,
+			max_duration_ms = GREATEST(max_duration_ms, ?),
+			latency_buckets[?] = COALESCE(latency_buckets[?], 0) + 1
+		WHERE day = ? AND caller = ?`,
+		failures, withinSLO, durationMs, durationMs, bucket+1, bucket+1,
+		started.UTC().Format("2006-01-02"), caller).Error
+	if err != nil {
+		s.logger.Error("failed to record address check", "caller", caller, "error", err)
+	}
+
+	if callErr != nil {
+		s.recordFailure(caller, code, callErr, databaseID, durationMs)
+	}
+}
+
+// recordFailure stores a failed or rejected call for the caller's recent failures
+func (s *AddressCheckUsageService) recordFailure(caller, code string, callErr error, databaseID string, durationMs int64) {
+	message := callErr.Error()
+	if len(message) > addressCheckMaxErrorLength {
+		message = message[:addressCheckMaxErrorLength]
+	}
+	failure := &models.AddressCheckFailure{
+		ID:         uuid.New().String(),
+		Caller:     caller,
+		Code:       code,
+		Error:      message,
+		DatabaseID: databaseID,
+		DurationMs: durationMs,
+	}
+	if err := s.db.Create(failure).Error; err != nil {
+		s.logger.Error("failed to record address check failure", "caller", caller, "error", err)
+	}
+}
+
+// GetReport returns a caller's usage over the last days with its SLO compliance and recent failures
+func (s *AddressCheckUsageService) GetReport(caller string, days, failures int) (*models.AddressCheckUsageReport, error) {
+	if s.db == nil || !database.IsConnected() {
+		return nil, fmt.Errorf("database is not connected")
+	}
+	if days <= 0 {
+		days = 7
+	}
+	if days > addressCheckMaxReportDays {
+		days = addressCheckMaxReportDays
+	}
+	if failures <= 0 {
+		failures = 20
+	}
+	if failures > addressCheckMaxRecentFailures {
+		failures = addressCheckMaxRecentFailures
+	}
+
+	from, to := usageWindow(days)
+	report := &models.AddressCheckUsageReport{
+		Caller:         caller,
+		DailyQuota:     int64(s.quota(caller)),
+		From:           from,
+		To:             to,
+		Daily:          []models.AddressCheckUsage{},
+		RecentFailures: []models.AddressCheckFailure{},
+	}
+
+	if err := s.db.Where("caller = ? AND day >= ? AND day <= ?", caller, from, to).Order("day").Find(&report.Daily).Error; err != nil {
+		return nil, fmt.Errorf("failed to get address check usage: %w", err)
+	}
+	if err := s.db.Where("caller = ?", caller).Order("created_at DESC").Limit(failures).Find(&report.RecentFailures).Error; err != nil {
+		return nil, fmt.Errorf("failed to get address check failures: %w", err)
+	}
+
+	for _, day := range report.Daily {
+		if day.Day.Equal(to) {
+			report.UsedToday = day.Requests
+		}
+	}
+	if report.DailyQuota > 0 {
+		remaining := report.DailyQuota - report.UsedToday
+		if remaining < 0 {
+			remaining = 0
+		}
+		report.RemainingToday = &remaining
+	}
+
+	report.SLO = s.slo(report.Daily)
+	return report, nil
+}
+
+// slo computes SLO compliance and latency percentiles over the daily counters
+func (s *AddressCheckUsageService) slo(daily []models.AddressCheckUsage) models.AddressCheckSLO {
+	slo := models.AddressCheckSLO{
+		LatencyThresholdMs: s.sloLatency.Milliseconds(),
+		Target:             s.sloTarget,
+		Compliance:         1,
+	}
+
+	var requests, failures, withinSLO, durationMs, maxDurationMs int64
+	buckets := make([]int64, s.bucketsLength)
+	for _, day := range daily {
+		requests += day.Requests
+		failures += day.Failures
+		withinSLO += day.WithinSLO
+		durationMs += day.DurationMs
+		if day.MaxDurationMs > maxDurationMs {
+			maxDurationMs = day.MaxDurationMs
+		}
+		for i, count := range day.LatencyBuckets {
+			if i < len(buckets) {
+				buckets[i] += count
+			}
+		}
+	}
+
+	if requests > 0 {
+		slo.Compliance = float64(withinSLO) / float64(requests)
+		slo.ErrorRate = float64(failures) / float64(requests)
+		slo.AvgDurationMs = averageMs(durationMs, requests)
+	}
+	slo.Met = slo.Compliance >= s.sloTarget
+	slo.P50Ms = latencyPercentile(buckets, 0.50, maxDurationMs)
+	slo.P95Ms = latencyPercentile(buckets, 0.95, maxDurationMs)
+	slo.P99Ms = latencyPercentile(buckets, 0.99, maxDurationMs)
+	return slo
+}
+
+// latencyPercentile returns the upper bound of the histogram bucket holding the percentile,
+// or the slowest call when it falls in the unbounded last bucket
+func latencyPercentile(buckets []int64, percentile float64, maxDurationMs int64) int64 {
+	var total int64
+	for _, count := range buckets {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := int64(percentile*float64(total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, count := range buckets {
+		seen += count
+		if seen >= rank {
+			if i < len(models.AddressCheckLatencyBucketsMs) {
+				return models.AddressCheckLatencyBucketsMs[i]
+			}
+			break
+		}
+	}
+	return maxDurationMs
+}
+
+// Prune removes usage and failures older than the retention period; it is registered as the
+// address_check_usage_pruning job type
+func (s *AddressCheckUsageService) Prune() error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	cutoff := time.Now().UTC().Add(-s.retention)
+	if err := s.db.Where("day < ?", cutoff.Format("2006-01-02")).Delete(&models.AddressCheckUsage{}).Error; err != nil {
+		return fmt.Errorf("failed to prune address check usage: %w", err)
+	}
+	if err := s.db.Where("created_at < ?", cutoff).Delete(&models.AddressCheckFailure{}).Error; err != nil {
+		return fmt.Errorf("failed to prune address check failures: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// maxAlertSilenceDuration caps how long a single silence can last
+const maxAlertSilenceDuration = 30 * 24 * time.Hour
+
+// ErrInvalidAlertSilence is returned when a silence request fails validation
+var ErrInvalidAlertSilence = errors.New("invalid alert silence")
+
+// AlertSilenceService handles time-boxed silences of connection alert rules
+type AlertSilenceService struct {
+	db                *gorm.DB
+	connectionService *ConnectionService
+	logger            *slog.Logger
+}
+
+// NewAlertSilenceService creates a new alert silence service
+func NewAlertSilenceService(connectionService *ConnectionService, logger *slog.Logger) *AlertSilenceService {
+	return &AlertSilenceService{
+		db:                database.GetDB(),
+		connectionService: connectionService,
+		logger:            logging.OrDefault(logger),
+	}
+}
+
+// CreateSilence silences an alert rule of a connection until the requested end
+func (s *AlertSilenceService) CreateSilence(connectionID, userID string, req *models.AlertSilenceRequest) (*models.AlertSilence, error) {
+	if !slices.Contains(models.AlertRules, req.Rule) {
+		return nil, fmt.Errorf("%w: unknown rule %q", ErrInvalidAlertSilence, req.Rule)
+	}
+
+	startsAt := time.Now().UTC()
+	if req.StartsAt != nil {
+		startsAt = req.StartsAt.UTC()
+	}
+	endsAt := req.EndsAt.UTC()
+	if !endsAt.After(startsAt) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidAlertSilence)
+	}
+	if endsAt.Sub(startsAt) > maxAlertSilenceDuration {
+		return nil, fmt.Errorf("%w: a silence must not last longer than %s", ErrInvalidAlertSilence, maxAlertSilenceDuration)
+	}
+
+	if _, err := s.connectionService.GetConnection(connectionID); err != nil {
+		return nil, err
+	}
+
+	silence := &models.AlertSilence{
+		ID:           uuid.New().String(),
+		ConnectionID: connectionID,
+		Rule:         req.Rule,
+		Reason:       req.Reason,
+		StartsAt:     startsAt,
+		EndsAt:       endsAt,
+		CreatedBy:    userID,
+	}
+	if err := s.db.Create(silence).Error; err != nil {
+		return nil, fmt.Errorf("failed to create alert silence: %w", err)
+	}
+	return silence, nil
+}
+
+// GetSilences returns the silences of a connection, newest first; expired ones only when requested
+func (s *AlertSilenceService) GetSilences(connectionID string, includeExpired bool) (*models.AlertSilenceList, error) {
+	silences := []models.AlertSilence{}
+
+	query := s.db.Where("connection_id = ?", connectionID)
+	if !includeExpired {
+		query = query.Where("ends_at > ?", time.Now().UTC())
+	}
+	if err := query.Order("created_at DESC").Find(&silences).Error; err != nil {
+		return nil, fmt.Errorf("failed to get alert silences: %w", err)
+	}
+
+	return &models.AlertSilenceList{Silences: silences, Rules: models.AlertRules}, nil
+}
+
+// ExpireSilence ends a silence immediately
+func (s *AlertSilenceService) ExpireSilence(connectionID, id, userID string) (*models.AlertSilence, error) {
+	var silence models.AlertSilence
+	if err := s.db.First(&silence, "id = ? AND connection_id = ?", id, connectionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("alert silence not found")
+		}
+		return nil, fmt.Errorf("failed to get alert silence: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if !silence.EndsAt.After(now) {
+		return &silence, nil
+	}
+
+	silence.ExpiredAt = &now
+	silence.ExpiredBy = userID
+	silence.EndsAt = now
+	if err := s.db.Save(&silence).Error; err != nil {
+		return nil, fmt.Errorf("failed to expire alert silence: %w", err)
+	}
+	return &silence, nil
+}
+
+// IsSilenced reports whether a rule of a connection is silenced right now
+func (s *AlertSilenceService) IsSilenced(connectionID, rule string) bool {
+	if s.db == nil {
+		return false
+	}
+
+	now := time.Now().UTC()
+	var count int64
+	err := s.db.Model(&models.AlertSilence{}).
+		Where("connection_id = ? AND rule IN ? AND starts_at <= ? AND ends_at > ?",
+			connectionID, []string{rule, models.AlertRuleAll}, now, now).
+		Count(&count).Error
+	if err != nil {
+		// Deliver the alert when silences cannot be checked
+		s.logger.Error("failed to check alert silences", "connection_id", connectionID, "rule", rule, "error", err)
+		return false
+	}
+	return count > 0
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// AnnotationService handles notes attached to the monitoring timeline
+type AnnotationService struct {
+	db *gorm.DB
+}
+
+// NewAnnotationService creates a new annotation service
+func NewAnnotationService() *AnnotationService {
+	return &AnnotationService{
+		db: database.GetDB(),
+	}
+}
+
+// CreateAnnotation adds a note to a connection's timeline
+func (s *AnnotationService) CreateAnnotation(connectionID, userID, username string, req *models.AnnotationRequest) (*models.Annotation, error) {
+	occurredAt := time.Now()
+	if req.OccurredAt != nil {
+		occurredAt = *req.OccurredAt
+	}
+
+	annotation := &models.Annotation{
+		ID:           uuid.New().String(),
+		ConnectionID: connectionID,
+		DatabaseName: req.DatabaseName,
+		UserID:       userID,
+		Username:     username,
+		OccurredAt:   occurredAt,
+		Text:         req.Text,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.db.Create(annotation).Error; err != nil {
+		return nil, fmt.Errorf("failed to create annotation: %w", err)
+	}
+
+	return annotation, nil
+}
+
+// GetAnnotations returns annotations of a connection within [from, to].
+// Annotations without a database apply to every database of the connection.
+func (s *AnnotationService) GetAnnotations(connectionID, dbName string, from, to time.Time) ([]models.Annotation, error) {
+	annotations := []models.Annotation{}
+
+	query := s.db.Where("connection_id = ? AND occurred_at BETWEEN ? AND ?", connectionID, from, to)
+	if dbName != "" {
+		query = query.Where("database_name = ? OR database_name = ''", dbName)
+	}
+
+	if err := query.Order("occurred_at ASC").Find(&annotations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get annotations: %w", err)
+	}
+
+	return annotations, nil
+}
+
+// DeleteAnnotation removes an annotation; only its author or an admin may delete it
+func (s *AnnotationService) DeleteAnnotation(connectionID, id, userID string, isAdmin bool) error {
+	var annotation models.Annotation
+	if err := s.db.First(&annotation, "id = ? AND connection_id = ?", id, connectionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("annotation not found")
+		}
+		return fmt.Errorf("failed to get annotation: %w", err)
+	}
+
+	if annotation.UserID != userID && !isAdmin {
+		return fmt.Errorf("only the author can delete this annotation")
+	}
+
+	if err := s.db.Delete(&annotation).Error; err != nil {
+		return fmt.Errorf("failed to delete annotation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// ErrInvalidAnnouncementWindow is returned when an announcement ends before it starts
+var ErrInvalidAnnouncementWindow = errors.New("ends_at must be after starts_at")
+
+// AnnouncementService handles in-app announcement banners
+type AnnouncementService struct {
+	db *gorm.DB
+}
+
+// NewAnnouncementService creates a new announcement service
+func NewAnnouncementService() *AnnouncementService {
+	return &AnnouncementService{
+		db: database.GetDB(),
+	}
+}
+
+// CreateAnnouncement adds an announcement
+func (s *AnnouncementService) CreateAnnouncement(req *models.AnnouncementRequest, userID string) (*models.Announcement, error) {
+	announcement := &models.Announcement{
+		ID:        uuid.New().String(),
+		CreatedBy: userID,
+	}
+	if err := applyAnnouncementRequest(announcement, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(announcement).Error; err != nil {
+		return nil, fmt.Errorf("failed to create announcement: %w", err)
+	}
+	return announcement, nil
+}
+
+// GetAnnouncements returns all announcements, newest first
+func (s *AnnouncementService) GetAnnouncements(page Page) ([]models.Announcement, int64, error) {
+	announcements := []models.Announcement{}
+	total, err := findPage(s.db.Model(&models.Announcement{}).Order("created_at DESC"), page, &announcements)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get announcements: %w", err)
+	}
+	return announcements, total, nil
+}
+
+// GetActiveAnnouncements returns the announcements currently visible to a user with the given role,
+// most severe first
+func (s *AnnouncementService) GetActiveAnnouncements(role models.UserRole) ([]models.Announcement, error) {
+	audiences := []models.AnnouncementAudience{models.AudienceAll, models.AudienceUsers}
+	if role == models.RoleAdmin {
+		audiences = []models.AnnouncementAudience{models.AudienceAll, models.AudienceAdmins}
+	}
+
+	now := time.Now()
+	announcements := []models.Announcement{}
+	err := s.db.
+		Where("audience IN ?", audiences).
+		Where("starts_at IS NULL OR starts_at <= ?", now).
+		Where("ends_at IS NULL OR ends_at > ?", now).
+		Order("CASE severity WHEN 'critical' THEN 0 WHEN 'warning' THEN 1 ELSE 2 END, created_at DESC").
+		Find(&announcements).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get announcements: %w", err)
+	}
+	return announcements, nil
+}
+
+// UpdateAnnouncement replaces the message, severity, audience and window of an announcement
+func (s *AnnouncementService) UpdateAnnouncement(id string, req *models.AnnouncementRequest) (*models.Announcement, error) {
+	var announcement models.Announcement
+	if err := s.db.First(&announcement, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("announcement not found")
+		}
+		return nil, fmt.Errorf("failed to get announcement: %w", err)
+	}
+
+	if err := applyAnnouncementRequest(&announcement, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(&announcement).Error; err != nil {
+		return nil, fmt.Errorf("failed to update announcement: %w", err)
+	}
+	return &announcement, nil
+}
+
+// DeleteAnnouncement removes an announcement
+func (s *AnnouncementService) DeleteAnnouncement(id string) error {
+	result := s.db.Delete(&models.Announcement{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete announcement: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("announcement not found")
+	}
+	return nil
+}
+
+// applyAnnouncementRequest validates a request and copies it onto an announcement
+func applyAnnouncementRequest(announcement *models.Announcement, req *models.AnnouncementRequest) error {
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return ErrInvalidAnnouncementWindow
+	}
+
+	announcement.Message = req.Message
+	announcement.Severity = req.Severity
+	if announcement.Severity == "" {
+		announcement.Severity = models.AnnouncementInfo
+	}
+	announcement.Audience = req.Audience
+	if announcement.Audience == "" {
+		announcement.Audience = models.AudienceAll
+	}
+	announcement.StartsAt = req.StartsAt
+	announcement.EndsAt = req.EndsAt
+	return nil
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+var (
+	// ErrInvalidAPIKey is returned when an API key request is invalid
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrAPIKeyUnauthorized is returned for unknown, revoked or expired API keys
+	ErrAPIKeyUnauthorized = errors.New("API key is not valid")
+	// ErrAPIKeyRateLimited is returned when an API key exceeds its rate limit
+	ErrAPIKeyRateLimited = errors.New("API key rate limit exceeded, try again in a minute")
+)
+
+// apiKeyPrefix marks API keys so they are recognizable when pasted or leaked
+const apiKeyPrefix = "tak_"
+
+// apiKeyRateWindow is the window of the API key rate limits
+const apiKeyRateWindow = time.Minute
+
+// APIKeyService issues API keys and authenticates the requests made with them. Each key acts as
+// one user, limited to its scopes and rate limited per key.
+type APIKeyService struct {
+	db               *gorm.DB
+	defaultRateLimit int
+	store            SharedStore // Rate limit counters, shared by the replicas when it is Redis
+	logger           *slog.Logger
+}
+
+// NewAPIKeyService creates a new API key service. defaultRateLimit applies to keys without their
+// own limit, in requests per minute; zero disables it.
+func NewAPIKeyService(store SharedStore, defaultRateLimit int, logger *slog.Logger) *APIKeyService {
+	return &APIKeyService{
+		db:               database.GetDB(),
+		defaultRateLimit: defaultRateLimit,
+		store:            store,
+		logger:           logging.OrDefault(logger),
+	}
+}
+
+// GetKeys returns all API keys, newest first
+func (s *APIKeyService) GetKeys() ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	if err := s.db.Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// CreateKey issues an API key; the key is only returned here
+func (s *APIKeyService) CreateKey(req *models.APIKeyRequest, userID string) (*models.IssuedAPIKey, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	}
+	if req.RateLimitPerMinute < 0 {
+		return nil, fmt.Errorf("%w: rate_limit_per_minute must not be negative", ErrInvalidAPIKey)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIKey)
+	}
+
+	scopes := models.StringList{}
+	for _, scope := range req.Scopes {
+		if !models.IsAPIKeyScope(scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKey, scope)
+		}
+		scopes = append(scopes, scope)
+	}
+
+	keyUserID := req.UserID
+	if keyUserID == "" {
+		keyUserID = userID
+	}
+	var count int64
+	if err := s.db.Model(&models.User{}).Where("id = ?", keyUserID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := apiKeyPrefix + hex.EncodeToString(secret)
+
+	apiKey := models.APIKey{
+		ID:                 uuid.New().String(),
+		Name:               name,
+		UserID:             keyUserID,
+		KeyHash:            hashAPIKey(key),
+		KeyPrefix:          key[:len(apiKeyPrefix)+8],
+		Scopes:             scopes,
+		RateLimitPerMinute: req.RateLimitPerMinute,
+		CreatedBy:          userID,
+		ExpiresAt:          req.ExpiresAt,
+	}
+	if err := s.db.Create(&apiKey).Error; err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return &models.IssuedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// RevokeKey revokes an API key; requests made with it fail from then on
+func (s *APIKeyService) RevokeKey(id string) error {
+	result := s.db.Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke API key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := s.db.Model(&models.APIKey{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to revoke API key: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("API key not found")
+		}
+	}
+	return nil
+}
+
+// Authenticate returns the active API key for a key sent with a request, counting the request
+// against the key's rate limit
+func (s *APIKeyService) Authenticate(key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, ErrAPIKeyUnauthorized
+	}
+
+	var apiKey models.APIKey
+	if err := s.db.First(&apiKey, "key_hash = ?", hashAPIKey(key)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyUnauthorized
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now())) {
+		return nil, ErrAPIKeyUnauthorized
+	}
+
+	now := time.Now()
+	limit := apiKey.RateLimitPerMinute
+	if limit == 0 {
+		limit = s.defaultRateLimit
+	}
+	if !s.allow(apiKey.ID, limit, now) {
+		return nil, ErrAPIKeyRateLimited
+	}
+
+	s.db.Model(&apiKey).UpdateColumn("last_used_at", now.UTC())
+	return &apiKey, nil
+}
+
+// CheckKey returns ErrAPIKeyUnauthorized once an API key accepted earlier has been revoked or has
+// expired; unlike Authenticate it does not count a request
+func (s *APIKeyService) CheckKey(id string) error {
+	var apiKey models.APIKey
+	if err := s.db.First(&apiKey, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIKeyUnauthorized
+		}
+		return fmt.Errorf("failed to get API key: %w", err)
+	}
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now())) {
+		return ErrAPIKeyUnauthorized
+	}
+	return nil
+}
+
+// hashAPIKey returns the stored form of an API key
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// allow counts a request of a key in the current one-minute window and reports whether it is
+// within limit; a limit of zero allows everything. When the store fails the request is allowed.
+func (s *APIKeyService) allow(keyID string, limit int, now time.Time) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	window := now.Truncate(apiKeyRateWindow).Unix()
+	count, err := s.store.Incr(fmt.Sprintf("ratelimit:apikey:%s:%d", keyID, window), apiKeyRateWindow)
+	if err != nil {
+		s.logger.Warn("API key rate limit unavailable", "api_key_id", keyID, "error", err)
+		return true
+	}
+	return count <= int64(limit)
+}
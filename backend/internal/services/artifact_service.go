@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// ErrArtifactForbidden is returned when a user may not access an artifact
+var ErrArtifactForbidden = errors.New("access to artifact denied")
+
+// ArtifactService keeps backups, exports and snapshots in the configured storage backend,
+// hands out signed download links and removes artifacts once their retention has passed
+type ArtifactService struct {
+	db        *gorm.DB
+	storage   ArtifactStorage
+	retention time.Duration
+	urlTTL    time.Duration
+	logger    *slog.Logger
+}
+
+// NewArtifactService creates a new artifact service; a zero retention keeps artifacts until they are deleted
+func NewArtifactService(storage ArtifactStorage, retention, urlTTL time.Duration, logger *slog.Logger) *ArtifactService {
+	return &ArtifactService{
+		db:        database.GetDB(),
+		storage:   storage,
+		retention: retention,
+		urlTTL:    urlTTL,
+		logger:    logging.OrDefault(logger),
+	}
+}
+
+// Store writes an artifact to the storage backend and records it. size may be -1 when unknown
+// for the local backend; the S3 backend requires it.
+func (s *ArtifactService) Store(ctx context.Context, kind models.ArtifactKind, name, ownerID, contentType string, body io.Reader, size int64) (*models.Artifact, error) {
+	switch kind {
+	case models.ArtifactBackup, models.ArtifactExport, models.ArtifactSnapshot:
+	default:
+		return nil, fmt.Errorf("invalid artifact kind %q", kind)
+	}
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "" || name == "." || name == "/" {
+		return nil, fmt.Errorf("artifact name is required")
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	now := time.Now().UTC()
+	artifact := &models.Artifact{
+		ID:          uuid.New().String(),
+		Kind:        kind,
+		Name:        name,
+		Backend:     s.storage.Name(),
+		ContentType: contentType,
+		OwnerID:     ownerID,
+		CreatedAt:   now,
+	}
+	artifact.StorageKey = fmt.Sprintf("%s/%s/%s/%s", kind, now.Format("2006/01/02"), artifact.ID, name)
+	if s.retention > 0 {
+		expiresAt := now.Add(s.retention)
+		artifact.ExpiresAt = &expiresAt
+	}
+
+	counter := &countingReader{r: body}
+	if err := s.storage.Put(ctx, artifact.StorageKey, counter, size, contentType); err != nil {
+		return nil, err
+	}
+	artifact.SizeBytes = counter.n
+
+	if err := s.db.Create(artifact).Error; err != nil {
+		s.storage.Delete(context.Background(), artifact.StorageKey)
+		return nil, fmt.Errorf("failed to create artifact: %w", err)
+	}
+
+	return artifact, nil
+}
+
+// GetArtifacts returns the artifacts the user owns, or all artifacts for admins, optionally of one kind
+func (s *ArtifactService) GetArtifacts(userID string, isAdmin bool, kind string) ([]models.Artifact, error) {
+	query := s.db.Order("created_at DESC")
+	if !isAdmin {
+		query = query.Where("owner_id = ?", userID)
+	}
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var artifacts []models.Artifact
+	if err := query.Find(&artifacts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get artifacts: %w", err)
+	}
+	return artifacts, nil
+}
+
+// GetArtifact returns an artifact the user owns
+func (s *ArtifactService) GetArtifact(id, userID string, isAdmin bool) (*models.Artifact, error) {
+	var artifact models.Artifact
+	if err := s.db.First(&artifact, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("artifact not found")
+		}
+		return nil, fmt.Errorf("failed to get artifact: %w", err)
+	}
+
+	if artifact.OwnerID != userID && !isAdmin {
+		return nil, ErrArtifactForbidden
+	}
+	return &artifact, nil
+}
+
+// DownloadURL returns a signed link that downloads the artifact without authentication until it expires
+func (s *ArtifactService) DownloadURL(id, userID string, isAdmin bool) (*models.ArtifactDownload, error) {
+	artifact, err := s.GetArtifact(id, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if artifact.Backend != s.storage.Name() {
+		return nil, fmt.Errorf("artifact is stored in the %s backend, but %s is configured", artifact.Backend, s.storage.Name())
+	}
+
+	expiresAt := time.Now().UTC().Add(s.urlTTL)
+	url, err := s.storage.SignedURL(artifact.StorageKey, artifact.Name, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign download link: %w", err)
+	}
+
+	return &models.ArtifactDownload{URL: url, ExpiresAt: expiresAt}, nil
+}
+
+// OpenSigned checks a download link of the local backend and opens the artifact it points to.
+// S3 links are served by the bucket itself and never reach this method.
+func (s *ArtifactService) OpenSigned(ctx context.Context, key, filename, expires, signature string) (io.ReadCloser, error) {
+	local, ok := s.storage.(*LocalArtifactStorage)
+	if !ok {
+		return nil, ErrInvalidArtifactSignature
+	}
+	if err := local.Verify(key, filename, expires, signature, time.Now()); err != nil {
+		return nil, err
+	}
+	return local.Open(ctx, key)
+}
+
+// DeleteArtifact removes an artifact the user owns from storage and the catalog
+func (s *ArtifactService) DeleteArtifact(id, userID string, isAdmin bool) error {
+	artifact, err := s.GetArtifact(id, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	return s.remove(artifact)
+}
+
+// PruneExpired removes artifacts whose expiry has passed
+func (s *ArtifactService) PruneExpired() error {
+	var expired []models.Artifact
+	if err := s.db.Where("expires_at IS NOT NULL AND expires_at < ?", time.Now().UTC()).Find(&expired).Error; err != nil {
+		return fmt.Errorf("failed to get expired artifacts: %w", err)
+	}
+
+	failed := 0
+	for i := range expired {
+		if err := s.remove(&expired[i]); err != nil {
+			s.logger.Warn("failed to remove expired artifact", "artifact_id", expired[i].ID, "error", err)
+			failed++
+		}
+	}
+	if removed := len(expired) - failed; removed > 0 {
+		s.logger.Info("removed expired artifacts", "count", removed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to remove %d of %d expired artifacts", failed, len(expired))
+	}
+	return nil
+}
+
+// ProbeStorage checks the storage backend is reachable and writable
+func (s *ArtifactService) ProbeStorage() (string, error) {
+	return s.storage.Name(), s.storage.Probe()
+}
+
+// remove deletes the stored file before the record, so a failed delete is retried by the next cleanup
+func (s *ArtifactService) remove(artifact *models.Artifact) error {
+	if artifact.Backend != s.storage.Name() {
+		return fmt.Errorf("artifact is stored in the %s backend, but %s is configured", artifact.Backend, s.storage.Name())
+	}
+	if err := s.storage.Delete(context.Background(), artifact.StorageKey); err != nil {
+		return err
+	}
+	if err := s.db.Delete(artifact).Error; err != nil {
+		return fmt.Errorf("failed to delete artifact: %w", err)
+	}
+	return nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidArtifactSignature is returned for download links that are forged or expired
+var ErrInvalidArtifactSignature = errors.New("invalid or expired download link")
+
+// ArtifactStorage stores large artifacts such as backups, exports and snapshots under keys
+// like "export/2026/01/02/<id>/result.csv"
+type ArtifactStorage interface {
+	// Name returns the backend name
+	Name() string
+	// Put stores size bytes read from body under key
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Open returns the content stored under key
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes key; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that downloads key as filename without further authentication until expires
+	SignedURL(key, filename string, expires time.Time) (string, error)
+	// Probe checks the backend is reachable and writable
+	Probe() error
+}
+
+// ArtifactStorageConfig selects and configures the artifact storage backend
+type ArtifactStorageConfig struct {
+	Backend       string // local or s3
+	LocalDir      string
+	PublicURL     string // Prefix of local download links, e.g. https://truadmin.example.com; relative links when empty
+	SigningSecret string // Signs local download links
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3Prefix    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool // Address the bucket in the path (MinIO and most S3-compatible servers) instead of the host name
+}
+
+// NewArtifactStorage creates the configured artifact storage backend
+func NewArtifactStorage(cfg ArtifactStorageConfig) (ArtifactStorage, error) {
+	switch cfg.Backend {
+	case "local", "":
+		return NewLocalArtifactStorage(cfg.LocalDir, cfg.PublicURL, cfg.SigningSecret)
+	case "s3":
+		return NewS3ArtifactStorage(cfg)
+	default:
+		return nil, fmt.Errorf("unknown artifact storage backend %q", cfg.Backend)
+	}
+}
+
+// LocalArtifactStorage stores artifacts as files below a directory. Its download links point at
+// the API's public download endpoint and are signed with HMAC-SHA256.
+type LocalArtifactStorage struct {
+	dir       string
+	publicURL string
+	secret    []byte
+}
+
+// NewLocalArtifactStorage creates the artifact directory if needed
+func NewLocalArtifactStorage(dir, publicURL, secret string) (*LocalArtifactStorage, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("artifact directory is required")
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("artifact signing secret is required")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	return &LocalArtifactStorage{
+		dir:       dir,
+		publicURL: strings.TrimRight(publicURL, "/"),
+		secret:    []byte(secret),
+	}, nil
+}
+
+// Name returns the backend name
+func (s *LocalArtifactStorage) Name() string { return "local" }
+
+// path maps a key to a file below the artifact directory, rejecting keys that would escape it
+func (s *LocalArtifactStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if key == "" || clean != "/"+key {
+		return "", fmt.Errorf("invalid artifact key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
+
+// Put writes the artifact to a temporary file and renames it into place, so readers never see partial files
+func (s *LocalArtifactStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create artifact file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("artifact size mismatch: wrote %d of %d bytes", written, size)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store artifact: %w", err)
+	}
+	return nil
+}
+
+// Open opens the artifact file
+func (s *LocalArtifactStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("artifact not found")
+		}
+		return nil, fmt.Errorf("failed to open artifact: %w", err)
+	}
+	return file, nil
+}
+
+// Delete removes the artifact file
+func (s *LocalArtifactStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete artifact: %w", err)
+	}
+	return nil
+}
+
+// SignedURL returns a link to the public download endpoint
+func (s *LocalArtifactStorage) SignedURL(key, filename string, expires time.Time) (string, error) {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{}
+	query.Set("key", key)
+	query.Set("filename", filename)
+	query.Set("expires", exp)
+	query.Set("signature", s.sign(key, filename, exp))
+	return s.publicURL + "/api/v1/artifacts/download?" + query.Encode(), nil
+}
+
+// Verify checks the signature and expiry of a download link
+func (s *LocalArtifactStorage) Verify(key, filename, expires, signature string, now time.Time) error {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > exp {
+		return ErrInvalidArtifactSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, filename, expires))) {
+		return ErrInvalidArtifactSignature
+	}
+	return nil
+}
+
+// sign returns the hex HMAC of a download link's parameters
+func (s *LocalArtifactStorage) sign(key, filename, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + filename + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Probe checks a file can be created in the artifact directory
+func (s *LocalArtifactStorage) Probe() error {
+	f, err := os.CreateTemp(s.dir, ".probe-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", s.dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// s3MaxPresignExpiry is the longest validity S3 accepts for a presigned URL
+const s3MaxPresignExpiry = 7 * 24 * time.Hour
+
+// s3UnsignedPayload skips payload hashing so artifacts can be streamed without reading them twice
+const s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3ArtifactStorage stores artifacts in a bucket of Amazon S3 or an S3-compatible server such as
+// MinIO. Requests are signed with AWS Signature Version 4.
+type S3ArtifactStorage struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	prefix    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	client    *http.Client
+}
+
+// NewS3ArtifactStorage creates a new S3 artifact storage
+func NewS3ArtifactStorage(cfg ArtifactStorageConfig) (*S3ArtifactStorage, error) {
+	if cfg.S3Bucket == "" || cfg.S3AccessKey == "" || cfg.S3SecretKey == "" {
+		return nil, fmt.Errorf("S3 bucket, access key and secret key are required")
+	}
+	endpoint, err := url.Parse(cfg.S3Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.S3Endpoint)
+	}
+	region := cfg.S3Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3ArtifactStorage{
+		endpoint:  endpoint,
+		region:    region,
+		bucket:    cfg.S3Bucket,
+		prefix:    strings.Trim(cfg.S3Prefix, "/"),
+		accessKey: cfg.S3AccessKey,
+		secretKey: cfg.S3SecretKey,
+		pathStyle: cfg.S3PathStyle,
+		// No overall timeout: uploads and downloads of large artifacts are bounded by their context
+		client: &http.Client{},
+	}, nil
+}
+
+// Name returns the backend name
+func (s *S3ArtifactStorage) Name() string { return "s3" }
+
+// objectURL returns the URL of an object, or of the bucket when key is empty
+func (s *S3ArtifactStorage) objectURL(key string) *url.URL {
+	objectKey := key
+	if s.prefix != "" && key != "" {
+		objectKey = s.prefix + "/" + key
+	}
+
+	u := *s.endpoint
+	basePath := strings.TrimRight(u.Path, "/")
+	if s.pathStyle {
+		u.Path = basePath + "/" + s.bucket + "/" + objectKey
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = basePath + "/" + objectKey
+	}
+	u.RawPath = s3EscapePath(u.Path)
+	return &u
+}
+
+// Put uploads the artifact in a single PUT request
+func (s *S3ArtifactStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if size < 0 {
+		return fmt.Errorf("artifact size is required for S3 uploads")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), body)
+	if err != nil {
+		return fmt.Errorf("failed to create S3 request: %w", err)
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload artifact: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Open downloads the artifact; the caller closes the returned body
+func (s *S3ArtifactStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 request: %w", err)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		var statusErr *s3StatusError
+		if errors.As(err, &statusErr) && statusErr.status == http.StatusNotFound {
+			return nil, fmt.Errorf("artifact not found")
+		}
+		return nil, fmt.Errorf("failed to download artifact: %w", err)
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the artifact; S3 reports success for missing keys
+func (s *S3ArtifactStorage) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create S3 request: %w", err)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete artifact: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// SignedURL returns a presigned GET URL that downloads the object as filename
+func (s *S3ArtifactStorage) SignedURL(key, filename string, expires time.Time) (string, error) {
+	now := time.Now().UTC()
+	ttl := expires.Sub(now)
+	if ttl <= 0 {
+		return "", fmt.Errorf("download link expiry must be in the future")
+	}
+	if ttl > s3MaxPresignExpiry {
+		ttl = s3MaxPresignExpiry
+	}
+	return s.presign(key, filename, now, ttl), nil
+}
+
+// presign builds a query-string authenticated URL valid for ttl from now
+func (s *S3ArtifactStorage) presign(key, filename string, now time.Time, ttl time.Duration) string {
+	u := s.objectURL(key)
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if filename != "" {
+		query.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		s3CanonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(now, amzDate, scope, canonicalRequest))
+
+	u.RawQuery = s3CanonicalQuery(query)
+	return u.String()
+}
+
+// Probe checks the bucket exists and the credentials are accepted
+func (s *S3ArtifactStorage) Probe() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL("").String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create S3 request: %w", err)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("bucket %s is not accessible: %w", s.bucket, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// s3StatusError is a non-2xx response from the S3 server
+type s3StatusError struct {
+	status int
+	detail string
+}
+
+func (e *s3StatusError) Error() string {
+	return fmt.Sprintf("S3 returned status %d: %s", e.status, e.detail)
+}
+
+// do signs and sends a request, turning non-2xx responses into errors
+func (s *S3ArtifactStorage) do(req *http.Request) (*http.Response, error) {
+	s.signRequest(req, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &s3StatusError{status: resp.StatusCode, detail: strings.TrimSpace(string(detail))}
+	}
+	return resp, nil
+}
+
+// signRequest adds the Signature Version 4 Authorization header
+func (s *S3ArtifactStorage) signRequest(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": s3UnsignedPayload,
+		"x-amz-date":           amzDate,
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers["content-type"] = contentType
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, s.signature(now, amzDate, scope, canonicalRequest)))
+}
+
+// scope returns the credential scope of a request made at now
+func (s *S3ArtifactStorage) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+// signature derives the signing key for the day and signs the canonical request
+func (s *S3ArtifactStorage) signature(now time.Time, amzDate, scope, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3CanonicalQuery encodes query parameters sorted by name with RFC 3986 escaping
+func s3CanonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := []string{}
+	for _, name := range names {
+		values := append([]string{}, query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, s3Escape(name, true)+"="+s3Escape(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EscapePath escapes each segment of an object path, keeping the slashes
+func s3EscapePath(path string) string {
+	return s3Escape(path, false)
+}
+
+// s3Escape percent-encodes everything but unreserved characters, and slashes unless encodeSlash is set
+func s3Escape(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(value) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// auditBackfillBatchSize is the number of log entries read per query during a backfill
+const auditBackfillBatchSize = 500
+
+// auditBackfillSource is a stored log table whose entries are shipped as audit events
+type auditBackfillSource struct {
+	name  string
+	model interface{}
+	// batch returns the events of the entries after afterID up to cutoffID, with the ID of the last one
+	batch func(db *gorm.DB, afterID, cutoffID int) ([]models.AuditEvent, int, error)
+}
+
+// AuditBackfillService ships role and TruETL save log entries written before audit sinks were
+// configured to the sinks, once. New entries are shipped by their log services when written.
+type AuditBackfillService struct {
+	db      *gorm.DB
+	audit   *AuditService
+	sources []auditBackfillSource
+}
+
+// NewAuditBackfillService creates a new audit backfill service
+func NewAuditBackfillService(audit *AuditService) *AuditBackfillService {
+	return &AuditBackfillService{
+		db:    database.GetDB(),
+		audit: audit,
+		sources: []auditBackfillSource{
+			{name: "role", model: &models.RoleSaveLog{}, batch: roleBackfillBatch},
+			{name: "truetl", model: &models.TruETLSaveLog{}, batch: truETLBackfillBatch},
+		},
+	}
+}
+
+// Run ships the stored entries of every source that has not been backfilled yet and records the
+// progress, so an interrupted backfill resumes where it stopped. It is registered as the
+// audit_backfill job type and does nothing once every source is complete or without sinks.
+func (s *AuditBackfillService) Run() error {
+	if len(s.audit.Sinks()) == 0 {
+		return nil
+	}
+
+	for _, source := range s.sources {
+		if err := s.backfill(source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetStates returns the backfill progress of every source that has started
+func (s *AuditBackfillService) GetStates() ([]models.AuditBackfillState, error) {
+	states := []models.AuditBackfillState{}
+	if err := s.db.Order("source ASC").Find(&states).Error; err != nil {
+		return nil, fmt.Errorf("failed to get audit backfill states: %w", err)
+	}
+	return states, nil
+}
+
+// backfill ships the entries of one source up to the cutoff taken on its first run
+func (s *AuditBackfillService) backfill(source auditBackfillSource) error {
+	var state models.AuditBackfillState
+	err := s.db.First(&state, "source = ?", source.name).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		state = models.AuditBackfillState{Source: source.name}
+		if err := s.db.Model(source.model).Select("COALESCE(MAX(id), 0)").Scan(&state.CutoffID).Error; err != nil {
+			return fmt.Errorf("failed to get %s audit backfill cutoff: %w", source.name, err)
+		}
+		if err := s.db.Create(&state).Error; err != nil {
+			return fmt.Errorf("failed to start %s audit backfill: %w", source.name, err)
+		}
+		slog.Info("starting audit backfill", "source", source.name, "entries_up_to_id", state.CutoffID)
+	} else if err != nil {
+		return fmt.Errorf("failed to get %s audit backfill state: %w", source.name, err)
+	}
+	if state.CompletedAt != nil {
+		return nil
+	}
+
+	for {
+		events, lastID, err := source.batch(s.db, state.LastID, state.CutoffID)
+		if err != nil {
+			return fmt.Errorf("failed to read %s log entries for audit backfill: %w", source.name, err)
+		}
+		if len(events) == 0 {
+			break
+		}
+		for _, event := range events {
+			event.Backfilled = true
+			s.audit.RecordWait(event)
+		}
+
+		state.LastID = lastID
+		if err := s.db.Model(&state).Update("last_id", state.LastID).Error; err != nil {
+			return fmt.Errorf("failed to record %s audit backfill progress: %w", source.name, err)
+		}
+	}
+
+	now := time.Now().UTC()
+	if err := s.db.Model(&state).Update("completed_at", now).Error; err != nil {
+		return fmt.Errorf("failed to complete %s audit backfill: %w", source.name, err)
+	}
+	slog.Info("completed audit backfill", "source", source.name, "entries_up_to_id", state.CutoffID)
+	return nil
+}
+
+// roleBackfillBatch reads the next batch of role log entries
+func roleBackfillBatch(db *gorm.DB, afterID, cutoffID int) ([]models.AuditEvent, int, error) {
+	var entries []models.RoleSaveLog
+	if err := db.Where("id > ? AND id <= ?", afterID, cutoffID).Order("id ASC").Limit(auditBackfillBatchSize).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	events := make([]models.AuditEvent, 0, len(entries))
+	lastID := afterID
+	for i := range entries {
+		events = append(events, roleAuditEvent(&entries[i]))
+		lastID = entries[i].ID
+	}
+	return events, lastID, nil
+}
+
+// truETLBackfillBatch reads the next batch of TruETL save log entries
+func truETLBackfillBatch(db *gorm.DB, afterID, cutoffID int) ([]models.AuditEvent, int, error) {
+	var entries []models.TruETLSaveLog
+	if err := db.Where("id > ? AND id <= ?", afterID, cutoffID).Order("id ASC").Limit(auditBackfillBatchSize).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	events := make([]models.AuditEvent, 0, len(entries))
+	lastID := afterID
+	for i := range entries {
+		events = append(events, truETLAuditEvent(&entries[i]))
+		lastID = entries[i].ID
+	}
+	return events, lastID, nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// auditQueueSize is the number of audit events buffered for delivery before new events are dropped
+const auditQueueSize = 1000
+
+// AuditSink delivers audit events to an external destination
+type AuditSink interface {
+	Name() string
+	Write(event *models.AuditEvent) error
+	Probe() error // Checks the destination is reachable without delivering an event
+	Close() error
+}
+
+// AuditService ships audit events to the configured sinks in the background,
+// so slow destinations never delay the audited request
+type AuditService struct {
+	sinks  []AuditSink
+	queue  chan *models.AuditEvent
+	wg     sync.WaitGroup
+	once   sync.Once
+	logger *slog.Logger
+}
+
+// NewAuditService creates a new audit service and starts its delivery worker.
+// Without sinks events are discarded after being accepted.
+func NewAuditService(logger *slog.Logger, sinks ...AuditSink) *AuditService {
+	s := &AuditService{
+		sinks:  sinks,
+		queue:  make(chan *models.AuditEvent, auditQueueSize),
+		logger: logging.OrDefault(logger),
+	}
+
+	s.wg.Add(1)
+	go s.run()
+
+	return s
+}
+
+// Sinks returns the names of the configured audit sinks
+func (s *AuditService) Sinks() []string {
+	names := []string{}
+	if s == nil {
+		return names
+	}
+	for _, sink := range s.sinks {
+		names = append(names, sink.Name())
+	}
+	return names
+}
+
+// ProbeSinks checks every configured sink and returns the error of each by sink name
+func (s *AuditService) ProbeSinks() map[string]error {
+	results := map[string]error{}
+	if s == nil {
+		return results
+	}
+	for _, sink := range s.sinks {
+		results[sink.Name()] = sink.Probe()
+	}
+	return results
+}
+
+// Record queues an audit event for delivery; it never blocks the caller
+func (s *AuditService) Record(event models.AuditEvent) {
+	if s == nil || len(s.sinks) == 0 {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	select {
+	case s.queue <- &event:
+	default:
+		s.logger.Warn("audit queue is full, dropping event", "source", event.Source, "action", event.Action)
+	}
+}
+
+// RecordWait queues an audit event for delivery, waiting while the queue is full. It is meant for
+// background work such as backfills that must not lose events; requests use Record.
+func (s *AuditService) RecordWait(event models.AuditEvent) {
+	if s == nil || len(s.sinks) == 0 {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	s.queue <- &event
+}
+
+// Close flushes queued events and closes all sinks
+func (s *AuditService) Close() {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		close(s.queue)
+		s.wg.Wait()
+		for _, sink := range s.sinks {
+			if err := sink.Close(); err != nil {
+				s.logger.Error("failed to close audit sink", "sink", sink.Name(), "error", err)
+			}
+		}
+	})
+}
+
+// run delivers queued events to every sink until the queue is closed
+func (s *AuditService) run() {
+	defer s.wg.Done()
+	for event := range s.queue {
+		for _, sink := range s.sinks {
+			if err := sink.Write(event); err != nil {
+				s.logger.Error("failed to deliver audit event", "sink", sink.Name(), "error", err)
+			}
+		}
+	}
+}
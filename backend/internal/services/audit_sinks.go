@@ -0,0 +1,307 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// syslogFacilities maps facility names to their RFC5424 codes
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11, "ntp": 12, "security": 13, "console": 14,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// Syslog severities used for audit events
+const (
+	syslogSeverityError   = 3
+	syslogSeverityWarning = 4
+	syslogSeverityNotice  = 5
+)
+
+// syslogSDID is the structured data ID of audit event parameters (32473 is the documentation enterprise number)
+const syslogSDID = "audit@32473"
+
+// ParseSyslogFacility converts a facility name (e.g. "local0") or number into its code
+func ParseSyslogFacility(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if code, ok := syslogFacilities[value]; ok {
+		return code, nil
+	}
+	if code, err := strconv.Atoi(value); err == nil && code >= 0 && code <= 23 {
+		return code, nil
+	}
+	return 0, fmt.Errorf("unknown syslog facility %q", value)
+}
+
+// WebhookAuditSink posts each audit event as JSON to a URL
+type WebhookAuditSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookAuditSink creates a new webhook audit sink
+func NewWebhookAuditSink(url string) *WebhookAuditSink {
+	return &WebhookAuditSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the sink name
+func (s *WebhookAuditSink) Name() string { return "webhook" }
+
+// Write posts the event to the webhook
+func (s *WebhookAuditSink) Write(event *models.AuditEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Probe checks the webhook host accepts TCP connections
+func (s *WebhookAuditSink) Probe() error {
+	return probeURL(s.url)
+}
+
+// Close releases the sink
+func (s *WebhookAuditSink) Close() error { return nil }
+
+// FileAuditSink appends each audit event as a JSON line to a file
+type FileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileAuditSink opens (or creates) the audit file for appending
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	return &FileAuditSink{file: file}, nil
+}
+
+// Name returns the sink name
+func (s *FileAuditSink) Name() string { return "file" }
+
+// Write appends the event to the file
+func (s *FileAuditSink) Write(event *models.AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit file: %w", err)
+	}
+	return nil
+}
+
+// Probe checks the audit file is still open
+func (s *FileAuditSink) Probe() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Stat(); err != nil {
+		return fmt.Errorf("audit file is not available: %w", err)
+	}
+	return nil
+}
+
+// Close closes the audit file
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// SyslogAuditSink sends audit events to a syslog server formatted per RFC5424.
+// UDP sends one message per datagram; TCP uses octet-counting framing (RFC6587).
+type SyslogAuditSink struct {
+	network  string
+	address  string
+	facility int
+	appName  string
+	hostname string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogAuditSink creates a syslog sink for an address such as "udp://syslog:514" or "tcp://siem:6514"
+func NewSyslogAuditSink(address string, facility int, appName string) (*SyslogAuditSink, error) {
+	u, err := url.Parse(address)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid syslog address %q: expected udp://host:port or tcp://host:port", address)
+	}
+	if u.Scheme != "udp" && u.Scheme != "tcp" {
+		return nil, fmt.Errorf("unsupported syslog network %q", u.Scheme)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	if appName == "" {
+		appName = "truadmin"
+	}
+
+	return &SyslogAuditSink{
+		network:  u.Scheme,
+		address:  u.Host,
+		facility: facility,
+		appName:  appName,
+		hostname: hostname,
+	}, nil
+}
+
+// Name returns the sink name
+func (s *SyslogAuditSink) Name() string { return "syslog" }
+
+// Write sends the event, reconnecting once if the connection was lost
+func (s *SyslogAuditSink) Write(event *models.AuditEvent) error {
+	msg := s.format(event)
+	if s.network == "tcp" {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			s.conn, err = net.DialTimeout(s.network, s.address, 5*time.Second)
+			if err != nil {
+				return fmt.Errorf("failed to connect to syslog: %w", err)
+			}
+		}
+
+		s.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		if _, err = s.conn.Write([]byte(msg)); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	return fmt.Errorf("failed to write to syslog: %w", err)
+}
+
+// Probe checks the syslog server is reachable; for UDP only address resolution can be verified
+func (s *SyslogAuditSink) Probe() error {
+	if s.network == "udp" {
+		if _, err := net.ResolveUDPAddr("udp", s.address); err != nil {
+			return fmt.Errorf("failed to resolve syslog address: %w", err)
+		}
+		return nil
+	}
+	return probeTCP(s.address)
+}
+
+// Close closes the syslog connection
+func (s *SyslogAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// format renders the event as an RFC5424 message:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ELEMENT] MSG
+func (s *SyslogAuditSink) format(event *models.AuditEvent) string {
+	severity := syslogSeverityNotice
+	switch event.Status {
+	case models.AuditEventStatusError:
+		severity = syslogSeverityError
+	case models.AuditEventStatusPartial:
+		severity = syslogSeverityWarning
+	}
+
+	params := [][2]string{
+		{"source", event.Source},
+		{"action", event.Action},
+		{"status", string(event.Status)},
+		{"actor", event.ActorID},
+		{"connection", event.ConnectionID},
+		{"target", event.TargetID},
+		{"request", event.RequestID},
+	}
+
+	var sd strings.Builder
+	sd.WriteString("[" + syslogSDID)
+	for _, p := range params {
+		if p[1] == "" {
+			continue
+		}
+		sd.WriteString(" " + p[0] + "=\"" + escapeSDParam(p[1]) + "\"")
+	}
+	sd.WriteString("]")
+
+	msg := event.Message
+	if msg == "" {
+		msg = event.Source + " " + event.Action + " " + string(event.Status)
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		s.facility*8+severity,
+		event.Timestamp.UTC().Format(time.RFC3339Nano),
+		syslogHeaderField(s.hostname, 255),
+		syslogHeaderField(s.appName, 48),
+		os.Getpid(),
+		syslogHeaderField(event.Source+"."+event.Action, 32),
+		sd.String(),
+		msg,
+	)
+}
+
+// escapeSDParam escapes the characters RFC5424 reserves inside structured data values
+func escapeSDParam(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
+
+// syslogHeaderField restricts a header field to printable US-ASCII without spaces and a maximum length
+func syslogHeaderField(value string, maxLen int) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r > 32 && r < 127 {
+			b.WriteRune(r)
+		}
+		if b.Len() == maxLen {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "-"
+	}
+	return b.String()
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,6 +12,7 @@ import (
 	"gorm.io/gorm"
 
 	"truadmin/internal/database"
+	"truadmin/internal/logging"
 	"truadmin/internal/models"
 )
 
@@ -18,13 +20,27 @@ import (
 type AuthService struct {
 	db        *gorm.DB
 	jwtSecret string
+	store     SharedStore // Revoked tokens until they expire, failed logins and lockouts, used TOTP codes
+	lockout   LoginLockoutPolicy
+	totp      TOTPPolicy
+	logger    *slog.Logger
+
+	totpSecretKey    []byte // Encrypts TOTP secrets
+	totpChallengeKey []byte // Signs the challenges between password and TOTP code
 }
 
 // NewAuthService creates a new auth service
-func NewAuthService(jwtSecret string) *AuthService {
+func NewAuthService(jwtSecret string, store SharedStore, lockout LoginLockoutPolicy, totp TOTPPolicy, logger *slog.Logger) *AuthService {
+	secretKey, challengeKey := totpKeys(totp.EncryptionKey, jwtSecret)
 	return &AuthService{
-		db:        database.GetDB(),
-		jwtSecret: jwtSecret,
+		db:               database.GetDB(),
+		jwtSecret:        jwtSecret,
+		store:            store,
+		lockout:          lockout,
+		totp:             totp,
+		totpSecretKey:    secretKey,
+		totpChallengeKey: challengeKey,
+		logger:           logging.OrDefault(logger),
 	}
 }
 
@@ -84,13 +100,21 @@ func (s *AuthService) InitialSetup(password string) error {
 	return nil
 }
 
-// Login authenticates a user and returns a JWT token
-func (s *AuthService) Login(username, password string) (*models.LoginResponse, error) {
+// Login authenticates a user and returns a JWT token, or a challenge for users with two-factor
+// authentication. Failed attempts are counted per username and client IP, which are locked out for
+// a while once they fail too often. The client IP and user agent are kept with the session of the token.
+func (s *AuthService) Login(username, password, clientIP, userAgent string) (*models.LoginResponse, error) {
+	// Locked out usernames and client IPs are rejected without checking the password
+	if err := s.checkLoginLock(username, clientIP); err != nil {
+		return nil, err
+	}
+
 	// Find user by username
 	var user models.User
 	if err := s.db.Where("username = ?", username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("invalid credentials")
+			attempts, lockedFor := s.recordLoginFailure(username, clientIP)
+			return nil, &LoginFailedError{ClientIP: clientIP, Attempts: attempts, LockedFor: lockedFor}
 		}
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
@@ -102,18 +126,35 @@ func (s *AuthService) Login(username, password string) (*models.LoginResponse, e
 
 	// Verify password
 	if !s.checkPassword(password, user.Password) {
-		return nil, fmt.Errorf("invalid credentials")
+		attempts, lockedFor := s.recordLoginFailure(username, clientIP)
+		return nil, &LoginFailedError{UserID: user.ID, ClientIP: clientIP, Attempts: attempts, LockedFor: lockedFor}
 	}
 
+	// Users with two-factor authentication get a token for the code; failures are kept until then,
+	// so a known password does not reset the count of wrong codes
+	if user.TOTPEnabled {
+		challenge, err := s.totpChallenge(&user)
+		if err != nil {
+			return nil, err
+		}
+		return &models.LoginResponse{
+			User:         &user,
+			TOTPRequired: true,
+			Challenge:    challenge,
+		}, nil
+	}
+	s.clearLoginFailures(username)
+
 	// Generate JWT token
-	token, err := s.generateToken(&user)
+	token, err := s.generateToken(&user, clientIP, userAgent)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
 	return &models.LoginResponse{
-		Token: token,
-		User:  &user,
+		Token:                  token,
+		User:                   &user,
+		TOTPEnrollmentRequired: s.TOTPEnrollmentRequired(&user),
 	}, nil
 }
 
@@ -232,11 +273,57 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 		return nil, fmt.Errorf("invalid token: %w", err)
 	}
 
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+
+	if claims.ID != "" {
+		var expiresAt time.Time
+		if claims.ExpiresAt != nil {
+			expiresAt = claims.ExpiresAt.Time
+		}
+		if s.tokenRevoked(claims.ID, expiresAt) {
+			return nil, fmt.Errorf("token has been revoked")
+		}
 	}
 
-	return nil, fmt.Errorf("invalid token claims")
+	return claims, nil
+}
+
+// tokenRevoked reports whether a token was revoked. The store is only a cache in front of the session
+// rows: an in-memory store forgets revocations at restart, while the session row keeps them.
+func (s *AuthService) tokenRevoked(tokenID string, expiresAt time.Time) bool {
+	_, revoked, err := s.store.Get(revokedTokenKey(tokenID))
+	if err != nil {
+		// Tokens stay usable while the store is down rather than locking everyone out
+		s.logger.Warn("token revocation check unavailable", "error", err)
+	} else if revoked {
+		return true
+	}
+	return s.sessionRevoked(tokenID, expiresAt)
+}
+
+// RevokeToken rejects a token from now on, on every replica sharing the store. The revocation is
+// kept until the token would have expired anyway.
+func (s *AuthService) RevokeToken(tokenID string, expiresAt time.Time) error {
+	if tokenID == "" {
+		return fmt.Errorf("token cannot be revoked")
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	if err := s.store.Set(revokedTokenKey(tokenID), []byte("1"), ttl); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	s.endSession(tokenID)
+	return nil
+}
+
+// revokedTokenKey is the store key marking a token as revoked
+func revokedTokenKey(tokenID string) string {
+	return "revoked-token:" + tokenID
 }
 
 // hashPassword hashes a password using bcrypt
@@ -254,21 +341,28 @@ func (s *AuthService) checkPassword(password, hash string) bool {
 	return err == nil
 }
 
-// generateToken generates a JWT token for a user
-func (s *AuthService) generateToken(user *models.User) (string, error) {
+// generateToken generates a JWT token for a user and records its session
+func (s *AuthService) generateToken(user *models.User, clientIP, userAgent string) (string, error) {
 	claims := JWTClaims{
 		UserID:   user.ID,
 		Username: user.Username,
 		Role:     user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(), // Identifies the token for revocation
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return "", err
+	}
+	if err := s.createSession(user.ID, claims.ID, clientIP, userAgent, claims.ExpiresAt.Time); err != nil {
+		return "", err
+	}
+	return token, nil
 }
 
 // ChangePassword changes a user's password (admin only)
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/models"
+)
+
+// sessionTouchInterval is how often the last use of a session is written; requests in between do
+// not touch the internal database
+const sessionTouchInterval = time.Minute
+
+// createSession records the session of a token issued at login
+func (s *AuthService) createSession(userID, tokenID, clientIP, userAgent string, expiresAt time.Time) error {
+	now := time.Now()
+	session := &models.UserSession{
+		ID:         tokenID,
+		UserID:     userID,
+		ClientIP:   clientIP,
+		UserAgent:  userAgent,
+		LastSeenIP: clientIP,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  now,
+	}
+	if err := s.db.Create(session).Error; err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// TouchSession records a request made with the token of a session, at most once per
+// sessionTouchInterval. Tokens issued before sessions were tracked have no session and are ignored.
+func (s *AuthService) TouchSession(tokenID, clientIP string) {
+	if tokenID == "" {
+		return
+	}
+	now := time.Now()
+	err := s.db.Model(&models.UserSession{}).
+		Where("id = ? AND last_seen_at < ?", tokenID, now.Add(-sessionTouchInterval)).
+		Updates(map[string]interface{}{"last_seen_at": now, "last_seen_ip": clientIP}).Error
+	if err != nil {
+		s.logger.Warn("failed to record session use", "error", err)
+	}
+}
+
+// sessionRevoked reports whether the session of a token was ended, and puts the revocation back
+// into the store when the store had lost it. Tokens issued before sessions were tracked have no
+// session and are not revoked.
+func (s *AuthService) sessionRevoked(tokenID string, expiresAt time.Time) bool {
+	var session models.UserSession
+	err := s.db.Select("id", "revoked_at").Where("id = ?", tokenID).Limit(1).Find(&session).Error
+	if err != nil {
+		s.logger.Warn("session revocation check unavailable", "error", err)
+		return false
+	}
+	if session.RevokedAt == nil {
+		return false
+	}
+
+	if ttl := time.Until(expiresAt); ttl > 0 {
+		if err := s.store.Set(revokedTokenKey(tokenID), []byte("1"), ttl); err != nil {
+			s.logger.Warn("failed to cache token revocation", "error", err)
+		}
+	}
+	return true
+}
+
+// CheckSession returns an error once a token accepted earlier has expired or been revoked, or its
+// user was blocked. Connections outliving the request that opened them, such as WebSockets, call it
+// periodically; tokenID is empty for requests authenticated otherwise.
+func (s *AuthService) CheckSession(userID, tokenID string, expiresAt time.Time) error {
+	if !expiresAt.IsZero() && !time.Now().Before(expiresAt) {
+		return fmt.Errorf("token has expired")
+	}
+	if tokenID != "" && s.tokenRevoked(tokenID, expiresAt) {
+		return fmt.Errorf("token has been revoked")
+	}
+
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.IsBlocked {
+		return fmt.Errorf("user account is blocked")
+	}
+	return nil
+}
+
+// GetUserSessions retrieves the sessions of a user that are neither expired nor revoked, most
+// recently used first
+func (s *AuthService) GetUserSessions(userID string) ([]models.UserSession, error) {
+	if _, err := s.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+
+	var sessions []models.UserSession
+	err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends a session by revoking its token; the user has to log in again. Ending a session
+// that already ended does nothing.
+func (s *AuthService) RevokeSession(sessionID string) (*models.UserSession, error) {
+	var session models.UserSession
+	if err := s.db.First(&session, "id = ?", sessionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("session not found")
+		}
+		return nil, fmt.Errorf("failed to find session: %w", err)
+	}
+	if session.RevokedAt != nil {
+		return &session, nil
+	}
+
+	if err := s.RevokeToken(session.ID, session.ExpiresAt); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// endSession marks the session of a revoked token as ended
+func (s *AuthService) endSession(tokenID string) {
+	err := s.db.Model(&models.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", tokenID).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		s.logger.Warn("failed to end session", "error", err)
+	}
+}
+
+// PruneSessions removes sessions whose token expired; it is registered as the session_pruning job type
+func (s *AuthService) PruneSessions() error {
+	result := s.db.Where("expires_at < ?", time.Now()).Delete(&models.UserSession{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to prune sessions: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		s.logger.Info("pruned expired sessions", "count", result.RowsAffected)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"truadmin/internal/models"
+)
+
+func TestRevokedSessionSurvivesRestart(t *testing.T) {
+	newTestInternalDB(t, &models.User{}, &models.UserSession{})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	auth := NewAuthService("test-secret", NewMemoryStore(), LoginLockoutPolicy{}, TOTPPolicy{}, logger)
+	if err := auth.InitialSetup("admin-password"); err != nil {
+		t.Fatalf("InitialSetup() error = %v", err)
+	}
+	login, err := auth.Login("admin", "admin-password", "192.0.2.1", "test")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	claims, err := auth.ValidateToken(login.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	if _, err := auth.RevokeSession(claims.ID); err != nil {
+		t.Fatalf("RevokeSession() error = %v", err)
+	}
+
+	// A restarted server starts with an empty in-memory store
+	restarted := NewAuthService("test-secret", NewMemoryStore(), LoginLockoutPolicy{}, TOTPPolicy{}, logger)
+	if _, err := restarted.ValidateToken(login.Token); err == nil {
+		t.Fatal("ValidateToken() accepted the token of a revoked session after a restart")
+	}
+}
+
+func TestCheckSessionFailsOnceRevoked(t *testing.T) {
+	newTestInternalDB(t, &models.User{}, &models.UserSession{})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	auth := NewAuthService("test-secret", NewMemoryStore(), LoginLockoutPolicy{}, TOTPPolicy{}, logger)
+	if err := auth.InitialSetup("admin-password"); err != nil {
+		t.Fatalf("InitialSetup() error = %v", err)
+	}
+	login, err := auth.Login("admin", "admin-password", "192.0.2.1", "test")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	claims, err := auth.ValidateToken(login.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+
+	if err := auth.CheckSession(claims.UserID, claims.ID, claims.ExpiresAt.Time); err != nil {
+		t.Fatalf("CheckSession() error = %v before the session was revoked", err)
+	}
+	if _, err := auth.RevokeSession(claims.ID); err != nil {
+		t.Fatalf("RevokeSession() error = %v", err)
+	}
+	if err := auth.CheckSession(claims.UserID, claims.ID, claims.ExpiresAt.Time); err == nil {
+		t.Fatal("CheckSession() accepted a revoked session")
+	}
+}
@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// bulkRunRetention is how long finished runs stay available for progress queries
+const bulkRunRetention = 24 * time.Hour
+
+// ErrBulkRunForbidden is returned when a user accesses a bulk run started by someone else
+var ErrBulkRunForbidden = errors.New("only the user who started this run can access it")
+
+// bulkItem is one unit of work of a bulk run
+type bulkItem struct {
+	label string
+	work  func(ctx context.Context) (interface{}, error)
+}
+
+// BulkRunService runs bulk grants, bulk role changes, batch address checks and multi-database scripts in the background,
+// throttled by the load of the target server. Runs are kept in memory.
+type BulkRunService struct {
+	databaseService   *DatabaseService
+	hohAddressService *HohAddressService
+	throttle          BulkThrottle
+	logger            *slog.Logger
+
+	mu      sync.Mutex
+	runs    map[string]*models.BulkRun
+	cancels map[string]context.CancelFunc
+}
+
+// NewBulkRunService creates a new bulk run service
+func NewBulkRunService(databaseService *DatabaseService, hohAddressService *HohAddressService, throttle BulkThrottle, logger *slog.Logger) *BulkRunService {
+	if throttle.ChunkSize <= 0 {
+		throttle.ChunkSize = 10
+	}
+	if throttle.PollInterval <= 0 {
+		throttle.PollInterval = 5 * time.Second
+	}
+	return &BulkRunService{
+		databaseService:   databaseService,
+		hohAddressService: hohAddressService,
+		throttle:          throttle,
+		runs:              map[string]*models.BulkRun{},
+		cancels:           map[string]context.CancelFunc{},
+		logger:            logging.OrDefault(logger),
+	}
+}
+
+// StartBulkGrant grants the same privileges to every listed role of a connection
+func (s *BulkRunService) StartBulkGrant(connectionID, userID string, req *models.BulkGrantRequest) *models.BulkRun {
+	items := make([]bulkItem, 0, len(req.RoleIDs))
+	for _, roleID := range req.RoleIDs {
+		roleID := roleID
+		items = append(items, bulkItem{
+			label: roleID,
+			work: func(ctx context.Context) (interface{}, error) {
+				return nil, s.databaseService.GrantPrivileges(ctx, connectionID, roleID, &req.Grant)
+			},
+		})
+	}
+	return s.start(models.BulkKindGrant, connectionID, userID, items, func() (*sql.DB, error) {
+		return s.databaseService.connectToDatabase(connectionID)
+	})
+}
+
+// PreviewRoleAlter returns the ALTER ROLE statements a bulk role change would execute
+func (s *BulkRunService) PreviewRoleAlter(ctx context.Context, connectionID string, req *models.BulkRoleAlterRequest) (*models.BulkRoleAlterPreview, error) {
+	plans, err := s.databaseService.PlanRoleAlter(ctx, connectionID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &models.BulkRoleAlterPreview{Roles: plans, DryRun: true}
+	for _, plan := range plans {
+		if plan.Statement != "" {
+			preview.Changed++
+		} else {
+			preview.Skipped++
+		}
+	}
+	return preview, nil
+}
+
+// StartRoleAlter changes the attributes of every role matching a filter, one ALTER ROLE per role.
+// The statements are planned when the run starts, exactly as the preview shows them.
+func (s *BulkRunService) StartRoleAlter(ctx context.Context, connectionID, userID string, req *models.BulkRoleAlterRequest) (*models.BulkRun, error) {
+	plans, err := s.databaseService.PlanRoleAlter(ctx, connectionID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []bulkItem{}
+	for _, plan := range plans {
+		if plan.Statement == "" {
+			continue
+		}
+		plan := plan
+		items = append(items, bulkItem{
+			label: plan.RoleName,
+			work: func(ctx context.Context) (interface{}, error) {
+				if err := s.databaseService.alterRole(ctx, connectionID, plan.Statement); err != nil {
+					return nil, err
+				}
+				return map[string]string{"statement": plan.Statement}, nil
+			},
+		})
+	}
+	return s.start(models.BulkKindRoleAlter, connectionID, userID, items, func() (*sql.DB, error) {
+		return s.databaseService.connectToDatabase(connectionID)
+	}), nil
+}
+
+// StartAddressChecks checks every address against a HohAddress database
+func (s *BulkRunService) StartAddressChecks(hohAddressDatabaseID, userID string, req *models.BulkAddressCheckRequest) (*models.BulkRun, error) {
+	hohAddressDB, err := s.hohAddressService.GetDatabase(hohAddressDatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]bulkItem, 0, len(req.Addresses))
+	for _, address := range req.Addresses {
+		address := address
+		items = append(items, bulkItem{
+			label: fmt.Sprintf("%s, %s, %s %s", address.Address1, address.City, address.State, address.Zip),
+			work: func(ctx context.Context) (interface{}, error) {
+				return s.hohAddressService.CheckAddressStatus(ctx, hohAddressDatabaseID, address.Address1, address.Address2,
+					address.City, address.State, address.Zip, address.ProgramType)
+			},
+		})
+	}
+	return s.start(models.BulkKindAddressCheck, hohAddressDB.ConnectionID, userID, items, func() (*sql.DB, error) {
+		return s.hohAddressService.connectToDatabase(hohAddressDatabaseID)
+	}), nil
+}
+
+// StartScriptRun runs a script in each listed database of a connection
+func (s *BulkRunService) StartScriptRun(connectionID, userID string, req *models.ScriptRunRequest) *models.BulkRun {
+	items := make([]bulkItem, 0, len(req.Databases))
+	for _, dbName := range req.Databases {
+		dbName := dbName
+		items = append(items, bulkItem{
+			label: dbName,
+			work: func(ctx context.Context) (interface{}, error) {
+				result, err := s.databaseService.ExecuteQuery(ctx, connectionID, dbName, req.Script)
+				if err != nil {
+					return nil, err
+				}
+				if result.Error != "" {
+					return nil, errors.New(result.Error)
+				}
+				return map[string]int{"rows": len(result.Rows)}, nil
+			},
+		})
+	}
+	return s.start(models.BulkKindScriptRun, connectionID, userID, items, func() (*sql.DB, error) {
+		return s.databaseService.connectToDatabase(connectionID)
+	})
+}
+
+// GetRun returns a snapshot of a run; only its starter or an admin may read it
+func (s *BulkRunService) GetRun(id, userID string, isAdmin bool) (*models.BulkRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run, ok := s.runs[id]
+	if !ok {
+		return nil, fmt.Errorf("bulk run not found")
+	}
+	if run.UserID != userID && !isAdmin {
+		return nil, ErrBulkRunForbidden
+	}
+	return snapshotBulkRun(run), nil
+}
+
+// GetRuns returns the runs started by a user, or all runs for an admin, newest first
+func (s *BulkRunService) GetRuns(userID string, isAdmin bool) []*models.BulkRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := []*models.BulkRun{}
+	for _, run := range s.runs {
+		if run.UserID == userID || isAdmin {
+			summary := snapshotBulkRun(run)
+			summary.Items = []models.BulkItemResult{}
+			runs = append(runs, summary)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+	return runs
+}
+
+// CancelRun stops a run after its current item
+func (s *BulkRunService) CancelRun(id, userID string, isAdmin bool) (*models.BulkRun, error) {
+	if _, err := s.GetRun(id, userID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	cancel, ok := s.cancels[id]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return s.GetRun(id, userID, isAdmin)
+}
+
+// start registers a run and processes its items in chunks, checking the server load before each chunk
+func (s *BulkRunService) start(kind, connectionID, userID string, items []bulkItem, openProbe func() (*sql.DB, error)) *models.BulkRun {
+	now := time.Now().UTC()
+	run := &models.BulkRun{
+		ID:           uuid.New().String(),
+		Kind:         kind,
+		ConnectionID: connectionID,
+		UserID:       userID,
+		State:        models.BulkRunRunning,
+		Total:        len(items),
+		Items:        []models.BulkItemResult{},
+		StartedAt:    now,
+		UpdatedAt:    now,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	s.pruneLocked(now)
+	s.runs[run.ID] = run
+	s.cancels[run.ID] = cancel
+	snapshot := snapshotBulkRun(run)
+	s.mu.Unlock()
+
+	go s.process(ctx, run, items, openProbe)
+	return snapshot
+}
+
+// process executes the items of a run and records the outcome
+func (s *BulkRunService) process(ctx context.Context, run *models.BulkRun, items []bulkItem, openProbe func() (*sql.DB, error)) {
+	defer func() {
+		s.mu.Lock()
+		if cancel, ok := s.cancels[run.ID]; ok {
+			cancel()
+			delete(s.cancels, run.ID)
+		}
+		s.mu.Unlock()
+	}()
+
+	probe, err := openProbe()
+	if err != nil {
+		s.finish(run, models.BulkRunFailed, err)
+		return
+	}
+
+	for i, item := range items {
+		if i%s.throttle.ChunkSize == 0 {
+			if err := s.waitForCapacity(ctx, run, probe); err != nil {
+				if ctx.Err() != nil {
+					s.finish(run, models.BulkRunCancelled, nil)
+				} else {
+					s.finish(run, models.BulkRunFailed, err)
+				}
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			s.finish(run, models.BulkRunCancelled, nil)
+			return
+		}
+
+		result, err := item.work(ctx)
+		entry := models.BulkItemResult{Item: item.label, Status: "success", Result: result}
+		if err != nil {
+			entry = models.BulkItemResult{Item: item.label, Status: "error", Error: err.Error()}
+		}
+
+		s.mu.Lock()
+		run.Items = append(run.Items, entry)
+		run.Done++
+		if err != nil {
+			run.Failed++
+		}
+		run.UpdatedAt = time.Now().UTC()
+		s.mu.Unlock()
+	}
+
+	s.finish(run, models.BulkRunCompleted, nil)
+}
+
+// waitForCapacity pauses the run while the target server is overloaded
+func (s *BulkRunService) waitForCapacity(ctx context.Context, run *models.BulkRun, probe *sql.DB) error {
+	var pausedSince time.Time
+	err := s.throttle.wait(ctx, probe, func(load *models.ServerLoad, reason string) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		now := time.Now().UTC()
+		if load != nil {
+			run.Load = load
+		}
+		if reason != "" {
+			if pausedSince.IsZero() {
+				pausedSince = now
+				s.logger.InfoContext(ctx, "bulk run paused", "run_id", run.ID, "reason", reason)
+			}
+			run.State = models.BulkRunPaused
+			run.PausedReason = reason
+		} else {
+			run.State = models.BulkRunRunning
+			run.PausedReason = ""
+		}
+		if !pausedSince.IsZero() && reason == "" {
+			run.PausedMs += now.Sub(pausedSince).Milliseconds()
+			pausedSince = time.Time{}
+			s.logger.InfoContext(ctx, "bulk run resumed", "run_id", run.ID)
+		}
+		run.UpdatedAt = now
+	})
+
+	if !pausedSince.IsZero() {
+		s.mu.Lock()
+		run.PausedMs += time.Since(pausedSince).Milliseconds()
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// finish records the final state of a run
+func (s *BulkRunService) finish(run *models.BulkRun, state models.BulkRunState, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	run.State = state
+	run.PausedReason = ""
+	if err != nil {
+		run.Error = err.Error()
+	}
+	run.UpdatedAt = now
+	run.FinishedAt = &now
+}
+
+// pruneLocked drops finished runs older than the retention; s.mu must be held
+func (s *BulkRunService) pruneLocked(now time.Time) {
+	for id, run := range s.runs {
+		if run.FinishedAt != nil && now.Sub(*run.FinishedAt) > bulkRunRetention {
+			delete(s.runs, id)
+		}
+	}
+}
+
+// snapshotBulkRun copies a run so it can be returned while the run continues
+func snapshotBulkRun(run *models.BulkRun) *models.BulkRun {
+	snapshot := *run
+	snapshot.Items = append([]models.BulkItemResult{}, run.Items...)
+	if run.Load != nil {
+		load := *run.Load
+		snapshot.Load = &load
+	}
+	return &snapshot
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// BulkThrottle pauses bulk operations while the target server is busy
+type BulkThrottle struct {
+	ChunkSize          int           // Items processed between load checks
+	MaxActiveQueries   int           // Pause while more queries are active; zero disables the check
+	MaxConnectionUsage float64       // Pause while connections / max_connections is higher; zero disables the check
+	PollInterval       time.Duration // How often the load is sampled while paused
+	MaxWait            time.Duration // Fail the run when the load stays high for longer
+}
+
+// readServerLoad samples the connection usage of the server behind db
+func readServerLoad(ctx context.Context, db *sql.DB) (*models.ServerLoad, error) {
+	load := &models.ServerLoad{SampledAt: time.Now().UTC()}
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE state = 'active' AND pid <> pg_backend_pid()),
+			COUNT(*),
+			current_setting('max_connections')::int
+		FROM pg_stat_activity
+		WHERE backend_type = 'client backend'
+	`).Scan(&load.ActiveQueries, &load.Connections, &load.MaxConnections)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read server load: %w", err)
+	}
+	if load.MaxConnections > 0 {
+		load.ConnectionUsage = float64(load.Connections) / float64(load.MaxConnections)
+	}
+	return load, nil
+}
+
+// overloaded returns why the load is too high, or an empty string when work may continue
+func (t BulkThrottle) overloaded(load *models.ServerLoad) string {
+	if t.MaxActiveQueries > 0 && load.ActiveQueries > t.MaxActiveQueries {
+		return fmt.Sprintf("%d active queries, limit is %d", load.ActiveQueries, t.MaxActiveQueries)
+	}
+	if t.MaxConnectionUsage > 0 && load.ConnectionUsage > t.MaxConnectionUsage {
+		return fmt.Sprintf("%.0f%% of max_connections in use, limit is %.0f%%", load.ConnectionUsage*100, t.MaxConnectionUsage*100)
+	}
+	return ""
+}
+
+// wait blocks until the server load is acceptable, reporting each sample and pause reason.
+// A failed sample lets the run continue, since the throttle must not block work on servers
+// where pg_stat_activity is not readable.
+func (t BulkThrottle) wait(ctx context.Context, db *sql.DB, report func(load *models.ServerLoad, reason string)) error {
+	deadline := time.Now().Add(t.MaxWait)
+	for {
+		load, err := readServerLoad(ctx, db)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			report(nil, "")
+			return nil
+		}
+
+		reason := t.overloaded(load)
+		report(load, reason)
+		if reason == "" {
+			return nil
+		}
+		if t.MaxWait > 0 && time.Now().After(deadline) {
+			return fmt.Errorf("server load stayed too high for %s: %s", t.MaxWait, reason)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(t.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// capacitySampleRetention is how long recorded capacity samples are kept
+const capacitySampleRetention = 90 * 24 * time.Hour
+
+// CapacityService aggregates database sizes, connection counts and growth across all connections
+type CapacityService struct {
+	db                *gorm.DB
+	connectionService *ConnectionService
+	databaseService   *DatabaseService
+	monitored         *MonitoredDatabaseService
+	accessGrants      *AccessGrantService
+}
+
+// NewCapacityService creates a new capacity service
+func NewCapacityService(connectionService *ConnectionService, databaseService *DatabaseService, monitored *MonitoredDatabaseService, accessGrants *AccessGrantService) *CapacityService {
+	return &CapacityService{
+		db:                database.GetDB(),
+		connectionService: connectionService,
+		databaseService:   databaseService,
+		monitored:         monitored,
+		accessGrants:      accessGrants,
+	}
+}
+
+// GetSnapshot collects live capacity figures for every connection the user may read, with growth
+// rates from recorded samples
+func (s *CapacityService) GetSnapshot(userID string) (*models.CapacitySnapshot, error) {
+	all, err := s.connectionService.GetAllConnections()
+	if err != nil {
+		return nil, err
+	}
+	connections := make([]*models.Connection, 0, len(all))
+	for _, conn := range all {
+		allowed, err := s.accessGrants.CanReadConnection(userID, conn.ID)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			connections = append(connections, conn)
+		}
+	}
+
+	snapshot := &models.CapacitySnapshot{
+		SchemaVersion: models.CapacitySchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		Connections:   make([]models.ConnectionCapacity, len(connections)),
+	}
+
+	var wg sync.WaitGroup
+	for i, conn := range connections {
+		wg.Add(1)
+		go func(i int, conn *models.Connection) {
+			defer wg.Done()
+			snapshot.Connections[i] = s.collectConnection(conn)
+		}(i, conn)
+	}
+	wg.Wait()
+
+	for i := range snapshot.Connections {
+		s.attachGrowth(&snapshot.Connections[i], snapshot.GeneratedAt)
+	}
+
+	return snapshot, nil
+}
+
+// RecordSamples stores the current capacity figures of the monitored databases and prunes old
+// samples; it is registered as the capacity_sampling job type
+func (s *CapacityService) RecordSamples() error {
+	connections, err := s.connectionService.GetAllConnections()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	targets, err := s.monitored.dueTargets(models.MonitoringCollectorCapacity, now)
+	if err != nil {
+		return err
+	}
+
+	samples := []models.CapacitySample{}
+	for _, conn := range connections {
+		if !targets.includesConnection(conn.ID) {
+			continue
+		}
+		capacity := s.collectConnection(conn)
+		if capacity.Status != "ok" {
+			continue
+		}
+		for _, db := range capacity.Databases {
+			if !targets.includes(conn.ID, db.Name) {
+				continue
+			}
+			samples = append(samples, models.CapacitySample{
+				ConnectionID: conn.ID,
+				DatabaseName: db.Name,
+				SizeBytes:    db.SizeBytes,
+				Connections:  db.Connections,
+				CollectedAt:  now,
+			})
+		}
+	}
+
+	if len(samples) > 0 {
+		if err := s.db.Create(&samples).Error; err != nil {
+			return fmt.Errorf("failed to record capacity samples: %w", err)
+		}
+	}
+
+	if err := s.db.Where("collected_at < ?", now.Add(-capacitySampleRetention)).Delete(&models.CapacitySample{}).Error; err != nil {
+		return fmt.Errorf("failed to prune capacity samples: %w", err)
+	}
+
+	return nil
+}
+
+// collectConnection reads sizes and connection counts from a connection's server
+func (s *CapacityService) collectConnection(conn *models.Connection) models.ConnectionCapacity {
+	capacity := models.ConnectionCapacity{
+		ConnectionID: conn.ID,
+		Name:         conn.Name,
+		Type:         conn.Type,
+		Host:         conn.Host,
+		Port:         conn.Port,
+		Status:       "ok",
+		Databases:    []models.DatabaseCapacity{},
+	}
+
+	if conn.Type != "postgres" {
+		capacity.Status = "unsupported"
+		return capacity
+	}
+
+	db, err := s.databaseService.connectToDatabase(conn.ID)
+	if err != nil {
+		capacity.Status = "error"
+		capacity.Error = err.Error()
+		return capacity
+	}
+
+	if err := db.QueryRow(`SELECT current_setting('max_connections')::bigint`).Scan(&capacity.MaxConnections); err != nil {
+		capacity.Status = "error"
+		capacity.Error = fmt.Sprintf("failed to get max_connections: %v", err)
+		return capacity
+	}
+
+	rows, err := db.Query(`
+		SELECT d.datname, pg_database_size(d.oid), COALESCE(s.numbackends, 0)
+		FROM pg_database d
+		LEFT JOIN pg_stat_database s ON s.datid = d.oid
+		WHERE d.datistemplate = false AND d.datallowconn
+		ORDER BY d.datname
+	`)
+	if err != nil {
+		capacity.Status = "error"
+		capacity.Error = fmt.Sprintf("failed to get database sizes: %v", err)
+		return capacity
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dbCapacity models.DatabaseCapacity
+		if err := rows.Scan(&dbCapacity.Name, &dbCapacity.SizeBytes, &dbCapacity.Connections); err != nil {
+			capacity.Status = "error"
+			capacity.Error = fmt.Sprintf("failed to scan database size: %v", err)
+			return capacity
+		}
+		capacity.TotalSizeBytes += dbCapacity.SizeBytes
+		capacity.Connections += dbCapacity.Connections
+		capacity.Databases = append(capacity.Databases, dbCapacity)
+	}
+
+	return capacity
+}
+
+// attachGrowth computes per-day growth over the last 7 and 30 days from the oldest sample in each window
+func (s *CapacityService) attachGrowth(capacity *models.ConnectionCapacity, now time.Time) {
+	for i := range capacity.Databases {
+		db := &capacity.Databases[i]
+		db.Growth.BytesPerDay7d = s.growthSince(capacity.ConnectionID, db, now.Add(-7*24*time.Hour), now)
+		db.Growth.BytesPerDay30d = s.growthSince(capacity.ConnectionID, db, now.Add(-30*24*time.Hour), now)
+	}
+}
+
+// growthSince returns the average bytes/day since the oldest sample after since
+func (s *CapacityService) growthSince(connectionID string, db *models.DatabaseCapacity, since, now time.Time) *float64 {
+	var sample models.CapacitySample
+	err := s.db.Where("connection_id = ? AND database_name = ? AND collected_at >= ?", connectionID, db.Name, since).
+		Order("collected_at ASC").First(&sample).Error
+	if err != nil {
+		return nil
+	}
+
+	days := now.Sub(sample.CollectedAt).Hours() / 24
+	if days < 1.0/24 {
+		return nil
+	}
+
+	rate := float64(db.SizeBytes-sample.SizeBytes) / days
+	return &rate
+}
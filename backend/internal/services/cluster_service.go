@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// clusterLeaderLockKey is the PostgreSQL advisory lock held by the leader ("truadmin" in ASCII)
+const clusterLeaderLockKey int64 = 0x74727561646d696e
+
+// clusterQueryTimeout bounds each election and heartbeat query
+const clusterQueryTimeout = 5 * time.Second
+
+// clusterReplicaRetention is how long the rows of stopped replicas are kept for the status page
+const clusterReplicaRetention = 24 * time.Hour
+
+// interruptedWork is background work recorded with the replica that runs it
+type interruptedWork interface {
+	// FailInterrupted marks work of replicas that are not in liveReplicas as failed
+	FailInterrupted(liveReplicas []string) error
+}
+
+// ClusterService coordinates replicas sharing the internal database. One replica at a time holds
+// a session-level advisory lock and is the leader, which alone runs the scheduler jobs; when its
+// session ends PostgreSQL releases the lock and another replica takes over on its next attempt.
+// Every replica records a heartbeat with the health of its workers.
+type ClusterService struct {
+	db                *gorm.DB
+	scheduler         *SchedulerService
+	replicaID         string
+	hostname          string
+	startedAt         time.Time
+	electionEnabled   bool
+	heartbeatInterval time.Duration
+	staleAfter        time.Duration
+	work              []interruptedWork
+	stop              chan struct{}
+	wg                sync.WaitGroup
+	logger            *slog.Logger
+
+	mu          sync.Mutex
+	leaderConn  *sql.Conn // Session holding the leader lock
+	leaderSince *time.Time
+	started     bool
+}
+
+// NewClusterService creates a new cluster service. The replica ID is name (the hostname when empty)
+// with a random suffix, so a restarted process never takes over the work of its predecessor. Without
+// election every replica acts as the leader. Replicas without a heartbeat for staleAfter are gone.
+func NewClusterService(name string, scheduler *SchedulerService, electionEnabled bool, heartbeatInterval, staleAfter time.Duration, logger *slog.Logger) *ClusterService {
+	hostname, _ := os.Hostname()
+	if name == "" {
+		name = hostname
+	}
+	if name == "" {
+		name = "truadmin"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+
+	if heartbeatInterval <= 0 {
+		heartbeatInterval = 15 * time.Second
+	}
+	if staleAfter < 2*heartbeatInterval {
+		staleAfter = 2 * heartbeatInterval
+	}
+
+	return &ClusterService{
+		db:                database.GetDB(),
+		scheduler:         scheduler,
+		replicaID:         name + "-" + hex.EncodeToString(suffix),
+		hostname:          hostname,
+		startedAt:         time.Now().UTC(),
+		electionEnabled:   electionEnabled,
+		heartbeatInterval: heartbeatInterval,
+		staleAfter:        staleAfter,
+		stop:              make(chan struct{}),
+		logger:            logging.OrDefault(logger),
+	}
+}
+
+// ReplicaID returns the ID of this replica
+func (s *ClusterService) ReplicaID() string {
+	return s.replicaID
+}
+
+// IsLeader reports whether this replica runs the scheduler jobs
+func (s *ClusterService) IsLeader() bool {
+	if !s.electionEnabled {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leaderConn != nil
+}
+
+// TrackInterrupted registers work that RecoverInterrupted fails when its replica is gone
+func (s *ClusterService) TrackInterrupted(work ...interruptedWork) {
+	s.work = append(s.work, work...)
+}
+
+// Start runs the first election and heartbeat, then repeats them every heartbeat interval
+func (s *ClusterService) Start() {
+	s.mu.Lock()
+	if s.started || s.db == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.started = true
+	s.mu.Unlock()
+
+	s.tick()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.heartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.tick()
+			}
+		}
+	}()
+
+	s.logger.Info("replica started", "replica_id", s.replicaID, "leader_election", s.electionEnabled)
+}
+
+// Stop ends the heartbeat, gives up leadership and removes this replica's row
+func (s *ClusterService) Stop() {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = false
+	close(s.stop)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	s.mu.Lock()
+	s.releaseLeadership()
+	s.mu.Unlock()
+
+	if err := s.db.Delete(&models.ClusterReplica{}, "id = ?", s.replicaID).Error; err != nil {
+		s.logger.Warn("failed to remove replica", "replica_id", s.replicaID, "error", err)
+	}
+}
+
+// GetStatus returns the replicas that reported within the retention period, the leader first
+func (s *ClusterService) GetStatus() (*models.ClusterStatus, error) {
+	var replicas []models.ClusterReplica
+	if err := s.db.Where("last_heartbeat_at >= ?", time.Now().UTC().Add(-clusterReplicaRetention)).
+		Order("leader DESC, started_at").Find(&replicas).Error; err != nil {
+		return nil, fmt.Errorf("failed to get cluster replicas: %w", err)
+	}
+
+	status := &models.ClusterStatus{
+		ReplicaID:      s.replicaID,
+		LeaderElection: s.electionEnabled,
+		Replicas:       replicas,
+	}
+	staleBefore := time.Now().UTC().Add(-s.staleAfter)
+	for i := range status.Replicas {
+		replica := &status.Replicas[i]
+		replica.Healthy = !replica.LastHeartbeatAt.Before(staleBefore) && workersHealthy(replica.Workers)
+		if replica.Leader && !replica.LastHeartbeatAt.Before(staleBefore) && status.LeaderID == "" {
+			status.LeaderID = replica.ID
+		}
+	}
+	return status, nil
+}
+
+// LiveReplicaIDs returns the replicas with a recent heartbeat, including this one
+func (s *ClusterService) LiveReplicaIDs() ([]string, error) {
+	var ids []string
+	if err := s.db.Model(&models.ClusterReplica{}).
+		Where("last_heartbeat_at >= ?", time.Now().UTC().Add(-s.staleAfter)).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to get live cluster replicas: %w", err)
+	}
+
+	for _, id := range ids {
+		if id == s.replicaID {
+			return ids, nil
+		}
+	}
+	return append(ids, s.replicaID), nil
+}
+
+// RecoverInterrupted fails the tracked work of replicas that stopped or crashed
+func (s *ClusterService) RecoverInterrupted() error {
+	live, err := s.LiveReplicaIDs()
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, work := range s.work {
+		if err := work.FailInterrupted(live); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// PruneReplicas removes the rows of replicas gone for longer than the retention period
+func (s *ClusterService) PruneReplicas() error {
+	cutoff := time.Now().UTC().Add(-clusterReplicaRetention)
+	if err := s.db.Where("last_heartbeat_at < ?", cutoff).Delete(&models.ClusterReplica{}).Error; err != nil {
+		return fmt.Errorf("failed to prune cluster replicas: %w", err)
+	}
+	return nil
+}
+
+// tick runs an election and records a heartbeat
+func (s *ClusterService) tick() {
+	if s.electionEnabled {
+		s.elect()
+	}
+	if err := s.heartbeat(); err != nil {
+		s.logger.Warn("failed to send heartbeat", "replica_id", s.replicaID, "error", err)
+	}
+}
+
+// elect checks that the leader session is still alive or tries to take the leader lock
+func (s *ClusterService) elect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), clusterQueryTimeout)
+	defer cancel()
+
+	if s.leaderConn != nil {
+		// The lock lives as long as the session; if the session broke, it is gone
+		if _, err := s.leaderConn.ExecContext(ctx, "SELECT 1"); err == nil {
+			return
+		}
+		s.logger.Warn("replica lost the leader session", "replica_id", s.replicaID)
+		s.leaderConn.Close()
+		s.leaderConn = nil
+		s.leaderSince = nil
+	}
+
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		s.logger.Warn("failed to get database instance", "error", err)
+		return
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		s.logger.Warn("failed to open leader session", "error", err)
+		return
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", clusterLeaderLockKey).Scan(&acquired); err != nil || !acquired {
+		if err != nil {
+			s.logger.Warn("failed to try the leader lock", "error", err)
+		}
+		conn.Close()
+		return
+	}
+
+	now := time.Now().UTC()
+	s.leaderConn = conn
+	s.leaderSince = &now
+	s.logger.Info("replica is now the leader", "replica_id", s.replicaID)
+}
+
+// releaseLeadership unlocks the leader lock and returns the session to the pool; callers hold s.mu
+func (s *ClusterService) releaseLeadership() {
+	if s.leaderConn == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clusterQueryTimeout)
+	defer cancel()
+	if _, err := s.leaderConn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", clusterLeaderLockKey); err != nil {
+		s.logger.Warn("failed to release the leader lock", "error", err)
+	}
+	s.leaderConn.Close()
+	s.leaderConn = nil
+	s.leaderSince = nil
+}
+
+// heartbeat records this replica with its leadership and worker health
+func (s *ClusterService) heartbeat() error {
+	s.mu.Lock()
+	leaderSince := s.leaderSince
+	s.mu.Unlock()
+
+	replica := &models.ClusterReplica{
+		ID:              s.replicaID,
+		Hostname:        s.hostname,
+		PID:             os.Getpid(),
+		Leader:          s.IsLeader(),
+		LeaderSince:     leaderSince,
+		Workers:         models.WorkerStatusList{},
+		StartedAt:       s.startedAt,
+		LastHeartbeatAt: time.Now().UTC(),
+	}
+	if !s.electionEnabled {
+		replica.LeaderSince = &s.startedAt
+	}
+	if s.scheduler != nil {
+		replica.Workers = s.scheduler.Status()
+	}
+
+	if err := s.db.Save(replica).Error; err != nil {
+		return fmt.Errorf("failed to record cluster heartbeat: %w", err)
+	}
+	return nil
+}
+
+// workersHealthy reports whether the last run of every worker succeeded
+func workersHealthy(workers models.WorkerStatusList) bool {
+	for _, worker := range workers {
+		if worker.LastError != "" {
+			return false
+		}
+	}
+	return true
+}
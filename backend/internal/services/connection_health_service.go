@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// healthCheckTimeout bounds a single connection ping. Opening a pool has no timeout of its own,
+// so an unreachable host is reported as down after this long.
+const healthCheckTimeout = 10 * time.Second
+
+// ConnectionHealthService pings every saved connection in the background and keeps the history of
+// the results, so connection lists can show their status without testing each one on demand
+type ConnectionHealthService struct {
+	db                *gorm.DB
+	connectionService *ConnectionService
+	retention         time.Duration
+}
+
+// NewConnectionHealthService creates a new connection health service.
+// Checks older than retention are removed by CheckAll.
+func NewConnectionHealthService(connectionService *ConnectionService, retention time.Duration) *ConnectionHealthService {
+	return &ConnectionHealthService{
+		db:                database.GetDB(),
+		connectionService: connectionService,
+		retention:         retention,
+	}
+}
+
+// CheckAll pings every saved connection concurrently, records the results and prunes old checks;
+// it is registered as the connection_health job type
+func (s *ConnectionHealthService) CheckAll() error {
+	connections, err := s.connectionService.GetAllConnections()
+	if err != nil {
+		return err
+	}
+
+	checks := make([]models.ConnectionHealthCheck, len(connections))
+	var wg sync.WaitGroup
+	for i, conn := range connections {
+		wg.Add(1)
+		go func(i int, conn *models.Connection) {
+			defer wg.Done()
+			checks[i] = s.check(conn)
+		}(i, conn)
+	}
+	wg.Wait()
+
+	if len(checks) > 0 {
+		if err := s.db.Create(&checks).Error; err != nil {
+			return fmt.Errorf("failed to record connection health checks: %w", err)
+		}
+	}
+
+	if s.retention > 0 {
+		cutoff := time.Now().UTC().Add(-s.retention)
+		if err := s.db.Where("checked_at < ?", cutoff).Delete(&models.ConnectionHealthCheck{}).Error; err != nil {
+			return fmt.Errorf("failed to prune connection health checks: %w", err)
+		}
+	}
+	return nil
+}
+
+// check pings the default database of a connection through its shared pool
+func (s *ConnectionHealthService) check(conn *models.Connection) models.ConnectionHealthCheck {
+	check := models.ConnectionHealthCheck{
+		ConnectionID: conn.ID,
+		Status:       models.ConnectionHealthUp,
+		CheckedAt:    time.Now().UTC(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		db, _, err := s.connectionService.pools.get(conn, "")
+		if err == nil {
+			err = db.PingContext(ctx)
+		}
+		done <- err
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("no response within %s", healthCheckTimeout)
+	}
+
+	check.LatencyMs = time.Since(check.CheckedAt).Milliseconds()
+	if err != nil {
+		check.Status = models.ConnectionHealthDown
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// GetHealth returns the latest health of every saved connection; connections not checked yet are unknown
+func (s *ConnectionHealthService) GetHealth() ([]models.ConnectionHealth, error) {
+	connections, err := s.connectionService.GetAllConnections()
+	if err != nil {
+		return nil, err
+	}
+
+	var latest []models.ConnectionHealthCheck
+	if err := s.db.Raw(`
+		SELECT DISTINCT ON (connection_id) *
+		FROM connection_health_checks
+		ORDER BY connection_id, checked_at DESC
+	`).Scan(&latest).Error; err != nil {
+		return nil, fmt.Errorf("failed to get connection health: %w", err)
+	}
+	byConnection := make(map[string]models.ConnectionHealthCheck, len(latest))
+	for _, check := range latest {
+		byConnection[check.ConnectionID] = check
+	}
+
+	uptime, err := s.uptimeRatios(time.Now().UTC().Add(-24 * time.Hour))
+	if err != nil {
+		return nil, err
+	}
+
+	health := make([]models.ConnectionHealth, 0, len(connections))
+	for _, conn := range connections {
+		item := models.ConnectionHealth{
+			ConnectionID: conn.ID,
+			Name:         conn.Name,
+			Type:         conn.Type,
+			Status:       models.ConnectionHealthUnknown,
+		}
+		if check, ok := byConnection[conn.ID]; ok {
+			checkedAt := check.CheckedAt
+			item.Status = check.Status
+			item.LatencyMs = check.LatencyMs
+			item.Error = check.Error
+			item.CheckedAt = &checkedAt
+			if since, err := s.statusSince(check); err == nil {
+				item.StatusSince = since
+			}
+		}
+		if ratio, ok := uptime[conn.ID]; ok {
+			item.UptimeRatio = &ratio
+		}
+		health = append(health, item)
+	}
+	return health, nil
+}
+
+// GetHistory returns the checks of a connection in a time range, newest first
+func (s *ConnectionHealthService) GetHistory(connectionID string, from, to time.Time, page Page) ([]models.ConnectionHealthCheck, int64, error) {
+	var checks []models.ConnectionHealthCheck
+
+	query := s.db.Where("connection_id = ? AND checked_at >= ? AND checked_at <= ?", connectionID, from, to).
+		Order("checked_at DESC")
+
+	total, err := findPage(query, page, &checks)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get connection health history: %w", err)
+	}
+	return models.NonNil(checks), total, nil
+}
+
+// uptimeRatios returns the share of up checks since a time, by connection ID
+func (s *ConnectionHealthService) uptimeRatios(since time.Time) (map[string]float64, error) {
+	var rows []struct {
+		ConnectionID string
+		Up           int64
+		Total        int64
+	}
+	if err := s.db.Model(&models.ConnectionHealthCheck{}).
+		Select("connection_id, SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS up, COUNT(*) AS total", models.ConnectionHealthUp).
+		Where("checked_at >= ?", since).
+		Group("connection_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get connection uptime: %w", err)
+	}
+
+	ratios := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		if row.Total > 0 {
+			ratios[row.ConnectionID] = float64(row.Up) / float64(row.Total)
+		}
+	}
+	return ratios, nil
+}
+
+// statusSince returns when a connection entered its latest status: the check after the last one
+// with a different status, or the oldest kept check when the status never changed
+func (s *ConnectionHealthService) statusSince(latest models.ConnectionHealthCheck) (*time.Time, error) {
+	var changedAt []time.Time
+	if err := s.db.Model(&models.ConnectionHealthCheck{}).
+		Where("connection_id = ? AND status <> ?", latest.ConnectionID, latest.Status).
+		Order("checked_at DESC").Limit(1).
+		Pluck("checked_at", &changedAt).Error; err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&models.ConnectionHealthCheck{}).Where("connection_id = ?", latest.ConnectionID)
+	if len(changedAt) > 0 {
+		query = query.Where("checked_at > ?", changedAt[0])
+	}
+	var since []time.Time
+	if err := query.Order("checked_at ASC").Limit(1).Pluck("checked_at", &since).Error; err != nil {
+		return nil, err
+	}
+	if len(since) == 0 {
+		return nil, nil
+	}
+	return &since[0], nil
+}
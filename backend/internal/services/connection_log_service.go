@@ -1,32 +1,37 @@
 package services
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"time"
 
 	"gorm.io/gorm"
 
 	"truadmin/internal/database"
+	"truadmin/internal/logging"
 	"truadmin/internal/models"
 )
 
 // ConnectionLogService handles logging of Connection operations
 type ConnectionLogService struct {
-	db *gorm.DB
+	db    *gorm.DB
+	audit *AuditService
 }
 
-// NewConnectionLogService creates a new Connection log service
-func NewConnectionLogService() *ConnectionLogService {
+// NewConnectionLogService creates a new Connection log service; operations are also shipped to the audit sinks
+func NewConnectionLogService(audit *AuditService) *ConnectionLogService {
 	return &ConnectionLogService{
-		db: database.GetDB(),
+		db:    database.GetDB(),
+		audit: audit,
 	}
 }
 
 // LogOperation logs a connection operation
 func (s *ConnectionLogService) LogOperation(
+	ctx context.Context,
 	connectionID string,
 	userID string,
-	operation string, // "create", "update", "delete"
+	operation string, // "create", "update", "delete", "restore"
 	status models.ConnectionSaveLogStatus,
 	changesSummary models.ConnectionChangesSummary,
 	errorMessage string,
@@ -38,52 +43,64 @@ func (s *ConnectionLogService) LogOperation(
 		Status:         status,
 		ChangesSummary: changesSummary,
 		ErrorMessage:   errorMessage,
+		RequestID:      logging.RequestID(ctx),
 		CreatedAt:      time.Now(),
 	}
 
+	s.audit.Record(models.AuditEvent{
+		Source:       "connection",
+		Action:       operation,
+		Status:       models.AuditEventStatus(status),
+		ActorID:      userID,
+		ConnectionID: connectionID,
+		TargetID:     connectionID,
+		Message:      errorMessage,
+		RequestID:    logging.RequestID(ctx),
+	})
+
 	if err := s.db.Create(&logEntry).Error; err != nil {
-		log.Printf("ERROR: Failed to log Connection operation: %v", err)
-		log.Printf("  connectionID: %s, userID: %s, operation: %s, status: %s", connectionID, userID, operation, status)
+		slog.ErrorContext(ctx, "failed to log connection operation", "connection_id", connectionID, "user_id", userID, "operation", operation, "status", status, "error", err)
 		return err
 	}
 
-	log.Printf("✅ Logged Connection operation: connectionID=%s, userID=%s, operation=%s, status=%s",
-		connectionID, userID, operation, status)
+	slog.InfoContext(ctx, "logged connection operation", "connection_id", connectionID, "user_id", userID, "operation", operation, "status", status)
 	return nil
 }
 
 // GetLogs retrieves logs for connections
-func (s *ConnectionLogService) GetLogs(limit int) ([]models.ConnectionSaveLog, error) {
+func (s *ConnectionLogService) GetLogs(page Page) ([]models.ConnectionSaveLog, int64, error) {
 	var logs []models.ConnectionSaveLog
 
 	query := s.db.Order("created_at DESC")
 
-	if limit > 0 {
-		query = query.Limit(limit)
-	}
-
-	if err := query.Find(&logs).Error; err != nil {
-		return nil, err
+	total, err := findPage(query, page, &logs)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return logs, nil
+	return logs, total, nil
 }
 
 // GetLogsByConnection retrieves logs for a specific connection
-func (s *ConnectionLogService) GetLogsByConnection(connectionID string, limit int) ([]models.ConnectionSaveLog, error) {
+func (s *ConnectionLogService) GetLogsByConnection(connectionID string, page Page) ([]models.ConnectionSaveLog, int64, error) {
 	var logs []models.ConnectionSaveLog
 
 	query := s.db.Where("connection_id = ?", connectionID).
 		Order("created_at DESC")
 
-	if limit > 0 {
-		query = query.Limit(limit)
+	total, err := findPage(query, page, &logs)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if err := query.Find(&logs).Error; err != nil {
-		return nil, err
-	}
-
-	return logs, nil
+	return logs, total, nil
 }
 
+// ExportLogs streams the connection logs matching the filter, optionally of one connection, oldest first
+func (s *ConnectionLogService) ExportLogs(connectionID string, filter models.LogExportFilter, fn func(*models.ConnectionSaveLog) error) error {
+	query := s.db
+	if connectionID != "" {
+		query = query.Where("connection_id = ?", connectionID)
+	}
+	return streamLogs(query, filter, "operation", fn)
+}
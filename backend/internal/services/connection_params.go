@@ -0,0 +1,90 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"truadmin/internal/models"
+)
+
+// connectionParamName matches the names of extra connection parameters; SQL Server names may
+// contain spaces, e.g. "app name"
+var connectionParamName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*( [A-Za-z0-9_.-]+)*$`)
+
+// connectionAllowedParams lists, per connection type, the extra parameters that may be set: timeouts,
+// client identification and session settings. Anything else is rejected, as driver options such as
+// MySQL's allowAllFiles or the lib/pq sslkey and passfile paths would let whoever edits a connection
+// read files on the truadmin host or weaken authentication. Names match case-insensitively.
+var connectionAllowedParams = map[string][]string{
+	"postgres": {
+		"connect_timeout", "application_name", "fallback_application_name", "sslsni",
+		"search_path", "statement_timeout", "lock_timeout", "idle_in_transaction_session_timeout",
+		"timezone", "datestyle", "client_encoding", "default_transaction_read_only", "options",
+	},
+	"mysql":   mysqlAllowedParams,
+	"mariadb": mysqlAllowedParams,
+	"mssql": {
+		"app name", "connection timeout", "dial timeout", "keepalive", "packet size",
+		"applicationintent", "multisubnetfailover", "workstation id",
+	},
+	"snowflake": {
+		"warehouse", "role", "schema", "logintimeout", "requesttimeout", "clienttimeout",
+		"client_session_keep_alive", "timezone", "query_tag",
+	},
+}
+
+// mysqlAllowedParams holds the driver options and session variables allowed for MySQL and MariaDB
+var mysqlAllowedParams = []string{
+	"timeout", "readtimeout", "writetimeout", "charset", "collation", "loc", "maxallowedpacket",
+	"clientfoundrows", "columnswithalias", "rejectreadonly", "checkconnliveness", "connectionattributes",
+	"sql_mode", "time_zone", "transaction_isolation", "wait_timeout", "net_read_timeout",
+	"net_write_timeout", "max_execution_time", "innodb_lock_wait_timeout", "lock_wait_timeout",
+}
+
+// connectionParamAllowed reports whether an extra parameter may be set on a connection of a type
+func connectionParamAllowed(connType, key string) bool {
+	return slices.ContainsFunc(connectionAllowedParams[connType], func(allowed string) bool {
+		return strings.EqualFold(key, allowed)
+	})
+}
+
+// validateConnectionParams checks the extra parameters of a connection of a type
+func validateConnectionParams(connType string, params models.ConnectionParams) error {
+	for _, key := range params.Keys() {
+		if !connectionParamName.MatchString(key) || (connType != "mssql" && strings.Contains(key, " ")) {
+			return fmt.Errorf("invalid extra parameter name: %q", key)
+		}
+		if !connectionParamAllowed(connType, key) {
+			return fmt.Errorf("extra parameter %q is not supported for %s connections", key, connType)
+		}
+	}
+	return nil
+}
+
+// driverParams returns the extra parameters of a connection passed to the driver of connType.
+// Parameters stored before the allowlist existed are dropped rather than trusted.
+func driverParams(connType string, conn *models.Connection) models.ConnectionParams {
+	params := models.ConnectionParams{}
+	for key, value := range conn.ExtraParams {
+		if !connectionParamAllowed(connType, key) || !connectionParamName.MatchString(key) {
+			slog.Warn("ignoring unsupported extra connection parameter", "connection_id", conn.ID, "param", key)
+			continue
+		}
+		params[key] = value
+	}
+	return params
+}
+
+// postgresParams formats extra parameters as keyword=value pairs of a lib/pq connection string, each
+// value quoted so spaces and quotes pass through
+func postgresParams(params models.ConnectionParams) string {
+	var b strings.Builder
+	for _, key := range params.Keys() {
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(params[key])
+		fmt.Fprintf(&b, " %s='%s'", key, value)
+	}
+	return b.String()
+}
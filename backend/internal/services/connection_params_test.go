@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+
+	"truadmin/internal/models"
+)
+
+func TestValidateConnectionParams(t *testing.T) {
+	tests := []struct {
+		connType string
+		key      string
+		wantErr  bool
+	}{
+		{"postgres", "connect_timeout", false},
+		{"postgres", "Application_Name", false},
+		{"postgres", "sslkey", true},
+		{"postgres", "sslcert", true},
+		{"postgres", "sslrootcert", true},
+		{"postgres", "passfile", true},
+		{"postgres", "password", true},
+		{"mysql", "timeout", false},
+		{"mysql", "sql_mode", false},
+		{"mysql", "allowAllFiles", true},
+		{"mysql", "allowCleartextPasswords", true},
+		{"mysql", "allowOldPasswords", true},
+		{"mysql", "multiStatements", true},
+		{"mariadb", "charset", false},
+		{"mssql", "app name", false},
+		{"mssql", "certificate", true},
+		{"snowflake", "warehouse", false},
+		{"snowflake", "authenticator", true},
+		{"postgres", "bad key", true},
+	}
+
+	for _, tt := range tests {
+		err := validateConnectionParams(tt.connType, models.ConnectionParams{tt.key: "x"})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateConnectionParams(%s, %q) error = %v, want error %v", tt.connType, tt.key, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDriverParamsDropsUnsupported(t *testing.T) {
+	conn := &models.Connection{ID: "c1", Type: "mysql", ExtraParams: models.ConnectionParams{
+		"timeout":       "5s",
+		"allowAllFiles": "true",
+	}}
+
+	params := driverParams("mysql", conn)
+	if _, ok := params["allowAllFiles"]; ok {
+		t.Errorf("driverParams kept allowAllFiles: %v", params)
+	}
+	if params["timeout"] != "5s" {
+		t.Errorf("driverParams dropped timeout: %v", params)
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// PoolConfig holds the limits applied to every pool of the ConnectionPoolManager
+type PoolConfig struct {
+	MaxOpenConns    int           // Per database; zero means unlimited
+	MaxIdleConns    int           // Per database
+	IdleTimeout     time.Duration // Idle connections are closed, and whole pools unused for this long are dropped
+	ConnMaxLifetime time.Duration // Zero means connections are reused forever
+}
+
+// poolKey identifies the pool of one database of a saved connection
+type poolKey struct {
+	connectionID string
+	dbName       string
+}
+
+// connectionPool is a shared sql.DB together with what is needed to detect that it went stale
+type connectionPool struct {
+	db        *sql.DB
+	dialect   dialect
+	version   time.Time // UpdatedAt of the connection the pool was opened with
+	createdAt time.Time
+	lastUsed  time.Time
+}
+
+// ConnectionPoolManager shares one sql.DB per saved connection and database across services,
+// instead of opening and closing a pool on every call. Pools are dropped when their connection
+// is edited or deleted, and when they have not been used for the idle timeout.
+// Handles returned by the manager are shared and must not be closed by callers.
+type ConnectionPoolManager struct {
+	cfg    PoolConfig
+	mu     sync.Mutex
+	pools  map[poolKey]*connectionPool
+	stop   chan struct{}
+	once   sync.Once
+	logger *slog.Logger
+}
+
+// NewConnectionPoolManager creates a new pool manager and starts evicting idle pools
+func NewConnectionPoolManager(cfg PoolConfig, logger *slog.Logger) *ConnectionPoolManager {
+	m := &ConnectionPoolManager{
+		cfg:    cfg,
+		pools:  map[poolKey]*connectionPool{},
+		stop:   make(chan struct{}),
+		logger: logging.OrDefault(logger),
+	}
+
+	if cfg.IdleTimeout > 0 {
+		go m.evictLoop()
+	}
+
+	return m
+}
+
+// get returns the shared pool for a database of a connection, opening it on first use
+func (m *ConnectionPoolManager) get(conn *models.Connection, dbName string) (*sql.DB, dialect, error) {
+	if dbName == "" {
+		dbName = conn.Database
+	}
+	key := poolKey{connectionID: conn.ID, dbName: dbName}
+
+	m.mu.Lock()
+	if pool, ok := m.pools[key]; ok {
+		if pool.version.Equal(conn.UpdatedAt) {
+			pool.lastUsed = time.Now()
+			m.mu.Unlock()
+			return pool.db, pool.dialect, nil
+		}
+		// The connection was edited elsewhere (e.g. by another instance)
+		delete(m.pools, key)
+		go pool.db.Close()
+	}
+	m.mu.Unlock()
+
+	// Opened outside the lock so a slow server does not block other pools
+	db, d, err := openConnection(conn, dbName)
+	if err != nil {
+		return nil, nil, err
+	}
+	db.SetMaxOpenConns(m.cfg.MaxOpenConns)
+	db.SetMaxIdleConns(m.cfg.MaxIdleConns)
+	db.SetConnMaxIdleTime(m.cfg.IdleTimeout)
+	db.SetConnMaxLifetime(m.cfg.ConnMaxLifetime)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.pools[key]; ok && existing.version.Equal(conn.UpdatedAt) {
+		// Another caller opened the same pool meanwhile
+		db.Close()
+		existing.lastUsed = time.Now()
+		return existing.db, existing.dialect, nil
+	}
+	now := time.Now()
+	m.pools[key] = &connectionPool{db: db, dialect: d, version: conn.UpdatedAt, createdAt: now, lastUsed: now}
+	return db, d, nil
+}
+
+// Invalidate closes every pool of a connection; it is called when the connection is edited or deleted
+func (m *ConnectionPoolManager) Invalidate(connectionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, pool := range m.pools {
+		if key.connectionID == connectionID {
+			delete(m.pools, key)
+			// Queries still running on the pool finish before it closes
+			go pool.db.Close()
+		}
+	}
+}
+
+// Stats returns the state of every open pool, ordered by connection and database
+func (m *ConnectionPoolManager) Stats() []models.ConnectionPoolStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]models.ConnectionPoolStats, 0, len(m.pools))
+	for key, pool := range m.pools {
+		dbStats := pool.db.Stats()
+		stats = append(stats, models.ConnectionPoolStats{
+			ConnectionID:    key.connectionID,
+			DatabaseName:    key.dbName,
+			OpenConnections: dbStats.OpenConnections,
+			InUse:           dbStats.InUse,
+			Idle:            dbStats.Idle,
+			MaxOpen:         dbStats.MaxOpenConnections,
+			WaitCount:       dbStats.WaitCount,
+			WaitDurationMs:  dbStats.WaitDuration.Milliseconds(),
+			CreatedAt:       pool.createdAt,
+			LastUsedAt:      pool.lastUsed,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].ConnectionID != stats[j].ConnectionID {
+			return stats[i].ConnectionID < stats[j].ConnectionID
+		}
+		return stats[i].DatabaseName < stats[j].DatabaseName
+	})
+	return stats
+}
+
+// Close stops idle eviction and closes all pools
+func (m *ConnectionPoolManager) Close() {
+	m.once.Do(func() {
+		close(m.stop)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for key, pool := range m.pools {
+			pool.db.Close()
+			delete(m.pools, key)
+		}
+	})
+}
+
+// evictLoop drops pools unused for longer than the idle timeout until Close is called
+func (m *ConnectionPoolManager) evictLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.evictIdle(time.Now())
+		}
+	}
+}
+
+// evictIdle closes pools that have not been used since the idle timeout and have no queries running
+func (m *ConnectionPoolManager) evictIdle(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, pool := range m.pools {
+		if now.Sub(pool.lastUsed) > m.cfg.IdleTimeout && pool.db.Stats().InUse == 0 {
+			delete(m.pools, key)
+			pool.db.Close()
+			m.logger.Info("closed idle connection pool", "connection_id", key.connectionID, "database", key.dbName)
+		}
+	}
+}
+
+// withSession runs fn on a dedicated connection of a shared pool and resets the session afterwards,
+// so settings, roles or transactions left open by user SQL never leak to other callers of the pool.
+// When the session cannot be reset the connection is discarded instead of returned to the pool.
+func withSession(ctx context.Context, db *sql.DB, d dialect, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	fnErr := fn(conn)
+
+	reset := d.resetSessionStatement()
+	if reset == "" {
+		discardConn(conn)
+	} else if _, err := conn.ExecContext(context.Background(), reset); err != nil {
+		discardConn(conn)
+	}
+
+	return fnErr
+}
+
+// discardConn makes database/sql close the physical connection instead of returning it to the pool
+func discardConn(conn *sql.Conn) {
+	conn.Raw(func(driverConn any) error {
+		return driver.ErrBadConn
+	})
+}
@@ -1,31 +1,42 @@
 package services
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
 	"truadmin/internal/database"
+	"truadmin/internal/logging"
 	"truadmin/internal/models"
 )
 
 // ConnectionService handles business logic for database connections
 type ConnectionService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	pools  *ConnectionPoolManager
+	logger *slog.Logger
 }
 
 // NewConnectionService creates a new connection service
-func NewConnectionService() *ConnectionService {
+func NewConnectionService(pools *ConnectionPoolManager, logger *slog.Logger) *ConnectionService {
 	return &ConnectionService{
-		db: database.GetDB(),
+		db:     database.GetDB(),
+		pools:  pools,
+		logger: logging.OrDefault(logger),
 	}
 }
 
-// CreateConnection creates a new database connection configuration
-func (s *ConnectionService) CreateConnection(req *models.ConnectionRequest) (*models.Connection, error) {
+// CreateConnection creates a new database connection configuration and records its first revision
+func (s *ConnectionService) CreateConnection(req *models.ConnectionRequest, userID string) (*models.Connection, error) {
+	if err := applyConnectionDSN(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
 	// Validate connection parameters
 	if err := s.validateConnectionRequest(req); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
@@ -41,22 +52,30 @@ func (s *ConnectionService) CreateConnection(req *models.ConnectionRequest) (*mo
 
 	// Create new connection
 	conn := &models.Connection{
-		ID:        uuid.New().String(),
-		Name:      req.Name,
-		Type:      req.Type,
-		Host:      req.Host,
-		Port:      req.Port,
-		Database:  req.Database,
-		Username:  req.Username,
-		Password:  req.Password, // TODO: Encrypt password before storing
-		SSLMode:   req.SSLMode,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:            uuid.New().String(),
+		Name:          req.Name,
+		Type:          req.Type,
+		Host:          req.Host,
+		Port:          req.Port,
+		Database:      req.Database,
+		Username:      req.Username,
+		Password:      req.Password, // TODO: Encrypt password before storing
+		SSLMode:       req.SSLMode,
+		ExtraParams:   req.ExtraParams,
+		RequiresGrant: req.RequiresGrant,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
 	}
 
 	// Save to database
-	if err := s.db.Create(conn).Error; err != nil {
-		return nil, fmt.Errorf("failed to create connection: %w", err)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(conn).Error; err != nil {
+			return fmt.Errorf("failed to create connection: %w", err)
+		}
+		return s.recordRevision(tx, conn.ID, userID, "create", nil, nil, conn)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return conn, nil
@@ -83,20 +102,37 @@ func (s *ConnectionService) GetAllConnections() ([]*models.Connection, error) {
 	return connections, nil
 }
 
-// DeleteConnection removes a connection by ID
-func (s *ConnectionService) DeleteConnection(id string) error {
-	result := s.db.Delete(&models.Connection{}, "id = ?", id)
-	if result.Error != nil {
-		return fmt.Errorf("failed to delete connection: %w", result.Error)
+// DeleteConnection removes a connection by ID; its revision history is kept so it can be restored
+func (s *ConnectionService) DeleteConnection(id string, userID string) error {
+	conn, err := s.GetConnection(id)
+	if err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("connection not found")
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Connection{}, "id = ?", id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete connection: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("connection not found")
+		}
+		return s.recordRevision(tx, id, userID, "delete", nil, conn, conn)
+	})
+	if err != nil {
+		return err
 	}
+
+	s.pools.Invalidate(id)
 	return nil
 }
 
-// UpdateConnection updates an existing connection
-func (s *ConnectionService) UpdateConnection(id string, req *models.ConnectionRequest) (*models.Connection, error) {
+// UpdateConnection updates an existing connection and records the changed fields as a revision
+func (s *ConnectionService) UpdateConnection(id string, req *models.ConnectionRequest, userID string) (*models.Connection, error) {
+	if err := applyConnectionDSN(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
 	// Validate connection parameters
 	if err := s.validateConnectionRequest(req); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
@@ -116,6 +152,8 @@ func (s *ConnectionService) UpdateConnection(id string, req *models.ConnectionRe
 		return nil, fmt.Errorf("failed to check existing connection: %w", err)
 	}
 
+	previous := *conn
+
 	// Update connection fields
 	conn.Name = req.Name
 	conn.Type = req.Type
@@ -123,20 +161,46 @@ func (s *ConnectionService) UpdateConnection(id string, req *models.ConnectionRe
 	conn.Port = req.Port
 	conn.Database = req.Database
 	conn.Username = req.Username
-	if req.Password != "" {
+	if req.Password != "" && req.Password != models.MaskedValue {
 		conn.Password = req.Password // TODO: Encrypt password before storing
 	}
 	conn.SSLMode = req.SSLMode
+	conn.ExtraParams = req.ExtraParams
+	conn.RequiresGrant = req.RequiresGrant
 	conn.UpdatedAt = time.Now()
 
 	// Save to database
-	if err := s.db.Save(conn).Error; err != nil {
-		return nil, fmt.Errorf("failed to update connection: %w", err)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(connectionUsageColumns...).Save(conn).Error; err != nil {
+			return fmt.Errorf("failed to update connection: %w", err)
+		}
+		return s.recordRevision(tx, id, userID, "update", nil, &previous, conn)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	// Pools opened with the old settings must not be reused
+	s.pools.Invalidate(id)
+
 	return conn, nil
 }
 
+// openDatabase returns the shared pool for a database of a connection (the connection's default
+// when dbName is empty). The pool is owned by the pool manager and must not be closed.
+func (s *ConnectionService) openDatabase(connectionID, dbName string) (*sql.DB, dialect, error) {
+	conn, err := s.GetConnection(connectionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.pools.get(conn, dbName)
+}
+
+// PoolStats returns the state of the shared connection pools
+func (s *ConnectionService) PoolStats() []models.ConnectionPoolStats {
+	return s.pools.Stats()
+}
+
 // validateConnectionRequest validates connection request parameters
 func (s *ConnectionService) validateConnectionRequest(req *models.ConnectionRequest) error {
 	if req.Name == "" {
@@ -157,6 +221,9 @@ func (s *ConnectionService) validateConnectionRequest(req *models.ConnectionRequ
 	if req.Username == "" {
 		return fmt.Errorf("username is required")
 	}
+	if req.Password == "" {
+		return fmt.Errorf("password is required")
+	}
 
 	// Validate connection type
 	validTypes := map[string]bool{
@@ -170,6 +237,9 @@ func (s *ConnectionService) validateConnectionRequest(req *models.ConnectionRequ
 	if !validTypes[req.Type] {
 		return fmt.Errorf("invalid connection type: %s", req.Type)
 	}
+	if err := validateConnectionParams(req.Type, req.ExtraParams); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/models"
+)
+
+// GetRevisions returns the revision history of a connection, newest first.
+// History is kept for deleted connections as well.
+func (s *ConnectionService) GetRevisions(connectionID string) ([]models.ConnectionRevision, error) {
+	var revisions []models.ConnectionRevision
+	if err := s.db.Where("connection_id = ?", connectionID).
+		Order("revision DESC").
+		Find(&revisions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get connection revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// RestoreRevision restores a connection to the configuration it had at the given revision.
+// A deleted connection is recreated with its original ID. The restore is recorded as a new revision.
+func (s *ConnectionService) RestoreRevision(connectionID string, revision int, userID string) (*models.Connection, error) {
+	var target models.ConnectionRevision
+	if err := s.db.Where("connection_id = ? AND revision = ?", connectionID, revision).First(&target).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("revision not found")
+		}
+		return nil, fmt.Errorf("failed to get connection revision: %w", err)
+	}
+	if target.Operation == "delete" {
+		return nil, fmt.Errorf("cannot restore to a delete revision")
+	}
+
+	state := target.State
+	// Revisions may hold extra parameters stored before they were restricted
+	if err := validateConnectionParams(state.Type, state.ExtraParams); err != nil {
+		return nil, fmt.Errorf("cannot restore revision: %w", err)
+	}
+	var restored *models.Connection
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Restoring must not clash with another connection that took the name since
+		var existing models.Connection
+		if err := tx.Where("name = ? AND id != ?", state.Name, connectionID).First(&existing).Error; err == nil {
+			return fmt.Errorf("connection with name '%s' already exists", state.Name)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to check existing connection: %w", err)
+		}
+
+		var current models.Connection
+		var previous *models.Connection
+		if err := tx.First(&current, "id = ?", connectionID).Error; err == nil {
+			before := current
+			previous = &before
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get connection: %w", err)
+		} else {
+			current = models.Connection{ID: connectionID, CreatedAt: time.Now()}
+		}
+
+		current.Name = state.Name
+		current.Type = state.Type
+		current.Host = state.Host
+		current.Port = state.Port
+		current.Database = state.Database
+		current.Username = state.Username
+		current.Password = state.Password
+		current.SSLMode = state.SSLMode
+		current.ExtraParams = state.ExtraParams
+		if state.RequiresGrant != nil {
+			current.RequiresGrant = *state.RequiresGrant
+		}
+		current.UpdatedAt = time.Now()
+
+		if err := tx.Omit(connectionUsageColumns...).Save(&current).Error; err != nil {
+			return fmt.Errorf("failed to restore connection: %w", err)
+		}
+
+		restored = &current
+		return s.recordRevision(tx, connectionID, userID, "restore", &revision, previous, &current)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.pools.Invalidate(connectionID)
+
+	return restored, nil
+}
+
+// recordRevision stores the next revision of a connection inside tx.
+// previous is nil for operations that create the connection.
+func (s *ConnectionService) recordRevision(tx *gorm.DB, connectionID, userID, operation string, restoredFrom *int, previous, current *models.Connection) error {
+	var last int
+	if err := tx.Model(&models.ConnectionRevision{}).
+		Where("connection_id = ?", connectionID).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&last).Error; err != nil {
+		return fmt.Errorf("failed to get last connection revision: %w", err)
+	}
+
+	revision := models.ConnectionRevision{
+		ConnectionID: connectionID,
+		Revision:     last + 1,
+		UserID:       userID,
+		Operation:    operation,
+		RestoredFrom: restoredFrom,
+		Changes:      diffConnections(previous, current),
+		State:        models.ConnectionStateOf(current),
+		CreatedAt:    time.Now(),
+	}
+	if operation == "delete" {
+		revision.Changes = models.ConnectionFieldChanges{}
+	}
+
+	if err := tx.Create(&revision).Error; err != nil {
+		return fmt.Errorf("failed to record connection revision: %w", err)
+	}
+	return nil
+}
+
+// diffConnections lists the fields that differ between two connection configurations; passwords are masked
+func diffConnections(previous, current *models.Connection) models.ConnectionFieldChanges {
+	var before models.ConnectionState
+	if previous != nil {
+		before = models.ConnectionStateOf(previous)
+	}
+	after := models.ConnectionStateOf(current)
+
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"name", before.Name, after.Name},
+		{"type", before.Type, after.Type},
+		{"host", before.Host, after.Host},
+		{"port", strconv.Itoa(before.Port), strconv.Itoa(after.Port)},
+		{"database", before.Database, after.Database},
+		{"username", before.Username, after.Username},
+		{"password", before.Password, after.Password},
+		{"ssl_mode", before.SSLMode, after.SSLMode},
+		{"requires_grant", formatOptionalBool(before.RequiresGrant), formatOptionalBool(after.RequiresGrant)},
+		{"extra_params", before.ExtraParams.String(), after.ExtraParams.String()},
+	}
+	if previous == nil {
+		fields[3].old = ""
+		fields[9].old = ""
+	}
+
+	changes := models.ConnectionFieldChanges{}
+	for _, f := range fields {
+		if f.old == f.new {
+			continue
+		}
+		change := models.ConnectionFieldChange{Field: f.name, OldValue: f.old, NewValue: f.new}
+		if f.name == "password" {
+			change.OldValue, change.NewValue = "", models.MaskedValue
+			if f.old != "" {
+				change.OldValue = models.MaskedValue
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// formatOptionalBool formats a revisioned flag, treating a missing value as false
+func formatOptionalBool(value *bool) string {
+	return strconv.FormatBool(value != nil && *value)
+}
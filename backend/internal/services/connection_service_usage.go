@@ -0,0 +1,41 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// connectionUsageColumns are maintained by the activity writer and must not be overwritten
+// when a connection configuration is saved
+var connectionUsageColumns = []string{"usage_count", "last_used_at", "last_used_by"}
+
+// GetStaleConnections returns connections not used for at least the given number of days,
+// longest idle first. Connections that were never used count from their creation.
+func (s *ConnectionService) GetStaleConnections(days int) ([]models.StaleConnection, error) {
+	now := time.Now().UTC()
+	cutoff := now.AddDate(0, 0, -days)
+
+	stale := []models.StaleConnection{}
+	err := s.db.Table("connections c").
+		Select(`c.id, c.name, c.type, c.host, c.database, COALESCE(c.usage_count, 0) AS usage_count,
+			c.last_used_at, COALESCE(c.last_used_by, '') AS last_used_by,
+			COALESCE(u.username, '') AS last_used_by_username, c.created_at`).
+		Joins("LEFT JOIN users u ON u.id = c.last_used_by").
+		Where("COALESCE(c.last_used_at, c.created_at) < ?", cutoff).
+		Order("COALESCE(c.last_used_at, c.created_at) ASC").
+		Scan(&stale).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale connections: %w", err)
+	}
+
+	for i := range stale {
+		since := stale[i].CreatedAt
+		if stale[i].LastUsedAt != nil {
+			since = *stale[i].LastUsedAt
+		}
+		stale[i].IdleDays = int(now.Sub(since).Hours() / 24)
+	}
+	return stale, nil
+}
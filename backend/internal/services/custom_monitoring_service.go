@@ -0,0 +1,402 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+const (
+	// customQueryTimeout bounds a single run of a custom monitoring query
+	customQueryTimeout = 30 * time.Second
+	// customQueryMaxRows caps the rows kept of each run
+	customQueryMaxRows = 100
+)
+
+// ErrInvalidCustomQuery is returned when a custom monitoring query fails validation
+var ErrInvalidCustomQuery = errors.New("invalid custom monitoring query")
+
+// customQueryWriteKeyword finds keywords of statements that write data or create tables
+var customQueryWriteKeyword = regexp.MustCompile(`(?i)\b(insert|update|delete|merge|into)\b`)
+
+// CustomMonitoringService runs admin-defined read-only monitoring queries on every monitoring
+// sample and reports their thresholds as alert statuses
+type CustomMonitoringService struct {
+	db                  *gorm.DB
+	databaseService     *DatabaseService
+	notificationService *NotificationService
+	logger              *slog.Logger
+}
+
+// NewCustomMonitoringService creates a new custom monitoring service
+func NewCustomMonitoringService(databaseService *DatabaseService, notificationService *NotificationService, logger *slog.Logger) *CustomMonitoringService {
+	return &CustomMonitoringService{
+		db:                  database.GetDB(),
+		databaseService:     databaseService,
+		notificationService: notificationService,
+		logger:              logging.OrDefault(logger),
+	}
+}
+
+// GetQueries returns the custom monitoring queries of a connection
+func (s *CustomMonitoringService) GetQueries(connectionID string) ([]models.CustomMonitoringQuery, error) {
+	queries := []models.CustomMonitoringQuery{}
+	if err := s.db.Where("connection_id = ?", connectionID).Order("name").Find(&queries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get custom monitoring queries: %w", err)
+	}
+	return queries, nil
+}
+
+// GetQuery returns a custom monitoring query of a connection
+func (s *CustomMonitoringService) GetQuery(connectionID, id string) (*models.CustomMonitoringQuery, error) {
+	var query models.CustomMonitoringQuery
+	if err := s.db.Where("id = ? AND connection_id = ?", id, connectionID).First(&query).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("custom monitoring query not found")
+		}
+		return nil, fmt.Errorf("failed to get custom monitoring query: %w", err)
+	}
+	return &query, nil
+}
+
+// CreateQuery validates and registers a custom monitoring query, running it once so that
+// invalid SQL is rejected up front
+func (s *CustomMonitoringService) CreateQuery(connectionID string, req *models.CustomMonitoringQueryRequest, createdBy string) (*models.CustomMonitoringQuery, error) {
+	if _, err := s.databaseService.connectionService.GetConnection(connectionID); err != nil {
+		return nil, err
+	}
+
+	query := &models.CustomMonitoringQuery{
+		ID:           uuid.New().String(),
+		ConnectionID: connectionID,
+		CreatedBy:    createdBy,
+		LastStatus:   models.CustomQueryStatusPending,
+	}
+	if err := s.apply(query, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(query).Error; err != nil {
+		return nil, fmt.Errorf("failed to create custom monitoring query: %w", err)
+	}
+	return query, nil
+}
+
+// UpdateQuery validates and replaces the definition of a custom monitoring query
+func (s *CustomMonitoringService) UpdateQuery(connectionID, id string, req *models.CustomMonitoringQueryRequest) (*models.CustomMonitoringQuery, error) {
+	query, err := s.GetQuery(connectionID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(query, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(query).Error; err != nil {
+		return nil, fmt.Errorf("failed to update custom monitoring query: %w", err)
+	}
+	return query, nil
+}
+
+// DeleteQuery removes a custom monitoring query
+func (s *CustomMonitoringService) DeleteQuery(connectionID, id string) error {
+	result := s.db.Where("id = ? AND connection_id = ?", id, connectionID).Delete(&models.CustomMonitoringQuery{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete custom monitoring query: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("custom monitoring query not found")
+	}
+	return nil
+}
+
+// RunQuery runs a custom monitoring query immediately and stores its result
+func (s *CustomMonitoringService) RunQuery(connectionID, id string) (*models.CustomMonitoringQuery, error) {
+	query, err := s.GetQuery(connectionID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.run(query); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// RunAll runs every enabled custom monitoring query; queries of different connections run concurrently
+func (s *CustomMonitoringService) RunAll() error {
+	var queries []models.CustomMonitoringQuery
+	if err := s.db.Where("enabled = ?", true).Order("connection_id, name").Find(&queries).Error; err != nil {
+		return fmt.Errorf("failed to get custom monitoring queries: %w", err)
+	}
+
+	byConnection := map[string][]*models.CustomMonitoringQuery{}
+	for i := range queries {
+		byConnection[queries[i].ConnectionID] = append(byConnection[queries[i].ConnectionID], &queries[i])
+	}
+
+	var wg sync.WaitGroup
+	for _, connQueries := range byConnection {
+		wg.Add(1)
+		go func(connQueries []*models.CustomMonitoringQuery) {
+			defer wg.Done()
+			for _, query := range connQueries {
+				if err := s.run(query); err != nil {
+					s.logger.Warn("failed to store custom monitoring query result", "query", query.Name, "error", err)
+				}
+			}
+		}(connQueries)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// GetAlertStatuses reports the last status of each enabled custom monitoring query of a connection
+func (s *CustomMonitoringService) GetAlertStatuses(connectionID string) ([]models.AlertStatus, error) {
+	var queries []models.CustomMonitoringQuery
+	if err := s.db.Where("connection_id = ? AND enabled = ?", connectionID, true).Order("name").Find(&queries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get custom monitoring queries: %w", err)
+	}
+
+	statuses := []models.AlertStatus{}
+	for _, query := range queries {
+		statuses = append(statuses, models.AlertStatus{
+			Source:    "custom_query",
+			Name:      query.Name,
+			Status:    query.LastStatus,
+			Message:   customQueryMessage(&query),
+			UpdatedAt: query.LastRunAt,
+		})
+	}
+	return statuses, nil
+}
+
+// apply validates a request, test-runs its SQL and copies it onto the query
+func (s *CustomMonitoringService) apply(query *models.CustomMonitoringQuery, req *models.CustomMonitoringQueryRequest) error {
+	direction := req.ThresholdDirection
+	if direction == "" {
+		direction = models.ThresholdAbove
+	}
+	if direction != models.ThresholdAbove && direction != models.ThresholdBelow {
+		return fmt.Errorf("%w: threshold_direction must be %s or %s", ErrInvalidCustomQuery, models.ThresholdAbove, models.ThresholdBelow)
+	}
+	if err := validateCustomQuerySQL(req.Query); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCustomQuery, err)
+	}
+
+	query.Name = req.Name
+	query.Description = req.Description
+	query.DatabaseName = req.DatabaseName
+	query.Query = strings.TrimSpace(req.Query)
+	query.ValueColumn = req.ValueColumn
+	query.WarningThreshold = req.WarningThreshold
+	query.CriticalThreshold = req.CriticalThreshold
+	query.ThresholdDirection = direction
+	query.Enabled = req.Enabled == nil || *req.Enabled
+
+	result, err := s.execute(query)
+	if err != nil {
+		return err
+	}
+	if result.Error != "" {
+		return fmt.Errorf("%w: query failed: %s", ErrInvalidCustomQuery, result.Error)
+	}
+	if (query.WarningThreshold != nil || query.CriticalThreshold != nil) && len(result.Columns) > 0 {
+		if _, err := customQueryValue(query, result); err != nil && !strings.Contains(err.Error(), "no rows") {
+			return fmt.Errorf("%w: %v", ErrInvalidCustomQuery, err)
+		}
+	}
+	return nil
+}
+
+// run executes a custom monitoring query, evaluates its thresholds, stores the result and
+// notifies when the query starts alerting
+func (s *CustomMonitoringService) run(query *models.CustomMonitoringQuery) error {
+	previous := query.LastStatus
+
+	result, err := s.execute(query)
+	if err != nil {
+		result = &models.QueryResult{Error: err.Error()}
+	}
+
+	now := time.Now()
+	query.LastRunAt = &now
+	query.LastError = result.Error
+	query.LastValue = nil
+	query.LastResult = models.CustomQueryResult{
+		Columns:   models.NonNil(result.Columns),
+		Rows:      models.NonNil(result.Rows),
+		Truncated: result.Truncated,
+	}
+
+	if result.Error != "" {
+		query.LastStatus = models.CustomQueryStatusFailing
+	} else {
+		query.LastStatus = models.CustomQueryStatusOK
+		if query.WarningThreshold != nil || query.CriticalThreshold != nil {
+			value, err := customQueryValue(query, result)
+			if err != nil {
+				query.LastStatus = models.CustomQueryStatusFailing
+				query.LastError = err.Error()
+			} else {
+				query.LastValue = &value
+				query.LastStatus = evaluateCustomQueryThresholds(query, value)
+			}
+		}
+	}
+
+	if err := s.db.Model(query).Select("last_run_at", "last_status", "last_value", "last_error", "last_result").Updates(query).Error; err != nil {
+		return fmt.Errorf("failed to update custom monitoring query: %w", err)
+	}
+
+	alerting := query.LastStatus == models.CustomQueryStatusWarning || query.LastStatus == models.CustomQueryStatusCritical || query.LastStatus == models.CustomQueryStatusFailing
+	if alerting && query.LastStatus != previous && s.notificationService != nil {
+		s.notificationService.NotifyConnection(query.ConnectionID, models.AlertRuleCustomQueryThreshold,
+			fmt.Sprintf("Custom monitoring query %s is %s", query.Name, query.LastStatus),
+			customQueryMessage(query))
+	}
+	return nil
+}
+
+// execute runs the SQL of a custom monitoring query in a read-only transaction that is always
+// rolled back, so even statements that slipped through validation cannot change data
+func (s *CustomMonitoringService) execute(query *models.CustomMonitoringQuery) (*models.QueryResult, error) {
+	db, d, err := s.databaseService.connect(query.ConnectionID, query.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), customQueryTimeout)
+	defer cancel()
+
+	var result *models.QueryResult
+	err = withSession(ctx, db, d, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return fmt.Errorf("failed to begin read-only transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		rows, err := tx.QueryContext(ctx, query.Query)
+		result = readQueryResult(rows, err, customQueryMaxRows)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// validateCustomQuerySQL accepts a single statement that only reads data
+func validateCustomQuerySQL(script string) error {
+	statements := splitSQLStatements(script)
+	if len(statements) != 1 {
+		return fmt.Errorf("query must be a single statement")
+	}
+
+	switch sqlStatementKeyword(statements[0]) {
+	case "SELECT", "WITH", "VALUES", "TABLE", "SHOW":
+	default:
+		return fmt.Errorf("query must be read-only (SELECT, WITH, VALUES, TABLE or SHOW)")
+	}
+
+	// WITH may wrap data-modifying statements, which the read-only transaction would reject
+	// only at run time
+	if keyword := customQueryWriteKeyword.FindString(stripSQLComments(statements[0])); keyword != "" {
+		return fmt.Errorf("query must be read-only: %s is not allowed", strings.ToUpper(keyword))
+	}
+	return nil
+}
+
+// customQueryValue reads the value compared to the thresholds: the value column (or first
+// column) of the first row
+func customQueryValue(query *models.CustomMonitoringQuery, result *models.QueryResult) (float64, error) {
+	column := query.ValueColumn
+	if column == "" {
+		if len(result.Columns) == 0 {
+			return 0, fmt.Errorf("query returns no columns")
+		}
+		column = result.Columns[0]
+	}
+	if len(result.Rows) == 0 {
+		return 0, fmt.Errorf("query returned no rows")
+	}
+
+	raw, ok := result.Rows[0][column]
+	if !ok {
+		return 0, fmt.Errorf("value column %s not found in result", column)
+	}
+
+	switch v := raw.(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		value, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("value column %s is not numeric: %q", column, v)
+		}
+		return value, nil
+	case nil:
+		return 0, fmt.Errorf("value column %s is NULL", column)
+	default:
+		value, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("value column %s is not numeric", column)
+		}
+		return value, nil
+	}
+}
+
+// evaluateCustomQueryThresholds returns the status of a value against the query's thresholds
+func evaluateCustomQueryThresholds(query *models.CustomMonitoringQuery, value float64) string {
+	crosses := func(threshold *float64) bool {
+		if threshold == nil {
+			return false
+		}
+		if query.ThresholdDirection == models.ThresholdBelow {
+			return value <= *threshold
+		}
+		return value >= *threshold
+	}
+
+	switch {
+	case crosses(query.CriticalThreshold):
+		return models.CustomQueryStatusCritical
+	case crosses(query.WarningThreshold):
+		return models.CustomQueryStatusWarning
+	default:
+		return models.CustomQueryStatusOK
+	}
+}
+
+// customQueryMessage describes the last run of a custom monitoring query
+func customQueryMessage(query *models.CustomMonitoringQuery) string {
+	if query.LastError != "" {
+		return query.LastError
+	}
+	if query.LastValue != nil {
+		return fmt.Sprintf("%s.%s = %s", query.DatabaseName, query.Name, strconv.FormatFloat(*query.LastValue, 'f', -1, 64))
+	}
+	return ""
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// ErrDashboardForbidden is returned when a user may not access a dashboard
+var ErrDashboardForbidden = errors.New("access to dashboard denied")
+
+// AlertSource provides alert states shown by alert_status widgets
+type AlertSource interface {
+	GetAlertStatuses(connectionID string) ([]models.AlertStatus, error)
+}
+
+// DashboardService handles pinned monitoring dashboards
+type DashboardService struct {
+	db                *gorm.DB
+	databaseService   *DatabaseService
+	snapshotService   *SnapshotService
+	annotationService *AnnotationService
+	customQueries     *CustomMonitoringService
+	accessGrants      *AccessGrantService
+	alertSources      []AlertSource
+}
+
+// NewDashboardService creates a new dashboard service
+func NewDashboardService(databaseService *DatabaseService, snapshotService *SnapshotService, annotationService *AnnotationService, customQueries *CustomMonitoringService, accessGrants *AccessGrantService, alertSources ...AlertSource) *DashboardService {
+	return &DashboardService{
+		db:                database.GetDB(),
+		databaseService:   databaseService,
+		snapshotService:   snapshotService,
+		annotationService: annotationService,
+		customQueries:     customQueries,
+		accessGrants:      accessGrants,
+		alertSources:      alertSources,
+	}
+}
+
+// CreateDashboard creates a new dashboard owned by the user
+func (s *DashboardService) CreateDashboard(req *models.DashboardRequest, ownerID string) (*models.Dashboard, error) {
+	widgets, err := normalizeDashboardWidgets(req.Widgets)
+	if err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	if _, err := s.databaseService.connectionService.GetConnection(req.ConnectionID); err != nil {
+		return nil, err
+	}
+
+	dashboard := &models.Dashboard{
+		ID:           uuid.New().String(),
+		Name:         req.Name,
+		ConnectionID: req.ConnectionID,
+		OwnerID:      ownerID,
+		Shared:       req.Shared,
+		Widgets:      widgets,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	if err := s.db.Create(dashboard).Error; err != nil {
+		return nil, fmt.Errorf("failed to create dashboard: %w", err)
+	}
+
+	return dashboard, nil
+}
+
+// GetDashboards returns dashboards owned by the user plus those shared with the team
+func (s *DashboardService) GetDashboards(userID, connectionID string) ([]models.Dashboard, error) {
+	dashboards := []models.Dashboard{}
+
+	query := s.db.Where("owner_id = ? OR shared = ?", userID, true)
+	if connectionID != "" {
+		query = query.Where("connection_id = ?", connectionID)
+	}
+
+	if err := query.Order("name ASC").Find(&dashboards).Error; err != nil {
+		return nil, fmt.Errorf("failed to get dashboards: %w", err)
+	}
+	return dashboards, nil
+}
+
+// GetDashboard returns a dashboard the user may view
+func (s *DashboardService) GetDashboard(id, userID string, isAdmin bool) (*models.Dashboard, error) {
+	var dashboard models.Dashboard
+	if err := s.db.First(&dashboard, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("dashboard not found")
+		}
+		return nil, fmt.Errorf("failed to get dashboard: %w", err)
+	}
+
+	if dashboard.OwnerID != userID && !dashboard.Shared && !isAdmin {
+		return nil, ErrDashboardForbidden
+	}
+	return &dashboard, nil
+}
+
+// UpdateDashboard updates a dashboard owned by the user
+func (s *DashboardService) UpdateDashboard(id, userID string, isAdmin bool, req *models.DashboardRequest) (*models.Dashboard, error) {
+	widgets, err := normalizeDashboardWidgets(req.Widgets)
+	if err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	dashboard, err := s.GetDashboard(id, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if dashboard.OwnerID != userID && !isAdmin {
+		return nil, ErrDashboardForbidden
+	}
+
+	dashboard.Name = req.Name
+	dashboard.ConnectionID = req.ConnectionID
+	dashboard.Shared = req.Shared
+	dashboard.Widgets = widgets
+	dashboard.UpdatedAt = time.Now()
+
+	if err := s.db.Save(dashboard).Error; err != nil {
+		return nil, fmt.Errorf("failed to update dashboard: %w", err)
+	}
+
+	return dashboard, nil
+}
+
+// DeleteDashboard removes a dashboard owned by the user
+func (s *DashboardService) DeleteDashboard(id, userID string, isAdmin bool) error {
+	dashboard, err := s.GetDashboard(id, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	if dashboard.OwnerID != userID && !isAdmin {
+		return ErrDashboardForbidden
+	}
+
+	if err := s.db.Delete(dashboard).Error; err != nil {
+		return fmt.Errorf("failed to delete dashboard: %w", err)
+	}
+	return nil
+}
+
+// GetDashboardData resolves the data of every widget of a dashboard concurrently in one call
+func (s *DashboardService) GetDashboardData(id, userID string, isAdmin bool) (*models.DashboardData, error) {
+	dashboard, err := s.GetDashboard(id, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	// Widgets show live queries and metrics of the connection, so the viewer needs access to it
+	if err := s.accessGrants.CheckUserAccess(userID, dashboard.ConnectionID, false); err != nil {
+		return nil, err
+	}
+
+	data := &models.DashboardData{
+		DashboardID: dashboard.ID,
+		GeneratedAt: time.Now(),
+		Widgets:     make(map[string]models.WidgetData, len(dashboard.Widgets)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, widget := range dashboard.Widgets {
+		wg.Add(1)
+		go func(widget models.DashboardWidget) {
+			defer wg.Done()
+
+			result := models.WidgetData{Type: widget.Type}
+			value, err := s.resolveWidget(dashboard.ConnectionID, &widget, userID, isAdmin)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Data = value
+			}
+
+			mu.Lock()
+			data.Widgets[widget.ID] = result
+			mu.Unlock()
+		}(widget)
+	}
+	wg.Wait()
+
+	return data, nil
+}
+
+// resolveWidget loads the data displayed by a single widget
+func (s *DashboardService) resolveWidget(connectionID string, widget *models.DashboardWidget, userID string, isAdmin bool) (interface{}, error) {
+	switch widget.Type {
+	case models.WidgetTypeActiveQueries:
+		return s.databaseService.GetActiveQueries(context.Background(), connectionID, widget.DatabaseName, true)
+
+	case models.WidgetTypeSnapshot:
+		return s.snapshotService.GetSnapshot(widget.Config["snapshot_id"], userID, isAdmin)
+
+	case models.WidgetTypeMetricChart:
+		metrics, err := s.databaseService.GetDatabaseMetrics(connectionID, widget.DatabaseName)
+		if err != nil {
+			return nil, err
+		}
+		// Include the last day of timeline annotations for correlation
+		annotations, err := s.annotationService.GetAnnotations(connectionID, widget.DatabaseName, time.Now().Add(-24*time.Hour), time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return models.MetricsWithAnnotations{Metrics: metrics, Annotations: annotations}, nil
+
+	case models.WidgetTypeAlertStatus:
+		statuses := []models.AlertStatus{}
+		for _, source := range s.alertSources {
+			sourceStatuses, err := source.GetAlertStatuses(connectionID)
+			if err != nil {
+				return nil, err
+			}
+			statuses = append(statuses, sourceStatuses...)
+		}
+		return statuses, nil
+
+	case models.WidgetTypeCustomQuery:
+		// Shows the result stored by the last monitoring run instead of querying the server
+		return s.customQueries.GetQuery(connectionID, widget.Config["query_id"])
+
+	default:
+		return nil, fmt.Errorf("unsupported widget type: %s", widget.Type)
+	}
+}
+
+// normalizeDashboardWidgets validates widgets and assigns IDs to new ones
+func normalizeDashboardWidgets(widgets []models.DashboardWidget) (models.DashboardWidgets, error) {
+	normalized := models.DashboardWidgets{}
+	for _, widget := range widgets {
+		switch widget.Type {
+		case models.WidgetTypeActiveQueries, models.WidgetTypeMetricChart:
+			if widget.DatabaseName == "" {
+				return nil, fmt.Errorf("widget %s requires database_name", widget.Type)
+			}
+		case models.WidgetTypeSnapshot:
+			if widget.Config["snapshot_id"] == "" {
+				return nil, fmt.Errorf("snapshot widget requires config.snapshot_id")
+			}
+		case models.WidgetTypeCustomQuery:
+			if widget.Config["query_id"] == "" {
+				return nil, fmt.Errorf("custom_query widget requires config.query_id")
+			}
+		case models.WidgetTypeAlertStatus:
+		default:
+			return nil, fmt.Errorf("unsupported widget type: %s", widget.Type)
+		}
+
+		if widget.ID == "" {
+			widget.ID = uuid.New().String()
+		}
+		normalized = append(normalized, widget)
+	}
+	return normalized, nil
+}
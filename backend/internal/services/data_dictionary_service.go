@@ -0,0 +1,540 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// ErrInvalidDataDictionary is returned when a data dictionary request or schedule fails validation
+var ErrInvalidDataDictionary = errors.New("invalid data dictionary request")
+
+// dataDictionaryRelkinds maps the documented pg_class.relkind values to their kind names
+var dataDictionaryRelkinds = map[string]string{
+	"r": "table",
+	"p": "partitioned table",
+	"v": "view",
+	"m": "materialized view",
+	"f": "foreign table",
+}
+
+// DataDictionaryService documents the catalog of a database (schemas, tables, columns, foreign
+// keys and indexes) as Markdown, HTML or JSON artifacts, on demand or on a schedule
+type DataDictionaryService struct {
+	db              *gorm.DB
+	databaseService *DatabaseService
+	artifactService *ArtifactService
+	accessGrants    *AccessGrantService
+	logger          *slog.Logger
+}
+
+// NewDataDictionaryService creates a new data dictionary service
+func NewDataDictionaryService(databaseService *DatabaseService, artifactService *ArtifactService, accessGrants *AccessGrantService, logger *slog.Logger) *DataDictionaryService {
+	return &DataDictionaryService{
+		db:              database.GetDB(),
+		databaseService: databaseService,
+		artifactService: artifactService,
+		accessGrants:    accessGrants,
+		logger:          logging.OrDefault(logger),
+	}
+}
+
+// BuildDictionary reads the catalog of a PostgreSQL database; schemas limits it to the listed
+// schemas, otherwise every non-system schema is documented. Partitions are left out.
+func (s *DataDictionaryService) BuildDictionary(connectionID, dbName string, schemas []string) (*models.DataDictionary, error) {
+	conn, err := s.databaseService.connectionService.GetConnection(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	db, err := s.databaseService.connectToSpecificDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	dictionary := &models.DataDictionary{
+		ConnectionName: conn.Name,
+		DatabaseName:   dbName,
+		GeneratedAt:    time.Now().UTC(),
+		Schemas:        []models.DataDictionarySchema{},
+	}
+
+	// Schemas
+	schemaQuery := `
+		SELECT n.nspname, COALESCE(obj_description(n.oid, 'pg_namespace'), '')
+		FROM pg_namespace n
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+		AND n.nspname NOT LIKE 'pg\_temp\_%'
+		AND n.nspname NOT LIKE 'pg\_toast\_temp\_%'
+		AND (cardinality($1::text[]) = 0 OR n.nspname = ANY($1))
+		ORDER BY n.nspname
+	`
+	rows, err := db.Query(schemaQuery, pq.Array(schemas))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schemas: %w", err)
+	}
+	schemaNames := []string{}
+	schemaIndex := map[string]int{}
+	for rows.Next() {
+		var schema models.DataDictionarySchema
+		if err := rows.Scan(&schema.Name, &schema.Comment); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan schema: %w", err)
+		}
+		schema.Tables = []models.DataDictionaryTable{}
+		schemaIndex[schema.Name] = len(dictionary.Schemas)
+		schemaNames = append(schemaNames, schema.Name)
+		dictionary.Schemas = append(dictionary.Schemas, schema)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schemas: %w", err)
+	}
+	if len(schemas) > 0 && len(schemaNames) < len(schemas) {
+		return nil, fmt.Errorf("%w: some of the requested schemas do not exist", ErrInvalidDataDictionary)
+	}
+
+	// Tables are collected by qualified name first and attached to their schemas at the end
+	tables := map[string]*models.DataDictionaryTable{}
+	tableOrder := []string{}
+	tableSchema := map[string]string{}
+	qualified := func(schema, table string) string { return schema + "." + table }
+
+	tableQuery := `
+		SELECT n.nspname, c.relname, c.relkind::text, COALESCE(obj_description(c.oid, 'pg_class'), '')
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f')
+		AND NOT c.relispartition
+		AND n.nspname = ANY($1)
+		ORDER BY n.nspname, c.relname
+	`
+	rows, err = db.Query(tableQuery, pq.Array(schemaNames))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tables: %w", err)
+	}
+	for rows.Next() {
+		var schema, relkind string
+		table := &models.DataDictionaryTable{
+			Columns:     []models.DataDictionaryColumn{},
+			ForeignKeys: []models.DataDictionaryForeignKey{},
+			Indexes:     []models.DataDictionaryIndex{},
+		}
+		if err := rows.Scan(&schema, &table.Name, &relkind, &table.Comment); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		table.Kind = dataDictionaryRelkinds[relkind]
+		key := qualified(schema, table.Name)
+		tables[key] = table
+		tableOrder = append(tableOrder, key)
+		tableSchema[key] = schema
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tables: %w", err)
+	}
+
+	// Columns
+	columnQuery := `
+		SELECT n.nspname, c.relname, a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+			COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), COALESCE(col_description(c.oid, a.attnum), '')
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attnum > 0
+		AND NOT a.attisdropped
+		AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+		AND n.nspname = ANY($1)
+		ORDER BY n.nspname, c.relname, a.attnum
+	`
+	rows, err = db.Query(columnQuery, pq.Array(schemaNames))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+	for rows.Next() {
+		var schema, tableName string
+		var column models.DataDictionaryColumn
+		if err := rows.Scan(&schema, &tableName, &column.Name, &column.Type, &column.Nullable, &column.Default, &column.Comment); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		if table, ok := tables[qualified(schema, tableName)]; ok {
+			table.Columns = append(table.Columns, column)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating columns: %w", err)
+	}
+
+	// Foreign keys, with column names in key order
+	foreignKeyQuery := `
+		SELECT n.nspname, c.relname, con.conname, pg_get_constraintdef(con.oid), rn.nspname, rc.relname,
+			ARRAY(
+				SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY k(attnum, ord)
+				JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+				ORDER BY k.ord
+			),
+			ARRAY(
+				SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY k(attnum, ord)
+				JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+				ORDER BY k.ord
+			)
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_class rc ON rc.oid = con.confrelid
+		JOIN pg_namespace rn ON rn.oid = rc.relnamespace
+		WHERE con.contype = 'f'
+		AND n.nspname = ANY($1)
+		ORDER BY n.nspname, c.relname, con.conname
+	`
+	rows, err = db.Query(foreignKeyQuery, pq.Array(schemaNames))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query foreign keys: %w", err)
+	}
+	for rows.Next() {
+		var schema, tableName string
+		var fk models.DataDictionaryForeignKey
+		if err := rows.Scan(&schema, &tableName, &fk.Name, &fk.Definition, &fk.ReferencedSchema, &fk.ReferencedTable,
+			pq.Array(&fk.Columns), pq.Array(&fk.ReferencedColumns)); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan foreign key: %w", err)
+		}
+		if table, ok := tables[qualified(schema, tableName)]; ok {
+			table.ForeignKeys = append(table.ForeignKeys, fk)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating foreign keys: %w", err)
+	}
+
+	// Indexes
+	indexQuery := `
+		SELECT n.nspname, c.relname, i.relname, ix.indisprimary, ix.indisunique, pg_get_indexdef(ix.indexrelid)
+		FROM pg_index ix
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_class c ON c.oid = ix.indrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = ANY($1)
+		ORDER BY n.nspname, c.relname, NOT ix.indisprimary, i.relname
+	`
+	rows, err = db.Query(indexQuery, pq.Array(schemaNames))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %w", err)
+	}
+	for rows.Next() {
+		var schema, tableName string
+		var index models.DataDictionaryIndex
+		if err := rows.Scan(&schema, &tableName, &index.Name, &index.Primary, &index.Unique, &index.Definition); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan index: %w", err)
+		}
+		if table, ok := tables[qualified(schema, tableName)]; ok {
+			table.Indexes = append(table.Indexes, index)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating indexes: %w", err)
+	}
+
+	for _, key := range tableOrder {
+		schema := &dictionary.Schemas[schemaIndex[tableSchema[key]]]
+		schema.Tables = append(schema.Tables, *tables[key])
+	}
+
+	return dictionary, nil
+}
+
+// GenerateArtifact documents a database and stores the result as an export artifact of the user
+func (s *DataDictionaryService) GenerateArtifact(connectionID, dbName, ownerID string, req *models.DataDictionaryRequest) (*models.Artifact, error) {
+	format, err := normalizeDataDictionaryFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	dictionary, err := s.BuildDictionary(connectionID, dbName, req.Schemas)
+	if err != nil {
+		return nil, err
+	}
+
+	content, contentType, extension, err := renderDataDictionary(dictionary, format)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("data-dictionary-%s-%s.%s", dbName, dictionary.GeneratedAt.Format("20060102-150405"), extension)
+	return s.artifactService.Store(context.Background(), models.ArtifactExport, name, ownerID, contentType, bytes.NewReader(content), int64(len(content)))
+}
+
+// CreateSchedule schedules a data dictionary to be regenerated daily or weekly
+func (s *DataDictionaryService) CreateSchedule(ownerID string, req *models.DataDictionaryScheduleRequest) (*models.DataDictionarySchedule, error) {
+	if req.Frequency != models.DigestFrequencyDaily && req.Frequency != models.DigestFrequencyWeekly {
+		return nil, fmt.Errorf("%w: frequency must be daily or weekly", ErrInvalidDataDictionary)
+	}
+	format, err := normalizeDataDictionaryFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.databaseService.connectionService.GetConnection(req.ConnectionID); err != nil {
+		return nil, err
+	}
+	if err := s.accessGrants.CheckUserAccess(ownerID, req.ConnectionID, false); err != nil {
+		return nil, err
+	}
+
+	schedule := &models.DataDictionarySchedule{
+		ID:           uuid.New().String(),
+		OwnerID:      ownerID,
+		ConnectionID: req.ConnectionID,
+		DatabaseName: req.DatabaseName,
+		Format:       format,
+		Schemas:      models.StringList(models.NonNil(req.Schemas)),
+		Frequency:    req.Frequency,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := s.db.Create(schedule).Error; err != nil {
+		return nil, fmt.Errorf("failed to create schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// GetSchedules returns the data dictionary schedules of a user, or all schedules for admins
+func (s *DataDictionaryService) GetSchedules(userID string, isAdmin bool) ([]models.DataDictionarySchedule, error) {
+	query := s.db.Order("created_at DESC")
+	if !isAdmin {
+		query = query.Where("owner_id = ?", userID)
+	}
+
+	schedules := []models.DataDictionarySchedule{}
+	if err := query.Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// DeleteSchedule removes a schedule owned by the user
+func (s *DataDictionaryService) DeleteSchedule(id, userID string, isAdmin bool) error {
+	var schedule models.DataDictionarySchedule
+	if err := s.db.First(&schedule, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("schedule not found")
+		}
+		return fmt.Errorf("failed to get schedule: %w", err)
+	}
+	if schedule.OwnerID != userID && !isAdmin {
+		return fmt.Errorf("schedule not found")
+	}
+
+	if err := s.db.Delete(&schedule).Error; err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+	return nil
+}
+
+// RunDueSchedules regenerates every data dictionary whose period has elapsed
+func (s *DataDictionaryService) RunDueSchedules() error {
+	var schedules []models.DataDictionarySchedule
+	if err := s.db.Find(&schedules).Error; err != nil {
+		return fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	failed := 0
+	for i := range schedules {
+		schedule := &schedules[i]
+		if schedule.LastRunAt != nil && time.Since(*schedule.LastRunAt) < digestPeriod(schedule.Frequency) {
+			continue
+		}
+
+		// The owner's grant may have ended since the schedule was created
+		if err := s.accessGrants.CheckUserAccess(schedule.OwnerID, schedule.ConnectionID, false); err != nil {
+			if !errors.Is(err, ErrAccessGrantRequired) {
+				s.logger.Error("failed to check data dictionary access", "schedule_id", schedule.ID, "error", err)
+				failed++
+			}
+			continue
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"last_run_at": now, "last_error": ""}
+		artifact, err := s.GenerateArtifact(schedule.ConnectionID, schedule.DatabaseName, schedule.OwnerID, &models.DataDictionaryRequest{
+			Format:  schedule.Format,
+			Schemas: schedule.Schemas,
+		})
+		if err != nil {
+			s.logger.Error("failed to generate data dictionary", "schedule_id", schedule.ID, "error", err)
+			updates["last_error"] = err.Error()
+			failed++
+		} else {
+			updates["last_artifact_id"] = artifact.ID
+		}
+
+		if err := s.db.Model(schedule).Updates(updates).Error; err != nil {
+			s.logger.Warn("failed to record data dictionary outcome", "schedule_id", schedule.ID, "error", err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d data dictionaries failed", failed)
+	}
+	return nil
+}
+
+// normalizeDataDictionaryFormat validates a format, defaulting to Markdown
+func normalizeDataDictionaryFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return models.DataDictionaryMarkdown, nil
+	case models.DataDictionaryMarkdown, models.DataDictionaryHTML, models.DataDictionaryJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: format must be markdown, html or json", ErrInvalidDataDictionary)
+	}
+}
+
+// renderDataDictionary renders a data dictionary and returns its content type and file extension
+func renderDataDictionary(d *models.DataDictionary, format string) ([]byte, string, string, error) {
+	switch format {
+	case models.DataDictionaryJSON:
+		content, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to render data dictionary: %w", err)
+		}
+		return content, "application/json", "json", nil
+
+	case models.DataDictionaryHTML:
+		var b bytes.Buffer
+		if err := dataDictionaryHTMLTemplate.Execute(&b, d); err != nil {
+			return nil, "", "", fmt.Errorf("failed to render data dictionary: %w", err)
+		}
+		return b.Bytes(), "text/html; charset=utf-8", "html", nil
+
+	default:
+		return []byte(renderDataDictionaryMarkdown(d)), "text/markdown; charset=utf-8", "md", nil
+	}
+}
+
+// renderDataDictionaryMarkdown renders a data dictionary as Markdown
+func renderDataDictionaryMarkdown(d *models.DataDictionary) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Data dictionary: %s\n\n", d.DatabaseName)
+	fmt.Fprintf(&b, "Connection: %s  \nGenerated: %s\n\n", d.ConnectionName, d.GeneratedAt.Format(time.RFC1123))
+
+	for _, schema := range d.Schemas {
+		fmt.Fprintf(&b, "## Schema `%s`\n\n", schema.Name)
+		if schema.Comment != "" {
+			fmt.Fprintf(&b, "%s\n\n", schema.Comment)
+		}
+		if len(schema.Tables) == 0 {
+			b.WriteString("No tables.\n\n")
+		}
+
+		for _, table := range schema.Tables {
+			fmt.Fprintf(&b, "### `%s.%s` (%s)\n\n", schema.Name, table.Name, table.Kind)
+			if table.Comment != "" {
+				fmt.Fprintf(&b, "%s\n\n", table.Comment)
+			}
+
+			b.WriteString("| Column | Type | Nullable | Default | Comment |\n")
+			b.WriteString("|---|---|---|---|---|\n")
+			for _, column := range table.Columns {
+				nullable := "no"
+				if column.Nullable {
+					nullable = "yes"
+				}
+				fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", markdownCell(column.Name), markdownCell(column.Type),
+					nullable, markdownCell(column.Default), markdownCell(column.Comment))
+			}
+			b.WriteString("\n")
+
+			if len(table.ForeignKeys) > 0 {
+				b.WriteString("**Foreign keys**\n\n")
+				for _, fk := range table.ForeignKeys {
+					fmt.Fprintf(&b, "- `%s`: (%s) → `%s.%s` (%s)\n", fk.Name, strings.Join(fk.Columns, ", "),
+						fk.ReferencedSchema, fk.ReferencedTable, strings.Join(fk.ReferencedColumns, ", "))
+				}
+				b.WriteString("\n")
+			}
+
+			if len(table.Indexes) > 0 {
+				b.WriteString("**Indexes**\n\n")
+				for _, index := range table.Indexes {
+					fmt.Fprintf(&b, "- `%s`: `%s`\n", index.Name, index.Definition)
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// markdownCell escapes a value for a Markdown table cell
+func markdownCell(s string) string {
+	s = strings.NewReplacer("|", "\\|", "<", "&lt;", ">", "&gt;").Replace(s)
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// dataDictionaryHTMLTemplate renders a data dictionary as a standalone HTML page
+var dataDictionaryHTMLTemplate = template.Must(template.New("data-dictionary").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Data dictionary: {{.DatabaseName}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+code { background: #f4f4f4; padding: 0 3px; }
+.comment { color: #555; }
+</style>
+</head>
+<body>
+<h1>Data dictionary: {{.DatabaseName}}</h1>
+<p>Connection: {{.ConnectionName}}<br>Generated: {{.GeneratedAt.Format "Mon, 02 Jan 2006 15:04:05 MST"}}</p>
+{{range $schema := .Schemas}}
+<h2>Schema <code>{{$schema.Name}}</code></h2>
+{{if $schema.Comment}}<p class="comment">{{$schema.Comment}}</p>{{end}}
+{{range $schema.Tables}}
+<h3><code>{{$schema.Name}}.{{.Name}}</code> ({{.Kind}})</h3>
+{{if .Comment}}<p class="comment">{{.Comment}}</p>{{end}}
+<table>
+<tr><th>Column</th><th>Type</th><th>Nullable</th><th>Default</th><th>Comment</th></tr>
+{{range .Columns}}<tr><td>{{.Name}}</td><td>{{.Type}}</td><td>{{if .Nullable}}yes{{else}}no{{end}}</td><td>{{.Default}}</td><td>{{.Comment}}</td></tr>
+{{end}}</table>
+{{if .ForeignKeys}}<p><strong>Foreign keys</strong></p>
+<ul>
+{{range .ForeignKeys}}<li><code>{{.Name}}</code>: ({{join .Columns ", "}}) → <code>{{.ReferencedSchema}}.{{.ReferencedTable}}</code> ({{join .ReferencedColumns ", "}})</li>
+{{end}}</ul>{{end}}
+{{if .Indexes}}<p><strong>Indexes</strong></p>
+<ul>
+{{range .Indexes}}<li><code>{{.Name}}</code>: <code>{{.Definition}}</code></li>
+{{end}}</ul>{{end}}
+{{end}}
+{{end}}
+</body>
+</html>
+`))
@@ -1,8 +1,11 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/lib/pq"
@@ -12,105 +15,76 @@ import (
 // DatabaseService handles business logic for database operations
 type DatabaseService struct {
 	connectionService *ConnectionService
+	limits            QueryLimits
+
+	runningMu sync.Mutex
+	running   map[string]*runningQuery // In-flight queries by query ID
+}
+
+// QueryLimits caps the rows user queries return, so large SELECTs cannot exhaust server memory
+type QueryLimits struct {
+	MaxRows       int // Rows kept in memory for a buffered result; zero means unlimited
+	StreamMaxRows int // Rows written by a streamed result; zero means unlimited
+
+	Timeout    time.Duration // Default timeout of buffered queries; zero means none
+	MaxTimeout time.Duration // Longest timeout a request may ask for; zero means unlimited
 }
 
 // NewDatabaseService creates a new database service
-func NewDatabaseService(connService *ConnectionService) *DatabaseService {
+func NewDatabaseService(connService *ConnectionService, limits QueryLimits) *DatabaseService {
 	return &DatabaseService{
 		connectionService: connService,
+		limits:            limits,
+		running:           map[string]*runningQuery{},
 	}
 }
 
-// connectToDatabase creates a connection to the specified database
+// connectToDatabase creates a connection to the default database of a PostgreSQL connection
 func (s *DatabaseService) connectToDatabase(connectionID string) (*sql.DB, error) {
-	// Get connection details
-	conn, err := s.connectionService.GetConnection(connectionID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get connection: %w", err)
-	}
-
-	// Build connection string
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		conn.Host,
-		conn.Port,
-		conn.Username,
-		conn.Password,
-		conn.Database,
-		conn.SSLMode,
-	)
+	return s.connectToSpecificDatabase(connectionID, "")
+}
 
-	// Open database connection
-	db, err := sql.Open("postgres", connStr)
+// connectToSpecificDatabase creates a connection to a specific database of a PostgreSQL connection.
+// Features that query pg_catalog use it; other engines get ErrUnsupportedDialect.
+func (s *DatabaseService) connectToSpecificDatabase(connectionID, dbName string) (*sql.DB, error) {
+	db, d, err := s.connect(connectionID, dbName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
-
-	// Test connection
-	if err := db.Ping(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	if d.name() != "postgres" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDialect, d.name())
 	}
-
 	return db, nil
 }
 
-// connectToSpecificDatabase creates a connection to a specific database
-func (s *DatabaseService) connectToSpecificDatabase(connectionID, dbName string) (*sql.DB, error) {
+// connect returns the shared pool of a database of any supported engine, using the connection's
+// default database when dbName is empty. Pools are owned by the pool manager and must not be closed.
+func (s *DatabaseService) connect(connectionID, dbName string) (*sql.DB, dialect, error) {
 	// Get connection details
 	conn, err := s.connectionService.GetConnection(connectionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get connection: %w", err)
+		return nil, nil, fmt.Errorf("failed to get connection: %w", err)
 	}
 
-	// Build connection string with specific database
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		conn.Host,
-		conn.Port,
-		conn.Username,
-		conn.Password,
-		dbName,
-		conn.SSLMode,
-	)
-
-	// Open database connection
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
-	}
-
-	// Test connection
-	if err := db.Ping(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
-	}
-
-	return db, nil
+	return s.connectionService.pools.get(conn, dbName)
 }
 
 // GetDatabases retrieves all databases for a connection
-func (s *DatabaseService) GetDatabases(connectionID string) ([]*models.Database, error) {
-	db, err := s.connectToDatabase(connectionID)
+func (s *DatabaseService) GetDatabases(ctx context.Context, connectionID string) ([]*models.Database, error) {
+	db, d, err := s.connect(connectionID, "")
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
-	query := `
-		SELECT
-			datname as name,
-			pg_database_size(datname) as size
-		FROM pg_database
-		WHERE datistemplate = false
-		ORDER BY datname
-	`
+	query, args := d.databasesQuery()
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query databases: %w", err)
 	}
 	defer rows.Close()
 
-	var databases []*models.Database
+	databases := []*models.Database{}
 	for rows.Next() {
 		var dbModel models.Database
 		var size int64
@@ -133,12 +107,11 @@ func (s *DatabaseService) GetDatabases(connectionID string) ([]*models.Database,
 }
 
 // GetRoles retrieves all roles for a connection (both login roles and groups)
-func (s *DatabaseService) GetRoles(connectionID string) ([]*models.Role, error) {
+func (s *DatabaseService) GetRoles(ctx context.Context, connectionID string) ([]*models.Role, error) {
 	db, err := s.connectToDatabase(connectionID)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
 	query := `
 		SELECT
@@ -165,13 +138,13 @@ func (s *DatabaseService) GetRoles(connectionID string) ([]*models.Role, error)
 			r.rolname
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query roles: %w", err)
 	}
 	defer rows.Close()
 
-	var roles []*models.Role
+	roles := []*models.Role{}
 	for rows.Next() {
 		var role models.Role
 		var canLogin, isSuper, canCreateRole, canCreateDB, inherit, replication bool
@@ -192,7 +165,7 @@ func (s *DatabaseService) GetRoles(connectionID string) ([]*models.Role, error)
 		}
 
 		// Build permissions list
-		var permissions []string
+		permissions := []string{}
 		if isSuper {
 			permissions = append(permissions, "SUPERUSER")
 		}
@@ -228,9 +201,9 @@ func (s *DatabaseService) GetRoles(connectionID string) ([]*models.Role, error)
 }
 
 // GetRole retrieves a specific role by ID
-func (s *DatabaseService) GetRole(connectionID, roleID string) (*models.Role, error) {
+func (s *DatabaseService) GetRole(ctx context.Context, connectionID, roleID string) (*models.Role, error) {
 	// TODO: Implement actual database query to get role
-	roles, err := s.GetRoles(connectionID)
+	roles, err := s.GetRoles(ctx, connectionID)
 	if err != nil {
 		return nil, err
 	}
@@ -252,8 +225,8 @@ func (s *DatabaseService) CreateRole(connectionID string, req *models.RoleReques
 		ID:          fmt.Sprintf("role-%d", now.Unix()),
 		Name:        req.Name,
 		Description: req.Description,
-		Permissions: req.Permissions,
-		Users:       req.Users,
+		Permissions: models.NonNil(req.Permissions),
+		Users:       models.NonNil(req.Users),
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
@@ -268,8 +241,8 @@ func (s *DatabaseService) UpdateRole(connectionID, roleID string, req *models.Ro
 		ID:          roleID,
 		Name:        req.Name,
 		Description: req.Description,
-		Permissions: req.Permissions,
-		Users:       req.Users,
+		Permissions: models.NonNil(req.Permissions),
+		Users:       models.NonNil(req.Users),
 		CreatedAt:   now.Add(-24 * time.Hour),
 		UpdatedAt:   now,
 	}
@@ -283,48 +256,15 @@ func (s *DatabaseService) DeleteRole(connectionID, roleID string) error {
 }
 
 // GetActiveQueries retrieves all active queries for a database
-func (s *DatabaseService) GetActiveQueries(connectionID, dbName string, onlyActive bool) ([]*models.ActiveQuery, error) {
-	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+func (s *DatabaseService) GetActiveQueries(ctx context.Context, connectionID, dbName string, onlyActive bool) ([]*models.ActiveQuery, error) {
+	db, d, err := s.connect(connectionID, dbName)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
-	stateFilter := ""
-	if onlyActive {
-		stateFilter = "AND state != 'idle'"
-	}
+	query, args := d.activeQueriesQuery(dbName, onlyActive)
 
-	query := fmt.Sprintf(`
-		SELECT
-			pid,
-			COALESCE(usename, '') as user,
-			COALESCE(state, '') as state,
-			COALESCE(query, '') as query,
-			COALESCE(EXTRACT(EPOCH FROM (NOW() - query_start))::int, 0) as duration_seconds,
-			COALESCE(query_start, NOW()) as start_time,
-			COALESCE(client_addr::text, client_hostname, 'local') as hostname,
-			COALESCE(backend_start, NOW()) as backend_start,
-			COALESCE(backend_type, '') as backend_type,
-			COALESCE(wait_event_type || ': ' || wait_event, '') as wait_event,
-			COALESCE(
-				CASE
-					WHEN cardinality(pg_blocking_pids(pid)) > 0
-					THEN array_to_string(pg_blocking_pids(pid), ',')
-					ELSE ''
-				END,
-			'') as blocked_by
-		FROM pg_stat_activity
-		WHERE datname = $1
-			%s
-			AND pid != pg_backend_pid()
-			AND query NOT LIKE '%%pg_stat_activity%%'
-			AND query NOT LIKE '%%pg_locks%%'
-			AND query NOT LIKE '%%FROM information_schema%%'
-		ORDER BY query_start DESC
-	`, stateFilter)
-
-	rows, err := db.Query(query, dbName)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get active queries: %w", err)
 	}
@@ -350,16 +290,21 @@ func (s *DatabaseService) GetActiveQueries(connectionID, dbName string, onlyActi
 		return nil, fmt.Errorf("error iterating query rows: %w", err)
 	}
 
+	if d.name() == "postgres" {
+		if err := resolveBlockingChains(db, queries); err != nil {
+			return nil, err
+		}
+	}
+
 	return queries, nil
 }
 
 // GetDeadlocks retrieves blocking queries that could lead to deadlocks
-func (s *DatabaseService) GetDeadlocks(connectionID, dbName string) ([]*models.Deadlock, error) {
+func (s *DatabaseService) GetDeadlocks(ctx context.Context, connectionID, dbName string) ([]*models.Deadlock, error) {
 	db, err := s.connectToSpecificDatabase(connectionID, dbName)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
 	query := `
 		SELECT DISTINCT
@@ -388,7 +333,7 @@ func (s *DatabaseService) GetDeadlocks(connectionID, dbName string) ([]*models.D
 			AND blocked.pid != pg_backend_pid()
 	`
 
-	rows, err := db.Query(query, dbName)
+	rows, err := db.QueryContext(ctx, query, dbName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get deadlocks: %w", err)
 	}
@@ -422,12 +367,11 @@ func (s *DatabaseService) GetDeadlocks(connectionID, dbName string) ([]*models.D
 }
 
 // GetLocks retrieves all active locks in the database
-func (s *DatabaseService) GetLocks(connectionID, dbName string, showSystem bool) ([]*models.Lock, error) {
+func (s *DatabaseService) GetLocks(ctx context.Context, connectionID, dbName string, showSystem bool) ([]*models.Lock, error) {
 	db, err := s.connectToSpecificDatabase(connectionID, dbName)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
 	// Build condition for filtering system locks
 	systemFilter := ""
@@ -474,7 +418,7 @@ func (s *DatabaseService) GetLocks(connectionID, dbName string, showSystem bool)
 		LIMIT 50
 	`, systemFilter)
 
-	rows, err := db.Query(query, dbName)
+	rows, err := db.QueryContext(ctx, query, dbName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get locks: %w", err)
 	}
@@ -502,37 +446,51 @@ func (s *DatabaseService) GetLocks(connectionID, dbName string, showSystem bool)
 	return locks, nil
 }
 
-// TerminateQueries terminates specified backend processes
-func (s *DatabaseService) TerminateQueries(connectionID, dbName string, pids []string) (int, error) {
-	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+// TerminateQueries terminates specified backend processes and returns one log entry per PID
+// with the query text and database user captured just before termination. Processing stops
+// at the first failing PID; the entries gathered so far are returned with the error.
+func (s *DatabaseService) TerminateQueries(ctx context.Context, connectionID, dbName string, pids []string) ([]*models.QueryTerminationLog, error) {
+	db, d, err := s.connect(connectionID, dbName)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	defer db.Close()
 
-	terminated := 0
+	entries := make([]*models.QueryTerminationLog, 0, len(pids))
 	for _, pidStr := range pids {
-		query := "SELECT pg_terminate_backend($1)"
-		var result bool
-		err := db.QueryRow(query, pidStr).Scan(&result)
+		pid, err := strconv.Atoi(pidStr)
 		if err != nil {
-			return terminated, fmt.Errorf("failed to terminate PID %s: %w", pidStr, err)
+			return entries, fmt.Errorf("invalid PID %q", pidStr)
+		}
+
+		entry := &models.QueryTerminationLog{
+			ConnectionID: connectionID,
+			DatabaseName: dbName,
+			PID:          pid,
+		}
+		entries = append(entries, entry)
+
+		// Capture what the backend was running before it goes away
+		activityQuery, args := d.sessionQuery(pid)
+		if err := db.QueryRowContext(ctx, activityQuery, args...).Scan(&entry.DBUsername, &entry.Query); err != nil && err != sql.ErrNoRows {
+			entry.ErrorMessage = err.Error()
+			return entries, fmt.Errorf("failed to read activity of PID %d: %w", pid, err)
 		}
-		if result {
-			terminated++
+
+		if entry.Terminated, err = d.terminateSession(ctx, db, pid); err != nil {
+			entry.ErrorMessage = err.Error()
+			return entries, fmt.Errorf("failed to terminate PID %s: %w", pidStr, err)
 		}
 	}
 
-	return terminated, nil
+	return entries, nil
 }
 
 // GetQueryHistory retrieves query history from pg_stat_statements
-func (s *DatabaseService) GetQueryHistory(connectionID, dbName string) ([]*models.QueryStatement, error) {
+func (s *DatabaseService) GetQueryHistory(ctx context.Context, connectionID, dbName string) ([]*models.QueryStatement, error) {
 	db, err := s.connectToSpecificDatabase(connectionID, dbName)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
 	query := `
 		SELECT
@@ -562,7 +520,7 @@ func (s *DatabaseService) GetQueryHistory(connectionID, dbName string) ([]*model
 		LIMIT 100
 	`
 
-	rows, err := db.Query(query, dbName)
+	rows, err := db.QueryContext(ctx, query, dbName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get query history: %w", err)
 	}
@@ -600,21 +558,47 @@ func (s *DatabaseService) GetQueryHistory(connectionID, dbName string) ([]*model
 	return statements, nil
 }
 
-// ExecuteQuery executes a SQL query on a specific database
-func (s *DatabaseService) ExecuteQuery(connectionID, dbName string, query string) (*models.QueryResult, error) {
-	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+// ExecuteQuery executes a SQL query on a specific database, with args bound to its placeholders.
+// The query is cancelled when ctx ends and, unless ctx has a deadline, after the default query timeout.
+func (s *DatabaseService) ExecuteQuery(ctx context.Context, connectionID, dbName string, query string, args ...any) (*models.QueryResult, error) {
+	db, d, err := s.connect(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	// User SQL may change settings or leave a transaction open, so it gets a session of its own
+	// that is reset before going back to the shared pool
+	var result *models.QueryResult
+	err = withSession(ctx, db, d, func(conn *sql.Conn) error {
+		if err := setStatementTimeout(ctx, conn, d); err != nil {
+			return err
+		}
+		rows, err := conn.QueryContext(ctx, query, args...)
+		result = readQueryResult(rows, err, s.limits.MaxRows)
+		if message := interruptedQueryError(ctx); message != "" && result.Error != "" {
+			result.Error = message
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
-	rows, err := db.Query(query)
+	return result, nil
+}
+
+// readQueryResult collects the rows of a user query; query errors are reported in the result.
+// Reading stops after maxRows rows (unless it is zero) and marks the result as truncated.
+func readQueryResult(rows *sql.Rows, err error, maxRows int) *models.QueryResult {
 	if err != nil {
 		return &models.QueryResult{
 			Columns: []string{},
 			Rows:    []map[string]any{},
 			Error:   err.Error(),
-		}, nil
+		}
 	}
 	defer rows.Close()
 
@@ -624,32 +608,25 @@ func (s *DatabaseService) ExecuteQuery(connectionID, dbName string, query string
 			Columns: []string{},
 			Rows:    []map[string]any{},
 			Error:   err.Error(),
-		}, nil
+		}
 	}
 
-	var resultRows []map[string]any
+	resultRows := []map[string]any{}
 	for rows.Next() {
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
+		if maxRows > 0 && len(resultRows) >= maxRows {
+			return &models.QueryResult{
+				Columns:   models.NonNil(columns),
+				Rows:      resultRows,
+				Truncated: true,
+			}
 		}
 
-		if err := rows.Scan(valuePtrs...); err != nil {
+		row, err := scanQueryRow(rows, columns)
+		if err != nil {
 			return &models.QueryResult{
 				Columns: columns,
 				Rows:    resultRows,
 				Error:   err.Error(),
-			}, nil
-		}
-
-		row := make(map[string]any)
-		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				row[col] = val
 			}
 		}
 		resultRows = append(resultRows, row)
@@ -660,22 +637,45 @@ func (s *DatabaseService) ExecuteQuery(connectionID, dbName string, query string
 			Columns: columns,
 			Rows:    resultRows,
 			Error:   err.Error(),
-		}, nil
+		}
 	}
 
 	return &models.QueryResult{
-		Columns: columns,
+		Columns: models.NonNil(columns),
 		Rows:    resultRows,
-	}, nil
+	}
+}
+
+// scanQueryRow scans the current row into a map by column name, turning byte slices into strings
+func scanQueryRow(rows *sql.Rows, columns []string) (map[string]any, error) {
+	values := make([]interface{}, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return nil, err
+	}
+
+	row := make(map[string]any, len(columns))
+	for i, col := range columns {
+		val := values[i]
+		if b, ok := val.([]byte); ok {
+			row[col] = string(b)
+		} else {
+			row[col] = val
+		}
+	}
+	return row, nil
 }
 
 // GetRoleMembership retrieves parent and child roles for a role
-func (s *DatabaseService) GetRoleMembership(connectionID, roleID string) (parentRoles []models.RoleMembership, childRoles []models.RoleMembership, err error) {
+func (s *DatabaseService) GetRoleMembership(ctx context.Context, connectionID, roleID string) (parentRoles []models.RoleMembership, childRoles []models.RoleMembership, err error) {
 	db, err := s.connectToDatabase(connectionID)
 	if err != nil {
 		return nil, nil, err
 	}
-	defer db.Close()
 
 	// Initialize empty slices instead of nil
 	parentRoles = make([]models.RoleMembership, 0)
@@ -695,7 +695,7 @@ func (s *DatabaseService) GetRoleMembership(connectionID, roleID string) (parent
 		ORDER BY r.rolname
 	`
 
-	parentRows, err := db.Query(parentQuery, roleID)
+	parentRows, err := db.QueryContext(ctx, parentQuery, roleID)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to query parent roles: %w", err)
 	}
@@ -724,7 +724,7 @@ func (s *DatabaseService) GetRoleMembership(connectionID, roleID string) (parent
 		ORDER BY r.rolname
 	`
 
-	childRows, err := db.Query(childQuery, roleID)
+	childRows, err := db.QueryContext(ctx, childQuery, roleID)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to query child roles: %w", err)
 	}
@@ -743,21 +743,21 @@ func (s *DatabaseService) GetRoleMembership(connectionID, roleID string) (parent
 }
 
 // GetDetailedRole retrieves complete information about a role
-func (s *DatabaseService) GetDetailedRole(connectionID, roleID string) (*models.DetailedRole, error) {
+func (s *DatabaseService) GetDetailedRole(ctx context.Context, connectionID, roleID string) (*models.DetailedRole, error) {
 	// Get basic role info
-	role, err := s.GetRole(connectionID, roleID)
+	role, err := s.GetRole(ctx, connectionID, roleID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get role membership
-	parentRoles, childRoles, err := s.GetRoleMembership(connectionID, roleID)
+	parentRoles, childRoles, err := s.GetRoleMembership(ctx, connectionID, roleID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get role privileges
-	privileges, err := s.GetRolePrivileges(connectionID, roleID)
+	privileges, err := s.GetRolePrivileges(ctx, connectionID, roleID)
 	if err != nil {
 		return nil, err
 	}
@@ -786,24 +786,15 @@ func (s *DatabaseService) GetDetailedRole(connectionID, roleID string) (*models.
 }
 
 // GetSchemas retrieves all schemas in a database
-func (s *DatabaseService) GetSchemas(connectionID, dbName string) ([]*models.Schema, error) {
-	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+func (s *DatabaseService) GetSchemas(ctx context.Context, connectionID, dbName string) ([]*models.Schema, error) {
+	db, d, err := s.connect(connectionID, dbName)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
-	query := `
-		SELECT
-			schema_name as name,
-			schema_owner as owner
-		FROM information_schema.schemata
-		WHERE catalog_name = $1
-		AND schema_name NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
-		ORDER BY schema_name
-	`
+	query, args := d.schemasQuery(dbName)
 
-	rows, err := db.Query(query, dbName)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query schemas: %w", err)
 	}
@@ -824,25 +815,15 @@ func (s *DatabaseService) GetSchemas(connectionID, dbName string) ([]*models.Sch
 }
 
 // GetTablesInSchema retrieves all tables in a schema
-func (s *DatabaseService) GetTablesInSchema(connectionID, dbName, schemaName string) ([]*models.DatabaseObject, error) {
-	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+func (s *DatabaseService) GetTablesInSchema(ctx context.Context, connectionID, dbName, schemaName string) ([]*models.DatabaseObject, error) {
+	db, d, err := s.connect(connectionID, dbName)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
-	query := `
-		SELECT
-			table_name,
-			table_schema
-		FROM information_schema.tables
-		WHERE table_catalog = $1
-		AND table_schema = $2
-		AND table_type = 'BASE TABLE'
-		ORDER BY table_name
-	`
+	query, args := d.tablesQuery(dbName, schemaName)
 
-	rows, err := db.Query(query, dbName, schemaName)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tables: %w", err)
 	}
@@ -863,24 +844,15 @@ func (s *DatabaseService) GetTablesInSchema(connectionID, dbName, schemaName str
 }
 
 // GetViewsInSchema retrieves all views in a schema
-func (s *DatabaseService) GetViewsInSchema(connectionID, dbName, schemaName string) ([]*models.DatabaseObject, error) {
-	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+func (s *DatabaseService) GetViewsInSchema(ctx context.Context, connectionID, dbName, schemaName string) ([]*models.DatabaseObject, error) {
+	db, d, err := s.connect(connectionID, dbName)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
-	query := `
-		SELECT
-			table_name,
-			table_schema
-		FROM information_schema.views
-		WHERE table_catalog = $1
-		AND table_schema = $2
-		ORDER BY table_name
-	`
+	query, args := d.viewsQuery(dbName, schemaName)
 
-	rows, err := db.Query(query, dbName, schemaName)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query views: %w", err)
 	}
@@ -901,25 +873,15 @@ func (s *DatabaseService) GetViewsInSchema(connectionID, dbName, schemaName stri
 }
 
 // GetFunctionsInSchema retrieves all functions and procedures in a schema
-func (s *DatabaseService) GetFunctionsInSchema(connectionID, dbName, schemaName string) ([]*models.DatabaseObject, error) {
-	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+func (s *DatabaseService) GetFunctionsInSchema(ctx context.Context, connectionID, dbName, schemaName string) ([]*models.DatabaseObject, error) {
+	db, d, err := s.connect(connectionID, dbName)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
-	query := `
-		SELECT
-			routine_name,
-			routine_schema,
-			routine_type
-		FROM information_schema.routines
-		WHERE routine_catalog = $1
-		AND routine_schema = $2
-		ORDER BY routine_name
-	`
+	query, args := d.routinesQuery(dbName, schemaName)
 
-	rows, err := db.Query(query, dbName, schemaName)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query functions: %w", err)
 	}
@@ -945,19 +907,18 @@ func (s *DatabaseService) GetFunctionsInSchema(connectionID, dbName, schemaName
 }
 
 // GetRolePrivileges retrieves all privileges for a role
-func (s *DatabaseService) GetRolePrivileges(connectionID, roleID string) ([]models.RolePrivilege, error) {
+func (s *DatabaseService) GetRolePrivileges(ctx context.Context, connectionID, roleID string) ([]models.RolePrivilege, error) {
 	db, err := s.connectToDatabase(connectionID)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
 	privileges := make([]models.RolePrivilege, 0)
 
 	// First, get the role name from OID
 	var roleName string
 	roleQuery := `SELECT rolname FROM pg_roles WHERE oid = $1::oid`
-	err = db.QueryRow(roleQuery, roleID).Scan(&roleName)
+	err = db.QueryRowContext(ctx, roleQuery, roleID).Scan(&roleName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get role name: %w", err)
 	}
@@ -970,7 +931,7 @@ func (s *DatabaseService) GetRolePrivileges(connectionID, roleID string) ([]mode
 		ORDER BY datname
 	`
 
-	dbListRows, err := db.Query(dbListQuery)
+	dbListRows, err := db.QueryContext(ctx, dbListQuery)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query database list: %w", err)
 	}
@@ -988,7 +949,7 @@ func (s *DatabaseService) GetRolePrivileges(connectionID, roleID string) ([]mode
 	// Get database-level privileges
 	for _, dbName := range databases {
 		var hasConnect, hasCreate bool
-		db.QueryRow(
+		db.QueryRowContext(ctx,
 			`SELECT
 				has_database_privilege($1, $2, 'CONNECT') as has_connect,
 				has_database_privilege($1, $2, 'CREATE') as has_create`,
@@ -1035,7 +996,6 @@ func (s *DatabaseService) GetRolePrivileges(connectionID, roleID string) ([]mode
 
 		schemaRows, err := dbConn.Query(schemaQuery, roleName)
 		if err != nil {
-			dbConn.Close()
 			continue
 		}
 
@@ -1081,7 +1041,6 @@ func (s *DatabaseService) GetRolePrivileges(connectionID, roleID string) ([]mode
 
 		tableRows, err := dbConn.Query(tableQuery, roleName)
 		if err != nil {
-			dbConn.Close()
 			continue
 		}
 
@@ -1098,15 +1057,13 @@ func (s *DatabaseService) GetRolePrivileges(connectionID, roleID string) ([]mode
 			privileges = append(privileges, priv)
 		}
 		tableRows.Close()
-
-		dbConn.Close()
 	}
 
 	return privileges, nil
 }
 
 // GrantPrivileges grants privileges to a role
-func (s *DatabaseService) GrantPrivileges(connectionID, roleID string, req *models.GrantRequest) error {
+func (s *DatabaseService) GrantPrivileges(ctx context.Context, connectionID, roleID string, req *models.GrantRequest) error {
 	// Determine which database to connect to
 	var db *sql.DB
 	var err error
@@ -1122,45 +1079,27 @@ func (s *DatabaseService) GrantPrivileges(connectionID, roleID string, req *mode
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
 	// Get the role name from OID
 	var roleName string
 	roleQuery := `SELECT rolname FROM pg_roles WHERE oid = $1::oid`
-	err = db.QueryRow(roleQuery, roleID).Scan(&roleName)
+	err = db.QueryRowContext(ctx, roleQuery, roleID).Scan(&roleName)
 	if err != nil {
 		return fmt.Errorf("failed to get role name: %w", err)
 	}
 
-	// Build GRANT statement
-	var grantSQL string
-	objectIdentifier := req.ObjectName
-	if req.ObjectSchema != "" {
-		objectIdentifier = fmt.Sprintf("%s.%s", req.ObjectSchema, req.ObjectName)
-	}
-
-	privileges := ""
-	for i, priv := range req.Privileges {
-		if i > 0 {
-			privileges += ", "
-		}
-		privileges += priv
+	req, routine, err := resolveGrantObject(ctx, db, req)
+	if err != nil {
+		return err
 	}
 
-	switch req.ObjectType {
-	case "table", "view":
-		grantSQL = fmt.Sprintf("GRANT %s ON TABLE %s TO %s", privileges, objectIdentifier, roleName)
-	case "schema":
-		grantSQL = fmt.Sprintf("GRANT %s ON SCHEMA %s TO %s", privileges, req.ObjectName, roleName)
-	case "database":
-		grantSQL = fmt.Sprintf("GRANT %s ON DATABASE %s TO %s", privileges, req.ObjectName, roleName)
-	case "function", "procedure":
-		grantSQL = fmt.Sprintf("GRANT %s ON FUNCTION %s TO %s", privileges, objectIdentifier, roleName)
-	default:
-		return fmt.Errorf("unsupported object type: %s", req.ObjectType)
+	// Build GRANT statement
+	grantSQL, err := buildGrantSQL(req, roleName, routine)
+	if err != nil {
+		return err
 	}
 
-	_, err = db.Exec(grantSQL)
+	_, err = db.ExecContext(ctx, grantSQL)
 	if err != nil {
 		return fmt.Errorf("failed to grant privileges: %w", err)
 	}
@@ -1169,7 +1108,7 @@ func (s *DatabaseService) GrantPrivileges(connectionID, roleID string, req *mode
 }
 
 // RevokePrivileges revokes privileges from a role
-func (s *DatabaseService) RevokePrivileges(connectionID, roleID string, req *models.GrantRequest) error {
+func (s *DatabaseService) RevokePrivileges(ctx context.Context, connectionID, roleID string, req *models.GrantRequest) error {
 	// Determine which database to connect to
 	var db *sql.DB
 	var err error
@@ -1185,82 +1124,106 @@ func (s *DatabaseService) RevokePrivileges(connectionID, roleID string, req *mod
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
 	// Get the role name from OID
 	var roleName string
 	roleQuery := `SELECT rolname FROM pg_roles WHERE oid = $1::oid`
-	err = db.QueryRow(roleQuery, roleID).Scan(&roleName)
+	err = db.QueryRowContext(ctx, roleQuery, roleID).Scan(&roleName)
 	if err != nil {
 		return fmt.Errorf("failed to get role name: %w", err)
 	}
 
+	req, routine, err := resolveGrantObject(ctx, db, req)
+	if err != nil {
+		return err
+	}
+
 	// Build REVOKE statement
-	var revokeSQL string
-	objectIdentifier := req.ObjectName
-	if req.ObjectSchema != "" {
-		objectIdentifier = fmt.Sprintf("%s.%s", req.ObjectSchema, req.ObjectName)
+	revokeSQL, err := buildRevokeSQL(req, roleName, routine)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, revokeSQL)
+	if err != nil {
+		return fmt.Errorf("failed to revoke privileges: %w", err)
 	}
 
-	privileges := ""
-	for i, priv := range req.Privileges {
-		if i > 0 {
-			privileges += ", "
+	return nil
+}
+
+// resolveGrantObject returns a privilege request naming its object with the catalog spelling,
+// and the signature of functions and procedures
+func resolveGrantObject(ctx context.Context, db *sql.DB, req *models.GrantRequest) (*models.GrantRequest, string, error) {
+	d := postgresDialect{}
+	resolved := *req
+
+	// Database-level grants connect to the default database, which the catalog listings need by name
+	dbName := req.ObjectDatabase
+	if dbName == "" && req.ObjectType != "database" {
+		if err := db.QueryRowContext(ctx, "SELECT current_database()").Scan(&dbName); err != nil {
+			return nil, "", fmt.Errorf("failed to get current database: %w", err)
 		}
-		privileges += priv
 	}
 
 	switch req.ObjectType {
 	case "table", "view":
-		revokeSQL = fmt.Sprintf("REVOKE %s ON TABLE %s FROM %s", privileges, objectIdentifier, roleName)
+		schemaName := req.ObjectSchema
+		if schemaName == "" {
+			schemaName = d.defaultSchema(dbName)
+		}
+		var err error
+		if resolved.ObjectSchema, resolved.ObjectName, err = resolveTable(ctx, db, d, dbName, schemaName, req.ObjectName); err != nil {
+			return nil, "", err
+		}
 	case "schema":
-		revokeSQL = fmt.Sprintf("REVOKE %s ON SCHEMA %s FROM %s", privileges, req.ObjectName, roleName)
-	case "database":
-		revokeSQL = fmt.Sprintf("REVOKE %s ON DATABASE %s FROM %s", privileges, req.ObjectName, roleName)
+		query, args := d.schemasQuery(dbName)
+		schemas, err := readCatalogNames(ctx, db, query, args)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list schemas: %w", err)
+		}
+		if resolved.ObjectName, err = resolveName("schema", dbName, schemas, req.ObjectName); err != nil {
+			return nil, "", err
+		}
 	case "function", "procedure":
-		revokeSQL = fmt.Sprintf("REVOKE %s ON FUNCTION %s FROM %s", privileges, objectIdentifier, roleName)
-	default:
-		return fmt.Errorf("unsupported object type: %s", req.ObjectType)
-	}
-
-	_, err = db.Exec(revokeSQL)
-	if err != nil {
-		return fmt.Errorf("failed to revoke privileges: %w", err)
+		routine, err := resolveRoutineSignature(ctx, db, req.ObjectSchema, req.ObjectName)
+		if err != nil {
+			return nil, "", err
+		}
+		return &resolved, routine, nil
 	}
-
-	return nil
+	return &resolved, "", nil
 }
 
 // GrantMembership grants membership to a role
-func (s *DatabaseService) GrantMembership(connectionID, roleID string, req *models.MembershipRequest) error {
+func (s *DatabaseService) GrantMembership(ctx context.Context, connectionID, roleID string, req *models.MembershipRequest) error {
 	db, err := s.connectToDatabase(connectionID)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
 	// Get the role name from OID
 	var roleName string
 	roleQuery := `SELECT rolname FROM pg_roles WHERE oid = $1::oid`
-	err = db.QueryRow(roleQuery, roleID).Scan(&roleName)
+	err = db.QueryRowContext(ctx, roleQuery, roleID).Scan(&roleName)
 	if err != nil {
 		return fmt.Errorf("failed to get role name: %w", err)
 	}
 
 	// Get the member role name from OID
 	var memberRoleName string
-	err = db.QueryRow(roleQuery, req.MemberRoleOID).Scan(&memberRoleName)
+	err = db.QueryRowContext(ctx, roleQuery, req.MemberRoleOID).Scan(&memberRoleName)
 	if err != nil {
 		return fmt.Errorf("failed to get member role name: %w", err)
 	}
 
 	// Build GRANT ROLE statement
-	grantSQL := fmt.Sprintf("GRANT %s TO %s", roleName, memberRoleName)
+	grantSQL := fmt.Sprintf("GRANT %s TO %s", quotePostgresName(roleName), quotePostgresName(memberRoleName))
 	if req.AdminOption {
 		grantSQL += " WITH ADMIN OPTION"
 	}
 
-	_, err = db.Exec(grantSQL)
+	_, err = db.ExecContext(ctx, grantSQL)
 	if err != nil {
 		return fmt.Errorf("failed to grant membership: %w", err)
 	}
@@ -1269,32 +1232,31 @@ func (s *DatabaseService) GrantMembership(connectionID, roleID string, req *mode
 }
 
 // RevokeMembership revokes membership from a role
-func (s *DatabaseService) RevokeMembership(connectionID, roleID string, req *models.MembershipRequest) error {
+func (s *DatabaseService) RevokeMembership(ctx context.Context, connectionID, roleID string, req *models.MembershipRequest) error {
 	db, err := s.connectToDatabase(connectionID)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
 	// Get the role name from OID
 	var roleName string
 	roleQuery := `SELECT rolname FROM pg_roles WHERE oid = $1::oid`
-	err = db.QueryRow(roleQuery, roleID).Scan(&roleName)
+	err = db.QueryRowContext(ctx, roleQuery, roleID).Scan(&roleName)
 	if err != nil {
 		return fmt.Errorf("failed to get role name: %w", err)
 	}
 
 	// Get the member role name from OID
 	var memberRoleName string
-	err = db.QueryRow(roleQuery, req.MemberRoleOID).Scan(&memberRoleName)
+	err = db.QueryRowContext(ctx, roleQuery, req.MemberRoleOID).Scan(&memberRoleName)
 	if err != nil {
 		return fmt.Errorf("failed to get member role name: %w", err)
 	}
 
 	// Build REVOKE ROLE statement
-	revokeSQL := fmt.Sprintf("REVOKE %s FROM %s", roleName, memberRoleName)
+	revokeSQL := fmt.Sprintf("REVOKE %s FROM %s", quotePostgresName(roleName), quotePostgresName(memberRoleName))
 
-	_, err = db.Exec(revokeSQL)
+	_, err = db.ExecContext(ctx, revokeSQL)
 	if err != nil {
 		return fmt.Errorf("failed to revoke membership: %w", err)
 	}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/lib/pq"
+
+	"truadmin/internal/models"
+)
+
+// blockingSession is a backend that waits for a lock or holds one another backend waits for
+type blockingSession struct {
+	blockers       []int64
+	state          string
+	query          string
+	lockMode       string
+	lockedResource string
+	waitedMs       int64
+}
+
+// resolveBlockingChains annotates blocked sessions with their blocking chain: the root blocker
+// found by following pg_blocking_pids, the lock mode waited for and how long the chain has existed.
+// Blockers are looked up server-wide, since a chain can cross databases.
+func resolveBlockingChains(db *sql.DB, queries []*models.ActiveQuery) error {
+	blocked := false
+	for _, q := range queries {
+		if q.BlockedBy != "" {
+			blocked = true
+			break
+		}
+	}
+	if !blocked {
+		return nil
+	}
+
+	sessions, err := loadBlockingSessions(db)
+	if err != nil {
+		return err
+	}
+
+	for _, q := range queries {
+		pid, err := strconv.ParseInt(q.ID, 10, 64)
+		if err != nil {
+			continue
+		}
+		session, ok := sessions[pid]
+		if !ok || len(session.blockers) == 0 {
+			continue
+		}
+
+		q.LockMode = session.lockMode
+		q.LockedResource = session.lockedResource
+		q.BlockedFor = session.waitedMs
+
+		// The chain exists since its most recent link formed
+		chainDuration := session.waitedMs
+		visited := map[int64]bool{pid: true}
+		current := session
+		for current != nil && len(current.blockers) > 0 {
+			// With several blockers the first one is followed
+			next := current.blockers[0]
+			if visited[next] {
+				q.BlockingCycle = true
+				break
+			}
+			visited[next] = true
+			q.BlockingChain = append(q.BlockingChain, strconv.FormatInt(next, 10))
+
+			current = sessions[next] // nil when the blocker ended since pg_blocking_pids was read
+			if current != nil && len(current.blockers) > 0 && current.waitedMs < chainDuration {
+				chainDuration = current.waitedMs
+			}
+		}
+
+		if !q.BlockingCycle && len(q.BlockingChain) > 0 {
+			q.RootBlocker = q.BlockingChain[len(q.BlockingChain)-1]
+			if current != nil {
+				q.RootBlockerState = current.state
+				q.RootBlockerQuery = current.query
+			}
+		}
+		q.ChainDuration = chainDuration
+	}
+	return nil
+}
+
+// loadBlockingSessions reads every backend of the server that is blocked or blocking, with the lock it waits for
+func loadBlockingSessions(db *sql.DB) (map[int64]*blockingSession, error) {
+	var versionNum int
+	if err := db.QueryRow(`SELECT current_setting('server_version_num')::int`).Scan(&versionNum); err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+
+	// pg_locks.waitstart exists since PostgreSQL 14; older servers only tell when the query started
+	lockColumns, waitStart := "mode, locktype, relation", "a.query_start"
+	if versionNum >= 140000 {
+		lockColumns, waitStart = "mode, locktype, relation, waitstart", "COALESCE(l.waitstart, a.query_start)"
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`
+		WITH blocked AS (
+			SELECT pid, pg_blocking_pids(pid) AS blockers
+			FROM pg_stat_activity
+			WHERE cardinality(pg_blocking_pids(pid)) > 0
+		)
+		SELECT
+			a.pid,
+			COALESCE(b.blockers, '{}'),
+			COALESCE(a.state, ''),
+			COALESCE(a.query, ''),
+			COALESCE(l.mode, ''),
+			COALESCE(l.relation::regclass::text, l.locktype, ''),
+			COALESCE((EXTRACT(EPOCH FROM (NOW() - %s)) * 1000)::bigint, 0)
+		FROM pg_stat_activity a
+		LEFT JOIN blocked b ON b.pid = a.pid
+		LEFT JOIN LATERAL (
+			SELECT %s FROM pg_locks WHERE pid = a.pid AND NOT granted LIMIT 1
+		) l ON true
+		WHERE b.pid IS NOT NULL
+			OR a.pid IN (SELECT unnest(blockers) FROM blocked)
+	`, waitStart, lockColumns))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocking sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := map[int64]*blockingSession{}
+	for rows.Next() {
+		var pid int64
+		var session blockingSession
+		if err := rows.Scan(&pid, pq.Array(&session.blockers), &session.state, &session.query, &session.lockMode, &session.lockedResource, &session.waitedMs); err != nil {
+			return nil, fmt.Errorf("failed to scan blocking session: %w", err)
+		}
+		sessions[pid] = &session
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating blocking sessions: %w", err)
+	}
+
+	return sessions, nil
+}
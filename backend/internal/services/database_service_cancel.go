@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// ErrQueryIDInUse is returned when a query is started with the ID of a query that is still running
+var ErrQueryIDInUse = errors.New("a query with this ID is already running")
+
+// ErrQueryCancelForbidden is returned when a user cancels a query started by someone else
+var ErrQueryCancelForbidden = errors.New("access to query denied")
+
+// runningQuery is an in-flight query started with a query ID
+type runningQuery struct {
+	connectionID string
+	userID       string
+	cancel       context.CancelFunc
+}
+
+// TrackQuery returns the context a user query runs under. It ends with parent (e.g. when the client
+// disconnects), after the request's timeout_ms (capped at the configured maximum) and, when the
+// request has a query_id, when CancelQuery is called with that ID. done must be called once the
+// query has finished.
+func (s *DatabaseService) TrackQuery(parent context.Context, connectionID, userID string, req *models.QueryRequest) (context.Context, func(), error) {
+	ctx, cancel := context.WithCancel(parent)
+	if req.TimeoutMs > 0 {
+		timeout := time.Duration(req.TimeoutMs) * time.Millisecond
+		if s.limits.MaxTimeout > 0 && timeout > s.limits.MaxTimeout {
+			timeout = s.limits.MaxTimeout
+		}
+		cancel()
+		ctx, cancel = context.WithTimeout(parent, timeout)
+	}
+	if req.QueryID == "" {
+		return ctx, cancel, nil
+	}
+
+	s.runningMu.Lock()
+	defer s.runningMu.Unlock()
+
+	if _, ok := s.running[req.QueryID]; ok {
+		cancel()
+		return nil, nil, ErrQueryIDInUse
+	}
+	s.running[req.QueryID] = &runningQuery{
+		connectionID: connectionID,
+		userID:       userID,
+		cancel:       cancel,
+	}
+
+	done := func() {
+		s.runningMu.Lock()
+		delete(s.running, req.QueryID)
+		s.runningMu.Unlock()
+		cancel()
+	}
+	return ctx, done, nil
+}
+
+// CancelQuery cancels an in-flight query of a connection by the query_id it was started with.
+// Users can cancel their own queries; admins can cancel any.
+func (s *DatabaseService) CancelQuery(connectionID, queryID, userID string, isAdmin bool) error {
+	s.runningMu.Lock()
+	defer s.runningMu.Unlock()
+
+	query, ok := s.running[queryID]
+	if !ok || query.connectionID != connectionID {
+		return fmt.Errorf("query not found")
+	}
+	if !isAdmin && query.userID != userID {
+		return ErrQueryCancelForbidden
+	}
+
+	query.cancel()
+	return nil
+}
+
+// withQueryTimeout bounds ctx by the default query timeout unless it already has a deadline
+func (s *DatabaseService) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || s.limits.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.limits.Timeout)
+}
+
+// setStatementTimeout makes the database server stop the session's statements at the context
+// deadline too, so a query ends even when the cancellation sent by the driver is lost
+func setStatementTimeout(ctx context.Context, conn *sql.Conn, d dialect) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	remaining := time.Until(deadline)
+	if remaining < time.Millisecond {
+		remaining = time.Millisecond
+	}
+	if _, err := conn.ExecContext(ctx, d.statementTimeoutStatement(remaining)); err != nil {
+		return fmt.Errorf("failed to set statement timeout: %w", err)
+	}
+	return nil
+}
+
+// interruptedQueryError returns why a query stopped when its context ended before it finished,
+// or an empty string
+func interruptedQueryError(ctx context.Context) string {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return "query exceeded its timeout and was cancelled"
+	case context.Canceled:
+		return "query was cancelled"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"truadmin/internal/models"
+)
+
+// defaultCollationOID is the OID of the "default" collation, which follows the database locale
+const defaultCollationOID = 100
+
+// GetCollationAudit reports database encodings, collation version mismatches and the indexes they affect,
+// together with a REINDEX plan that rebuilds those indexes and refreshes the recorded versions
+func (s *DatabaseService) GetCollationAudit(ctx context.Context, connectionID, dbName string) (*models.CollationAuditReport, error) {
+	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.CollationAuditReport{
+		DatabaseName:         dbName,
+		Databases:            []models.DatabaseEncoding{},
+		MismatchedCollations: []models.CollationMismatch{},
+		AffectedIndexes:      []models.CollationAffectedIndex{},
+		ReindexPlan:          []string{},
+	}
+
+	if err := db.QueryRowContext(ctx, `SELECT current_setting('server_version_num')::int`).Scan(&report.ServerVersionNum); err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT datname, pg_encoding_to_char(encoding), datcollate, datctype
+		FROM pg_database
+		WHERE datistemplate = false
+		ORDER BY datname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database encodings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var enc models.DatabaseEncoding
+		if err := rows.Scan(&enc.Name, &enc.Encoding, &enc.Collate, &enc.Ctype); err != nil {
+			return nil, fmt.Errorf("failed to scan database encoding: %w", err)
+		}
+		report.Databases = append(report.Databases, enc)
+	}
+
+	// Database-level collation versions are only tracked since PostgreSQL 15
+	if report.ServerVersionNum >= 150000 {
+		var recorded, actual sql.NullString
+		err := db.QueryRowContext(ctx, `
+			SELECT datcollversion, pg_database_collation_actual_version(oid)
+			FROM pg_database
+			WHERE datname = current_database()
+		`).Scan(&recorded, &actual)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get database collation version: %w", err)
+		}
+		if recorded.Valid {
+			report.DatabaseCollationVersion = &recorded.String
+		}
+		if actual.Valid {
+			report.ActualCollationVersion = &actual.String
+		}
+		report.DatabaseVersionMismatch = recorded.Valid && actual.Valid && recorded.String != actual.String
+	}
+
+	mismatchRows, err := db.QueryContext(ctx, `
+		SELECT n.nspname, c.collname,
+			CASE c.collprovider WHEN 'i' THEN 'icu' WHEN 'c' THEN 'libc' ELSE 'default' END,
+			c.collversion, pg_collation_actual_version(c.oid)
+		FROM pg_collation c
+		JOIN pg_namespace n ON n.oid = c.collnamespace
+		WHERE c.collversion IS NOT NULL
+		  AND c.collversion IS DISTINCT FROM pg_collation_actual_version(c.oid)
+		ORDER BY n.nspname, c.collname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collation versions: %w", err)
+	}
+	defer mismatchRows.Close()
+
+	for mismatchRows.Next() {
+		var m models.CollationMismatch
+		var actual sql.NullString
+		if err := mismatchRows.Scan(&m.SchemaName, &m.Name, &m.Provider, &m.RecordedVersion, &actual); err != nil {
+			return nil, fmt.Errorf("failed to scan collation: %w", err)
+		}
+		m.ActualVersion = actual.String
+		report.MismatchedCollations = append(report.MismatchedCollations, m)
+	}
+
+	// Indexes are affected when they use a mismatched collation explicitly,
+	// or use the default collation while the database collation version is stale
+	indexRows, err := db.QueryContext(ctx, `
+		SELECT DISTINCT n.nspname, t.relname, ic.relname, coll.collname
+		FROM pg_index i
+		JOIN pg_class ic ON ic.oid = i.indexrelid
+		JOIN pg_class t ON t.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = ic.relnamespace
+		CROSS JOIN LATERAL unnest(i.indcollation::oid[]) AS u(colloid)
+		JOIN pg_collation coll ON coll.oid = u.colloid
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+		  AND n.nspname NOT LIKE 'pg_toast%'
+		  AND (
+			(coll.collversion IS NOT NULL AND coll.collversion IS DISTINCT FROM pg_collation_actual_version(coll.oid))
+			OR (coll.oid = $1 AND $2)
+		  )
+		ORDER BY n.nspname, t.relname, ic.relname
+	`, defaultCollationOID, report.DatabaseVersionMismatch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected indexes: %w", err)
+	}
+	defer indexRows.Close()
+
+	seen := map[string]bool{}
+	for indexRows.Next() {
+		var idx models.CollationAffectedIndex
+		if err := indexRows.Scan(&idx.SchemaName, &idx.TableName, &idx.IndexName, &idx.Collation); err != nil {
+			return nil, fmt.Errorf("failed to scan index: %w", err)
+		}
+		report.AffectedIndexes = append(report.AffectedIndexes, idx)
+
+		qualified := pq.QuoteIdentifier(idx.SchemaName) + "." + pq.QuoteIdentifier(idx.IndexName)
+		if !seen[qualified] {
+			seen[qualified] = true
+			report.ReindexPlan = append(report.ReindexPlan, fmt.Sprintf("REINDEX INDEX CONCURRENTLY %s;", qualified))
+		}
+	}
+
+	// Recorded versions are refreshed only after the affected indexes are rebuilt
+	for _, m := range report.MismatchedCollations {
+		report.ReindexPlan = append(report.ReindexPlan, fmt.Sprintf("ALTER COLLATION %s.%s REFRESH VERSION;",
+			pq.QuoteIdentifier(m.SchemaName), pq.QuoteIdentifier(m.Name)))
+	}
+	if report.DatabaseVersionMismatch {
+		report.ReindexPlan = append(report.ReindexPlan, fmt.Sprintf("ALTER DATABASE %s REFRESH COLLATION VERSION;",
+			pq.QuoteIdentifier(dbName)))
+	}
+
+	return report, nil
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"truadmin/internal/models"
+)
+
+const (
+	// ddlProbeLockTimeout is how long a probed statement may wait for a lock before it counts as blocked
+	ddlProbeLockTimeout = 100 * time.Millisecond
+	// ddlProbeStatementTimeout bounds statements that got their locks but take long to run (e.g. table rewrites)
+	ddlProbeStatementTimeout = "5s"
+	// ddlProbePollInterval is how often the waiting probe session is inspected for blockers
+	ddlProbePollInterval = 10 * time.Millisecond
+)
+
+// PostgreSQL error codes reported by a probed statement
+const (
+	pqLockNotAvailable = "55P03"
+	pqQueryCanceled    = "57014"
+)
+
+// ProbeDDL runs each statement of a script inside a transaction with a short lock_timeout and always
+// rolls it back. While a statement waits for a lock, the sessions holding it are recorded, so users
+// can see which sessions a migration would queue behind before running it for real.
+func (s *DatabaseService) ProbeDDL(ctx context.Context, connectionID, dbName string, script string) (*models.DDLProbeResult, error) {
+	statements := splitSQLStatements(script)
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("no statements to probe")
+	}
+	for _, stmt := range statements {
+		if isTransactionControlStatement(stmt) {
+			return nil, fmt.Errorf("transaction control statements cannot be probed: %s", sqlStatementKeyword(stmt))
+		}
+	}
+
+	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Always roll back: the probe must never persist changes
+	defer tx.Rollback()
+
+	var pid int
+	if err := tx.QueryRowContext(ctx, "SELECT pg_backend_pid()").Scan(&pid); err != nil {
+		return nil, fmt.Errorf("failed to get probe session: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL lock_timeout = '%dms'", ddlProbeLockTimeout.Milliseconds())); err != nil {
+		return nil, fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = '%s'", ddlProbeStatementTimeout)); err != nil {
+		return nil, fmt.Errorf("failed to set statement timeout: %w", err)
+	}
+
+	result := &models.DDLProbeResult{
+		LockTimeoutMs: int(ddlProbeLockTimeout.Milliseconds()),
+		Statements:    []models.DDLProbeStatement{},
+		ProbedAt:      time.Now().UTC(),
+	}
+
+	aborted := false
+	for _, stmt := range statements {
+		probe := models.DDLProbeStatement{Statement: stmt, Conflicts: []models.LockConflict{}}
+		if aborted {
+			// The transaction is aborted after an error, so later statements cannot run
+			probe.Status = "skipped"
+			result.Statements = append(result.Statements, probe)
+			continue
+		}
+
+		started := time.Now()
+		conflicts, err := probeStatement(ctx, db, tx, pid, stmt)
+		probe.DurationMs = time.Since(started).Milliseconds()
+		probe.Conflicts = conflicts
+
+		var pqErr *pq.Error
+		switch {
+		case err == nil:
+			probe.Status = "ok"
+		case errors.As(err, &pqErr) && string(pqErr.Code) == pqLockNotAvailable:
+			probe.Status = "blocked"
+			probe.Error = err.Error()
+			result.WouldBlock = true
+		case errors.As(err, &pqErr) && string(pqErr.Code) == pqQueryCanceled:
+			probe.Status = "timeout"
+			probe.Error = fmt.Sprintf("statement acquired its locks but ran longer than %s: %v", ddlProbeStatementTimeout, err)
+		default:
+			probe.Status = "error"
+			probe.Error = err.Error()
+		}
+		if err != nil {
+			aborted = true
+		}
+		if len(probe.Conflicts) > 0 {
+			result.WouldBlock = true
+		}
+		result.Statements = append(result.Statements, probe)
+	}
+
+	return result, nil
+}
+
+// probeStatement executes a statement in the probe transaction while another session of db
+// records which sessions block the probe session
+func probeStatement(ctx context.Context, db *sql.DB, tx *sql.Tx, pid int, stmt string) ([]models.LockConflict, error) {
+	watcher := watchLockConflicts(db, pid, ddlProbePollInterval)
+	_, err := execSingleStatement(ctx, tx, stmt)
+	return watcher.stop(), err
+}
+
+// lockConflictWatcher records the sessions blocking one session, polled from another session
+type lockConflictWatcher struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	seen   map[int]models.LockConflict
+}
+
+// watchLockConflicts polls the blockers of pid every interval until stop is called
+func watchLockConflicts(db *sql.DB, pid int, interval time.Duration) *lockConflictWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &lockConflictWatcher{cancel: cancel, seen: map[int]models.LockConflict{}}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for {
+			for _, conflict := range readLockConflicts(ctx, db, pid) {
+				w.seen[conflict.PID] = conflict
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+	return w
+}
+
+// stop ends the polling and returns every session seen blocking pid
+func (w *lockConflictWatcher) stop() []models.LockConflict {
+	w.cancel()
+	w.wg.Wait()
+
+	conflicts := make([]models.LockConflict, 0, len(w.seen))
+	for _, conflict := range w.seen {
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// readLockConflicts returns the sessions currently blocking pid with the locks they hold on the
+// relations pid is waiting for. Errors are ignored: the probe session may finish at any moment.
+func readLockConflicts(ctx context.Context, db *sql.DB, pid int) []models.LockConflict {
+	rows, err := db.QueryContext(ctx, `
+		SELECT a.pid,
+			COALESCE(a.usename, ''),
+			COALESCE(a.application_name, ''),
+			COALESCE(a.client_addr::text, ''),
+			COALESCE(a.state, ''),
+			COALESCE(a.query, ''),
+			a.xact_start,
+			COALESCE(string_agg(DISTINCT l.mode, ', '), ''),
+			COALESCE(string_agg(DISTINCT l.relation::regclass::text, ', '), '')
+		FROM pg_stat_activity a
+		LEFT JOIN pg_locks l ON l.pid = a.pid AND l.granted
+			AND l.relation IN (SELECT relation FROM pg_locks WHERE pid = $1 AND NOT granted)
+		WHERE a.pid = ANY(pg_blocking_pids($1))
+		GROUP BY a.pid, a.usename, a.application_name, a.client_addr, a.state, a.query, a.xact_start
+	`, pid)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var conflicts []models.LockConflict
+	for rows.Next() {
+		var conflict models.LockConflict
+		var xactStart sql.NullTime
+		if err := rows.Scan(&conflict.PID, &conflict.Username, &conflict.ApplicationName, &conflict.ClientAddr,
+			&conflict.State, &conflict.Query, &xactStart, &conflict.LockModes, &conflict.Relations); err != nil {
+			return conflicts
+		}
+		if xactStart.Valid {
+			conflict.XactStart = &xactStart.Time
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
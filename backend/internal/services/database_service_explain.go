@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// ErrInvalidExplain is returned for statements that cannot be explained
+var ErrInvalidExplain = errors.New("invalid explain request")
+
+const (
+	// explainDefaultTimeout bounds an analyzed run when the request does not set a timeout
+	explainDefaultTimeout = 30 * time.Second
+	// explainMaxTimeout is the longest statement timeout a request may ask for
+	explainMaxTimeout = 5 * time.Minute
+)
+
+// explainPlanKeys are the plan node properties mapped to typed fields of models.PlanNode
+var explainPlanKeys = map[string]bool{
+	"Node Type":           true,
+	"Parent Relationship": true,
+	"Relation Name":       true,
+	"Schema":              true,
+	"Alias":               true,
+	"Index Name":          true,
+	"Join Type":           true,
+	"Startup Cost":        true,
+	"Total Cost":          true,
+	"Plan Rows":           true,
+	"Plan Width":          true,
+	"Actual Startup Time": true,
+	"Actual Total Time":   true,
+	"Actual Rows":         true,
+	"Actual Loops":        true,
+	"Plans":               true,
+}
+
+// Explain returns the execution plan of a single statement as a tree. With analyze the statement
+// runs for actual timings, inside a transaction with a statement timeout that is always rolled
+// back, so data-modifying statements can be analyzed without changing anything. Cancelling ctx stops
+// the statement.
+func (s *DatabaseService) Explain(ctx context.Context, connectionID, dbName string, req *models.ExplainRequest) (*models.ExplainResult, error) {
+	statements := splitSQLStatements(req.Query)
+	if len(statements) != 1 {
+		return nil, fmt.Errorf("%w: exactly one statement can be explained, got %d", ErrInvalidExplain, len(statements))
+	}
+	stmt := statements[0]
+	switch keyword := sqlStatementKeyword(stmt); {
+	case keyword == "EXPLAIN":
+		return nil, fmt.Errorf("%w: send the statement without EXPLAIN", ErrInvalidExplain)
+	case isTransactionControlStatement(stmt):
+		return nil, fmt.Errorf("%w: transaction control statements cannot be explained: %s", ErrInvalidExplain, keyword)
+	}
+	if req.Buffers && !req.Analyze {
+		return nil, fmt.Errorf("%w: buffers requires analyze", ErrInvalidExplain)
+	}
+
+	timeout := explainDefaultTimeout
+	if req.TimeoutMs > 0 {
+		timeout = time.Duration(req.TimeoutMs) * time.Millisecond
+	}
+	if timeout > explainMaxTimeout {
+		timeout = explainMaxTimeout
+	}
+
+	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Always roll back: an analyzed statement must never persist changes
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())); err != nil {
+		return nil, fmt.Errorf("failed to set statement timeout: %w", err)
+	}
+
+	// Prepared, so the server rejects the statement if the splitter let more than one command through
+	explain, err := tx.PrepareContext(ctx, buildExplainSQL(stmt, req))
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain statement: %w", explainError(ctx, err))
+	}
+	defer explain.Close()
+
+	var raw []byte
+	if err := explain.QueryRowContext(ctx).Scan(&raw); err != nil {
+		return nil, fmt.Errorf("failed to explain statement: %w", explainError(ctx, err))
+	}
+
+	result, err := parseExplainOutput(raw)
+	if err != nil {
+		return nil, err
+	}
+	result.Query = stmt
+	result.Analyzed = req.Analyze
+	return result, nil
+}
+
+// explainError replaces the driver error of an explain cancelled through its context
+func explainError(ctx context.Context, err error) error {
+	if message := interruptedQueryError(ctx); message != "" {
+		return errors.New(message)
+	}
+	return err
+}
+
+// buildExplainSQL prefixes a statement with EXPLAIN and the requested options
+func buildExplainSQL(stmt string, req *models.ExplainRequest) string {
+	options := []string{"FORMAT JSON"}
+	if req.Analyze {
+		options = append(options, "ANALYZE")
+	}
+	if req.Buffers {
+		options = append(options, "BUFFERS")
+	}
+	if req.Verbose {
+		options = append(options, "VERBOSE")
+	}
+	return fmt.Sprintf("EXPLAIN (%s) %s", strings.Join(options, ", "), stmt)
+}
+
+// parseExplainOutput parses the JSON output of EXPLAIN into a plan tree
+func parseExplainOutput(raw []byte) (*models.ExplainResult, error) {
+	var output []map[string]any
+	if err := json.Unmarshal(raw, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	if len(output) == 0 {
+		return nil, fmt.Errorf("failed to parse plan: empty EXPLAIN output")
+	}
+	top := output[0]
+	plan, ok := top["Plan"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse plan: no plan in EXPLAIN output")
+	}
+
+	result := &models.ExplainResult{
+		Plan:            parsePlanNode(plan),
+		PlanningTimeMs:  planFloat(top, "Planning Time"),
+		ExecutionTimeMs: planFloat(top, "Execution Time"),
+		Raw:             raw,
+		ExplainedAt:     time.Now().UTC(),
+	}
+	if triggers, ok := top["Triggers"].([]any); ok && len(triggers) > 0 {
+		result.Triggers = triggers
+	}
+	return result, nil
+}
+
+// parsePlanNode converts a plan node and its children
+func parsePlanNode(node map[string]any) models.PlanNode {
+	planNode := models.PlanNode{
+		NodeType:           planString(node, "Node Type"),
+		ParentRelationship: planString(node, "Parent Relationship"),
+		RelationName:       planString(node, "Relation Name"),
+		Schema:             planString(node, "Schema"),
+		Alias:              planString(node, "Alias"),
+		IndexName:          planString(node, "Index Name"),
+		JoinType:           planString(node, "Join Type"),
+		ActualStartupMs:    planFloat(node, "Actual Startup Time"),
+		ActualTotalMs:      planFloat(node, "Actual Total Time"),
+		ActualRows:         planFloat(node, "Actual Rows"),
+		ActualLoops:        planFloat(node, "Actual Loops"),
+		Details:            map[string]any{},
+		Children:           []models.PlanNode{},
+	}
+	if v := planFloat(node, "Startup Cost"); v != nil {
+		planNode.StartupCost = *v
+	}
+	if v := planFloat(node, "Total Cost"); v != nil {
+		planNode.TotalCost = *v
+	}
+	if v := planFloat(node, "Plan Rows"); v != nil {
+		planNode.PlanRows = *v
+	}
+	if v := planFloat(node, "Plan Width"); v != nil {
+		planNode.PlanWidth = int(*v)
+	}
+	for key, value := range node {
+		if !explainPlanKeys[key] {
+			planNode.Details[key] = value
+		}
+	}
+
+	if children, ok := node["Plans"].([]any); ok {
+		for _, child := range children {
+			if childNode, ok := child.(map[string]any); ok {
+				planNode.Children = append(planNode.Children, parsePlanNode(childNode))
+			}
+		}
+	}
+
+	if planNode.ActualTotalMs != nil && planNode.ActualLoops != nil {
+		// Actual times are per loop; children's time is included in their parent's
+		exclusive := *planNode.ActualTotalMs * *planNode.ActualLoops
+		for _, child := range planNode.Children {
+			if child.ActualTotalMs != nil && child.ActualLoops != nil {
+				exclusive -= *child.ActualTotalMs * *child.ActualLoops
+			}
+		}
+		if exclusive < 0 {
+			exclusive = 0
+		}
+		planNode.ExclusiveMs = &exclusive
+	}
+	if planNode.ActualRows != nil && planNode.PlanRows > 0 {
+		factor := *planNode.ActualRows / planNode.PlanRows
+		planNode.RowsEstimateFactor = &factor
+	}
+	return planNode
+}
+
+// planString returns a text property of a plan node, or an empty string
+func planString(node map[string]any, key string) string {
+	value, _ := node[key].(string)
+	return value
+}
+
+// planFloat returns a numeric property of a plan node, or nil when it is missing
+func planFloat(node map[string]any, key string) *float64 {
+	value, ok := node[key].(float64)
+	if !ok {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// GetDatabaseMetrics returns point-in-time activity metrics of a database
+func (s *DatabaseService) GetDatabaseMetrics(connectionID, dbName string) (*models.DatabaseMetrics, error) {
+	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &models.DatabaseMetrics{
+		DatabaseName: dbName,
+		CollectedAt:  time.Now(),
+	}
+
+	err = db.QueryRow(`
+		SELECT
+			pg_database_size(datid),
+			numbackends,
+			xact_commit,
+			xact_rollback,
+			blks_read,
+			blks_hit,
+			tup_returned,
+			tup_fetched,
+			tup_inserted,
+			tup_updated,
+			tup_deleted,
+			deadlocks,
+			temp_bytes
+		FROM pg_stat_database
+		WHERE datname = current_database()
+	`).Scan(
+		&metrics.SizeBytes,
+		&metrics.Connections,
+		&metrics.XactCommit,
+		&metrics.XactRollback,
+		&metrics.BlksRead,
+		&metrics.BlksHit,
+		&metrics.TupReturned,
+		&metrics.TupFetched,
+		&metrics.TupInserted,
+		&metrics.TupUpdated,
+		&metrics.TupDeleted,
+		&metrics.Deadlocks,
+		&metrics.TempBytes,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database metrics: %w", err)
+	}
+
+	if total := metrics.BlksRead + metrics.BlksHit; total > 0 {
+		metrics.CacheHitRatio = float64(metrics.BlksHit) / float64(total)
+	}
+
+	return metrics, nil
+}
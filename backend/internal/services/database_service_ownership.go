@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"truadmin/internal/models"
+)
+
+// relkindAlterKeyword maps pg_class.relkind to the ALTER keyword used to change its owner
+var relkindAlterKeyword = map[string]string{
+	"r": "TABLE",
+	"p": "TABLE",
+	"v": "VIEW",
+	"m": "MATERIALIZED VIEW",
+	"S": "SEQUENCE",
+	"f": "FOREIGN TABLE",
+}
+
+// ChangeOwner changes the owner of a single database object
+func (s *DatabaseService) ChangeOwner(ctx context.Context, connectionID string, req *models.OwnershipChangeRequest) (*models.OwnershipChangeResult, error) {
+	var db *sql.DB
+	var err error
+
+	if req.ObjectDatabase != "" && req.ObjectType != "database" {
+		db, err = s.connectToSpecificDatabase(connectionID, req.ObjectDatabase)
+	} else {
+		db, err = s.connectToDatabase(connectionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkRoleExists(ctx, db, req.NewOwner); err != nil {
+		return nil, err
+	}
+
+	stmt, err := buildAlterOwnerStatement(ctx, db, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		return nil, fmt.Errorf("failed to change owner: %w", err)
+	}
+
+	return &models.OwnershipChangeResult{
+		Statements: []string{stmt},
+		Changed:    1,
+	}, nil
+}
+
+// ReassignSchemaOwnership moves every object in a schema owned by one role to another role
+func (s *DatabaseService) ReassignSchemaOwnership(ctx context.Context, connectionID, dbName, schemaName string, req *models.BulkOwnershipRequest) (*models.OwnershipChangeResult, error) {
+	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkRoleExists(ctx, db, req.FromRole); err != nil {
+		return nil, err
+	}
+	if err := checkRoleExists(ctx, db, req.ToRole); err != nil {
+		return nil, err
+	}
+
+	statements, err := planSchemaOwnershipChange(ctx, db, schemaName, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.OwnershipChangeResult{
+		Statements: statements,
+		DryRun:     req.DryRun,
+	}
+	if req.DryRun || len(statements) == 0 {
+		return result, nil
+	}
+
+	// Apply all changes atomically so a failure does not leave the schema half-migrated
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("failed to execute %q: %w", stmt, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	result.Changed = len(statements)
+	return result, nil
+}
+
+// planSchemaOwnershipChange builds ALTER ... OWNER TO statements for objects in a schema owned by req.FromRole
+func planSchemaOwnershipChange(ctx context.Context, db *sql.DB, schemaName string, req *models.BulkOwnershipRequest) ([]string, error) {
+	var schemaOwner string
+	err := db.QueryRowContext(ctx, `
+		SELECT pg_get_userbyid(nspowner) FROM pg_namespace WHERE nspname = $1
+	`, schemaName).Scan(&schemaOwner)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("schema not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema: %w", err)
+	}
+
+	toRole := pq.QuoteIdentifier(req.ToRole)
+	statements := []string{}
+
+	if req.IncludeSchema && schemaOwner == req.FromRole {
+		statements = append(statements, fmt.Sprintf("ALTER SCHEMA %s OWNER TO %s", pq.QuoteIdentifier(schemaName), toRole))
+	}
+
+	// Relations; sequences owned by a table column follow their table and are skipped
+	relRows, err := db.QueryContext(ctx, `
+		SELECT c.relname, c.relkind::text
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+		  AND pg_get_userbyid(c.relowner) = $2
+		  AND c.relkind IN ('r', 'p', 'v', 'm', 'S', 'f')
+		  AND NOT c.relispartition
+		  AND NOT EXISTS (
+			SELECT 1 FROM pg_depend d
+			WHERE d.classid = 'pg_class'::regclass AND d.objid = c.oid
+			  AND d.refclassid = 'pg_class'::regclass AND d.deptype IN ('a', 'i')
+		  )
+		ORDER BY c.relkind, c.relname
+	`, schemaName, req.FromRole)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list relations: %w", err)
+	}
+	defer relRows.Close()
+
+	for relRows.Next() {
+		var name, relkind string
+		if err := relRows.Scan(&name, &relkind); err != nil {
+			return nil, fmt.Errorf("failed to scan relation: %w", err)
+		}
+		statements = append(statements, fmt.Sprintf("ALTER %s %s.%s OWNER TO %s",
+			relkindAlterKeyword[relkind], pq.QuoteIdentifier(schemaName), pq.QuoteIdentifier(name), toRole))
+	}
+	if err := relRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list relations: %w", err)
+	}
+
+	// Functions, procedures and aggregates; regprocedure renders the qualified signature
+	procRows, err := db.QueryContext(ctx, `
+		SELECT p.oid::regprocedure::text, p.prokind::text
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = $1
+		  AND pg_get_userbyid(p.proowner) = $2
+		ORDER BY p.proname
+	`, schemaName, req.FromRole)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list functions: %w", err)
+	}
+	defer procRows.Close()
+
+	for procRows.Next() {
+		var signature, prokind string
+		if err := procRows.Scan(&signature, &prokind); err != nil {
+			return nil, fmt.Errorf("failed to scan function: %w", err)
+		}
+		keyword := "FUNCTION"
+		switch prokind {
+		case "p":
+			keyword = "PROCEDURE"
+		case "a":
+			keyword = "AGGREGATE"
+		}
+		statements = append(statements, fmt.Sprintf("ALTER %s %s OWNER TO %s", keyword, signature, toRole))
+	}
+	if err := procRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list functions: %w", err)
+	}
+
+	return statements, nil
+}
+
+// buildAlterOwnerStatement builds the ALTER ... OWNER TO statement for a single object
+func buildAlterOwnerStatement(ctx context.Context, db *sql.DB, req *models.OwnershipChangeRequest) (string, error) {
+	newOwner := pq.QuoteIdentifier(req.NewOwner)
+
+	qualified := pq.QuoteIdentifier(req.ObjectName)
+	if req.ObjectSchema != "" {
+		qualified = pq.QuoteIdentifier(req.ObjectSchema) + "." + pq.QuoteIdentifier(req.ObjectName)
+	}
+
+	switch req.ObjectType {
+	case "database":
+		return fmt.Sprintf("ALTER DATABASE %s OWNER TO %s", pq.QuoteIdentifier(req.ObjectName), newOwner), nil
+	case "schema":
+		return fmt.Sprintf("ALTER SCHEMA %s OWNER TO %s", pq.QuoteIdentifier(req.ObjectName), newOwner), nil
+	case "table":
+		return fmt.Sprintf("ALTER TABLE %s OWNER TO %s", qualified, newOwner), nil
+	case "view":
+		return fmt.Sprintf("ALTER VIEW %s OWNER TO %s", qualified, newOwner), nil
+	case "materialized_view":
+		return fmt.Sprintf("ALTER MATERIALIZED VIEW %s OWNER TO %s", qualified, newOwner), nil
+	case "sequence":
+		return fmt.Sprintf("ALTER SEQUENCE %s OWNER TO %s", qualified, newOwner), nil
+	case "function", "procedure":
+		signature, err := resolveRoutineSignature(ctx, db, req.ObjectSchema, req.ObjectName)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("ALTER %s %s OWNER TO %s", strings.ToUpper(req.ObjectType), signature, newOwner), nil
+	default:
+		return "", fmt.Errorf("unsupported object type: %s", req.ObjectType)
+	}
+}
+
+// resolveRoutineSignature returns the regprocedure text for a function or procedure.
+// A name without an argument list must match exactly one routine in the schema.
+func resolveRoutineSignature(ctx context.Context, db *sql.DB, schemaName, name string) (string, error) {
+	if strings.Contains(name, "(") {
+		ref := name
+		if schemaName != "" {
+			ref = pq.QuoteIdentifier(schemaName) + "." + name
+		}
+		var signature sql.NullString
+		if err := db.QueryRowContext(ctx, `SELECT to_regprocedure($1)::text`, ref).Scan(&signature); err != nil {
+			return "", fmt.Errorf("failed to resolve function: %w", err)
+		}
+		if !signature.Valid {
+			return "", fmt.Errorf("function not found")
+		}
+		return signature.String, nil
+	}
+
+	if schemaName == "" {
+		schemaName = "public"
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT p.oid::regprocedure::text
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = $1 AND p.proname = $2
+	`, schemaName, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve function: %w", err)
+	}
+	defer rows.Close()
+
+	signatures := []string{}
+	for rows.Next() {
+		var signature string
+		if err := rows.Scan(&signature); err != nil {
+			return "", fmt.Errorf("failed to scan function: %w", err)
+		}
+		signatures = append(signatures, signature)
+	}
+
+	switch len(signatures) {
+	case 0:
+		return "", fmt.Errorf("function not found")
+	case 1:
+		return signatures[0], nil
+	default:
+		return "", fmt.Errorf("function name is ambiguous, specify argument types: %s", strings.Join(signatures, ", "))
+	}
+}
+
+// checkRoleExists verifies that a role with the given name exists
+func checkRoleExists(ctx context.Context, db *sql.DB, roleName string) error {
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM pg_roles WHERE rolname = $1)`, roleName).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check role: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("role %s not found", roleName)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"truadmin/internal/models"
+)
+
+// ErrQueryNotPageable is returned when pagination is requested for anything but a single SELECT
+var ErrQueryNotPageable = errors.New("only a single SELECT statement can be paginated")
+
+// ErrInvalidQueryCursor is returned for cursors that are malformed or belong to a different query
+var ErrInvalidQueryCursor = errors.New("invalid query cursor")
+
+// defaultQueryPageSize is used when a cursor is given without a page size
+const defaultQueryPageSize = 100
+
+// queryCursor is the position after a page of a paginated query, handed to clients as opaque base64
+type queryCursor struct {
+	Query  string `json:"q"`           // Fingerprint of the query and key columns the cursor belongs to
+	Offset int    `json:"o,omitempty"` // Rows already read, for offset pagination
+	After  []any  `json:"a,omitempty"` // Key column values of the last row read, for keyset pagination
+}
+
+// ExecuteQueryPage executes one page of a SELECT on a specific database. The statement is wrapped
+// in a subquery that is limited to the page, so only one page is ever held in memory.
+func (s *DatabaseService) ExecuteQueryPage(ctx context.Context, connectionID, dbName string, req *models.QueryRequest) (*models.QueryResult, error) {
+	query, err := pageableQuery(req.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultQueryPageSize
+	}
+	if s.limits.MaxRows > 0 && pageSize > s.limits.MaxRows {
+		pageSize = s.limits.MaxRows
+	}
+
+	fingerprint := queryFingerprint(query, req.KeyColumns)
+	cursor := &queryCursor{Query: fingerprint}
+	if req.Cursor != "" {
+		if cursor, err = decodeQueryCursor(req.Cursor); err != nil || cursor.Query != fingerprint {
+			return nil, ErrInvalidQueryCursor
+		}
+		if cursor.After != nil && len(cursor.After) != len(req.KeyColumns) {
+			return nil, ErrInvalidQueryCursor
+		}
+	}
+
+	db, d, err := s.connect(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	pagedQuery, args, err := buildPagedQuery(d, query, req.KeyColumns, cursor, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	var result *models.QueryResult
+	err = withSession(ctx, db, d, func(conn *sql.Conn) error {
+		if err := setStatementTimeout(ctx, conn, d); err != nil {
+			return err
+		}
+		// One row past the page tells whether there is a next page
+		rows, err := conn.QueryContext(ctx, pagedQuery, args...)
+		result = readQueryResult(rows, err, pageSize)
+		if message := interruptedQueryError(ctx); message != "" && result.Error != "" {
+			result.Error = message
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Error != "" || !result.Truncated {
+		result.Truncated = false
+		return result, nil
+	}
+	result.Truncated = false
+
+	next := queryCursor{Query: fingerprint}
+	if len(req.KeyColumns) == 0 {
+		next.Offset = cursor.Offset + len(result.Rows)
+	} else {
+		last := result.Rows[len(result.Rows)-1]
+		for _, column := range req.KeyColumns {
+			value, ok := last[column]
+			if !ok {
+				result.Error = fmt.Sprintf("key column %s is not in the result", column)
+				return result, nil
+			}
+			next.After = append(next.After, value)
+		}
+	}
+
+	if result.NextCursor, err = encodeQueryCursor(&next); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StreamQuery executes a SQL query on a specific database and hands its columns and then each row
+// to the callbacks as they are read, so the result set is never held in memory. SQL errors are
+// reported in the summary; an error returned by a callback (e.g. a closed client) stops the query.
+// The default query timeout does not apply to streams, only a deadline of ctx.
+func (s *DatabaseService) StreamQuery(ctx context.Context, connectionID, dbName, query string, onColumns func([]string) error, onRow func(map[string]any) error) (*models.QueryStreamSummary, error) {
+	db, d, err := s.connect(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.QueryStreamSummary{}
+	err = withSession(ctx, db, d, func(conn *sql.Conn) error {
+		if err := setStatementTimeout(ctx, conn, d); err != nil {
+			return err
+		}
+		rows, err := conn.QueryContext(ctx, query)
+		if err != nil {
+			summary.Error = err.Error()
+			if message := interruptedQueryError(ctx); message != "" {
+				summary.Error = message
+			}
+			return nil
+		}
+		defer rows.Close()
+
+		columns, err := rows.Columns()
+		if err != nil {
+			summary.Error = err.Error()
+			return nil
+		}
+		if err := onColumns(models.NonNil(columns)); err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			if s.limits.StreamMaxRows > 0 && summary.RowCount >= int64(s.limits.StreamMaxRows) {
+				summary.Truncated = true
+				return nil
+			}
+
+			row, err := scanQueryRow(rows, columns)
+			if err != nil {
+				summary.Error = err.Error()
+				return nil
+			}
+			if err := onRow(row); err != nil {
+				return err
+			}
+			summary.RowCount++
+		}
+
+		if err := rows.Err(); err != nil {
+			summary.Error = err.Error()
+			if message := interruptedQueryError(ctx); message != "" {
+				summary.Error = message
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// pageableQuery returns the statement of a single-SELECT query without its terminating semicolon
+func pageableQuery(query string) (string, error) {
+	statements := splitSQLStatements(query)
+	if len(statements) != 1 {
+		return "", ErrQueryNotPageable
+	}
+	switch sqlStatementKeyword(statements[0]) {
+	case "SELECT", "WITH", "VALUES", "TABLE":
+		return statements[0], nil
+	}
+	return "", ErrQueryNotPageable
+}
+
+// buildPagedQuery wraps a SELECT so it returns the page after cursor plus one more row. With key
+// columns the rows are ordered by them and continue after the cursor's values, otherwise at its offset.
+func buildPagedQuery(d dialect, query string, keyColumns []string, cursor *queryCursor, pageSize int) (string, []any, error) {
+	// The statement goes on lines of its own so a trailing line comment cannot swallow the parenthesis
+	stmt := "SELECT * FROM (\n" + query + "\n) AS truadmin_page"
+	args := []any{}
+
+	if len(keyColumns) > 0 {
+		quoted := make([]string, len(keyColumns))
+		for i, column := range keyColumns {
+			quoted[i] = "truadmin_page." + quoteName(d, column)
+		}
+
+		if len(cursor.After) > 0 {
+			placeholders := make([]string, len(cursor.After))
+			for i, value := range cursor.After {
+				placeholders[i] = d.placeholder(i + 1)
+				args = append(args, value)
+			}
+			condition, err := d.keysetCondition(quoted, ">", placeholders)
+			if err != nil {
+				return "", nil, err
+			}
+			stmt += " WHERE " + condition
+		}
+		stmt += " ORDER BY " + strings.Join(quoted, ", ")
+	}
+
+	stmt += d.pageClause(pageSize+1, cursor.Offset, len(keyColumns) > 0)
+	return stmt, args, nil
+}
+
+// queryFingerprint identifies the query and key columns a cursor was issued for
+func queryFingerprint(query string, keyColumns []string) string {
+	hash := sha256.Sum256([]byte(query + "\x00" + strings.Join(keyColumns, "\x00")))
+	return hex.EncodeToString(hash[:8])
+}
+
+// encodeQueryCursor encodes a cursor as URL-safe base64 JSON
+func encodeQueryCursor(cursor *queryCursor) (string, error) {
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeQueryCursor decodes a cursor; numbers are kept as json.Number so large keys keep their precision
+func decodeQueryCursor(encoded string) (*queryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var cursor queryCursor
+	if err := decoder.Decode(&cursor); err != nil {
+		return nil, err
+	}
+	if cursor.Offset < 0 {
+		return nil, ErrInvalidQueryCursor
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"truadmin/internal/models"
+)
+
+// ErrInvalidPrivilegeCheck is returned for privilege checks with an unknown object type or a
+// privilege the object type does not have
+var ErrInvalidPrivilegeCheck = errors.New("invalid privilege check")
+
+// checkablePrivileges lists the privileges of each object type a privilege check accepts
+var checkablePrivileges = map[string][]string{
+	"database": {"CREATE", "CONNECT", "TEMPORARY"},
+	"schema":   {"CREATE", "USAGE"},
+	"table":    {"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"},
+	"column":   {"SELECT", "INSERT", "UPDATE", "REFERENCES"},
+	"sequence": {"USAGE", "SELECT", "UPDATE"},
+	"function": {"EXECUTE"},
+}
+
+// CheckRolePrivilege answers whether a role of a PostgreSQL connection holds a privilege on an
+// object, as the has_*_privilege functions do, without running anything as the role. The result
+// lists the ACL entries of the object that reach the role, each with the membership path from the
+// role to the grantee; grants held through memberships that do not inherit are listed too, since
+// they explain a denial that SET ROLE would lift.
+func (s *DatabaseService) CheckRolePrivilege(ctx context.Context, connectionID, roleID string, req *models.PrivilegeCheckRequest) (*models.PrivilegeCheckResult, error) {
+	objectType := strings.ToLower(req.ObjectType)
+	privilege := strings.ToUpper(strings.TrimSpace(req.Privilege))
+	if privilege == "TEMP" {
+		privilege = "TEMPORARY"
+	}
+	privileges, ok := checkablePrivileges[objectType]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown object type %q", ErrInvalidPrivilegeCheck, req.ObjectType)
+	}
+	if !slices.Contains(privileges, privilege) {
+		return nil, fmt.Errorf("%w: %s privileges are %s", ErrInvalidPrivilegeCheck, objectType, strings.Join(privileges, ", "))
+	}
+	if objectType != "database" && objectType != "schema" && req.ObjectSchema == "" {
+		return nil, fmt.Errorf("%w: object_schema is required for a %s", ErrInvalidPrivilegeCheck, objectType)
+	}
+	if objectType == "column" && req.Column == "" {
+		return nil, fmt.Errorf("%w: column is required for a column", ErrInvalidPrivilegeCheck)
+	}
+
+	db, err := s.connectToSpecificDatabase(connectionID, req.ObjectDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.PrivilegeCheckResult{
+		ObjectType: objectType,
+		Privilege:  privilege,
+		Grants:     []models.PrivilegeGrant{},
+	}
+	err = db.QueryRowContext(ctx, `SELECT rolname, rolsuper FROM pg_roles WHERE oid = $1::oid`, roleID).Scan(&result.Role, &result.Superuser)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("role not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	objectOID, object, err := resolvePrivilegeObject(ctx, db, objectType, req)
+	if err != nil {
+		return nil, err
+	}
+	result.Object = object
+
+	checkQuery, aclQuery := privilegeCheckQueries(objectType)
+	checkArgs := []interface{}{roleID, objectOID, privilege}
+	aclArgs := []interface{}{objectOID, privilege}
+	if objectType == "column" {
+		checkArgs = append(checkArgs, req.Column)
+		aclArgs = append(aclArgs, req.Column)
+	}
+	if err := db.QueryRowContext(ctx, checkQuery, checkArgs...).Scan(&result.Allowed); err != nil {
+		return nil, fmt.Errorf("failed to check privilege: %w", err)
+	}
+
+	memberships, err := loadRoleMemberships(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	inheritedPaths := memberships.paths(roleID, true)
+	allPaths := memberships.paths(roleID, false)
+
+	rows, err := db.QueryContext(ctx, aclQuery, aclArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query object privileges: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var grantee string
+		var grant models.PrivilegeGrant
+		if err := rows.Scan(&grantee, &grant.Grantor, &grant.WithGrantOption, &grant.Level); err != nil {
+			return nil, fmt.Errorf("failed to scan object privilege: %w", err)
+		}
+
+		// Grantee 0 is PUBLIC, which every role is implicitly a member of
+		if grantee == "0" {
+			grant.Grantee = "PUBLIC"
+			grant.Path = []string{result.Role, "PUBLIC"}
+			grant.Inherited = true
+		} else if path, ok := inheritedPaths[grantee]; ok {
+			grant.Grantee = memberships.names[grantee]
+			grant.Path = memberships.pathNames(path)
+			grant.Inherited = true
+		} else if path, ok := allPaths[grantee]; ok {
+			grant.Grantee = memberships.names[grantee]
+			grant.Path = memberships.pathNames(path)
+		} else {
+			continue
+		}
+		result.Grants = append(result.Grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating object privileges: %w", err)
+	}
+
+	// Shortest paths first, so the most direct explanation leads
+	slices.SortStableFunc(result.Grants, func(a, b models.PrivilegeGrant) int {
+		if a.Inherited != b.Inherited {
+			if a.Inherited {
+				return -1
+			}
+			return 1
+		}
+		return len(a.Path) - len(b.Path)
+	})
+	result.Reason = privilegeCheckReason(result)
+	return result, nil
+}
+
+// resolvePrivilegeObject finds the OID of the checked object and returns it with the object's
+// display name
+func resolvePrivilegeObject(ctx context.Context, db *sql.DB, objectType string, req *models.PrivilegeCheckRequest) (string, string, error) {
+	var oid sql.NullString
+	var object string
+	var err error
+
+	switch objectType {
+	case "database":
+		object = req.ObjectName
+		err = db.QueryRowContext(ctx, `SELECT oid::text FROM pg_database WHERE datname = $1`, req.ObjectName).Scan(&oid)
+	case "schema":
+		object = req.ObjectName
+		err = db.QueryRowContext(ctx, `SELECT oid::text FROM pg_namespace WHERE nspname = $1`, req.ObjectName).Scan(&oid)
+	case "table", "column", "sequence":
+		relkinds := []string{"r", "p", "v", "m", "f"}
+		if objectType == "sequence" {
+			relkinds = []string{"S"}
+		}
+		object = req.ObjectSchema + "." + req.ObjectName
+		err = db.QueryRowContext(ctx, `
+			SELECT c.oid::text
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = $1 AND c.relname = $2 AND c.relkind::text = ANY($3)
+		`, req.ObjectSchema, req.ObjectName, pq.Array(relkinds)).Scan(&oid)
+		if err == nil && objectType == "column" {
+			object += "." + req.Column
+			var exists bool
+			err = db.QueryRowContext(ctx, `
+				SELECT EXISTS (
+					SELECT 1 FROM pg_attribute
+					WHERE attrelid = $1::oid AND attname = $2 AND attnum > 0 AND NOT attisdropped
+				)
+			`, oid.String, req.Column).Scan(&exists)
+			if err == nil && !exists {
+				return "", "", fmt.Errorf("object not found")
+			}
+		}
+	case "function":
+		// The signature is parsed by to_regprocedure, which returns NULL for unknown functions
+		object = req.ObjectSchema + "." + req.ObjectName
+		err = db.QueryRowContext(ctx, `SELECT to_regprocedure($1)::oid::text`, pq.QuoteIdentifier(req.ObjectSchema)+"."+req.ObjectName).Scan(&oid)
+	}
+
+	if err == sql.ErrNoRows || (err == nil && !oid.Valid) {
+		return "", "", fmt.Errorf("object not found")
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve object: %w", err)
+	}
+	return oid.String, object, nil
+}
+
+// privilegeCheckQueries returns the has_*_privilege query of an object type, taking the role OID,
+// object OID, privilege and column, and the query listing the matching ACL entries of the object,
+// taking the object OID, privilege and column. Objects without an ACL get their built-in default.
+func privilegeCheckQueries(objectType string) (string, string) {
+	aclQuery := func(catalog, aclColumn, aclKind, ownerColumn string) string {
+		return fmt.Sprintf(`
+			SELECT a.grantee::text, pg_get_userbyid(a.grantor), a.is_grantable, 'object'
+			FROM %s o, aclexplode(coalesce(o.%s, acldefault('%s', o.%s))) a
+			WHERE o.oid = $1::oid AND a.privilege_type = $2
+		`, catalog, aclColumn, aclKind, ownerColumn)
+	}
+
+	switch objectType {
+	case "database":
+		return `SELECT has_database_privilege($1::oid, $2::oid, $3)`, aclQuery("pg_database", "datacl", "d", "datdba")
+	case "schema":
+		return `SELECT has_schema_privilege($1::oid, $2::oid, $3)`, aclQuery("pg_namespace", "nspacl", "n", "nspowner")
+	case "table":
+		return `SELECT has_table_privilege($1::oid, $2::oid, $3)`, aclQuery("pg_class", "relacl", "r", "relowner")
+	case "sequence":
+		return `SELECT has_sequence_privilege($1::oid, $2::oid, $3)`, aclQuery("pg_class", "relacl", "s", "relowner")
+	case "function":
+		return `SELECT has_function_privilege($1::oid, $2::oid, $3)`, aclQuery("pg_proc", "proacl", "f", "proowner")
+	default: // column
+		// A column privilege is held through a grant on the column or on the whole table
+		return `SELECT has_column_privilege($1::oid, $2::oid, $4::text, $3)`, `
+			SELECT a.grantee::text, pg_get_userbyid(a.grantor), a.is_grantable, 'object'
+			FROM pg_attribute o, aclexplode(coalesce(o.attacl, '{}'::aclitem[])) a
+			WHERE o.attrelid = $1::oid AND o.attname = $3 AND a.privilege_type = $2
+			UNION ALL
+			SELECT a.grantee::text, pg_get_userbyid(a.grantor), a.is_grantable, 'table'
+			FROM pg_class o, aclexplode(coalesce(o.relacl, acldefault('r', o.relowner))) a
+			WHERE o.oid = $1::oid AND a.privilege_type = $2
+		`
+	}
+}
+
+// privilegeCheckReason summarizes why a privilege check was allowed or denied
+func privilegeCheckReason(result *models.PrivilegeCheckResult) string {
+	if result.Superuser {
+		return "superusers bypass all privilege checks"
+	}
+
+	var inherited, setRoleOnly *models.PrivilegeGrant
+	for i := range result.Grants {
+		if result.Grants[i].Inherited && inherited == nil {
+			inherited = &result.Grants[i]
+		}
+		if !result.Grants[i].Inherited && setRoleOnly == nil {
+			setRoleOnly = &result.Grants[i]
+		}
+	}
+
+	switch {
+	case result.Allowed && inherited != nil:
+		if len(inherited.Path) == 1 {
+			return fmt.Sprintf("granted to %s directly", result.Role)
+		}
+		return fmt.Sprintf("granted to %s, reached through %s", inherited.Grantee, strings.Join(inherited.Path, " -> "))
+	case result.Allowed:
+		// e.g. through predefined roles such as pg_read_all_data, which bypass object ACLs
+		return "granted by a server-wide role rather than by a grant on the object"
+	case setRoleOnly != nil:
+		return fmt.Sprintf("granted to %s, but a membership on the path %s does not inherit privileges; it applies only after SET ROLE %s",
+			setRoleOnly.Grantee, strings.Join(setRoleOnly.Path, " -> "), setRoleOnly.Grantee)
+	default:
+		return "not granted to the role, to any role it is a member of or to PUBLIC"
+	}
+}
+
+// roleMemberships is the role membership graph of a PostgreSQL server, keyed by role OID
+type roleMemberships struct {
+	names   map[string]string
+	parents map[string][]roleMembershipEdge
+}
+
+// roleMembershipEdge is a membership of a role in a parent role
+type roleMembershipEdge struct {
+	parent  string
+	inherit bool
+}
+
+// loadRoleMemberships reads all roles and memberships of the server. Before PostgreSQL 16 a
+// membership inherits when the member role has INHERIT; since 16 each grant has its own option.
+func loadRoleMemberships(ctx context.Context, db *sql.DB) (*roleMemberships, error) {
+	var versionNum int
+	if err := db.QueryRowContext(ctx, `SELECT current_setting('server_version_num')::int`).Scan(&versionNum); err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+
+	graph := &roleMemberships{names: map[string]string{}, parents: map[string][]roleMembershipEdge{}}
+
+	roleRows, err := db.QueryContext(ctx, `SELECT oid::text, rolname FROM pg_roles`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles: %w", err)
+	}
+	defer roleRows.Close()
+	for roleRows.Next() {
+		var oid, name string
+		if err := roleRows.Scan(&oid, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		graph.names[oid] = name
+	}
+	if err := roleRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating roles: %w", err)
+	}
+
+	membershipQuery := `
+		SELECT m.member::text, m.roleid::text, r.rolinherit
+		FROM pg_auth_members m
+		JOIN pg_roles r ON r.oid = m.member
+	`
+	if versionNum >= 160000 {
+		membershipQuery = `SELECT m.member::text, m.roleid::text, m.inherit_option FROM pg_auth_members m`
+	}
+	rows, err := db.QueryContext(ctx, membershipQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query role memberships: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var member string
+		var edge roleMembershipEdge
+		if err := rows.Scan(&member, &edge.parent, &edge.inherit); err != nil {
+			return nil, fmt.Errorf("failed to scan role membership: %w", err)
+		}
+		graph.parents[member] = append(graph.parents[member], edge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating role memberships: %w", err)
+	}
+	return graph, nil
+}
+
+// paths returns the shortest membership path from a role to every role it is a member of,
+// directly or indirectly, including the role itself. With inheritOnly, only memberships that
+// inherit privileges are followed.
+func (g *roleMemberships) paths(roleID string, inheritOnly bool) map[string][]string {
+	paths := map[string][]string{roleID: {roleID}}
+	queue := []string{roleID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range g.parents[current] {
+			if inheritOnly && !edge.inherit {
+				continue
+			}
+			if _, seen := paths[edge.parent]; seen {
+				continue
+			}
+			paths[edge.parent] = append(slices.Clone(paths[current]), edge.parent)
+			queue = append(queue, edge.parent)
+		}
+	}
+	return paths
+}
+
+// pathNames returns the role names of a membership path
+func (g *roleMemberships) pathNames(path []string) []string {
+	names := make([]string, len(path))
+	for i, oid := range path {
+		names[i] = g.names[oid]
+	}
+	return names
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"truadmin/internal/models"
+)
+
+// ErrInvalidRoleAlter is returned when a bulk role change has no filter, no changes or an invalid value
+var ErrInvalidRoleAlter = errors.New("invalid role change")
+
+// PlanRoleAlter resolves the roles matching a filter and builds the ALTER ROLE statement of each.
+// Roles that already have the requested attributes and the role of the saved connection itself
+// are listed as skipped.
+func (s *DatabaseService) PlanRoleAlter(ctx context.Context, connectionID string, req *models.BulkRoleAlterRequest) ([]models.RoleAlterPlan, error) {
+	filter := &req.Filter
+	if len(filter.RoleIDs) == 0 && filter.NamePattern == "" && filter.CanLogin == nil && filter.MemberOf == "" {
+		return nil, fmt.Errorf("%w: filter must set role_ids, name_pattern, can_login or member_of", ErrInvalidRoleAlter)
+	}
+	changes := &req.Changes
+	if changes.Login == nil && changes.Superuser == nil && changes.CreateDB == nil && changes.CreateRole == nil &&
+		changes.Inherit == nil && changes.Replication == nil && changes.BypassRLS == nil &&
+		changes.ConnectionLimit == nil && changes.ValidUntil == nil {
+		return nil, fmt.Errorf("%w: no attribute changes given", ErrInvalidRoleAlter)
+	}
+
+	db, err := s.connectToDatabase(connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []string{`r.rolname NOT LIKE 'pg\_%'`}
+	args := []interface{}{}
+	if len(filter.RoleIDs) > 0 {
+		args = append(args, pq.Array(filter.RoleIDs))
+		conditions = append(conditions, fmt.Sprintf("r.oid::text = ANY($%d)", len(args)))
+	}
+	if filter.NamePattern != "" {
+		args = append(args, filter.NamePattern)
+		conditions = append(conditions, fmt.Sprintf("r.rolname LIKE $%d", len(args)))
+	}
+	if filter.CanLogin != nil {
+		args = append(args, *filter.CanLogin)
+		conditions = append(conditions, fmt.Sprintf("r.rolcanlogin = $%d", len(args)))
+	}
+	if filter.MemberOf != "" {
+		args = append(args, filter.MemberOf)
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM pg_auth_members m JOIN pg_roles g ON g.oid = m.roleid
+			WHERE m.member = r.oid AND g.rolname = $%d
+		)`, len(args)))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			r.oid::text,
+			r.rolname,
+			r.rolcanlogin,
+			r.rolsuper,
+			r.rolcreatedb,
+			r.rolcreaterole,
+			r.rolinherit,
+			r.rolreplication,
+			r.rolbypassrls,
+			r.rolconnlimit,
+			r.rolname = current_user
+		FROM pg_roles r
+		WHERE %s
+		ORDER BY r.rolname
+	`, strings.Join(conditions, " AND "))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles: %w", err)
+	}
+	defer rows.Close()
+
+	plans := []models.RoleAlterPlan{}
+	for rows.Next() {
+		var plan models.RoleAlterPlan
+		var current roleAttributes
+		var isCurrentUser bool
+		if err := rows.Scan(&plan.RoleID, &plan.RoleName, &current.Login, &current.Superuser, &current.CreateDB,
+			&current.CreateRole, &current.Inherit, &current.Replication, &current.BypassRLS,
+			&current.ConnectionLimit, &isCurrentUser); err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+
+		if isCurrentUser {
+			// Changing it could lock TruAdmin out of the server
+			plan.Skipped = "role used by this connection"
+		} else {
+			stmt, err := buildAlterRoleSQL(plan.RoleName, current, changes)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidRoleAlter, err)
+			}
+			plan.Statement = stmt
+			if stmt == "" {
+				plan.Skipped = "already has the requested attributes"
+			}
+		}
+		plans = append(plans, plan)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating roles: %w", err)
+	}
+
+	return plans, nil
+}
+
+// alterRole executes a planned ALTER ROLE statement
+func (s *DatabaseService) alterRole(ctx context.Context, connectionID, stmt string) error {
+	db, err := s.connectToDatabase(connectionID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("failed to alter role: %w", err)
+	}
+	return nil
+}
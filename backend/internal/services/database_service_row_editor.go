@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"truadmin/internal/models"
+)
+
+// ErrInvalidRowEdit is returned for row edits that do not fit the table
+var ErrInvalidRowEdit = errors.New("invalid row edit")
+
+// ErrRowVersionConflict is returned when a row changed after the version an edit was made to
+var ErrRowVersionConflict = errors.New("row was changed since it was read; reload it and retry")
+
+// editableTable is a table resolved for the row editor
+type editableTable struct {
+	d          dialect
+	name       string   // Quoted schema and table name
+	columns    []string // In ordinal order
+	primaryKey []string
+}
+
+// InsertTableRow inserts a row into a table and returns it as stored, with its version
+func (s *DatabaseService) InsertTableRow(ctx context.Context, connectionID, dbName, schemaName, tableName string, values models.RowValues) (*models.TableRow, error) {
+	return runRowEdit(s, ctx, connectionID, dbName, schemaName, tableName, func(tx *sql.Tx, t *editableTable) (*models.TableRow, error) {
+		columns, args, err := t.assignments(values)
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) == 0 {
+			return nil, fmt.Errorf("%w: values must set at least one column", ErrInvalidRowEdit)
+		}
+
+		quoted := make([]string, len(columns))
+		placeholders := make([]string, len(columns))
+		for i, column := range columns {
+			quoted[i] = quoteName(t.d, column)
+			placeholders[i] = t.d.placeholder(i + 1)
+		}
+		stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", t.name, strings.Join(quoted, ", "), strings.Join(placeholders, ", "))
+
+		if returning := t.d.insertReturningClause(); returning != "" {
+			rows, err := tx.QueryContext(ctx, stmt+returning, args...)
+			if err != nil {
+				return nil, fmt.Errorf("failed to insert row: %w", err)
+			}
+			return readTableRow(rows)
+		}
+
+		result, err := tx.ExecContext(ctx, stmt, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert row: %w", err)
+		}
+		key := models.RowValues{}
+		for _, column := range t.primaryKey {
+			if value, ok := values[column]; ok {
+				key[column] = value
+			}
+		}
+		// A single key column left out is generated (AUTO_INCREMENT)
+		if len(key) < len(t.primaryKey) && len(t.primaryKey) == 1 {
+			id, err := result.LastInsertId()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the key of the inserted row: %w", err)
+			}
+			key[t.primaryKey[0]] = id
+		}
+		return t.selectRow(ctx, tx, key, false)
+	})
+}
+
+// UpdateTableRow changes columns of the row with the given primary key, provided it still has the
+// version the change was made to. It returns the row before and after the change.
+func (s *DatabaseService) UpdateTableRow(ctx context.Context, connectionID, dbName, schemaName, tableName string, req *models.TableRowUpdateRequest) (*models.TableRow, *models.TableRow, error) {
+	var old *models.TableRow
+	updated, err := runRowEdit(s, ctx, connectionID, dbName, schemaName, tableName, func(tx *sql.Tx, t *editableTable) (*models.TableRow, error) {
+		var err error
+		if old, err = t.lockRow(ctx, tx, req.Key, req.Version); err != nil {
+			return nil, err
+		}
+
+		columns, args, err := t.assignments(req.Values)
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) == 0 {
+			return old, nil
+		}
+
+		assignments := make([]string, len(columns))
+		for i, column := range columns {
+			assignments[i] = fmt.Sprintf("%s = %s", quoteName(t.d, column), t.d.placeholder(i+1))
+		}
+		condition, keyArgs, err := t.keyCondition(req.Key, len(args)+1)
+		if err != nil {
+			return nil, err
+		}
+		stmt := fmt.Sprintf("UPDATE %s SET %s WHERE %s", t.name, strings.Join(assignments, ", "), condition)
+		if _, err := tx.ExecContext(ctx, stmt, append(args, keyArgs...)...); err != nil {
+			return nil, fmt.Errorf("failed to update row: %w", err)
+		}
+
+		// The update may have changed the key itself
+		key := models.RowValues{}
+		for _, column := range t.primaryKey {
+			key[column] = req.Key[column]
+			if value, ok := req.Values[column]; ok {
+				key[column] = value
+			}
+		}
+		return t.selectRow(ctx, tx, key, false)
+	})
+	if err != nil {
+		return old, nil, err
+	}
+	return old, updated, nil
+}
+
+// DeleteTableRow deletes the row with the given primary key, provided it still has the version the
+// deletion was requested for, and returns the deleted row
+func (s *DatabaseService) DeleteTableRow(ctx context.Context, connectionID, dbName, schemaName, tableName string, req *models.TableRowDeleteRequest) (*models.TableRow, error) {
+	return runRowEdit(s, ctx, connectionID, dbName, schemaName, tableName, func(tx *sql.Tx, t *editableTable) (*models.TableRow, error) {
+		old, err := t.lockRow(ctx, tx, req.Key, req.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		condition, args, err := t.keyCondition(req.Key, 1)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s", t.name, condition), args...); err != nil {
+			return nil, fmt.Errorf("failed to delete row: %w", err)
+		}
+		return old, nil
+	})
+}
+
+// runRowEdit resolves a table with a primary key and runs an edit of it in a transaction that is
+// committed when the edit succeeds
+func runRowEdit(s *DatabaseService, ctx context.Context, connectionID, dbName, schemaName, tableName string, edit func(tx *sql.Tx, t *editableTable) (*models.TableRow, error)) (*models.TableRow, error) {
+	db, d, err := s.connect(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	schemaName, tableName, err = resolveTable(ctx, db, d, dbName, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := readTableColumns(ctx, db, d, dbName, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	t := &editableTable{d: d, name: qualifiedName(d, schemaName, tableName)}
+	for _, column := range columns {
+		t.columns = append(t.columns, column.Name)
+		if column.Key == "PRI" {
+			t.primaryKey = append(t.primaryKey, column.Name)
+		}
+	}
+	if len(t.primaryKey) == 0 {
+		return nil, fmt.Errorf("%w: rows can only be edited in tables with a primary key", ErrInvalidRowEdit)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	row, err := edit(tx, t)
+	if err != nil {
+		if message := interruptedQueryError(ctx); message != "" {
+			return nil, errors.New(message)
+		}
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit row edit: %w", err)
+	}
+	return row, nil
+}
+
+// assignments returns the columns set by values in a stable order with their arguments
+func (t *editableTable) assignments(values models.RowValues) ([]string, []any, error) {
+	columns := make([]string, 0, len(values))
+	resolved := make(models.RowValues, len(values))
+	for name, value := range values {
+		column, _, ok := matchName(t.columns, name)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: unknown column %q", ErrInvalidRowEdit, name)
+		}
+		columns = append(columns, column)
+		resolved[column] = value
+	}
+	sort.Strings(columns)
+
+	args := make([]any, len(columns))
+	for i, column := range columns {
+		args[i] = rowEditArg(resolved[column])
+	}
+	return columns, args, nil
+}
+
+// keyCondition builds the condition matching the primary key values of a row, with parameters numbered from start
+func (t *editableTable) keyCondition(key models.RowValues, start int) (string, []any, error) {
+	if len(key) != len(t.primaryKey) {
+		return "", nil, fmt.Errorf("%w: key must hold exactly the primary key columns %s", ErrInvalidRowEdit, strings.Join(t.primaryKey, ", "))
+	}
+	conditions := make([]string, len(t.primaryKey))
+	args := make([]any, len(t.primaryKey))
+	for i, column := range t.primaryKey {
+		value, ok := key[column]
+		if !ok || value == nil {
+			return "", nil, fmt.Errorf("%w: key must hold exactly the primary key columns %s", ErrInvalidRowEdit, strings.Join(t.primaryKey, ", "))
+		}
+		conditions[i] = fmt.Sprintf("%s = %s", quoteName(t.d, column), t.d.placeholder(start+i))
+		args[i] = rowEditArg(value)
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// lockRow reads a row for update and checks that it still has the expected version
+func (t *editableTable) lockRow(ctx context.Context, tx *sql.Tx, key models.RowValues, version string) (*models.TableRow, error) {
+	row, err := t.selectRow(ctx, tx, key, true)
+	if err != nil {
+		return nil, err
+	}
+	if row.Version != version {
+		return nil, ErrRowVersionConflict
+	}
+	return row, nil
+}
+
+// selectRow reads every column of the row with the given primary key, locking it when forUpdate is set
+func (t *editableTable) selectRow(ctx context.Context, tx *sql.Tx, key models.RowValues, forUpdate bool) (*models.TableRow, error) {
+	condition, args, err := t.keyCondition(key, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	quoted := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		quoted[i] = quoteName(t.d, column)
+	}
+	tableHint, suffix := "", ""
+	if forUpdate {
+		if tableHint, suffix, err = t.d.lockRowClauses(); err != nil {
+			return nil, err
+		}
+	}
+	stmt := fmt.Sprintf("SELECT %s FROM %s%s WHERE %s%s", strings.Join(quoted, ", "), t.name, tableHint, condition, suffix)
+
+	rows, err := tx.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read row: %w", err)
+	}
+	return readTableRow(rows)
+}
+
+// readTableRow reads the single row of a result with its version
+func readTableRow(rows *sql.Rows) (*models.TableRow, error) {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read row: %w", err)
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		return nil, fmt.Errorf("row not found")
+	}
+	values, err := scanQueryRow(rows, columns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+	return &models.TableRow{Values: values, Version: rowVersion(values)}, nil
+}
+
+// rowVersion hashes every column of a row; json.Marshal sorts the keys, so equal rows hash equally
+func rowVersion(row map[string]any) string {
+	encoded, _ := json.Marshal(row)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:16])
+}
+
+// rowEditArg converts a JSON value of a row edit to a statement argument; objects and arrays are
+// passed as JSON text for json columns
+func rowEditArg(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		return v.String()
+	case map[string]any, []any:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"truadmin/internal/models"
+)
+
+// BrowseTableRows reads a page of the rows of a table with the requested columns, filter and sort
+// order, so tables can be browsed without writing a SELECT. Offset pages also count the rows
+// matching the filter; keyset pages return the cursor of the next page instead.
+func (s *DatabaseService) BrowseTableRows(ctx context.Context, connectionID, dbName, schemaName, tableName string, req *models.TableRowsRequest) (*models.TableRowsPage, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultQueryPageSize
+	}
+	if s.limits.MaxRows > 0 && limit > s.limits.MaxRows {
+		limit = s.limits.MaxRows
+	}
+
+	var cursor *queryCursor
+	if req.Cursor != "" {
+		var err error
+		if cursor, err = decodeQueryCursor(req.Cursor); err != nil || len(cursor.After) == 0 {
+			return nil, ErrInvalidQueryCursor
+		}
+	}
+
+	db, d, err := s.connect(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	schemaName, tableName, err = resolveTable(ctx, db, d, dbName, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := readTableColumns(ctx, db, d, dbName, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var after []any
+	if cursor != nil {
+		after = cursor.After
+	}
+	q, err := buildTableRowsQuery(d, schemaName, tableName, columns, req, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := tableRowsFingerprint(schemaName, tableName, req.Filter, q)
+	if cursor != nil && cursor.Query != fingerprint {
+		return nil, ErrInvalidQueryCursor
+	}
+
+	page := &models.TableRowsPage{KeyColumns: q.primaryKey}
+	err = withSession(ctx, db, d, func(conn *sql.Conn) error {
+		if err := setStatementTimeout(ctx, conn, d); err != nil {
+			return err
+		}
+
+		if q.countQuery != "" {
+			var total int64
+			if err := conn.QueryRowContext(ctx, q.countQuery, q.countArgs...).Scan(&total); err != nil {
+				if message := interruptedQueryError(ctx); message != "" {
+					return errors.New(message)
+				}
+				return fmt.Errorf("failed to count table rows: %w", err)
+			}
+			page.Total = &total
+		}
+
+		rows, err := conn.QueryContext(ctx, q.query, q.args...)
+		result := readQueryResult(rows, err, limit)
+		if result.Error != "" {
+			if message := interruptedQueryError(ctx); message != "" {
+				return errors.New(message)
+			}
+			return fmt.Errorf("failed to read table rows: %s", result.Error)
+		}
+		page.Columns = result.Columns
+		page.Rows = result.Rows
+		if len(req.Columns) == 0 && len(q.primaryKey) > 0 {
+			page.Versions = make([]string, len(result.Rows))
+			for i, row := range result.Rows {
+				page.Versions[i] = rowVersion(row)
+			}
+		}
+
+		// One row past the page tells whether there is a next keyset page
+		if result.Truncated && (req.Keyset || cursor != nil) {
+			next := queryCursor{Query: fingerprint}
+			last := result.Rows[len(result.Rows)-1]
+			for _, column := range q.orderBy {
+				next.After = append(next.After, last[column])
+			}
+			if page.NextCursor, err = encodeQueryCursor(&next); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// readTableColumns reads the columns of a table with their types and keys
+func readTableColumns(ctx context.Context, db *sql.DB, d dialect, dbName, schemaName, tableName string) ([]*models.Column, error) {
+	query, args := d.columnDetailsQuery(dbName, schemaName, tableName)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+	defer rows.Close()
+
+	columns := []*models.Column{}
+	for rows.Next() {
+		var column models.Column
+		if err := rows.Scan(&column.Name, &column.Type, &column.Nullable, &column.Key, &column.Default); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		columns = append(columns, &column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating columns: %w", err)
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table not found")
+	}
+
+	return columns, nil
+}
+
+// tableRowsFingerprint identifies the table, filter and order a keyset cursor was issued for
+func tableRowsFingerprint(schemaName, tableName string, filter *models.FilterExpression, q *tableRowsQuery) string {
+	encodedFilter, _ := json.Marshal(filter)
+	return queryFingerprint(schemaName+"\x00"+tableName+"\x00"+string(encodedFilter)+"\x00"+q.direction, q.orderBy)
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"truadmin/internal/models"
+)
+
+// ErrRunAsRoleFailed is returned when the session cannot switch to the requested role, e.g. because
+// the role does not exist or the connection's login role is not a member of it
+var ErrRunAsRoleFailed = errors.New("cannot run as role")
+
+// ExecuteQueryAsRole executes a SQL query on a PostgreSQL database after SET ROLE to another role,
+// so the result shows what that role can see and do. The role stays in effect only for this query:
+// the session is reset before it goes back to the pool. The result reports the effective role.
+func (s *DatabaseService) ExecuteQueryAsRole(ctx context.Context, connectionID, dbName, role, query string) (*models.QueryResult, error) {
+	db, d, err := s.connect(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+	// MySQL's SET ROLE only activates roles granted to the current user; it cannot take on another identity
+	if d.name() != "postgres" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDialect, d.name())
+	}
+
+	ctx, cancel := s.withQueryTimeout(ctx)
+	defer cancel()
+
+	var result *models.QueryResult
+	err = withSession(ctx, db, d, func(conn *sql.Conn) error {
+		if err := setStatementTimeout(ctx, conn, d); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, "SET ROLE "+d.quoteIdentifier(role)); err != nil {
+			return fmt.Errorf("%w %s: %v", ErrRunAsRoleFailed, role, err)
+		}
+		var effectiveRole string
+		if err := conn.QueryRowContext(ctx, "SELECT current_user").Scan(&effectiveRole); err != nil {
+			return fmt.Errorf("failed to read effective role: %w", err)
+		}
+
+		rows, err := conn.QueryContext(ctx, query)
+		result = readQueryResult(rows, err, s.limits.MaxRows)
+		if message := interruptedQueryError(ctx); message != "" && result.Error != "" {
+			result.Error = message
+		}
+
+		// The query itself may have changed the role (SET ROLE / RESET ROLE); report the role it ended with.
+		// The read fails when the query left an aborted transaction, and the role is then unchanged.
+		var endRole string
+		if err := conn.QueryRowContext(ctx, "SELECT current_user").Scan(&endRole); err == nil {
+			effectiveRole = endRole
+		}
+		result.EffectiveRole = effectiveRole
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
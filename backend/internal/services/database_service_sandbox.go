@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"truadmin/internal/models"
+)
+
+// sandboxStatementTimeout bounds how long a sandbox run may hold locks before it is rolled back
+const sandboxStatementTimeout = "30s"
+
+// ExecuteSandboxQuery runs every statement of a script inside BEGIN ... ROLLBACK and reports
+// the rows each statement would affect. Nothing is ever committed. Cancelling ctx stops the run.
+func (s *DatabaseService) ExecuteSandboxQuery(ctx context.Context, connectionID, dbName string, query string) (*models.QueryResult, error) {
+	result := &models.QueryResult{
+		Columns:    []string{},
+		Rows:       []map[string]any{},
+		Sandbox:    true,
+		Statements: []models.StatementResult{},
+	}
+
+	statements := splitSQLStatements(query)
+	for _, stmt := range statements {
+		if isTransactionControlStatement(stmt) {
+			result.Error = fmt.Sprintf("transaction control statements are not allowed in sandbox mode: %s", sqlStatementKeyword(stmt))
+			return result, nil
+		}
+	}
+
+	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Always roll back: the sandbox must never persist changes
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = '%s'", sandboxStatementTimeout)); err != nil {
+		return nil, fmt.Errorf("failed to set statement timeout: %w", err)
+	}
+
+	for _, stmt := range statements {
+		stmtResult := models.StatementResult{Statement: stmt}
+
+		res, err := execSingleStatement(ctx, tx, stmt)
+		if err != nil {
+			// The transaction is aborted after an error, so later statements cannot run
+			stmtResult.Error = err.Error()
+			if message := interruptedQueryError(ctx); message != "" {
+				stmtResult.Error = message
+			}
+			result.Statements = append(result.Statements, stmtResult)
+			result.Error = stmtResult.Error
+			break
+		}
+
+		if affected, err := res.RowsAffected(); err == nil {
+			stmtResult.RowsAffected = affected
+		}
+		result.Statements = append(result.Statements, stmtResult)
+	}
+
+	return result, nil
+}
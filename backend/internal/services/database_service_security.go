@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// postgresOldestSupportedMajor is the oldest PostgreSQL major version still receiving fixes
+// (13 reached end of life in November 2025)
+const postgresOldestSupportedMajor = 14
+
+// securityDetailLimit caps the roles, grants and pg_hba lines listed per finding
+const securityDetailLimit = 50
+
+// GetSecurityReport checks the server of a PostgreSQL connection and one of its databases for
+// common weaknesses and scores the result. Checks the connection's role lacks the privileges for
+// are reported as skipped and left out of the score.
+func (s *DatabaseService) GetSecurityReport(ctx context.Context, connectionID, dbName string) (*models.SecurityReport, error) {
+	conn, err := s.connectionService.GetConnection(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.SecurityReport{
+		ConnectionID:   conn.ID,
+		ConnectionName: conn.Name,
+		DatabaseName:   dbName,
+		GeneratedAt:    time.Now().UTC(),
+	}
+	var versionNum int
+	if err := db.QueryRowContext(ctx, `SELECT current_setting('server_version'), current_setting('server_version_num')::int`).
+		Scan(&report.ServerVersion, &versionNum); err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+
+	report.Findings = []models.SecurityFinding{
+		checkSuperusers(ctx, db),
+		checkLoginSuperusers(ctx, db),
+		checkPasswordExpiry(ctx, db),
+		checkPublicSchemaGrants(ctx, db),
+		checkHBATrust(ctx, db),
+		checkSSL(ctx, db),
+		checkServerVersion(report.ServerVersion, versionNum),
+	}
+	report.Score, report.Grade = scoreSecurityFindings(report.Findings)
+
+	return report, nil
+}
+
+// checkSuperusers counts the superuser roles; each one bypasses every permission check
+func checkSuperusers(ctx context.Context, db *sql.DB) models.SecurityFinding {
+	finding := models.SecurityFinding{Check: "superusers", Title: "Superuser count", Weight: 10}
+	names, err := queryStrings(ctx, db, `SELECT rolname FROM pg_roles WHERE rolsuper ORDER BY rolname`)
+	if err != nil {
+		return skippedFinding(finding, err)
+	}
+
+	finding.Details = names
+	finding.Message = fmt.Sprintf("%d roles are superusers", len(names))
+	switch {
+	case len(names) <= 2:
+		finding.Status = models.SecurityCheckPass
+	case len(names) <= 5:
+		finding.Status = models.SecurityCheckWarn
+	default:
+		finding.Status = models.SecurityCheckFail
+	}
+	if finding.Status != models.SecurityCheckPass {
+		finding.Recommendation = "Grant narrower roles such as pg_monitor, pg_read_all_data or CREATEROLE instead of SUPERUSER"
+	}
+	return finding
+}
+
+// checkLoginSuperusers lists superusers that can log in, other than the bootstrap role
+func checkLoginSuperusers(ctx context.Context, db *sql.DB) models.SecurityFinding {
+	finding := models.SecurityFinding{Check: "login_superusers", Title: "Superusers with LOGIN", Weight: 15}
+	names, err := queryStrings(ctx, db, `SELECT rolname FROM pg_roles WHERE rolsuper AND rolcanlogin AND oid <> 10 ORDER BY rolname`)
+	if err != nil {
+		return skippedFinding(finding, err)
+	}
+
+	finding.Details = names
+	if len(names) == 0 {
+		finding.Status = models.SecurityCheckPass
+		finding.Message = "Only the bootstrap superuser can log in"
+		return finding
+	}
+	finding.Status = models.SecurityCheckWarn
+	finding.Message = fmt.Sprintf("%d roles besides the bootstrap superuser have both LOGIN and SUPERUSER", len(names))
+	finding.Recommendation = "Log in with unprivileged roles and SET ROLE to a NOLOGIN superuser role when needed"
+	return finding
+}
+
+// checkPasswordExpiry lists login roles whose password never expires
+func checkPasswordExpiry(ctx context.Context, db *sql.DB) models.SecurityFinding {
+	finding := models.SecurityFinding{Check: "password_expiry", Title: "Password expiry", Weight: 10}
+	names, err := queryStrings(ctx, db, `
+		SELECT rolname FROM pg_roles
+		WHERE rolcanlogin AND oid <> 10 AND (rolvaliduntil IS NULL OR rolvaliduntil = 'infinity')
+		ORDER BY rolname
+	`)
+	if err != nil {
+		return skippedFinding(finding, err)
+	}
+
+	finding.Details = names
+	if len(names) == 0 {
+		finding.Status = models.SecurityCheckPass
+		finding.Message = "Every login role has a password expiry"
+		return finding
+	}
+	finding.Status = models.SecurityCheckWarn
+	finding.Message = fmt.Sprintf("%d login roles have no password expiry (VALID UNTIL)", len(names))
+	finding.Recommendation = "Set VALID UNTIL on login roles and rotate their passwords, or authenticate them with certificates or SSO"
+	return finding
+}
+
+// checkPublicSchemaGrants looks for CREATE on the public schema and table privileges granted to PUBLIC
+func checkPublicSchemaGrants(ctx context.Context, db *sql.DB) models.SecurityFinding {
+	finding := models.SecurityFinding{Check: "public_grants", Title: "Grants to PUBLIC", Weight: 15}
+
+	var publicCreate bool
+	err := db.QueryRowContext(ctx, `
+		SELECT COALESCE((SELECT has_schema_privilege('public', oid, 'CREATE') FROM pg_namespace WHERE nspname = 'public'), false)
+	`).Scan(&publicCreate)
+	if err != nil {
+		return skippedFinding(finding, err)
+	}
+	tableGrants, err := queryStrings(ctx, db, fmt.Sprintf(`
+		SELECT table_schema || '.' || table_name || ': ' || string_agg(privilege_type, ', ' ORDER BY privilege_type)
+		FROM information_schema.table_privileges
+		WHERE grantee = 'PUBLIC' AND table_schema NOT IN ('pg_catalog', 'information_schema')
+		GROUP BY table_schema, table_name
+		ORDER BY 1
+		LIMIT %d
+	`, securityDetailLimit))
+	if err != nil {
+		return skippedFinding(finding, err)
+	}
+
+	finding.Details = tableGrants
+	switch {
+	case publicCreate:
+		finding.Status = models.SecurityCheckFail
+		finding.Message = "Every role can create objects in the public schema"
+		finding.Recommendation = "REVOKE CREATE ON SCHEMA public FROM PUBLIC"
+		if len(tableGrants) > 0 {
+			finding.Message += fmt.Sprintf(", and %d tables grant privileges to PUBLIC", len(tableGrants))
+		}
+	case len(tableGrants) > 0:
+		finding.Status = models.SecurityCheckWarn
+		finding.Message = fmt.Sprintf("%d tables grant privileges to PUBLIC", len(tableGrants))
+		finding.Recommendation = "Revoke the table privileges from PUBLIC and grant them to the roles that need them"
+	default:
+		finding.Status = models.SecurityCheckPass
+		finding.Message = "PUBLIC cannot create in the public schema and holds no table privileges"
+	}
+	return finding
+}
+
+// checkHBATrust looks for pg_hba.conf lines that accept connections without a password (trust) or
+// with a clear-text one (password); reading pg_hba_file_rules takes superuser by default
+func checkHBATrust(ctx context.Context, db *sql.DB) models.SecurityFinding {
+	finding := models.SecurityFinding{Check: "hba_trust", Title: "pg_hba.conf authentication", Weight: 20}
+	rows, err := db.QueryContext(ctx, `
+		SELECT line_number, type, array_to_string(database, ','), array_to_string(user_name, ','), COALESCE(address, ''), auth_method
+		FROM pg_hba_file_rules
+		WHERE error IS NULL AND auth_method IN ('trust', 'password')
+		ORDER BY line_number
+	`)
+	if err != nil {
+		return skippedFinding(finding, err)
+	}
+	defer rows.Close()
+
+	trust := 0
+	for rows.Next() {
+		var line int
+		var connType, databases, users, address, method string
+		if err := rows.Scan(&line, &connType, &databases, &users, &address, &method); err != nil {
+			return skippedFinding(finding, err)
+		}
+		if method == "trust" {
+			trust++
+		}
+		finding.Details = append(finding.Details, strings.Join(strings.Fields(
+			fmt.Sprintf("line %d: %s %s %s %s %s", line, connType, databases, users, address, method)), " "))
+	}
+	if err := rows.Err(); err != nil {
+		return skippedFinding(finding, err)
+	}
+
+	switch {
+	case trust > 0:
+		finding.Status = models.SecurityCheckFail
+		finding.Message = fmt.Sprintf("%d pg_hba.conf lines accept connections without a password (trust)", trust)
+		finding.Recommendation = "Replace trust with scram-sha-256, cert or peer authentication"
+	case len(finding.Details) > 0:
+		finding.Status = models.SecurityCheckWarn
+		finding.Message = fmt.Sprintf("%d pg_hba.conf lines send passwords in clear text (password)", len(finding.Details))
+		finding.Recommendation = "Use scram-sha-256 instead of password authentication"
+	default:
+		finding.Status = models.SecurityCheckPass
+		finding.Message = "No pg_hba.conf line uses trust or password authentication"
+	}
+	return finding
+}
+
+// checkSSL checks that the server accepts SSL and that remote client sessions use it
+func checkSSL(ctx context.Context, db *sql.DB) models.SecurityFinding {
+	finding := models.SecurityFinding{Check: "ssl", Title: "SSL usage", Weight: 15}
+	var sslEnabled string
+	var plain, remote int
+	err := db.QueryRowContext(ctx, `
+		SELECT current_setting('ssl'),
+			count(*) FILTER (WHERE NOT s.ssl),
+			count(*)
+		FROM pg_stat_activity a
+		JOIN pg_stat_ssl s ON s.pid = a.pid
+		WHERE a.client_addr IS NOT NULL AND a.backend_type = 'client backend'
+	`).Scan(&sslEnabled, &plain, &remote)
+	if err != nil {
+		return skippedFinding(finding, err)
+	}
+
+	switch {
+	case sslEnabled != "on":
+		finding.Status = models.SecurityCheckFail
+		finding.Message = "SSL is disabled; passwords and data travel unencrypted"
+		finding.Recommendation = "Set ssl = on with a certificate and require hostssl in pg_hba.conf"
+	case plain > 0:
+		finding.Status = models.SecurityCheckWarn
+		finding.Message = fmt.Sprintf("%d of %d remote sessions do not use SSL", plain, remote)
+		finding.Recommendation = "Use hostssl instead of host lines in pg_hba.conf so remote clients must use SSL"
+	default:
+		finding.Status = models.SecurityCheckPass
+		finding.Message = fmt.Sprintf("SSL is enabled and all %d remote sessions use it", remote)
+	}
+	return finding
+}
+
+// checkServerVersion checks that the major version still receives security fixes
+func checkServerVersion(version string, versionNum int) models.SecurityFinding {
+	finding := models.SecurityFinding{Check: "server_version", Title: "Server version", Weight: 15}
+	major := versionNum / 10000
+	if major >= postgresOldestSupportedMajor {
+		finding.Status = models.SecurityCheckPass
+		finding.Message = fmt.Sprintf("PostgreSQL %s is a supported major version", version)
+		finding.Recommendation = "Keep applying minor releases, which carry the security fixes"
+		return finding
+	}
+	finding.Status = models.SecurityCheckFail
+	finding.Message = fmt.Sprintf("PostgreSQL %s no longer receives security fixes", version)
+	finding.Recommendation = fmt.Sprintf("Upgrade to PostgreSQL %d or later", postgresOldestSupportedMajor)
+	return finding
+}
+
+// skippedFinding marks a check that could not be run
+func skippedFinding(finding models.SecurityFinding, err error) models.SecurityFinding {
+	finding.Status = models.SecurityCheckSkipped
+	finding.Message = fmt.Sprintf("Could not be checked: %v", err)
+	finding.Details = nil
+	return finding
+}
+
+// scoreSecurityFindings scores the checks that were run out of 100: passes earn their weight,
+// warnings half of it. Grades go from A (90 and up) to F (below 60).
+func scoreSecurityFindings(findings []models.SecurityFinding) (int, string) {
+	earned, total := 0.0, 0
+	for _, finding := range findings {
+		switch finding.Status {
+		case models.SecurityCheckPass:
+			earned += float64(finding.Weight)
+		case models.SecurityCheckWarn:
+			earned += float64(finding.Weight) / 2
+		case models.SecurityCheckSkipped:
+			continue
+		}
+		total += finding.Weight
+	}
+	if total == 0 {
+		return 0, "F"
+	}
+
+	score := int(math.Round(earned / float64(total) * 100))
+	switch {
+	case score >= 90:
+		return score, "A"
+	case score >= 80:
+		return score, "B"
+	case score >= 70:
+		return score, "C"
+	case score >= 60:
+		return score, "D"
+	default:
+		return score, "F"
+	}
+}
+
+// queryStrings runs a query returning one text column and collects its values
+func queryStrings(ctx context.Context, db *sql.DB, query string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/lib/pq"
+	"truadmin/internal/models"
+)
+
+// maxOrphanedObjectsListed caps the orphaned large objects returned in a report
+const maxOrphanedObjectsListed = 1000
+
+// GetLargeObjectReport reports pg_largeobject usage, orphaned large objects and TOAST-heavy tables
+func (s *DatabaseService) GetLargeObjectReport(ctx context.Context, connectionID, dbName string, toastLimit int) (*models.LargeObjectReport, error) {
+	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.LargeObjectReport{
+		DatabaseName:     dbName,
+		ReferenceColumns: []string{},
+		OrphanedObjects:  []models.LargeObjectInfo{},
+		ToastHeavyTables: []models.ToastUsage{},
+	}
+
+	err = db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM pg_largeobject_metadata),
+			pg_total_relation_size('pg_catalog.pg_largeobject')
+	`).Scan(&report.LargeObjectCount, &report.LargeObjectBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get large object usage: %w", err)
+	}
+
+	referenceQuery, columns, err := buildLargeObjectReferenceQuery(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	report.ReferenceColumns = columns
+
+	orphanFilter := "TRUE"
+	if referenceQuery != "" {
+		orphanFilter = fmt.Sprintf("m.oid NOT IN (%s)", referenceQuery)
+	}
+
+	err = db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*) FROM pg_largeobject_metadata m WHERE %s
+	`, orphanFilter)).Scan(&report.OrphanedCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count orphaned large objects: %w", err)
+	}
+
+	if report.OrphanedCount > 0 {
+		rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+			SELECT m.oid::bigint, pg_get_userbyid(m.lomowner)
+			FROM pg_largeobject_metadata m
+			WHERE %s
+			ORDER BY m.oid
+			LIMIT %d
+		`, orphanFilter, maxOrphanedObjectsListed))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get orphaned large objects: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var lo models.LargeObjectInfo
+			if err := rows.Scan(&lo.OID, &lo.Owner); err != nil {
+				return nil, fmt.Errorf("failed to scan large object: %w", err)
+			}
+			report.OrphanedObjects = append(report.OrphanedObjects, lo)
+		}
+	}
+
+	toastRows, err := db.QueryContext(ctx, `
+		SELECT
+			n.nspname,
+			c.relname,
+			pg_relation_size(c.oid) AS table_bytes,
+			pg_total_relation_size(c.reltoastrelid) AS toast_bytes
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.reltoastrelid <> 0
+		  AND c.relkind IN ('r', 'm')
+		  AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY pg_total_relation_size(c.reltoastrelid) DESC
+		LIMIT $1
+	`, toastLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TOAST usage: %w", err)
+	}
+	defer toastRows.Close()
+
+	for toastRows.Next() {
+		var usage models.ToastUsage
+		if err := toastRows.Scan(&usage.SchemaName, &usage.TableName, &usage.TableBytes, &usage.ToastBytes); err != nil {
+			return nil, fmt.Errorf("failed to scan TOAST usage: %w", err)
+		}
+		if usage.TableBytes > 0 {
+			usage.ToastRatio = float64(usage.ToastBytes) / float64(usage.TableBytes)
+		}
+		report.ToastHeavyTables = append(report.ToastHeavyTables, usage)
+	}
+
+	return report, nil
+}
+
+// CleanupOrphanedLargeObjects unlinks large objects not referenced by any oid/lo column,
+// the same heuristic vacuumlo uses. The cleanup only runs when the caller confirms the current orphan count.
+func (s *DatabaseService) CleanupOrphanedLargeObjects(ctx context.Context, connectionID, dbName string, req *models.LargeObjectCleanupRequest) (*models.LargeObjectCleanupResult, error) {
+	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	referenceQuery, _, err := buildLargeObjectReferenceQuery(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	orphanFilter := "TRUE"
+	if referenceQuery != "" {
+		orphanFilter = fmt.Sprintf("m.oid NOT IN (%s)", referenceQuery)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &models.LargeObjectCleanupResult{DryRun: req.DryRun}
+
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*) FROM pg_largeobject_metadata m WHERE %s
+	`, orphanFilter)).Scan(&result.Orphaned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count orphaned large objects: %w", err)
+	}
+
+	if result.Orphaned != req.ExpectedCount {
+		return nil, fmt.Errorf("orphaned large object count changed (expected %d, found %d), refresh the report and retry",
+			req.ExpectedCount, result.Orphaned)
+	}
+
+	if req.DryRun || result.Orphaned == 0 {
+		return result, nil
+	}
+
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(lo_unlink(m.oid)) FROM pg_largeobject_metadata m WHERE %s
+	`, orphanFilter)).Scan(&result.Unlinked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlink large objects: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// buildLargeObjectReferenceQuery builds a UNION query selecting every value stored in oid/lo columns
+// of user tables. It returns an empty query when no such columns exist.
+func buildLargeObjectReferenceQuery(ctx context.Context, db *sql.DB) (string, []string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT n.nspname, c.relname, a.attname
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_type t ON t.oid = a.atttypid
+		WHERE a.attnum > 0
+		  AND NOT a.attisdropped
+		  AND c.relkind IN ('r', 'm')
+		  AND t.typname IN ('oid', 'lo')
+		  AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		  AND n.nspname NOT LIKE 'pg_toast%'
+		ORDER BY n.nspname, c.relname, a.attname
+	`)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to find large object columns: %w", err)
+	}
+	defer rows.Close()
+
+	columns := []string{}
+	selects := []string{}
+	for rows.Next() {
+		var schemaName, tableName, columnName string
+		if err := rows.Scan(&schemaName, &tableName, &columnName); err != nil {
+			return "", nil, fmt.Errorf("failed to scan large object column: %w", err)
+		}
+		columns = append(columns, fmt.Sprintf("%s.%s.%s", schemaName, tableName, columnName))
+		selects = append(selects, fmt.Sprintf("SELECT %s::oid FROM %s.%s WHERE %s IS NOT NULL",
+			pq.QuoteIdentifier(columnName), pq.QuoteIdentifier(schemaName), pq.QuoteIdentifier(tableName), pq.QuoteIdentifier(columnName)))
+	}
+	if err := rows.Err(); err != nil {
+		return "", nil, fmt.Errorf("failed to find large object columns: %w", err)
+	}
+
+	return strings.Join(selects, " UNION "), columns, nil
+}
+
+// GetDatabaseStats reports the size, dead tuples, estimated bloat, scan and cache statistics of a
+// database from pg_stat_user_tables and the pg_statio views, with the limit largest tables and indexes
+func (s *DatabaseService) GetDatabaseStats(ctx context.Context, connectionID, dbName string, limit int) (*models.DatabaseSizeStats, error) {
+	db, err := s.connectToSpecificDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.DatabaseSizeStats{
+		DatabaseName: dbName,
+		Tables:       []models.TableSizeStats{},
+		Indexes:      []models.IndexSizeStats{},
+	}
+
+	var heapHit, heapRead, idxHit, idxRead int64
+	err = db.QueryRowContext(ctx, `
+		SELECT
+			pg_database_size(current_database()),
+			COALESCE(SUM(pg_relation_size(s.relid)), 0)::bigint,
+			COALESCE(SUM(pg_indexes_size(s.relid)), 0)::bigint,
+			COALESCE(SUM(s.n_live_tup), 0)::bigint,
+			COALESCE(SUM(s.n_dead_tup), 0)::bigint,
+			COALESCE(SUM(s.seq_scan), 0)::bigint,
+			COALESCE(SUM(s.idx_scan), 0)::bigint,
+			COALESCE(SUM(io.heap_blks_hit), 0)::bigint,
+			COALESCE(SUM(io.heap_blks_read), 0)::bigint,
+			COALESCE(SUM(io.idx_blks_hit), 0)::bigint,
+			COALESCE(SUM(io.idx_blks_read), 0)::bigint
+		FROM pg_stat_user_tables s
+		LEFT JOIN pg_statio_user_tables io ON io.relid = s.relid
+	`).Scan(&stats.DatabaseBytes, &stats.TableBytes, &stats.IndexBytes, &stats.LiveTuples, &stats.DeadTuples,
+		&stats.SeqScans, &stats.IndexScans, &heapHit, &heapRead, &idxHit, &idxRead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database statistics: %w", err)
+	}
+	stats.DeadTupleRatio = statsRatio(stats.DeadTuples, stats.LiveTuples+stats.DeadTuples)
+	stats.IndexScanRatio = statsRatio(stats.IndexScans, stats.SeqScans+stats.IndexScans)
+	stats.CacheHitRatio = statsRatio(heapHit, heapHit+heapRead)
+	stats.IndexCacheHitRatio = statsRatio(idxHit, idxHit+idxRead)
+
+	// The row width of analyzed tables comes from pg_stats; 24 is the tuple header size
+	tableRows, err := db.QueryContext(ctx, `
+		WITH widths AS (
+			SELECT schemaname, tablename, SUM((1 - null_frac) * avg_width) AS row_width
+			FROM pg_stats
+			GROUP BY schemaname, tablename
+		)
+		SELECT
+			s.schemaname,
+			s.relname,
+			pg_relation_size(s.relid),
+			pg_indexes_size(s.relid),
+			pg_total_relation_size(s.relid),
+			s.n_live_tup,
+			s.n_dead_tup,
+			COALESCE(s.seq_scan, 0),
+			COALESCE(s.idx_scan, 0),
+			COALESCE(io.heap_blks_hit, 0),
+			COALESCE(io.heap_blks_read, 0),
+			c.reltuples::bigint,
+			w.row_width,
+			COALESCE((SELECT option_value::int FROM pg_options_to_table(c.reloptions) WHERE option_name = 'fillfactor'), 100),
+			current_setting('block_size')::bigint,
+			s.last_vacuum,
+			s.last_autovacuum
+		FROM pg_stat_user_tables s
+		JOIN pg_class c ON c.oid = s.relid
+		LEFT JOIN pg_statio_user_tables io ON io.relid = s.relid
+		LEFT JOIN widths w ON w.schemaname = s.schemaname AND w.tablename = s.relname
+		ORDER BY pg_total_relation_size(s.relid) DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table statistics: %w", err)
+	}
+	defer tableRows.Close()
+
+	for tableRows.Next() {
+		var t models.TableSizeStats
+		var blksHit, blksRead, reltuples, fillfactor, blockSize int64
+		var rowWidth sql.NullFloat64
+		if err := tableRows.Scan(&t.SchemaName, &t.TableName, &t.TableBytes, &t.IndexBytes, &t.TotalBytes,
+			&t.LiveTuples, &t.DeadTuples, &t.SeqScans, &t.IndexScans, &blksHit, &blksRead,
+			&reltuples, &rowWidth, &fillfactor, &blockSize, &t.LastVacuum, &t.LastAutovacuum); err != nil {
+			return nil, fmt.Errorf("failed to scan table statistics: %w", err)
+		}
+		t.DeadTupleRatio = statsRatio(t.DeadTuples, t.LiveTuples+t.DeadTuples)
+		t.IndexScanRatio = statsRatio(t.IndexScans, t.SeqScans+t.IndexScans)
+		t.CacheHitRatio = statsRatio(blksHit, blksHit+blksRead)
+		if rowWidth.Valid && reltuples >= 0 {
+			bloat := estimateHeapBloat(t.TableBytes, reltuples, rowWidth.Float64, fillfactor, blockSize)
+			t.EstimatedBloatBytes = &bloat
+			t.BloatRatio = statsRatio(bloat, t.TableBytes)
+			stats.EstimatedBloatBytes += bloat
+		}
+		stats.Tables = append(stats.Tables, t)
+	}
+	if err := tableRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating table statistics: %w", err)
+	}
+
+	indexRows, err := db.QueryContext(ctx, `
+		SELECT
+			s.schemaname,
+			s.relname,
+			s.indexrelname,
+			pg_relation_size(s.indexrelid),
+			COALESCE(s.idx_scan, 0),
+			COALESCE(io.idx_blks_hit, 0),
+			COALESCE(io.idx_blks_read, 0)
+		FROM pg_stat_user_indexes s
+		LEFT JOIN pg_statio_user_indexes io ON io.indexrelid = s.indexrelid
+		ORDER BY pg_relation_size(s.indexrelid) DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index statistics: %w", err)
+	}
+	defer indexRows.Close()
+
+	for indexRows.Next() {
+		var idx models.IndexSizeStats
+		var blksHit, blksRead int64
+		if err := indexRows.Scan(&idx.SchemaName, &idx.TableName, &idx.IndexName, &idx.IndexBytes, &idx.Scans, &blksHit, &blksRead); err != nil {
+			return nil, fmt.Errorf("failed to scan index statistics: %w", err)
+		}
+		idx.CacheHitRatio = statsRatio(blksHit, blksHit+blksRead)
+		stats.Indexes = append(stats.Indexes, idx)
+	}
+	if err := indexRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating index statistics: %w", err)
+	}
+
+	return stats, nil
+}
+
+// estimateHeapBloat estimates the bytes of a heap beyond what its live rows need when packed at
+// the table's fillfactor. Rows take their average width plus the tuple header and line pointer;
+// pages lose their header. It is an estimate in the spirit of the check_postgres bloat check.
+func estimateHeapBloat(tableBytes, liveRows int64, rowWidth float64, fillfactor, blockSize int64) int64 {
+	if blockSize <= 0 {
+		return 0
+	}
+	const tupleOverhead, pageHeader = 24 + 4, 24
+
+	usable := float64(blockSize*fillfactor/100 - pageHeader)
+	rowsPerPage := math.Floor(usable / (rowWidth + tupleOverhead))
+	if rowsPerPage < 1 {
+		rowsPerPage = 1
+	}
+	expected := int64(math.Ceil(float64(liveRows)/rowsPerPage)) * blockSize
+	if expected >= tableBytes {
+		return 0
+	}
+	return tableBytes - expected
+}
+
+// statsRatio returns part/total, or nil when total is zero
+func statsRatio(part, total int64) *float64 {
+	if total <= 0 {
+		return nil
+	}
+	ratio := float64(part) / float64(total)
+	return &ratio
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"truadmin/internal/models"
+)
+
+func TestGetDatabases(t *testing.T) {
+	svc, conn, mock := newTestDatabaseService(t)
+
+	mock.ExpectQuery("FROM pg_database").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "size"}).
+			AddRow("app", int64(8192)).
+			AddRow("reports", int64(4096)))
+
+	databases, err := svc.GetDatabases(context.Background(), conn.ID)
+	if err != nil {
+		t.Fatalf("GetDatabases() error = %v", err)
+	}
+	if len(databases) != 2 || databases[0].Name != "app" || *databases[1].Size != 4096 {
+		t.Errorf("GetDatabases() = %+v", databases)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetDatabasesUnknownConnection(t *testing.T) {
+	svc, _, _ := newTestDatabaseService(t)
+
+	if _, err := svc.GetDatabases(context.Background(), "missing"); err == nil {
+		t.Fatal("GetDatabases() on an unknown connection returned no error")
+	}
+}
+
+func TestGrantPrivileges(t *testing.T) {
+	svc, conn, mock := newTestDatabaseService(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT rolname FROM pg_roles WHERE oid = $1::oid")).
+		WithArgs("16384").
+		WillReturnRows(sqlmock.NewRows([]string{"rolname"}).AddRow("reporting"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT current_database()")).
+		WillReturnRows(sqlmock.NewRows([]string{"current_database"}).AddRow("app"))
+	mock.ExpectQuery("FROM information_schema.schemata").
+		WithArgs("app").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "owner"}).AddRow("public", "admin").AddRow("Sales", "admin"))
+	mock.ExpectExec(regexp.QuoteMeta(`GRANT USAGE ON SCHEMA "Sales" TO "reporting"`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	req := &models.GrantRequest{ObjectType: "schema", ObjectName: "Sales", Privileges: []string{"USAGE"}}
+	if err := svc.GrantPrivileges(context.Background(), conn.ID, "16384", req); err != nil {
+		t.Fatalf("GrantPrivileges() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRevokePrivilegesUnknownSchema(t *testing.T) {
+	svc, conn, mock := newTestDatabaseService(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT rolname FROM pg_roles WHERE oid = $1::oid")).
+		WithArgs("16384").
+		WillReturnRows(sqlmock.NewRows([]string{"rolname"}).AddRow("reporting"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT current_database()")).
+		WillReturnRows(sqlmock.NewRows([]string{"current_database"}).AddRow("app"))
+	mock.ExpectQuery("FROM information_schema.schemata").
+		WithArgs("app").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "owner"}).AddRow("public", "admin"))
+
+	req := &models.GrantRequest{ObjectType: "schema", ObjectName: "missing", Privileges: []string{"USAGE"}}
+	err := svc.RevokePrivileges(context.Background(), conn.ID, "16384", req)
+	if !errors.Is(err, ErrUnknownIdentifier) {
+		t.Fatalf("RevokePrivileges() error = %v, want %v", err, ErrUnknownIdentifier)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetSchemasUsesRequestedDatabase(t *testing.T) {
+	svc, conn, mock := newTestDatabaseService(t)
+
+	mock.ExpectQuery("FROM information_schema.schemata").
+		WithArgs("reports").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "owner"}).AddRow("public", "admin"))
+
+	schemas, err := svc.GetSchemas(context.Background(), conn.ID, "reports")
+	if err != nil {
+		t.Fatalf("GetSchemas() error = %v", err)
+	}
+	if len(schemas) != 1 || schemas[0].Name != "public" || schemas[0].Database != "reports" {
+		t.Errorf("GetSchemas() = %+v", schemas)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
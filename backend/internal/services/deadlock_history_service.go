@@ -0,0 +1,456 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+const (
+	// deadlockLogChunk is how much of a server log is read per query
+	deadlockLogChunk = 4 << 20
+	// deadlockLogMaxRead bounds how much of a server log one collection run reads, so a large
+	// backlog on the first run is worked off over several runs
+	deadlockLogMaxRead = 64 << 20
+	// deadlockSQLState is the SQLSTATE of "deadlock detected"
+	deadlockSQLState = "40P01"
+)
+
+var (
+	deadlockWaitLine  = regexp.MustCompile(`^Process (\d+) waits for (.+?); blocked by process (\d+)\.$`)
+	deadlockQueryLine = regexp.MustCompile(`^Process (\d+): (.*)$`)
+	logPrefixTime     = regexp.MustCompile(`\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(\.\d+)?( [A-Z]{2,5}| ?[+-]\d{2}(:?\d{2})?)?`)
+	logPrefixPID      = regexp.MustCompile(`\[(\d+)\]`)
+	logPrefixDatabase = regexp.MustCompile(`(?:db|database)=([^\s,]+)|[\w.-]+@([\w.-]+)`)
+)
+
+// logTimeLayouts are the timestamp formats of %t/%m in log_line_prefix and of csvlog
+var logTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999 MST",
+	"2006-01-02 15:04:05.999999999 -07",
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02 15:04:05.999999999 -07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// DeadlockHistoryService collects the deadlock reports PostgreSQL writes to its server log.
+// pg_stat_activity only shows current blocking; the log is the only record of deadlocks that
+// already happened. Logs are read through pg_read_binary_file, so the connection's user needs
+// pg_read_server_files (or superuser) and the server needs logging_collector enabled.
+type DeadlockHistoryService struct {
+	db                *gorm.DB
+	connectionService *ConnectionService
+	databaseService   *DatabaseService
+	monitored         *MonitoredDatabaseService
+	retention         time.Duration
+	logger            *slog.Logger
+}
+
+// NewDeadlockHistoryService creates a new deadlock history service; events older than retention are removed by Prune
+func NewDeadlockHistoryService(connectionService *ConnectionService, databaseService *DatabaseService, monitored *MonitoredDatabaseService, retention time.Duration, logger *slog.Logger) *DeadlockHistoryService {
+	return &DeadlockHistoryService{
+		db:                database.GetDB(),
+		connectionService: connectionService,
+		databaseService:   databaseService,
+		monitored:         monitored,
+		retention:         retention,
+		logger:            logging.OrDefault(logger),
+	}
+}
+
+// CollectDeadlocks reads new deadlock reports from the server log of every PostgreSQL connection
+// with a monitored database that collects deadlocks. The log covers the whole server, so the events
+// of all its databases are recorded. It is registered as the deadlock_collection job type.
+func (s *DeadlockHistoryService) CollectDeadlocks() error {
+	connections, err := s.connectionService.GetAllConnections()
+	if err != nil {
+		return err
+	}
+
+	targets, err := s.monitored.dueTargets(models.MonitoringCollectorDeadlocks, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	for _, conn := range connections {
+		if conn.Type != "postgres" || !targets.includesConnection(conn.ID) {
+			continue
+		}
+		s.recordCollectorError(conn, s.collectConnection(conn.ID))
+	}
+	return nil
+}
+
+// GetHistory returns the deadlocks of a database within a time range, newest first. Events whose
+// database could not be told from the log line prefix are included for every database.
+func (s *DeadlockHistoryService) GetHistory(connectionID, dbName string, from, to time.Time, page Page) ([]models.DeadlockEvent, int64, error) {
+	var events []models.DeadlockEvent
+
+	query := s.db.Where("connection_id = ? AND (database_name = ? OR database_name = '')", connectionID, dbName).
+		Where("detected_at >= ? AND detected_at < ?", from, to).
+		Order("detected_at DESC")
+
+	total, err := findPage(query, page, &events)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get deadlock history: %w", err)
+	}
+	return models.NonNil(events), total, nil
+}
+
+// GetCollectorStatus returns how far the server log of a connection has been read and the last
+// collection error, or nil when the connection has not been collected yet
+func (s *DeadlockHistoryService) GetCollectorStatus(connectionID string) (*models.DeadlockLogCursor, error) {
+	var cursor models.DeadlockLogCursor
+	if err := s.db.First(&cursor, "connection_id = ?", connectionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get deadlock collector status: %w", err)
+	}
+	return &cursor, nil
+}
+
+// Prune removes deadlock events older than the retention period; it is registered as the deadlock_pruning job type
+func (s *DeadlockHistoryService) Prune() error {
+	if s.retention <= 0 {
+		return nil
+	}
+	if err := s.db.Where("detected_at < ?", time.Now().Add(-s.retention)).Delete(&models.DeadlockEvent{}).Error; err != nil {
+		return fmt.Errorf("failed to prune deadlock events: %w", err)
+	}
+	return nil
+}
+
+// collectConnection reads the part of the server log written since the last run
+func (s *DeadlockHistoryService) collectConnection(connectionID string) error {
+	db, err := s.databaseService.connectToDatabase(connectionID)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := s.GetCollectorStatus(connectionID)
+	if err != nil {
+		return err
+	}
+	if cursor == nil {
+		cursor = &models.DeadlockLogCursor{ConnectionID: connectionID}
+	}
+
+	logFile, err := currentServerLog(db)
+	if err != nil {
+		return err
+	}
+
+	budget := int64(deadlockLogMaxRead)
+	if cursor.LogFile != "" && cursor.LogFile != logFile {
+		// The log was rotated; finish the previous file before moving on
+		if _, err := s.readLog(db, connectionID, cursor.LogFile, cursor.Offset, &budget); err != nil {
+			s.logger.Warn("failed to read the rest of server log", "log_file", cursor.LogFile, "connection_id", connectionID, "error", err)
+		}
+		cursor.Offset = 0
+	}
+
+	offset, err := s.readLog(db, connectionID, logFile, cursor.Offset, &budget)
+	if err != nil {
+		return err
+	}
+
+	cursor.LogFile = logFile
+	cursor.Offset = offset
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "connection_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"log_file", "log_offset", "updated_at"}),
+	}).Create(cursor).Error; err != nil {
+		return fmt.Errorf("failed to save deadlock log position: %w", err)
+	}
+	return nil
+}
+
+// readLog stores the deadlocks reported in a log file from offset on and returns the offset
+// after the last complete log entry
+func (s *DeadlockHistoryService) readLog(db *sql.DB, connectionID, logFile string, offset int64, budget *int64) (int64, error) {
+	var size sql.NullInt64
+	if err := db.QueryRow(`SELECT size FROM pg_stat_file($1, true)`, logFile).Scan(&size); err != nil {
+		return offset, fmt.Errorf("failed to read server log: %w", err)
+	}
+	if !size.Valid {
+		return 0, nil // Removed by log rotation
+	}
+	if size.Int64 < offset {
+		offset = 0 // Truncated by log_truncate_on_rotation
+	}
+
+	csvLog := strings.HasSuffix(logFile, ".csv")
+	for offset < size.Int64 && *budget > 0 {
+		length := size.Int64 - offset
+		if length > deadlockLogChunk {
+			length = deadlockLogChunk
+		}
+
+		var data []byte
+		if err := db.QueryRow(`SELECT pg_read_binary_file($1, $2, $3, true)`, logFile, offset, length).Scan(&data); err != nil {
+			return offset, fmt.Errorf("failed to read server log: %w", err)
+		}
+		if len(data) == 0 {
+			break
+		}
+		*budget -= int64(len(data))
+
+		var events []models.DeadlockEvent
+		var consumed int
+		if csvLog {
+			events, consumed = parseDeadlocksCSV(data)
+		} else {
+			events, consumed = parseDeadlocksStderr(data)
+		}
+		if err := s.storeEvents(connectionID, events); err != nil {
+			return offset, err
+		}
+
+		if consumed == 0 {
+			if len(data) < deadlockLogChunk {
+				break // The last entry is still being written
+			}
+			consumed = len(data) // An entry larger than a chunk cannot be parsed; skip it
+		}
+		offset += int64(consumed)
+	}
+
+	return offset, nil
+}
+
+// storeEvents stores deadlock events, skipping those already collected
+func (s *DeadlockHistoryService) storeEvents(connectionID string, events []models.DeadlockEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	for i := range events {
+		events[i].ConnectionID = connectionID
+		hash := sha256.Sum256([]byte(events[i].DetectedAt.UTC().Format(time.RFC3339Nano) + "\x00" + strconv.Itoa(events[i].PID) + "\x00" + events[i].Detail))
+		events[i].Fingerprint = hex.EncodeToString(hash[:])
+	}
+
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&events).Error; err != nil {
+		return fmt.Errorf("failed to store deadlock events: %w", err)
+	}
+	s.logger.Info("collected deadlocks from server log", "count", len(events), "connection_id", connectionID)
+	return nil
+}
+
+// recordCollectorError stores the outcome of a collection run and logs it when it changed,
+// so a server without a readable log does not warn on every run
+func (s *DeadlockHistoryService) recordCollectorError(conn *models.Connection, err error) {
+	message := ""
+	if err != nil {
+		message = err.Error()
+	}
+
+	var previous string
+	s.db.Model(&models.DeadlockLogCursor{}).Where("connection_id = ?", conn.ID).Pluck("last_error", &previous)
+	if previous == message {
+		return
+	}
+
+	if err != nil {
+		s.logger.Warn("failed to collect deadlocks", "connection", conn.Name, "error", err)
+	} else {
+		s.logger.Info("collecting deadlocks again", "connection", conn.Name)
+	}
+
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "connection_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_error", "updated_at"}),
+	}).Create(&models.DeadlockLogCursor{ConnectionID: conn.ID, LastError: message}).Error; err != nil {
+		s.logger.Warn("failed to record deadlock collector status", "connection", conn.Name, "error", err)
+	}
+}
+
+// currentServerLog returns the path of the log file the server writes to, preferring csvlog
+func currentServerLog(db *sql.DB) (string, error) {
+	var csvFile, anyFile sql.NullString
+	if err := db.QueryRow(`SELECT pg_current_logfile('csvlog'), pg_current_logfile()`).Scan(&csvFile, &anyFile); err != nil {
+		return "", fmt.Errorf("failed to locate server log: %w", err)
+	}
+
+	switch {
+	case csvFile.Valid:
+		return csvFile.String, nil
+	case !anyFile.Valid:
+		return "", fmt.Errorf("server log is not available: logging_collector must be on")
+	case strings.HasSuffix(anyFile.String, ".json"):
+		return "", fmt.Errorf("jsonlog is not supported: enable the stderr or csvlog destination")
+	}
+	return anyFile.String, nil
+}
+
+// parseDeadlocksCSV returns the deadlocks of a csvlog chunk and the length of its complete records
+func parseDeadlocksCSV(data []byte) ([]models.DeadlockEvent, int) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	events := []models.DeadlockEvent{}
+	consumed := 0
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			break // End of the chunk, possibly inside a record that is cut off
+		}
+		end := int(reader.InputOffset())
+		if data[end-1] != '\n' {
+			break // The last record is still being written
+		}
+		consumed = end
+
+		// log_time, user_name, database_name, process_id, ... error_severity, sql_state_code, message, detail, ... query
+		if len(record) < 20 || record[12] != deadlockSQLState {
+			continue
+		}
+		pid, _ := strconv.Atoi(record[3])
+		events = append(events, models.DeadlockEvent{
+			DatabaseName: record[2],
+			DetectedAt:   parseLogTime(record[0]),
+			PID:          pid,
+			Processes:    parseDeadlockDetail(record[14]),
+			Statement:    record[19],
+			Detail:       record[14],
+		})
+	}
+	return events, consumed
+}
+
+// logMessage is one message of a stderr log: a prefixed line and its tab-indented continuation lines
+type logMessage struct {
+	start int
+	text  string
+}
+
+// parseDeadlocksStderr returns the deadlocks of a stderr log chunk and the length of its complete
+// entries. A report is the "deadlock detected" ERROR line followed by DETAIL, HINT, CONTEXT and
+// STATEMENT lines with the same log line prefix.
+func parseDeadlocksStderr(data []byte) ([]models.DeadlockEvent, int) {
+	end := bytes.LastIndexByte(data, '\n') + 1
+	messages := []logMessage{}
+	for pos := 0; pos < end; {
+		next := pos + bytes.IndexByte(data[pos:end], '\n') + 1
+		line := strings.TrimRight(string(data[pos:next-1]), "\r")
+		if strings.HasPrefix(line, "\t") && len(messages) > 0 {
+			messages[len(messages)-1].text += "\n" + line[1:]
+		} else {
+			messages = append(messages, logMessage{start: pos, text: line})
+		}
+		pos = next
+	}
+
+	events := []models.DeadlockEvent{}
+	for i := 0; i < len(messages); i++ {
+		idx := strings.Index(messages[i].text, "ERROR:  deadlock detected")
+		if idx < 0 {
+			continue
+		}
+		prefix := messages[i].text[:idx]
+
+		event := models.DeadlockEvent{DetectedAt: parseLogTime(prefix)}
+		if m := logPrefixPID.FindStringSubmatch(prefix); m != nil {
+			event.PID, _ = strconv.Atoi(m[1])
+		}
+		if m := logPrefixDatabase.FindStringSubmatch(prefix); m != nil {
+			event.DatabaseName = m[1] + m[2]
+		}
+
+		complete := false
+		j := i + 1
+		for ; j < len(messages); j++ {
+			rest, ok := strings.CutPrefix(messages[j].text, prefix)
+			if !ok {
+				complete = true
+				break
+			}
+			if detail, ok := strings.CutPrefix(rest, "DETAIL:  "); ok {
+				event.Detail = detail
+			} else if statement, ok := strings.CutPrefix(rest, "STATEMENT:  "); ok {
+				event.Statement = statement
+				complete = true
+				j++
+				break
+			} else if !strings.HasPrefix(rest, "HINT:  ") && !strings.HasPrefix(rest, "CONTEXT:  ") {
+				complete = true
+				break
+			}
+		}
+		if !complete {
+			// The report may continue in data not written yet; read it again next time
+			return events, messages[i].start
+		}
+
+		event.Processes = parseDeadlockDetail(event.Detail)
+		events = append(events, event)
+		i = j - 1
+	}
+	return events, end
+}
+
+// parseDeadlockDetail extracts the processes of a deadlock from the DETAIL of its report:
+// "Process 1 waits for ShareLock on transaction 2; blocked by process 3." lines followed by
+// "Process 1: <query>" lines, where a query can span several lines
+func parseDeadlockDetail(detail string) models.DeadlockProcesses {
+	processes := models.DeadlockProcesses{}
+	index := map[int]int{}
+	process := func(pid int) *models.DeadlockProcess {
+		if i, ok := index[pid]; ok {
+			return &processes[i]
+		}
+		index[pid] = len(processes)
+		processes = append(processes, models.DeadlockProcess{PID: pid})
+		return &processes[len(processes)-1]
+	}
+
+	var current *models.DeadlockProcess
+	for _, line := range strings.Split(detail, "\n") {
+		if m := deadlockWaitLine.FindStringSubmatch(line); m != nil {
+			pid, _ := strconv.Atoi(m[1])
+			blockedBy, _ := strconv.Atoi(m[3])
+			p := process(pid)
+			p.WaitsFor = m[2]
+			p.BlockedBy = blockedBy
+			current = nil
+		} else if m := deadlockQueryLine.FindStringSubmatch(line); m != nil {
+			pid, _ := strconv.Atoi(m[1])
+			current = process(pid)
+			current.Query = m[2]
+		} else if current != nil {
+			current.Query += "\n" + line
+		}
+	}
+	return processes
+}
+
+// parseLogTime finds the timestamp in a log line prefix or csvlog field; abbreviated time zones
+// other than UTC/GMT are read as UTC. The collection time is used when there is none.
+func parseLogTime(text string) time.Time {
+	if match := logPrefixTime.FindString(text); match != "" {
+		for _, layout := range logTimeLayouts {
+			if t, err := time.Parse(layout, match); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Now().UTC()
+}
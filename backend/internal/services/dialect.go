@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// ErrUnsupportedDialect is returned when a feature is not available for the engine of a connection
+var ErrUnsupportedDialect = errors.New("not supported for this connection type")
+
+// dialect opens connections to one database engine and provides its metadata queries.
+// Every query method returns the statement together with its arguments, since engines
+// differ in placeholder syntax and in which arguments they need.
+type dialect interface {
+	// name returns the connection type the dialect serves
+	name() string
+	// open returns a connection pool for a database of the connection (the connection's default when dbName is empty)
+	open(conn *models.Connection, dbName string) (*sql.DB, error)
+
+	// databasesQuery lists databases as (name, size in bytes)
+	databasesQuery() (string, []any)
+	// schemasQuery lists schemas of a database as (name, owner)
+	schemasQuery(dbName string) (string, []any)
+	// tablesQuery, viewsQuery and routinesQuery list objects of a schema as (name, schema[, routine type])
+	tablesQuery(dbName, schemaName string) (string, []any)
+	viewsQuery(dbName, schemaName string) (string, []any)
+	routinesQuery(dbName, schemaName string) (string, []any)
+	// columnsQuery lists columns of a table as (name, data type, character length, numeric precision, numeric scale, nullable)
+	columnsQuery(dbName, schemaName, tableName string) (string, []any)
+	// columnDetailsQuery lists columns of a table as (name, full type, nullable, key as PRI/UNI/MUL or empty, default)
+	columnDetailsQuery(dbName, schemaName, tableName string) (string, []any)
+	// tableStatsQuery lists the tables of all user schemas of a database as (name, schema, estimated rows, size in bytes)
+	tableStatsQuery(dbName string) (string, []any)
+	// defaultSchema returns the schema unqualified table names resolve to
+	defaultSchema(dbName string) string
+	// quoteIdentifier quotes a column or table name for use in generated SQL
+	quoteIdentifier(name string) string
+	// placeholder returns the n-th (1-based) bind parameter of a statement
+	placeholder(n int) string
+	// likeOperator returns the case-insensitive pattern match operator
+	likeOperator() string
+	// castToText converts a column expression to text for pattern matching
+	castToText(expr string) string
+	// insertReturningClause makes an INSERT return the inserted row; empty when the engine cannot
+	insertReturningClause() string
+	// pageClause limits a SELECT to limit rows after skipping offset; it follows the ORDER BY
+	// clause, and ordered tells whether the statement has one
+	pageClause(limit, offset int, ordered bool) string
+	// keysetCondition matches the rows whose quoted columns, compared in order with operator (> or
+	// <), come after the values bound to placeholders
+	keysetCondition(columns []string, operator string, placeholders []string) (string, error)
+	// lockRowClauses returns the table hint and the statement suffix that make a SELECT lock the
+	// rows it reads until the transaction ends
+	lockRowClauses() (tableHint, suffix string, err error)
+
+	// activeQueriesQuery lists sessions of a database with the columns scanned into models.ActiveQuery
+	activeQueriesQuery(dbName string, onlyActive bool) (string, []any)
+	// sessionQuery returns (user, query) of one session
+	sessionQuery(pid int) (string, []any)
+	// terminateSession ends one session and reports whether it was terminated
+	terminateSession(ctx context.Context, db *sql.DB, pid int) (bool, error)
+	// resetSessionStatement clears settings and open transactions of a pooled session;
+	// empty when the engine has none and the connection has to be discarded instead
+	resetSessionStatement() string
+	// statementTimeoutStatement limits how long each statement of the session may run
+	statementTimeoutStatement(timeout time.Duration) string
+}
+
+// limitOffsetClause is the LIMIT ... OFFSET page clause shared by most engines
+func limitOffsetClause(limit, offset int) string {
+	clause := fmt.Sprintf(" LIMIT %d", limit)
+	if offset > 0 {
+		clause += fmt.Sprintf(" OFFSET %d", offset)
+	}
+	return clause
+}
+
+// rowValueComparison compares the columns as one row value, for engines that support it
+func rowValueComparison(columns []string, operator string, placeholders []string) string {
+	return fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), operator, strings.Join(placeholders, ", "))
+}
+
+// expandedKeysetCondition spells a row value comparison out column by column, binding each
+// placeholder more than once
+func expandedKeysetCondition(columns []string, operator string, placeholders []string) string {
+	alternatives := make([]string, len(columns))
+	for i := range columns {
+		terms := []string{}
+		for j := 0; j < i; j++ {
+			terms = append(terms, columns[j]+" = "+placeholders[j])
+		}
+		terms = append(terms, columns[i]+" "+operator+" "+placeholders[i])
+		alternatives[i] = "(" + strings.Join(terms, " AND ") + ")"
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")"
+}
+
+// dialectFor returns the dialect of a connection type
+func dialectFor(connType string) (dialect, error) {
+	switch connType {
+	case "postgres", "":
+		return postgresDialect{}, nil
+	case "mysql", "mariadb":
+		return mysqlDialect{kind: connType}, nil
+	case "mssql":
+		return mssqlDialect{}, nil
+	case "snowflake":
+		return snowflakeDialect{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDialect, connType)
+	}
+}
+
+// dialectForTag resolves the loosely written engine names used outside saved connections
+// (for example the target_db_type of TruETL mappings) to a connection type
+func dialectForTag(tag string) string {
+	switch strings.ToLower(strings.TrimSpace(tag)) {
+	case "postgres", "postgresql", "pg", "pgsql":
+		return "postgres"
+	case "mysql":
+		return "mysql"
+	case "mariadb", "maria":
+		return "mariadb"
+	case "mssql", "sqlserver", "sql server":
+		return "mssql"
+	case "snowflake":
+		return "snowflake"
+	default:
+		return ""
+	}
+}
+
+// openConnection opens and pings a database of a saved connection using the connection's dialect
+func openConnection(conn *models.Connection, dbName string) (*sql.DB, dialect, error) {
+	d, err := dialectFor(conn.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Open database connection
+	db, err := d.open(conn, dbName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Test connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return db, d, nil
+}
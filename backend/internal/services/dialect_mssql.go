@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// openMSSQL opens a connection pool for a SQL Server DSN. Like openPostgres, it is a variable so that
+// service code can be pointed at a mock driver.
+var openMSSQL = func(dsn string) (*sql.DB, error) {
+	return sql.Open(capturedMSSQLDriver, dsn)
+}
+
+// mssqlSystemDatabases are hidden from database listings
+const mssqlSystemDatabases = "'master', 'tempdb', 'model', 'msdb'"
+
+// mssqlDialect serves SQL Server connections through microsoft/go-mssqldb
+type mssqlDialect struct{}
+
+func (mssqlDialect) name() string { return "mssql" }
+
+func (mssqlDialect) open(conn *models.Connection, dbName string) (*sql.DB, error) {
+	if dbName == "" {
+		dbName = conn.Database
+	}
+
+	query := url.Values{}
+	query.Set("database", dbName)
+	query.Set("app name", "truadmin")
+	encrypt, trustCertificate := mssqlEncryptMode(conn.SSLMode)
+	query.Set("encrypt", encrypt)
+	if trustCertificate {
+		query.Set("TrustServerCertificate", "true")
+	}
+	for key, value := range driverParams("mssql", conn) {
+		query.Set(key, value)
+	}
+
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(conn.Username, conn.Password),
+		Host:     net.JoinHostPort(conn.Host, strconv.Itoa(conn.Port)),
+		RawQuery: query.Encode(),
+	}
+	return openMSSQL(dsn.String())
+}
+
+// mssqlEncryptMode maps a PostgreSQL-style sslmode to the encrypt parameter of the SQL Server driver
+// and whether the server certificate is accepted without verification
+func mssqlEncryptMode(sslMode string) (string, bool) {
+	switch sslMode {
+	case "disable":
+		return "disable", false
+	case "allow", "prefer":
+		return "false", true // Only the login is encrypted
+	case "require":
+		return "true", true
+	default:
+		return "true", false
+	}
+}
+
+func (mssqlDialect) databasesQuery() (string, []any) {
+	return fmt.Sprintf(`
+		SELECT
+			d.name,
+			COALESCE(SUM(CAST(f.size AS BIGINT)) * 8192, 0) AS size
+		FROM sys.databases d
+		LEFT JOIN sys.master_files f ON f.database_id = d.database_id
+		WHERE d.name NOT IN (%s)
+		GROUP BY d.name
+		ORDER BY d.name
+	`, mssqlSystemDatabases), nil
+}
+
+func (mssqlDialect) schemasQuery(dbName string) (string, []any) {
+	return `
+		SELECT
+			schema_name AS name,
+			COALESCE(schema_owner, '') AS owner
+		FROM INFORMATION_SCHEMA.SCHEMATA
+		WHERE catalog_name = @p1
+		AND schema_name NOT IN ('sys', 'INFORMATION_SCHEMA', 'guest')
+		AND schema_name NOT LIKE 'db[_]%'
+		ORDER BY schema_name
+	`, []any{dbName}
+}
+
+func (mssqlDialect) tablesQuery(dbName, schemaName string) (string, []any) {
+	return `
+		SELECT
+			table_name,
+			table_schema
+		FROM INFORMATION_SCHEMA.TABLES
+		WHERE table_catalog = @p1
+		AND table_schema = @p2
+		AND table_type = 'BASE TABLE'
+		ORDER BY table_name
+	`, []any{dbName, schemaName}
+}
+
+func (mssqlDialect) viewsQuery(dbName, schemaName string) (string, []any) {
+	return `
+		SELECT
+			table_name,
+			table_schema
+		FROM INFORMATION_SCHEMA.VIEWS
+		WHERE table_catalog = @p1
+		AND table_schema = @p2
+		ORDER BY table_name
+	`, []any{dbName, schemaName}
+}
+
+func (mssqlDialect) routinesQuery(dbName, schemaName string) (string, []any) {
+	return `
+		SELECT
+			routine_name,
+			routine_schema,
+			routine_type
+		FROM INFORMATION_SCHEMA.ROUTINES
+		WHERE routine_catalog = @p1
+		AND routine_schema = @p2
+		ORDER BY routine_name
+	`, []any{dbName, schemaName}
+}
+
+func (mssqlDialect) columnsQuery(dbName, schemaName, tableName string) (string, []any) {
+	return `
+		SELECT
+			column_name,
+			data_type,
+			character_maximum_length,
+			numeric_precision,
+			numeric_scale,
+			CAST(CASE WHEN is_nullable = 'YES' THEN 1 ELSE 0 END AS BIT)
+		FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE table_catalog = @p1
+		AND table_schema = @p2
+		AND table_name = @p3
+		ORDER BY ordinal_position
+	`, []any{dbName, schemaName, tableName}
+}
+
+func (mssqlDialect) columnDetailsQuery(dbName, schemaName, tableName string) (string, []any) {
+	// character_maximum_length is -1 for the (max) types
+	return `
+		SELECT
+			c.column_name,
+			CASE
+				WHEN c.character_maximum_length = -1 THEN c.data_type + '(max)'
+				WHEN c.character_maximum_length IS NOT NULL THEN c.data_type + '(' + CAST(c.character_maximum_length AS VARCHAR(10)) + ')'
+				WHEN c.data_type IN ('decimal', 'numeric') THEN c.data_type + '(' + CAST(c.numeric_precision AS VARCHAR(10)) + ',' + CAST(c.numeric_scale AS VARCHAR(10)) + ')'
+				ELSE c.data_type
+			END AS type,
+			CAST(CASE WHEN c.is_nullable = 'YES' THEN 1 ELSE 0 END AS BIT) AS nullable,
+			COALESCE((
+				SELECT TOP 1 CASE tc.constraint_type WHEN 'PRIMARY KEY' THEN 'PRI' WHEN 'UNIQUE' THEN 'UNI' ELSE 'MUL' END
+				FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE k
+				JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc
+					ON tc.constraint_schema = k.constraint_schema
+					AND tc.constraint_name = k.constraint_name
+				WHERE k.table_schema = c.table_schema
+				AND k.table_name = c.table_name
+				AND k.column_name = c.column_name
+				ORDER BY CASE tc.constraint_type WHEN 'PRIMARY KEY' THEN 0 WHEN 'UNIQUE' THEN 1 ELSE 2 END
+			), '') AS [key],
+			COALESCE(c.column_default, '') AS default_value
+		FROM INFORMATION_SCHEMA.COLUMNS c
+		WHERE c.table_catalog = @p1
+		AND c.table_schema = @p2
+		AND c.table_name = @p3
+		ORDER BY c.ordinal_position
+	`, []any{dbName, schemaName, tableName}
+}
+
+func (mssqlDialect) tableStatsQuery(dbName string) (string, []any) {
+	// Heaps have index 0 and clustered tables index 1; both hold the table rows
+	return `
+		SELECT
+			t.name,
+			s.name,
+			COALESCE(SUM(CASE WHEN p.index_id IN (0, 1) THEN p.row_count ELSE 0 END), 0) AS estimated_rows,
+			COALESCE(SUM(p.reserved_page_count), 0) * 8192 AS size
+		FROM sys.tables t
+		JOIN sys.schemas s ON s.schema_id = t.schema_id
+		LEFT JOIN sys.dm_db_partition_stats p ON p.object_id = t.object_id
+		WHERE DB_NAME() = @p1
+		AND t.is_ms_shipped = 0
+		GROUP BY s.name, t.name
+		ORDER BY s.name, t.name
+	`, []any{dbName}
+}
+
+func (mssqlDialect) defaultSchema(dbName string) string { return "dbo" }
+
+func (mssqlDialect) quoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+func (mssqlDialect) placeholder(n int) string { return fmt.Sprintf("@p%d", n) }
+
+// LIKE is case-insensitive under the default (_CI) collations
+func (mssqlDialect) likeOperator() string { return "LIKE" }
+
+func (mssqlDialect) castToText(expr string) string {
+	return fmt.Sprintf("CAST(%s AS NVARCHAR(MAX))", expr)
+}
+
+// SQL Server returns inserted rows with an OUTPUT clause before VALUES, not after the statement
+func (mssqlDialect) insertReturningClause() string { return "" }
+
+// SQL Server has no LIMIT; OFFSET ... FETCH needs an ORDER BY, so unordered pages get a neutral one
+func (mssqlDialect) pageClause(limit, offset int, ordered bool) string {
+	clause := fmt.Sprintf(" OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", offset, limit)
+	if !ordered {
+		clause = " ORDER BY (SELECT NULL)" + clause
+	}
+	return clause
+}
+
+// There are no row value comparisons, but named parameters can be bound more than once
+func (mssqlDialect) keysetCondition(columns []string, operator string, placeholders []string) (string, error) {
+	return expandedKeysetCondition(columns, operator, placeholders), nil
+}
+
+// SQL Server locks through table hints instead of FOR UPDATE
+func (mssqlDialect) lockRowClauses() (string, string, error) {
+	return " WITH (UPDLOCK, ROWLOCK)", "", nil
+}
+
+func (mssqlDialect) activeQueriesQuery(dbName string, onlyActive bool) (string, []any) {
+	stateFilter := ""
+	if onlyActive {
+		stateFilter = "AND r.session_id IS NOT NULL"
+	}
+
+	return fmt.Sprintf(`
+		SELECT
+			s.session_id,
+			COALESCE(s.login_name, '') AS [user],
+			CASE WHEN r.session_id IS NULL THEN 'idle' ELSE 'active' END AS state,
+			COALESCE(t.text, '') AS query,
+			COALESCE(DATEDIFF(SECOND, r.start_time, GETDATE()), 0) AS duration_seconds,
+			COALESCE(r.start_time, s.last_request_start_time) AS start_time,
+			COALESCE(s.host_name, 'local') AS hostname,
+			s.login_time AS backend_start,
+			COALESCE(s.program_name, '') AS backend_type,
+			COALESCE(r.wait_type, '') AS wait_event,
+			CASE WHEN COALESCE(r.blocking_session_id, 0) > 0 THEN CAST(r.blocking_session_id AS VARCHAR(10)) ELSE '' END AS blocked_by
+		FROM sys.dm_exec_sessions s
+		LEFT JOIN sys.dm_exec_requests r ON r.session_id = s.session_id
+		OUTER APPLY sys.dm_exec_sql_text(r.sql_handle) t
+		WHERE s.is_user_process = 1
+			AND DB_NAME(s.database_id) = @p1
+			%s
+			AND s.session_id != @@SPID
+		ORDER BY start_time DESC
+	`, stateFilter), []any{dbName}
+}
+
+func (mssqlDialect) sessionQuery(pid int) (string, []any) {
+	return `
+		SELECT COALESCE(s.login_name, ''), COALESCE(t.text, '')
+		FROM sys.dm_exec_sessions s
+		LEFT JOIN sys.dm_exec_requests r ON r.session_id = s.session_id
+		OUTER APPLY sys.dm_exec_sql_text(r.sql_handle) t
+		WHERE s.session_id = @p1
+	`, []any{pid}
+}
+
+func (mssqlDialect) terminateSession(ctx context.Context, db *sql.DB, pid int) (bool, error) {
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("KILL %d", pid)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// The driver resets pooled sessions with sp_reset_connection, which cannot be sent as a statement
+func (mssqlDialect) resetSessionStatement() string { return "" }
+
+// SQL Server has no statement timeout setting; lock waits are bounded and the context deadline covers the rest
+func (mssqlDialect) statementTimeoutStatement(timeout time.Duration) string {
+	return fmt.Sprintf("SET LOCK_TIMEOUT %d", timeout.Milliseconds())
+}
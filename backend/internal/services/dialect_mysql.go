@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"truadmin/internal/models"
+)
+
+// openMySQL opens a connection pool for a MySQL DSN. Like openPostgres, it is a variable so that
+// service code can be pointed at a mock driver.
+var openMySQL = func(dsn string) (*sql.DB, error) {
+	return sql.Open(capturedMySQLDriver, dsn)
+}
+
+// mysqlSystemSchemas are hidden from database listings
+const mysqlSystemSchemas = "'mysql', 'information_schema', 'performance_schema', 'sys'"
+
+// mysqlDialect serves MySQL and MariaDB connections through go-sql-driver/mysql.
+// MySQL has no schemas inside a database, so each database is reported as its own single schema.
+type mysqlDialect struct {
+	kind string // mysql or mariadb
+}
+
+func (d mysqlDialect) name() string { return d.kind }
+
+func (d mysqlDialect) open(conn *models.Connection, dbName string) (*sql.DB, error) {
+	if dbName == "" {
+		dbName = conn.Database
+	}
+
+	cfg := mysql.NewConfig()
+	cfg.User = conn.Username
+	cfg.Passwd = conn.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(conn.Host, strconv.Itoa(conn.Port))
+	cfg.DBName = dbName
+	cfg.ParseTime = true
+	cfg.TLSConfig = mysqlTLSMode(conn.SSLMode)
+	// Driver options such as timeout or charset; other names are set as session variables
+	if params := driverParams(d.kind, conn); len(params) > 0 {
+		cfg.Params = map[string]string{}
+		for key, value := range params {
+			cfg.Params[key] = value
+		}
+	}
+
+	return openMySQL(cfg.FormatDSN())
+}
+
+// mysqlTLSMode maps a PostgreSQL-style sslmode to the tls parameter of the MySQL driver
+func mysqlTLSMode(sslMode string) string {
+	switch sslMode {
+	case "allow", "prefer":
+		return "preferred"
+	case "require":
+		return "skip-verify"
+	case "verify-ca", "verify-full":
+		return "true"
+	default:
+		return "false"
+	}
+}
+
+func (mysqlDialect) databasesQuery() (string, []any) {
+	return fmt.Sprintf(`
+		SELECT
+			s.schema_name AS name,
+			COALESCE(SUM(t.data_length + t.index_length), 0) AS size
+		FROM information_schema.schemata s
+		LEFT JOIN information_schema.tables t ON t.table_schema = s.schema_name
+		WHERE s.schema_name NOT IN (%s)
+		GROUP BY s.schema_name
+		ORDER BY s.schema_name
+	`, mysqlSystemSchemas), nil
+}
+
+func (mysqlDialect) schemasQuery(dbName string) (string, []any) {
+	return `
+		SELECT
+			schema_name AS name,
+			'' AS owner
+		FROM information_schema.schemata
+		WHERE schema_name = ?
+	`, []any{dbName}
+}
+
+func (mysqlDialect) tablesQuery(dbName, schemaName string) (string, []any) {
+	return `
+		SELECT
+			table_name,
+			table_schema
+		FROM information_schema.tables
+		WHERE table_schema = ?
+		AND table_type = 'BASE TABLE'
+		ORDER BY table_name
+	`, []any{schemaName}
+}
+
+func (mysqlDialect) viewsQuery(dbName, schemaName string) (string, []any) {
+	return `
+		SELECT
+			table_name,
+			table_schema
+		FROM information_schema.views
+		WHERE table_schema = ?
+		ORDER BY table_name
+	`, []any{schemaName}
+}
+
+func (mysqlDialect) routinesQuery(dbName, schemaName string) (string, []any) {
+	return `
+		SELECT
+			routine_name,
+			routine_schema,
+			routine_type
+		FROM information_schema.routines
+		WHERE routine_schema = ?
+		ORDER BY routine_name
+	`, []any{schemaName}
+}
+
+func (mysqlDialect) columnsQuery(dbName, schemaName, tableName string) (string, []any) {
+	return `
+		SELECT
+			column_name,
+			data_type,
+			character_maximum_length,
+			numeric_precision,
+			numeric_scale,
+			is_nullable = 'YES'
+		FROM information_schema.columns
+		WHERE table_schema = ?
+		AND table_name = ?
+		ORDER BY ordinal_position
+	`, []any{schemaName, tableName}
+}
+
+func (mysqlDialect) columnDetailsQuery(dbName, schemaName, tableName string) (string, []any) {
+	return `
+		SELECT
+			column_name,
+			column_type,
+			is_nullable = 'YES',
+			column_key,
+			COALESCE(column_default, '')
+		FROM information_schema.columns
+		WHERE table_schema = ?
+		AND table_name = ?
+		ORDER BY ordinal_position
+	`, []any{schemaName, tableName}
+}
+
+func (mysqlDialect) tableStatsQuery(dbName string) (string, []any) {
+	return `
+		SELECT
+			table_name,
+			table_schema,
+			COALESCE(table_rows, 0),
+			COALESCE(data_length + index_length, 0)
+		FROM information_schema.tables
+		WHERE table_schema = ?
+		AND table_type = 'BASE TABLE'
+		ORDER BY table_name
+	`, []any{dbName}
+}
+
+func (mysqlDialect) defaultSchema(dbName string) string { return dbName }
+
+func (mysqlDialect) quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (mysqlDialect) placeholder(n int) string { return "?" }
+
+// LIKE is case-insensitive under the default (_ci) collations
+func (mysqlDialect) likeOperator() string { return "LIKE" }
+
+func (mysqlDialect) castToText(expr string) string { return fmt.Sprintf("CAST(%s AS CHAR)", expr) }
+
+// Only MariaDB 10.5+ has INSERT ... RETURNING; inserted rows are read back by their key instead
+func (mysqlDialect) insertReturningClause() string { return "" }
+
+func (mysqlDialect) pageClause(limit, offset int, ordered bool) string {
+	return limitOffsetClause(limit, offset)
+}
+
+func (mysqlDialect) keysetCondition(columns []string, operator string, placeholders []string) (string, error) {
+	return rowValueComparison(columns, operator, placeholders), nil
+}
+
+func (mysqlDialect) lockRowClauses() (string, string, error) { return "", " FOR UPDATE", nil }
+
+func (mysqlDialect) activeQueriesQuery(dbName string, onlyActive bool) (string, []any) {
+	stateFilter := ""
+	if onlyActive {
+		stateFilter = "AND command != 'Sleep'"
+	}
+
+	// The process list has no session start or blocking information; the start of the
+	// current command is reported for both times
+	return fmt.Sprintf(`
+		SELECT
+			id,
+			COALESCE(user, '') AS user,
+			CASE WHEN command = 'Sleep' THEN 'idle' ELSE 'active' END AS state,
+			COALESCE(info, '') AS query,
+			COALESCE(time, 0) AS duration_seconds,
+			NOW() - INTERVAL COALESCE(time, 0) SECOND AS start_time,
+			COALESCE(host, 'local') AS hostname,
+			NOW() - INTERVAL COALESCE(time, 0) SECOND AS backend_start,
+			COALESCE(command, '') AS backend_type,
+			COALESCE(state, '') AS wait_event,
+			'' AS blocked_by
+		FROM information_schema.processlist
+		WHERE db = ?
+			%s
+			AND id != CONNECTION_ID()
+		ORDER BY time ASC
+	`, stateFilter), []any{dbName}
+}
+
+func (mysqlDialect) sessionQuery(pid int) (string, []any) {
+	return `SELECT COALESCE(user, ''), COALESCE(info, '') FROM information_schema.processlist WHERE id = ?`, []any{pid}
+}
+
+func (mysqlDialect) terminateSession(ctx context.Context, db *sql.DB, pid int) (bool, error) {
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("KILL %d", pid)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MySQL only resets sessions through a protocol command the driver does not expose
+func (mysqlDialect) resetSessionStatement() string { return "" }
+
+// max_execution_time only bounds SELECT statements; the context deadline covers the others
+func (mysqlDialect) statementTimeoutStatement(timeout time.Duration) string {
+	return fmt.Sprintf("SET SESSION max_execution_time = %d", timeout.Milliseconds())
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// postgresDialect serves PostgreSQL connections through lib/pq
+type postgresDialect struct{}
+
+func (postgresDialect) name() string { return "postgres" }
+
+func (postgresDialect) open(conn *models.Connection, dbName string) (*sql.DB, error) {
+	if dbName == "" {
+		dbName = conn.Database
+	}
+
+	// Build connection string
+	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		conn.Host,
+		conn.Port,
+		conn.Username,
+		conn.Password,
+		dbName,
+		conn.SSLMode,
+	) + postgresParams(driverParams("postgres", conn))
+	return openPostgres(connStr)
+}
+
+func (postgresDialect) databasesQuery() (string, []any) {
+	return `
+		SELECT
+			datname as name,
+			pg_database_size(datname) as size
+		FROM pg_database
+		WHERE datistemplate = false
+		ORDER BY datname
+	`, nil
+}
+
+func (postgresDialect) schemasQuery(dbName string) (string, []any) {
+	return `
+		SELECT
+			schema_name as name,
+			schema_owner as owner
+		FROM information_schema.schemata
+		WHERE catalog_name = $1
+		AND schema_name NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+		ORDER BY schema_name
+	`, []any{dbName}
+}
+
+func (postgresDialect) tablesQuery(dbName, schemaName string) (string, []any) {
+	return `
+		SELECT
+			table_name,
+			table_schema
+		FROM information_schema.tables
+		WHERE table_catalog = $1
+		AND table_schema = $2
+		AND table_type = 'BASE TABLE'
+		ORDER BY table_name
+	`, []any{dbName, schemaName}
+}
+
+func (postgresDialect) viewsQuery(dbName, schemaName string) (string, []any) {
+	return `
+		SELECT
+			table_name,
+			table_schema
+		FROM information_schema.views
+		WHERE table_catalog = $1
+		AND table_schema = $2
+		ORDER BY table_name
+	`, []any{dbName, schemaName}
+}
+
+func (postgresDialect) routinesQuery(dbName, schemaName string) (string, []any) {
+	return `
+		SELECT
+			routine_name,
+			routine_schema,
+			routine_type
+		FROM information_schema.routines
+		WHERE routine_catalog = $1
+		AND routine_schema = $2
+		ORDER BY routine_name
+	`, []any{dbName, schemaName}
+}
+
+func (postgresDialect) columnsQuery(dbName, schemaName, tableName string) (string, []any) {
+	return `
+		SELECT
+			column_name,
+			data_type,
+			character_maximum_length,
+			numeric_precision,
+			numeric_scale,
+			is_nullable = 'YES'
+		FROM information_schema.columns
+		WHERE table_catalog = $1
+		AND table_schema = $2
+		AND table_name = $3
+		ORDER BY ordinal_position
+	`, []any{dbName, schemaName, tableName}
+}
+
+func (postgresDialect) columnDetailsQuery(dbName, schemaName, tableName string) (string, []any) {
+	return `
+		SELECT
+			c.column_name,
+			CASE
+				WHEN c.character_maximum_length IS NOT NULL THEN c.data_type || '(' || c.character_maximum_length || ')'
+				WHEN c.data_type = 'numeric' AND c.numeric_precision IS NOT NULL THEN c.data_type || '(' || c.numeric_precision || ',' || c.numeric_scale || ')'
+				WHEN c.data_type IN ('USER-DEFINED', 'ARRAY') THEN c.udt_name
+				ELSE c.data_type
+			END AS type,
+			c.is_nullable = 'YES' AS nullable,
+			COALESCE((
+				SELECT CASE tc.constraint_type WHEN 'PRIMARY KEY' THEN 'PRI' WHEN 'UNIQUE' THEN 'UNI' ELSE 'MUL' END
+				FROM information_schema.key_column_usage k
+				JOIN information_schema.table_constraints tc
+					ON tc.constraint_schema = k.constraint_schema
+					AND tc.constraint_name = k.constraint_name
+				WHERE k.table_schema = c.table_schema
+				AND k.table_name = c.table_name
+				AND k.column_name = c.column_name
+				ORDER BY CASE tc.constraint_type WHEN 'PRIMARY KEY' THEN 0 WHEN 'UNIQUE' THEN 1 ELSE 2 END
+				LIMIT 1
+			), '') AS key,
+			COALESCE(c.column_default, '') AS default_value
+		FROM information_schema.columns c
+		WHERE c.table_catalog = $1
+		AND c.table_schema = $2
+		AND c.table_name = $3
+		ORDER BY c.ordinal_position
+	`, []any{dbName, schemaName, tableName}
+}
+
+func (postgresDialect) tableStatsQuery(dbName string) (string, []any) {
+	// reltuples is -1 for tables that were never analyzed
+	return `
+		SELECT
+			t.table_name,
+			t.table_schema,
+			COALESCE(GREATEST(c.reltuples, 0), 0)::bigint AS estimated_rows,
+			COALESCE(pg_total_relation_size(c.oid), 0) AS size
+		FROM information_schema.tables t
+		LEFT JOIN pg_namespace n ON n.nspname = t.table_schema
+		LEFT JOIN pg_class c ON c.relnamespace = n.oid AND c.relname = t.table_name
+		WHERE t.table_catalog = $1
+		AND t.table_type = 'BASE TABLE'
+		AND t.table_schema NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY t.table_schema, t.table_name
+	`, []any{dbName}
+}
+
+func (postgresDialect) defaultSchema(dbName string) string { return "public" }
+
+func (postgresDialect) quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (postgresDialect) placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+func (postgresDialect) likeOperator() string { return "ILIKE" }
+
+func (postgresDialect) castToText(expr string) string { return fmt.Sprintf("CAST(%s AS TEXT)", expr) }
+
+func (postgresDialect) insertReturningClause() string { return " RETURNING *" }
+
+func (postgresDialect) pageClause(limit, offset int, ordered bool) string {
+	return limitOffsetClause(limit, offset)
+}
+
+func (postgresDialect) keysetCondition(columns []string, operator string, placeholders []string) (string, error) {
+	return rowValueComparison(columns, operator, placeholders), nil
+}
+
+func (postgresDialect) lockRowClauses() (string, string, error) { return "", " FOR UPDATE", nil }
+
+func (postgresDialect) activeQueriesQuery(dbName string, onlyActive bool) (string, []any) {
+	stateFilter := ""
+	if onlyActive {
+		stateFilter = "AND state != 'idle'"
+	}
+
+	return fmt.Sprintf(`
+		SELECT
+			pid,
+			COALESCE(usename, '') as user,
+			COALESCE(state, '') as state,
+			COALESCE(query, '') as query,
+			COALESCE(EXTRACT(EPOCH FROM (NOW() - query_start))::int, 0) as duration_seconds,
+			COALESCE(query_start, NOW()) as start_time,
+			COALESCE(client_addr::text, client_hostname, 'local') as hostname,
+			COALESCE(backend_start, NOW()) as backend_start,
+			COALESCE(backend_type, '') as backend_type,
+			COALESCE(wait_event_type || ': ' || wait_event, '') as wait_event,
+			COALESCE(
+				CASE
+					WHEN cardinality(pg_blocking_pids(pid)) > 0
+					THEN array_to_string(pg_blocking_pids(pid), ',')
+					ELSE ''
+				END,
+			'') as blocked_by
+		FROM pg_stat_activity
+		WHERE datname = $1
+			%s
+			AND pid != pg_backend_pid()
+			AND query NOT LIKE '%%pg_stat_activity%%'
+			AND query NOT LIKE '%%pg_locks%%'
+			AND query NOT LIKE '%%FROM information_schema%%'
+		ORDER BY query_start DESC
+	`, stateFilter), []any{dbName}
+}
+
+func (postgresDialect) sessionQuery(pid int) (string, []any) {
+	return `SELECT COALESCE(usename, ''), COALESCE(query, '') FROM pg_stat_activity WHERE pid = $1`, []any{pid}
+}
+
+func (postgresDialect) terminateSession(ctx context.Context, db *sql.DB, pid int) (bool, error) {
+	var terminated bool
+	err := db.QueryRowContext(ctx, "SELECT pg_terminate_backend($1)", pid).Scan(&terminated)
+	return terminated, err
+}
+
+// DISCARD ALL fails inside an open transaction, which makes the pool discard the connection
+func (postgresDialect) resetSessionStatement() string { return "DISCARD ALL" }
+
+func (postgresDialect) statementTimeoutStatement(timeout time.Duration) string {
+	return fmt.Sprintf("SET statement_timeout = %d", timeout.Milliseconds())
+}
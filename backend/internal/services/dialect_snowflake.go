@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// openSnowflake opens a connection pool for a Snowflake DSN. Like openPostgres, it is a variable so
+// that service code can be pointed at a mock driver.
+var openSnowflake = func(dsn string) (*sql.DB, error) {
+	return sql.Open(capturedSnowflakeDriver, dsn)
+}
+
+// snowflakeDialect serves Snowflake connections through snowflakedb/gosnowflake. The connection host
+// is the account identifier, with or without the snowflakecomputing.com suffix; queries run on the
+// user's default warehouse.
+type snowflakeDialect struct{}
+
+func (snowflakeDialect) name() string { return "snowflake" }
+
+func (snowflakeDialect) open(conn *models.Connection, dbName string) (*sql.DB, error) {
+	if dbName == "" {
+		dbName = conn.Database
+	}
+
+	account := strings.TrimSuffix(strings.ToLower(conn.Host), ".snowflakecomputing.com")
+	query := url.Values{}
+	query.Set("application", "truadmin")
+	if conn.Port != 0 && conn.Port != 443 {
+		query.Set("port", fmt.Sprint(conn.Port))
+	}
+	if conn.SSLMode == "disable" {
+		query.Set("protocol", "http")
+	}
+	for key, value := range driverParams("snowflake", conn) {
+		query.Set(key, value)
+	}
+
+	dsn := fmt.Sprintf("%s@%s/%s?%s", url.UserPassword(conn.Username, conn.Password).String(), account, url.PathEscape(dbName), query.Encode())
+	return openSnowflake(dsn)
+}
+
+func (snowflakeDialect) databasesQuery() (string, []any) {
+	// Database sizes are only in the account usage views, which need extra privileges
+	return `
+		SELECT
+			database_name AS name,
+			0 AS size
+		FROM information_schema.databases
+		ORDER BY database_name
+	`, nil
+}
+
+func (snowflakeDialect) schemasQuery(dbName string) (string, []any) {
+	return `
+		SELECT
+			schema_name AS name,
+			COALESCE(schema_owner, '') AS owner
+		FROM information_schema.schemata
+		WHERE catalog_name = ?
+		AND schema_name <> 'INFORMATION_SCHEMA'
+		ORDER BY schema_name
+	`, []any{dbName}
+}
+
+func (snowflakeDialect) tablesQuery(dbName, schemaName string) (string, []any) {
+	return `
+		SELECT
+			table_name,
+			table_schema
+		FROM information_schema.tables
+		WHERE table_catalog = ?
+		AND table_schema = ?
+		AND table_type = 'BASE TABLE'
+		ORDER BY table_name
+	`, []any{dbName, schemaName}
+}
+
+func (snowflakeDialect) viewsQuery(dbName, schemaName string) (string, []any) {
+	return `
+		SELECT
+			table_name,
+			table_schema
+		FROM information_schema.views
+		WHERE table_catalog = ?
+		AND table_schema = ?
+		ORDER BY table_name
+	`, []any{dbName, schemaName}
+}
+
+func (snowflakeDialect) routinesQuery(dbName, schemaName string) (string, []any) {
+	return `
+		SELECT name, routine_schema, routine_type FROM (
+			SELECT function_name AS name, function_schema AS routine_schema, 'FUNCTION' AS routine_type
+			FROM information_schema.functions
+			WHERE function_catalog = ?
+			AND function_schema = ?
+			UNION ALL
+			SELECT procedure_name, procedure_schema, 'PROCEDURE'
+			FROM information_schema.procedures
+			WHERE procedure_catalog = ?
+			AND procedure_schema = ?
+		) routines
+		ORDER BY name
+	`, []any{dbName, schemaName, dbName, schemaName}
+}
+
+func (snowflakeDialect) columnsQuery(dbName, schemaName, tableName string) (string, []any) {
+	return `
+		SELECT
+			column_name,
+			data_type,
+			character_maximum_length,
+			numeric_precision,
+			numeric_scale,
+			is_nullable = 'YES'
+		FROM information_schema.columns
+		WHERE table_catalog = ?
+		AND table_schema = ?
+		AND table_name = ?
+		ORDER BY ordinal_position
+	`, []any{dbName, schemaName, tableName}
+}
+
+func (snowflakeDialect) columnDetailsQuery(dbName, schemaName, tableName string) (string, []any) {
+	// Snowflake does not expose key columns in information_schema, and does not enforce keys anyway
+	return `
+		SELECT
+			column_name,
+			CASE
+				WHEN character_maximum_length IS NOT NULL THEN data_type || '(' || character_maximum_length || ')'
+				WHEN data_type = 'NUMBER' AND numeric_precision IS NOT NULL THEN data_type || '(' || numeric_precision || ',' || numeric_scale || ')'
+				ELSE data_type
+			END AS type,
+			is_nullable = 'YES' AS nullable,
+			'' AS key,
+			COALESCE(column_default, '') AS default_value
+		FROM information_schema.columns
+		WHERE table_catalog = ?
+		AND table_schema = ?
+		AND table_name = ?
+		ORDER BY ordinal_position
+	`, []any{dbName, schemaName, tableName}
+}
+
+func (snowflakeDialect) tableStatsQuery(dbName string) (string, []any) {
+	return `
+		SELECT
+			table_name,
+			table_schema,
+			COALESCE(row_count, 0),
+			COALESCE(bytes, 0)
+		FROM information_schema.tables
+		WHERE table_catalog = ?
+		AND table_type = 'BASE TABLE'
+		AND table_schema <> 'INFORMATION_SCHEMA'
+		ORDER BY table_schema, table_name
+	`, []any{dbName}
+}
+
+func (snowflakeDialect) defaultSchema(dbName string) string { return "PUBLIC" }
+
+func (snowflakeDialect) quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (snowflakeDialect) placeholder(n int) string { return "?" }
+
+func (snowflakeDialect) likeOperator() string { return "ILIKE" }
+
+func (snowflakeDialect) castToText(expr string) string { return fmt.Sprintf("TO_VARCHAR(%s)", expr) }
+
+func (snowflakeDialect) insertReturningClause() string { return "" }
+
+func (snowflakeDialect) pageClause(limit, offset int, ordered bool) string {
+	return limitOffsetClause(limit, offset)
+}
+
+// Parameters are positional, so spelling a comparison of several columns out would need the cursor
+// values bound once per use
+func (snowflakeDialect) keysetCondition(columns []string, operator string, placeholders []string) (string, error) {
+	if len(columns) > 1 {
+		return "", fmt.Errorf("%w: keyset pagination over several columns on snowflake", ErrUnsupportedDialect)
+	}
+	return fmt.Sprintf("%s %s %s", columns[0], operator, placeholders[0]), nil
+}
+
+// Standard Snowflake tables have no row locks, so rows cannot be read for update
+func (snowflakeDialect) lockRowClauses() (string, string, error) {
+	return "", "", fmt.Errorf("%w: locking rows on snowflake", ErrUnsupportedDialect)
+}
+
+func (snowflakeDialect) activeQueriesQuery(dbName string, onlyActive bool) (string, []any) {
+	stateFilter := ""
+	if onlyActive {
+		stateFilter = "AND execution_status IN ('RUNNING', 'QUEUED', 'BLOCKED', 'RESUMING_WAREHOUSE')"
+	}
+
+	// Snowflake lists queries rather than sessions, and only those visible to the current role.
+	// Blocked queries do not name their blocker.
+	return fmt.Sprintf(`
+		SELECT
+			session_id,
+			COALESCE(user_name, '') AS user_name,
+			CASE WHEN execution_status IN ('RUNNING', 'QUEUED', 'BLOCKED', 'RESUMING_WAREHOUSE') THEN 'active' ELSE 'idle' END AS state,
+			COALESCE(query_text, '') AS query,
+			DATEDIFF('second', start_time, COALESCE(end_time, CURRENT_TIMESTAMP())) AS duration_seconds,
+			start_time,
+			COALESCE(warehouse_name, '') AS hostname,
+			start_time AS backend_start,
+			COALESCE(query_type, '') AS backend_type,
+			COALESCE(execution_status, '') AS wait_event,
+			'' AS blocked_by
+		FROM TABLE(information_schema.query_history(result_limit => 1000))
+		WHERE database_name = ?
+			%s
+			AND session_id <> CURRENT_SESSION()::number
+		ORDER BY start_time DESC
+	`, stateFilter), []any{dbName}
+}
+
+func (snowflakeDialect) sessionQuery(pid int) (string, []any) {
+	return `
+		SELECT COALESCE(user_name, ''), COALESCE(query_text, '')
+		FROM TABLE(information_schema.query_history_by_session(session_id => ?, result_limit => 1))
+	`, []any{pid}
+}
+
+func (snowflakeDialect) terminateSession(ctx context.Context, db *sql.DB, pid int) (bool, error) {
+	var result string
+	if err := db.QueryRowContext(ctx, "SELECT SYSTEM$ABORT_SESSION(?)", pid).Scan(&result); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Session parameters cannot be reset in one statement, so pooled sessions are discarded
+func (snowflakeDialect) resetSessionStatement() string { return "" }
+
+// The timeout is in whole seconds; zero would mean no timeout
+func (snowflakeDialect) statementTimeoutStatement(timeout time.Duration) string {
+	seconds := int64(timeout.Round(time.Second).Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("ALTER SESSION SET STATEMENT_TIMEOUT_IN_SECONDS = %d", seconds)
+}
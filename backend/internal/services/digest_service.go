@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// digestTopQueries is the number of top queries included in a digest
+const digestTopQueries = 5
+
+// DigestService builds and emails per-connection activity digests
+type DigestService struct {
+	db                  *gorm.DB
+	databaseService     *DatabaseService
+	notificationService *NotificationService
+	accessGrants        *AccessGrantService
+	alertSources        []AlertSource
+	logger              *slog.Logger
+}
+
+// NewDigestService creates a new digest service
+func NewDigestService(databaseService *DatabaseService, notificationService *NotificationService, accessGrants *AccessGrantService, logger *slog.Logger, alertSources ...AlertSource) *DigestService {
+	return &DigestService{
+		db:                  database.GetDB(),
+		databaseService:     databaseService,
+		notificationService: notificationService,
+		accessGrants:        accessGrants,
+		alertSources:        alertSources,
+		logger:              logging.OrDefault(logger),
+	}
+}
+
+// Subscribe subscribes a user to a connection's digest
+func (s *DigestService) Subscribe(userID string, req *models.DigestSubscriptionRequest) (*models.DigestSubscription, error) {
+	if req.Frequency != models.DigestFrequencyDaily && req.Frequency != models.DigestFrequencyWeekly {
+		return nil, fmt.Errorf("invalid frequency: %s", req.Frequency)
+	}
+
+	if _, err := s.databaseService.connectionService.GetConnection(req.ConnectionID); err != nil {
+		return nil, err
+	}
+	if err := s.accessGrants.CheckUserAccess(userID, req.ConnectionID, false); err != nil {
+		return nil, err
+	}
+
+	subscription := &models.DigestSubscription{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		ConnectionID: req.ConnectionID,
+		Email:        req.Email,
+		Frequency:    req.Frequency,
+		LastSizes:    models.SizeMap{},
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	if err := s.db.Create(subscription).Error; err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// GetSubscriptions returns the digest subscriptions of a user
+func (s *DigestService) GetSubscriptions(userID string) ([]models.DigestSubscription, error) {
+	subscriptions := []models.DigestSubscription{}
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// DeleteSubscription removes a subscription owned by the user
+func (s *DigestService) DeleteSubscription(id, userID string, isAdmin bool) error {
+	subscription, err := s.getSubscription(id, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(subscription).Error; err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	return nil
+}
+
+// PreviewDigest builds the digest a subscription of the user with the given frequency would receive now
+func (s *DigestService) PreviewDigest(connectionID, userID string, frequency models.DigestFrequency) (*models.ActivityDigest, error) {
+	if err := s.accessGrants.CheckUserAccess(userID, connectionID, false); err != nil {
+		return nil, err
+	}
+	digest, _, err := s.BuildDigest(connectionID, time.Now().Add(-digestPeriod(frequency)), models.SizeMap{})
+	return digest, err
+}
+
+// SendDigest immediately sends the digest of a subscription
+func (s *DigestService) SendDigest(id, userID string, isAdmin bool) (*models.ActivityDigest, error) {
+	subscription, err := s.getSubscription(id, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	return s.sendSubscriptionDigest(subscription)
+}
+
+// RunDueDigests sends every digest whose period has elapsed; it is registered as the activity_digest job type
+func (s *DigestService) RunDueDigests() error {
+	if !s.notificationService.EmailEnabled() {
+		return nil
+	}
+
+	var subscriptions []models.DigestSubscription
+	if err := s.db.Find(&subscriptions).Error; err != nil {
+		return fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+
+	failed := 0
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		if subscription.LastSentAt != nil && time.Since(*subscription.LastSentAt) < digestPeriod(subscription.Frequency) {
+			continue
+		}
+		if _, err := s.sendSubscriptionDigest(subscription); err != nil {
+			if errors.Is(err, ErrAccessGrantRequired) {
+				// The subscriber's grant ended; the digest resumes once they are granted access again
+				continue
+			}
+			s.logger.Error("failed to send digest", "subscription_id", subscription.ID, "error", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d digests failed", failed)
+	}
+	return nil
+}
+
+// BuildDigest collects a connection's activity since the given time.
+// Sections that fail are reported in Errors so one unreachable source does not suppress the digest.
+func (s *DigestService) BuildDigest(connectionID string, since time.Time, lastSizes models.SizeMap) (*models.ActivityDigest, models.SizeMap, error) {
+	conn, err := s.databaseService.connectionService.GetConnection(connectionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	digest := &models.ActivityDigest{
+		ConnectionID:     conn.ID,
+		ConnectionName:   conn.Name,
+		PeriodStart:      since,
+		PeriodEnd:        time.Now(),
+		TopQueries:       []*models.QueryStatement{},
+		NewRoles:         []models.RoleSaveLog{},
+		FailedOperations: []models.DigestFailure{},
+		AlertCounts:      map[string]int{},
+		StorageGrowth:    []models.DigestStorageGrowth{},
+	}
+	sizes := models.SizeMap{}
+
+	// Top queries by total execution time (requires pg_stat_statements)
+	if statements, err := s.databaseService.GetQueryHistory(context.Background(), connectionID, conn.Database); err != nil {
+		digest.Errors = append(digest.Errors, fmt.Sprintf("top queries: %v", err))
+	} else {
+		if len(statements) > digestTopQueries {
+			statements = statements[:digestTopQueries]
+		}
+		digest.TopQueries = statements
+	}
+
+	// New roles created through truadmin
+	if err := s.db.Where("connection_id = ? AND operation = ? AND status = ? AND created_at >= ?",
+		connectionID, "create", models.RoleSaveStatusSuccess, since).
+		Order("created_at ASC").Find(&digest.NewRoles).Error; err != nil {
+		digest.Errors = append(digest.Errors, fmt.Sprintf("new roles: %v", err))
+	}
+
+	// Failed operations
+	var roleFailures []models.RoleSaveLog
+	if err := s.db.Where("connection_id = ? AND status = ? AND created_at >= ?", connectionID, models.RoleSaveStatusError, since).
+		Find(&roleFailures).Error; err == nil {
+		for _, l := range roleFailures {
+			digest.FailedOperations = append(digest.FailedOperations, models.DigestFailure{
+				Source: "role", Operation: l.Operation, Error: l.ErrorMessage, CreatedAt: l.CreatedAt,
+			})
+		}
+	}
+	var connectionFailures []models.ConnectionSaveLog
+	if err := s.db.Where("connection_id = ? AND status = ? AND created_at >= ?", connectionID, models.ConnectionSaveStatusError, since).
+		Find(&connectionFailures).Error; err == nil {
+		for _, l := range connectionFailures {
+			digest.FailedOperations = append(digest.FailedOperations, models.DigestFailure{
+				Source: "connection", Operation: l.Operation, Error: l.ErrorMessage, CreatedAt: l.CreatedAt,
+			})
+		}
+	}
+	var partitionFailures []models.PartitionMaintenanceLog
+	if err := s.db.Joins("JOIN partition_policies ON partition_policies.id = partition_maintenance_logs.policy_id").
+		Where("partition_policies.connection_id = ? AND partition_maintenance_logs.status = ? AND partition_maintenance_logs.created_at >= ?",
+			connectionID, models.PartitionRunStatusError, since).
+		Find(&partitionFailures).Error; err == nil {
+		for _, l := range partitionFailures {
+			digest.FailedOperations = append(digest.FailedOperations, models.DigestFailure{
+				Source: "partition_maintenance", Operation: l.Trigger, Error: l.ErrorMessage, CreatedAt: l.CreatedAt,
+			})
+		}
+	}
+
+	// Alert counts by status
+	for _, source := range s.alertSources {
+		statuses, err := source.GetAlertStatuses(connectionID)
+		if err != nil {
+			digest.Errors = append(digest.Errors, fmt.Sprintf("alerts: %v", err))
+			continue
+		}
+		for _, status := range statuses {
+			digest.AlertCounts[status.Status]++
+		}
+	}
+
+	// Storage growth since the previous digest
+	if databases, err := s.databaseService.GetDatabases(context.Background(), connectionID); err != nil {
+		digest.Errors = append(digest.Errors, fmt.Sprintf("storage: %v", err))
+	} else {
+		for _, db := range databases {
+			if db.Size == nil {
+				continue
+			}
+			growth := models.DigestStorageGrowth{DatabaseName: db.Name, SizeBytes: *db.Size}
+			if previous, ok := lastSizes[db.Name]; ok {
+				delta := *db.Size - previous
+				growth.PreviousBytes = &previous
+				growth.GrowthBytes = &delta
+			}
+			digest.StorageGrowth = append(digest.StorageGrowth, growth)
+			sizes[db.Name] = *db.Size
+		}
+	}
+
+	return digest, sizes, nil
+}
+
+// sendSubscriptionDigest builds, emails and records a subscription's digest
+func (s *DigestService) sendSubscriptionDigest(subscription *models.DigestSubscription) (*models.ActivityDigest, error) {
+	if err := s.accessGrants.CheckUserAccess(subscription.UserID, subscription.ConnectionID, false); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().Add(-digestPeriod(subscription.Frequency))
+	if subscription.LastSentAt != nil {
+		since = *subscription.LastSentAt
+	}
+
+	digest, sizes, err := s.BuildDigest(subscription.ConnectionID, since, subscription.LastSizes)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := fmt.Sprintf("[truadmin] %s %s digest", digest.ConnectionName, subscription.Frequency)
+	if err := s.notificationService.SendEmail([]string{subscription.Email}, subject, renderDigest(digest)); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.db.Model(subscription).Updates(map[string]interface{}{
+		"last_sent_at": now,
+		"last_sizes":   sizes,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	return digest, nil
+}
+
+// getSubscription loads a subscription and checks the user owns it
+func (s *DigestService) getSubscription(id, userID string, isAdmin bool) (*models.DigestSubscription, error) {
+	var subscription models.DigestSubscription
+	if err := s.db.First(&subscription, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("subscription not found")
+		}
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	if subscription.UserID != userID && !isAdmin {
+		return nil, fmt.Errorf("subscription not found")
+	}
+	return &subscription, nil
+}
+
+// digestPeriod returns the length of a digest period
+func digestPeriod(frequency models.DigestFrequency) time.Duration {
+	if frequency == models.DigestFrequencyWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// renderDigest renders a digest as plain text for email
+func renderDigest(d *models.ActivityDigest) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Activity digest for %s\n", d.ConnectionName)
+	fmt.Fprintf(&b, "Period: %s - %s\n\n", d.PeriodStart.Format(time.RFC1123), d.PeriodEnd.Format(time.RFC1123))
+
+	b.WriteString("Top queries (by total execution time)\n")
+	if len(d.TopQueries) == 0 {
+		b.WriteString("  none\n")
+	}
+	for i, q := range d.TopQueries {
+		query := strings.Join(strings.Fields(q.Query), " ")
+		if len(query) > 120 {
+			query = query[:120] + "..."
+		}
+		fmt.Fprintf(&b, "  %d. %.0f ms total, %d calls: %s\n", i+1, q.TotalExecTime, q.Calls, query)
+	}
+
+	fmt.Fprintf(&b, "\nNew roles: %d\n", len(d.NewRoles))
+
+	fmt.Fprintf(&b, "\nFailed operations: %d\n", len(d.FailedOperations))
+	for _, f := range d.FailedOperations {
+		fmt.Fprintf(&b, "  %s [%s/%s] %s\n", f.CreatedAt.Format(time.RFC3339), f.Source, f.Operation, f.Error)
+	}
+
+	b.WriteString("\nAlerts\n")
+	if len(d.AlertCounts) == 0 {
+		b.WriteString("  none\n")
+	}
+	for status, count := range d.AlertCounts {
+		fmt.Fprintf(&b, "  %s: %d\n", status, count)
+	}
+
+	b.WriteString("\nStorage\n")
+	for _, g := range d.StorageGrowth {
+		if g.GrowthBytes != nil {
+			fmt.Fprintf(&b, "  %s: %d bytes (%+d)\n", g.DatabaseName, g.SizeBytes, *g.GrowthBytes)
+		} else {
+			fmt.Fprintf(&b, "  %s: %d bytes\n", g.DatabaseName, g.SizeBytes)
+		}
+	}
+
+	if len(d.Errors) > 0 {
+		b.WriteString("\nSections with errors\n")
+		for _, e := range d.Errors {
+			fmt.Fprintf(&b, "  %s\n", e)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// ErrExportForbidden is returned when a user accesses an export started by someone else
+var ErrExportForbidden = errors.New("access to export denied")
+
+// ErrExportNotReady is returned when a download link is requested before the export has completed
+var ErrExportNotReady = errors.New("export has not completed")
+
+// ExportService writes query results to CSV artifacts in the background. The result is streamed
+// into a temporary file, so exports are not limited by memory, and then handed to artifact storage.
+type ExportService struct {
+	db              *gorm.DB
+	databaseService *DatabaseService
+	artifactService *ArtifactService
+	replicaID       string
+	logger          *slog.Logger
+}
+
+// NewExportService creates a new export service; exports are recorded with replicaID, the replica
+// running them
+func NewExportService(databaseService *DatabaseService, artifactService *ArtifactService, replicaID string, logger *slog.Logger) *ExportService {
+	return &ExportService{
+		db:              database.GetDB(),
+		databaseService: databaseService,
+		artifactService: artifactService,
+		replicaID:       replicaID,
+		logger:          logging.OrDefault(logger),
+	}
+}
+
+// StartExport records an export and runs it in the background
+func (s *ExportService) StartExport(connectionID, dbName, ownerID string, req *models.QueryExportRequest) (*models.QueryExport, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "export-" + time.Now().UTC().Format("20060102-150405")
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".csv") {
+		name += ".csv"
+	}
+
+	export := &models.QueryExport{
+		ID:           uuid.New().String(),
+		Name:         name,
+		ConnectionID: connectionID,
+		DatabaseName: dbName,
+		Query:        req.Query,
+		OwnerID:      ownerID,
+		Status:       models.QueryExportRunning,
+		ReplicaID:    s.replicaID,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := s.db.Create(export).Error; err != nil {
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+
+	go s.run(*export)
+
+	return export, nil
+}
+
+// GetExports returns the exports the user started, or all exports for admins
+func (s *ExportService) GetExports(userID string, isAdmin bool) ([]models.QueryExport, error) {
+	query := s.db.Order("created_at DESC")
+	if !isAdmin {
+		query = query.Where("owner_id = ?", userID)
+	}
+
+	var exports []models.QueryExport
+	if err := query.Find(&exports).Error; err != nil {
+		return nil, fmt.Errorf("failed to get exports: %w", err)
+	}
+	return exports, nil
+}
+
+// GetExport returns an export the user started
+func (s *ExportService) GetExport(id, userID string, isAdmin bool) (*models.QueryExport, error) {
+	var export models.QueryExport
+	if err := s.db.First(&export, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("export not found")
+		}
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+
+	if export.OwnerID != userID && !isAdmin {
+		return nil, ErrExportForbidden
+	}
+	return &export, nil
+}
+
+// DownloadURL returns a short-lived signed link to the CSV of a completed export. The link needs no
+// authentication, so browsers and download managers can fetch it directly and resume it with Range requests.
+func (s *ExportService) DownloadURL(id, userID string, isAdmin bool) (*models.ArtifactDownload, error) {
+	export, err := s.GetExport(id, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if export.Status != models.QueryExportCompleted || export.ArtifactID == nil {
+		return nil, ErrExportNotReady
+	}
+
+	download, err := s.artifactService.DownloadURL(*export.ArtifactID, userID, isAdmin)
+	if err != nil && err.Error() == "artifact not found" {
+		return nil, fmt.Errorf("export file has expired")
+	}
+	return download, err
+}
+
+// FailInterrupted marks running exports of replicas that are not in liveReplicas as failed;
+// they were cut off when their server stopped
+func (s *ExportService) FailInterrupted(liveReplicas []string) error {
+	if err := s.db.Model(&models.QueryExport{}).
+		Where("status = ? AND replica_id NOT IN ?", models.QueryExportRunning, liveReplicas).
+		Updates(map[string]interface{}{
+			"status":       models.QueryExportFailed,
+			"error":        "interrupted by a server restart",
+			"completed_at": time.Now().UTC(),
+		}).Error; err != nil {
+		return fmt.Errorf("failed to update interrupted exports: %w", err)
+	}
+	return nil
+}
+
+// run streams the query into a temporary CSV file, stores it as an artifact and records the outcome
+func (s *ExportService) run(export models.QueryExport) {
+	updates := map[string]interface{}{}
+
+	artifact, summary, err := s.writeExport(&export)
+	switch {
+	case err != nil:
+		updates["status"] = models.QueryExportFailed
+		updates["error"] = err.Error()
+	case summary.Error != "":
+		updates["status"] = models.QueryExportFailed
+		updates["error"] = summary.Error
+	default:
+		updates["status"] = models.QueryExportCompleted
+		updates["artifact_id"] = artifact.ID
+	}
+	if summary != nil {
+		updates["row_count"] = summary.RowCount
+		updates["truncated"] = summary.Truncated
+	}
+	updates["completed_at"] = time.Now().UTC()
+
+	if err := s.db.Model(&models.QueryExport{}).Where("id = ?", export.ID).Updates(updates).Error; err != nil {
+		s.logger.Warn("failed to record export outcome", "export_id", export.ID, "error", err)
+	}
+}
+
+// writeExport writes the CSV file and stores it unless the query failed
+func (s *ExportService) writeExport(export *models.QueryExport) (*models.Artifact, *models.QueryStreamSummary, error) {
+	tmp, err := os.CreateTemp("", "truadmin-export-*.csv")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	writer := csv.NewWriter(tmp)
+	var columns []string
+	summary, err := s.databaseService.StreamQuery(context.Background(), export.ConnectionID, export.DatabaseName, export.Query,
+		func(cols []string) error {
+			columns = cols
+			return writer.Write(cols)
+		},
+		func(row map[string]any) error {
+			record := make([]string, len(columns))
+			for i, column := range columns {
+				record[i] = csvValue(row[column])
+			}
+			return writer.Write(record)
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+	if summary.Error != "" {
+		return nil, summary, nil
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, summary, fmt.Errorf("failed to write export file: %w", err)
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return nil, summary, fmt.Errorf("failed to write export file: %w", err)
+	}
+	if _, err := tmp.Seek(0, 0); err != nil {
+		return nil, summary, fmt.Errorf("failed to read export file: %w", err)
+	}
+
+	artifact, err := s.artifactService.Store(context.Background(), models.ArtifactExport, export.Name, export.OwnerID, "text/csv", tmp, info.Size())
+	if err != nil {
+		return nil, summary, err
+	}
+	return artifact, summary, nil
+}
+
+// csvValue formats a scanned column value for a CSV cell; NULL becomes an empty cell
+func csvValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
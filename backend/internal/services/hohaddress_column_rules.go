@@ -0,0 +1,199 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/models"
+)
+
+// ColumnEditDeniedError is returned when an edit sets columns the user may not edit and a rule of
+// one of them rejects such edits
+type ColumnEditDeniedError struct {
+	Columns []string
+}
+
+func (e *ColumnEditDeniedError) Error() string {
+	return fmt.Sprintf("you are not allowed to edit %s", strings.Join(e.Columns, ", "))
+}
+
+// AuthorizeColumnEdits checks the columns of a row edit against the column rules of the table. It
+// returns the edit without the columns the user may not edit, together with those columns; when a
+// rule of one of them rejects such edits it returns a ColumnEditDeniedError instead.
+func (s *HohAddressService) AuthorizeColumnEdits(hohAddressDatabaseID, tableName string, editor RowScope, data map[string]interface{}) (map[string]interface{}, []string, error) {
+	if editor.Admin || len(data) == 0 {
+		return data, nil, nil
+	}
+
+	var rules []models.HohAddressColumnRule
+	if err := s.db.Where("hohaddress_database_id = '' OR hohaddress_database_id = ?", hohAddressDatabaseID).
+		Where("table_name = ?", tableName).Find(&rules).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get column rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return data, nil, nil
+	}
+	groups, err := permissionGroupsOf(s.db, editor.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allowed := make(map[string]interface{}, len(data))
+	denied := []string{}
+	reject := false
+	for key, value := range data {
+		permitted := true
+		for _, rule := range rules {
+			if !strings.EqualFold(rule.Column, key) || memberOfAny(groups, rule.Groups) {
+				continue
+			}
+			permitted = false
+			if rule.Action != models.ColumnRuleActionStrip {
+				reject = true
+			}
+		}
+		if permitted {
+			allowed[key] = value
+		} else {
+			denied = append(denied, key)
+		}
+	}
+
+	slices.Sort(denied)
+	if reject {
+		return nil, denied, &ColumnEditDeniedError{Columns: denied}
+	}
+	return allowed, denied, nil
+}
+
+// memberOfAny reports whether one of groups is among the group IDs
+func memberOfAny(groups []models.PermissionGroup, groupIDs models.StringList) bool {
+	for _, group := range groups {
+		if groupIDs.Contains(group.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetColumnRules returns the column rules, optionally only those of a HohAddress database
+func (s *HohAddressService) GetColumnRules(hohAddressDatabaseID string) ([]models.HohAddressColumnRule, error) {
+	rules := []models.HohAddressColumnRule{}
+
+	query := s.db.Order("table_name, column_name")
+	if hohAddressDatabaseID != "" {
+		query = query.Where("hohaddress_database_id = ?", hohAddressDatabaseID)
+	}
+	if err := query.Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get column rules: %w", err)
+	}
+	return rules, nil
+}
+
+// GetColumnRule returns a column rule by ID
+func (s *HohAddressService) GetColumnRule(id string) (*models.HohAddressColumnRule, error) {
+	var rule models.HohAddressColumnRule
+	if err := s.db.First(&rule, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("column rule not found")
+		}
+		return nil, fmt.Errorf("failed to get column rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// CreateColumnRule creates a column rule
+func (s *HohAddressService) CreateColumnRule(req *models.HohAddressColumnRuleRequest, createdBy string) (*models.HohAddressColumnRule, error) {
+	rule := &models.HohAddressColumnRule{ID: uuid.New().String(), CreatedBy: createdBy}
+	if err := s.applyColumnRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to create column rule: %w", err)
+	}
+	return rule, nil
+}
+
+// UpdateColumnRule replaces the database, column, groups, action and description of a column rule
+func (s *HohAddressService) UpdateColumnRule(id string, req *models.HohAddressColumnRuleRequest) (*models.HohAddressColumnRule, error) {
+	rule, err := s.GetColumnRule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyColumnRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to update column rule: %w", err)
+	}
+	return rule, nil
+}
+
+// DeleteColumnRule deletes a column rule
+func (s *HohAddressService) DeleteColumnRule(id string) error {
+	result := s.db.Delete(&models.HohAddressColumnRule{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete column rule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("column rule not found")
+	}
+	return nil
+}
+
+// applyColumnRuleRequest validates a column rule request and copies it onto the rule
+func (s *HohAddressService) applyColumnRuleRequest(rule *models.HohAddressColumnRule, req *models.HohAddressColumnRuleRequest) error {
+	column := strings.TrimSpace(req.Column)
+	if column == "" {
+		return fmt.Errorf("column rule column is required")
+	}
+	if req.HohAddressDatabaseID != "" {
+		if _, err := s.GetDatabase(req.HohAddressDatabaseID); err != nil {
+			return err
+		}
+	}
+
+	var count int64
+	if err := s.db.Model(&models.HohAddressColumnRule{}).
+		Where("hohaddress_database_id = ? AND table_name = ? AND LOWER(column_name) = LOWER(?) AND id <> ?", req.HohAddressDatabaseID, req.Table, column, rule.ID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check column rule: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("column rule for this column already exists")
+	}
+
+	groups := models.StringList{}
+	for _, groupID := range req.Groups {
+		if groups.Contains(groupID) {
+			continue
+		}
+		if err := s.db.Model(&models.PermissionGroup{}).Where("id = ?", groupID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to find permission group: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("permission group not found")
+		}
+		groups = append(groups, groupID)
+	}
+
+	action := req.Action
+	if action == "" {
+		action = models.ColumnRuleActionReject
+	}
+
+	rule.HohAddressDatabaseID = req.HohAddressDatabaseID
+	rule.Table = req.Table
+	rule.Column = column
+	rule.Groups = groups
+	rule.Action = action
+	rule.Description = req.Description
+	return nil
+}
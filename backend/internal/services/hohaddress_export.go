@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"truadmin/internal/models"
+)
+
+// hohAddressExportTables lists the tracking tables that can be exported
+var hohAddressExportTables = map[string]bool{
+	"hohaddressstatuslist": true,
+	"hohaddressblacklist":  true,
+	"hohaddresswhitelist":  true,
+}
+
+// ExportTable streams all rows of a HohAddress table matching the same filters, filter expression,
+// row filters and sorting as the list endpoints. header receives the columns in display order before
+// the first row; rows are read from the server one at a time and never collected in memory.
+func (s *HohAddressService) ExportTable(ctx context.Context, hohAddressDatabaseID, tableName string, scope RowScope, filters map[string]string, filter *models.FilterExpression, sortBy, sortOrder string, header func([]string) error, row func([]interface{}) error) error {
+	if !hohAddressExportTables[tableName] {
+		return fmt.Errorf("table %s cannot be exported", tableName)
+	}
+
+	db, err := s.connectToDatabase(hohAddressDatabaseID)
+	if err != nil {
+		return err
+	}
+
+	columns, err := s.getOrderedColumns(ctx, db, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to get columns: %w", err)
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("table tracking.%s not found", tableName)
+	}
+
+	// Build WHERE clause the same way as the list endpoints
+	whereCondition, args, err := s.buildWhereClause(ctx, db, hohAddressDatabaseID, tableName, scope, filters, filter)
+	if err != nil {
+		return err
+	}
+	orderBy, err := buildOrderBy(columns, sortBy, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s",
+		quoteIdentifiers(columns), trackingTable(tableName), whereCondition, orderBy)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query tracking.%s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	if err := header(columns); err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		for i, val := range values {
+			if b, ok := val.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		if err := row(values); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"truadmin/internal/models"
+)
+
+// hohAddressImportMaxRows caps the data rows of a single CSV import
+const hohAddressImportMaxRows = 50000
+
+// ErrInvalidHohAddressImport is returned when an import file cannot be read as a whole
+var ErrInvalidHohAddressImport = errors.New("invalid import file")
+
+// hohAddressAutomaticColumns are filled on insert and ignored when present in an import file
+var hohAddressAutomaticColumns = map[string]bool{
+	"address1_upd": true,
+	"address2_upd": true,
+	"city_upd":     true,
+	"updatedby":    true,
+	"updatedon":    true,
+}
+
+// ImportWhitelist inserts the rows of a CSV file into tracking.hohaddresswhitelist
+func (s *HohAddressService) ImportWhitelist(ctx context.Context, hohAddressDatabaseID string, scope RowScope, r io.Reader, username string) (*models.HohAddressImportReport, error) {
+	return s.importRows(ctx, hohAddressDatabaseID, "hohaddresswhitelist", scope, r, username)
+}
+
+// ImportBlacklist inserts the rows of a CSV file into tracking.hohaddressblacklist
+func (s *HohAddressService) ImportBlacklist(ctx context.Context, hohAddressDatabaseID string, scope RowScope, r io.Reader, username string) (*models.HohAddressImportReport, error) {
+	return s.importRows(ctx, hohAddressDatabaseID, "hohaddressblacklist", scope, r, username)
+}
+
+// importRows inserts CSV rows one by one the way CreateWhitelistRow does: addresses are
+// normalized with the tracking.get_* functions and rows whose normalized address already exists
+// in the table or earlier in the file are skipped. The header names the table columns. Rows are
+// independent, so a failing row does not stop the import; rows outside the row filters of the
+// importing user fail. The column rules apply to the columns of the header: columns the user may
+// not set are left out, or the whole file is refused with a ColumnEditDeniedError.
+func (s *HohAddressService) importRows(ctx context.Context, hohAddressDatabaseID, tableName string, scope RowScope, r io.Reader, username string) (*models.HohAddressImportReport, error) {
+	db, err := s.connectToDatabase(hohAddressDatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	columnNames, err := getTrackingTableColumns(ctx, db, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(columnNames) == 0 {
+		return nil, fmt.Errorf("table tracking.%s not found", tableName)
+	}
+	tableColumns := map[string]bool{}
+	for _, colName := range columnNames {
+		tableColumns[colName] = true
+	}
+	scopeCondition, scopeArgs, err := s.editScopeCondition(ctx, db, hohAddressDatabaseID, tableName, scope, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidHohAddressImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHohAddressImport, err)
+	}
+
+	// Map the header to table columns; automatic columns are dropped (empty name)
+	fields := make([]string, len(header))
+	seenFields := map[string]bool{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		switch {
+		case name == "":
+			return nil, fmt.Errorf("%w: column %d has no name", ErrInvalidHohAddressImport, i+1)
+		case !tableColumns[name]:
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidHohAddressImport, name)
+		case seenFields[name]:
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidHohAddressImport, name)
+		}
+		seenFields[name] = true
+		if !hohAddressAutomaticColumns[name] {
+			fields[i] = name
+		}
+	}
+
+	// Every row sets the columns of the header, so the column rules are checked once for all rows
+	headerData := map[string]interface{}{}
+	for _, field := range fields {
+		if field != "" {
+			headerData[field] = nil
+		}
+	}
+	allowed, denied, err := s.AuthorizeColumnEdits(hohAddressDatabaseID, tableName, scope, headerData)
+	if err != nil {
+		return nil, err
+	}
+	for i, field := range fields {
+		if _, ok := allowed[field]; !ok {
+			fields[i] = ""
+		}
+	}
+
+	report := &models.HohAddressImportReport{
+		Table:           "tracking." + tableName,
+		Rows:            []models.HohAddressImportRow{},
+		StrippedColumns: denied,
+	}
+	addRow := func(row models.HohAddressImportRow) {
+		report.Total++
+		switch row.Status {
+		case models.HohAddressImportImported:
+			report.Imported++
+		case models.HohAddressImportDuplicate:
+			report.Duplicates++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	importedLines := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+
+		if err != nil {
+			// Rows with the wrong number of fields are reported; anything else leaves the
+			// rest of the file unreadable
+			if !errors.Is(err, csv.ErrFieldCount) {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidHohAddressImport, err)
+			}
+			addRow(models.HohAddressImportRow{Line: line, Status: models.HohAddressImportError, Error: fmt.Sprintf("expected %d fields, got %d", len(header), len(record))})
+			continue
+		}
+
+		data := map[string]interface{}{}
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if fields[i] == "" || value == "" {
+				continue // Empty cells keep the column default
+			}
+			data[fields[i]] = value
+		}
+		if len(data) == 0 {
+			continue // Blank line
+		}
+
+		if report.Total >= hohAddressImportMaxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidHohAddressImport, hohAddressImportMaxRows)
+		}
+
+		key, err := getHohAddressKey(ctx, db, data)
+		if err != nil {
+			addRow(models.HohAddressImportRow{Line: line, Status: models.HohAddressImportError, Error: err.Error()})
+			continue
+		}
+		keyStr := fmt.Sprintf("%#v", key)
+		if first, ok := importedLines[keyStr]; ok {
+			addRow(models.HohAddressImportRow{Line: line, Status: models.HohAddressImportDuplicate, Error: fmt.Sprintf("same address as line %d", first)})
+			continue
+		}
+
+		exists, err := hohAddressExists(ctx, db, tableName, key)
+		if err != nil {
+			addRow(models.HohAddressImportRow{Line: line, Status: models.HohAddressImportError, Error: err.Error()})
+			continue
+		}
+		if exists {
+			addRow(models.HohAddressImportRow{Line: line, Status: models.HohAddressImportDuplicate, Error: "a record with this combination of address1_upd, address2_upd, city_upd, city, state, and zip already exists"})
+			continue
+		}
+
+		row, err := s.insertScopedRow(ctx, db, hohAddressDatabaseID, tableName, scope, columnNames, data, username, scopeCondition, scopeArgs)
+		if err != nil {
+			addRow(models.HohAddressImportRow{Line: line, Status: models.HohAddressImportError, Error: err.Error()})
+			continue
+		}
+		importedLines[keyStr] = line
+		addRow(models.HohAddressImportRow{Line: line, Status: models.HohAddressImportImported, Row: row})
+	}
+
+	return report, nil
+}
@@ -1,29 +1,36 @@
 package services
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 
 	"truadmin/internal/database"
+	"truadmin/internal/logging"
 	"truadmin/internal/models"
 )
 
 // HohAddressLogService handles logging of HohAddress save operations
 type HohAddressLogService struct {
-	db *gorm.DB
+	db    *gorm.DB
+	audit *AuditService
 }
 
-// NewHohAddressLogService creates a new HohAddress log service
-func NewHohAddressLogService() *HohAddressLogService {
+// NewHohAddressLogService creates a new HohAddress log service; operations are also shipped to the audit sinks
+func NewHohAddressLogService(audit *AuditService) *HohAddressLogService {
 	return &HohAddressLogService{
-		db: database.GetDB(),
+		db:    database.GetDB(),
+		audit: audit,
 	}
 }
 
 // LogSaveOperation logs a save operation
 func (s *HohAddressLogService) LogSaveOperation(
+	ctx context.Context,
 	hohAddressDatabaseID string,
 	userID string,
 	status models.HohAddressSaveLogStatus,
@@ -40,35 +47,83 @@ func (s *HohAddressLogService) LogSaveOperation(
 		SQLScript:            sqlScript,
 		ErrorMessage:         errorMessage,
 		ExecutionTimeMs:      executionTimeMs,
+		RequestID:            logging.RequestID(ctx),
 		CreatedAt:            time.Now(),
 	}
 
+	s.audit.Record(models.AuditEvent{
+		Source:    "hohaddress",
+		Action:    "save",
+		Status:    models.AuditEventStatus(status),
+		ActorID:   userID,
+		TargetID:  hohAddressDatabaseID,
+		Message:   errorMessage,
+		RequestID: logging.RequestID(ctx),
+	})
+
 	if err := s.db.Create(&logEntry).Error; err != nil {
-		log.Printf("ERROR: Failed to log HohAddress save operation: %v", err)
-		log.Printf("  hohAddressDatabaseID: %s, userID: %s, status: %s", hohAddressDatabaseID, userID, status)
+		slog.ErrorContext(ctx, "failed to log HohAddress save operation", "hohaddress_database_id", hohAddressDatabaseID, "user_id", userID, "status", status, "error", err)
 		return err
 	}
 
-	log.Printf("✅ Logged HohAddress save operation: hohAddressDatabaseID=%s, userID=%s, status=%s, executionTime=%dms",
-		hohAddressDatabaseID, userID, status, executionTimeMs)
+	slog.InfoContext(ctx, "logged HohAddress save operation", "hohaddress_database_id", hohAddressDatabaseID, "user_id", userID, "status", status, "execution_time_ms", executionTimeMs)
 	return nil
 }
 
 // GetSaveLogs retrieves save logs for a specific HohAddress database
-func (s *HohAddressLogService) GetSaveLogs(hohAddressDatabaseID string, limit int) ([]models.HohAddressSaveLog, error) {
+func (s *HohAddressLogService) GetSaveLogs(hohAddressDatabaseID string, page Page) ([]models.HohAddressSaveLog, int64, error) {
 	var logs []models.HohAddressSaveLog
 
 	query := s.db.Where("hohaddress_database_id = ?", hohAddressDatabaseID).
 		Order("created_at DESC")
 
-	if limit > 0 {
-		query = query.Limit(limit)
+	total, err := findPage(query, page, &logs)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if err := query.Find(&logs).Error; err != nil {
-		return nil, err
+	return logs, total, nil
+}
+
+// ExportSaveLogs streams the save logs of a HohAddress database matching the filter, oldest first
+func (s *HohAddressLogService) ExportSaveLogs(hohAddressDatabaseID string, filter models.LogExportFilter, fn func(*models.HohAddressSaveLog) error) error {
+	return streamLogs(s.db.Where("hohaddress_database_id = ?", hohAddressDatabaseID), filter, "", fn)
+}
+
+// LogColumnViolation records an edit that tried to set columns the user may not edit
+func (s *HohAddressLogService) LogColumnViolation(ctx context.Context, violation *models.HohAddressColumnViolation) error {
+	violation.RequestID = logging.RequestID(ctx)
+
+	s.audit.Record(models.AuditEvent{
+		Source:    "hohaddress",
+		Action:    "column_violation",
+		Status:    models.AuditEventStatusError,
+		ActorID:   violation.UserID,
+		TargetID:  violation.HohAddressDatabaseID,
+		Message:   fmt.Sprintf("%s of %s: %s", violation.Action, violation.Table, strings.Join(violation.Columns, ", ")),
+		RequestID: violation.RequestID,
+	})
+
+	if err := s.db.Create(violation).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to log HohAddress column violation", "hohaddress_database_id", violation.HohAddressDatabaseID, "user_id", violation.UserID, "error", err)
+		return err
 	}
 
-	return logs, nil
+	slog.WarnContext(ctx, "blocked edit of protected HohAddress columns", "hohaddress_database_id", violation.HohAddressDatabaseID, "table", violation.Table, "user_id", violation.UserID, "columns", violation.Columns, "action", violation.Action)
+	return nil
 }
 
+// GetColumnViolations retrieves the column violations of a HohAddress database, newest first
+func (s *HohAddressLogService) GetColumnViolations(hohAddressDatabaseID string, page Page) ([]models.HohAddressColumnViolation, int64, error) {
+	var violations []models.HohAddressColumnViolation
+
+	query := s.db.Where("hohaddress_database_id = ?", hohAddressDatabaseID).
+		Order("created_at DESC")
+
+	total, err := findPage(query, page, &violations)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return violations, total, nil
+}
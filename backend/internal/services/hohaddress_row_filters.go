@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/models"
+)
+
+// RowScope identifies the user HohAddress lists are read or edited for. Admins see every row and
+// edit every column; other users are limited by the row filters and column rules that apply to
+// them or their permission groups.
+type RowScope struct {
+	UserID string
+	Admin  bool
+}
+
+// rowFilterCondition builds the condition limiting a table to the rows the user of scope may see,
+// with its values as parameters numbered from start. It is empty when no row filter applies.
+func (s *HohAddressService) rowFilterCondition(hohAddressDatabaseID, tableName string, scope RowScope, columnTypes map[string]string, start int) (string, []interface{}, error) {
+	if scope.Admin {
+		return "", nil, nil
+	}
+	rowFilters, err := s.rowFiltersOf(scope.UserID, hohAddressDatabaseID, tableName)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(rowFilters) == 0 {
+		return "", nil, nil
+	}
+
+	conditions := []string{}
+	args := []interface{}{}
+	for _, rowFilter := range rowFilters {
+		condition, filterArgs, err := buildFilterExpression(postgresDialect{}, columnTypes, &rowFilter.Filter.FilterExpression, start+len(args))
+		if err != nil {
+			// A filter on columns the table lacks matches nothing, so it never widens what the user sees
+			s.logger.Warn("row filter does not fit table", "row_filter", rowFilter.ID, "table", tableName, "error", err)
+			continue
+		}
+		conditions = append(conditions, condition)
+		args = append(args, filterArgs...)
+	}
+	if len(conditions) == 0 {
+		return "FALSE", args, nil
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args, nil
+}
+
+// ErrHohAddressRowNotFound is returned when a row to edit does not exist or lies outside the row
+// filters of the editor
+var ErrHohAddressRowNotFound = errors.New("row not found")
+
+// ErrOutsideRowFilter is returned when a row written by a user would fall outside their row filters
+var ErrOutsideRowFilter = errors.New("the row would fall outside the rows you may edit")
+
+// rowQuerier runs single-row statements on a database or within a transaction
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// editScopeCondition is rowFilterCondition for writes, reading the column types of the table only
+// when the editor is not an admin
+func (s *HohAddressService) editScopeCondition(ctx context.Context, db *sql.DB, hohAddressDatabaseID, tableName string, scope RowScope, start int) (string, []interface{}, error) {
+	if scope.Admin {
+		return "", nil, nil
+	}
+	columnTypes, err := getTrackingColumnTypes(ctx, db, tableName)
+	if err != nil {
+		return "", nil, err
+	}
+	return s.rowFilterCondition(hohAddressDatabaseID, tableName, scope, columnTypes, start)
+}
+
+// beginScopedWrite returns where a write limited by a row filter condition runs: the database
+// itself when there is no condition, otherwise a transaction that finishScopedWrite commits
+func beginScopedWrite(ctx context.Context, db *sql.DB, condition string) (rowQuerier, *sql.Tx, error) {
+	if condition == "" {
+		return db, nil, nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return tx, tx, nil
+}
+
+// finishScopedWrite commits a write begun by beginScopedWrite once the written row, whose keyColumn
+// holds key, still matches the condition, whose parameters are numbered from 2. Otherwise it
+// returns ErrOutsideRowFilter and the caller rolls the write back. A nil tx has nothing to check.
+func finishScopedWrite(ctx context.Context, tx *sql.Tx, tableName, keyColumn string, key interface{}, condition string, args []interface{}) error {
+	if tx == nil {
+		return nil
+	}
+
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = $1 AND %s", trackingTable(tableName), quotePostgresName(keyColumn), condition)
+	var count int
+	if err := tx.QueryRowContext(ctx, query, append([]interface{}{key}, args...)...).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check row filters: %w", err)
+	}
+	if count == 0 {
+		return ErrOutsideRowFilter
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// insertScopedRow inserts a row with insertHohAddressRow, keeping it only when it matches the row
+// filter condition of the editor, whose parameters are numbered from 2. The kept row is added to
+// the row edit log.
+func (s *HohAddressService) insertScopedRow(ctx context.Context, db *sql.DB, hohAddressDatabaseID, tableName string, scope RowScope, columnNames []string, data map[string]interface{}, username, condition string, args []interface{}) (map[string]interface{}, error) {
+	keyColumn, err := getTrackingKeyColumn(ctx, db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	q, tx, err := beginScopedWrite(ctx, db, condition)
+	if err != nil {
+		return nil, err
+	}
+	if tx != nil {
+		defer tx.Rollback()
+	}
+	row, err := insertHohAddressRow(ctx, q, tableName, columnNames, data, username)
+	if err != nil {
+		return nil, err
+	}
+	if err := finishScopedWrite(ctx, tx, tableName, keyColumn, row[keyColumn], condition, args); err != nil {
+		return nil, err
+	}
+
+	s.logRowEdit(hohAddressDatabaseID, tableName, keyColumn, models.RowEditInsert, scope, nil, row)
+	return row, nil
+}
+
+// getTrackingKeyColumn returns the primary key column of a tracking table, or its first column when
+// it has no primary key
+func getTrackingKeyColumn(ctx context.Context, db *sql.DB, tableName string) (string, error) {
+	primaryKey, err := getTrackingPrimaryKey(ctx, db, tableName)
+	if err != nil {
+		return "", err
+	}
+	if len(primaryKey) > 0 {
+		return primaryKey[0], nil
+	}
+	columnNames, err := getTrackingTableColumns(ctx, db, tableName)
+	if err != nil {
+		return "", err
+	}
+	if len(columnNames) == 0 {
+		return "", fmt.Errorf("table tracking.%s not found", tableName)
+	}
+	return columnNames[0], nil
+}
+
+// rowFiltersOf returns the row filters of a user and their permission groups on a table of a database
+func (s *HohAddressService) rowFiltersOf(userID, hohAddressDatabaseID, tableName string) ([]models.HohAddressRowFilter, error) {
+	groups, err := permissionGroupsOf(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	groupIDs := make([]string, len(groups))
+	for i, group := range groups {
+		groupIDs[i] = group.ID
+	}
+
+	query := s.db.Where("hohaddress_database_id = '' OR hohaddress_database_id = ?", hohAddressDatabaseID).
+		Where("table_name = '' OR table_name = ?", tableName)
+	if len(groupIDs) > 0 {
+		query = query.Where("user_id = ? OR group_id IN ?", userID, groupIDs)
+	} else {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var rowFilters []models.HohAddressRowFilter
+	if err := query.Find(&rowFilters).Error; err != nil {
+		return nil, fmt.Errorf("failed to get row filters: %w", err)
+	}
+	return rowFilters, nil
+}
+
+// GetRowFilters returns the row filters, optionally only those of a user or a permission group
+func (s *HohAddressService) GetRowFilters(userID, groupID string) ([]models.HohAddressRowFilter, error) {
+	rowFilters := []models.HohAddressRowFilter{}
+
+	query := s.db.Order("created_at")
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if groupID != "" {
+		query = query.Where("group_id = ?", groupID)
+	}
+	if err := query.Find(&rowFilters).Error; err != nil {
+		return nil, fmt.Errorf("failed to get row filters: %w", err)
+	}
+	return rowFilters, nil
+}
+
+// GetRowFilter returns a row filter by ID
+func (s *HohAddressService) GetRowFilter(id string) (*models.HohAddressRowFilter, error) {
+	var rowFilter models.HohAddressRowFilter
+	if err := s.db.First(&rowFilter, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("row filter not found")
+		}
+		return nil, fmt.Errorf("failed to get row filter: %w", err)
+	}
+	return &rowFilter, nil
+}
+
+// CreateRowFilter creates a row filter
+func (s *HohAddressService) CreateRowFilter(req *models.HohAddressRowFilterRequest, createdBy string) (*models.HohAddressRowFilter, error) {
+	rowFilter := &models.HohAddressRowFilter{ID: uuid.New().String(), CreatedBy: createdBy}
+	if err := s.applyRowFilterRequest(rowFilter, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(rowFilter).Error; err != nil {
+		return nil, fmt.Errorf("failed to create row filter: %w", err)
+	}
+	return rowFilter, nil
+}
+
+// UpdateRowFilter replaces the owner, database, table, filter and description of a row filter
+func (s *HohAddressService) UpdateRowFilter(id string, req *models.HohAddressRowFilterRequest) (*models.HohAddressRowFilter, error) {
+	rowFilter, err := s.GetRowFilter(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRowFilterRequest(rowFilter, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(rowFilter).Error; err != nil {
+		return nil, fmt.Errorf("failed to update row filter: %w", err)
+	}
+	return rowFilter, nil
+}
+
+// DeleteRowFilter deletes a row filter
+func (s *HohAddressService) DeleteRowFilter(id string) error {
+	result := s.db.Delete(&models.HohAddressRowFilter{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete row filter: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("row filter not found")
+	}
+	return nil
+}
+
+// applyRowFilterRequest validates a row filter request and copies it onto the row filter. The filter
+// is checked for its structure only, as the columns of the tables may differ between databases.
+func (s *HohAddressService) applyRowFilterRequest(rowFilter *models.HohAddressRowFilter, req *models.HohAddressRowFilterRequest) error {
+	if (req.UserID == "") == (req.GroupID == "") {
+		return fmt.Errorf("a row filter needs either a user_id or a group_id")
+	}
+
+	var count int64
+	if req.UserID != "" {
+		if err := s.db.Model(&models.User{}).Where("id = ?", req.UserID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to find user: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("user not found")
+		}
+	} else {
+		if err := s.db.Model(&models.PermissionGroup{}).Where("id = ?", req.GroupID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to find permission group: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("permission group not found")
+		}
+	}
+	if req.HohAddressDatabaseID != "" {
+		if _, err := s.GetDatabase(req.HohAddressDatabaseID); err != nil {
+			return err
+		}
+	}
+
+	columnTypes := map[string]string{}
+	collectFilterFields(&req.Filter, columnTypes)
+	if _, _, err := buildFilterExpression(postgresDialect{}, columnTypes, &req.Filter, 1); err != nil {
+		return err
+	}
+
+	rowFilter.UserID = req.UserID
+	rowFilter.GroupID = req.GroupID
+	rowFilter.HohAddressDatabaseID = req.HohAddressDatabaseID
+	rowFilter.Table = req.Table
+	rowFilter.Filter = models.StoredFilterExpression{FilterExpression: req.Filter}
+	rowFilter.Description = req.Description
+	return nil
+}
+
+// collectFilterFields adds the fields of an expression and its nested groups to columnTypes as text
+// columns, so the expression can be built without a table
+func collectFilterFields(expr *models.FilterExpression, columnTypes map[string]string) {
+	if expr.Field != "" {
+		columnTypes[expr.Field] = "text"
+	}
+	for i := range expr.And {
+		collectFilterFields(&expr.And[i], columnTypes)
+	}
+	for i := range expr.Or {
+		collectFilterFields(&expr.Or[i], columnTypes)
+	}
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"truadmin/internal/models"
+)
+
+// newTestHohAddressService returns a HohAddressService with a registered database whose server is a
+// go-sqlmock database. The user "analyst" may only see and edit rows whose state is TX.
+func newTestHohAddressService(t *testing.T) (*HohAddressService, string, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db := newTestInternalDB(t, &models.Connection{}, &models.HohAddressDatabase{}, &models.HohAddressRowFilter{}, &models.HohAddressColumnRule{}, &models.PermissionGroup{}, &models.TableRowEditLog{})
+	mock := newMockPostgres(t)
+
+	conn := &models.Connection{ID: "conn-1", Name: "primary", Type: "postgres", Host: "localhost", Port: 5432, Database: "app", Username: "admin", Password: "secret", SSLMode: "disable"}
+	registration := &models.HohAddressDatabase{ID: "hoh-1", ConnectionID: conn.ID, DatabaseName: "addresses", DisplayName: "Addresses"}
+	rowFilter := &models.HohAddressRowFilter{ID: "filter-1", UserID: "analyst"}
+	rowFilter.Filter.FilterExpression = models.FilterExpression{Field: "state", Op: "eq", Value: "TX"}
+	for _, record := range []interface{}{conn, registration, rowFilter} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("failed to seed %T: %v", record, err)
+		}
+	}
+
+	// go-sqlmock serves a single driver connection, so the pool must keep it
+	pools := NewConnectionPoolManager(PoolConfig{MaxOpenConns: 1, MaxIdleConns: 1}, nil)
+	t.Cleanup(pools.Close)
+	return NewHohAddressService(NewConnectionService(pools, nil), NewTableRowLogService(nil), nil), registration.ID, mock
+}
+
+// analystScope is the editor limited by the row filter of newTestHohAddressService
+var analystScope = RowScope{UserID: "analyst"}
+
+// hohAddressTestColumns are the columns of the mocked tracking tables
+var hohAddressTestColumns = []string{"id", "address1", "address1_upd", "address2_upd", "city_upd", "city", "state", "zip", "updatedby", "updatedon"}
+
+// expectTrackingColumns expects the column and column type lookups of a tracking table
+func expectTrackingColumns(mock sqlmock.Sqlmock, tableName string, withNames bool) {
+	if withNames {
+		names := sqlmock.NewRows([]string{"column_name"})
+		for _, column := range hohAddressTestColumns {
+			names.AddRow(column)
+		}
+		mock.ExpectQuery(`SELECT column_name\s+FROM information_schema.columns\s+WHERE table_schema = 'tracking'\s+AND table_name = ('` + tableName + `'|\$1)`).WillReturnRows(names)
+	}
+	types := sqlmock.NewRows([]string{"column_name", "data_type"})
+	for _, column := range hohAddressTestColumns {
+		dataType := "text"
+		if column == "id" {
+			dataType = "integer"
+		}
+		types.AddRow(column, dataType)
+	}
+	mock.ExpectQuery("SELECT column_name, data_type").WithArgs(tableName).WillReturnRows(types)
+}
+
+// hohAddressTestRow is a row of a mocked tracking table in the state the test gives
+func hohAddressTestRow(id int64, state string) *sqlmock.Rows {
+	return sqlmock.NewRows(hohAddressTestColumns).
+		AddRow(id, "1 Main St", "1 MAIN ST", "", "AUSTIN", "Austin", state, "78701", "analyst", nil)
+}
+
+func TestDeleteRowOutsideRowFilter(t *testing.T) {
+	svc, id, mock := newTestHohAddressService(t)
+
+	mock.ExpectQuery("FROM information_schema.table_constraints").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id"))
+	expectTrackingColumns(mock, "hohaddresswhitelist", false)
+	mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM tracking.hohaddresswhitelist WHERE "id" = $1 AND ("state" = $2) RETURNING *`)).
+		WithArgs("7", "TX").
+		WillReturnRows(sqlmock.NewRows(hohAddressTestColumns))
+
+	if err := svc.DeleteWhitelistRow(context.Background(), id, analystScope, "7"); !errors.Is(err, ErrHohAddressRowNotFound) {
+		t.Errorf("DeleteWhitelistRow() error = %v, want %v", err, ErrHohAddressRowNotFound)
+	}
+
+	// Admins are not limited by row filters
+	mock.ExpectQuery("FROM information_schema.table_constraints").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id"))
+	mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM tracking.hohaddresswhitelist WHERE "id" = $1 RETURNING *`)).
+		WithArgs("7").
+		WillReturnRows(hohAddressTestRow(7, "OK"))
+
+	if err := svc.DeleteWhitelistRow(context.Background(), id, RowScope{UserID: "admin", Admin: true}, "7"); err != nil {
+		t.Errorf("DeleteWhitelistRow() as admin error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUpdateRowOutsideRowFilter(t *testing.T) {
+	svc, id, mock := newTestHohAddressService(t)
+	currentRow := regexp.QuoteMeta(`FROM tracking.hohaddressblacklist WHERE "id" = $1 AND ("state" = $2) FOR UPDATE`)
+
+	// A row the editor cannot see is not found
+	mock.ExpectQuery("FROM information_schema.table_constraints").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id"))
+	expectTrackingColumns(mock, "hohaddressblacklist", true)
+	mock.ExpectBegin()
+	mock.ExpectQuery(currentRow).WithArgs("7", "TX").
+		WillReturnRows(sqlmock.NewRows(hohAddressTestColumns))
+	mock.ExpectRollback()
+
+	if _, err := svc.UpdateBlacklistRow(context.Background(), id, analystScope, "7", map[string]interface{}{"zip": "78702"}, "analyst"); !errors.Is(err, ErrHohAddressRowNotFound) {
+		t.Errorf("UpdateBlacklistRow() of a hidden row error = %v, want %v", err, ErrHohAddressRowNotFound)
+	}
+
+	// A visible row cannot be moved outside the row filter
+	mock.ExpectQuery("FROM information_schema.table_constraints").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id"))
+	expectTrackingColumns(mock, "hohaddressblacklist", true)
+	mock.ExpectBegin()
+	mock.ExpectQuery(currentRow).WithArgs("8", "TX").
+		WillReturnRows(hohAddressTestRow(8, "TX"))
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM tracking.hohaddressblacklist\s+WHERE address1_upd = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`UPDATE tracking.hohaddressblacklist SET`).
+		WillReturnRows(hohAddressTestRow(8, "OK"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM "tracking"."hohaddressblacklist" WHERE "id" = $1 AND ("state" = $2)`)).
+		WithArgs("8", "TX").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+
+	if _, err := svc.UpdateBlacklistRow(context.Background(), id, analystScope, "8", map[string]interface{}{"state": "OK"}, "analyst"); !errors.Is(err, ErrOutsideRowFilter) {
+		t.Errorf("UpdateBlacklistRow() out of the row filter error = %v, want %v", err, ErrOutsideRowFilter)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateRowOutsideRowFilter(t *testing.T) {
+	svc, id, mock := newTestHohAddressService(t)
+
+	expectTrackingColumns(mock, "hohaddressblacklist", true)
+	mock.ExpectQuery("SELECT kcu.column_name").WithArgs("hohaddressblacklist").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "tracking"."hohaddressblacklist"`).
+		WillReturnRows(hohAddressTestRow(9, "OK"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM "tracking"."hohaddressblacklist" WHERE "id" = $1 AND ("state" = $2)`)).
+		WithArgs(int64(9), "TX").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+
+	_, err := svc.CreateBlacklistRow(context.Background(), id, analystScope, map[string]interface{}{"city": "Austin", "state": "OK"}, "analyst")
+	if !errors.Is(err, ErrOutsideRowFilter) {
+		t.Errorf("CreateBlacklistRow() error = %v, want %v", err, ErrOutsideRowFilter)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportRowsOutsideRowFilter(t *testing.T) {
+	svc, id, mock := newTestHohAddressService(t)
+
+	expectTrackingColumns(mock, "hohaddressblacklist", true)
+	for i, state := range []string{"OK", "TX"} {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT tracking.get_hohaddress1($1)")).
+			WillReturnRows(sqlmock.NewRows([]string{"address1_upd"}).AddRow("1 MAIN ST"))
+		mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM "tracking"."hohaddressblacklist"\s+WHERE address1_upd = \$1`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("SELECT kcu.column_name").WithArgs("hohaddressblacklist").
+			WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id"))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tracking"."hohaddressblacklist"`).
+			WillReturnRows(hohAddressTestRow(int64(10+i), state))
+		matching := 0
+		if state == "TX" {
+			matching = 1
+		}
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM "tracking"."hohaddressblacklist" WHERE "id" = $1 AND ("state" = $2)`)).
+			WithArgs(int64(10+i), "TX").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(matching))
+		if state == "TX" {
+			mock.ExpectCommit()
+		} else {
+			mock.ExpectRollback()
+		}
+	}
+
+	report, err := svc.ImportBlacklist(context.Background(), id, analystScope, strings.NewReader("address1,state\n1 Main St,OK\n1 Main St,TX\n"), "analyst")
+	if err != nil {
+		t.Fatalf("ImportBlacklist() error = %v", err)
+	}
+	if report.Imported != 1 || report.Failed != 1 || report.Rows[0].Error != ErrOutsideRowFilter.Error() {
+		t.Errorf("ImportBlacklist() = %+v", report)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportRowsColumnRules(t *testing.T) {
+	svc, id, mock := newTestHohAddressService(t)
+	for _, rule := range []*models.HohAddressColumnRule{
+		{ID: "rule-1", HohAddressDatabaseID: id, Table: "hohaddressblacklist", Column: "zip", Action: models.ColumnRuleActionReject},
+		{ID: "rule-2", Table: "hohaddressblacklist", Column: "city", Action: models.ColumnRuleActionStrip},
+	} {
+		if err := svc.db.Create(rule).Error; err != nil {
+			t.Fatalf("failed to seed column rule: %v", err)
+		}
+	}
+
+	// A rejected column refuses the whole file before any row is inserted
+	expectTrackingColumns(mock, "hohaddressblacklist", true)
+	var deniedErr *ColumnEditDeniedError
+	_, err := svc.ImportBlacklist(context.Background(), id, analystScope, strings.NewReader("address1,state,zip\n1 Main St,TX,78702\n"), "analyst")
+	if !errors.As(err, &deniedErr) || len(deniedErr.Columns) != 1 || deniedErr.Columns[0] != "zip" {
+		t.Fatalf("ImportBlacklist() with a rejected column error = %v, want a ColumnEditDeniedError for zip", err)
+	}
+
+	// A stripped column is left out of every row
+	expectTrackingColumns(mock, "hohaddressblacklist", true)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT tracking.get_hohaddress1($1)")).
+		WillReturnRows(sqlmock.NewRows([]string{"address1_upd"}).AddRow("1 MAIN ST"))
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM "tracking"."hohaddressblacklist"\s+WHERE address1_upd = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT kcu.column_name").WithArgs("hohaddressblacklist").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "tracking"."hohaddressblacklist" \("address1", "address1_upd", "state", "updatedby", "updatedon"\)`).
+		WillReturnRows(hohAddressTestRow(12, "TX"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM "tracking"."hohaddressblacklist" WHERE "id" = $1 AND ("state" = $2)`)).
+		WithArgs(int64(12), "TX").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectCommit()
+
+	report, err := svc.ImportBlacklist(context.Background(), id, analystScope, strings.NewReader("address1,city,state\n1 Main St,Austin,TX\n"), "analyst")
+	if err != nil {
+		t.Fatalf("ImportBlacklist() with a stripped column error = %v", err)
+	}
+	if report.Imported != 1 || len(report.StrippedColumns) != 1 || report.StrippedColumns[0] != "city" {
+		t.Errorf("ImportBlacklist() = %+v", report)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
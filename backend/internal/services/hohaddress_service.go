@@ -1,17 +1,19 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"strings"
+	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq" // PostgreSQL driver
 	"gorm.io/gorm"
 
 	"truadmin/internal/database"
+	"truadmin/internal/logging"
 	"truadmin/internal/models"
 )
 
@@ -19,18 +21,22 @@ import (
 type HohAddressService struct {
 	db                *gorm.DB
 	connectionService *ConnectionService
+	rowLogs           *TableRowLogService // Row edit log of the whitelist and blacklist writes; nil keeps none
+	logger            *slog.Logger
 }
 
-// NewHohAddressService creates a new HohAddress service
-func NewHohAddressService(connectionService *ConnectionService) *HohAddressService {
+// NewHohAddressService creates a new HohAddress service; logger defaults to the default logger when nil
+func NewHohAddressService(connectionService *ConnectionService, rowLogs *TableRowLogService, logger *slog.Logger) *HohAddressService {
 	return &HohAddressService{
 		db:                database.GetDB(),
 		connectionService: connectionService,
+		rowLogs:           rowLogs,
+		logger:            logging.OrDefault(logger),
 	}
 }
 
 // GetEligibleDatabases returns databases that have tracking schema and tables starting with hohaddress
-func (s *HohAddressService) GetEligibleDatabases(connectionID string) ([]models.Database, error) {
+func (s *HohAddressService) GetEligibleDatabases(ctx context.Context, connectionID string) ([]models.Database, error) {
 	// Get connection
 	conn, err := s.connectionService.GetConnection(connectionID)
 	if err != nil {
@@ -43,14 +49,10 @@ func (s *HohAddressService) GetEligibleDatabases(connectionID string) ([]models.
 	}
 
 	// Connect to the database
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		conn.Host, conn.Port, conn.Username, conn.Password, conn.Database, conn.SSLMode)
-
-	db, err := sql.Open("postgres", connStr)
+	db, _, err := s.connectionService.pools.get(conn, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
-	defer db.Close()
 
 	// Get list of all databases
 	query := `
@@ -61,7 +63,7 @@ func (s *HohAddressService) GetEligibleDatabases(connectionID string) ([]models.
 		ORDER BY datname
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get databases: %w", err)
 	}
@@ -77,26 +79,15 @@ func (s *HohAddressService) GetEligibleDatabases(connectionID string) ([]models.
 			continue
 		}
 
-		fmt.Printf("Checking database: %s\n", dbName)
+		s.logger.DebugContext(ctx, "checking database", "database", dbName)
 
 		// Connect to each database to check for tracking schema and hohaddress tables
-		dbConnStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-			conn.Host, conn.Port, conn.Username, conn.Password, dbName, conn.SSLMode)
-
-		dbConn, err := sql.Open("postgres", dbConnStr)
+		dbConn, _, err := s.connectionService.pools.get(conn, dbName)
 		if err != nil {
-			fmt.Printf("Failed to open connection to database %s: %v\n", dbName, err)
+			s.logger.WarnContext(ctx, "failed to connect to database", "database", dbName, "error", err)
 			continue
 		}
 
-		// Actually establish the connection
-		if err := dbConn.Ping(); err != nil {
-			fmt.Printf("Failed to ping database %s: %v\n", dbName, err)
-			dbConn.Close()
-			continue
-		}
-		fmt.Printf("Successfully connected to database: %s\n", dbName)
-
 		// Check if tracking schema exists and has tables starting with hohaddress (case-insensitive)
 		checkQuery := `
 			SELECT EXISTS (
@@ -108,30 +99,24 @@ func (s *HohAddressService) GetEligibleDatabases(connectionID string) ([]models.
 		`
 
 		var exists bool
-		if err := dbConn.QueryRow(checkQuery).Scan(&exists); err != nil {
-			// Log error for debugging
-			fmt.Printf("Error checking database %s: %v\n", dbName, err)
-			dbConn.Close()
+		if err := dbConn.QueryRowContext(ctx, checkQuery).Scan(&exists); err != nil {
+			s.logger.WarnContext(ctx, "failed to check database", "database", dbName, "error", err)
 			continue
 		}
 
-		fmt.Printf("Database %s - tracking schema with hohaddress* tables exists: %v\n", dbName, exists)
+		s.logger.DebugContext(ctx, "checked database for tracking.hohaddress* tables", "database", dbName, "eligible", exists)
 
 		if !exists {
-			dbConn.Close()
 			continue
 		}
 
-		dbConn.Close()
-
 		// Add to eligible databases
-		fmt.Printf("✓ Adding database %s to eligible list\n", dbName)
 		eligibleDatabases = append(eligibleDatabases, models.Database{
 			Name: dbName,
 		})
 	}
 
-	fmt.Printf("Total eligible databases found: %d\n", len(eligibleDatabases))
+	s.logger.DebugContext(ctx, "found eligible databases", "connection_id", connectionID, "count", len(eligibleDatabases))
 	return eligibleDatabases, nil
 }
 
@@ -182,6 +167,86 @@ func (s *HohAddressService) AddDatabase(req *models.HohAddressDatabaseRequest) (
 	return hohAddressDB, nil
 }
 
+// InitializeSchema creates the tracking schema with its tables and functions in a database of a
+// connection, which makes the database eligible for HohAddress
+func (s *HohAddressService) InitializeSchema(ctx context.Context, connectionID string, req *models.SchemaBootstrapRequest, userID string) (*models.SchemaBootstrapLog, error) {
+	conn, err := s.connectionService.GetConnection(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	if conn.Type != "postgres" {
+		return nil, fmt.Errorf("only PostgreSQL databases are supported for HohAddress")
+	}
+
+	return s.connectionService.initializeModuleSchema(ctx, hohAddressSchema, conn, req, userID)
+}
+
+// GetSchemaLogs retrieves the tracking schema initializations on a connection
+func (s *HohAddressService) GetSchemaLogs(connectionID string, page Page) ([]models.SchemaBootstrapLog, int64, error) {
+	return s.connectionService.GetSchemaBootstrapLogs(connectionID, hohAddressSchema.module, page)
+}
+
+// AddEligibleDatabases adds the selected eligible databases of a connection to HohAddress, or all of them
+// when none are selected. Databases already registered, by name or as the alias of a re-pointed
+// registration, are skipped; the other databases are added one by one and fail on their own.
+func (s *HohAddressService) AddEligibleDatabases(ctx context.Context, req *models.BulkDatabaseRegistrationRequest) (*models.BulkDatabaseRegistrationResult, error) {
+	eligible, err := s.GetEligibleDatabases(ctx, req.ConnectionID)
+	if err != nil {
+		return nil, err
+	}
+	isEligible := make(map[string]bool, len(eligible))
+	allEligible := []string{}
+	for _, database := range eligible {
+		isEligible[database.Name] = true
+		allEligible = append(allEligible, database.Name)
+	}
+	names := req.Databases
+	if len(names) == 0 {
+		names = allEligible
+	}
+
+	var registered []models.HohAddressDatabase
+	if err := s.db.Where("connection_id = ?", req.ConnectionID).Find(&registered).Error; err != nil {
+		return nil, fmt.Errorf("failed to get HohAddress databases: %w", err)
+	}
+	taken := map[string]bool{}
+	for _, hohAddressDB := range registered {
+		taken[hohAddressDB.DatabaseName] = true
+		taken[hohAddressDB.ConnectedDatabase()] = true
+	}
+
+	result := &models.BulkDatabaseRegistrationResult{Items: []models.BulkItemResult{}}
+	for _, name := range names {
+		item := models.BulkItemResult{Item: name}
+		switch {
+		case taken[name]:
+			item.Status = "skipped"
+			item.Error = "database already added to HohAddress"
+			result.Skipped++
+		case !isEligible[name]:
+			item.Status = "error"
+			item.Error = "database is not eligible for HohAddress"
+			result.Failed++
+		default:
+			added, err := s.AddDatabase(&models.HohAddressDatabaseRequest{ConnectionID: req.ConnectionID, DatabaseName: name})
+			if err != nil {
+				item.Status = "error"
+				item.Error = err.Error()
+				result.Failed++
+				break
+			}
+			item.Status = "success"
+			item.Result = added
+			result.Added++
+		}
+		taken[name] = true
+		result.Items = append(result.Items, item)
+	}
+
+	s.logger.InfoContext(ctx, "bulk added HohAddress databases", "connection_id", req.ConnectionID, "added", result.Added, "skipped", result.Skipped, "failed", result.Failed)
+	return result, nil
+}
+
 // GetDatabases retrieves all HohAddress databases with connection details
 func (s *HohAddressService) GetDatabases() ([]models.HohAddressDatabaseWithConnection, error) {
 	var hohAddressDatabases []models.HohAddressDatabase
@@ -270,6 +335,11 @@ func (s *HohAddressService) UpdateDatabase(id string, req *models.HohAddressData
 		displayName = req.DatabaseName
 	}
 
+	// A new database name or connection replaces any alias set by re-pointing
+	if req.ConnectionID != hohAddressDB.ConnectionID || req.DatabaseName != hohAddressDB.DatabaseName {
+		hohAddressDB.DatabaseAlias = ""
+	}
+
 	// Update fields
 	hohAddressDB.ConnectionID = req.ConnectionID
 	hohAddressDB.DatabaseName = req.DatabaseName
@@ -296,6 +366,59 @@ func (s *HohAddressService) DeleteDatabase(id string) error {
 	return nil
 }
 
+// RepointDatabase switches a HohAddress database to another database, for clusters that name databases
+// per environment. The registration keeps its database name, with the new database as its alias,
+// and only switches when the new database has the tracking schema of the database it replaces.
+func (s *HohAddressService) RepointDatabase(ctx context.Context, id string, req *models.RepointDatabaseRequest) (*models.HohAddressDatabase, error) {
+	var hohAddressDB models.HohAddressDatabase
+	if err := s.db.First(&hohAddressDB, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("HohAddress database not found")
+		}
+		return nil, fmt.Errorf("failed to get HohAddress database: %w", err)
+	}
+
+	connectionID := req.ConnectionID
+	if connectionID == "" {
+		connectionID = hohAddressDB.ConnectionID
+	}
+	conn, err := s.connectionService.GetConnection(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	if conn.Type != "postgres" {
+		return nil, fmt.Errorf("only PostgreSQL databases are supported for HohAddress")
+	}
+
+	// Check if another database already uses the new database
+	var existing models.HohAddressDatabase
+	if err := s.db.Where("connection_id = ? AND (database_name = ? OR database_alias = ?) AND id != ?", connectionID, req.DatabaseName, req.DatabaseName, id).First(&existing).Error; err == nil {
+		return nil, fmt.Errorf("database already added to HohAddress")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check existing database: %w", err)
+	}
+
+	// The current connection may be gone; the check then falls back to the required tables
+	current, _ := s.connectionService.GetConnection(hohAddressDB.ConnectionID)
+	if err := s.connectionService.checkRepointTarget(ctx, hohAddressSchema, current, hohAddressDB.ConnectedDatabase(), conn, req.DatabaseName); err != nil {
+		return nil, err
+	}
+
+	alias := req.DatabaseName
+	if alias == hohAddressDB.DatabaseName {
+		alias = ""
+	}
+	hohAddressDB.ConnectionID = connectionID
+	hohAddressDB.DatabaseAlias = alias
+	hohAddressDB.UpdatedAt = time.Now()
+	if err := s.db.Save(&hohAddressDB).Error; err != nil {
+		return nil, fmt.Errorf("failed to update HohAddress database: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "re-pointed HohAddress database", "id", id, "database", hohAddressDB.DatabaseName, "connected_database", hohAddressDB.ConnectedDatabase())
+	return &hohAddressDB, nil
+}
+
 // connectToDatabase connects to the specific HohAddress database
 func (s *HohAddressService) connectToDatabase(hohAddressDatabaseID string) (*sql.DB, error) {
 	// Get HohAddress database info
@@ -311,10 +434,7 @@ func (s *HohAddressService) connectToDatabase(hohAddressDatabaseID string) (*sql
 	}
 
 	// Connect to the specific database
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		conn.Host, conn.Port, conn.Username, conn.Password, hohAddressDB.DatabaseName, conn.SSLMode)
-
-	db, err := sql.Open("postgres", connStr)
+	db, _, err := s.connectionService.pools.get(conn, hohAddressDB.ConnectedDatabase())
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -323,20 +443,19 @@ func (s *HohAddressService) connectToDatabase(hohAddressDatabaseID string) (*sql
 }
 
 // GetTableColumns retrieves column names for a table in the correct display order
-func (s *HohAddressService) GetTableColumns(hohAddressDatabaseID string, tableName string) ([]string, error) {
+func (s *HohAddressService) GetTableColumns(ctx context.Context, hohAddressDatabaseID string, tableName string) ([]string, error) {
 	db, err := s.connectToDatabase(hohAddressDatabaseID)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
 	// Use the same ordering logic as getOrderedColumns
-	return s.getOrderedColumns(db, tableName)
+	return s.getOrderedColumns(ctx, db, tableName)
 }
 
 // getOrderedColumns retrieves column names in the correct order from information_schema
 // Returns columns in a logical display order: ID first, then address fields, then metadata
-func (s *HohAddressService) getOrderedColumns(db *sql.DB, tableName string) ([]string, error) {
+func (s *HohAddressService) getOrderedColumns(ctx context.Context, db *sql.DB, tableName string) ([]string, error) {
 	query := `
 		SELECT column_name 
 		FROM information_schema.columns 
@@ -344,7 +463,7 @@ func (s *HohAddressService) getOrderedColumns(db *sql.DB, tableName string) ([]s
 		AND table_name = $1
 		ORDER BY ordinal_position
 	`
-	rows, err := db.Query(query, tableName)
+	rows, err := db.QueryContext(ctx, query, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
@@ -402,165 +521,85 @@ func (s *HohAddressService) getOrderedColumns(db *sql.DB, tableName string) ([]s
 	return orderedColumns, nil
 }
 
-// buildWhereClause builds a WHERE clause with proper type handling for filters
-func (s *HohAddressService) buildWhereClause(db *sql.DB, tableName string, filters map[string]string) (string, []interface{}, error) {
+// buildWhereClause builds a WHERE clause with proper type handling for the search filters and
+// the filter expression (nil for none); both are validated against the columns of the table.
+// The row filters of the user of scope are always added.
+func (s *HohAddressService) buildWhereClause(ctx context.Context, db *sql.DB, hohAddressDatabaseID, tableName string, scope RowScope, filters map[string]string, filter *models.FilterExpression) (string, []interface{}, error) {
 	// Get column types to determine appropriate filter operator
-	columnTypes := make(map[string]string)
-	typeRows, err := db.Query(fmt.Sprintf(`
-		SELECT column_name, data_type 
-		FROM information_schema.columns 
-		WHERE table_schema = 'tracking' 
-		AND table_name = '%s'
-	`, tableName))
+	columnTypes, err := getTrackingColumnTypes(ctx, db, tableName)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to get column types: %w", err)
-	}
-	defer typeRows.Close()
-	
-	for typeRows.Next() {
-		var colName, dataType string
-		if err := typeRows.Scan(&colName, &dataType); err == nil {
-			columnTypes[colName] = dataType
-		}
+		return "", nil, err
 	}
 
-	// Check if all filter values are the same (general search)
-	allSameValue := ""
-	hasMultipleValues := false
-	filterCount := 0
-	for _, value := range filters {
-		if value != "" {
-			filterCount++
-			if allSameValue == "" {
-				allSameValue = value
-			} else if allSameValue != value {
-				hasMultipleValues = true
-				break
-			}
-		}
+	whereClause, args, err := buildFilterClause(columnTypes, filters)
+	if err != nil {
+		return "", nil, err
 	}
-
-	// Build WHERE clause from filters
-	whereClause := "1=1"
-	args := []interface{}{}
-	argIndex := 1
-
-	// If all values are the same (general search), use OR logic
-	if !hasMultipleValues && filterCount > 1 && allSameValue != "" {
-		// General search: value should match ANY of the fields (OR)
-		conditions := []string{}
-		for key := range filters {
-			if filters[key] != "" {
-				dataType, exists := columnTypes[key]
-				if exists && (dataType == "integer" || dataType == "bigint" || dataType == "numeric" || dataType == "real" || dataType == "double precision" || dataType == "smallint") {
-					conditions = append(conditions, fmt.Sprintf("CAST(%s AS TEXT) ILIKE $%d", key, argIndex))
-				} else {
-					conditions = append(conditions, fmt.Sprintf("%s ILIKE $%d", key, argIndex))
-				}
-				args = append(args, "%"+allSameValue+"%")
-				argIndex++
-			}
-		}
-		if len(conditions) > 0 {
-			whereClause += " AND (" + conditions[0]
-			for i := 1; i < len(conditions); i++ {
-				whereClause += " OR " + conditions[i]
-			}
-			whereClause += ")"
-		}
-	} else {
-		// Specific filters: each field must match its value (AND)
-		for key, value := range filters {
-			if value != "" {
-				dataType, exists := columnTypes[key]
-				// Use ILIKE for text-like types, CAST to text for numeric types
-				if exists && (dataType == "integer" || dataType == "bigint" || dataType == "numeric" || dataType == "real" || dataType == "double precision" || dataType == "smallint") {
-					// For numeric types, cast to text and use ILIKE
-					whereClause += fmt.Sprintf(" AND CAST(%s AS TEXT) ILIKE $%d", key, argIndex)
-					args = append(args, "%"+value+"%")
-					argIndex++
-				} else {
-					// For text types, use ILIKE directly
-					whereClause += fmt.Sprintf(" AND %s ILIKE $%d", key, argIndex)
-					args = append(args, "%"+value+"%")
-					argIndex++
-				}
-			}
+	if filter != nil {
+		condition, filterArgs, err := buildFilterExpression(postgresDialect{}, columnTypes, filter, len(args)+1)
+		if err != nil {
+			return "", nil, err
 		}
+		whereClause += " AND " + condition
+		args = append(args, filterArgs...)
+	}
+	scopeCondition, scopeArgs, err := s.rowFilterCondition(hohAddressDatabaseID, tableName, scope, columnTypes, len(args)+1)
+	if err != nil {
+		return "", nil, err
+	}
+	if scopeCondition != "" {
+		whereClause += " AND " + scopeCondition
+		args = append(args, scopeArgs...)
 	}
-
 	return whereClause, args, nil
 }
 
 // GetStatusList retrieves data from tracking.hohaddressstatuslist (read-only)
-func (s *HohAddressService) GetStatusList(hohAddressDatabaseID string, filters map[string]string, limit, offset int, whereClause string) ([]map[string]interface{}, int, error) {
+func (s *HohAddressService) GetStatusList(ctx context.Context, hohAddressDatabaseID string, scope RowScope, filters map[string]string, limit, offset int, filter *models.FilterExpression) ([]map[string]interface{}, int, error) {
 	db, err := s.connectToDatabase(hohAddressDatabaseID)
 	if err != nil {
 		return nil, 0, err
 	}
-	defer db.Close()
 
 	// Get ordered columns first
-	columns, err := s.getOrderedColumns(db, "hohaddressstatuslist")
+	columns, err := s.getOrderedColumns(ctx, db, "hohaddressstatuslist")
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get columns: %w", err)
 	}
 
 	// Build column list for SELECT
-	columnList := ""
-	for i, col := range columns {
-		if i > 0 {
-			columnList += ", "
-		}
-		columnList += col
-	}
-
-	// Build WHERE clause from filters with proper type handling
-	var whereCondition string
-	var args []interface{}
-	var argIndex int
-	
-	whereClauseTrimmed := strings.TrimSpace(whereClause)
-	if whereClauseTrimmed != "" {
-		// Use custom WHERE clause if provided
-		whereCondition = whereClauseTrimmed
-		args = []interface{}{}
-		argIndex = 1
-		fmt.Printf("Using custom WHERE clause: %s\n", whereCondition)
-	} else {
-		// Build WHERE from filters
-		builtWhere, builtArgs, err := s.buildWhereClause(db, "hohaddressstatuslist", filters)
-		if err != nil {
-			return nil, 0, err
-		}
-		whereCondition = builtWhere
-		args = builtArgs
-		argIndex = len(args) + 1
-		fmt.Printf("Using built WHERE clause: %s\n", whereCondition)
+	columnList := quoteIdentifiers(columns)
+
+	// Build WHERE clause from filters and the filter expression with proper type handling
+	whereCondition, args, err := s.buildWhereClause(ctx, db, hohAddressDatabaseID, "hohaddressstatuslist", scope, filters, filter)
+	if err != nil {
+		return nil, 0, err
 	}
+	argIndex := len(args) + 1
+	s.logger.DebugContext(ctx, "using built WHERE clause", "where", whereCondition)
 
 	// Get total count
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM tracking.hohaddressstatuslist WHERE %s", whereCondition)
-	fmt.Printf("Count query: %s\n", countQuery)
+	s.logger.DebugContext(ctx, "counting status list", "query", countQuery)
 	var totalCount int
-	err = db.QueryRow(countQuery, args...).Scan(&totalCount)
+	err = db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get count: %w", err)
 	}
 
 	// Get data with limit and offset using explicit column order
 	query := fmt.Sprintf("SELECT %s FROM tracking.hohaddressstatuslist WHERE %s ORDER BY %s LIMIT $%d OFFSET $%d", 
-		columnList, whereCondition, columns[0], argIndex, argIndex+1)
-	fmt.Printf("Data query: %s\n", query)
+		columnList, whereCondition, quotePostgresName(columns[0]), argIndex, argIndex+1)
+	s.logger.DebugContext(ctx, "querying status list", "query", query)
 	args = append(args, limit, offset)
 
-	rows, err := db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query tracking.hohaddressstatuslist: %w", err)
 	}
 	defer rows.Close()
 
-	var results []map[string]interface{}
+	results := []map[string]interface{}{}
 	for rows.Next() {
 		values := make([]interface{}, len(columns))
 		valuePtrs := make([]interface{}, len(columns))
@@ -592,78 +631,54 @@ func (s *HohAddressService) GetStatusList(hohAddressDatabaseID string, filters m
 }
 
 // GetBlacklist retrieves data from tracking.hohaddressblacklist
-func (s *HohAddressService) GetBlacklist(hohAddressDatabaseID string, filters map[string]string, sortBy string, sortOrder string, limit, offset int, whereClause string) ([]map[string]interface{}, int, error) {
+func (s *HohAddressService) GetBlacklist(ctx context.Context, hohAddressDatabaseID string, scope RowScope, filters map[string]string, sortBy string, sortOrder string, limit, offset int, filter *models.FilterExpression) ([]map[string]interface{}, int, error) {
 	db, err := s.connectToDatabase(hohAddressDatabaseID)
 	if err != nil {
 		return nil, 0, err
 	}
-	defer db.Close()
 
 	// Get ordered columns first
-	columns, err := s.getOrderedColumns(db, "hohaddressblacklist")
+	columns, err := s.getOrderedColumns(ctx, db, "hohaddressblacklist")
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get columns: %w", err)
 	}
 
 	// Build column list for SELECT
-	columnList := ""
-	for i, col := range columns {
-		if i > 0 {
-			columnList += ", "
-		}
-		columnList += col
-	}
-
-	// Build WHERE clause from filters with proper type handling
-	var whereCondition string
-	var args []interface{}
-	var argIndex int
-	
-	whereClauseTrimmed := strings.TrimSpace(whereClause)
-	if whereClauseTrimmed != "" {
-		// Use custom WHERE clause if provided
-		whereCondition = whereClauseTrimmed
-		args = []interface{}{}
-		argIndex = 1
-	} else {
-		// Build WHERE from filters
-		builtWhere, builtArgs, err := s.buildWhereClause(db, "hohaddressblacklist", filters)
-		if err != nil {
-			return nil, 0, err
-		}
-		whereCondition = builtWhere
-		args = builtArgs
-		argIndex = len(args) + 1
-	}
+	columnList := quoteIdentifiers(columns)
 
-	// Validate sortBy and sortOrder
-	if sortBy == "" {
-		sortBy = columns[0] // Default to first column
+	// Build WHERE clause from filters and the filter expression with proper type handling
+	whereCondition, args, err := s.buildWhereClause(ctx, db, hohAddressDatabaseID, "hohaddressblacklist", scope, filters, filter)
+	if err != nil {
+		return nil, 0, err
 	}
-	if sortOrder != "ASC" && sortOrder != "DESC" {
-		sortOrder = "ASC"
+	argIndex := len(args) + 1
+
+	// Validate sortBy against the columns
+	orderBy, err := buildOrderBy(columns, sortBy, sortOrder)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// Get total count
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM tracking.hohaddressblacklist WHERE %s", whereCondition)
 	var totalCount int
-	err = db.QueryRow(countQuery, args...).Scan(&totalCount)
+	err = db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get count: %w", err)
 	}
 
 	// Get data with limit, offset and sorting using explicit column order
-	query := fmt.Sprintf("SELECT %s FROM tracking.hohaddressblacklist WHERE %s ORDER BY %s %s LIMIT $%d OFFSET $%d", 
-		columnList, whereCondition, sortBy, sortOrder, argIndex, argIndex+1)
+	query := fmt.Sprintf("SELECT %s FROM tracking.hohaddressblacklist WHERE %s ORDER BY %s LIMIT $%d OFFSET $%d", 
+		columnList, whereCondition, orderBy, argIndex, argIndex+1)
 	args = append(args, limit, offset)
 
-	rows, err := db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query tracking.hohaddressblacklist: %w", err)
 	}
 	defer rows.Close()
 
-	var results []map[string]interface{}
+	results := []map[string]interface{}{}
 	for rows.Next() {
 		values := make([]interface{}, len(columns))
 		valuePtrs := make([]interface{}, len(columns))
@@ -694,153 +709,40 @@ func (s *HohAddressService) GetBlacklist(hohAddressDatabaseID string, filters ma
 	return results, totalCount, nil
 }
 
-// CreateBlacklistRow creates a new row in tracking.hohaddressblacklist
-func (s *HohAddressService) CreateBlacklistRow(hohAddressDatabaseID string, data map[string]interface{}, username string) (map[string]interface{}, error) {
+// CreateBlacklistRow creates a new row in tracking.hohaddressblacklist; it must match the row filters of the editor
+func (s *HohAddressService) CreateBlacklistRow(ctx context.Context, hohAddressDatabaseID string, scope RowScope, data map[string]interface{}, username string) (map[string]interface{}, error) {
 	db, err := s.connectToDatabase(hohAddressDatabaseID)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
-	// Get column names from the table
-	columnsQuery := `
-		SELECT column_name 
-		FROM information_schema.columns 
-		WHERE table_schema = 'tracking' 
-		AND table_name = 'hohaddressblacklist'
-		ORDER BY ordinal_position
-	`
-	colRows, err := db.Query(columnsQuery)
+	columnNames, err := getTrackingTableColumns(ctx, db, "hohaddressblacklist")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get columns: %w", err)
-	}
-	defer colRows.Close()
-
-	var columnNames []string
-	for colRows.Next() {
-		var colName string
-		if err := colRows.Scan(&colName); err != nil {
-			return nil, fmt.Errorf("failed to scan column name: %w", err)
-		}
-		columnNames = append(columnNames, colName)
-	}
-
-	// Build INSERT query with automatic fields
-	columns := ""
-	placeholders := ""
-	values := []interface{}{}
-	argIndex := 1
-
-	// Get values for _upd functions
-	address1, _ := data["address1"].(string)
-	address2, _ := data["address2"].(string)
-	city, _ := data["city"].(string)
-
-	for _, colName := range columnNames {
-		var colValue interface{}
-		var useFunction bool
-		var functionExpr string
-
-		// Handle special fields
-		switch colName {
-		case "address1_upd":
-			if address1 != "" {
-				useFunction = true
-				functionExpr = fmt.Sprintf("tracking.get_hohaddress1($%d)", argIndex)
-				colValue = address1
-				argIndex++
-			}
-		case "address2_upd":
-			if address2 != "" {
-				useFunction = true
-				functionExpr = fmt.Sprintf("tracking.get_hohaddress2($%d)", argIndex)
-				colValue = address2
-				argIndex++
-			}
-		case "city_upd":
-			if city != "" {
-				useFunction = true
-				functionExpr = fmt.Sprintf("tracking.get_hohcity($%d)", argIndex)
-				colValue = city
-				argIndex++
-			}
-		case "updatedby":
-			colValue = username
-		case "updatedon":
-			useFunction = true
-			functionExpr = "CURRENT_TIMESTAMP"
-		default:
-			if val, ok := data[colName]; ok {
-				colValue = val
-			} else {
-				continue // Skip if not provided and not a special field
-			}
-		}
-
-		if colValue == nil && !useFunction {
-			continue
-		}
-
-		if columns != "" {
-			columns += ", "
-			placeholders += ", "
-		}
-		columns += colName
-
-		if useFunction {
-			placeholders += functionExpr
-		} else {
-			placeholders += fmt.Sprintf("$%d", argIndex)
-			values = append(values, colValue)
-			argIndex++
-		}
-	}
-
-	if columns == "" {
-		return nil, fmt.Errorf("no valid columns provided")
-	}
-
-	insertQuery := fmt.Sprintf("INSERT INTO tracking.hohaddressblacklist (%s) VALUES (%s) RETURNING *", columns, placeholders)
-
-	// Execute query and get result
-	row := db.QueryRow(insertQuery, values...)
-
-	// Scan the returned row
-	resultValues := make([]interface{}, len(columnNames))
-	resultPtrs := make([]interface{}, len(columnNames))
-	for i := range resultValues {
-		resultPtrs[i] = &resultValues[i]
+		return nil, err
 	}
-
-	if err := row.Scan(resultPtrs...); err != nil {
-		return nil, fmt.Errorf("failed to scan inserted row: %w", err)
+	data, err = resolveHohAddressColumns("hohaddressblacklist", columnNames, data)
+	if err != nil {
+		return nil, err
 	}
 
-	// Build result map
-	result := make(map[string]interface{})
-	for i, colName := range columnNames {
-		val := resultValues[i]
-		if b, ok := val.([]byte); ok {
-			result[colName] = string(b)
-		} else {
-			result[colName] = val
-		}
+	scopeCondition, scopeArgs, err := s.editScopeCondition(ctx, db, hohAddressDatabaseID, "hohaddressblacklist", scope, 2)
+	if err != nil {
+		return nil, err
 	}
-
-	return result, nil
+	return s.insertScopedRow(ctx, db, hohAddressDatabaseID, "hohaddressblacklist", scope, columnNames, data, username, scopeCondition, scopeArgs)
 }
 
-// UpdateBlacklistRow updates a row in tracking.hohaddressblacklist
-func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowID interface{}, data map[string]interface{}, username string) (map[string]interface{}, error) {
+// UpdateBlacklistRow updates a row in tracking.hohaddressblacklist. Users limited by row filters can only update
+// rows matching them, and only so that the rows still match.
+func (s *HohAddressService) UpdateBlacklistRow(ctx context.Context, hohAddressDatabaseID string, scope RowScope, rowID interface{}, data map[string]interface{}, username string) (map[string]interface{}, error) {
 	db, err := s.connectToDatabase(hohAddressDatabaseID)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
 	// Get primary key column
 	var pkColumn string
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT column_name 
 		FROM information_schema.table_constraints tc
 		JOIN information_schema.key_column_usage kcu ON tc.constraint_name = kcu.constraint_name
@@ -852,7 +754,7 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 
 	if err != nil {
 		// Fallback to first column
-		err = db.QueryRow(`
+		err = db.QueryRowContext(ctx, `
 			SELECT column_name 
 			FROM information_schema.columns 
 			WHERE table_schema = 'tracking' 
@@ -873,7 +775,7 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 		AND table_name = 'hohaddressblacklist'
 		ORDER BY ordinal_position
 	`
-	colRows, err := db.Query(columnsQuery)
+	colRows, err := db.QueryContext(ctx, columnsQuery)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
@@ -888,14 +790,37 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 		columnNames = append(columnNames, colName)
 	}
 
-	// Get current row to check if uniqueness fields are being changed
-	var currentAddress1Upd, currentAddress2Upd, currentCityUpd, currentCity, currentState interface{}
-	var currentZip interface{}
-	err = db.QueryRow(fmt.Sprintf("SELECT address1_upd, address2_upd, city_upd, city, state, zip FROM tracking.hohaddressblacklist WHERE %s = $1", pkColumn), rowID).Scan(
-		&currentAddress1Upd, &currentAddress2Upd, &currentCityUpd, &currentCity, &currentState, &currentZip)
+	data, err = resolveHohAddressColumns("hohaddressblacklist", columnNames, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows outside the row filters of the editor are not found; the row is locked until the update
+	// is checked against them
+	scopeCondition, scopeArgs, err := s.editScopeCondition(ctx, db, hohAddressDatabaseID, "hohaddressblacklist", scope, 2)
+	if err != nil {
+		return nil, err
+	}
+	q, tx, err := beginScopedWrite(ctx, db, scopeCondition)
+	if err != nil {
+		return nil, err
+	}
+	currentQuery := fmt.Sprintf("SELECT * FROM tracking.hohaddressblacklist WHERE %s = $1", quotePostgresName(pkColumn))
+	if tx != nil {
+		defer tx.Rollback()
+		currentQuery += " AND " + scopeCondition + " FOR UPDATE"
+	}
+
+	// Get current row to check if uniqueness fields are being changed; the row edit log keeps all of it
+	current, err := scanTrackingRow(q.QueryRowContext(ctx, currentQuery, append([]interface{}{rowID}, scopeArgs...)...), columnNames)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHohAddressRowNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current row: %w", err)
 	}
+	currentAddress1Upd, currentAddress2Upd, currentCityUpd := current["address1_upd"], current["address2_upd"], current["city_upd"]
+	currentCity, currentState, currentZip := current["city"], current["state"], current["zip"]
 
 	// Get values for _upd functions
 	address1, hasAddress1 := data["address1"].(string)
@@ -907,7 +832,7 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 	// Calculate new _upd values if fields are being updated
 	var address1Upd, address2Upd, cityUpd string
 	if hasAddress1 && address1 != "" {
-		err = db.QueryRow("SELECT tracking.get_hohaddress1($1)", address1).Scan(&address1Upd)
+		err = q.QueryRowContext(ctx, "SELECT tracking.get_hohaddress1($1)", address1).Scan(&address1Upd)
 		if err != nil {
 			return nil, fmt.Errorf("failed to calculate address1_upd: %w", err)
 		}
@@ -915,7 +840,7 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 		address1Upd = fmt.Sprintf("%v", currentAddress1Upd)
 	}
 	if hasAddress2 && address2 != "" {
-		err = db.QueryRow("SELECT tracking.get_hohaddress2($1)", address2).Scan(&address2Upd)
+		err = q.QueryRowContext(ctx, "SELECT tracking.get_hohaddress2($1)", address2).Scan(&address2Upd)
 		if err != nil {
 			return nil, fmt.Errorf("failed to calculate address2_upd: %w", err)
 		}
@@ -923,7 +848,7 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 		address2Upd = fmt.Sprintf("%v", currentAddress2Upd)
 	}
 	if hasCity && city != "" {
-		err = db.QueryRow("SELECT tracking.get_hohcity($1)", city).Scan(&cityUpd)
+		err = q.QueryRowContext(ctx, "SELECT tracking.get_hohcity($1)", city).Scan(&cityUpd)
 		if err != nil {
 			return nil, fmt.Errorf("failed to calculate city_upd: %w", err)
 		}
@@ -956,9 +881,9 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 		AND state = $5 
 		AND zip = $6
 		AND %s != $7
-	`, pkColumn)
+	`, quotePostgresName(pkColumn))
 	var count int
-	err = db.QueryRow(checkQuery, address1Upd, address2Upd, cityUpd, checkCity, checkState, checkZip, rowID).Scan(&count)
+	err = q.QueryRowContext(ctx, checkQuery, address1Upd, address2Upd, cityUpd, checkCity, checkState, checkZip, rowID).Scan(&count)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check uniqueness: %w", err)
 	}
@@ -972,6 +897,10 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 	argIndex := 2
 
 	for key, value := range data {
+		// Keys become column names in the SET clause, so only columns of the table are accepted
+		if !slices.Contains(columnNames, key) {
+			return nil, fmt.Errorf("unknown column %q", key)
+		}
 		if key == pkColumn {
 			continue // Skip primary key in SET clause
 		}
@@ -982,7 +911,7 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 		// Handle special fields
 		switch key {
 		case "address1":
-			setClause += fmt.Sprintf("%s = $%d", key, argIndex)
+			setClause += fmt.Sprintf("%s = $%d", quotePostgresName(key), argIndex)
 			values = append(values, value)
 			argIndex++
 			// Also update address1_upd if address1 is being updated
@@ -996,7 +925,7 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 			}
 			continue
 		case "address2":
-			setClause += fmt.Sprintf("%s = $%d", key, argIndex)
+			setClause += fmt.Sprintf("%s = $%d", quotePostgresName(key), argIndex)
 			values = append(values, value)
 			argIndex++
 			// Also update address2_upd if address2 is being updated
@@ -1010,7 +939,7 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 			}
 			continue
 		case "city":
-			setClause += fmt.Sprintf("%s = $%d", key, argIndex)
+			setClause += fmt.Sprintf("%s = $%d", quotePostgresName(key), argIndex)
 			values = append(values, value)
 			argIndex++
 			// Also update city_upd if city is being updated
@@ -1027,17 +956,17 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 			// Skip _upd fields - they are auto-updated
 			continue
 		case "updatedby":
-			setClause += fmt.Sprintf("%s = $%d", key, argIndex)
+			setClause += fmt.Sprintf("%s = $%d", quotePostgresName(key), argIndex)
 			values = append(values, username)
 			argIndex++
 			continue
 		case "updatedon":
-			setClause += fmt.Sprintf("%s = CURRENT_TIMESTAMP", key)
+			setClause += fmt.Sprintf("%s = CURRENT_TIMESTAMP", quotePostgresName(key))
 			continue
 		}
 
 		// Regular field
-		setClause += fmt.Sprintf("%s = $%d", key, argIndex)
+		setClause += fmt.Sprintf("%s = $%d", quotePostgresName(key), argIndex)
 		values = append(values, value)
 		argIndex++
 	}
@@ -1054,10 +983,10 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 		return nil, fmt.Errorf("no fields to update")
 	}
 
-	updateQuery := fmt.Sprintf("UPDATE tracking.hohaddressblacklist SET %s WHERE %s = $1 RETURNING *", setClause, pkColumn)
+	updateQuery := fmt.Sprintf("UPDATE tracking.hohaddressblacklist SET %s WHERE %s = $1 RETURNING *", setClause, quotePostgresName(pkColumn))
 
 	// Execute query and get result
-	row := db.QueryRow(updateQuery, values...)
+	row := q.QueryRowContext(ctx, updateQuery, values...)
 
 	// Scan the returned row
 	resultValues := make([]interface{}, len(columnNames))
@@ -1081,20 +1010,25 @@ func (s *HohAddressService) UpdateBlacklistRow(hohAddressDatabaseID string, rowI
 		}
 	}
 
+	if err := finishScopedWrite(ctx, tx, "hohaddressblacklist", pkColumn, rowID, scopeCondition, scopeArgs); err != nil {
+		return nil, err
+	}
+
+	s.logRowEdit(hohAddressDatabaseID, "hohaddressblacklist", pkColumn, models.RowEditUpdate, scope, current, result)
 	return result, nil
 }
 
-// DeleteBlacklistRow deletes a row from tracking.hohaddressblacklist
-func (s *HohAddressService) DeleteBlacklistRow(hohAddressDatabaseID string, rowID interface{}) error {
+// DeleteBlacklistRow deletes a row from tracking.hohaddressblacklist; rows outside the row
+// filters of the editor are not found
+func (s *HohAddressService) DeleteBlacklistRow(ctx context.Context, hohAddressDatabaseID string, scope RowScope, rowID interface{}) error {
 	db, err := s.connectToDatabase(hohAddressDatabaseID)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
 	// Get primary key column
 	var pkColumn string
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT column_name 
 		FROM information_schema.table_constraints tc
 		JOIN information_schema.key_column_usage kcu ON tc.constraint_name = kcu.constraint_name
@@ -1106,7 +1040,7 @@ func (s *HohAddressService) DeleteBlacklistRow(hohAddressDatabaseID string, rowI
 
 	if err != nil {
 		// Fallback to first column
-		err = db.QueryRow(`
+		err = db.QueryRowContext(ctx, `
 			SELECT column_name 
 			FROM information_schema.columns 
 			WHERE table_schema = 'tracking' 
@@ -1119,97 +1053,72 @@ func (s *HohAddressService) DeleteBlacklistRow(hohAddressDatabaseID string, rowI
 		}
 	}
 
-	deleteQuery := fmt.Sprintf("DELETE FROM tracking.hohaddressblacklist WHERE %s = $1", pkColumn)
-	result, err := db.Exec(deleteQuery, rowID)
+	deleteQuery := fmt.Sprintf("DELETE FROM tracking.hohaddressblacklist WHERE %s = $1", quotePostgresName(pkColumn))
+	scopeCondition, scopeArgs, err := s.editScopeCondition(ctx, db, hohAddressDatabaseID, "hohaddressblacklist", scope, 2)
 	if err != nil {
-		return fmt.Errorf("failed to delete row: %w", err)
+		return err
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	if scopeCondition != "" {
+		deleteQuery += " AND " + scopeCondition
 	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("row not found")
+	deleted, err := deleteTrackingRow(ctx, db, deleteQuery+" RETURNING *", append([]interface{}{rowID}, scopeArgs...))
+	if err != nil {
+		return err
 	}
 
+	s.logRowEdit(hohAddressDatabaseID, "hohaddressblacklist", pkColumn, models.RowEditDelete, scope, deleted, nil)
 	return nil
 }
 
 // GetWhitelist retrieves data from tracking.hohaddresswhitelist
-func (s *HohAddressService) GetWhitelist(hohAddressDatabaseID string, filters map[string]string, sortBy string, sortOrder string, limit, offset int, whereClause string) ([]map[string]interface{}, int, error) {
+func (s *HohAddressService) GetWhitelist(ctx context.Context, hohAddressDatabaseID string, scope RowScope, filters map[string]string, sortBy string, sortOrder string, limit, offset int, filter *models.FilterExpression) ([]map[string]interface{}, int, error) {
 	db, err := s.connectToDatabase(hohAddressDatabaseID)
 	if err != nil {
 		return nil, 0, err
 	}
-	defer db.Close()
 
 	// Get ordered columns first
-	columns, err := s.getOrderedColumns(db, "hohaddresswhitelist")
+	columns, err := s.getOrderedColumns(ctx, db, "hohaddresswhitelist")
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get columns: %w", err)
 	}
 
 	// Build column list for SELECT
-	columnList := ""
-	for i, col := range columns {
-		if i > 0 {
-			columnList += ", "
-		}
-		columnList += col
-	}
-
-	// Build WHERE clause from filters with proper type handling
-	var whereCondition string
-	var args []interface{}
-	var argIndex int
-	
-	whereClauseTrimmed := strings.TrimSpace(whereClause)
-	if whereClauseTrimmed != "" {
-		// Use custom WHERE clause if provided
-		whereCondition = whereClauseTrimmed
-		args = []interface{}{}
-		argIndex = 1
-	} else {
-		// Build WHERE from filters
-		builtWhere, builtArgs, err := s.buildWhereClause(db, "hohaddresswhitelist", filters)
-		if err != nil {
-			return nil, 0, err
-		}
-		whereCondition = builtWhere
-		args = builtArgs
-		argIndex = len(args) + 1
-	}
+	columnList := quoteIdentifiers(columns)
 
-	// Validate sortBy and sortOrder
-	if sortBy == "" {
-		sortBy = columns[0] // Default to first column
+	// Build WHERE clause from filters and the filter expression with proper type handling
+	whereCondition, args, err := s.buildWhereClause(ctx, db, hohAddressDatabaseID, "hohaddresswhitelist", scope, filters, filter)
+	if err != nil {
+		return nil, 0, err
 	}
-	if sortOrder != "ASC" && sortOrder != "DESC" {
-		sortOrder = "ASC"
+	argIndex := len(args) + 1
+
+	// Validate sortBy against the columns
+	orderBy, err := buildOrderBy(columns, sortBy, sortOrder)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// Get total count
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM tracking.hohaddresswhitelist WHERE %s", whereCondition)
 	var totalCount int
-	err = db.QueryRow(countQuery, args...).Scan(&totalCount)
+	err = db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get count: %w", err)
 	}
 
 	// Get data with limit, offset and sorting using explicit column order
-	query := fmt.Sprintf("SELECT %s FROM tracking.hohaddresswhitelist WHERE %s ORDER BY %s %s LIMIT $%d OFFSET $%d", 
-		columnList, whereCondition, sortBy, sortOrder, argIndex, argIndex+1)
+	query := fmt.Sprintf("SELECT %s FROM tracking.hohaddresswhitelist WHERE %s ORDER BY %s LIMIT $%d OFFSET $%d", 
+		columnList, whereCondition, orderBy, argIndex, argIndex+1)
 	args = append(args, limit, offset)
 
-	rows, err := db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query tracking.hohaddresswhitelist: %w", err)
 	}
 	defer rows.Close()
 
-	var results []map[string]interface{}
+	results := []map[string]interface{}{}
 	for rows.Next() {
 		values := make([]interface{}, len(columns))
 		valuePtrs := make([]interface{}, len(columns))
@@ -1240,23 +1149,93 @@ func (s *HohAddressService) GetWhitelist(hohAddressDatabaseID string, filters ma
 	return results, totalCount, nil
 }
 
-// CreateWhitelistRow creates a new row in tracking.hohaddresswhitelist
-func (s *HohAddressService) CreateWhitelistRow(hohAddressDatabaseID string, data map[string]interface{}, username string) (map[string]interface{}, error) {
+// CreateWhitelistRow creates a new row in tracking.hohaddresswhitelist; it must match the row filters of the editor
+func (s *HohAddressService) CreateWhitelistRow(ctx context.Context, hohAddressDatabaseID string, scope RowScope, data map[string]interface{}, username string) (map[string]interface{}, error) {
 	db, err := s.connectToDatabase(hohAddressDatabaseID)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
-	// Get column names from the table
+	columnNames, err := getTrackingTableColumns(ctx, db, "hohaddresswhitelist")
+	if err != nil {
+		return nil, err
+	}
+	data, err = resolveHohAddressColumns("hohaddresswhitelist", columnNames, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check uniqueness: address1_upd, address2_upd, city_upd, city, state, zip
+	key, err := getHohAddressKey(ctx, db, data)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := hohAddressExists(ctx, db, "hohaddresswhitelist", key)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("a record with this combination of address1_upd, address2_upd, city_upd, city, state, and zip already exists")
+	}
+
+	scopeCondition, scopeArgs, err := s.editScopeCondition(ctx, db, hohAddressDatabaseID, "hohaddresswhitelist", scope, 2)
+	if err != nil {
+		return nil, err
+	}
+	return s.insertScopedRow(ctx, db, hohAddressDatabaseID, "hohaddresswhitelist", scope, columnNames, data, username, scopeCondition, scopeArgs)
+}
+
+// trackingTable returns the quoted name of a table in the tracking schema
+func trackingTable(tableName string) string {
+	return qualifiedName(postgresDialect{}, "tracking", tableName)
+}
+
+// resolveHohAddressColumns returns the row data keyed by the catalog spelling of its columns
+func resolveHohAddressColumns(tableName string, columnNames []string, data map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		column, err := resolveName("column", "tracking."+tableName, columnNames, key)
+		if err != nil {
+			return nil, err
+		}
+		resolved[column] = value
+	}
+	return resolved, nil
+}
+
+// getTrackingColumnTypes returns the data types of the columns of a table in the tracking schema
+func getTrackingColumnTypes(ctx context.Context, db *sql.DB, tableName string) (map[string]string, error) {
+	columnTypes := make(map[string]string)
+	typeRows, err := db.QueryContext(ctx, `
+		SELECT column_name, data_type 
+		FROM information_schema.columns 
+		WHERE table_schema = 'tracking' 
+		AND table_name = $1
+	`, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
+	defer typeRows.Close()
+
+	for typeRows.Next() {
+		var colName, dataType string
+		if err := typeRows.Scan(&colName, &dataType); err == nil {
+			columnTypes[colName] = dataType
+		}
+	}
+	return columnTypes, nil
+}
+
+// getTrackingTableColumns returns the column names of a table in the tracking schema
+func getTrackingTableColumns(ctx context.Context, db *sql.DB, tableName string) ([]string, error) {
 	columnsQuery := `
 		SELECT column_name 
 		FROM information_schema.columns 
 		WHERE table_schema = 'tracking' 
-		AND table_name = 'hohaddresswhitelist'
+		AND table_name = $1
 		ORDER BY ordinal_position
 	`
-	colRows, err := db.Query(columnsQuery)
+	colRows, err := db.QueryContext(ctx, columnsQuery, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
@@ -1271,53 +1250,89 @@ func (s *HohAddressService) CreateWhitelistRow(hohAddressDatabaseID string, data
 		columnNames = append(columnNames, colName)
 	}
 
-	// Get values for _upd functions
-	address1, _ := data["address1"].(string)
-	address2, _ := data["address2"].(string)
-	city, _ := data["city"].(string)
-	state, _ := data["state"]
-	zip, _ := data["zip"]
+	return columnNames, nil
+}
 
-	// Calculate _upd values using database functions for uniqueness check
+// normalizeHohAddress calculates the _upd values of an address using the tracking.get_* functions
+func normalizeHohAddress(ctx context.Context, db *sql.DB, address1, address2, city string) (string, string, string, error) {
 	var address1Upd, address2Upd, cityUpd string
 	if address1 != "" {
-		err = db.QueryRow("SELECT tracking.get_hohaddress1($1)", address1).Scan(&address1Upd)
-		if err != nil {
-			return nil, fmt.Errorf("failed to calculate address1_upd: %w", err)
+		if err := db.QueryRowContext(ctx, "SELECT tracking.get_hohaddress1($1)", address1).Scan(&address1Upd); err != nil {
+			return "", "", "", fmt.Errorf("failed to calculate address1_upd: %w", err)
 		}
 	}
 	if address2 != "" {
-		err = db.QueryRow("SELECT tracking.get_hohaddress2($1)", address2).Scan(&address2Upd)
-		if err != nil {
-			return nil, fmt.Errorf("failed to calculate address2_upd: %w", err)
+		if err := db.QueryRowContext(ctx, "SELECT tracking.get_hohaddress2($1)", address2).Scan(&address2Upd); err != nil {
+			return "", "", "", fmt.Errorf("failed to calculate address2_upd: %w", err)
 		}
 	}
 	if city != "" {
-		err = db.QueryRow("SELECT tracking.get_hohcity($1)", city).Scan(&cityUpd)
-		if err != nil {
-			return nil, fmt.Errorf("failed to calculate city_upd: %w", err)
+		if err := db.QueryRowContext(ctx, "SELECT tracking.get_hohcity($1)", city).Scan(&cityUpd); err != nil {
+			return "", "", "", fmt.Errorf("failed to calculate city_upd: %w", err)
 		}
 	}
+	return address1Upd, address2Upd, cityUpd, nil
+}
 
-	// Check uniqueness: address1_upd, address2_upd, city_upd, city, state, zip
-	checkQuery := `
+// hohAddressKey identifies an address in the uniqueness checks of the tracking tables
+type hohAddressKey struct {
+	Address1Upd string
+	Address2Upd string
+	CityUpd     string
+	City        string
+	State       interface{}
+	Zip         interface{}
+}
+
+// getHohAddressKey normalizes the address of a row into its uniqueness key
+func getHohAddressKey(ctx context.Context, db *sql.DB, data map[string]interface{}) (hohAddressKey, error) {
+	address1, _ := data["address1"].(string)
+	address2, _ := data["address2"].(string)
+	city, _ := data["city"].(string)
+
+	// Calculate _upd values using database functions for uniqueness check
+	address1Upd, address2Upd, cityUpd, err := normalizeHohAddress(ctx, db, address1, address2, city)
+	if err != nil {
+		return hohAddressKey{}, err
+	}
+
+	return hohAddressKey{
+		Address1Upd: address1Upd,
+		Address2Upd: address2Upd,
+		CityUpd:     cityUpd,
+		City:        city,
+		State:       data["state"],
+		Zip:         data["zip"],
+	}, nil
+}
+
+// hohAddressExists reports whether a tracking table already has a row with the same
+// address1_upd, address2_upd, city_upd, city, state and zip
+func hohAddressExists(ctx context.Context, db *sql.DB, tableName string, key hohAddressKey) (bool, error) {
+	checkQuery := fmt.Sprintf(`
 		SELECT COUNT(*) 
-		FROM tracking.hohaddresswhitelist 
+		FROM %s 
 		WHERE address1_upd = $1 
 		AND address2_upd = $2 
 		AND city_upd = $3 
 		AND city = $4 
 		AND state = $5 
 		AND zip = $6
-	`
+	`, trackingTable(tableName))
 	var count int
-	err = db.QueryRow(checkQuery, address1Upd, address2Upd, cityUpd, city, state, zip).Scan(&count)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check uniqueness: %w", err)
-	}
-	if count > 0 {
-		return nil, fmt.Errorf("a record with this combination of address1_upd, address2_upd, city_upd, city, state, and zip already exists")
+	if err := db.QueryRowContext(ctx, checkQuery, key.Address1Upd, key.Address2Upd, key.CityUpd, key.City, key.State, key.Zip).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check uniqueness: %w", err)
 	}
+	return count > 0, nil
+}
+
+// insertHohAddressRow inserts a row into a tracking table, filling the _upd columns with the
+// tracking.get_* functions and the updatedby and updatedon columns automatically
+func insertHohAddressRow(ctx context.Context, db rowQuerier, tableName string, columnNames []string, data map[string]interface{}, username string) (map[string]interface{}, error) {
+	// Get values for _upd functions
+	address1, _ := data["address1"].(string)
+	address2, _ := data["address2"].(string)
+	city, _ := data["city"].(string)
 
 	// Build INSERT query with automatic fields
 	columns := ""
@@ -1374,7 +1389,7 @@ func (s *HohAddressService) CreateWhitelistRow(hohAddressDatabaseID string, data
 			columns += ", "
 			placeholders += ", "
 		}
-		columns += colName
+		columns += quotePostgresName(colName)
 
 		if useFunction {
 			placeholders += functionExpr
@@ -1389,10 +1404,10 @@ func (s *HohAddressService) CreateWhitelistRow(hohAddressDatabaseID string, data
 		return nil, fmt.Errorf("no valid columns provided")
 	}
 
-	insertQuery := fmt.Sprintf("INSERT INTO tracking.hohaddresswhitelist (%s) VALUES (%s) RETURNING *", columns, placeholders)
+	insertQuery := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING *", trackingTable(tableName), columns, placeholders)
 
 	// Execute query and get result
-	row := db.QueryRow(insertQuery, values...)
+	row := db.QueryRowContext(ctx, insertQuery, values...)
 
 	// Scan the returned row
 	resultValues := make([]interface{}, len(columnNames))
@@ -1419,17 +1434,17 @@ func (s *HohAddressService) CreateWhitelistRow(hohAddressDatabaseID string, data
 	return result, nil
 }
 
-// UpdateWhitelistRow updates a row in tracking.hohaddresswhitelist
-func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowID interface{}, data map[string]interface{}, username string) (map[string]interface{}, error) {
+// UpdateWhitelistRow updates a row in tracking.hohaddresswhitelist. Users limited by row filters can only update
+// rows matching them, and only so that the rows still match.
+func (s *HohAddressService) UpdateWhitelistRow(ctx context.Context, hohAddressDatabaseID string, scope RowScope, rowID interface{}, data map[string]interface{}, username string) (map[string]interface{}, error) {
 	db, err := s.connectToDatabase(hohAddressDatabaseID)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
 	// Get primary key column
 	var pkColumn string
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT column_name 
 		FROM information_schema.table_constraints tc
 		JOIN information_schema.key_column_usage kcu ON tc.constraint_name = kcu.constraint_name
@@ -1441,7 +1456,7 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 
 	if err != nil {
 		// Fallback to first column
-		err = db.QueryRow(`
+		err = db.QueryRowContext(ctx, `
 			SELECT column_name 
 			FROM information_schema.columns 
 			WHERE table_schema = 'tracking' 
@@ -1462,7 +1477,7 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 		AND table_name = 'hohaddresswhitelist'
 		ORDER BY ordinal_position
 	`
-	colRows, err := db.Query(columnsQuery)
+	colRows, err := db.QueryContext(ctx, columnsQuery)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
@@ -1477,14 +1492,37 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 		columnNames = append(columnNames, colName)
 	}
 
-	// Get current row to check if uniqueness fields are being changed
-	var currentAddress1Upd, currentAddress2Upd, currentCityUpd, currentCity, currentState interface{}
-	var currentZip interface{}
-	err = db.QueryRow(fmt.Sprintf("SELECT address1_upd, address2_upd, city_upd, city, state, zip FROM tracking.hohaddresswhitelist WHERE %s = $1", pkColumn), rowID).Scan(
-		&currentAddress1Upd, &currentAddress2Upd, &currentCityUpd, &currentCity, &currentState, &currentZip)
+	data, err = resolveHohAddressColumns("hohaddresswhitelist", columnNames, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows outside the row filters of the editor are not found; the row is locked until the update
+	// is checked against them
+	scopeCondition, scopeArgs, err := s.editScopeCondition(ctx, db, hohAddressDatabaseID, "hohaddresswhitelist", scope, 2)
+	if err != nil {
+		return nil, err
+	}
+	q, tx, err := beginScopedWrite(ctx, db, scopeCondition)
+	if err != nil {
+		return nil, err
+	}
+	currentQuery := fmt.Sprintf("SELECT * FROM tracking.hohaddresswhitelist WHERE %s = $1", quotePostgresName(pkColumn))
+	if tx != nil {
+		defer tx.Rollback()
+		currentQuery += " AND " + scopeCondition + " FOR UPDATE"
+	}
+
+	// Get current row to check if uniqueness fields are being changed; the row edit log keeps all of it
+	current, err := scanTrackingRow(q.QueryRowContext(ctx, currentQuery, append([]interface{}{rowID}, scopeArgs...)...), columnNames)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHohAddressRowNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current row: %w", err)
 	}
+	currentAddress1Upd, currentAddress2Upd, currentCityUpd := current["address1_upd"], current["address2_upd"], current["city_upd"]
+	currentCity, currentState, currentZip := current["city"], current["state"], current["zip"]
 
 	// Get values for _upd functions
 	address1, hasAddress1 := data["address1"].(string)
@@ -1496,7 +1534,7 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 	// Calculate new _upd values if fields are being updated
 	var address1Upd, address2Upd, cityUpd string
 	if hasAddress1 && address1 != "" {
-		err = db.QueryRow("SELECT tracking.get_hohaddress1($1)", address1).Scan(&address1Upd)
+		err = q.QueryRowContext(ctx, "SELECT tracking.get_hohaddress1($1)", address1).Scan(&address1Upd)
 		if err != nil {
 			return nil, fmt.Errorf("failed to calculate address1_upd: %w", err)
 		}
@@ -1504,7 +1542,7 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 		address1Upd = fmt.Sprintf("%v", currentAddress1Upd)
 	}
 	if hasAddress2 && address2 != "" {
-		err = db.QueryRow("SELECT tracking.get_hohaddress2($1)", address2).Scan(&address2Upd)
+		err = q.QueryRowContext(ctx, "SELECT tracking.get_hohaddress2($1)", address2).Scan(&address2Upd)
 		if err != nil {
 			return nil, fmt.Errorf("failed to calculate address2_upd: %w", err)
 		}
@@ -1512,7 +1550,7 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 		address2Upd = fmt.Sprintf("%v", currentAddress2Upd)
 	}
 	if hasCity && city != "" {
-		err = db.QueryRow("SELECT tracking.get_hohcity($1)", city).Scan(&cityUpd)
+		err = q.QueryRowContext(ctx, "SELECT tracking.get_hohcity($1)", city).Scan(&cityUpd)
 		if err != nil {
 			return nil, fmt.Errorf("failed to calculate city_upd: %w", err)
 		}
@@ -1545,9 +1583,9 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 		AND state = $5 
 		AND zip = $6
 		AND %s != $7
-	`, pkColumn)
+	`, quotePostgresName(pkColumn))
 	var count int
-	err = db.QueryRow(checkQuery, address1Upd, address2Upd, cityUpd, checkCity, checkState, checkZip, rowID).Scan(&count)
+	err = q.QueryRowContext(ctx, checkQuery, address1Upd, address2Upd, cityUpd, checkCity, checkState, checkZip, rowID).Scan(&count)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check uniqueness: %w", err)
 	}
@@ -1561,6 +1599,10 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 	argIndex := 2
 
 	for key, value := range data {
+		// Keys become column names in the SET clause, so only columns of the table are accepted
+		if !slices.Contains(columnNames, key) {
+			return nil, fmt.Errorf("unknown column %q", key)
+		}
 		if key == pkColumn {
 			continue // Skip primary key in SET clause
 		}
@@ -1571,7 +1613,7 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 		// Handle special fields
 		switch key {
 		case "address1":
-			setClause += fmt.Sprintf("%s = $%d", key, argIndex)
+			setClause += fmt.Sprintf("%s = $%d", quotePostgresName(key), argIndex)
 			values = append(values, value)
 			argIndex++
 			// Also update address1_upd if address1 is being updated
@@ -1585,7 +1627,7 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 			}
 			continue
 		case "address2":
-			setClause += fmt.Sprintf("%s = $%d", key, argIndex)
+			setClause += fmt.Sprintf("%s = $%d", quotePostgresName(key), argIndex)
 			values = append(values, value)
 			argIndex++
 			// Also update address2_upd if address2 is being updated
@@ -1599,7 +1641,7 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 			}
 			continue
 		case "city":
-			setClause += fmt.Sprintf("%s = $%d", key, argIndex)
+			setClause += fmt.Sprintf("%s = $%d", quotePostgresName(key), argIndex)
 			values = append(values, value)
 			argIndex++
 			// Also update city_upd if city is being updated
@@ -1616,17 +1658,17 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 			// Skip _upd fields - they are auto-updated
 			continue
 		case "updatedby":
-			setClause += fmt.Sprintf("%s = $%d", key, argIndex)
+			setClause += fmt.Sprintf("%s = $%d", quotePostgresName(key), argIndex)
 			values = append(values, username)
 			argIndex++
 			continue
 		case "updatedon":
-			setClause += fmt.Sprintf("%s = CURRENT_TIMESTAMP", key)
+			setClause += fmt.Sprintf("%s = CURRENT_TIMESTAMP", quotePostgresName(key))
 			continue
 		}
 
 		// Regular field
-		setClause += fmt.Sprintf("%s = $%d", key, argIndex)
+		setClause += fmt.Sprintf("%s = $%d", quotePostgresName(key), argIndex)
 		values = append(values, value)
 		argIndex++
 	}
@@ -1643,10 +1685,10 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 		return nil, fmt.Errorf("no fields to update")
 	}
 
-	updateQuery := fmt.Sprintf("UPDATE tracking.hohaddresswhitelist SET %s WHERE %s = $1 RETURNING *", setClause, pkColumn)
+	updateQuery := fmt.Sprintf("UPDATE tracking.hohaddresswhitelist SET %s WHERE %s = $1 RETURNING *", setClause, quotePostgresName(pkColumn))
 
 	// Execute query and get result
-	row := db.QueryRow(updateQuery, values...)
+	row := q.QueryRowContext(ctx, updateQuery, values...)
 
 	// Scan the returned row
 	resultValues := make([]interface{}, len(columnNames))
@@ -1670,20 +1712,25 @@ func (s *HohAddressService) UpdateWhitelistRow(hohAddressDatabaseID string, rowI
 		}
 	}
 
+	if err := finishScopedWrite(ctx, tx, "hohaddresswhitelist", pkColumn, rowID, scopeCondition, scopeArgs); err != nil {
+		return nil, err
+	}
+
+	s.logRowEdit(hohAddressDatabaseID, "hohaddresswhitelist", pkColumn, models.RowEditUpdate, scope, current, result)
 	return result, nil
 }
 
-// DeleteWhitelistRow deletes a row from tracking.hohaddresswhitelist
-func (s *HohAddressService) DeleteWhitelistRow(hohAddressDatabaseID string, rowID interface{}) error {
+// DeleteWhitelistRow deletes a row from tracking.hohaddresswhitelist; rows outside the row
+// filters of the editor are not found
+func (s *HohAddressService) DeleteWhitelistRow(ctx context.Context, hohAddressDatabaseID string, scope RowScope, rowID interface{}) error {
 	db, err := s.connectToDatabase(hohAddressDatabaseID)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
 	// Get primary key column
 	var pkColumn string
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT column_name 
 		FROM information_schema.table_constraints tc
 		JOIN information_schema.key_column_usage kcu ON tc.constraint_name = kcu.constraint_name
@@ -1695,7 +1742,7 @@ func (s *HohAddressService) DeleteWhitelistRow(hohAddressDatabaseID string, rowI
 
 	if err != nil {
 		// Fallback to first column
-		err = db.QueryRow(`
+		err = db.QueryRowContext(ctx, `
 			SELECT column_name 
 			FROM information_schema.columns 
 			WHERE table_schema = 'tracking' 
@@ -1708,21 +1755,20 @@ func (s *HohAddressService) DeleteWhitelistRow(hohAddressDatabaseID string, rowI
 		}
 	}
 
-	deleteQuery := fmt.Sprintf("DELETE FROM tracking.hohaddresswhitelist WHERE %s = $1", pkColumn)
-	result, err := db.Exec(deleteQuery, rowID)
+	deleteQuery := fmt.Sprintf("DELETE FROM tracking.hohaddresswhitelist WHERE %s = $1", quotePostgresName(pkColumn))
+	scopeCondition, scopeArgs, err := s.editScopeCondition(ctx, db, hohAddressDatabaseID, "hohaddresswhitelist", scope, 2)
 	if err != nil {
-		return fmt.Errorf("failed to delete row: %w", err)
+		return err
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	if scopeCondition != "" {
+		deleteQuery += " AND " + scopeCondition
 	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("row not found")
+	deleted, err := deleteTrackingRow(ctx, db, deleteQuery+" RETURNING *", append([]interface{}{rowID}, scopeArgs...))
+	if err != nil {
+		return err
 	}
 
+	s.logRowEdit(hohAddressDatabaseID, "hohaddresswhitelist", pkColumn, models.RowEditDelete, scope, deleted, nil)
 	return nil
 }
 
@@ -1744,12 +1790,11 @@ type AddressCheckResult struct {
 }
 
 // CheckAddressStatus checks an address step by step and returns detailed information
-func (s *HohAddressService) CheckAddressStatus(hohAddressDatabaseID string, address1, address2, city, state, zip, programType string) (*AddressCheckResult, error) {
+func (s *HohAddressService) CheckAddressStatus(ctx context.Context, hohAddressDatabaseID string, address1, address2, city, state, zip, programType string) (*AddressCheckResult, error) {
 	db, err := s.connectToDatabase(hohAddressDatabaseID)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
 	steps := []AddressCheckStep{}
 	var finalSuccess int = 0
@@ -1767,7 +1812,7 @@ func (s *HohAddressService) CheckAddressStatus(hohAddressDatabaseID string, addr
 	steps = append(steps, step1)
 
 	var normalizedA1, normalizedA2, normalizedCity string
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT 
 			tracking.get_hohaddress1($1),
 			tracking.get_hohaddress2($2),
@@ -1799,7 +1844,7 @@ func (s *HohAddressService) CheckAddressStatus(hohAddressDatabaseID string, addr
 	steps = append(steps, step2)
 
 	var inBlacklist bool
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT EXISTS(
 			SELECT 1 FROM tracking.hohaddressblacklist
 			WHERE address1_upd = $1
@@ -1857,7 +1902,7 @@ func (s *HohAddressService) CheckAddressStatus(hohAddressDatabaseID string, addr
 		programTypeNormalized = programType
 	}
 
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT COALESCE(MAX(total), 0)
 		FROM tracking.hohaddressstatuslist
 		WHERE address1 = $1
@@ -1873,7 +1918,7 @@ func (s *HohAddressService) CheckAddressStatus(hohAddressDatabaseID string, addr
 
 	var inWhitelist bool
 	var whitelistCapacity int
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT 
 			EXISTS(
 				SELECT 1 FROM tracking.hohaddresswhitelist
@@ -0,0 +1,488 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// hohAddressTimeTravelTables lists the tracking tables whose past state can be rebuilt
+var hohAddressTimeTravelTables = map[string]bool{
+	"hohaddressblacklist": true,
+	"hohaddresswhitelist": true,
+}
+
+// GetTableAsOf rebuilds a whitelist or blacklist as it was at a point in time by undoing, newest
+// first, the row edits logged for it since. Edits made through the table row editor and the
+// HohAddress row, import and update routes are in that log. Rows whose updatedon is later than the
+// point in time without a logged edit are listed in UnloggedRows; rows deleted outside the log
+// cannot be told apart and stay missing. With a rowID only the row with that primary key is
+// returned. withRestoreSQL adds the statements that bring the current table back to that state.
+func (s *HohAddressService) GetTableAsOf(ctx context.Context, hohAddressDatabaseID, tableName string, at time.Time, rowID string, withRestoreSQL bool) (*models.HohAddressTableSnapshot, error) {
+	if !hohAddressTimeTravelTables[tableName] {
+		return nil, fmt.Errorf("table %s cannot be rebuilt", tableName)
+	}
+
+	hohAddressDB, err := s.GetDatabase(hohAddressDatabaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HohAddress database: %w", err)
+	}
+	db, err := s.connectToDatabase(hohAddressDatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := s.getOrderedColumns(ctx, db, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table tracking.%s not found", tableName)
+	}
+	primaryKey, err := getTrackingPrimaryKey(ctx, db, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(primaryKey) == 0 {
+		return nil, fmt.Errorf("%w: tracking.%s has no primary key", ErrInvalidFilter, tableName)
+	}
+	if rowID != "" && len(primaryKey) > 1 {
+		return nil, fmt.Errorf("%w: tracking.%s has a composite primary key, so a row cannot be picked by one ID", ErrInvalidFilter, tableName)
+	}
+
+	current, err := readTrackingRows(ctx, db, tableName, columns, primaryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var edits []models.TableRowEditLog
+	err = s.db.Where("connection_id = ? AND database_name = ? AND LOWER(schema_name) = 'tracking' AND LOWER(table_name) = ?",
+		hohAddressDB.ConnectionID, hohAddressDB.ConnectedDatabase(), tableName).
+		Where("status = ? AND created_at > ?", models.AuditEventStatusSuccess, at).
+		Order("created_at DESC, id DESC").
+		Find(&edits).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get row edits: %w", err)
+	}
+
+	past, err := undoRowEdits(current, primaryKey, edits)
+	if err != nil {
+		return nil, err
+	}
+	unlogged, err := unloggedTrackingRows(ctx, db, tableName, columns, primaryKey, at, edits)
+	if err != nil {
+		return nil, err
+	}
+	if rowID != "" {
+		current = rowsWithKey(current, primaryKey, rowID)
+		past = rowsWithKey(past, primaryKey, rowID)
+		unlogged = rowsWithKey(unlogged, primaryKey, rowID)
+	}
+
+	statements := restoreStatements(trackingTable(tableName), columns, primaryKey, current, past)
+	snapshot := &models.HohAddressTableSnapshot{
+		Table:       tableName,
+		AsOf:        at,
+		RowID:       rowID,
+		Rows:        past,
+		UndoneEdits: len(edits),
+		ChangedRows: len(statements),
+	}
+	if len(unlogged) > 0 {
+		snapshot.UnloggedRows = unlogged
+	} else if withRestoreSQL && len(statements) > 0 {
+		snapshot.RestoreSQL = "BEGIN;\n" + strings.Join(statements, "\n") + "\nCOMMIT;\n"
+	}
+	return snapshot, nil
+}
+
+// unloggedTrackingRows returns the primary keys of the rows whose updatedon is later than at while
+// none of the edits wrote them. Tables without an updatedon column have none.
+func unloggedTrackingRows(ctx context.Context, db *sql.DB, tableName string, columns, primaryKey []string, at time.Time, edits []models.TableRowEditLog) ([]models.RowValues, error) {
+	if !slices.Contains(columns, "updatedon") {
+		return nil, nil
+	}
+
+	logged := map[string]bool{}
+	for _, edit := range edits {
+		newValues, err := jsonRowValues(edit.NewValues)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := rowKey(primaryKey, newValues); ok {
+			logged[key] = true
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE updatedon > $1 ORDER BY %s", quoteIdentifiers(primaryKey), trackingTable(tableName), quoteIdentifiers(primaryKey))
+	rows, err := db.QueryContext(ctx, query, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rows changed since %s: %w", at.Format(time.RFC3339), err)
+	}
+	defer rows.Close()
+
+	unlogged := []models.RowValues{}
+	for rows.Next() {
+		row, err := scanQueryRow(rows, primaryKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		values, err := jsonRowValues(row)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := rowKey(primaryKey, values); ok && !logged[key] {
+			unlogged = append(unlogged, values)
+		}
+	}
+	return unlogged, rows.Err()
+}
+
+// logRowEdit adds a write to a whitelist or blacklist to the row edit log that GetTableAsOf undoes.
+// The write has been made by then, so failing to log it is only reported.
+func (s *HohAddressService) logRowEdit(hohAddressDatabaseID, tableName, keyColumn, operation string, scope RowScope, oldRow, newRow map[string]interface{}) {
+	if s.rowLogs == nil {
+		return
+	}
+	entry, err := s.rowEditLog(hohAddressDatabaseID, tableName, keyColumn, operation, oldRow, newRow)
+	if err != nil {
+		s.logger.Error("failed to log HohAddress row edit", "hohaddress_database_id", hohAddressDatabaseID, "table", tableName, "operation", operation, "error", err)
+		return
+	}
+	entry.UserID = scope.UserID
+	s.rowLogs.LogEdit(context.Background(), entry)
+}
+
+// rowEditLog builds the row edit log entry of a successful write to a tracking table
+func (s *HohAddressService) rowEditLog(hohAddressDatabaseID, tableName, keyColumn, operation string, oldRow, newRow map[string]interface{}) (*models.TableRowEditLog, error) {
+	var registration models.HohAddressDatabase
+	if err := s.db.First(&registration, "id = ?", hohAddressDatabaseID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get HohAddress database: %w", err)
+	}
+	oldValues, err := jsonRowValues(oldRow)
+	if err != nil {
+		return nil, err
+	}
+	newValues, err := jsonRowValues(newRow)
+	if err != nil {
+		return nil, err
+	}
+	keyRow := newValues
+	if keyRow == nil {
+		keyRow = oldValues
+	}
+
+	return &models.TableRowEditLog{
+		ConnectionID: registration.ConnectionID,
+		DatabaseName: registration.ConnectedDatabase(),
+		SchemaName:   "tracking",
+		Table:        tableName,
+		Operation:    operation,
+		RowKey:       models.RowValues{keyColumn: keyRow[keyColumn]},
+		OldValues:    oldValues,
+		NewValues:    newValues,
+		Status:       models.AuditEventStatusSuccess,
+	}, nil
+}
+
+// scanTrackingRow scans a whole row of a tracking table, read with SELECT *, into a map by column
+// name, turning byte slices into strings
+func scanTrackingRow(row *sql.Row, columnNames []string) (map[string]interface{}, error) {
+	values := make([]interface{}, len(columnNames))
+	ptrs := make([]interface{}, len(columnNames))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := row.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{}, len(columnNames))
+	for i, colName := range columnNames {
+		if b, ok := values[i].([]byte); ok {
+			result[colName] = string(b)
+		} else {
+			result[colName] = values[i]
+		}
+	}
+	return result, nil
+}
+
+// deleteTrackingRow runs a DELETE ... RETURNING * of a single row and returns the deleted row, or
+// ErrHohAddressRowNotFound when nothing matched
+func deleteTrackingRow(ctx context.Context, db *sql.DB, query string, args []interface{}) (map[string]interface{}, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete row: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete row: %w", err)
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to delete row: %w", err)
+		}
+		return nil, ErrHohAddressRowNotFound
+	}
+	deleted, err := scanQueryRow(rows, columns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan deleted row: %w", err)
+	}
+	return deleted, rows.Err()
+}
+
+// getTrackingPrimaryKey returns the primary key columns of a table in the tracking schema
+func getTrackingPrimaryKey(ctx context.Context, db *sql.DB, tableName string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT kcu.column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema
+		WHERE tc.table_schema = 'tracking'
+		AND tc.table_name = $1
+		AND tc.constraint_type = 'PRIMARY KEY'
+		ORDER BY kcu.ordinal_position
+	`, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine primary key: %w", err)
+	}
+	defer rows.Close()
+
+	var primaryKey []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("failed to scan primary key column: %w", err)
+		}
+		primaryKey = append(primaryKey, column)
+	}
+	return primaryKey, rows.Err()
+}
+
+// readTrackingRows reads every row of a tracking table in primary key order, with the values in
+// the form row edits are logged in
+func readTrackingRows(ctx context.Context, db *sql.DB, tableName string, columns, primaryKey []string) ([]models.RowValues, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s ORDER BY %s", quoteIdentifiers(columns), trackingTable(tableName), quoteIdentifiers(primaryKey))
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tracking.%s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	result := []models.RowValues{}
+	for rows.Next() {
+		row, err := scanQueryRow(rows, columns)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		values, err := jsonRowValues(row)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, values)
+	}
+	return result, rows.Err()
+}
+
+// jsonRowValues converts a row to the values it has once stored as JSON, keeping numbers exact, so
+// rows read from the table compare equal to logged ones
+func jsonRowValues(row map[string]any) (models.RowValues, error) {
+	if row == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode row: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var values models.RowValues
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("failed to decode row: %w", err)
+	}
+	return values, nil
+}
+
+// rowKeyText returns a primary key value as text, the way row IDs appear in HohAddress routes
+func rowKeyText(value any) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+// rowKey identifies a row by its primary key values; ok is false when one of them is missing
+func rowKey(primaryKey []string, row models.RowValues) (key string, ok bool) {
+	parts := make([]string, len(primaryKey))
+	for i, column := range primaryKey {
+		value, found := row[column]
+		if !found || value == nil {
+			return "", false
+		}
+		parts[i] = rowKeyText(value)
+	}
+	return strings.Join(parts, "\x00"), true
+}
+
+// undoRowEdits returns the rows as they were before the edits, which must be ordered newest first.
+// Rows keep their current order; rows the table no longer has follow in the order they are restored.
+func undoRowEdits(current []models.RowValues, primaryKey []string, edits []models.TableRowEditLog) ([]models.RowValues, error) {
+	order := []string{}
+	listed := map[string]bool{} // Keys in order, kept when their row is removed
+	state := map[string]models.RowValues{}
+	put := func(row models.RowValues) error {
+		key, ok := rowKey(primaryKey, row)
+		if !ok {
+			return fmt.Errorf("row lacks primary key values")
+		}
+		if !listed[key] {
+			listed[key] = true
+			order = append(order, key)
+		}
+		state[key] = row
+		return nil
+	}
+	for _, row := range current {
+		if err := put(row); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, edit := range edits {
+		oldValues, err := jsonRowValues(edit.OldValues)
+		if err != nil {
+			return nil, err
+		}
+		newValues, err := jsonRowValues(edit.NewValues)
+		if err != nil {
+			return nil, err
+		}
+
+		if edit.Operation == models.RowEditInsert || edit.Operation == models.RowEditUpdate {
+			if key, ok := rowKey(primaryKey, newValues); ok {
+				delete(state, key)
+			}
+		}
+		if edit.Operation == models.RowEditUpdate || edit.Operation == models.RowEditDelete {
+			if err := put(oldValues); err != nil {
+				return nil, fmt.Errorf("failed to undo row edit %d: %w", edit.ID, err)
+			}
+		}
+	}
+
+	past := make([]models.RowValues, 0, len(state))
+	for _, key := range order {
+		if row, ok := state[key]; ok {
+			past = append(past, row)
+		}
+	}
+	return past, nil
+}
+
+// rowsWithKey returns the rows whose single-column primary key is rowID
+func rowsWithKey(rows []models.RowValues, primaryKey []string, rowID string) []models.RowValues {
+	matched := []models.RowValues{}
+	for _, row := range rows {
+		if key, ok := rowKey(primaryKey, row); ok && key == rowID {
+			matched = append(matched, row)
+		}
+	}
+	return matched
+}
+
+// restoreStatements returns the statements turning the current rows of a table into the past ones:
+// deletes of rows added since, updates of the changed columns and inserts of removed rows. Columns
+// the table no longer has are left out.
+func restoreStatements(table string, columns, primaryKey []string, current, past []models.RowValues) []string {
+	pastByKey := make(map[string]models.RowValues, len(past))
+	for _, row := range past {
+		key, _ := rowKey(primaryKey, row)
+		pastByKey[key] = row
+	}
+	currentByKey := make(map[string]models.RowValues, len(current))
+
+	statements := []string{}
+	for _, row := range current {
+		key, _ := rowKey(primaryKey, row)
+		currentByKey[key] = row
+		if _, ok := pastByKey[key]; !ok {
+			statements = append(statements, fmt.Sprintf("DELETE FROM %s WHERE %s;", table, restoreKeyCondition(primaryKey, row)))
+		}
+	}
+	for _, row := range past {
+		key, _ := rowKey(primaryKey, row)
+		now, exists := currentByKey[key]
+		if !exists {
+			names := []string{}
+			values := []string{}
+			for _, column := range columns {
+				if value, ok := row[column]; ok {
+					names = append(names, quotePostgresName(column))
+					values = append(values, restoreLiteral(value))
+				}
+			}
+			statements = append(statements, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);", table, strings.Join(names, ", "), strings.Join(values, ", ")))
+			continue
+		}
+
+		assignments := []string{}
+		for _, column := range columns {
+			value, ok := row[column]
+			if ok && !sameValue(value, now[column]) {
+				assignments = append(assignments, quotePostgresName(column)+" = "+restoreLiteral(value))
+			}
+		}
+		if len(assignments) > 0 {
+			statements = append(statements, fmt.Sprintf("UPDATE %s SET %s WHERE %s;", table, strings.Join(assignments, ", "), restoreKeyCondition(primaryKey, now)))
+		}
+	}
+	return statements
+}
+
+// sameValue reports whether two row values are equal once stored as JSON
+func sameValue(a, b any) bool {
+	encodedA, _ := json.Marshal(a)
+	encodedB, _ := json.Marshal(b)
+	return bytes.Equal(encodedA, encodedB)
+}
+
+// restoreKeyCondition matches a row by its primary key values
+func restoreKeyCondition(primaryKey []string, row models.RowValues) string {
+	conditions := make([]string, len(primaryKey))
+	for i, column := range primaryKey {
+		conditions[i] = quotePostgresName(column) + " = " + restoreLiteral(row[column])
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// restoreLiteral formats a logged value as a SQL literal; text, timestamps and JSON are quoted and
+// left for PostgreSQL to cast to the column type
+func restoreLiteral(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case json.Number:
+		return v.String()
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	default:
+		encoded, _ := json.Marshal(v)
+		return "'" + strings.ReplaceAll(string(encoded), "'", "''") + "'"
+	}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"truadmin/internal/models"
+)
+
+func TestUndoRowEdits(t *testing.T) {
+	primaryKey := []string{"id"}
+	current := []models.RowValues{
+		{"id": json.Number("1"), "city": "Austin"},
+		{"id": json.Number("3"), "city": "Dallas"},
+	}
+	// Newest first, with values as they come back from the log
+	edits := []models.TableRowEditLog{
+		{ID: 4, Operation: models.RowEditInsert, NewValues: models.RowValues{"id": float64(3), "city": "Dallas"}},
+		{ID: 3, Operation: models.RowEditDelete, OldValues: models.RowValues{"id": float64(2), "city": "Houston"}},
+		{ID: 2, Operation: models.RowEditUpdate, OldValues: models.RowValues{"id": float64(1), "city": "Waco"}, NewValues: models.RowValues{"id": float64(1), "city": "Austin"}},
+	}
+
+	past, err := undoRowEdits(current, primaryKey, edits)
+	if err != nil {
+		t.Fatalf("undoRowEdits() error = %v", err)
+	}
+	want := []models.RowValues{
+		{"id": json.Number("1"), "city": "Waco"},
+		{"id": json.Number("2"), "city": "Houston"},
+	}
+	if !reflect.DeepEqual(past, want) {
+		t.Errorf("undoRowEdits() = %v, want %v", past, want)
+	}
+
+	if _, err := undoRowEdits(current, primaryKey, []models.TableRowEditLog{
+		{ID: 5, Operation: models.RowEditDelete, OldValues: models.RowValues{"city": "Plano"}},
+	}); err == nil {
+		t.Error("undoRowEdits() of a logged row without its key returned no error")
+	}
+}
+
+func TestRestoreStatements(t *testing.T) {
+	columns := []string{"id", "city", "note"}
+	primaryKey := []string{"id"}
+	current := []models.RowValues{
+		{"id": json.Number("1"), "city": "Austin", "note": nil},
+		{"id": json.Number("3"), "city": "Dallas", "note": nil},
+	}
+	past := []models.RowValues{
+		{"id": json.Number("1"), "city": "Waco", "note": nil},
+		{"id": json.Number("2"), "city": "O'Fallon", "note": "moved", "dropped": true},
+	}
+
+	got := restoreStatements(trackingTable("hohaddressblacklist"), columns, primaryKey, current, past)
+	want := []string{
+		`DELETE FROM "tracking"."hohaddressblacklist" WHERE "id" = 3;`,
+		`UPDATE "tracking"."hohaddressblacklist" SET "city" = 'Waco' WHERE "id" = 1;`,
+		`INSERT INTO "tracking"."hohaddressblacklist" ("id", "city", "note") VALUES (2, 'O''Fallon', 'moved');`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("restoreStatements() = %q, want %q", got, want)
+	}
+
+	if got := restoreStatements(trackingTable("hohaddressblacklist"), columns, primaryKey, current, current); len(got) != 0 {
+		t.Errorf("restoreStatements() of an unchanged table = %q, want none", got)
+	}
+}
+
+func TestRowsWithKey(t *testing.T) {
+	rows := []models.RowValues{
+		{"id": json.Number("1")},
+		{"id": json.Number("12")},
+	}
+	got := rowsWithKey(rows, []string{"id"}, "12")
+	if len(got) != 1 || got[0]["id"] != json.Number("12") {
+		t.Errorf("rowsWithKey() = %v", got)
+	}
+}
+
+func TestGetTableAsOfUnloggedRows(t *testing.T) {
+	svc, id, mock := newTestHohAddressService(t)
+	at := time.Now().Add(-time.Hour)
+
+	// A row deleted through the HohAddress routes is logged and comes back
+	mock.ExpectQuery("FROM information_schema.table_constraints").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id"))
+	mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM tracking.hohaddresswhitelist WHERE "id" = $1 RETURNING *`)).
+		WithArgs("7").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "city", "updatedon"}).AddRow(7, "Waco", at.Add(-time.Hour)))
+	if err := svc.DeleteWhitelistRow(context.Background(), id, RowScope{UserID: "admin", Admin: true}, "7"); err != nil {
+		t.Fatalf("DeleteWhitelistRow() error = %v", err)
+	}
+	var logged []models.TableRowEditLog
+	if err := svc.db.Find(&logged).Error; err != nil {
+		t.Fatalf("failed to read the row edit log: %v", err)
+	}
+	if len(logged) != 1 || logged[0].Operation != models.RowEditDelete || logged[0].SchemaName != "tracking" || logged[0].OldValues["city"] != "Waco" {
+		t.Fatalf("row edit log = %+v, want the deleted row", logged)
+	}
+
+	// Row 3 was changed since without going through the log
+	mock.ExpectQuery(`SELECT column_name\s+FROM information_schema.columns`).WithArgs("hohaddresswhitelist").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id").AddRow("city").AddRow("updatedon"))
+	mock.ExpectQuery("SELECT kcu.column_name").WithArgs("hohaddresswhitelist").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id", "city", "updatedon" FROM "tracking"."hohaddresswhitelist" ORDER BY "id"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "city", "updatedon"}).AddRow(3, "Dallas", at.Add(time.Minute)))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "tracking"."hohaddresswhitelist" WHERE updatedon > $1`)).
+		WithArgs(at).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+	snapshot, err := svc.GetTableAsOf(context.Background(), id, "hohaddresswhitelist", at, "", true)
+	if err != nil {
+		t.Fatalf("GetTableAsOf() error = %v", err)
+	}
+	if snapshot.UndoneEdits != 1 || len(snapshot.Rows) != 2 {
+		t.Errorf("GetTableAsOf() undid %d edits into %v, want the deleted row back", snapshot.UndoneEdits, snapshot.Rows)
+	}
+	wantUnlogged := []models.RowValues{{"id": json.Number("3")}}
+	if !reflect.DeepEqual(snapshot.UnloggedRows, wantUnlogged) || snapshot.RestoreSQL != "" {
+		t.Errorf("GetTableAsOf() unlogged rows = %v with restore SQL %q, want %v and none", snapshot.UnloggedRows, snapshot.RestoreSQL, wantUnlogged)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
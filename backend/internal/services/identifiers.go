@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Quoting policies of generated SQL
+const (
+	IdentifierQuoteAlways = "always" // Every name is quoted, so it keeps its exact spelling
+	IdentifierQuoteNeeded = "needed" // Only names the engine would fold, misparse or read as a keyword are quoted
+)
+
+// Case policies for names given by users, mappings and URLs
+const (
+	IdentifierCasePreserve    = "preserve"    // Names must match the catalog exactly
+	IdentifierCaseInsensitive = "insensitive" // Names resolve to the catalog spelling when exactly one matches ignoring case
+)
+
+// ErrUnknownIdentifier is returned when a schema, table or column is not in the catalog
+var ErrUnknownIdentifier = errors.New("unknown identifier")
+
+// IdentifierPolicy controls how schema, table and column names are quoted in generated SQL and
+// matched against the catalog. It is shared by the table browser, grants, HohAddress and TruETL.
+type IdentifierPolicy struct {
+	Quoting         string
+	Case            string
+	ValidateCatalog bool // Check names against the catalog before building SQL, naming close matches
+}
+
+var (
+	identifierPolicyMu sync.RWMutex
+	identifierPolicy   = IdentifierPolicy{Quoting: IdentifierQuoteAlways, Case: IdentifierCasePreserve, ValidateCatalog: true}
+)
+
+// SetIdentifierPolicy replaces the identifier policy; it is called once at startup
+func SetIdentifierPolicy(policy IdentifierPolicy) error {
+	if policy.Quoting != IdentifierQuoteAlways && policy.Quoting != IdentifierQuoteNeeded {
+		return fmt.Errorf("identifier quoting must be %s or %s, got %q", IdentifierQuoteAlways, IdentifierQuoteNeeded, policy.Quoting)
+	}
+	if policy.Case != IdentifierCasePreserve && policy.Case != IdentifierCaseInsensitive {
+		return fmt.Errorf("identifier case must be %s or %s, got %q", IdentifierCasePreserve, IdentifierCaseInsensitive, policy.Case)
+	}
+
+	identifierPolicyMu.Lock()
+	defer identifierPolicyMu.Unlock()
+	identifierPolicy = policy
+	return nil
+}
+
+// currentIdentifierPolicy returns the identifier policy in effect
+func currentIdentifierPolicy() IdentifierPolicy {
+	identifierPolicyMu.RLock()
+	defer identifierPolicyMu.RUnlock()
+	return identifierPolicy
+}
+
+// Names that can be left unquoted: PostgreSQL folds unquoted names to lower case and Snowflake to
+// upper case, MySQL and SQL Server keep them
+var (
+	plainPostgresName  = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+	plainSnowflakeName = regexp.MustCompile(`^[A-Z_][A-Z0-9_$]*$`)
+	plainMySQLName     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+)
+
+// reservedNames are keywords reserved by PostgreSQL or MySQL that must be quoted as names
+var reservedNames = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true, "as": true,
+	"asc": true, "asymmetric": true, "between": true, "both": true, "by": true, "case": true, "cast": true,
+	"change": true, "check": true, "collate": true, "column": true, "condition": true, "constraint": true,
+	"create": true, "cross": true, "current_catalog": true, "current_date": true, "current_role": true,
+	"current_time": true, "current_timestamp": true, "current_user": true, "database": true, "default": true,
+	"deferrable": true, "delete": true, "desc": true, "distinct": true, "do": true, "drop": true, "else": true,
+	"end": true, "except": true, "exists": true, "false": true, "fetch": true, "for": true, "foreign": true,
+	"from": true, "full": true, "grant": true, "group": true, "having": true, "in": true, "index": true,
+	"initially": true, "inner": true, "insert": true, "intersect": true, "interval": true, "into": true,
+	"is": true, "join": true, "key": true, "keys": true, "lateral": true, "leading": true, "left": true,
+	"like": true, "limit": true, "localtime": true, "localtimestamp": true, "match": true, "natural": true,
+	"not": true, "null": true, "offset": true, "on": true, "only": true, "option": true, "or": true,
+	"order": true, "outer": true, "placing": true, "primary": true, "range": true, "read": true,
+	"references": true, "returning": true, "right": true, "schema": true, "select": true, "session_user": true,
+	"set": true, "show": true, "some": true, "symmetric": true, "table": true, "then": true, "to": true,
+	"trailing": true, "true": true, "union": true, "unique": true, "update": true, "usage": true,
+	"user": true, "using": true, "values": true, "variadic": true, "when": true, "where": true,
+	"window": true, "with": true, "write": true,
+}
+
+// quoteName quotes a schema, table, column or role name for the dialect following the quoting policy
+func quoteName(d dialect, name string) string {
+	if currentIdentifierPolicy().Quoting == IdentifierQuoteNeeded && !reservedNames[strings.ToLower(name)] {
+		plain := plainMySQLName
+		switch d.name() {
+		case "postgres":
+			plain = plainPostgresName
+		case "snowflake":
+			plain = plainSnowflakeName
+		}
+		if plain.MatchString(name) {
+			return name
+		}
+	}
+	return d.quoteIdentifier(name)
+}
+
+// quotePostgresName quotes a name in SQL that only runs on PostgreSQL
+func quotePostgresName(name string) string {
+	return quoteName(postgresDialect{}, name)
+}
+
+// qualifiedName quotes a schema-qualified name; the schema is left out when empty
+func qualifiedName(d dialect, schemaName, name string) string {
+	if schemaName == "" {
+		return quoteName(d, name)
+	}
+	return quoteName(d, schemaName) + "." + quoteName(d, name)
+}
+
+// matchName returns the spelling of name among the catalog names: the name itself, or with the
+// insensitive case policy the only catalog name equal to it ignoring case. folded lists every
+// catalog name equal to name ignoring case when there is no exact match.
+func matchName(catalog []string, name string) (match string, folded []string, ok bool) {
+	for _, candidate := range catalog {
+		if candidate == name {
+			return name, nil, true
+		}
+		if strings.EqualFold(candidate, name) {
+			folded = append(folded, candidate)
+		}
+	}
+	if currentIdentifierPolicy().Case == IdentifierCaseInsensitive && len(folded) == 1 {
+		return folded[0], folded, true
+	}
+	return "", folded, false
+}
+
+// matchColumn returns the spelling of a column among the keys of a column map
+func matchColumn(columns map[string]string, name string) (string, bool) {
+	if _, ok := columns[name]; ok {
+		return name, true
+	}
+	catalog := make([]string, 0, len(columns))
+	for column := range columns {
+		catalog = append(catalog, column)
+	}
+	match, _, ok := matchName(catalog, name)
+	return match, ok
+}
+
+// resolveName returns the spelling of name among the catalog names following the case policy.
+// Without catalog validation an unknown name is returned unchanged for the database to report.
+func resolveName(kind, scope string, catalog []string, name string) (string, error) {
+	match, err := lookupName(kind, scope, catalog, name)
+	if err != nil && !currentIdentifierPolicy().ValidateCatalog {
+		return name, nil
+	}
+	return match, err
+}
+
+// lookupName is resolveName for checks that report unknown names whatever the policy. kind and
+// scope describe the name in errors, e.g. "column" and "public.orders".
+func lookupName(kind, scope string, catalog []string, name string) (string, error) {
+	match, folded, ok := matchName(catalog, name)
+	if ok {
+		return match, nil
+	}
+
+	where := ""
+	if scope != "" {
+		where = " in " + scope
+	}
+	switch {
+	case len(folded) == 1:
+		return "", fmt.Errorf("%w: %s %q does not exist%s; did you mean %q?", ErrUnknownIdentifier, kind, name, where, folded[0])
+	case len(folded) > 1:
+		return "", fmt.Errorf("%w: %s %q is ambiguous%s, it matches %s ignoring case", ErrUnknownIdentifier, kind, name, where, strings.Join(folded, ", "))
+	default:
+		return "", fmt.Errorf("%w: %s %q does not exist%s", ErrUnknownIdentifier, kind, name, where)
+	}
+}
+
+// resolveTable returns the catalog spelling of a schema and a table or view of a database. Nothing
+// is read when the policy neither validates names nor ignores case.
+func resolveTable(ctx context.Context, db *sql.DB, d dialect, dbName, schemaName, tableName string) (string, string, error) {
+	policy := currentIdentifierPolicy()
+	if !policy.ValidateCatalog && policy.Case == IdentifierCasePreserve {
+		return schemaName, tableName, nil
+	}
+
+	query, args := d.schemasQuery(dbName)
+	schemas, err := readCatalogNames(ctx, db, query, args)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list schemas: %w", err)
+	}
+	if schemaName, err = resolveName("schema", dbName, schemas, schemaName); err != nil {
+		return "", "", err
+	}
+
+	tables := []string{}
+	for _, list := range []func(string, string) (string, []any){d.tablesQuery, d.viewsQuery} {
+		query, args := list(dbName, schemaName)
+		names, err := readCatalogNames(ctx, db, query, args)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to list tables: %w", err)
+		}
+		tables = append(tables, names...)
+	}
+	if tableName, err = resolveName("table", schemaName, tables, tableName); err != nil {
+		return "", "", err
+	}
+	return schemaName, tableName, nil
+}
+
+// readCatalogNames returns the first column of a catalog listing; the dialect listings carry more
+// columns, which are skipped
+func readCatalogNames(ctx context.Context, db *sql.DB, query string, args []any) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	values := make([]any, len(columns))
+	for rows.Next() {
+		var name string
+		values[0] = &name
+		for i := 1; i < len(values); i++ {
+			values[i] = new(any)
+		}
+		if err := rows.Scan(values...); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
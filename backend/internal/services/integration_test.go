@@ -0,0 +1,153 @@
+//go:build integration
+
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"truadmin/internal/models"
+)
+
+// Run with: go test -tags integration ./internal/services/ (needs a Docker daemon)
+
+const integrationPostgresImage = "postgres:16-alpine"
+
+// newIntegrationDatabaseService starts a PostgreSQL container and returns a DatabaseService over a
+// saved connection to it, together with a superuser handle for setting up fixtures
+func newIntegrationDatabaseService(t *testing.T) (*DatabaseService, *models.Connection, *sql.DB) {
+	t.Helper()
+	ctx := context.Background()
+
+	container, err := tcpostgres.Run(ctx, integrationPostgresImage,
+		tcpostgres.WithDatabase("app"),
+		tcpostgres.WithUsername("admin"),
+		tcpostgres.WithPassword("secret"),
+		tcpostgres.BasicWaitStrategies(),
+	)
+	testcontainers.CleanupContainer(t, container)
+	if err != nil {
+		t.Fatalf("failed to start postgres container: %v", err)
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("failed to get container host: %v", err)
+	}
+	port, err := container.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		t.Fatalf("failed to get container port: %v", err)
+	}
+	dsn, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("failed to get connection string: %v", err)
+	}
+	admin, err := openPostgres(dsn)
+	if err != nil {
+		t.Fatalf("failed to open fixture connection: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	db := newTestInternalDB(t, &models.Connection{})
+	conn := &models.Connection{
+		ID:       "conn-it",
+		Name:     "integration",
+		Type:     "postgres",
+		Host:     host,
+		Port:     port.Int(),
+		Database: "app",
+		Username: "admin",
+		Password: "secret",
+		SSLMode:  "disable",
+	}
+	if err := db.Create(conn).Error; err != nil {
+		t.Fatalf("failed to save connection: %v", err)
+	}
+
+	pools := NewConnectionPoolManager(PoolConfig{MaxOpenConns: 4, MaxIdleConns: 2}, nil)
+	t.Cleanup(pools.Close)
+	return NewDatabaseService(NewConnectionService(pools, nil), QueryLimits{}), conn, admin
+}
+
+func TestIntegrationPostgres(t *testing.T) {
+	svc, conn, admin := newIntegrationDatabaseService(t)
+
+	fixtures := []string{
+		`CREATE SCHEMA "Sales"`,
+		`CREATE TABLE "Sales".orders (id serial PRIMARY KEY, customer text NOT NULL)`,
+		`INSERT INTO "Sales".orders (customer) VALUES ('ann'), ('bob')`,
+		`CREATE ROLE reporting NOLOGIN`,
+	}
+	for _, stmt := range fixtures {
+		if _, err := admin.Exec(stmt); err != nil {
+			t.Fatalf("fixture %q failed: %v", stmt, err)
+		}
+	}
+	var roleID string
+	if err := admin.QueryRow(`SELECT oid::text FROM pg_roles WHERE rolname = 'reporting'`).Scan(&roleID); err != nil {
+		t.Fatalf("failed to read role oid: %v", err)
+	}
+
+	t.Run("databases", func(t *testing.T) {
+		databases, err := svc.GetDatabases(context.Background(), conn.ID)
+		if err != nil {
+			t.Fatalf("GetDatabases() error = %v", err)
+		}
+		found := false
+		for _, database := range databases {
+			found = found || database.Name == "app"
+		}
+		if !found {
+			t.Errorf("GetDatabases() = %+v, want app listed", databases)
+		}
+	})
+
+	t.Run("query", func(t *testing.T) {
+		result, err := svc.ExecuteQuery(context.Background(), conn.ID, "", `SELECT customer FROM "Sales".orders ORDER BY id`)
+		if err != nil {
+			t.Fatalf("ExecuteQuery() error = %v", err)
+		}
+		if result.Error != "" || len(result.Rows) != 2 {
+			t.Errorf("ExecuteQuery() = %+v", result)
+		}
+	})
+
+	t.Run("grant and revoke", func(t *testing.T) {
+		req := &models.GrantRequest{ObjectType: "table", ObjectSchema: "Sales", ObjectName: "orders", Privileges: []string{"SELECT"}}
+		if err := svc.GrantPrivileges(context.Background(), conn.ID, roleID, req); err != nil {
+			t.Fatalf("GrantPrivileges() error = %v", err)
+		}
+		if !hasTablePrivilege(t, admin, "reporting", `"Sales".orders`, "SELECT") {
+			t.Fatal("SELECT was not granted")
+		}
+
+		if err := svc.RevokePrivileges(context.Background(), conn.ID, roleID, req); err != nil {
+			t.Fatalf("RevokePrivileges() error = %v", err)
+		}
+		if hasTablePrivilege(t, admin, "reporting", `"Sales".orders`, "SELECT") {
+			t.Fatal("SELECT was not revoked")
+		}
+	})
+
+	t.Run("unknown table", func(t *testing.T) {
+		req := &models.GrantRequest{ObjectType: "table", ObjectSchema: "Sales", ObjectName: "missing", Privileges: []string{"SELECT"}}
+		if err := svc.GrantPrivileges(context.Background(), conn.ID, roleID, req); !errors.Is(err, ErrUnknownIdentifier) {
+			t.Fatalf("GrantPrivileges() error = %v, want %v", err, ErrUnknownIdentifier)
+		}
+	})
+}
+
+// hasTablePrivilege asks the server whether a role holds a privilege on a table
+func hasTablePrivilege(t *testing.T, db *sql.DB, role, table, privilege string) bool {
+	t.Helper()
+	var granted bool
+	if err := db.QueryRow(`SELECT has_table_privilege($1, $2, $3)`, role, table, privilege).Scan(&granted); err != nil {
+		t.Fatalf("failed to check privilege: %v", err)
+	}
+	return granted
+}
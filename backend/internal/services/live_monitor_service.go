@@ -0,0 +1,525 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// ErrInvalidLiveMonitor is returned when a live monitor subscription or command fails validation
+var ErrInvalidLiveMonitor = errors.New("invalid live monitor subscription")
+
+// ErrLiveMonitorBusy is returned when a connection already has the maximum number of subscribers
+var ErrLiveMonitorBusy = errors.New("too many live monitor subscribers for this connection")
+
+// liveMonitorMaxInterval caps the push interval a client may ask for
+const liveMonitorMaxInterval = 5 * time.Minute
+
+// LiveMonitorService polls active queries, locks and deadlocks for WebSocket subscribers. Each
+// connection and database is polled by a single goroutine shared by all of its subscribers; every
+// subscriber gets its own interval, topics and incremental updates. Pollers publish what they
+// collect through the shared store, so with several replicas a sample taken by one of them within
+// the minimum interval is reused by the others instead of polling the database again.
+type LiveMonitorService struct {
+	databaseService *DatabaseService
+	store           SharedStore
+	origin          string // Tells this replica's published samples from those of others
+	defaultInterval time.Duration
+	minInterval     time.Duration
+	maxSubscribers  int
+	logger          *slog.Logger
+
+	mu          sync.Mutex
+	pollers     map[string]*livePoller // by connection ID and database name
+	subscribers map[string]int         // by connection ID
+}
+
+// livePoller polls one database for its subscribers
+type livePoller struct {
+	key          string
+	connectionID string
+	dbName       string
+	subscribers  map[*LiveSubscription]struct{}
+	wake         chan struct{}          // Signalled when a subscriber joins or changes its interval or topics
+	shared       map[string]*liveSample // Latest samples published by other replicas, by topic; guarded by service.mu
+	unsubscribe  func()
+}
+
+// liveSample is the items of one topic collected by a poller, as published to other replicas
+type liveSample struct {
+	Origin      string                     `json:"origin"`
+	Topic       string                     `json:"topic"`
+	Items       map[string]json.RawMessage `json:"items"`
+	CollectedAt time.Time                  `json:"collected_at"`
+}
+
+// LiveSubscription is a WebSocket client of the live monitor. Updates receives the messages to
+// push; when the client falls behind, pending deltas are dropped and a snapshot is sent instead.
+type LiveSubscription struct {
+	Updates chan *models.LiveMonitorMessage
+
+	service *LiveMonitorService
+	poller  *livePoller
+
+	// Guarded by service.mu
+	topics   map[string]bool
+	interval time.Duration
+	lastPush time.Time
+	pushNow  bool // Push without waiting for the interval
+	resync   bool // Send a snapshot instead of a delta
+
+	// Owned by the poller goroutine
+	sent map[string]map[string]json.RawMessage // Items last pushed, by topic and key
+}
+
+// NewLiveMonitorService creates a new live monitor service
+func NewLiveMonitorService(databaseService *DatabaseService, store SharedStore, defaultInterval, minInterval time.Duration, maxSubscribers int, logger *slog.Logger) *LiveMonitorService {
+	if minInterval <= 0 {
+		minInterval = time.Second
+	}
+	if defaultInterval < minInterval {
+		defaultInterval = minInterval
+	}
+	origin := make([]byte, 8)
+	rand.Read(origin)
+	return &LiveMonitorService{
+		databaseService: databaseService,
+		store:           store,
+		origin:          hex.EncodeToString(origin),
+		defaultInterval: defaultInterval,
+		minInterval:     minInterval,
+		maxSubscribers:  maxSubscribers,
+		pollers:         map[string]*livePoller{},
+		subscribers:     map[string]int{},
+		logger:          logging.OrDefault(logger),
+	}
+}
+
+// Subscribe registers a subscriber for a database; empty topics subscribe to all of them and a zero
+// interval uses the default. The first message is a full snapshot.
+func (s *LiveMonitorService) Subscribe(connectionID, dbName string, topics []string, interval time.Duration) (*LiveSubscription, error) {
+	if _, err := s.databaseService.connectionService.GetConnection(connectionID); err != nil {
+		return nil, err
+	}
+	topicSet, err := parseLiveTopics(topics)
+	if err != nil {
+		return nil, err
+	}
+	interval, err = s.normalizeInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxSubscribers > 0 && s.subscribers[connectionID] >= s.maxSubscribers {
+		return nil, ErrLiveMonitorBusy
+	}
+
+	key := connectionID + "/" + dbName
+	poller, ok := s.pollers[key]
+	if !ok {
+		poller = &livePoller{
+			key:          key,
+			connectionID: connectionID,
+			dbName:       dbName,
+			subscribers:  map[*LiveSubscription]struct{}{},
+			wake:         make(chan struct{}, 1),
+			shared:       map[string]*liveSample{},
+		}
+		unsubscribe, err := s.store.Subscribe(liveSampleChannel(key), func(message []byte) { s.receiveSample(poller, message) })
+		if err != nil {
+			s.logger.Warn("live monitor samples of other replicas unavailable", "poller", key, "error", err)
+			unsubscribe = func() {}
+		}
+		poller.unsubscribe = unsubscribe
+		s.pollers[key] = poller
+		go s.run(poller)
+	}
+
+	sub := &LiveSubscription{
+		Updates:  make(chan *models.LiveMonitorMessage, 1),
+		service:  s,
+		poller:   poller,
+		topics:   topicSet,
+		interval: interval,
+		pushNow:  true,
+		resync:   true,
+		sent:     map[string]map[string]json.RawMessage{},
+	}
+	poller.subscribers[sub] = struct{}{}
+	s.subscribers[connectionID]++
+	poller.signal()
+
+	return sub, nil
+}
+
+// Update applies a command of the client: new topics are answered with a snapshot, a new interval
+// takes effect from the next push
+func (sub *LiveSubscription) Update(cmd *models.LiveMonitorCommand) error {
+	s := sub.service
+
+	var topicSet map[string]bool
+	if cmd.Topics != nil {
+		var err error
+		if topicSet, err = parseLiveTopics(cmd.Topics); err != nil {
+			return err
+		}
+	}
+	var interval time.Duration
+	if cmd.Interval != "" {
+		d, err := time.ParseDuration(cmd.Interval)
+		if err != nil {
+			return fmt.Errorf("%w: invalid interval %q", ErrInvalidLiveMonitor, cmd.Interval)
+		}
+		if interval, err = s.normalizeInterval(d); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if topicSet != nil {
+		sub.topics = topicSet
+		sub.pushNow = true
+		sub.resync = true
+	}
+	if interval > 0 {
+		sub.interval = interval
+	}
+	sub.poller.signal()
+	return nil
+}
+
+// Interval returns the current push interval of the subscriber
+func (sub *LiveSubscription) Interval() time.Duration {
+	sub.service.mu.Lock()
+	defer sub.service.mu.Unlock()
+	return sub.interval
+}
+
+// Close unregisters the subscriber; the poller stops with its last subscriber
+func (sub *LiveSubscription) Close() {
+	s := sub.service
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := sub.poller.subscribers[sub]; !ok {
+		return
+	}
+	delete(sub.poller.subscribers, sub)
+	if s.subscribers[sub.poller.connectionID]--; s.subscribers[sub.poller.connectionID] <= 0 {
+		delete(s.subscribers, sub.poller.connectionID)
+	}
+	sub.poller.signal()
+}
+
+// normalizeInterval applies the default and checks the bounds of a push interval
+func (s *LiveMonitorService) normalizeInterval(interval time.Duration) (time.Duration, error) {
+	if interval == 0 {
+		return s.defaultInterval, nil
+	}
+	if interval < s.minInterval || interval > liveMonitorMaxInterval {
+		return 0, fmt.Errorf("%w: interval must be between %s and %s", ErrInvalidLiveMonitor, s.minInterval, liveMonitorMaxInterval)
+	}
+	return interval, nil
+}
+
+// parseLiveTopics validates topic names; empty means all topics
+func parseLiveTopics(topics []string) (map[string]bool, error) {
+	if len(topics) == 0 {
+		topics = models.LiveTopics
+	}
+
+	set := map[string]bool{}
+	for _, topic := range topics {
+		switch topic {
+		case models.LiveTopicActiveQueries, models.LiveTopicLocks, models.LiveTopicDeadlocks:
+			set[topic] = true
+		default:
+			return nil, fmt.Errorf("%w: unknown topic %q", ErrInvalidLiveMonitor, topic)
+		}
+	}
+	return set, nil
+}
+
+// signal wakes the poller without blocking
+func (p *livePoller) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run pushes updates to the subscribers of a poller as they fall due, until none are left
+func (s *LiveMonitorService) run(p *livePoller) {
+	for {
+		s.mu.Lock()
+		if len(p.subscribers) == 0 {
+			delete(s.pollers, p.key)
+			s.mu.Unlock()
+			p.unsubscribe()
+			return
+		}
+
+		now := time.Now()
+		due := []*LiveSubscription{}
+		wait := time.Duration(-1)
+		for sub := range p.subscribers {
+			// A little early is fine, so subscribers with the same interval are served by one poll
+			next := sub.lastPush.Add(sub.interval - sub.interval/10)
+			if sub.pushNow || !now.Before(next) {
+				due = append(due, sub)
+				continue
+			}
+			if d := next.Sub(now); wait < 0 || d < wait {
+				wait = d
+			}
+		}
+		s.mu.Unlock()
+
+		if len(due) > 0 {
+			s.push(p, due)
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-p.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// push polls the topics wanted by the due subscribers and sends each of them its changes
+func (s *LiveMonitorService) push(p *livePoller, due []*LiveSubscription) {
+	type subState struct {
+		sub    *LiveSubscription
+		topics map[string]bool
+		resync bool
+	}
+
+	s.mu.Lock()
+	states := make([]subState, 0, len(due))
+	wanted := map[string]bool{}
+	for _, sub := range due {
+		topics := make(map[string]bool, len(sub.topics))
+		for topic := range sub.topics {
+			topics[topic] = true
+			wanted[topic] = true
+		}
+		states = append(states, subState{sub: sub, topics: topics, resync: sub.resync})
+		sub.pushNow = false
+		sub.resync = false
+		sub.lastPush = time.Now()
+	}
+	s.mu.Unlock()
+
+	now := time.Now().UTC()
+	items := map[string]map[string]json.RawMessage{}
+	errs := map[string]error{}
+	for topic := range wanted {
+		if shared := s.sharedSample(p, topic, now); shared != nil {
+			items[topic] = shared
+			continue
+		}
+		items[topic], errs[topic] = s.collect(p, topic)
+		if errs[topic] == nil {
+			s.publishSample(p, topic, items[topic], now)
+		}
+	}
+
+	for _, st := range states {
+		for topic := range st.topics {
+			if err := errs[topic]; err != nil {
+				// Locks and deadlocks are PostgreSQL only; stop asking for them
+				if errors.Is(err, ErrUnsupportedDialect) {
+					s.mu.Lock()
+					delete(st.sub.topics, topic)
+					s.mu.Unlock()
+				}
+				st.sub.send(&models.LiveMonitorMessage{
+					Type:      models.LiveMessageError,
+					Timestamp: now,
+					Error:     fmt.Sprintf("%s: %v", topic, err),
+				})
+			}
+		}
+
+		msg := &models.LiveMonitorMessage{
+			Type:      models.LiveMessageDelta,
+			Timestamp: now,
+			Interval:  st.sub.Interval().String(),
+			Topics:    map[string]*models.LiveTopicDelta{},
+		}
+		if st.resync {
+			msg.Type = models.LiveMessageSnapshot
+			st.sub.sent = map[string]map[string]json.RawMessage{}
+		}
+
+		changed := false
+		for topic := range st.topics {
+			if errs[topic] != nil {
+				continue
+			}
+			delta := diffLiveItems(st.sub.sent[topic], items[topic])
+			st.sub.sent[topic] = items[topic]
+			if st.resync || len(delta.Upserted) > 0 || len(delta.Removed) > 0 {
+				msg.Topics[topic] = delta
+				changed = true
+			}
+		}
+		// Unchanged state is not pushed, except for the snapshot
+		if changed || st.resync {
+			st.sub.send(msg)
+		}
+	}
+}
+
+// send queues a message for the subscriber. When the client has not read the previous message
+// yet, it is replaced and the next push becomes a snapshot, since a dropped delta would corrupt
+// the state of the client.
+func (sub *LiveSubscription) send(msg *models.LiveMonitorMessage) {
+	select {
+	case sub.Updates <- msg:
+		return
+	default:
+	}
+
+	select {
+	case <-sub.Updates:
+	default:
+	}
+	select {
+	case sub.Updates <- msg:
+	default:
+	}
+
+	if msg.Type == models.LiveMessageSnapshot {
+		return
+	}
+	sub.service.mu.Lock()
+	sub.resync = true
+	sub.service.mu.Unlock()
+}
+
+// liveSampleChannel is the store channel carrying the samples of a connection and database
+func liveSampleChannel(pollerKey string) string {
+	return "live:" + pollerKey
+}
+
+// sharedSample returns the items of a topic published by another replica within the minimum
+// interval, or nil when the topic has to be collected
+func (s *LiveMonitorService) sharedSample(p *livePoller, topic string, now time.Time) map[string]json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sample, ok := p.shared[topic]
+	if !ok || now.Sub(sample.CollectedAt) >= s.minInterval {
+		return nil
+	}
+	return sample.Items
+}
+
+// publishSample shares the items of a topic with the pollers of other replicas
+func (s *LiveMonitorService) publishSample(p *livePoller, topic string, items map[string]json.RawMessage, now time.Time) {
+	message, err := json.Marshal(&liveSample{Origin: s.origin, Topic: topic, Items: items, CollectedAt: now})
+	if err != nil {
+		return
+	}
+	if err := s.store.Publish(liveSampleChannel(p.key), message); err != nil {
+		s.logger.Warn("failed to publish live monitor sample", "topic", topic, "error", err)
+	}
+}
+
+// receiveSample keeps a sample published by another replica for the next push
+func (s *LiveMonitorService) receiveSample(p *livePoller, message []byte) {
+	var sample liveSample
+	if err := json.Unmarshal(message, &sample); err != nil || sample.Origin == s.origin {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := p.shared[sample.Topic]; !ok || sample.CollectedAt.After(current.CollectedAt) {
+		p.shared[sample.Topic] = &sample
+	}
+}
+
+// collect reads one topic and keys its items
+func (s *LiveMonitorService) collect(p *livePoller, topic string) (map[string]json.RawMessage, error) {
+	switch topic {
+	case models.LiveTopicActiveQueries:
+		queries, err := s.databaseService.GetActiveQueries(context.Background(), p.connectionID, p.dbName, true)
+		if err != nil {
+			return nil, err
+		}
+		return keyLiveItems(queries, func(q *models.ActiveQuery) string { return q.ID })
+	case models.LiveTopicLocks:
+		locks, err := s.databaseService.GetLocks(context.Background(), p.connectionID, p.dbName, false)
+		if err != nil {
+			return nil, err
+		}
+		return keyLiveItems(locks, func(l *models.Lock) string {
+			return l.PID + "/" + l.LockType + "/" + l.Relation + "/" + l.Mode
+		})
+	default:
+		deadlocks, err := s.databaseService.GetDeadlocks(context.Background(), p.connectionID, p.dbName)
+		if err != nil {
+			return nil, err
+		}
+		return keyLiveItems(deadlocks, func(d *models.Deadlock) string { return d.ID })
+	}
+}
+
+// keyLiveItems encodes items by key; repeated keys get a #n suffix
+func keyLiveItems[T any](items []T, key func(T) string) (map[string]json.RawMessage, error) {
+	keyed := make(map[string]json.RawMessage, len(items))
+	for _, item := range items {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode item: %w", err)
+		}
+		k := key(item)
+		for n := 2; ; n++ {
+			if _, exists := keyed[k]; !exists {
+				break
+			}
+			k = fmt.Sprintf("%s#%d", key(item), n)
+		}
+		keyed[k] = encoded
+	}
+	return keyed, nil
+}
+
+// diffLiveItems returns the items added or changed in current and the keys no longer present
+func diffLiveItems(previous, current map[string]json.RawMessage) *models.LiveTopicDelta {
+	delta := &models.LiveTopicDelta{
+		Upserted: map[string]json.RawMessage{},
+		Removed:  []string{},
+	}
+	for k, item := range current {
+		if old, ok := previous[k]; !ok || string(old) != string(item) {
+			delta.Upserted[k] = item
+		}
+	}
+	for k := range previous {
+		if _, ok := current[k]; !ok {
+			delta.Removed = append(delta.Removed, k)
+		}
+	}
+	return delta
+}
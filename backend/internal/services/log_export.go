@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/models"
+)
+
+// ErrLogFilterUnsupported is returned when a log export is filtered by operation on a log that has none
+var ErrLogFilterUnsupported = errors.New("this log has no operation to filter by")
+
+// streamLogs applies an export filter to a log query and hands the matching entries to fn oldest
+// first. Entries are read one at a time, so exports are not limited by memory. Logs without an
+// operation column pass an empty operationColumn.
+func streamLogs[T any](query *gorm.DB, filter models.LogExportFilter, operationColumn string, fn func(*T) error) error {
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Operation != "" {
+		if operationColumn == "" {
+			return ErrLogFilterUnsupported
+		}
+		query = query.Where(operationColumn+" = ?", filter.Operation)
+	}
+
+	rows, err := query.Model(new(T)).Order("created_at, id").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to export logs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry T
+		if err := query.ScanRows(rows, &entry); err != nil {
+			return fmt.Errorf("failed to read log entry: %w", err)
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to export logs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoginLockoutPolicy configures how usernames and client IPs are locked out after failed logins.
+// Each failure past the limit doubles the lockout, up to MaxLockout.
+type LoginLockoutPolicy struct {
+	MaxAttempts      int           // Failed attempts per username before it is locked; zero disables the lockout
+	MaxAttemptsPerIP int           // Failed attempts per client IP, across usernames; zero disables the lockout
+	LockoutDuration  time.Duration // First lockout
+	MaxLockout       time.Duration
+	Window           time.Duration // Failed attempts are counted until this long after the first one
+}
+
+// LoginLockedError is returned by Login while the username or the client IP is locked out; the
+// password is not checked then
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginFailedError is returned by Login for wrong usernames or passwords. It carries what the user
+// logs record about the attempt; the message is the same for unknown usernames and wrong passwords.
+type LoginFailedError struct {
+	UserID    string // Empty for unknown usernames
+	ClientIP  string
+	Attempts  int64         // Failed attempts of the username within the window, this one included
+	LockedFor time.Duration // Lockout started by this attempt, if any
+	TOTP      bool          // The password was right but the two-factor code was not
+}
+
+func (e *LoginFailedError) Error() string { return "invalid credentials" }
+
+// Detail describes the attempt for the user logs
+func (e *LoginFailedError) Detail() string {
+	reason := "invalid password"
+	if e.TOTP {
+		reason = "invalid two-factor code"
+	}
+	detail := fmt.Sprintf("%s from %s, failed attempt %d", reason, e.ClientIP, e.Attempts)
+	if e.LockedFor > 0 {
+		detail += fmt.Sprintf(", locked for %s", e.LockedFor.Round(time.Second))
+	}
+	return detail
+}
+
+// loginLockKey and loginFailuresKey name the shared store entries of a username ("user") or client IP ("ip")
+func loginLockKey(kind, subject string) string {
+	return fmt.Sprintf("login:lock:%s:%s", kind, subject)
+}
+
+func loginFailuresKey(kind, subject string) string {
+	return fmt.Sprintf("login:failures:%s:%s", kind, subject)
+}
+
+// checkLoginLock returns a LoginLockedError when the username or client IP is locked out
+func (s *AuthService) checkLoginLock(username, clientIP string) error {
+	var retryAfter time.Duration
+	for _, key := range []string{loginLockKey("user", strings.ToLower(username)), loginLockKey("ip", clientIP)} {
+		value, ok, err := s.store.Get(key)
+		if err != nil {
+			s.logger.Warn("login lockout unavailable", "error", err)
+			return nil
+		}
+		if !ok {
+			continue
+		}
+		until, err := time.Parse(time.RFC3339Nano, string(value))
+		if err != nil {
+			continue
+		}
+		if wait := time.Until(until); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login of a username and client IP and locks out those past their
+// limit. It returns the failures of the username and the lockout of the username, if one started.
+func (s *AuthService) recordLoginFailure(username, clientIP string) (int64, time.Duration) {
+	attempts, lockedFor := s.countLoginFailure("user", strings.ToLower(username), s.lockout.MaxAttempts)
+	s.countLoginFailure("ip", clientIP, s.lockout.MaxAttemptsPerIP)
+	return attempts, lockedFor
+}
+
+// countLoginFailure counts a failed login of one subject and locks it out once it reached limit
+func (s *AuthService) countLoginFailure(kind, subject string, limit int) (int64, time.Duration) {
+	failures, err := s.store.Incr(loginFailuresKey(kind, subject), s.lockout.Window)
+	if err != nil {
+		s.logger.Warn("login lockout unavailable", "error", err)
+		return 0, 0
+	}
+	if limit <= 0 || failures < int64(limit) {
+		return failures, 0
+	}
+
+	lockedFor := s.lockout.LockoutDuration
+	for i := int64(limit); i < failures && lockedFor < s.lockout.MaxLockout; i++ {
+		lockedFor *= 2
+	}
+	if s.lockout.MaxLockout > 0 && lockedFor > s.lockout.MaxLockout {
+		lockedFor = s.lockout.MaxLockout
+	}
+	if lockedFor <= 0 {
+		return failures, 0
+	}
+
+	until := time.Now().Add(lockedFor).Format(time.RFC3339Nano)
+	if err := s.store.Set(loginLockKey(kind, subject), []byte(until), lockedFor); err != nil {
+		s.logger.Warn("failed to lock out login", "kind", kind, "subject", subject, "error", err)
+		return failures, 0
+	}
+	s.logger.Info("locked out login", "kind", kind, "subject", subject, "locked_for", lockedFor, "failures", failures)
+	return failures, lockedFor
+}
+
+// clearLoginFailures forgets the failed logins of a username after a successful login; those of the
+// client IP are kept, as they may belong to other usernames
+func (s *AuthService) clearLoginFailures(username string) {
+	if err := s.store.Delete(loginFailuresKey("user", strings.ToLower(username))); err != nil {
+		s.logger.Warn("failed to reset login failures", "error", err)
+	}
+}
+
+// UnlockUser lifts the lockout of a user and forgets their failed logins; lockouts of client IPs
+// stay in place
+func (s *AuthService) UnlockUser(userID string) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	username := strings.ToLower(user.Username)
+	if err := s.store.Delete(loginLockKey("user", username), loginFailuresKey("user", username)); err != nil {
+		return fmt.Errorf("failed to unlock user: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+-- HohAddress tracking schema: the status list loaded by the address pipeline, and the black- and
+-- whitelists maintained in truadmin. The _upd columns hold the normalized address used for matching.
+
+CREATE SCHEMA IF NOT EXISTS tracking;
+
+CREATE TABLE IF NOT EXISTS tracking.hohaddressstatuslist (
+    id          SERIAL PRIMARY KEY,
+    address1    VARCHAR(255) NOT NULL DEFAULT '',
+    address2    VARCHAR(255) NOT NULL DEFAULT '',
+    city        VARCHAR(100) NOT NULL DEFAULT '',
+    state       VARCHAR(2)   NOT NULL DEFAULT '',
+    zip         VARCHAR(10)  NOT NULL DEFAULT '',
+    programtype VARCHAR(20)  NOT NULL DEFAULT '',
+    total       INTEGER      NOT NULL DEFAULT 0,
+    updatedon   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS hohaddressstatuslist_address_idx
+    ON tracking.hohaddressstatuslist (address1, address2, city, state, zip, programtype);
+
+CREATE TABLE IF NOT EXISTS tracking.hohaddressblacklist (
+    id           SERIAL PRIMARY KEY,
+    address1     VARCHAR(255) NOT NULL,
+    address2     VARCHAR(255) NOT NULL DEFAULT '',
+    city         VARCHAR(100) NOT NULL,
+    state        VARCHAR(2)   NOT NULL,
+    zip          VARCHAR(10)  NOT NULL,
+    description  TEXT,
+    category     VARCHAR(100),
+    updatedby    VARCHAR(255),
+    updatedon    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    address1_upd VARCHAR(255) NOT NULL DEFAULT '',
+    address2_upd VARCHAR(255) NOT NULL DEFAULT '',
+    city_upd     VARCHAR(100) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS hohaddressblacklist_address_idx
+    ON tracking.hohaddressblacklist (address1_upd, address2_upd, city_upd, state, zip);
+
+CREATE TABLE IF NOT EXISTS tracking.hohaddresswhitelist (
+    id           SERIAL PRIMARY KEY,
+    address1     VARCHAR(255) NOT NULL,
+    address2     VARCHAR(255) NOT NULL DEFAULT '',
+    city         VARCHAR(100) NOT NULL,
+    state        VARCHAR(2)   NOT NULL,
+    zip          VARCHAR(10)  NOT NULL,
+    description  TEXT,
+    category     VARCHAR(100),
+    capacity     INTEGER      NOT NULL DEFAULT 0,
+    updatedby    VARCHAR(255),
+    updatedon    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    address1_upd VARCHAR(255) NOT NULL DEFAULT '',
+    address2_upd VARCHAR(255) NOT NULL DEFAULT '',
+    city_upd     VARCHAR(100) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS hohaddresswhitelist_address_idx
+    ON tracking.hohaddresswhitelist (address1_upd, address2_upd, city_upd, state, zip);
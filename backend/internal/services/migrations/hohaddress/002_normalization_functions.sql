@@ -0,0 +1,57 @@
+-- Address normalization used to match addresses regardless of spelling: upper case, punctuation
+-- removed, whitespace collapsed and common USPS suffixes and unit designators abbreviated.
+-- Databases that bring their own rules can replace these functions afterwards.
+
+CREATE OR REPLACE FUNCTION tracking.get_hohcity(city TEXT)
+RETURNS TEXT
+LANGUAGE sql
+IMMUTABLE
+AS $$
+    SELECT TRIM(REGEXP_REPLACE(REGEXP_REPLACE(UPPER(COALESCE(city, '')), '[^A-Z0-9 ]', ' ', 'g'), '\s+', ' ', 'g'))
+$$;
+
+CREATE OR REPLACE FUNCTION tracking.get_hohaddress1(address1 TEXT)
+RETURNS TEXT
+LANGUAGE plpgsql
+IMMUTABLE
+AS $$
+DECLARE
+    result TEXT := ' ' || tracking.get_hohcity(address1) || ' ';
+BEGIN
+    result := REPLACE(result, ' NORTH ', ' N ');
+    result := REPLACE(result, ' SOUTH ', ' S ');
+    result := REPLACE(result, ' EAST ', ' E ');
+    result := REPLACE(result, ' WEST ', ' W ');
+    result := REPLACE(result, ' STREET ', ' ST ');
+    result := REPLACE(result, ' AVENUE ', ' AVE ');
+    result := REPLACE(result, ' ROAD ', ' RD ');
+    result := REPLACE(result, ' DRIVE ', ' DR ');
+    result := REPLACE(result, ' BOULEVARD ', ' BLVD ');
+    result := REPLACE(result, ' LANE ', ' LN ');
+    result := REPLACE(result, ' COURT ', ' CT ');
+    result := REPLACE(result, ' PLACE ', ' PL ');
+    result := REPLACE(result, ' PARKWAY ', ' PKWY ');
+    result := REPLACE(result, ' HIGHWAY ', ' HWY ');
+    result := REPLACE(result, ' CIRCLE ', ' CIR ');
+    result := REPLACE(result, ' TERRACE ', ' TER ');
+    RETURN TRIM(result);
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION tracking.get_hohaddress2(address2 TEXT)
+RETURNS TEXT
+LANGUAGE plpgsql
+IMMUTABLE
+AS $$
+DECLARE
+    result TEXT := ' ' || tracking.get_hohcity(address2) || ' ';
+BEGIN
+    result := REPLACE(result, ' APARTMENT ', ' APT ');
+    result := REPLACE(result, ' SUITE ', ' STE ');
+    result := REPLACE(result, ' BUILDING ', ' BLDG ');
+    result := REPLACE(result, ' FLOOR ', ' FL ');
+    result := REPLACE(result, ' NUMBER ', ' ');
+    result := REPLACE(result, ' NO ', ' ');
+    RETURN TRIM(result);
+END;
+$$;
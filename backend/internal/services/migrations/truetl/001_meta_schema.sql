@@ -0,0 +1,44 @@
+-- TruETL mapping schema. meta.dms_tables holds one row per mapped field of a service;
+-- meta.dms_fields holds the field list of a mapped table.
+
+CREATE SCHEMA IF NOT EXISTS meta;
+
+CREATE TABLE IF NOT EXISTS meta.dms_tables (
+    id                 SERIAL PRIMARY KEY,
+    service_name       VARCHAR(255) NOT NULL,
+    source_db_name     VARCHAR(255) NOT NULL DEFAULT '',
+    source_db_type     VARCHAR(50)  NOT NULL DEFAULT '',
+    source_schema_name VARCHAR(255) NOT NULL DEFAULT '',
+    source_table_name  VARCHAR(255) NOT NULL DEFAULT '',
+    source_field_name  VARCHAR(255) NOT NULL DEFAULT '',
+    source_field_type  VARCHAR(100) NOT NULL DEFAULT '',
+    target_db_name     VARCHAR(255) NOT NULL DEFAULT '',
+    target_db_type     VARCHAR(50)  NOT NULL DEFAULT '',
+    target_schema_name VARCHAR(255) NOT NULL DEFAULT '',
+    target_table_name  VARCHAR(255) NOT NULL DEFAULT '',
+    target_field_name  VARCHAR(255) NOT NULL DEFAULT '',
+    target_field_type  VARCHAR(100) NOT NULL DEFAULT '',
+    target_field_value TEXT         NOT NULL DEFAULT '',
+    is_id              INTEGER      NOT NULL DEFAULT 0,
+    row_num            INTEGER      NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS dms_tables_service_idx
+    ON meta.dms_tables (service_name, source_db_name, source_schema_name, source_table_name);
+
+CREATE INDEX IF NOT EXISTS dms_tables_target_idx
+    ON meta.dms_tables (target_db_name, target_schema_name, target_table_name);
+
+CREATE TABLE IF NOT EXISTS meta.dms_fields (
+    id             SERIAL PRIMARY KEY,
+    table_id       INTEGER      NOT NULL,
+    source_field   VARCHAR(255) NOT NULL DEFAULT '',
+    source_type    VARCHAR(100) NOT NULL DEFAULT '',
+    target_field   VARCHAR(255) NOT NULL DEFAULT '',
+    target_type    VARCHAR(100) NOT NULL DEFAULT '',
+    target_value   TEXT         NOT NULL DEFAULT '',
+    is_primary_key BOOLEAN      NOT NULL DEFAULT FALSE,
+    row_order      INTEGER      NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS dms_fields_table_idx ON meta.dms_fields (table_id, row_order);
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"truadmin/internal/models"
+)
+
+// RepointSchemaError is returned when re-pointing a TruETL or HohAddress registration would switch
+// to a database that lacks tables or columns of the module schema
+type RepointSchemaError struct {
+	DatabaseName string
+	Missing      []string // schema.table or schema.table.column
+}
+
+func (e *RepointSchemaError) Error() string {
+	return fmt.Sprintf("database %s does not have the expected schema, missing: %s", e.DatabaseName, strings.Join(e.Missing, ", "))
+}
+
+// moduleSchema names the schema a module works in and the tables a database needs at the least
+type moduleSchema struct {
+	module   string // Directory of the module's embedded migrations
+	name     string
+	required []string // Tables of the schema, lower case
+}
+
+var (
+	truETLSchema     = moduleSchema{module: "truetl", name: "meta", required: []string{"dms_tables", "dms_fields"}}
+	hohAddressSchema = moduleSchema{module: "hohaddress", name: "tracking", required: []string{"hohaddressstatuslist", "hohaddressblacklist", "hohaddresswhitelist"}}
+)
+
+// checkRepointTarget compares the module schema of the database a registration connects to now with
+// the database it should switch to. Every table and column found in the current database must exist
+// in the target; when the current database cannot be read, e.g. because it was renamed away, only the
+// required tables are checked.
+func (s *ConnectionService) checkRepointTarget(ctx context.Context, schema moduleSchema, current *models.Connection, currentDB string, target *models.Connection, targetDB string) error {
+	targetConn, _, err := s.pools.get(target, targetDB)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	actual, err := readModuleSchema(ctx, targetConn, schema.name)
+	if err != nil {
+		return fmt.Errorf("failed to read schema of %s: %w", targetDB, err)
+	}
+
+	var expected []string
+	if current != nil {
+		if currentConn, _, err := s.pools.get(current, currentDB); err != nil {
+			s.logger.WarnContext(ctx, "cannot read module schema, checking required tables only", "schema", schema.name, "database", currentDB, "error", err)
+		} else if expected, err = readModuleSchema(ctx, currentConn, schema.name); err != nil {
+			s.logger.WarnContext(ctx, "cannot read module schema, checking required tables only", "schema", schema.name, "database", currentDB, "error", err)
+			expected = nil
+		}
+	}
+	if len(expected) == 0 {
+		for _, table := range schema.required {
+			expected = append(expected, schema.name+"."+table)
+		}
+	}
+
+	found := make(map[string]bool, len(actual))
+	for _, name := range actual {
+		found[name] = true
+	}
+	missing := []string{}
+	for _, name := range expected {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return &RepointSchemaError{DatabaseName: targetDB, Missing: missing}
+	}
+	return nil
+}
+
+// readModuleSchema lists the tables of a PostgreSQL schema as schema.table and their columns as
+// schema.table.column, in lower case as the modules match them case-insensitively
+func readModuleSchema(ctx context.Context, db *sql.DB, schemaName string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT LOWER(t.table_name), LOWER(COALESCE(c.column_name, ''))
+		FROM information_schema.tables t
+		LEFT JOIN information_schema.columns c
+			ON c.table_schema = t.table_schema
+			AND c.table_name = t.table_name
+		WHERE LOWER(t.table_schema) = $1
+		AND t.table_type = 'BASE TABLE'
+		ORDER BY 1, 2
+	`, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	lastTable := ""
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+		if table != lastTable {
+			names = append(names, schemaName+"."+table)
+			lastTable = table
+		}
+		if column != "" {
+			names = append(names, schemaName+"."+table+"."+column)
+		}
+	}
+	return names, rows.Err()
+}
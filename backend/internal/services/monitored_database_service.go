@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// ErrInvalidMonitoredDatabase is returned when a monitored database request fails validation
+var ErrInvalidMonitoredDatabase = errors.New("invalid monitored database")
+
+// ErrMonitoredDatabaseExists is returned when a database of a connection is registered twice
+var ErrMonitoredDatabaseExists = errors.New("database is already monitored")
+
+// MonitoredDatabaseService manages the registry of databases collected by the background
+// collectors. The collector jobs read it on every run, so changes apply without a restart.
+type MonitoredDatabaseService struct {
+	db                *gorm.DB
+	connectionService *ConnectionService
+
+	mu            sync.Mutex
+	lastCollected map[string]time.Time // By monitored database ID and collector
+}
+
+// NewMonitoredDatabaseService creates a new monitored database service
+func NewMonitoredDatabaseService(connectionService *ConnectionService) *MonitoredDatabaseService {
+	return &MonitoredDatabaseService{
+		db:                database.GetDB(),
+		connectionService: connectionService,
+		lastCollected:     map[string]time.Time{},
+	}
+}
+
+// GetDatabases returns the monitored databases of a connection, or of every connection when connectionID is empty
+func (s *MonitoredDatabaseService) GetDatabases(connectionID string) (*models.MonitoredDatabaseList, error) {
+	databases := []models.MonitoredDatabase{}
+
+	query := s.db.Order("connection_id, database_name")
+	if connectionID != "" {
+		query = query.Where("connection_id = ?", connectionID)
+	}
+	if err := query.Find(&databases).Error; err != nil {
+		return nil, fmt.Errorf("failed to get monitored databases: %w", err)
+	}
+
+	return &models.MonitoredDatabaseList{Databases: databases, Collectors: models.MonitoringCollectors}, nil
+}
+
+// GetDatabase returns a monitored database of a connection
+func (s *MonitoredDatabaseService) GetDatabase(connectionID, id string) (*models.MonitoredDatabase, error) {
+	var monitored models.MonitoredDatabase
+	if err := s.db.Where("id = ? AND connection_id = ?", id, connectionID).First(&monitored).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("monitored database not found")
+		}
+		return nil, fmt.Errorf("failed to get monitored database: %w", err)
+	}
+	return &monitored, nil
+}
+
+// CreateDatabase registers a database of a PostgreSQL connection for background collection
+func (s *MonitoredDatabaseService) CreateDatabase(connectionID, userID string, req *models.MonitoredDatabaseRequest) (*models.MonitoredDatabase, error) {
+	conn, err := s.connectionService.GetConnection(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	// The collectors read the PostgreSQL statistics views
+	if conn.Type != "postgres" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDialect, conn.Type)
+	}
+
+	monitored := &models.MonitoredDatabase{
+		ID:           uuid.New().String(),
+		ConnectionID: connectionID,
+		CreatedBy:    userID,
+	}
+	if err := applyMonitoredDatabaseRequest(monitored, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkUnique(monitored); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(monitored).Error; err != nil {
+		return nil, fmt.Errorf("failed to create monitored database: %w", err)
+	}
+	return monitored, nil
+}
+
+// UpdateDatabase replaces the settings of a monitored database
+func (s *MonitoredDatabaseService) UpdateDatabase(connectionID, id string, req *models.MonitoredDatabaseRequest) (*models.MonitoredDatabase, error) {
+	monitored, err := s.GetDatabase(connectionID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyMonitoredDatabaseRequest(monitored, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkUnique(monitored); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(monitored).Error; err != nil {
+		return nil, fmt.Errorf("failed to update monitored database: %w", err)
+	}
+	return monitored, nil
+}
+
+// DeleteDatabase removes a database from the registry
+func (s *MonitoredDatabaseService) DeleteDatabase(connectionID, id string) error {
+	result := s.db.Where("id = ? AND connection_id = ?", id, connectionID).Delete(&models.MonitoredDatabase{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete monitored database: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("monitored database not found")
+	}
+
+	s.mu.Lock()
+	for _, collector := range models.MonitoringCollectors {
+		delete(s.lastCollected, id+"/"+collector)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// checkUnique rejects a second registration of the same database of a connection
+func (s *MonitoredDatabaseService) checkUnique(monitored *models.MonitoredDatabase) error {
+	var count int64
+	if err := s.db.Model(&models.MonitoredDatabase{}).
+		Where("connection_id = ? AND database_name = ? AND id <> ?", monitored.ConnectionID, monitored.DatabaseName, monitored.ID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check monitored databases: %w", err)
+	}
+	if count > 0 {
+		return ErrMonitoredDatabaseExists
+	}
+	return nil
+}
+
+// applyMonitoredDatabaseRequest validates a request and copies it onto a monitored database
+func applyMonitoredDatabaseRequest(monitored *models.MonitoredDatabase, req *models.MonitoredDatabaseRequest) error {
+	if req.IntervalSeconds < 0 {
+		return fmt.Errorf("%w: interval_seconds must not be negative", ErrInvalidMonitoredDatabase)
+	}
+
+	collectors := models.StringList{}
+	for _, collector := range req.Collectors {
+		if !slices.Contains(models.MonitoringCollectors, collector) {
+			return fmt.Errorf("%w: unknown collector %q", ErrInvalidMonitoredDatabase, collector)
+		}
+		if !slices.Contains(collectors, collector) {
+			collectors = append(collectors, collector)
+		}
+	}
+	if len(req.Collectors) == 0 {
+		collectors = append(collectors, models.MonitoringCollectors...)
+	}
+
+	monitored.DatabaseName = req.DatabaseName
+	monitored.IntervalSeconds = req.IntervalSeconds
+	monitored.Collectors = collectors
+	monitored.Enabled = req.Enabled == nil || *req.Enabled
+	return nil
+}
+
+// monitoringTargets are the databases a collector samples in one run
+type monitoringTargets struct {
+	all       bool                       // The registry is empty: every database is sampled
+	databases map[string]map[string]bool // Database names by connection ID
+}
+
+// includesConnection reports whether any database of a connection is sampled
+func (t *monitoringTargets) includesConnection(connectionID string) bool {
+	return t.all || len(t.databases[connectionID]) > 0
+}
+
+// includes reports whether a database of a connection is sampled
+func (t *monitoringTargets) includes(connectionID, dbName string) bool {
+	return t.all || t.databases[connectionID][dbName]
+}
+
+// dueTargets returns the databases a collector samples in a run at now and records them as
+// collected. A registered database is due when it is enabled, has the collector and its interval
+// has passed since the collector last sampled it.
+func (s *MonitoredDatabaseService) dueTargets(collector string, now time.Time) (*monitoringTargets, error) {
+	var registered []models.MonitoredDatabase
+	if err := s.db.Find(&registered).Error; err != nil {
+		return nil, fmt.Errorf("failed to get monitored databases: %w", err)
+	}
+
+	targets := &monitoringTargets{all: len(registered) == 0, databases: map[string]map[string]bool{}}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, monitored := range registered {
+		if !monitored.Enabled || !slices.Contains(monitored.Collectors, collector) {
+			continue
+		}
+		key := monitored.ID + "/" + collector
+		// Job runs drift slightly; the second of slack keeps an interval equal to the job's from skipping every other run
+		interval := time.Duration(monitored.IntervalSeconds) * time.Second
+		if last, ok := s.lastCollected[key]; ok && now.Sub(last)+time.Second < interval {
+			continue
+		}
+		s.lastCollected[key] = now
+
+		if targets.databases[monitored.ConnectionID] == nil {
+			targets.databases[monitored.ConnectionID] = map[string]bool{}
+		}
+		targets.databases[monitored.ConnectionID][monitored.DatabaseName] = true
+	}
+	return targets, nil
+}
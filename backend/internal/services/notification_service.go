@@ -0,0 +1,312 @@
+package services
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"truadmin/internal/logging"
+)
+
+// Notification represents an alert delivered to the configured channels
+type Notification struct {
+	Event     string    `json:"event"`
+	Subject   string    `json:"subject"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SMTP transport security modes
+const (
+	SMTPTLSStartTLS = "starttls" // Plain connection upgraded with STARTTLS (required)
+	SMTPTLSImplicit = "tls"      // TLS from the first byte, usually port 465
+	SMTPTLSNone     = "none"     // No encryption, only for trusted relays
+)
+
+// SMTPConfig holds the settings used to deliver email notifications
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	TLSMode  string // One of the SMTPTLS* modes; empty means STARTTLS
+}
+
+// AlertSilencer decides whether an alert rule of a connection is currently silenced
+type AlertSilencer interface {
+	IsSilenced(connectionID, rule string) bool
+}
+
+// NotificationService delivers alerts about background failures and email reports
+type NotificationService struct {
+	webhookURL string
+	mu         sync.RWMutex
+	smtp       SMTPConfig
+	silencer   AlertSilencer
+	client     *http.Client
+	logger     *slog.Logger
+}
+
+// NewNotificationService creates a new notification service.
+// When webhookURL is empty notifications are only written to the server log;
+// email is available when smtpConfig.Host and smtpConfig.From are set.
+func NewNotificationService(webhookURL string, smtpConfig SMTPConfig, logger *slog.Logger) *NotificationService {
+	return &NotificationService{
+		webhookURL: webhookURL,
+		smtp:       smtpConfig,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		logger:     logging.OrDefault(logger),
+	}
+}
+
+// Channels returns the names of the configured notification channels
+func (s *NotificationService) Channels() []string {
+	channels := []string{"log"}
+	if s.webhookURL != "" {
+		channels = append(channels, "webhook")
+	}
+	if s.EmailEnabled() {
+		channels = append(channels, "email")
+	}
+	return channels
+}
+
+// EmailEnabled reports whether SMTP delivery is configured
+func (s *NotificationService) EmailEnabled() bool {
+	cfg := s.smtpConfig()
+	return cfg.Host != "" && cfg.From != ""
+}
+
+// SetSMTPConfig replaces the SMTP settings used for subsequent emails
+func (s *NotificationService) SetSMTPConfig(cfg SMTPConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.smtp = cfg
+}
+
+// SetSilencer sets the silencer consulted by NotifyConnection
+func (s *NotificationService) SetSilencer(silencer AlertSilencer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.silencer = silencer
+}
+
+// smtpConfig returns the current SMTP settings
+func (s *NotificationService) smtpConfig() SMTPConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.smtp
+}
+
+// ProbeWebhook checks that the webhook host accepts TCP connections without posting a notification
+func (s *NotificationService) ProbeWebhook() error {
+	if s.webhookURL == "" {
+		return fmt.Errorf("webhook is not configured")
+	}
+	return probeURL(s.webhookURL)
+}
+
+// ProbeSMTP checks that the SMTP server accepts TCP connections
+func (s *NotificationService) ProbeSMTP() error {
+	if !s.EmailEnabled() {
+		return fmt.Errorf("email delivery is not configured")
+	}
+	cfg := s.smtpConfig()
+	return probeTCP(net.JoinHostPort(cfg.Host, cfg.port()))
+}
+
+// probeURL dials the host of an HTTP(S) URL
+func probeURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid URL %q", rawURL)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return probeTCP(net.JoinHostPort(u.Hostname(), port))
+}
+
+// probeTCP dials an address and closes the connection immediately
+func probeTCP(address string) error {
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("%s is unreachable: %w", address, err)
+	}
+	return conn.Close()
+}
+
+// SendEmail sends a plain-text email to the given recipients
+func (s *NotificationService) SendEmail(to []string, subject, body string) error {
+	if !s.EmailEnabled() {
+		return fmt.Errorf("email delivery is not configured")
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+
+	cfg := s.smtpConfig()
+
+	// Header values must not contain line breaks
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+
+	var msg strings.Builder
+	msg.WriteString("From: " + cfg.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + subject + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(body)
+
+	if err := sendMail(cfg, to, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	s.logger.Info("sent email", "subject", subject, "to", to)
+	return nil
+}
+
+// port returns the configured port, defaulting by TLS mode
+func (c SMTPConfig) port() string {
+	if c.Port != "" {
+		return c.Port
+	}
+	if c.TLSMode == SMTPTLSImplicit {
+		return "465"
+	}
+	return "587"
+}
+
+// sendMail delivers a message honoring the configured TLS mode.
+// Unlike smtp.SendMail it fails instead of falling back to plain text when STARTTLS is unavailable.
+func sendMail(cfg SMTPConfig, to []string, msg []byte) error {
+	address := net.JoinHostPort(cfg.Host, cfg.port())
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+
+	var conn net.Conn
+	var err error
+	if cfg.TLSMode == SMTPTLSImplicit {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", address, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", address, 10*time.Second)
+	}
+	if err != nil {
+		return fmt.Errorf("%s is unreachable: %w", address, err)
+	}
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if cfg.TLSMode == "" || cfg.TLSMode == SMTPTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if cfg.Username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted connection except to localhost
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(cfg.From); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// NotifyConnection sends a connection alert unless its rule (the event name) is silenced for the connection
+func (s *NotificationService) NotifyConnection(connectionID, event, subject, message string) error {
+	s.mu.RLock()
+	silencer := s.silencer
+	s.mu.RUnlock()
+
+	if silencer != nil && silencer.IsSilenced(connectionID, event) {
+		s.logger.Info("silenced notification", "event", event, "subject", subject, "message", message)
+		return nil
+	}
+	return s.Notify(event, subject, message)
+}
+
+// Notify sends a notification to all configured channels
+func (s *NotificationService) Notify(event, subject, message string) error {
+	notification := Notification{
+		Event:     event,
+		Subject:   subject,
+		Message:   message,
+		CreatedAt: time.Now(),
+	}
+
+	s.logger.Info("notification", "event", event, "subject", subject, "message", message)
+
+	if s.webhookURL == "" {
+		return nil
+	}
+
+	if err := s.sendWebhook(&notification); err != nil {
+		s.logger.Error("failed to deliver notification webhook", "event", event, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// sendWebhook posts the notification as JSON to the configured webhook URL
+func (s *NotificationService) sendWebhook(notification *Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	resp, err := s.client.Post(s.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// overviewQueryTimeout bounds the figures read from one server, so an unreachable server does not
+// hold up the whole overview
+const overviewQueryTimeout = 10 * time.Second
+
+// alertStatusSeverity orders alert statuses from harmless to worst
+var alertStatusSeverity = map[string]int{
+	models.CustomQueryStatusOK:       0,
+	models.CustomQueryStatusPending:  1,
+	models.CustomQueryStatusWarning:  2,
+	models.CustomQueryStatusFailing:  3,
+	models.CustomQueryStatusCritical: 4,
+}
+
+// OverviewService collects version, uptime, size, session and alert figures of every connection for
+// the landing view. The overview is cached, so reloading the page does not query every server again.
+type OverviewService struct {
+	connectionService *ConnectionService
+	databaseService   *DatabaseService
+	alertSources      []AlertSource
+	accessGrants      *AccessGrantService
+	ttl               time.Duration // Zero disables the cache
+	logger            *slog.Logger
+
+	mu     sync.Mutex // Held while collecting, so concurrent requests share one collection
+	cached *models.Overview
+}
+
+// NewOverviewService creates a new overview service; collected overviews are reused for ttl
+func NewOverviewService(connectionService *ConnectionService, databaseService *DatabaseService, accessGrants *AccessGrantService, ttl time.Duration, logger *slog.Logger, alertSources ...AlertSource) *OverviewService {
+	return &OverviewService{
+		connectionService: connectionService,
+		databaseService:   databaseService,
+		alertSources:      alertSources,
+		accessGrants:      accessGrants,
+		ttl:               ttl,
+		logger:            logging.OrDefault(logger),
+	}
+}
+
+// GetOverview returns the overview of the connections the user may read. The overview of all
+// connections is collected concurrently, or the cached one is used while it is fresh and refresh is
+// not requested.
+func (s *OverviewService) GetOverview(userID string, refresh bool) (*models.Overview, error) {
+	overview, err := s.collect(refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := &models.Overview{
+		GeneratedAt: overview.GeneratedAt,
+		Connections: make([]models.ConnectionOverview, 0, len(overview.Connections)),
+	}
+	for _, conn := range overview.Connections {
+		allowed, err := s.accessGrants.CanReadConnection(userID, conn.ConnectionID)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			visible.Connections = append(visible.Connections, conn)
+		}
+	}
+	return visible, nil
+}
+
+// collect returns the overview of all connections, reusing the cached one while it is fresh
+func (s *OverviewService) collect(refresh bool) (*models.Overview, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !refresh && s.cached != nil && time.Since(s.cached.GeneratedAt) < s.ttl {
+		return s.cached, nil
+	}
+
+	connections, err := s.connectionService.GetAllConnections()
+	if err != nil {
+		return nil, err
+	}
+
+	overview := &models.Overview{
+		GeneratedAt: time.Now().UTC(),
+		Connections: make([]models.ConnectionOverview, len(connections)),
+	}
+
+	var wg sync.WaitGroup
+	for i, conn := range connections {
+		wg.Add(1)
+		go func(i int, conn *models.Connection) {
+			defer wg.Done()
+			overview.Connections[i] = s.collectConnection(conn)
+		}(i, conn)
+	}
+	wg.Wait()
+
+	s.cached = overview
+	return overview, nil
+}
+
+// collectConnection reads the server figures and alert states of a connection
+func (s *OverviewService) collectConnection(conn *models.Connection) models.ConnectionOverview {
+	overview := models.ConnectionOverview{
+		ConnectionID: conn.ID,
+		Name:         conn.Name,
+		Type:         conn.Type,
+		Host:         conn.Host,
+		Port:         conn.Port,
+		Status:       "ok",
+		AlertStatus:  models.CustomQueryStatusOK,
+		AlertCounts:  map[string]int{},
+	}
+	s.attachAlerts(&overview)
+
+	if conn.Type != "postgres" {
+		overview.Status = "unsupported"
+		return overview
+	}
+
+	db, err := s.databaseService.connectToDatabase(conn.ID)
+	if err != nil {
+		overview.Status = "error"
+		overview.Error = err.Error()
+		return overview
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), overviewQueryTimeout)
+	defer cancel()
+
+	var startedAt time.Time
+	err = db.QueryRowContext(ctx, `
+		SELECT current_setting('server_version'),
+			pg_postmaster_start_time(),
+			(SELECT count(*) FROM pg_database WHERE NOT datistemplate),
+			(SELECT COALESCE(sum(pg_database_size(oid)), 0)::bigint FROM pg_database WHERE NOT datistemplate AND datallowconn),
+			(SELECT count(*) FROM pg_stat_activity
+				WHERE state = 'active' AND backend_type = 'client backend' AND pid <> pg_backend_pid())
+	`).Scan(&overview.ServerVersion, &startedAt, &overview.DatabaseCount, &overview.TotalSizeBytes, &overview.ActiveSessions)
+	if err != nil {
+		overview.Status = "error"
+		overview.Error = fmt.Sprintf("failed to get server figures: %v", err)
+		return overview
+	}
+
+	overview.StartedAt = &startedAt
+	overview.UptimeSeconds = int64(time.Since(startedAt).Seconds())
+	return overview
+}
+
+// attachAlerts counts the alerts of a connection by status and keeps the worst one. Sources that
+// fail are skipped; the server figures are still worth showing.
+func (s *OverviewService) attachAlerts(overview *models.ConnectionOverview) {
+	for _, source := range s.alertSources {
+		statuses, err := source.GetAlertStatuses(overview.ConnectionID)
+		if err != nil {
+			s.logger.Warn("failed to get connection alerts", "connection_id", overview.ConnectionID, "error", err)
+			continue
+		}
+		for _, status := range statuses {
+			overview.AlertCounts[status.Status]++
+			if alertStatusSeverity[status.Status] > alertStatusSeverity[overview.AlertStatus] {
+				overview.AlertStatus = status.Status
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package services
+
+import "gorm.io/gorm"
+
+// Page is a limit/offset window over a list; a zero Limit means no limit
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// findPage counts all rows matched by query and loads the requested page of them into dest
+func findPage(query *gorm.DB, page Page, dest interface{}) (int64, error) {
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit)
+	}
+	if page.Offset > 0 {
+		query = query.Offset(page.Offset)
+	}
+
+	if err := query.Find(dest).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// PageSlice returns the requested page of an in-memory list
+func PageSlice[T any](items []T, page Page) []T {
+	if page.Offset >= len(items) {
+		return []T{}
+	}
+	items = items[page.Offset:]
+	if page.Limit > 0 && page.Limit < len(items) {
+		items = items[:page.Limit]
+	}
+	return items
+}
@@ -0,0 +1,577 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// partitionBoundPattern extracts the bounds from pg_get_expr(relpartbound)
+var partitionBoundPattern = regexp.MustCompile(`FOR VALUES FROM \('([^']+)'\) TO \('([^']+)'\)`)
+
+// partitionDateLayout is the layout used for partition bound literals
+const partitionDateLayout = "2006-01-02"
+
+// existingPartition represents a partition attached to a policy's parent table
+type existingPartition struct {
+	name string
+	from time.Time
+	to   time.Time
+}
+
+// PartitionService handles partition maintenance policies and runs
+type PartitionService struct {
+	db                  *gorm.DB
+	databaseService     *DatabaseService
+	notificationService *NotificationService
+	accessGrants        *AccessGrantService
+	logger              *slog.Logger
+}
+
+// NewPartitionService creates a new partition service
+func NewPartitionService(databaseService *DatabaseService, notificationService *NotificationService, accessGrants *AccessGrantService, logger *slog.Logger) *PartitionService {
+	return &PartitionService{
+		db:                  database.GetDB(),
+		databaseService:     databaseService,
+		notificationService: notificationService,
+		accessGrants:        accessGrants,
+		logger:              logging.OrDefault(logger),
+	}
+}
+
+// CreatePolicy creates a new partition maintenance policy
+func (s *PartitionService) CreatePolicy(req *models.PartitionPolicyRequest, userID string) (*models.PartitionPolicy, error) {
+	if err := validatePartitionPolicyRequest(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	if _, err := s.databaseService.connectionService.GetConnection(req.ConnectionID); err != nil {
+		return nil, err
+	}
+	// A policy detaches and drops partitions on its connection, so it takes the same grant as running SQL there
+	if err := s.accessGrants.CheckUserAccess(userID, req.ConnectionID, true); err != nil {
+		return nil, err
+	}
+
+	var existing models.PartitionPolicy
+	err := s.db.Where("connection_id = ? AND database_name = ? AND schema_name = ? AND table_name = ?",
+		req.ConnectionID, req.DatabaseName, req.SchemaName, req.TableName).First(&existing).Error
+	if err == nil {
+		return nil, fmt.Errorf("policy for this table already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check existing policy: %w", err)
+	}
+
+	policy := &models.PartitionPolicy{
+		ID:        uuid.New().String(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	applyPartitionPolicyRequest(policy, req)
+
+	if err := s.db.Create(policy).Error; err != nil {
+		return nil, fmt.Errorf("failed to create policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// GetPolicies returns the partition maintenance policies on connections the user may read
+func (s *PartitionService) GetPolicies(userID string) ([]models.PartitionPolicy, error) {
+	all := []models.PartitionPolicy{}
+	if err := s.db.Order("created_at DESC").Find(&all).Error; err != nil {
+		return nil, fmt.Errorf("failed to get policies: %w", err)
+	}
+
+	policies := make([]models.PartitionPolicy, 0, len(all))
+	for _, policy := range all {
+		allowed, err := s.accessGrants.CanReadConnection(userID, policy.ConnectionID)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
+
+// GetPolicy returns a partition maintenance policy by ID
+func (s *PartitionService) GetPolicy(id string) (*models.PartitionPolicy, error) {
+	var policy models.PartitionPolicy
+	if err := s.db.First(&policy, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("policy not found")
+		}
+		return nil, fmt.Errorf("failed to get policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// GetUserPolicy returns a policy the user holds access to its connection for; changes reaches
+// the partitions themselves and needs the grant that running SQL takes
+func (s *PartitionService) GetUserPolicy(id, userID string, changes bool) (*models.PartitionPolicy, error) {
+	policy, err := s.GetPolicy(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.accessGrants.CheckUserAccess(userID, policy.ConnectionID, changes); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// UpdatePolicy updates an existing partition maintenance policy
+func (s *PartitionService) UpdatePolicy(id, userID string, req *models.PartitionPolicyRequest) (*models.PartitionPolicy, error) {
+	if err := validatePartitionPolicyRequest(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	policy, err := s.GetUserPolicy(id, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	// The policy may be moved to another connection, which needs a grant as well
+	if req.ConnectionID != policy.ConnectionID {
+		if _, err := s.databaseService.connectionService.GetConnection(req.ConnectionID); err != nil {
+			return nil, err
+		}
+		if err := s.accessGrants.CheckUserAccess(userID, req.ConnectionID, true); err != nil {
+			return nil, err
+		}
+	}
+
+	applyPartitionPolicyRequest(policy, req)
+	policy.UpdatedAt = time.Now()
+
+	if err := s.db.Save(policy).Error; err != nil {
+		return nil, fmt.Errorf("failed to update policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// DeletePolicy removes a partition maintenance policy
+func (s *PartitionService) DeletePolicy(id, userID string) error {
+	if _, err := s.GetUserPolicy(id, userID, true); err != nil {
+		return err
+	}
+
+	result := s.db.Delete(&models.PartitionPolicy{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete policy: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("policy not found")
+	}
+	return nil
+}
+
+// GetPlan previews the partitions a maintenance run would create and expire
+func (s *PartitionService) GetPlan(id, userID string) (*models.PartitionPlan, error) {
+	policy, err := s.GetUserPolicy(id, userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := s.databaseService.connectToSpecificDatabase(policy.ConnectionID, policy.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildPartitionPlan(db, policy, time.Now())
+}
+
+// RunPolicy applies the maintenance plan of a policy and records the outcome
+func (s *PartitionService) RunPolicy(id, userID, trigger string) (*models.PartitionMaintenanceLog, error) {
+	policy, err := s.GetUserPolicy(id, userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.runPolicy(policy, userID, trigger)
+}
+
+// RunDuePolicies runs every enabled policy; it is registered as the partition_maintenance job type
+func (s *PartitionService) RunDuePolicies() error {
+	var policies []models.PartitionPolicy
+	if err := s.db.Where("enabled = ?", true).Find(&policies).Error; err != nil {
+		return fmt.Errorf("failed to get policies: %w", err)
+	}
+
+	failed := 0
+	for i := range policies {
+		if _, err := s.runPolicy(&policies[i], "", "scheduled"); err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d partition policies failed", failed, len(policies))
+	}
+	return nil
+}
+
+// GetLogs retrieves maintenance run logs for a policy the user may read
+func (s *PartitionService) GetLogs(policyID, userID string, page Page) ([]models.PartitionMaintenanceLog, int64, error) {
+	if _, err := s.GetUserPolicy(policyID, userID, false); err != nil {
+		return nil, 0, err
+	}
+
+	var logs []models.PartitionMaintenanceLog
+
+	query := s.db.Where("policy_id = ?", policyID).
+		Order("created_at DESC")
+
+	total, err := findPage(query, page, &logs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// runPolicy executes a policy's plan in a single transaction, logging and alerting on failure
+func (s *PartitionService) runPolicy(policy *models.PartitionPolicy, userID, trigger string) (*models.PartitionMaintenanceLog, error) {
+	startTime := time.Now()
+
+	entry := &models.PartitionMaintenanceLog{
+		PolicyID: policy.ID,
+		UserID:   userID,
+		Trigger:  trigger,
+		Status:   models.PartitionRunStatusSuccess,
+	}
+
+	plan, err := s.applyPlan(policy, startTime)
+	if plan != nil {
+		entry.SQLScript = strings.Join(plan.Statements, ";\n")
+		entry.CreatedCount = len(plan.Create)
+		entry.ExpiredCount = len(plan.Expire)
+	}
+	entry.ExecutionTimeMs = int(time.Since(startTime).Milliseconds())
+
+	if err != nil {
+		entry.Status = models.PartitionRunStatusError
+		entry.ErrorMessage = err.Error()
+	}
+
+	now := time.Now()
+	s.db.Model(policy).Updates(map[string]interface{}{
+		"last_run_at": now,
+		"last_status": string(entry.Status),
+	})
+
+	// Scheduled runs with nothing to do are not worth a log entry
+	if err == nil && trigger == "scheduled" && plan != nil && len(plan.Statements) == 0 {
+		return entry, nil
+	}
+
+	if logErr := s.db.Create(entry).Error; logErr != nil {
+		s.logger.Error("failed to log partition maintenance run", "policy_id", policy.ID, "error", logErr)
+	}
+
+	if err != nil {
+		if s.notificationService != nil {
+			s.notificationService.NotifyConnection(policy.ConnectionID, models.AlertRulePartitionMaintenanceFailed,
+				fmt.Sprintf("Partition maintenance failed for %s.%s.%s", policy.DatabaseName, policy.SchemaName, policy.TableName),
+				err.Error())
+		}
+		return entry, err
+	}
+
+	s.logger.Info("ran partition maintenance", "database", policy.DatabaseName, "table", policy.SchemaName+"."+policy.TableName,
+		"created", entry.CreatedCount, "expired", entry.ExpiredCount)
+	return entry, nil
+}
+
+// applyPlan builds and executes the maintenance plan for a policy
+func (s *PartitionService) applyPlan(policy *models.PartitionPolicy, now time.Time) (*models.PartitionPlan, error) {
+	db, err := s.databaseService.connectToSpecificDatabase(policy.ConnectionID, policy.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := buildPartitionPlan(db, policy, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Statements) == 0 {
+		return plan, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return plan, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range plan.Statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return plan, fmt.Errorf("failed to execute %q: %w", stmt, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return plan, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return plan, nil
+}
+
+// buildPartitionPlan computes the partitions to create and expire for a policy
+func buildPartitionPlan(db *sql.DB, policy *models.PartitionPolicy, now time.Time) (*models.PartitionPlan, error) {
+	var strategy string
+	err := db.QueryRow(`
+		SELECT pt.partstrat::text
+		FROM pg_partitioned_table pt
+		JOIN pg_class c ON c.oid = pt.partrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2
+	`, policy.SchemaName, policy.TableName).Scan(&strategy)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("table %s.%s is not partitioned", policy.SchemaName, policy.TableName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partitioning info: %w", err)
+	}
+	if strategy != "r" {
+		return nil, fmt.Errorf("table %s.%s is not range-partitioned", policy.SchemaName, policy.TableName)
+	}
+
+	partitions, err := getExistingPartitions(db, policy.SchemaName, policy.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := pq.QuoteIdentifier(policy.SchemaName) + "." + pq.QuoteIdentifier(policy.TableName)
+	plan := &models.PartitionPlan{
+		PolicyID:   policy.ID,
+		Create:     []models.PartitionPlanItem{},
+		Expire:     []models.PartitionPlanItem{},
+		Statements: []string{},
+	}
+
+	// Upcoming partitions, starting with the one covering the current period
+	current := truncateToPartitionInterval(now, policy.Interval)
+	for i := 0; i <= policy.Premake; i++ {
+		from := addPartitionInterval(current, policy.Interval, i)
+		to := addPartitionInterval(current, policy.Interval, i+1)
+		if partitionRangeCovered(partitions, from, to) {
+			continue
+		}
+
+		name := partitionName(policy.TableName, policy.Interval, from)
+		plan.Create = append(plan.Create, models.PartitionPlanItem{
+			Name:   name,
+			From:   from.Format(partitionDateLayout),
+			To:     to.Format(partitionDateLayout),
+			Action: "create",
+		})
+		plan.Statements = append(plan.Statements, fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s.%s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+			pq.QuoteIdentifier(policy.SchemaName), pq.QuoteIdentifier(name), parent,
+			from.Format(partitionDateLayout), to.Format(partitionDateLayout)))
+	}
+
+	// Expired partitions end before the start of the oldest retained period
+	if policy.Retention > 0 {
+		cutoff := addPartitionInterval(current, policy.Interval, -policy.Retention)
+		for _, p := range partitions {
+			if p.to.After(cutoff) {
+				continue
+			}
+
+			child := pq.QuoteIdentifier(policy.SchemaName) + "." + pq.QuoteIdentifier(p.name)
+			plan.Expire = append(plan.Expire, models.PartitionPlanItem{
+				Name:   p.name,
+				From:   p.from.Format(partitionDateLayout),
+				To:     p.to.Format(partitionDateLayout),
+				Action: policy.RetentionAction,
+			})
+			plan.Statements = append(plan.Statements, fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s", parent, child))
+			if policy.RetentionAction == "drop" {
+				plan.Statements = append(plan.Statements, fmt.Sprintf("DROP TABLE %s", child))
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// getExistingPartitions lists the range partitions attached to a table; DEFAULT partitions are ignored
+func getExistingPartitions(db *sql.DB, schemaName, tableName string) ([]existingPartition, error) {
+	rows, err := db.Query(`
+		SELECT c.relname, pg_get_expr(c.relpartbound, c.oid)
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class p ON p.oid = i.inhparent
+		JOIN pg_namespace n ON n.oid = p.relnamespace
+		WHERE n.nspname = $1 AND p.relname = $2
+		ORDER BY c.relname
+	`, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partitions: %w", err)
+	}
+	defer rows.Close()
+
+	partitions := []existingPartition{}
+	for rows.Next() {
+		var name string
+		var bound sql.NullString
+		if err := rows.Scan(&name, &bound); err != nil {
+			return nil, fmt.Errorf("failed to scan partition: %w", err)
+		}
+
+		match := partitionBoundPattern.FindStringSubmatch(bound.String)
+		if match == nil {
+			continue
+		}
+		from, errFrom := parsePartitionBound(match[1])
+		to, errTo := parsePartitionBound(match[2])
+		if errFrom != nil || errTo != nil {
+			continue
+		}
+
+		partitions = append(partitions, existingPartition{name: name, from: from, to: to})
+	}
+
+	return partitions, rows.Err()
+}
+
+// parsePartitionBound parses the date part of a date/timestamp partition bound
+func parsePartitionBound(value string) (time.Time, error) {
+	if len(value) < len(partitionDateLayout) {
+		return time.Time{}, fmt.Errorf("unsupported partition bound: %s", value)
+	}
+	return time.Parse(partitionDateLayout, value[:len(partitionDateLayout)])
+}
+
+// partitionRangeCovered reports whether any existing partition overlaps [from, to)
+func partitionRangeCovered(partitions []existingPartition, from, to time.Time) bool {
+	for _, p := range partitions {
+		if p.from.Before(to) && from.Before(p.to) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateToPartitionInterval returns the start of the interval containing t
+func truncateToPartitionInterval(t time.Time, interval models.PartitionInterval) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case models.PartitionIntervalWeek:
+		offset := (int(day.Weekday()) + 6) % 7 // Weeks start on Monday
+		return day.AddDate(0, 0, -offset)
+	case models.PartitionIntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case models.PartitionIntervalYear:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// addPartitionInterval moves t by n intervals
+func addPartitionInterval(t time.Time, interval models.PartitionInterval, n int) time.Time {
+	switch interval {
+	case models.PartitionIntervalWeek:
+		return t.AddDate(0, 0, 7*n)
+	case models.PartitionIntervalMonth:
+		return t.AddDate(0, n, 0)
+	case models.PartitionIntervalYear:
+		return t.AddDate(n, 0, 0)
+	default:
+		return t.AddDate(0, 0, n)
+	}
+}
+
+// partitionName builds the child table name for a partition starting at from
+func partitionName(tableName string, interval models.PartitionInterval, from time.Time) string {
+	switch interval {
+	case models.PartitionIntervalMonth:
+		return fmt.Sprintf("%s_p%s", tableName, from.Format("200601"))
+	case models.PartitionIntervalYear:
+		return fmt.Sprintf("%s_p%s", tableName, from.Format("2006"))
+	default:
+		return fmt.Sprintf("%s_p%s", tableName, from.Format("20060102"))
+	}
+}
+
+// validatePartitionPolicyRequest validates partition policy parameters
+func validatePartitionPolicyRequest(req *models.PartitionPolicyRequest) error {
+	switch req.Interval {
+	case models.PartitionIntervalDay, models.PartitionIntervalWeek, models.PartitionIntervalMonth, models.PartitionIntervalYear:
+	default:
+		return fmt.Errorf("invalid interval: %s", req.Interval)
+	}
+	if req.Premake < 0 || req.Premake > 366 {
+		return fmt.Errorf("premake must be between 0 and 366")
+	}
+	if req.Retention < 0 {
+		return fmt.Errorf("retention must not be negative")
+	}
+	switch req.RetentionAction {
+	case "", "detach", "drop":
+	default:
+		return fmt.Errorf("invalid retention action: %s", req.RetentionAction)
+	}
+	return nil
+}
+
+// applyPartitionPolicyRequest copies request fields onto a policy
+func applyPartitionPolicyRequest(policy *models.PartitionPolicy, req *models.PartitionPolicyRequest) {
+	policy.ConnectionID = req.ConnectionID
+	policy.DatabaseName = req.DatabaseName
+	policy.SchemaName = req.SchemaName
+	policy.TableName = req.TableName
+	policy.Interval = req.Interval
+	policy.Premake = req.Premake
+	policy.Retention = req.Retention
+	policy.RetentionAction = req.RetentionAction
+	if policy.RetentionAction == "" {
+		policy.RetentionAction = "detach"
+	}
+	policy.Enabled = true
+	if req.Enabled != nil {
+		policy.Enabled = *req.Enabled
+	}
+}
+
+// GetAlertStatuses reports the last maintenance outcome of each policy on a connection
+func (s *PartitionService) GetAlertStatuses(connectionID string) ([]models.AlertStatus, error) {
+	var policies []models.PartitionPolicy
+	if err := s.db.Where("connection_id = ? AND enabled = ?", connectionID, true).Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to get policies: %w", err)
+	}
+
+	statuses := []models.AlertStatus{}
+	for _, policy := range policies {
+		status := "ok"
+		if policy.LastStatus == string(models.PartitionRunStatusError) {
+			status = "failing"
+		} else if policy.LastRunAt == nil {
+			status = "pending"
+		}
+		statuses = append(statuses, models.AlertStatus{
+			Source:    "partition_maintenance",
+			Name:      fmt.Sprintf("%s.%s.%s", policy.DatabaseName, policy.SchemaName, policy.TableName),
+			Status:    status,
+			UpdatedAt: policy.LastRunAt,
+		})
+	}
+
+	return statuses, nil
+}
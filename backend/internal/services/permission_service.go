@@ -0,0 +1,363 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// ErrPermissionDenied is returned when a user lacks the permission a request requires
+var ErrPermissionDenied = errors.New("permission denied")
+
+// permissionCacheTTL bounds how long effective permissions are cached; writes through the service
+// invalidate the caches of all replicas at once, the TTL covers invalidations lost in transit
+const permissionCacheTTL = 30 * time.Second
+
+// permissionInvalidateChannel carries cache invalidations between replicas
+const permissionInvalidateChannel = "permissions:invalidate"
+
+// permissionCacheEntry holds the effective permissions of a user
+type permissionCacheEntry struct {
+	permissions map[string]bool
+	expiresAt   time.Time
+}
+
+// PermissionService manages granular permissions of non-admin users. A user holds the permissions
+// granted directly plus those of the groups they belong to. Users without direct permissions or groups
+// hold every permission, so installations that never assign permissions keep their behaviour.
+type PermissionService struct {
+	db     *gorm.DB
+	store  SharedStore
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	cache map[string]permissionCacheEntry
+}
+
+// NewPermissionService creates a new permission service; its cache is invalidated through store
+// when any replica changes permissions
+func NewPermissionService(store SharedStore, logger *slog.Logger) *PermissionService {
+	s := &PermissionService{
+		db:     database.GetDB(),
+		store:  store,
+		cache:  map[string]permissionCacheEntry{},
+		logger: logging.OrDefault(logger),
+	}
+	if _, err := store.Subscribe(permissionInvalidateChannel, func([]byte) { s.clearCache() }); err != nil {
+		s.logger.Warn("permission cache invalidation from other replicas unavailable", "error", err)
+	}
+	return s
+}
+
+// HasPermission reports whether a user holds a permission; admins hold every permission
+func (s *PermissionService) HasPermission(userID string, role models.UserRole, permission string) (bool, error) {
+	if role == models.RoleAdmin {
+		return true, nil
+	}
+
+	s.mu.Lock()
+	entry, ok := s.cache[userID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.permissions[permission], nil
+	}
+
+	effective, err := s.GetEffectivePermissions(userID, role)
+	if err != nil {
+		return false, err
+	}
+
+	entry = permissionCacheEntry{permissions: map[string]bool{}, expiresAt: time.Now().Add(permissionCacheTTL)}
+	for _, p := range effective.Permissions {
+		entry.permissions[p] = true
+	}
+	s.mu.Lock()
+	s.cache[userID] = entry
+	s.mu.Unlock()
+
+	return entry.permissions[permission], nil
+}
+
+// GetEffectivePermissions returns the permissions a user holds and where they come from
+func (s *PermissionService) GetEffectivePermissions(userID string, role models.UserRole) (*models.EffectivePermissions, error) {
+	effective := &models.EffectivePermissions{
+		UserID: userID,
+		Role:   role,
+		Direct: []string{},
+		Groups: []string{},
+	}
+
+	var direct models.UserPermissions
+	hasDirect := true
+	if err := s.db.First(&direct, "user_id = ?", userID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get user permissions: %w", err)
+		}
+		hasDirect = false
+	}
+
+	groups, err := s.groupsOf(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := map[string]bool{}
+	for _, p := range direct.Permissions {
+		granted[p] = true
+		effective.Direct = append(effective.Direct, p)
+	}
+	for _, group := range groups {
+		effective.Groups = append(effective.Groups, group.Name)
+		for _, p := range group.Permissions {
+			granted[p] = true
+		}
+	}
+
+	switch {
+	case role == models.RoleAdmin:
+		effective.Source = models.PermissionSourceAdmin
+		effective.Permissions = allPermissions()
+	case !hasDirect && len(groups) == 0:
+		effective.Source = models.PermissionSourceDefault
+		effective.Permissions = allPermissions()
+	default:
+		effective.Source = models.PermissionSourceAssigned
+		effective.Permissions = []string{}
+		for _, info := range models.PermissionCatalog {
+			if granted[info.Name] {
+				effective.Permissions = append(effective.Permissions, info.Name)
+			}
+		}
+	}
+
+	return effective, nil
+}
+
+// SetUserPermissions replaces the permissions granted directly to a user
+func (s *PermissionService) SetUserPermissions(userID, updatedBy string, req *models.UserPermissionsRequest) (*models.UserPermissions, error) {
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureUser(userID); err != nil {
+		return nil, err
+	}
+
+	record := &models.UserPermissions{
+		UserID:      userID,
+		Permissions: permissions,
+		UpdatedBy:   updatedBy,
+	}
+	if err := s.db.Save(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to save user permissions: %w", err)
+	}
+
+	s.invalidate()
+	return record, nil
+}
+
+// ResetUserPermissions removes the permissions granted directly to a user
+func (s *PermissionService) ResetUserPermissions(userID string) error {
+	if err := s.db.Delete(&models.UserPermissions{}, "user_id = ?", userID).Error; err != nil {
+		return fmt.Errorf("failed to reset user permissions: %w", err)
+	}
+
+	s.invalidate()
+	return nil
+}
+
+// GetGroups returns all permission groups
+func (s *PermissionService) GetGroups() ([]models.PermissionGroup, error) {
+	var groups []models.PermissionGroup
+	if err := s.db.Order("name").Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get permission groups: %w", err)
+	}
+	return groups, nil
+}
+
+// GetGroup returns a permission group by ID
+func (s *PermissionService) GetGroup(id string) (*models.PermissionGroup, error) {
+	var group models.PermissionGroup
+	if err := s.db.First(&group, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("permission group not found")
+		}
+		return nil, fmt.Errorf("failed to get permission group: %w", err)
+	}
+	return &group, nil
+}
+
+// CreateGroup creates a permission group
+func (s *PermissionService) CreateGroup(req *models.PermissionGroupRequest) (*models.PermissionGroup, error) {
+	group := &models.PermissionGroup{ID: uuid.New().String()}
+	if err := s.applyGroupRequest(group, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(group).Error; err != nil {
+		return nil, fmt.Errorf("failed to create permission group: %w", err)
+	}
+
+	s.invalidate()
+	return group, nil
+}
+
+// UpdateGroup replaces the name, description, permissions and members of a permission group
+func (s *PermissionService) UpdateGroup(id string, req *models.PermissionGroupRequest) (*models.PermissionGroup, error) {
+	group, err := s.GetGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyGroupRequest(group, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(group).Error; err != nil {
+		return nil, fmt.Errorf("failed to update permission group: %w", err)
+	}
+
+	s.invalidate()
+	return group, nil
+}
+
+// DeleteGroup deletes a permission group
+func (s *PermissionService) DeleteGroup(id string) error {
+	result := s.db.Delete(&models.PermissionGroup{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete permission group: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("permission group not found")
+	}
+
+	s.invalidate()
+	return nil
+}
+
+// applyGroupRequest validates a group request and copies it onto the group
+func (s *PermissionService) applyGroupRequest(group *models.PermissionGroup, req *models.PermissionGroupRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("permission group name is required")
+	}
+
+	var count int64
+	if err := s.db.Model(&models.PermissionGroup{}).Where("name = ? AND id <> ?", name, group.ID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check permission group name: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("permission group with this name already exists")
+	}
+
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return err
+	}
+
+	members := models.StringList{}
+	seen := map[string]bool{}
+	for _, member := range req.Members {
+		if seen[member] {
+			continue
+		}
+		if err := s.ensureUser(member); err != nil {
+			return err
+		}
+		seen[member] = true
+		members = append(members, member)
+	}
+
+	group.Name = name
+	group.Description = req.Description
+	group.Permissions = permissions
+	group.Members = members
+	return nil
+}
+
+// groupsOf returns the permission groups a user belongs to
+func (s *PermissionService) groupsOf(userID string) ([]models.PermissionGroup, error) {
+	return permissionGroupsOf(s.db, userID)
+}
+
+// permissionGroupsOf returns the permission groups a user belongs to, ordered by name
+func permissionGroupsOf(db *gorm.DB, userID string) ([]models.PermissionGroup, error) {
+	var groups []models.PermissionGroup
+	// Members is a JSON list, so the match on its text is confirmed on the decoded list
+	if err := db.Where("members LIKE ?", "%\""+userID+"\"%").Order("name").Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get permission groups: %w", err)
+	}
+
+	result := []models.PermissionGroup{}
+	for _, group := range groups {
+		if group.Members.Contains(userID) {
+			result = append(result, group)
+		}
+	}
+	return result, nil
+}
+
+// ensureUser returns an error unless the user exists
+func (s *PermissionService) ensureUser(userID string) error {
+	var count int64
+	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+// invalidate drops all cached permissions on every replica; a group change can affect any number of users
+func (s *PermissionService) invalidate() {
+	s.clearCache()
+	if err := s.store.Publish(permissionInvalidateChannel, nil); err != nil {
+		s.logger.Warn("failed to invalidate permission caches of other replicas", "error", err)
+	}
+}
+
+// clearCache drops the permissions cached by this replica
+func (s *PermissionService) clearCache() {
+	s.mu.Lock()
+	s.cache = map[string]permissionCacheEntry{}
+	s.mu.Unlock()
+}
+
+// normalizePermissions validates permission names and removes duplicates, keeping catalog order
+func normalizePermissions(permissions []string) (models.StringList, error) {
+	requested := map[string]bool{}
+	for _, p := range permissions {
+		if !models.IsPermission(p) {
+			return nil, fmt.Errorf("invalid permission: %s", p)
+		}
+		requested[p] = true
+	}
+
+	result := models.StringList{}
+	for _, info := range models.PermissionCatalog {
+		if requested[info.Name] {
+			result = append(result, info.Name)
+		}
+	}
+	return result, nil
+}
+
+// allPermissions returns the names of every permission
+func allPermissions() []string {
+	names := make([]string, len(models.PermissionCatalog))
+	for i, info := range models.PermissionCatalog {
+		names[i] = info.Name
+	}
+	return names
+}
@@ -0,0 +1,21 @@
+package services
+
+import (
+	"database/sql"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+// openPostgres opens a connection pool for a PostgreSQL DSN. It is a variable so that
+// service code can be pointed at a mock driver (for example go-sqlmock) when exercised in isolation.
+var openPostgres = func(dsn string) (*sql.DB, error) {
+	return sql.Open(capturedPostgresDriver, dsn)
+}
+
+// SetPostgresOpener replaces openPostgres and returns a function restoring the previous opener.
+// Tests outside this package use it to serve saved connections from a mock database.
+func SetPostgresOpener(open func(dsn string) (*sql.DB, error)) (restore func()) {
+	previous := openPostgres
+	openPostgres = open
+	return func() { openPostgres = previous }
+}
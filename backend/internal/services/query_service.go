@@ -1,52 +1,175 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"truadmin/internal/models"
-
-	_ "github.com/lib/pq"
 )
 
 // QueryService handles SQL query execution and database metadata
 type QueryService struct {
 	connectionService *ConnectionService
+	databaseService   *DatabaseService
 }
 
 // NewQueryService creates a new query service
-func NewQueryService(connService *ConnectionService) *QueryService {
+func NewQueryService(connService *ConnectionService, databaseService *DatabaseService) *QueryService {
 	return &QueryService{
 		connectionService: connService,
+		databaseService:   databaseService,
+	}
+}
+
+// ExecuteQuery executes a SQL query on the default database of the specified connection.
+// Statements that return rows are read into the result; other statements report the rows they
+// affected. SQL errors are reported in the result, connection errors are returned. The query is
+// cancelled when ctx ends and, unless ctx has a deadline, after the default query timeout.
+func (s *QueryService) ExecuteQuery(ctx context.Context, connectionID string, req *models.QueryRequest) (*models.QueryResult, error) {
+	if req.Sandbox {
+		return s.databaseService.ExecuteSandboxQuery(ctx, connectionID, "", req.Query)
+	}
+	if req.PageSize > 0 || req.Cursor != "" {
+		return s.databaseService.ExecuteQueryPage(ctx, connectionID, "", req)
+	}
+
+	db, d, err := s.connectionService.openDatabase(connectionID, "")
+	if err != nil {
+		return nil, err
 	}
+
+	ctx, cancel := s.databaseService.withQueryTimeout(ctx)
+	defer cancel()
+
+	var result *models.QueryResult
+	err = withSession(ctx, db, d, func(conn *sql.Conn) error {
+		if err := setStatementTimeout(ctx, conn, d); err != nil {
+			return err
+		}
+		if returnsRows(req.Query) {
+			rows, err := conn.QueryContext(ctx, req.Query)
+			result = readQueryResult(rows, err, s.databaseService.limits.MaxRows)
+			if message := interruptedQueryError(ctx); message != "" && result.Error != "" {
+				result.Error = message
+			}
+			return nil
+		}
+
+		result = &models.QueryResult{
+			Columns: []string{},
+			Rows:    []map[string]any{},
+		}
+		res, err := conn.ExecContext(ctx, req.Query)
+		if err != nil {
+			result.Error = err.Error()
+			if message := interruptedQueryError(ctx); message != "" {
+				result.Error = message
+			}
+			return nil
+		}
+		if affected, err := res.RowsAffected(); err == nil {
+			result.RowsAffected = &affected
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
-// ExecuteQuery executes a SQL query on the specified connection
-func (s *QueryService) ExecuteQuery(connectionID string, req *models.QueryRequest) (*models.QueryResult, error) {
-	// TODO: Implement query execution logic
-	// TODO: Get connection by ID
-	// TODO: Establish database connection
-	// TODO: Execute query with proper error handling
-	// TODO: Parse results into QueryResult
-	// TODO: Handle SELECT vs INSERT/UPDATE/DELETE queries
-	return nil, nil
+// TrackQuery returns the context a user query runs under; see DatabaseService.TrackQuery
+func (s *QueryService) TrackQuery(parent context.Context, connectionID, userID string, req *models.QueryRequest) (context.Context, func(), error) {
+	return s.databaseService.TrackQuery(parent, connectionID, userID, req)
 }
 
-// GetTables retrieves all tables from the specified database
+// CancelQuery cancels an in-flight query of a connection started with a query ID
+func (s *QueryService) CancelQuery(connectionID, queryID, userID string, isAdmin bool) error {
+	return s.databaseService.CancelQuery(connectionID, queryID, userID, isAdmin)
+}
+
+// GetTables retrieves all tables from the default database of the specified connection
 func (s *QueryService) GetTables(connectionID string) ([]*models.Table, error) {
-	// TODO: Implement get tables logic
-	// TODO: Get connection by ID
-	// TODO: Query information_schema or equivalent
-	// TODO: Parse and return table list
-	return nil, nil
+	conn, err := s.connectionService.GetConnection(connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	db, d, err := s.connectionService.openDatabase(connectionID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	query, args := d.tableStatsQuery(conn.Database)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tables: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []*models.Table{}
+	for rows.Next() {
+		var table models.Table
+		var size int64
+		if err := rows.Scan(&table.Name, &table.Schema, &table.Rows, &size); err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		table.Size = formatByteSize(size)
+		tables = append(tables, &table)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tables: %w", err)
+	}
+
+	return tables, nil
 }
 
-// GetColumns retrieves all columns for a specific table
+// GetColumns retrieves all columns for a specific table. The table name may be qualified as
+// schema.table; unqualified names resolve to the default schema of the connection's engine.
 func (s *QueryService) GetColumns(connectionID string, tableName string) ([]*models.Column, error) {
-	// TODO: Implement get columns logic
-	// TODO: Get connection by ID
-	// TODO: Query information_schema or equivalent
-	// TODO: Parse and return column list with metadata
-	return nil, nil
+	conn, err := s.connectionService.GetConnection(connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	db, d, err := s.connectionService.openDatabase(connectionID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	schemaName := d.defaultSchema(conn.Database)
+	if idx := strings.Index(tableName, "."); idx > 0 {
+		schemaName, tableName = tableName[:idx], tableName[idx+1:]
+	}
+
+	query, args := d.columnDetailsQuery(conn.Database, schemaName, tableName)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+	defer rows.Close()
+
+	columns := []*models.Column{}
+	for rows.Next() {
+		var column models.Column
+		if err := rows.Scan(&column.Name, &column.Type, &column.Nullable, &column.Key, &column.Default); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		columns = append(columns, &column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating columns: %w", err)
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table not found")
+	}
+
+	return columns, nil
 }
 
 // TestConnection tests if a database connection is valid
@@ -57,27 +180,25 @@ func (s *QueryService) TestConnection(connectionID string) error {
 		return fmt.Errorf("failed to get connection: %w", err)
 	}
 
-	// Build connection string
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		conn.Host,
-		conn.Port,
-		conn.Username,
-		conn.Password,
-		conn.Database,
-		conn.SSLMode,
-	)
-
-	// Open database connection
-	db, err := sql.Open("postgres", connStr)
+	// Open database connection with the connection's dialect; it is pinged before it is returned
+	db, _, err := openConnection(conn, "")
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
-	}
-	defer db.Close()
-
-	// Test connection with ping
-	if err := db.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+		return err
 	}
+	db.Close()
 
 	return nil
 }
+
+// formatByteSize formats a size in bytes the way pg_size_pretty does, e.g. 8192 bytes or 16 kB
+func formatByteSize(size int64) string {
+	units := []string{"bytes", "kB", "MB", "GB", "TB", "PB"}
+	value := size
+	unit := 0
+	// pg_size_pretty switches units once the value reaches 10240 of the current unit
+	for value >= 10*1024 && unit < len(units)-1 {
+		value = (value + 512) / 1024
+		unit++
+	}
+	return fmt.Sprintf("%d %s", value, units[unit])
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// ErrInvalidQueryParameters is returned for parameter declarations or values a saved query does not accept
+var ErrInvalidQueryParameters = errors.New("invalid query parameters")
+
+// queryParamNamePattern matches the names of saved query parameters
+var queryParamNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// expandQueryTemplate replaces every {{name}} reference of a query with the text returned for it.
+// References inside quoted literals and identifiers, dollar-quoted bodies and comments are left alone.
+func expandQueryTemplate(query string, replace func(name string) (string, error)) (string, error) {
+	var out strings.Builder
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		if end := skipSQLQuoted(runes, i); end > i {
+			out.WriteString(string(runes[i:end]))
+			i = end
+			continue
+		}
+		if !hasRunePrefix(runes[i:], []rune("{{")) {
+			out.WriteRune(runes[i])
+			i++
+			continue
+		}
+
+		end := i + 2
+		for end < len(runes) && !hasRunePrefix(runes[end:], []rune("}}")) {
+			end++
+		}
+		if end >= len(runes) {
+			return "", fmt.Errorf("%w: unterminated {{ in query", ErrInvalidQueryParameters)
+		}
+		name := strings.TrimSpace(string(runes[i+2 : end]))
+		if !queryParamNamePattern.MatchString(name) {
+			return "", fmt.Errorf("%w: invalid reference {{%s}}", ErrInvalidQueryParameters, name)
+		}
+		text, err := replace(name)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(text)
+		i = end + 2
+	}
+	return out.String(), nil
+}
+
+// validateQueryParameters checks the parameter declarations of a saved query against its text:
+// every reference must be declared and every declared parameter referenced
+func validateQueryParameters(query string, params []models.QueryParameter) error {
+	declared := map[string]bool{}
+	for _, param := range params {
+		if !queryParamNamePattern.MatchString(param.Name) {
+			return fmt.Errorf("%w: invalid parameter name %q", ErrInvalidQueryParameters, param.Name)
+		}
+		if declared[param.Name] {
+			return fmt.Errorf("%w: parameter %q is declared twice", ErrInvalidQueryParameters, param.Name)
+		}
+		declared[param.Name] = true
+
+		if !slices.Contains(models.QueryParamTypes, param.Type) {
+			return fmt.Errorf("%w: parameter %q has unknown type %q", ErrInvalidQueryParameters, param.Name, param.Type)
+		}
+		for _, allowed := range param.AllowedValues {
+			if _, err := queryParamValue(param, allowed); err != nil {
+				return fmt.Errorf("%w: allowed value of parameter %q: %v", ErrInvalidQueryParameters, param.Name, err)
+			}
+		}
+		if param.Default != nil {
+			if _, err := queryParamValue(param, param.Default); err != nil {
+				return fmt.Errorf("%w: default of parameter %q: %v", ErrInvalidQueryParameters, param.Name, err)
+			}
+		}
+	}
+
+	referenced := map[string]bool{}
+	_, err := expandQueryTemplate(query, func(name string) (string, error) {
+		if !declared[name] {
+			return "", fmt.Errorf("%w: {{%s}} is not a declared parameter", ErrInvalidQueryParameters, name)
+		}
+		referenced[name] = true
+		return "", nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, param := range params {
+		if !referenced[param.Name] {
+			return fmt.Errorf("%w: parameter %q is not used in the query", ErrInvalidQueryParameters, param.Name)
+		}
+	}
+	return nil
+}
+
+// bindQueryTemplate turns the {{name}} references of a saved query into bind parameters of the
+// dialect and returns the statement with its arguments. Values are checked against the declared
+// types and allowed values; missing values take the default, or NULL unless the parameter is required.
+func bindQueryTemplate(d dialect, query string, params []models.QueryParameter, values map[string]any) (string, []any, error) {
+	resolved := make(map[string]any, len(params))
+	for _, param := range params {
+		value, ok := values[param.Name]
+		if !ok || value == nil {
+			value = param.Default
+		}
+		if value == nil {
+			if param.Required {
+				return "", nil, fmt.Errorf("%w: parameter %q is required", ErrInvalidQueryParameters, param.Name)
+			}
+			resolved[param.Name] = nil
+			continue
+		}
+
+		bound, err := queryParamValue(param, value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: parameter %q: %v", ErrInvalidQueryParameters, param.Name, err)
+		}
+		resolved[param.Name] = bound
+	}
+	for name := range values {
+		if _, ok := resolved[name]; !ok {
+			return "", nil, fmt.Errorf("%w: unknown parameter %q", ErrInvalidQueryParameters, name)
+		}
+	}
+
+	// Every reference gets a placeholder of its own, since ? placeholders cannot be reused
+	var args []any
+	stmt, err := expandQueryTemplate(query, func(name string) (string, error) {
+		value, ok := resolved[name]
+		if !ok {
+			return "", fmt.Errorf("%w: {{%s}} is not a declared parameter", ErrInvalidQueryParameters, name)
+		}
+		args = append(args, value)
+		return d.placeholder(len(args)), nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return stmt, args, nil
+}
+
+// queryParamValue converts a JSON value to the declared type of a parameter. Values may also be
+// given as strings, as they are when typed into a prompt. Dates and timestamps are bound as text.
+func queryParamValue(param models.QueryParameter, value any) (any, error) {
+	text := ""
+	switch v := value.(type) {
+	case string:
+		text = v
+	case json.Number:
+		text = v.String()
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		text = strconv.FormatBool(v)
+	default:
+		return nil, fmt.Errorf("unsupported value %v", value)
+	}
+
+	if len(param.AllowedValues) > 0 && !slices.Contains(param.AllowedValues, text) {
+		return nil, fmt.Errorf("%q is not one of the allowed values", text)
+	}
+
+	switch param.Type {
+	case models.QueryParamText:
+		return text, nil
+	case models.QueryParamInteger:
+		n, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", text)
+		}
+		return n, nil
+	case models.QueryParamNumber:
+		n, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", text)
+		}
+		return n, nil
+	case models.QueryParamBoolean:
+		b, err := strconv.ParseBool(strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", text)
+		}
+		return b, nil
+	case models.QueryParamDate:
+		if _, err := time.Parse(time.DateOnly, text); err != nil {
+			return nil, fmt.Errorf("%q is not a date (YYYY-MM-DD)", text)
+		}
+		return text, nil
+	case models.QueryParamTimestamp:
+		if _, err := time.Parse(time.RFC3339, text); err != nil {
+			return nil, fmt.Errorf("%q is not an RFC 3339 timestamp", text)
+		}
+		return text, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q", param.Type)
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiterSweepEvery is the number of new buckets after which full buckets are dropped
+const rateLimiterSweepEvery = 1000
+
+// tokenBucket holds the tokens of one client at the time they were last counted
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter limits requests per key, e.g. a client IP or user, with token buckets. A bucket holds up
+// to one minute of requests and refills continuously, so short bursts pass while the sustained rate
+// stays within the limit. Buckets live in the memory of each replica.
+type RateLimiter struct {
+	mu         sync.Mutex
+	perMinute  float64
+	buckets    map[string]*tokenBucket
+	newBuckets int
+}
+
+// NewRateLimiter creates a rate limiter allowing perMinute requests per key; zero or less returns nil,
+// which allows everything
+func NewRateLimiter(perMinute int) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		perMinute: float64(perMinute),
+		buckets:   map[string]*tokenBucket{},
+	}
+}
+
+// Allow takes a token from the bucket of key. When the bucket is empty it returns false and how long
+// until the next token is available.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.perMinute, last: now}
+		l.buckets[key] = bucket
+		l.added(now)
+	} else {
+		bucket.tokens = l.refilled(bucket, now)
+		bucket.last = now
+	}
+
+	if bucket.tokens < 1 {
+		wait := time.Duration(math.Ceil((1 - bucket.tokens) / l.perMinute * float64(time.Minute)))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// refilled returns the tokens of a bucket at now; callers hold l.mu
+func (l *RateLimiter) refilled(bucket *tokenBucket, now time.Time) float64 {
+	tokens := bucket.tokens + now.Sub(bucket.last).Minutes()*l.perMinute
+	return math.Min(tokens, l.perMinute)
+}
+
+// added counts a new bucket and drops full buckets, which behave like missing ones, every
+// rateLimiterSweepEvery new buckets; callers hold l.mu
+func (l *RateLimiter) added(now time.Time) {
+	l.newBuckets++
+	if l.newBuckets < rateLimiterSweepEvery {
+		return
+	}
+	l.newBuckets = 0
+	for key, bucket := range l.buckets {
+		if l.refilled(bucket, now) >= l.perMinute {
+			delete(l.buckets, key)
+		}
+	}
+}
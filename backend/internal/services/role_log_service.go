@@ -1,33 +1,38 @@
 package services
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"time"
 
 	"gorm.io/gorm"
 
 	"truadmin/internal/database"
+	"truadmin/internal/logging"
 	"truadmin/internal/models"
 )
 
 // RoleLogService handles logging of Role operations
 type RoleLogService struct {
-	db *gorm.DB
+	db    *gorm.DB
+	audit *AuditService
 }
 
-// NewRoleLogService creates a new Role log service
-func NewRoleLogService() *RoleLogService {
+// NewRoleLogService creates a new Role log service; operations are also shipped to the audit sinks
+func NewRoleLogService(audit *AuditService) *RoleLogService {
 	return &RoleLogService{
-		db: database.GetDB(),
+		db:    database.GetDB(),
+		audit: audit,
 	}
 }
 
 // LogOperation logs a role operation
 func (s *RoleLogService) LogOperation(
+	ctx context.Context,
 	connectionID string,
 	roleID string,
 	userID string,
-	operation string, // "create", "update", "delete", "grant_privileges", "revoke_privileges", "grant_membership", "revoke_membership"
+	operation string, // "create", "update", "delete", "grant_privileges", "revoke_privileges", "grant_membership", "revoke_membership", "change_owner", "reassign_owner"
 	status models.RoleSaveLogStatus,
 	errorMessage string,
 ) error {
@@ -38,70 +43,85 @@ func (s *RoleLogService) LogOperation(
 		Operation:    operation,
 		Status:       status,
 		ErrorMessage: errorMessage,
+		RequestID:    logging.RequestID(ctx),
 		CreatedAt:    time.Now(),
 	}
 
+	s.audit.Record(roleAuditEvent(&logEntry))
+
 	if err := s.db.Create(&logEntry).Error; err != nil {
-		log.Printf("ERROR: Failed to log Role operation: %v", err)
-		log.Printf("  connectionID: %s, roleID: %s, userID: %s, operation: %s, status: %s", connectionID, roleID, userID, operation, status)
+		slog.ErrorContext(ctx, "failed to log role operation", "connection_id", connectionID, "role_id", roleID, "user_id", userID, "operation", operation, "status", status, "error", err)
 		return err
 	}
 
-	log.Printf("✅ Logged Role operation: connectionID=%s, roleID=%s, userID=%s, operation=%s, status=%s",
-		connectionID, roleID, userID, operation, status)
+	slog.InfoContext(ctx, "logged role operation", "connection_id", connectionID, "role_id", roleID, "user_id", userID, "operation", operation, "status", status)
 	return nil
 }
 
+// roleAuditEvent converts a role log entry to the audit event shipped for it
+func roleAuditEvent(entry *models.RoleSaveLog) models.AuditEvent {
+	return models.AuditEvent{
+		Timestamp:    entry.CreatedAt.UTC(),
+		Source:       "role",
+		Action:       entry.Operation,
+		Status:       models.AuditEventStatus(entry.Status),
+		ActorID:      entry.UserID,
+		ConnectionID: entry.ConnectionID,
+		TargetID:     entry.RoleID,
+		Message:      entry.ErrorMessage,
+		RequestID:    entry.RequestID,
+	}
+}
+
 // GetLogs retrieves logs for roles
-func (s *RoleLogService) GetLogs(limit int) ([]models.RoleSaveLog, error) {
+func (s *RoleLogService) GetLogs(page Page) ([]models.RoleSaveLog, int64, error) {
 	var logs []models.RoleSaveLog
 
 	query := s.db.Order("created_at DESC")
 
-	if limit > 0 {
-		query = query.Limit(limit)
-	}
-
-	if err := query.Find(&logs).Error; err != nil {
-		return nil, err
+	total, err := findPage(query, page, &logs)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return logs, nil
+	return logs, total, nil
 }
 
 // GetLogsByConnection retrieves logs for a specific connection
-func (s *RoleLogService) GetLogsByConnection(connectionID string, limit int) ([]models.RoleSaveLog, error) {
+func (s *RoleLogService) GetLogsByConnection(connectionID string, page Page) ([]models.RoleSaveLog, int64, error) {
 	var logs []models.RoleSaveLog
 
 	query := s.db.Where("connection_id = ?", connectionID).
 		Order("created_at DESC")
 
-	if limit > 0 {
-		query = query.Limit(limit)
+	total, err := findPage(query, page, &logs)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if err := query.Find(&logs).Error; err != nil {
-		return nil, err
-	}
-
-	return logs, nil
+	return logs, total, nil
 }
 
 // GetLogsByRole retrieves logs for a specific role
-func (s *RoleLogService) GetLogsByRole(connectionID, roleID string, limit int) ([]models.RoleSaveLog, error) {
+func (s *RoleLogService) GetLogsByRole(connectionID, roleID string, page Page) ([]models.RoleSaveLog, int64, error) {
 	var logs []models.RoleSaveLog
 
 	query := s.db.Where("connection_id = ? AND role_id = ?", connectionID, roleID).
 		Order("created_at DESC")
 
-	if limit > 0 {
-		query = query.Limit(limit)
-	}
-
-	if err := query.Find(&logs).Error; err != nil {
-		return nil, err
+	total, err := findPage(query, page, &logs)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return logs, nil
+	return logs, total, nil
 }
 
+// ExportLogs streams the role logs of a connection matching the filter, optionally of one role, oldest first
+func (s *RoleLogService) ExportLogs(connectionID, roleID string, filter models.LogExportFilter, fn func(*models.RoleSaveLog) error) error {
+	query := s.db.Where("connection_id = ?", connectionID)
+	if roleID != "" {
+		query = query.Where("role_id = ?", roleID)
+	}
+	return streamLogs(query, filter, "operation", fn)
+}
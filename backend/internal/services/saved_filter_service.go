@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// ErrSavedFilterForbidden is returned when a user changes a saved filter owned by someone else
+var ErrSavedFilterForbidden = errors.New("only the owner can change this filter")
+
+// SavedFilterService handles named filters of the monitoring views
+type SavedFilterService struct {
+	db *gorm.DB
+}
+
+// NewSavedFilterService creates a new saved filter service
+func NewSavedFilterService() *SavedFilterService {
+	return &SavedFilterService{
+		db: database.GetDB(),
+	}
+}
+
+// CreateFilter saves a named filter for the user
+func (s *SavedFilterService) CreateFilter(userID string, req *models.SavedFilterRequest) (*models.SavedFilter, error) {
+	filter := &models.SavedFilter{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		ConnectionID: req.ConnectionID,
+		View:         req.View,
+		Name:         req.Name,
+		Criteria:     req.Criteria,
+		Shared:       req.Shared,
+	}
+	if filter.Criteria == nil {
+		filter.Criteria = models.FilterCriteria{}
+	}
+
+	if err := s.db.Create(filter).Error; err != nil {
+		return nil, fmt.Errorf("failed to create saved filter: %w", err)
+	}
+	return filter, nil
+}
+
+// GetFilters returns the user's own and shared filters of a view.
+// With a connection ID, filters of other connections are left out.
+func (s *SavedFilterService) GetFilters(userID, view, connectionID string) ([]models.SavedFilter, error) {
+	filters := []models.SavedFilter{}
+
+	query := s.db.Where("user_id = ? OR shared = ?", userID, true)
+	if view != "" {
+		query = query.Where("view = ?", view)
+	}
+	if connectionID != "" {
+		query = query.Where("connection_id = ? OR connection_id = ''", connectionID)
+	}
+
+	if err := query.Order("name ASC").Find(&filters).Error; err != nil {
+		return nil, fmt.Errorf("failed to get saved filters: %w", err)
+	}
+	return filters, nil
+}
+
+// UpdateFilter replaces a saved filter; only its owner or an admin may change it
+func (s *SavedFilterService) UpdateFilter(id, userID string, isAdmin bool, req *models.SavedFilterRequest) (*models.SavedFilter, error) {
+	filter, err := s.getOwnedFilter(id, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.ConnectionID = req.ConnectionID
+	filter.View = req.View
+	filter.Name = req.Name
+	filter.Criteria = req.Criteria
+	if filter.Criteria == nil {
+		filter.Criteria = models.FilterCriteria{}
+	}
+	filter.Shared = req.Shared
+
+	if err := s.db.Save(filter).Error; err != nil {
+		return nil, fmt.Errorf("failed to update saved filter: %w", err)
+	}
+	return filter, nil
+}
+
+// DeleteFilter removes a saved filter; only its owner or an admin may delete it
+func (s *SavedFilterService) DeleteFilter(id, userID string, isAdmin bool) error {
+	filter, err := s.getOwnedFilter(id, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(filter).Error; err != nil {
+		return fmt.Errorf("failed to delete saved filter: %w", err)
+	}
+	return nil
+}
+
+// getOwnedFilter loads a filter and checks that the user may change it
+func (s *SavedFilterService) getOwnedFilter(id, userID string, isAdmin bool) (*models.SavedFilter, error) {
+	var filter models.SavedFilter
+	if err := s.db.First(&filter, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("saved filter not found")
+		}
+		return nil, fmt.Errorf("failed to get saved filter: %w", err)
+	}
+
+	if filter.UserID != userID && !isAdmin {
+		return nil, ErrSavedFilterForbidden
+	}
+	return &filter, nil
+}
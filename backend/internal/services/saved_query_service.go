@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// ErrSavedQueryForbidden is returned when a user changes a saved query owned by someone else
+var ErrSavedQueryForbidden = errors.New("only the owner can change this query")
+
+// SavedQueryService handles named, reusable queries with typed parameters
+type SavedQueryService struct {
+	db              *gorm.DB
+	databaseService *DatabaseService
+}
+
+// NewSavedQueryService creates a new saved query service
+func NewSavedQueryService(databaseService *DatabaseService) *SavedQueryService {
+	return &SavedQueryService{
+		db:              database.GetDB(),
+		databaseService: databaseService,
+	}
+}
+
+// GetQueries returns the user's own and shared queries, of one connection when connectionID is set
+func (s *SavedQueryService) GetQueries(userID, connectionID string) ([]models.SavedQuery, error) {
+	queries := []models.SavedQuery{}
+
+	query := s.db.Where("user_id = ? OR shared = ?", userID, true)
+	if connectionID != "" {
+		query = query.Where("connection_id = ?", connectionID)
+	}
+
+	if err := query.Order("name ASC").Find(&queries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get saved queries: %w", err)
+	}
+	return queries, nil
+}
+
+// GetQuery returns a saved query the user owns or that is shared
+func (s *SavedQueryService) GetQuery(id, userID string, isAdmin bool) (*models.SavedQuery, error) {
+	var saved models.SavedQuery
+	if err := s.db.First(&saved, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("saved query not found")
+		}
+		return nil, fmt.Errorf("failed to get saved query: %w", err)
+	}
+
+	if saved.UserID != userID && !saved.Shared && !isAdmin {
+		return nil, fmt.Errorf("saved query not found")
+	}
+	return &saved, nil
+}
+
+// CreateQuery saves a query for the user
+func (s *SavedQueryService) CreateQuery(userID string, req *models.SavedQueryRequest) (*models.SavedQuery, error) {
+	saved := &models.SavedQuery{
+		ID:     uuid.New().String(),
+		UserID: userID,
+	}
+	if err := applySavedQueryRequest(saved, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(saved).Error; err != nil {
+		return nil, fmt.Errorf("failed to create saved query: %w", err)
+	}
+	return saved, nil
+}
+
+// UpdateQuery replaces a saved query; only its owner or an admin may change it
+func (s *SavedQueryService) UpdateQuery(id, userID string, isAdmin bool, req *models.SavedQueryRequest) (*models.SavedQuery, error) {
+	saved, err := s.getOwnedQuery(id, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySavedQueryRequest(saved, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(saved).Error; err != nil {
+		return nil, fmt.Errorf("failed to update saved query: %w", err)
+	}
+	return saved, nil
+}
+
+// DeleteQuery removes a saved query; only its owner or an admin may delete it
+func (s *SavedQueryService) DeleteQuery(id, userID string, isAdmin bool) error {
+	saved, err := s.getOwnedQuery(id, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(saved).Error; err != nil {
+		return fmt.Errorf("failed to delete saved query: %w", err)
+	}
+	return nil
+}
+
+// RunQuery runs a saved query of a connection with the given parameter values bound to its
+// {{name}} references. The values never become part of the SQL text.
+func (s *SavedQueryService) RunQuery(ctx context.Context, connectionID, id, userID string, isAdmin bool, values map[string]any) (*models.QueryResult, error) {
+	saved, err := s.GetQuery(id, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if saved.ConnectionID != connectionID {
+		return nil, fmt.Errorf("saved query not found")
+	}
+
+	_, d, err := s.databaseService.connect(saved.ConnectionID, saved.DatabaseName)
+	if err != nil {
+		return nil, err
+	}
+	stmt, args, err := bindQueryTemplate(d, saved.Query, saved.Parameters, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.databaseService.ExecuteQuery(ctx, saved.ConnectionID, saved.DatabaseName, stmt, args...)
+}
+
+// getOwnedQuery loads a saved query and checks that the user may change it
+func (s *SavedQueryService) getOwnedQuery(id, userID string, isAdmin bool) (*models.SavedQuery, error) {
+	saved, err := s.GetQuery(id, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if saved.UserID != userID && !isAdmin {
+		return nil, ErrSavedQueryForbidden
+	}
+	return saved, nil
+}
+
+// applySavedQueryRequest validates a request and copies it onto a saved query
+func applySavedQueryRequest(saved *models.SavedQuery, req *models.SavedQueryRequest) error {
+	params := models.QueryParameters(req.Parameters)
+	if params == nil {
+		params = models.QueryParameters{}
+	}
+	if err := validateQueryParameters(req.Query, params); err != nil {
+		return err
+	}
+
+	saved.ConnectionID = req.ConnectionID
+	saved.DatabaseName = req.DatabaseName
+	saved.Name = req.Name
+	saved.Description = req.Description
+	saved.Query = req.Query
+	saved.Parameters = params
+	saved.Shared = req.Shared
+	return nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// JobFunc is a unit of background work executed by the scheduler
+type JobFunc func() error
+
+// scheduledJob is a job type registered with the scheduler
+type scheduledJob struct {
+	name     string
+	interval time.Duration
+	run      JobFunc
+	running  sync.Mutex
+
+	statusMu sync.Mutex
+	status   models.WorkerStatus
+}
+
+// SchedulerService runs registered job types on fixed intervals
+type SchedulerService struct {
+	mu      sync.Mutex
+	jobs    []*scheduledJob
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	started bool
+	logger  *slog.Logger
+
+	isLeader func() bool // Jobs only run while it reports true; nil runs them on every replica
+}
+
+// NewSchedulerService creates a new scheduler service
+func NewSchedulerService(logger *slog.Logger) *SchedulerService {
+	return &SchedulerService{
+		stop:   make(chan struct{}),
+		logger: logging.OrDefault(logger),
+	}
+}
+
+// RequireLeader makes jobs run only while isLeader reports true, so that with several replicas
+// every job runs on one of them
+func (s *SchedulerService) RequireLeader(isLeader func() bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.isLeader = isLeader
+}
+
+// Status returns the health of the registered jobs
+func (s *SchedulerService) Status() []models.WorkerStatus {
+	s.mu.Lock()
+	jobs := append([]*scheduledJob(nil), s.jobs...)
+	isLeader := s.isLeader
+	s.mu.Unlock()
+
+	statuses := make([]models.WorkerStatus, 0, len(jobs))
+	for _, job := range jobs {
+		job.statusMu.Lock()
+		status := job.status
+		job.statusMu.Unlock()
+		if status.State == models.WorkerStateIdle && isLeader != nil && !isLeader() {
+			status.State = models.WorkerStateStandby
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Register adds a job type that runs every interval once the scheduler is started
+func (s *SchedulerService) Register(name string, interval time.Duration, run JobFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := &scheduledJob{name: name, interval: interval, run: run}
+	job.status = models.WorkerStatus{
+		Name:            name,
+		IntervalSeconds: int64(interval / time.Second),
+		State:           models.WorkerStateIdle,
+	}
+	s.jobs = append(s.jobs, job)
+
+	if s.started {
+		s.startJob(job)
+	}
+}
+
+// Start launches all registered job types
+func (s *SchedulerService) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+
+	for _, job := range s.jobs {
+		s.startJob(job)
+	}
+}
+
+// Stop signals all job loops to exit and waits for running jobs to finish
+func (s *SchedulerService) Stop() {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = false
+	close(s.stop)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// startJob runs a job loop in its own goroutine
+func (s *SchedulerService) startJob(job *scheduledJob) {
+	if job.interval <= 0 {
+		s.logger.Info("job has no interval, skipping", "job", job.name)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(job.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.runJob(job)
+			}
+		}
+	}()
+
+	s.logger.Info("job registered", "job", job.name, "interval", job.interval)
+}
+
+// runJob executes a job, skipping the tick if the previous run is still in progress or another
+// replica is the leader
+func (s *SchedulerService) runJob(job *scheduledJob) {
+	s.mu.Lock()
+	isLeader := s.isLeader
+	s.mu.Unlock()
+	if isLeader != nil && !isLeader() {
+		return
+	}
+
+	if !job.running.TryLock() {
+		s.logger.Info("job is still running, skipping tick", "job", job.name)
+		return
+	}
+	defer job.running.Unlock()
+
+	started := time.Now().UTC()
+	job.statusMu.Lock()
+	job.status.State = models.WorkerStateRunning
+	job.status.LastStartedAt = &started
+	job.statusMu.Unlock()
+
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("job panicked", "job", job.name, "panic", r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+		job.finish(started, err)
+	}()
+
+	if err = job.run(); err != nil {
+		s.logger.Error("job failed", "job", job.name, "error", err)
+	}
+}
+
+// finish records the outcome of a run in the job's status
+func (j *scheduledJob) finish(started time.Time, err error) {
+	finished := time.Now().UTC()
+
+	j.statusMu.Lock()
+	defer j.statusMu.Unlock()
+
+	j.status.State = models.WorkerStateIdle
+	j.status.Runs++
+	j.status.LastFinishedAt = &finished
+	j.status.LastDurationMs = finished.Sub(started).Milliseconds()
+	j.status.LastError = ""
+	if err != nil {
+		j.status.Failures++
+		j.status.LastError = err.Error()
+	}
+}
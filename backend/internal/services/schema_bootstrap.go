@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// moduleMigrations holds the SQL scripts that create the TruETL and HohAddress schemas, one directory
+// per module; the scripts of a module run in file name order
+//
+//go:embed migrations
+var moduleMigrations embed.FS
+
+// ErrSchemaAlreadyInitialized is returned when a database already has tables of the module schema
+var ErrSchemaAlreadyInitialized = errors.New("database already has the module schema")
+
+// moduleMigrationStatements returns the names of the migration scripts of a module and their statements
+func moduleMigrationStatements(module string) ([]string, []string, error) {
+	dir := path.Join("migrations", module)
+	entries, err := fs.ReadDir(moduleMigrations, dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s migrations: %w", module, err)
+	}
+
+	names := []string{}
+	statements := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		script, err := fs.ReadFile(moduleMigrations, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		names = append(names, entry.Name())
+		statements = append(statements, splitSQLStatements(string(script))...)
+	}
+	return names, statements, nil
+}
+
+// initializeModuleSchema creates the schema of a module in a database from the embedded migrations,
+// in one transaction, and logs every statement run. Databases that already have one of the required
+// tables are left alone, so the functions they may have customized are not replaced.
+func (s *ConnectionService) initializeModuleSchema(ctx context.Context, schema moduleSchema, conn *models.Connection, req *models.SchemaBootstrapRequest, userID string) (*models.SchemaBootstrapLog, error) {
+	names, statements, err := moduleMigrationStatements(schema.module)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.SchemaBootstrapLog{
+		ConnectionID: conn.ID,
+		DatabaseName: req.DatabaseName,
+		Module:       schema.module,
+		UserID:       userID,
+		Status:       models.SchemaBootstrapStatusSuccess,
+		Migrations:   names,
+		RequestID:    logging.RequestID(ctx),
+	}
+	if req.DryRun {
+		entry.Status = models.SchemaBootstrapStatusDryRun
+		entry.SQLScript = joinMigrationStatements(statements)
+		return entry, nil
+	}
+
+	db, _, err := s.pools.get(conn, req.DatabaseName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	existing, err := readModuleSchema(ctx, db, schema.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema of %s: %w", req.DatabaseName, err)
+	}
+	for _, table := range schema.required {
+		if slices.Contains(existing, schema.name+"."+table) {
+			return nil, fmt.Errorf("%w: %s.%s exists in %s", ErrSchemaAlreadyInitialized, schema.name, table, req.DatabaseName)
+		}
+	}
+
+	startTime := time.Now()
+	executed, err := runMigrationStatements(ctx, db, statements)
+	entry.SQLScript = joinMigrationStatements(executed)
+	entry.ExecutionTimeMs = int(time.Since(startTime).Milliseconds())
+	if err != nil {
+		entry.Status = models.SchemaBootstrapStatusError
+		entry.ErrorMessage = err.Error()
+	}
+
+	if logErr := s.db.Create(entry).Error; logErr != nil {
+		s.logger.ErrorContext(ctx, "failed to log schema initialization", "schema", schema.name, "error", logErr)
+	}
+	if err != nil {
+		return entry, err
+	}
+
+	s.logger.InfoContext(ctx, "initialized module schema", "schema", schema.name, "database", req.DatabaseName, "statements", len(executed))
+	return entry, nil
+}
+
+// runMigrationStatements runs statements in one transaction and returns those it ran, including a
+// failing one
+func runMigrationStatements(ctx context.Context, db *sql.DB, statements []string) ([]string, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	executed := []string{}
+	for i, stmt := range statements {
+		executed = append(executed, stmt)
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return executed, fmt.Errorf("statement %d failed, nothing was created: %w", i+1, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return executed, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return executed, nil
+}
+
+// joinMigrationStatements formats statements as one script
+func joinMigrationStatements(statements []string) string {
+	if len(statements) == 0 {
+		return ""
+	}
+	return strings.Join(statements, ";\n\n") + ";"
+}
+
+// GetSchemaBootstrapLogs retrieves the schema initializations of a module on a connection, newest first
+func (s *ConnectionService) GetSchemaBootstrapLogs(connectionID, module string, page Page) ([]models.SchemaBootstrapLog, int64, error) {
+	var logs []models.SchemaBootstrapLog
+
+	query := s.db.Where("connection_id = ? AND module = ?", connectionID, module).
+		Order("created_at DESC")
+
+	total, err := findPage(query, page, &logs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
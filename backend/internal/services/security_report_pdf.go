@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"truadmin/internal/models"
+)
+
+// Layout of the security report PDF: A4 pages in points, Helvetica at a fixed size. Lines are
+// wrapped by character count, which is close enough for Helvetica at pdfFontSize.
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 50
+	pdfFontSize   = 10
+	pdfLineHeight = 14
+	pdfLineChars  = 90
+)
+
+// pdfLine is one line of text on a PDF page
+type pdfLine struct {
+	text   string
+	bold   bool
+	size   int
+	indent int
+}
+
+// RenderSecurityReportPDF lays out a security posture report as a PDF document
+func RenderSecurityReportPDF(report *models.SecurityReport) []byte {
+	var lines []pdfLine
+	add := func(text string, bold bool, size, indent int) {
+		for _, wrapped := range wrapPDFText(text, pdfLineChars*pdfFontSize/size-indent/5) {
+			lines = append(lines, pdfLine{text: wrapped, bold: bold, size: size, indent: indent})
+		}
+	}
+	blank := func() { lines = append(lines, pdfLine{}) }
+
+	add("Security posture report", true, 18, 0)
+	blank()
+	add(fmt.Sprintf("Connection: %s (%s)", report.ConnectionName, report.ConnectionID), false, pdfFontSize, 0)
+	add("Database: "+report.DatabaseName, false, pdfFontSize, 0)
+	add("Server version: PostgreSQL "+report.ServerVersion, false, pdfFontSize, 0)
+	add("Generated: "+report.GeneratedAt.Format("2006-01-02 15:04:05 MST"), false, pdfFontSize, 0)
+	blank()
+	add(fmt.Sprintf("Score: %d/100   Grade: %s", report.Score, report.Grade), true, 14, 0)
+
+	for _, finding := range report.Findings {
+		blank()
+		add(fmt.Sprintf("[%s] %s (weight %d)", strings.ToUpper(finding.Status), finding.Title, finding.Weight), true, 12, 0)
+		add(finding.Message, false, pdfFontSize, 10)
+		for _, detail := range finding.Details {
+			add("- "+detail, false, pdfFontSize, 20)
+		}
+		if finding.Recommendation != "" {
+			add("Recommendation: "+finding.Recommendation, false, pdfFontSize, 10)
+		}
+	}
+
+	// Break the lines into pages
+	var pages [][]pdfLine
+	var page []pdfLine
+	y := pdfPageHeight - pdfMargin
+	for _, line := range lines {
+		height := pdfLineHeight
+		if line.size > pdfFontSize {
+			height = line.size + 6
+		}
+		if y-height < pdfMargin && len(page) > 0 {
+			pages = append(pages, page)
+			page, y = nil, pdfPageHeight-pdfMargin
+		}
+		y -= height
+		page = append(page, line)
+	}
+	pages = append(pages, page)
+
+	return writePDF(pages)
+}
+
+// writePDF writes the pages as a PDF file using the standard Helvetica fonts, which readers provide
+func writePDF(pages [][]pdfLine) []byte {
+	// Objects 1-4 are the catalog, page tree and fonts; each page takes two: the page and its content
+	objects := make([]string, 4, 4+2*len(pages))
+	kids := make([]string, len(pages))
+	for i, page := range pages {
+		pageID, contentID := 5+2*i, 6+2*i
+		kids[i] = fmt.Sprintf("%d 0 R", pageID)
+
+		var content bytes.Buffer
+		y := pdfPageHeight - pdfMargin
+		for _, line := range page {
+			size, font := line.size, "F1"
+			if size == 0 {
+				size = pdfFontSize
+			}
+			height := pdfLineHeight
+			if size > pdfFontSize {
+				height = size + 6
+			}
+			y -= height
+			if line.text == "" {
+				continue
+			}
+			if line.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", font, size, pdfMargin+line.indent, y, escapePDFText(line.text))
+		}
+		fmt.Fprintf(&content, "BT /F1 8 Tf %d %d Td (Page %d of %d) Tj ET\n", pdfPageWidth-pdfMargin-50, pdfMargin/2, i+1, len(pages))
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, contentID),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+	objects[2] = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"
+	objects[3] = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>"
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// wrapPDFText splits text into lines of at most width characters, breaking at spaces where possible
+func wrapPDFText(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	line := ""
+	for _, word := range words {
+		for len(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, word[:width])
+			word = word[width:]
+		}
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	return append(lines, line)
+}
+
+// escapePDFText escapes a PDF string literal; characters outside printable ASCII become '?', as the
+// standard fonts are not embedded
+func escapePDFText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// SeedService applies the declarative seed file to fresh installs
+type SeedService struct {
+	db                *gorm.DB
+	authService       *AuthService
+	connectionService *ConnectionService
+	settingsService   *SettingsService
+}
+
+// NewSeedService creates a new seed service
+func NewSeedService(authService *AuthService, connectionService *ConnectionService, settingsService *SettingsService) *SeedService {
+	return &SeedService{
+		db:                database.GetDB(),
+		authService:       authService,
+		connectionService: connectionService,
+		settingsService:   settingsService,
+	}
+}
+
+// seedEnvReference matches the ${VAR} references replaced in seed files; any other $ is kept as written
+var seedEnvReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// LoadSeedFile reads a seed file. ${VAR} references in values are replaced with environment variables,
+// so passwords can come from the deployment's secrets; unset variables and unknown keys are rejected.
+func LoadSeedFile(path string) (*models.SeedFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed file: %w", err)
+	}
+
+	var seed models.SeedFile
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse seed file %s: %w", path, err)
+	}
+	if document.Kind == 0 {
+		return &seed, nil
+	}
+	if err := expandSeedEnv(&document); err != nil {
+		return nil, fmt.Errorf("invalid seed file %s: %w", path, err)
+	}
+
+	// The expanded document is encoded again so that the strict decoder still rejects unknown keys
+	expanded, err := yaml.Marshal(&document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse seed file %s: %w", path, err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(expanded))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&seed); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse seed file %s: %w", path, err)
+	}
+	return &seed, nil
+}
+
+// expandSeedEnv replaces the ${VAR} references in the scalar values of a parsed seed file. Expanding
+// after parsing keeps secrets that contain YAML syntax, such as ": " or "#", intact.
+func expandSeedEnv(node *yaml.Node) error {
+	var unset []string
+	var expand func(node *yaml.Node)
+	expand = func(node *yaml.Node) {
+		if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "${") {
+			node.Value = seedEnvReference.ReplaceAllStringFunc(node.Value, func(reference string) string {
+				name := seedEnvReference.FindStringSubmatch(reference)[1]
+				value, ok := os.LookupEnv(name)
+				if !ok && !slices.Contains(unset, name) {
+					unset = append(unset, name)
+				}
+				return value
+			})
+			// An unquoted reference takes the type of its value, e.g. port: ${POSTGRES_PORT}
+			if node.Style == 0 {
+				node.Tag = ""
+			}
+		}
+		for _, child := range node.Content {
+			expand(child)
+		}
+	}
+	expand(node)
+
+	if len(unset) > 0 {
+		return fmt.Errorf("environment variables are not set: %s", strings.Join(unset, ", "))
+	}
+	return nil
+}
+
+// Apply creates the seeded users and connections that do not exist yet and saves the seeded
+// settings an administrator has not saved yet. Running it again changes nothing.
+func (s *SeedService) Apply(seed *models.SeedFile) error {
+	for _, user := range seed.Users {
+		if err := s.seedUser(user); err != nil {
+			return err
+		}
+	}
+	for _, conn := range seed.Connections {
+		if err := s.seedConnection(conn); err != nil {
+			return err
+		}
+	}
+	if seed.Settings.Branding != nil {
+		if err := s.seedBranding(seed.Settings.Branding); err != nil {
+			return err
+		}
+	}
+	if seed.Settings.SMTP != nil {
+		if err := s.seedSMTP(seed.Settings.SMTP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedUser creates a seeded user unless the username is taken
+func (s *SeedService) seedUser(seed models.SeedUser) error {
+	var count int64
+	if err := s.db.Model(&models.User{}).Where("username = ?", seed.Username).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check seeded user %s: %w", seed.Username, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	role := seed.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+	if _, err := s.authService.CreateUser(&models.CreateUserRequest{Username: seed.Username, Password: seed.Password, Role: role}); err != nil {
+		return fmt.Errorf("failed to seed user %s: %w", seed.Username, err)
+	}
+	slog.Info("seeded user", "username", seed.Username, "role", role)
+	return nil
+}
+
+// seedConnection creates a seeded connection unless the name is taken
+func (s *SeedService) seedConnection(seed models.SeedConnection) error {
+	var count int64
+	if err := s.db.Model(&models.Connection{}).Where("name = ?", seed.Name).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check seeded connection %s: %w", seed.Name, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	req := &models.ConnectionRequest{
+		Name:          seed.Name,
+		Type:          seed.Type,
+		Host:          seed.Host,
+		Port:          seed.Port,
+		Database:      seed.Database,
+		Username:      seed.Username,
+		Password:      seed.Password,
+		SSLMode:       seed.SSLMode,
+		ExtraParams:   seed.ExtraParams,
+		RequiresGrant: seed.RequiresGrant,
+	}
+	conn, err := s.connectionService.CreateConnection(req, "")
+	if err != nil {
+		return fmt.Errorf("failed to seed connection %s: %w", seed.Name, err)
+	}
+	slog.Info("seeded connection", "connection_id", conn.ID, "name", conn.Name)
+	return nil
+}
+
+// seedBranding saves the seeded branding unless branding was saved before
+func (s *SeedService) seedBranding(seed *models.SeedBranding) error {
+	settings, err := s.settingsService.loadBranding()
+	if err != nil || settings != nil {
+		return err
+	}
+	if seed.ProductName == "" {
+		return fmt.Errorf("failed to seed branding: product_name is required")
+	}
+
+	if _, err := s.settingsService.UpdateBranding(&models.BrandingRequest{ProductName: seed.ProductName, Palette: seed.Palette}, ""); err != nil {
+		return fmt.Errorf("failed to seed branding: %w", err)
+	}
+	slog.Info("seeded branding", "product_name", seed.ProductName)
+	return nil
+}
+
+// seedSMTP saves the seeded SMTP settings unless SMTP settings were saved before
+func (s *SeedService) seedSMTP(seed *models.SeedSMTP) error {
+	settings, err := s.settingsService.loadSMTP()
+	if err != nil || settings != nil {
+		return err
+	}
+	switch seed.TLSMode {
+	case "", SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+	default:
+		return fmt.Errorf("failed to seed SMTP settings: unknown tls_mode %q", seed.TLSMode)
+	}
+
+	req := &models.SMTPSettingsRequest{
+		Host:     seed.Host,
+		Port:     seed.Port,
+		Username: seed.Username,
+		Password: &seed.Password,
+		From:     seed.From,
+		TLSMode:  seed.TLSMode,
+	}
+	if _, err := s.settingsService.UpdateSMTPSettings(req, ""); err != nil {
+		return fmt.Errorf("failed to seed SMTP settings: %w", err)
+	}
+	slog.Info("seeded SMTP settings", "host", seed.Host)
+	return nil
+}
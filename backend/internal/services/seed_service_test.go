@@ -0,0 +1,67 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSeedFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "seed.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestLoadSeedFileExpandsOnlyBracedReferences(t *testing.T) {
+	t.Setenv("SEED_TEST_PASSWORD", "s3cret: #1")
+	t.Setenv("SEED_TEST_PORT", "5433")
+
+	seed, err := LoadSeedFile(writeSeedFile(t, `
+users:
+  - username: admin
+    password: pa$sw0rd
+  - username: analyst
+    password: ${SEED_TEST_PASSWORD}
+connections:
+  - name: warehouse
+    type: postgres
+    port: ${SEED_TEST_PORT}
+`))
+	if err != nil {
+		t.Fatalf("LoadSeedFile() error = %v", err)
+	}
+	if got := seed.Users[0].Password; got != "pa$sw0rd" {
+		t.Errorf("bare $ password = %q, want %q", got, "pa$sw0rd")
+	}
+	if got := seed.Users[1].Password; got != "s3cret: #1" {
+		t.Errorf("expanded password = %q, want %q", got, "s3cret: #1")
+	}
+	if got := seed.Connections[0].Port; got != 5433 {
+		t.Errorf("expanded port = %d, want 5433", got)
+	}
+}
+
+func TestLoadSeedFileRejectsUnsetVariables(t *testing.T) {
+	_, err := LoadSeedFile(writeSeedFile(t, `
+users:
+  - username: admin
+    password: ${SEED_TEST_UNSET_PASSWORD}
+`))
+	if err == nil {
+		t.Fatal("LoadSeedFile() accepted a reference to an unset variable")
+	}
+}
+
+func TestLoadSeedFileRejectsUnknownKeys(t *testing.T) {
+	_, err := LoadSeedFile(writeSeedFile(t, `
+users:
+  - username: admin
+    pasword: secret
+`))
+	if err == nil {
+		t.Fatal("LoadSeedFile() accepted an unknown key")
+	}
+}
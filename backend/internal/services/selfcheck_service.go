@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+	"truadmin/internal/webui"
+)
+
+// minJWTSecretLength is the minimum recommended length of the JWT signing secret
+const minJWTSecretLength = 32
+
+// weakJWTSecrets are placeholder secrets shipped in examples
+var weakJWTSecrets = []string{
+	"your-secret-key-change-in-production",
+	"secret",
+	"changeme",
+}
+
+// SelfCheckService verifies the server configuration and its external dependencies
+type SelfCheckService struct {
+	jwtSecret           string
+	frontend            *webui.Frontend
+	notificationService *NotificationService
+	auditService        *AuditService
+	artifactService     *ArtifactService
+	logger              *slog.Logger
+}
+
+// NewSelfCheckService creates a new self-check service
+func NewSelfCheckService(jwtSecret string, frontend *webui.Frontend, notificationService *NotificationService, auditService *AuditService, artifactService *ArtifactService, logger *slog.Logger) *SelfCheckService {
+	return &SelfCheckService{
+		jwtSecret:           jwtSecret,
+		frontend:            frontend,
+		notificationService: notificationService,
+		auditService:        auditService,
+		artifactService:     artifactService,
+		logger:              logging.OrDefault(logger),
+	}
+}
+
+// Run performs all checks and returns the report
+func (s *SelfCheckService) Run() *models.SelfCheckReport {
+	report := &models.SelfCheckReport{
+		Status:    models.SelfCheckPass,
+		CheckedAt: time.Now().UTC(),
+		Checks:    []models.SelfCheckItem{},
+	}
+
+	report.Checks = append(report.Checks, s.checkJWTSecret())
+	report.Checks = append(report.Checks, s.checkMigrations())
+	report.Checks = append(report.Checks, s.checkTempDir())
+	report.Checks = append(report.Checks, s.checkFrontend())
+	report.Checks = append(report.Checks, s.checkArtifactStorage())
+	report.Checks = append(report.Checks, s.checkNotificationChannels()...)
+
+	for _, check := range report.Checks {
+		if check.Status == models.SelfCheckFail {
+			report.Status = models.SelfCheckFail
+		} else if check.Status == models.SelfCheckWarn && report.Status == models.SelfCheckPass {
+			report.Status = models.SelfCheckWarn
+		}
+	}
+
+	return report
+}
+
+// LogReport writes a one-line summary per check to the server log
+func (s *SelfCheckService) LogReport(report *models.SelfCheckReport) {
+	for _, check := range report.Checks {
+		level := slog.LevelInfo
+		switch check.Status {
+		case models.SelfCheckWarn:
+			level = slog.LevelWarn
+		case models.SelfCheckFail:
+			level = slog.LevelError
+		}
+		s.logger.Log(context.Background(), level, "self-check", "check", check.Name, "message", check.Message)
+	}
+}
+
+// checkJWTSecret verifies the JWT secret is set, long enough and not a known placeholder
+func (s *SelfCheckService) checkJWTSecret() models.SelfCheckItem {
+	item := models.SelfCheckItem{Name: "jwt_secret", Status: models.SelfCheckPass, Message: "JWT secret is set"}
+
+	switch {
+	case s.jwtSecret == "":
+		item.Status = models.SelfCheckFail
+		item.Message = "JWT_SECRET is empty; tokens can be forged"
+	case containsFold(weakJWTSecrets, s.jwtSecret):
+		item.Status = models.SelfCheckFail
+		item.Message = "JWT_SECRET is a well-known placeholder value"
+	case len(s.jwtSecret) < minJWTSecretLength:
+		item.Status = models.SelfCheckWarn
+		item.Message = fmt.Sprintf("JWT_SECRET is %d characters; at least %d are recommended", len(s.jwtSecret), minJWTSecretLength)
+	}
+
+	return item
+}
+
+// checkMigrations verifies the internal database is connected and every migrated table exists
+func (s *SelfCheckService) checkMigrations() models.SelfCheckItem {
+	item := models.SelfCheckItem{Name: "internal_database", Status: models.SelfCheckPass, Message: "All migrations are applied"}
+
+	if !database.IsConnected() {
+		item.Status = models.SelfCheckFail
+		item.Message = "Internal database is not connected"
+		if err := database.GetDBError(); err != nil {
+			item.Message += ": " + err.Error()
+		}
+		return item
+	}
+
+	missing, err := database.MissingTables()
+	if err != nil {
+		item.Status = models.SelfCheckFail
+		item.Message = err.Error()
+		return item
+	}
+	if len(missing) > 0 {
+		item.Status = models.SelfCheckFail
+		item.Message = "Missing tables: " + strings.Join(missing, ", ")
+	}
+
+	return item
+}
+
+// checkTempDir verifies a file can be created in the temp directory
+func (s *SelfCheckService) checkTempDir() models.SelfCheckItem {
+	dir := os.TempDir()
+	item := models.SelfCheckItem{Name: "temp_dir", Status: models.SelfCheckPass, Message: dir + " is writable"}
+
+	f, err := os.CreateTemp(dir, "truadmin-selfcheck-*")
+	if err != nil {
+		item.Status = models.SelfCheckFail
+		item.Message = fmt.Sprintf("%s is not writable: %v", dir, err)
+		return item
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	return item
+}
+
+// checkFrontend verifies the frontend build contains index.html
+func (s *SelfCheckService) checkFrontend() models.SelfCheckItem {
+	item := models.SelfCheckItem{Name: "frontend_build", Status: models.SelfCheckPass, Message: "Frontend build found (" + s.frontend.Source + ")"}
+
+	if !s.frontend.Available() {
+		item.Status = models.SelfCheckWarn
+		item.Message = fmt.Sprintf("Frontend build not found (%s); only the API is served", s.frontend.Source)
+	}
+
+	return item
+}
+
+// checkArtifactStorage verifies the artifact storage backend is reachable and writable
+func (s *SelfCheckService) checkArtifactStorage() models.SelfCheckItem {
+	backend, err := s.artifactService.ProbeStorage()
+	item := models.SelfCheckItem{Name: "artifact_storage", Status: models.SelfCheckPass, Message: "Artifact storage (" + backend + ") is writable"}
+
+	if err != nil {
+		item.Status = models.SelfCheckFail
+		item.Message = fmt.Sprintf("Artifact storage (%s) is not usable: %v", backend, err)
+	}
+
+	return item
+}
+
+// checkNotificationChannels probes the webhook, SMTP server and audit sinks that are configured
+func (s *SelfCheckService) checkNotificationChannels() []models.SelfCheckItem {
+	items := []models.SelfCheckItem{}
+
+	probe := func(name string, err error, okMessage string) models.SelfCheckItem {
+		if err != nil {
+			return models.SelfCheckItem{Name: name, Status: models.SelfCheckFail, Message: err.Error()}
+		}
+		return models.SelfCheckItem{Name: name, Status: models.SelfCheckPass, Message: okMessage}
+	}
+
+	channels := s.notificationService.Channels()
+	if len(channels) == 1 {
+		items = append(items, models.SelfCheckItem{
+			Name:    "notifications",
+			Status:  models.SelfCheckWarn,
+			Message: "No webhook or email configured; background job failures are only logged",
+		})
+	}
+	for _, channel := range channels {
+		switch channel {
+		case "webhook":
+			items = append(items, probe("notification_webhook", s.notificationService.ProbeWebhook(), "Webhook host is reachable"))
+		case "email":
+			items = append(items, probe("notification_smtp", s.notificationService.ProbeSMTP(), "SMTP server is reachable"))
+		}
+	}
+
+	results := s.auditService.ProbeSinks()
+	for _, name := range s.auditService.Sinks() {
+		items = append(items, probe("audit_"+name, results[name], "Audit "+name+" sink is reachable"))
+	}
+
+	return items
+}
+
+// containsFold reports whether list contains value, ignoring case
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// MaxLogoBytes caps the size of an uploaded branding logo
+const MaxLogoBytes = 512 * 1024
+
+// defaultProductName is served until an administrator sets a product name
+const defaultProductName = "TruAdmin"
+
+// brandingLogoURL is where the SPA loads the uploaded logo from
+const brandingLogoURL = "/api/v1/branding/logo"
+
+// ErrInvalidSettings is returned when submitted settings fail validation
+var ErrInvalidSettings = errors.New("invalid settings")
+
+// hexColorPattern matches #rgb and #rrggbb colors
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// allowedLogoTypes lists the image types accepted as a logo. SVG is excluded because the
+// logo is served without authentication and SVG files can carry scripts.
+var allowedLogoTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// SettingsService handles deployment-wide settings managed by administrators
+type SettingsService struct {
+	db                  *gorm.DB
+	notificationService *NotificationService
+	envSMTP             SMTPConfig
+}
+
+// NewSettingsService creates a new settings service.
+// envSMTP is the SMTP configuration from the environment, used until SMTP settings are saved.
+func NewSettingsService(notificationService *NotificationService, envSMTP SMTPConfig) *SettingsService {
+	return &SettingsService{
+		db:                  database.GetDB(),
+		notificationService: notificationService,
+		envSMTP:             envSMTP,
+	}
+}
+
+// GetBranding returns the branding served to the SPA, falling back to the defaults
+func (s *SettingsService) GetBranding() (*models.Branding, error) {
+	settings, err := s.loadBranding()
+	if err != nil {
+		return nil, err
+	}
+	return brandingView(settings), nil
+}
+
+// UpdateBranding sets the product name and palette; empty colors reset to the default palette
+func (s *SettingsService) UpdateBranding(req *models.BrandingRequest, userID string) (*models.Branding, error) {
+	palette, err := normalizePalette(req.Palette)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.loadBranding()
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &models.BrandingSettings{ID: models.BrandingSettingsID}
+	}
+
+	settings.ProductName = req.ProductName
+	settings.Palette = palette
+	settings.UpdatedBy = userID
+
+	if err := s.db.Save(settings).Error; err != nil {
+		return nil, fmt.Errorf("failed to save branding: %w", err)
+	}
+	return brandingView(settings), nil
+}
+
+// GetLogo returns the uploaded logo and its content type
+func (s *SettingsService) GetLogo() ([]byte, string, error) {
+	settings, err := s.loadBranding()
+	if err != nil {
+		return nil, "", err
+	}
+	if settings == nil || len(settings.LogoData) == 0 {
+		return nil, "", fmt.Errorf("logo not found")
+	}
+	return settings.LogoData, settings.LogoContentType, nil
+}
+
+// SetLogo stores an uploaded logo after checking its size and detected image type
+func (s *SettingsService) SetLogo(data []byte, userID string) (*models.Branding, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: logo file is empty", ErrInvalidSettings)
+	}
+	if len(data) > MaxLogoBytes {
+		return nil, fmt.Errorf("%w: logo is too large: %d bytes, limit is %d bytes", ErrInvalidSettings, len(data), MaxLogoBytes)
+	}
+
+	contentType := http.DetectContentType(data)
+	if !allowedLogoTypes[contentType] {
+		return nil, fmt.Errorf("%w: unsupported logo type %s, use PNG, JPEG, GIF or WebP", ErrInvalidSettings, contentType)
+	}
+
+	settings, err := s.loadBranding()
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &models.BrandingSettings{
+			ID:          models.BrandingSettingsID,
+			ProductName: defaultProductName,
+			Palette:     models.DefaultBrandingPalette,
+		}
+	}
+
+	settings.LogoData = data
+	settings.LogoContentType = contentType
+	settings.UpdatedBy = userID
+
+	if err := s.db.Save(settings).Error; err != nil {
+		return nil, fmt.Errorf("failed to save logo: %w", err)
+	}
+	return brandingView(settings), nil
+}
+
+// DeleteLogo removes the uploaded logo
+func (s *SettingsService) DeleteLogo(userID string) error {
+	err := s.db.Model(&models.BrandingSettings{}).
+		Where("id = ?", models.BrandingSettingsID).
+		Updates(map[string]interface{}{
+			"logo_data":         nil,
+			"logo_content_type": "",
+			"updated_by":        userID,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete logo: %w", err)
+	}
+	return nil
+}
+
+// loadBranding returns the stored branding settings, or nil when none were saved yet
+func (s *SettingsService) loadBranding() (*models.BrandingSettings, error) {
+	var settings models.BrandingSettings
+	if err := s.db.First(&settings, "id = ?", models.BrandingSettingsID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get branding: %w", err)
+	}
+	return &settings, nil
+}
+
+// brandingView converts stored settings into the public branding response
+func brandingView(settings *models.BrandingSettings) *models.Branding {
+	if settings == nil {
+		return &models.Branding{
+			ProductName: defaultProductName,
+			Palette:     models.DefaultBrandingPalette,
+		}
+	}
+
+	branding := &models.Branding{
+		ProductName: settings.ProductName,
+		Palette:     settings.Palette,
+		UpdatedAt:   &settings.UpdatedAt,
+	}
+	if len(settings.LogoData) > 0 {
+		logoURL := fmt.Sprintf("%s?v=%d", brandingLogoURL, settings.UpdatedAt.Unix())
+		branding.LogoURL = &logoURL
+	}
+	return branding
+}
+
+// normalizePalette validates palette colors and fills empty ones from the default palette
+func normalizePalette(palette models.BrandingPalette) (models.BrandingPalette, error) {
+	defaults := models.DefaultBrandingPalette
+	colors := []struct {
+		name     string
+		value    *string
+		fallback string
+	}{
+		{"primary", &palette.Primary, defaults.Primary},
+		{"primary_hover", &palette.PrimaryHover, defaults.PrimaryHover},
+		{"primary_light", &palette.PrimaryLight, defaults.PrimaryLight},
+		{"primary_dark", &palette.PrimaryDark, defaults.PrimaryDark},
+	}
+
+	for _, color := range colors {
+		if *color.value == "" {
+			*color.value = color.fallback
+			continue
+		}
+		if !hexColorPattern.MatchString(*color.value) {
+			return palette, fmt.Errorf("%w: %s color %q must be #rgb or #rrggbb", ErrInvalidSettings, color.name, *color.value)
+		}
+	}
+	return palette, nil
+}
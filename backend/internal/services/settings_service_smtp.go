@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/models"
+)
+
+// GetSMTPSettings returns the effective SMTP settings with the password masked
+func (s *SettingsService) GetSMTPSettings() (*models.SMTPSettingsResponse, error) {
+	settings, err := s.loadSMTP()
+	if err != nil {
+		return nil, err
+	}
+	return s.smtpView(settings), nil
+}
+
+// UpdateSMTPSettings validates and saves the SMTP settings and applies them to email delivery
+func (s *SettingsService) UpdateSMTPSettings(req *models.SMTPSettingsRequest, userID string) (*models.SMTPSettingsResponse, error) {
+	host := strings.TrimSpace(req.Host)
+	if host != "" && req.From == "" {
+		return nil, fmt.Errorf("%w: from address is required when a host is set", ErrInvalidSettings)
+	}
+	if req.Port != "" {
+		if port, err := strconv.Atoi(req.Port); err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("%w: port %q must be between 1 and 65535", ErrInvalidSettings, req.Port)
+		}
+	}
+
+	settings, err := s.loadSMTP()
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		// The first save starts from the environment so an omitted password is kept
+		settings = &models.SMTPSettings{ID: models.SMTPSettingsID, Password: s.envSMTP.Password}
+	}
+
+	settings.Host = host
+	settings.Port = req.Port
+	settings.Username = req.Username
+	settings.From = req.From
+	settings.TLSMode = req.TLSMode
+	if settings.TLSMode == "" {
+		settings.TLSMode = SMTPTLSStartTLS
+	}
+	if req.Password != nil && *req.Password != models.MaskedValue {
+		settings.Password = *req.Password
+	}
+	settings.UpdatedBy = userID
+
+	if err := s.db.Save(settings).Error; err != nil {
+		return nil, fmt.Errorf("failed to save SMTP settings: %w", err)
+	}
+
+	s.notificationService.SetSMTPConfig(smtpConfigOf(settings))
+	return s.smtpView(settings), nil
+}
+
+// SendTestEmail sends a test message to the given address using the current SMTP settings
+func (s *SettingsService) SendTestEmail(to string) error {
+	if !s.notificationService.EmailEnabled() {
+		return fmt.Errorf("%w: email delivery is not configured", ErrInvalidSettings)
+	}
+
+	body := fmt.Sprintf("This is a test email from %s sent at %s.\n\nIf you received it, SMTP delivery is configured correctly.\n",
+		defaultProductName, time.Now().UTC().Format(time.RFC1123))
+	return s.notificationService.SendEmail([]string{to}, defaultProductName+" SMTP test", body)
+}
+
+// ApplySMTPSettings loads saved SMTP settings into the notification service; it is called at startup
+func (s *SettingsService) ApplySMTPSettings() error {
+	settings, err := s.loadSMTP()
+	if err != nil {
+		return err
+	}
+	if settings != nil {
+		s.notificationService.SetSMTPConfig(smtpConfigOf(settings))
+	}
+	return nil
+}
+
+// loadSMTP returns the saved SMTP settings, or nil when none were saved yet
+func (s *SettingsService) loadSMTP() (*models.SMTPSettings, error) {
+	var settings models.SMTPSettings
+	if err := s.db.First(&settings, "id = ?", models.SMTPSettingsID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get SMTP settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// smtpView converts saved settings, or the environment configuration when nil, into the masked response
+func (s *SettingsService) smtpView(settings *models.SMTPSettings) *models.SMTPSettingsResponse {
+	response := &models.SMTPSettingsResponse{Source: "environment"}
+	cfg := s.envSMTP
+	if settings != nil {
+		cfg = smtpConfigOf(settings)
+		response.Source = "settings"
+		response.UpdatedAt = &settings.UpdatedAt
+	}
+	if cfg.TLSMode == "" {
+		cfg.TLSMode = SMTPTLSStartTLS
+	}
+
+	response.Host = cfg.Host
+	response.Port = cfg.Port
+	response.Username = cfg.Username
+	response.From = cfg.From
+	response.TLSMode = cfg.TLSMode
+	response.Enabled = cfg.Host != "" && cfg.From != ""
+	if cfg.Password != "" {
+		response.Password = models.MaskedValue
+		response.PasswordSet = true
+	}
+	return response
+}
+
+// smtpConfigOf converts saved SMTP settings into a notification SMTP configuration
+func smtpConfigOf(settings *models.SMTPSettings) SMTPConfig {
+	return SMTPConfig{
+		Host:     settings.Host,
+		Port:     settings.Port,
+		Username: settings.Username,
+		Password: settings.Password,
+		From:     settings.From,
+		TLSMode:  settings.TLSMode,
+	}
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryStoreSweepEvery is the number of writes between sweeps of expired keys of the in-memory store
+const memoryStoreSweepEvery = 1000
+
+// SharedStore holds state that replicas must agree on: cached values, rate limit counters, revoked
+// tokens and pub/sub messages. With Redis configured it is shared by all replicas; otherwise an
+// in-memory store keeps the state of a single replica. Callers treat store errors as a cache miss
+// or an allowed request, so a store outage degrades to per-replica behaviour instead of failing.
+type SharedStore interface {
+	// Name returns the backend of the store: memory or redis
+	Name() string
+	// Ping checks that the store is reachable
+	Ping() error
+	// Get returns the value of a key and whether it exists
+	Get(key string) ([]byte, bool, error)
+	// Set stores a value; a zero ttl keeps it until it is deleted
+	Set(key string, value []byte, ttl time.Duration) error
+	// Delete removes keys
+	Delete(keys ...string) error
+	// Incr increments a counter that expires window after its first increment and returns its value
+	Incr(key string, window time.Duration) (int64, error)
+	// Publish sends a message to the subscribers of a channel on every replica
+	Publish(channel string, message []byte) error
+	// Subscribe calls handler with each message published to a channel until the returned function is called
+	Subscribe(channel string, handler func(message []byte)) (func(), error)
+	// Close releases the connections of the store
+	Close() error
+}
+
+// NewSharedStore creates the Redis store for redisURL (redis:// or rediss://), or an in-memory
+// store when it is empty. logger reports Redis subscriber reconnects.
+func NewSharedStore(redisURL string, logger *slog.Logger) (SharedStore, error) {
+	if strings.TrimSpace(redisURL) == "" {
+		return NewMemoryStore(), nil
+	}
+	return NewRedisStore(redisURL, logger)
+}
+
+// memoryItem is a value of the in-memory store
+type memoryItem struct {
+	value     []byte
+	counter   int64
+	expiresAt time.Time // Zero when the item does not expire
+}
+
+// MemoryStore is the SharedStore of a single replica
+type MemoryStore struct {
+	mu     sync.Mutex
+	items  map[string]*memoryItem
+	writes int
+
+	subsMu    sync.Mutex
+	subs      map[string]map[int]func([]byte)
+	nextSubID int
+}
+
+// NewMemoryStore creates a new in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		items: map[string]*memoryItem{},
+		subs:  map[string]map[int]func([]byte){},
+	}
+}
+
+// Name returns the backend of the store
+func (s *MemoryStore) Name() string { return "memory" }
+
+// Ping checks that the store is reachable, which it always is
+func (s *MemoryStore) Ping() error { return nil }
+
+// Get returns the value of a key and whether it exists
+func (s *MemoryStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.live(key, time.Now())
+	if !ok {
+		return nil, false, nil
+	}
+	return item.value, true, nil
+}
+
+// Set stores a value; a zero ttl keeps it until it is deleted
+func (s *MemoryStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	item := &memoryItem{value: value}
+	if ttl > 0 {
+		item.expiresAt = now.Add(ttl)
+	}
+	s.items[key] = item
+	s.wrote(now)
+	return nil
+}
+
+// Delete removes keys
+func (s *MemoryStore) Delete(keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.items, key)
+	}
+	return nil
+}
+
+// Incr increments a counter that expires window after its first increment and returns its value
+func (s *MemoryStore) Incr(key string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	item, ok := s.live(key, now)
+	if !ok {
+		item = &memoryItem{}
+		if window > 0 {
+			item.expiresAt = now.Add(window)
+		}
+		s.items[key] = item
+		s.wrote(now)
+	}
+	item.counter++
+	return item.counter, nil
+}
+
+// Publish calls the handlers subscribed to a channel
+func (s *MemoryStore) Publish(channel string, message []byte) error {
+	s.subsMu.Lock()
+	handlers := make([]func([]byte), 0, len(s.subs[channel]))
+	for _, handler := range s.subs[channel] {
+		handlers = append(handlers, handler)
+	}
+	s.subsMu.Unlock()
+
+	for _, handler := range handlers {
+		handler(message)
+	}
+	return nil
+}
+
+// Subscribe calls handler with each message published to a channel until the returned function is called
+func (s *MemoryStore) Subscribe(channel string, handler func(message []byte)) (func(), error) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+
+	id := s.nextSubID
+	s.nextSubID++
+	if s.subs[channel] == nil {
+		s.subs[channel] = map[int]func([]byte){}
+	}
+	s.subs[channel][id] = handler
+
+	return func() {
+		s.subsMu.Lock()
+		defer s.subsMu.Unlock()
+		delete(s.subs[channel], id)
+		if len(s.subs[channel]) == 0 {
+			delete(s.subs, channel)
+		}
+	}, nil
+}
+
+// Close releases the store
+func (s *MemoryStore) Close() error { return nil }
+
+// live returns an item that has not expired; callers hold s.mu
+func (s *MemoryStore) live(key string, now time.Time) (*memoryItem, bool) {
+	item, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	if !item.expiresAt.IsZero() && !now.Before(item.expiresAt) {
+		delete(s.items, key)
+		return nil, false
+	}
+	return item, true
+}
+
+// wrote counts a write and sweeps expired items every memoryStoreSweepEvery writes; callers hold s.mu
+func (s *MemoryStore) wrote(now time.Time) {
+	s.writes++
+	if s.writes < memoryStoreSweepEvery {
+		return
+	}
+	s.writes = 0
+	for key, item := range s.items {
+		if !item.expiresAt.IsZero() && !now.Before(item.expiresAt) {
+			delete(s.items, key)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package services
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"truadmin/internal/logging"
+)
+
+const (
+	// redisKeyPrefix namespaces the keys and channels of truadmin in a shared Redis
+	redisKeyPrefix = "truadmin:"
+	// redisMaxIdleConns is the number of command connections kept open between commands
+	redisMaxIdleConns = 8
+	// redisTimeout bounds dialing and each command
+	redisTimeout = 5 * time.Second
+	// redisResubscribeDelay is the wait before the subscriber reconnects after losing its connection
+	redisResubscribeDelay = time.Second
+)
+
+// redisError is an error reply of the Redis server
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// redisConn is a connection speaking RESP, the Redis protocol
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// RedisStore is the SharedStore of replicas sharing a Redis server. Commands use a small pool of
+// connections; subscriptions share one connection that is re-established when it breaks.
+type RedisStore struct {
+	addr      string
+	username  string
+	password  string
+	db        int
+	tlsConfig *tls.Config
+	idle      chan *redisConn
+	logger    *slog.Logger
+
+	subsMu    sync.Mutex
+	subs      map[string]map[int]func([]byte)
+	nextSubID int
+	subConn   *redisConn // Current subscriber connection; nil while reconnecting
+	subLoop   bool
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRedisStore creates a Redis store for a redis://[user:password@]host:port/db URL; rediss://
+// connects with TLS. Connections are opened when first needed.
+func NewRedisStore(redisURL string, logger *slog.Logger) (*RedisStore, error) {
+	u, err := url.Parse(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("invalid Redis URL: scheme must be redis or rediss")
+	}
+
+	s := &RedisStore{
+		addr:   u.Host,
+		idle:   make(chan *redisConn, redisMaxIdleConns),
+		subs:   map[string]map[int]func([]byte){},
+		closed: make(chan struct{}),
+		logger: logging.OrDefault(logger),
+	}
+	if u.Port() == "" {
+		s.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		s.username = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if s.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid Redis URL: database must be a number")
+		}
+	}
+	if u.Scheme == "rediss" {
+		s.tlsConfig = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	}
+	return s, nil
+}
+
+// Name returns the backend of the store
+func (s *RedisStore) Name() string { return "redis" }
+
+// Ping checks that the Redis server is reachable
+func (s *RedisStore) Ping() error {
+	_, err := s.do("PING")
+	return err
+}
+
+// Get returns the value of a key and whether it exists
+func (s *RedisStore) Get(key string) ([]byte, bool, error) {
+	reply, err := s.do("GET", redisKeyPrefix+key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected reply to GET")
+	}
+	return value, true, nil
+}
+
+// Set stores a value; a zero ttl keeps it until it is deleted
+func (s *RedisStore) Set(key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", redisKeyPrefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(redisMillis(ttl), 10))
+	}
+	_, err := s.do(args...)
+	return err
+}
+
+// Delete removes keys
+func (s *RedisStore) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := []string{"DEL"}
+	for _, key := range keys {
+		args = append(args, redisKeyPrefix+key)
+	}
+	_, err := s.do(args...)
+	return err
+}
+
+// Incr increments a counter that expires window after its first increment and returns its value
+func (s *RedisStore) Incr(key string, window time.Duration) (int64, error) {
+	key = redisKeyPrefix + key
+	// SET NX starts the window only for a new counter; INCR keeps the expiry it set
+	if window > 0 {
+		if _, err := s.do("SET", key, "0", "PX", strconv.FormatInt(redisMillis(window), 10), "NX"); err != nil {
+			return 0, err
+		}
+	}
+	reply, err := s.do("INCR", key)
+	if err != nil {
+		return 0, err
+	}
+	count, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected reply to INCR")
+	}
+	return count, nil
+}
+
+// Publish sends a message to the subscribers of a channel on every replica
+func (s *RedisStore) Publish(channel string, message []byte) error {
+	_, err := s.do("PUBLISH", redisKeyPrefix+channel, string(message))
+	return err
+}
+
+// Subscribe calls handler with each message published to a channel until the returned function is
+// called. Messages published while the subscriber connection is being re-established are lost.
+func (s *RedisStore) Subscribe(channel string, handler func(message []byte)) (func(), error) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+
+	id := s.nextSubID
+	s.nextSubID++
+	if s.subs[channel] == nil {
+		s.subs[channel] = map[int]func([]byte){}
+		if s.subConn != nil {
+			s.subConn.send("SUBSCRIBE", redisKeyPrefix+channel)
+		}
+	}
+	s.subs[channel][id] = handler
+	if !s.subLoop {
+		s.subLoop = true
+		go s.subscribeLoop()
+	}
+
+	return func() {
+		s.subsMu.Lock()
+		defer s.subsMu.Unlock()
+		delete(s.subs[channel], id)
+		if len(s.subs[channel]) == 0 {
+			delete(s.subs, channel)
+			if s.subConn != nil {
+				s.subConn.send("UNSUBSCRIBE", redisKeyPrefix+channel)
+			}
+		}
+	}, nil
+}
+
+// Close closes the connections of the store
+func (s *RedisStore) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+
+		s.subsMu.Lock()
+		if s.subConn != nil {
+			s.subConn.conn.Close()
+		}
+		s.subsMu.Unlock()
+
+		for {
+			select {
+			case c := <-s.idle:
+				c.conn.Close()
+			default:
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// do runs a command on a pooled connection and returns its reply
+func (s *RedisStore) do(args ...string) (interface{}, error) {
+	var c *redisConn
+	select {
+	case c = <-s.idle:
+	default:
+		var err error
+		if c, err = s.dial(); err != nil {
+			return nil, err
+		}
+	}
+
+	c.conn.SetDeadline(time.Now().Add(redisTimeout))
+	reply, err := c.command(args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection may be out of sync with the server
+		c.conn.Close()
+		return nil, err
+	}
+
+	select {
+	case s.idle <- c:
+	default:
+		c.conn.Close()
+	}
+	return reply, err
+}
+
+// dial opens a connection, authenticates and selects the database
+func (s *RedisStore) dial() (*redisConn, error) {
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var conn net.Conn
+	var err error
+	if s.tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.addr, s.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", s.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	c := &redisConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+	conn.SetDeadline(time.Now().Add(redisTimeout))
+	if s.password != "" {
+		args := []string{"AUTH", s.password}
+		if s.username != "" {
+			args = []string{"AUTH", s.username, s.password}
+		}
+		if _, err := c.command(args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to authenticate with Redis: %w", err)
+		}
+	}
+	if s.db != 0 {
+		if _, err := c.command("SELECT", strconv.Itoa(s.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to select Redis database: %w", err)
+		}
+	}
+	conn.SetDeadline(time.Time{})
+	return c, nil
+}
+
+// subscribeLoop keeps the subscriber connection open and dispatches messages until the store is closed
+func (s *RedisStore) subscribeLoop() {
+	for {
+		select {
+		case <-s.closed:
+			return
+		default:
+		}
+
+		if err := s.subscribeOnce(); err != nil {
+			select {
+			case <-s.closed:
+				return
+			default:
+			}
+			s.logger.Warn("Redis subscriber disconnected", "error", err)
+			time.Sleep(redisResubscribeDelay)
+		}
+	}
+}
+
+// subscribeOnce subscribes to the current channels on a new connection and reads messages until it breaks
+func (s *RedisStore) subscribeOnce() error {
+	c, err := s.dial()
+	if err != nil {
+		return err
+	}
+	defer c.conn.Close()
+
+	s.subsMu.Lock()
+	s.subConn = c
+	for channel := range s.subs {
+		c.send("SUBSCRIBE", redisKeyPrefix+channel)
+	}
+	s.subsMu.Unlock()
+
+	defer func() {
+		s.subsMu.Lock()
+		s.subConn = nil
+		s.subsMu.Unlock()
+	}()
+
+	for {
+		reply, err := c.read()
+		if err != nil {
+			return err
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 3 {
+			continue
+		}
+		kind, _ := parts[0].([]byte)
+		channel, _ := parts[1].([]byte)
+		payload, _ := parts[2].([]byte)
+		if string(kind) != "message" {
+			continue // Confirmations of SUBSCRIBE and UNSUBSCRIBE
+		}
+
+		s.subsMu.Lock()
+		handlers := []func([]byte){}
+		for _, handler := range s.subs[strings.TrimPrefix(string(channel), redisKeyPrefix)] {
+			handlers = append(handlers, handler)
+		}
+		s.subsMu.Unlock()
+
+		for _, handler := range handlers {
+			handler(payload)
+		}
+	}
+}
+
+// command writes a command and reads its reply
+func (c *redisConn) command(args ...string) (interface{}, error) {
+	if err := c.write(args...); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// send writes a command without waiting for its reply; the subscriber loop reads the confirmation
+func (c *redisConn) send(args ...string) {
+	c.conn.SetWriteDeadline(time.Now().Add(redisTimeout))
+	if err := c.write(args...); err != nil {
+		// The subscriber loop sees the broken connection and resubscribes
+		c.conn.Close()
+	}
+}
+
+// write encodes a command as an array of bulk strings
+func (c *redisConn) write(args ...string) error {
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return c.w.Flush()
+}
+
+// read decodes one reply: simple strings as string, errors as redisError, integers as int64,
+// bulk strings as []byte and arrays as []interface{}; null replies are nil
+func (c *redisConn) read() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid bulk length: %w", err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length: %w", err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = c.read(); err != nil {
+				var replyErr redisError
+				if !errors.As(err, &replyErr) {
+					return nil, err
+				}
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// redisMillis converts a duration to milliseconds, at least one
+func redisMillis(d time.Duration) int64 {
+	if ms := d.Milliseconds(); ms > 0 {
+		return ms
+	}
+	return 1
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// ErrSnapshotForbidden is returned when a user may not access a snapshot
+var ErrSnapshotForbidden = errors.New("access to snapshot denied")
+
+// SnapshotService handles stored query result snapshots and their sharing links
+type SnapshotService struct {
+	db              *gorm.DB
+	databaseService *DatabaseService
+	accessGrants    *AccessGrantService
+	maxBytes        int
+}
+
+// NewSnapshotService creates a new snapshot service; maxBytes caps the compressed size of a snapshot
+func NewSnapshotService(databaseService *DatabaseService, accessGrants *AccessGrantService, maxBytes int) *SnapshotService {
+	return &SnapshotService{
+		db:              database.GetDB(),
+		databaseService: databaseService,
+		accessGrants:    accessGrants,
+		maxBytes:        maxBytes,
+	}
+}
+
+// CreateSnapshot runs a query and stores its result set as a compressed snapshot
+func (s *SnapshotService) CreateSnapshot(req *models.SnapshotRequest, ownerID string) (*models.QueryResultSnapshot, error) {
+	if err := s.accessGrants.CheckUserAccess(ownerID, req.ConnectionID, true); err != nil {
+		return nil, err
+	}
+	result, err := s.databaseService.ExecuteQuery(context.Background(), req.ConnectionID, req.DatabaseName, req.Query)
+	if err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("query failed: %s", result.Error)
+	}
+
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return nil, fmt.Errorf("failed to compress result: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress result: %w", err)
+	}
+
+	if s.maxBytes > 0 && buf.Len() > s.maxBytes {
+		return nil, fmt.Errorf("snapshot is too large: %d bytes compressed, limit is %d bytes", buf.Len(), s.maxBytes)
+	}
+
+	snapshot := &models.QueryResultSnapshot{
+		ID:             uuid.New().String(),
+		Name:           req.Name,
+		ConnectionID:   req.ConnectionID,
+		DatabaseName:   req.DatabaseName,
+		Query:          req.Query,
+		OwnerID:        ownerID,
+		RowCount:       len(result.Rows),
+		OriginalSize:   len(raw),
+		CompressedSize: buf.Len(),
+		Data:           buf.Bytes(),
+		SharedWith:     models.StringList{},
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	if err := s.db.Create(snapshot).Error; err != nil {
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// GetSnapshots returns snapshots the user owns or that are shared with them, without result data
+func (s *SnapshotService) GetSnapshots(userID string, isAdmin bool) ([]models.QueryResultSnapshot, error) {
+	var snapshots []models.QueryResultSnapshot
+	if err := s.db.Omit("data").Order("created_at DESC").Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("failed to get snapshots: %w", err)
+	}
+
+	visible := []models.QueryResultSnapshot{}
+	for _, snapshot := range snapshots {
+		allowed, err := s.canViewSnapshot(&snapshot, userID, isAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			visible = append(visible, snapshot)
+		}
+	}
+	return visible, nil
+}
+
+// GetSnapshot returns a snapshot with its decompressed result set
+func (s *SnapshotService) GetSnapshot(id, userID string, isAdmin bool) (*models.SnapshotWithResult, error) {
+	var snapshot models.QueryResultSnapshot
+	if err := s.db.First(&snapshot, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("snapshot not found")
+		}
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	if allowed, err := s.canViewSnapshot(&snapshot, userID, isAdmin); err != nil {
+		return nil, err
+	} else if !allowed {
+		return nil, ErrSnapshotForbidden
+	}
+
+	return decodeSnapshot(&snapshot)
+}
+
+// GetSharedSnapshot resolves a sharing link token and checks the user may open it
+func (s *SnapshotService) GetSharedSnapshot(token, userID string, isAdmin bool) (*models.SnapshotWithResult, error) {
+	var snapshot models.QueryResultSnapshot
+	if err := s.db.First(&snapshot, "share_token = ?", token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("snapshot not found")
+		}
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	if allowed, err := s.canViewSnapshot(&snapshot, userID, isAdmin); err != nil {
+		return nil, err
+	} else if !allowed {
+		return nil, ErrSnapshotForbidden
+	}
+
+	return decodeSnapshot(&snapshot)
+}
+
+// ShareSnapshot creates (or rotates) the sharing link of a snapshot
+func (s *SnapshotService) ShareSnapshot(id, userID string, isAdmin bool, req *models.SnapshotShareRequest) (*models.QueryResultSnapshot, error) {
+	snapshot, err := s.getOwnedSnapshot(id, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenBytes := make([]byte, 24)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate share token: %w", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	snapshot.ShareToken = &token
+	snapshot.SharedWith = models.StringList(req.UserIDs)
+	if snapshot.SharedWith == nil {
+		snapshot.SharedWith = models.StringList{}
+	}
+
+	if err := s.db.Model(snapshot).Updates(map[string]interface{}{
+		"share_token": snapshot.ShareToken,
+		"shared_with": snapshot.SharedWith,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to share snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// UnshareSnapshot revokes the sharing link of a snapshot
+func (s *SnapshotService) UnshareSnapshot(id, userID string, isAdmin bool) error {
+	snapshot, err := s.getOwnedSnapshot(id, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Model(snapshot).Updates(map[string]interface{}{
+		"share_token": nil,
+		"shared_with": models.StringList{},
+	}).Error; err != nil {
+		return fmt.Errorf("failed to unshare snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSnapshot removes a snapshot owned by the user
+func (s *SnapshotService) DeleteSnapshot(id, userID string, isAdmin bool) error {
+	snapshot, err := s.getOwnedSnapshot(id, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(snapshot).Error; err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+	return nil
+}
+
+// getOwnedSnapshot loads a snapshot without data and checks the user may manage it
+func (s *SnapshotService) getOwnedSnapshot(id, userID string, isAdmin bool) (*models.QueryResultSnapshot, error) {
+	var snapshot models.QueryResultSnapshot
+	if err := s.db.Omit("data").First(&snapshot, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("snapshot not found")
+		}
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	if snapshot.OwnerID != userID && !isAdmin {
+		return nil, ErrSnapshotForbidden
+	}
+	return &snapshot, nil
+}
+
+// canViewSnapshot reports whether a user may read a snapshot. Besides the sharing link, users other
+// than the owner need access to the snapshot's connection, as the result set holds its data.
+func (s *SnapshotService) canViewSnapshot(snapshot *models.QueryResultSnapshot, userID string, isAdmin bool) (bool, error) {
+	if isAdmin || snapshot.OwnerID == userID {
+		return true, nil
+	}
+	if snapshot.ShareToken == nil {
+		return false, nil
+	}
+	if len(snapshot.SharedWith) > 0 && !snapshot.SharedWith.Contains(userID) {
+		return false, nil
+	}
+	return s.accessGrants.CanReadConnection(userID, snapshot.ConnectionID)
+}
+
+// decodeSnapshot decompresses the stored result set of a snapshot
+func decodeSnapshot(snapshot *models.QueryResultSnapshot) (*models.SnapshotWithResult, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(snapshot.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress snapshot: %w", err)
+	}
+	defer zr.Close()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress snapshot: %w", err)
+	}
+
+	var result models.QueryResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	return &models.SnapshotWithResult{
+		QueryResultSnapshot: *snapshot,
+		Result:              &result,
+	}, nil
+}
@@ -0,0 +1,528 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// SQL text is generated by the pure functions in this file so that it can be checked
+// without a database; callers only resolve the inputs and execute the result.
+
+// numericColumnTypes lists information_schema data types that are filtered as text via CAST
+var numericColumnTypes = map[string]bool{
+	"integer":          true,
+	"bigint":           true,
+	"smallint":         true,
+	"numeric":          true,
+	"real":             true,
+	"double precision": true,
+}
+
+// ErrInvalidFilter is returned for filters, filter expressions and sort columns that do not fit the table
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Limits of a filter expression
+const (
+	maxFilterDepth      = 5
+	maxFilterConditions = 50
+	maxFilterValues     = 1000
+)
+
+// filterComparisons maps the comparison operators of filter expressions to SQL
+var filterComparisons = map[string]string{
+	"eq":  "=",
+	"ne":  "<>",
+	"lt":  "<",
+	"lte": "<=",
+	"gt":  ">",
+	"gte": ">=",
+}
+
+// filterColumn returns the quoted column for a filter field, cast to text for pattern
+// matching when asText is set and the column is numeric
+func filterColumn(d dialect, columnTypes map[string]string, field string, asText bool) (string, error) {
+	name, ok := matchColumn(columnTypes, field)
+	if !ok {
+		return "", fmt.Errorf("%w: unknown column %q", ErrInvalidFilter, field)
+	}
+	dataType := columnTypes[name]
+	column := quoteName(d, name)
+	if asText && numericColumnTypes[dataType] {
+		column = d.castToText(column)
+	}
+	return column, nil
+}
+
+// escapeLikePattern escapes the wildcards of a value matched with LIKE or ILIKE
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// buildFilterClause builds a WHERE clause matching filters with ILIKE, starting at $1.
+// When several filters share the same value it is treated as a general search and
+// the columns are combined with OR, otherwise every filter must match (AND).
+// Filter keys must be columns of the table (keys of columnTypes).
+func buildFilterClause(columnTypes map[string]string, filters map[string]string) (string, []interface{}, error) {
+	// Iterate in a stable order so the generated SQL is deterministic
+	keys := make([]string, 0, len(filters))
+	for key, value := range filters {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	// Check if all filter values are the same (general search)
+	generalSearch := len(keys) > 1
+	for _, key := range keys {
+		if filters[key] != filters[keys[0]] {
+			generalSearch = false
+			break
+		}
+	}
+
+	whereClause := "1=1"
+	args := []interface{}{}
+	conditions := []string{}
+	for i, key := range keys {
+		column, err := filterColumn(postgresDialect{}, columnTypes, key, true)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, fmt.Sprintf("%s ILIKE $%d", column, i+1))
+		args = append(args, "%"+filters[key]+"%")
+	}
+
+	if len(conditions) == 0 {
+		return whereClause, args, nil
+	}
+	if generalSearch {
+		return whereClause + " AND (" + strings.Join(conditions, " OR ") + ")", args, nil
+	}
+	return whereClause + " AND " + strings.Join(conditions, " AND "), args, nil
+}
+
+// buildFilterExpression builds the SQL condition of a filter expression in the syntax of a
+// dialect, with its values as parameters numbered from start. Fields must be columns of the
+// table (keys of columnTypes) and are quoted; values are never part of the SQL text.
+func buildFilterExpression(d dialect, columnTypes map[string]string, expr *models.FilterExpression, start int) (string, []interface{}, error) {
+	b := &filterExpressionBuilder{d: d, columnTypes: columnTypes, next: start, args: []interface{}{}}
+	condition, err := b.build(expr, 1)
+	if err != nil {
+		return "", nil, err
+	}
+	return condition, b.args, nil
+}
+
+// filterExpressionBuilder collects the parameters of a filter expression while building its SQL
+type filterExpressionBuilder struct {
+	d           dialect
+	columnTypes map[string]string
+	next        int
+	args        []interface{}
+	conditions  int
+}
+
+// param adds a parameter and returns its placeholder
+func (b *filterExpressionBuilder) param(value interface{}) string {
+	b.args = append(b.args, value)
+	b.next++
+	return b.d.placeholder(b.next - 1)
+}
+
+// build builds one expression; depth counts the groups it is nested in
+func (b *filterExpressionBuilder) build(expr *models.FilterExpression, depth int) (string, error) {
+	if depth > maxFilterDepth {
+		return "", fmt.Errorf("%w: groups are nested more than %d levels deep", ErrInvalidFilter, maxFilterDepth)
+	}
+
+	isGroup := expr.And != nil || expr.Or != nil
+	if isGroup {
+		if expr.Field != "" || expr.Op != "" || (expr.And != nil && expr.Or != nil) {
+			return "", fmt.Errorf("%w: a group has either \"and\" or \"or\" and no field", ErrInvalidFilter)
+		}
+		children, joiner := expr.And, " AND "
+		if expr.Or != nil {
+			children, joiner = expr.Or, " OR "
+		}
+		if len(children) == 0 {
+			return "", fmt.Errorf("%w: empty group", ErrInvalidFilter)
+		}
+		conditions := make([]string, 0, len(children))
+		for i := range children {
+			condition, err := b.build(&children[i], depth+1)
+			if err != nil {
+				return "", err
+			}
+			conditions = append(conditions, condition)
+		}
+		return "(" + strings.Join(conditions, joiner) + ")", nil
+	}
+
+	b.conditions++
+	if b.conditions > maxFilterConditions {
+		return "", fmt.Errorf("%w: more than %d conditions", ErrInvalidFilter, maxFilterConditions)
+	}
+
+	switch expr.Op {
+	case "eq", "ne", "lt", "lte", "gt", "gte":
+		column, err := filterColumn(b.d, b.columnTypes, expr.Field, false)
+		if err != nil {
+			return "", err
+		}
+		value, err := filterValue(expr.Field, expr.Value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s %s", column, filterComparisons[expr.Op], b.param(value)), nil
+
+	case "contains", "starts_with", "ends_with":
+		column, err := filterColumn(b.d, b.columnTypes, expr.Field, true)
+		if err != nil {
+			return "", err
+		}
+		value, err := filterValue(expr.Field, expr.Value)
+		if err != nil {
+			return "", err
+		}
+		pattern := escapeLikePattern(value)
+		switch expr.Op {
+		case "contains":
+			pattern = "%" + pattern + "%"
+		case "starts_with":
+			pattern = pattern + "%"
+		default:
+			pattern = "%" + pattern
+		}
+		return fmt.Sprintf("%s %s %s", column, b.d.likeOperator(), b.param(pattern)), nil
+
+	case "in", "not_in":
+		column, err := filterColumn(b.d, b.columnTypes, expr.Field, false)
+		if err != nil {
+			return "", err
+		}
+		values, ok := expr.Value.([]interface{})
+		if !ok || len(values) == 0 || len(values) > maxFilterValues {
+			return "", fmt.Errorf("%w: %s on %q needs an array of 1 to %d values", ErrInvalidFilter, expr.Op, expr.Field, maxFilterValues)
+		}
+		placeholders := make([]string, len(values))
+		for i, raw := range values {
+			value, err := filterValue(expr.Field, raw)
+			if err != nil {
+				return "", err
+			}
+			placeholders[i] = b.param(value)
+		}
+		operator := "IN"
+		if expr.Op == "not_in" {
+			operator = "NOT IN"
+		}
+		return fmt.Sprintf("%s %s (%s)", column, operator, strings.Join(placeholders, ", ")), nil
+
+	case "is_null", "is_not_null":
+		column, err := filterColumn(b.d, b.columnTypes, expr.Field, false)
+		if err != nil {
+			return "", err
+		}
+		if expr.Op == "is_null" {
+			return column + " IS NULL", nil
+		}
+		return column + " IS NOT NULL", nil
+
+	case "":
+		return "", fmt.Errorf("%w: a condition needs a field and an op", ErrInvalidFilter)
+	default:
+		return "", fmt.Errorf("%w: unknown operator %q", ErrInvalidFilter, expr.Op)
+	}
+}
+
+// filterValue converts a scalar JSON value of a condition to its text form; the database
+// converts it to the type of the column
+func filterValue(field string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("%w: the value for %q must be a string, number or boolean", ErrInvalidFilter, field)
+	}
+}
+
+// buildOrderBy builds the ORDER BY list for a sort column, which must be one of columns;
+// an empty sortBy sorts by the first column
+func buildOrderBy(columns []string, sortBy, sortOrder string) (string, error) {
+	if sortOrder != "ASC" && sortOrder != "DESC" {
+		sortOrder = "ASC"
+	}
+	if len(columns) == 0 {
+		return "", fmt.Errorf("%w: the table has no columns to sort by", ErrInvalidFilter)
+	}
+	if sortBy == "" {
+		sortBy = columns[0]
+	} else if match, _, ok := matchName(columns, sortBy); ok {
+		sortBy = match
+	} else {
+		return "", fmt.Errorf("%w: cannot sort by unknown column %q", ErrInvalidFilter, sortBy)
+	}
+	return quotePostgresName(sortBy) + " " + sortOrder, nil
+}
+
+// quoteIdentifiers quotes each identifier and joins them into a column list
+func quoteIdentifiers(identifiers []string) string {
+	quoted := make([]string, len(identifiers))
+	for i, identifier := range identifiers {
+		quoted[i] = quotePostgresName(identifier)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// tableRowsQuery is the SQL of one page of a table browse
+type tableRowsQuery struct {
+	columns    []string // Selected columns
+	primaryKey []string
+	orderBy    []string // Sort column and primary key; a keyset cursor holds their values
+	direction  string
+	query      string // Reads one row past the page
+	args       []any
+	countQuery string // Counts the rows matching the filter; empty for keyset pages
+	countArgs  []any
+}
+
+// buildTableRowsQuery builds the query of a page of a table with the given columns. after holds
+// the orderBy values of the last row of the previous keyset page.
+func buildTableRowsQuery(d dialect, schemaName, tableName string, tableColumns []*models.Column, req *models.TableRowsRequest, after []any, limit int) (*tableRowsQuery, error) {
+	columnTypes := map[string]string{}
+	nullable := map[string]bool{}
+	q := &tableRowsQuery{columns: []string{}, primaryKey: []string{}, direction: "ASC"}
+	for _, column := range tableColumns {
+		columnTypes[column.Name] = columnBaseType(column.Type)
+		nullable[column.Name] = column.Nullable
+		if column.Key == "PRI" {
+			q.primaryKey = append(q.primaryKey, column.Name)
+		}
+	}
+	if strings.ToUpper(req.SortOrder) == "DESC" {
+		q.direction = "DESC"
+	}
+
+	for _, requested := range req.Columns {
+		column, ok := matchColumn(columnTypes, requested)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidFilter, requested)
+		}
+		if !slices.Contains(q.columns, column) {
+			q.columns = append(q.columns, column)
+		}
+	}
+	if len(q.columns) == 0 {
+		for _, column := range tableColumns {
+			q.columns = append(q.columns, column.Name)
+		}
+	}
+
+	sortBy := ""
+	if req.SortBy != "" {
+		var ok bool
+		if sortBy, ok = matchColumn(columnTypes, req.SortBy); !ok {
+			return nil, fmt.Errorf("%w: cannot sort by unknown column %q", ErrInvalidFilter, req.SortBy)
+		}
+		q.orderBy = append(q.orderBy, sortBy)
+	}
+	for _, column := range q.primaryKey {
+		if column != sortBy {
+			q.orderBy = append(q.orderBy, column)
+		}
+	}
+
+	keyset := req.Keyset || after != nil
+	if keyset {
+		if len(q.primaryKey) == 0 {
+			return nil, fmt.Errorf("%w: keyset pagination needs a table with a primary key", ErrInvalidFilter)
+		}
+		// Rows with NULL in the sort column would never compare after the cursor
+		if sortBy != "" && nullable[sortBy] {
+			return nil, fmt.Errorf("%w: keyset pagination cannot sort by %q, which allows NULL", ErrInvalidFilter, sortBy)
+		}
+		if after != nil && len(after) != len(q.orderBy) {
+			return nil, ErrInvalidQueryCursor
+		}
+		for _, column := range q.orderBy {
+			if !slices.Contains(q.columns, column) {
+				q.columns = append(q.columns, column)
+			}
+		}
+	} else if len(q.orderBy) == 0 {
+		q.orderBy = []string{tableColumns[0].Name}
+	}
+
+	quoteEach := func(columns []string, suffix string) []string {
+		quoted := make([]string, len(columns))
+		for i, column := range columns {
+			quoted[i] = quoteName(d, column) + suffix
+		}
+		return quoted
+	}
+	quote := func(columns []string, suffix string) string {
+		return strings.Join(quoteEach(columns, suffix), ", ")
+	}
+	from := qualifiedName(d, schemaName, tableName)
+
+	conditions := []string{}
+	if req.Filter != nil {
+		condition, filterArgs, err := buildFilterExpression(d, columnTypes, req.Filter, 1)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+		q.args = append(q.args, filterArgs...)
+	}
+	if !keyset {
+		q.countQuery = "SELECT COUNT(*) FROM " + from
+		if len(conditions) > 0 {
+			q.countQuery += " WHERE " + conditions[0]
+		}
+		q.countArgs = append([]any{}, q.args...)
+	}
+	if len(after) > 0 {
+		// Every column is sorted in the same direction, so the row comparison continues the order
+		placeholders := make([]string, len(after))
+		for i, value := range after {
+			q.args = append(q.args, value)
+			placeholders[i] = d.placeholder(len(q.args))
+		}
+		operator := ">"
+		if q.direction == "DESC" {
+			operator = "<"
+		}
+		condition, err := d.keysetCondition(quoteEach(q.orderBy, ""), operator, placeholders)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	q.query = fmt.Sprintf("SELECT %s FROM %s", quote(q.columns, ""), from)
+	if len(conditions) > 0 {
+		q.query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	offset := req.Offset
+	if keyset {
+		offset = 0
+	}
+	q.query += " ORDER BY " + quote(q.orderBy, " "+q.direction) + d.pageClause(limit+1, offset, true)
+	return q, nil
+}
+
+// columnBaseType returns the data type of a full column type such as varchar(255) or int(11) unsigned
+func columnBaseType(fullType string) string {
+	base, _, _ := strings.Cut(strings.ToLower(fullType), "(")
+	return strings.TrimSpace(base)
+}
+
+// buildGrantSQL builds the GRANT statement for a privilege request. routine is the resolved
+// signature of a function or procedure and empty for other objects.
+func buildGrantSQL(req *models.GrantRequest, roleName, routine string) (string, error) {
+	return buildPrivilegeSQL("GRANT", "TO", req, roleName, routine)
+}
+
+// buildRevokeSQL builds the REVOKE statement for a privilege request
+func buildRevokeSQL(req *models.GrantRequest, roleName, routine string) (string, error) {
+	return buildPrivilegeSQL("REVOKE", "FROM", req, roleName, routine)
+}
+
+// buildPrivilegeSQL builds "<verb> <privileges> ON <object> <preposition> <role>"
+func buildPrivilegeSQL(verb, preposition string, req *models.GrantRequest, roleName, routine string) (string, error) {
+	d := postgresDialect{}
+	privileges := strings.Join(req.Privileges, ", ")
+
+	var object string
+	switch req.ObjectType {
+	case "table", "view":
+		object = "TABLE " + qualifiedName(d, req.ObjectSchema, req.ObjectName)
+	case "schema":
+		object = "SCHEMA " + quoteName(d, req.ObjectName)
+	case "database":
+		object = "DATABASE " + quoteName(d, req.ObjectName)
+	case "function", "procedure":
+		if routine == "" {
+			return "", fmt.Errorf("function %s is not resolved", req.ObjectName)
+		}
+		object = "FUNCTION " + routine
+	default:
+		return "", fmt.Errorf("unsupported object type: %s", req.ObjectType)
+	}
+
+	return fmt.Sprintf("%s %s ON %s %s %s", verb, privileges, object, preposition, quoteName(d, roleName)), nil
+}
+
+// roleAttributes represents the current attributes of a role compared by buildAlterRoleSQL
+type roleAttributes struct {
+	Login           bool
+	Superuser       bool
+	CreateDB        bool
+	CreateRole      bool
+	Inherit         bool
+	Replication     bool
+	BypassRLS       bool
+	ConnectionLimit int
+}
+
+// buildAlterRoleSQL builds the ALTER ROLE statement applying changes to a role. It returns an
+// empty statement when the role already has every requested attribute.
+func buildAlterRoleSQL(roleName string, current roleAttributes, changes *models.RoleAttributeChanges) (string, error) {
+	options := []string{}
+	flag := func(change *bool, currentValue bool, keyword string) {
+		if change == nil || *change == currentValue {
+			return
+		}
+		if *change {
+			options = append(options, keyword)
+		} else {
+			options = append(options, "NO"+keyword)
+		}
+	}
+	flag(changes.Superuser, current.Superuser, "SUPERUSER")
+	flag(changes.CreateDB, current.CreateDB, "CREATEDB")
+	flag(changes.CreateRole, current.CreateRole, "CREATEROLE")
+	flag(changes.Inherit, current.Inherit, "INHERIT")
+	flag(changes.Login, current.Login, "LOGIN")
+	flag(changes.Replication, current.Replication, "REPLICATION")
+	flag(changes.BypassRLS, current.BypassRLS, "BYPASSRLS")
+
+	if changes.ConnectionLimit != nil && *changes.ConnectionLimit != current.ConnectionLimit {
+		if *changes.ConnectionLimit < -1 {
+			return "", fmt.Errorf("connection_limit must be -1 (unlimited) or more")
+		}
+		options = append(options, fmt.Sprintf("CONNECTION LIMIT %d", *changes.ConnectionLimit))
+	}
+
+	if changes.ValidUntil != nil {
+		validUntil := strings.TrimSpace(*changes.ValidUntil)
+		if validUntil != "infinity" {
+			t, err := time.Parse(time.RFC3339, validUntil)
+			if err != nil {
+				return "", fmt.Errorf("valid_until must be an RFC3339 time or infinity")
+			}
+			validUntil = t.UTC().Format("2006-01-02 15:04:05+00")
+		}
+		options = append(options, fmt.Sprintf("VALID UNTIL '%s'", validUntil))
+	}
+
+	if len(options) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("ALTER ROLE %s WITH %s", quotePostgresName(roleName), strings.Join(options, " ")), nil
+}
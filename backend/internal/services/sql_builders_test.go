@@ -0,0 +1,266 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"truadmin/internal/models"
+)
+
+func TestBuildFilterClause(t *testing.T) {
+	columnTypes := map[string]string{
+		"name":    "text",
+		"city":    "character varying",
+		"zipcode": "integer",
+	}
+
+	tests := []struct {
+		name      string
+		filters   map[string]string
+		wantWhere string
+		wantArgs  []interface{}
+		wantErr   error
+	}{
+		{
+			name:      "no filters",
+			filters:   map[string]string{},
+			wantWhere: "1=1",
+			wantArgs:  []interface{}{},
+		},
+		{
+			name:      "empty values are ignored",
+			filters:   map[string]string{"name": "", "city": ""},
+			wantWhere: "1=1",
+			wantArgs:  []interface{}{},
+		},
+		{
+			name:      "single filter",
+			filters:   map[string]string{"name": "ann"},
+			wantWhere: `1=1 AND "name" ILIKE $1`,
+			wantArgs:  []interface{}{"%ann%"},
+		},
+		{
+			name:      "different values must all match",
+			filters:   map[string]string{"name": "ann", "city": "oslo"},
+			wantWhere: `1=1 AND "city" ILIKE $1 AND "name" ILIKE $2`,
+			wantArgs:  []interface{}{"%oslo%", "%ann%"},
+		},
+		{
+			name:      "same value is a general search",
+			filters:   map[string]string{"name": "42", "city": "42", "zipcode": "42"},
+			wantWhere: `1=1 AND ("city" ILIKE $1 OR "name" ILIKE $2 OR CAST("zipcode" AS TEXT) ILIKE $3)`,
+			wantArgs:  []interface{}{"%42%", "%42%", "%42%"},
+		},
+		{
+			name:    "unknown column",
+			filters: map[string]string{"name; DROP TABLE x": "a"},
+			wantErr: ErrInvalidFilter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args, err := buildFilterClause(columnTypes, tt.filters)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("buildFilterClause() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildFilterClause() error = %v", err)
+			}
+			if where != tt.wantWhere {
+				t.Errorf("buildFilterClause() where = %q, want %q", where, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("buildFilterClause() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildGrantAndRevokeSQL(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        models.GrantRequest
+		routine    string
+		wantGrant  string
+		wantRevoke string
+		wantErr    bool
+	}{
+		{
+			name:       "table",
+			req:        models.GrantRequest{ObjectType: "table", ObjectSchema: "public", ObjectName: "orders", Privileges: []string{"SELECT", "UPDATE"}},
+			wantGrant:  `GRANT SELECT, UPDATE ON TABLE "public"."orders" TO "reporting"`,
+			wantRevoke: `REVOKE SELECT, UPDATE ON TABLE "public"."orders" FROM "reporting"`,
+		},
+		{
+			name:       "view without schema",
+			req:        models.GrantRequest{ObjectType: "view", ObjectName: "v", Privileges: []string{"SELECT"}},
+			wantGrant:  `GRANT SELECT ON TABLE "v" TO "reporting"`,
+			wantRevoke: `REVOKE SELECT ON TABLE "v" FROM "reporting"`,
+		},
+		{
+			name:       "schema",
+			req:        models.GrantRequest{ObjectType: "schema", ObjectName: "sales", Privileges: []string{"USAGE"}},
+			wantGrant:  `GRANT USAGE ON SCHEMA "sales" TO "reporting"`,
+			wantRevoke: `REVOKE USAGE ON SCHEMA "sales" FROM "reporting"`,
+		},
+		{
+			name:       "database",
+			req:        models.GrantRequest{ObjectType: "database", ObjectName: "app", Privileges: []string{"CONNECT"}},
+			wantGrant:  `GRANT CONNECT ON DATABASE "app" TO "reporting"`,
+			wantRevoke: `REVOKE CONNECT ON DATABASE "app" FROM "reporting"`,
+		},
+		{
+			name:       "quotes in names are escaped",
+			req:        models.GrantRequest{ObjectType: "table", ObjectSchema: "public", ObjectName: `a"b`, Privileges: []string{"SELECT"}},
+			wantGrant:  `GRANT SELECT ON TABLE "public"."a""b" TO "reporting"`,
+			wantRevoke: `REVOKE SELECT ON TABLE "public"."a""b" FROM "reporting"`,
+		},
+		{
+			name:       "resolved function",
+			req:        models.GrantRequest{ObjectType: "function", ObjectSchema: "public", ObjectName: "f", Privileges: []string{"EXECUTE"}},
+			routine:    "public.f(integer)",
+			wantGrant:  `GRANT EXECUTE ON FUNCTION public.f(integer) TO "reporting"`,
+			wantRevoke: `REVOKE EXECUTE ON FUNCTION public.f(integer) FROM "reporting"`,
+		},
+		{
+			name:    "unresolved function",
+			req:     models.GrantRequest{ObjectType: "function", ObjectName: "f", Privileges: []string{"EXECUTE"}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported object type",
+			req:     models.GrantRequest{ObjectType: "sequence", ObjectName: "s", Privileges: []string{"USAGE"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grant, err := buildGrantSQL(&tt.req, "reporting", tt.routine)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildGrantSQL() error = %v, want error %v", err, tt.wantErr)
+			}
+			revoke, err := buildRevokeSQL(&tt.req, "reporting", tt.routine)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildRevokeSQL() error = %v, want error %v", err, tt.wantErr)
+			}
+			if grant != tt.wantGrant {
+				t.Errorf("buildGrantSQL() = %q, want %q", grant, tt.wantGrant)
+			}
+			if revoke != tt.wantRevoke {
+				t.Errorf("buildRevokeSQL() = %q, want %q", revoke, tt.wantRevoke)
+			}
+		})
+	}
+}
+
+func TestBuildOrderBy(t *testing.T) {
+	columns := []string{"id", "Zip Code"}
+
+	tests := []struct {
+		name      string
+		columns   []string
+		sortBy    string
+		sortOrder string
+		want      string
+		wantErr   error
+	}{
+		{name: "defaults to the first column", columns: columns, want: `"id" ASC`},
+		{name: "matches a column", columns: columns, sortBy: "Zip Code", sortOrder: "DESC", want: `"Zip Code" DESC`},
+		{name: "invalid order", columns: columns, sortBy: "id", sortOrder: "sideways", want: `"id" ASC`},
+		{name: "unknown column", columns: columns, sortBy: "missing", wantErr: ErrInvalidFilter},
+		{name: "no columns", columns: nil, wantErr: ErrInvalidFilter},
+		{name: "no columns with a sort column", columns: []string{}, sortBy: "id", wantErr: ErrInvalidFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderBy, err := buildOrderBy(tt.columns, tt.sortBy, tt.sortOrder)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("buildOrderBy() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildOrderBy() error = %v", err)
+			}
+			if orderBy != tt.want {
+				t.Errorf("buildOrderBy() = %q, want %q", orderBy, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTableRowsQueryDialects(t *testing.T) {
+	columns := []*models.Column{
+		{Name: "id", Type: "int", Key: "PRI"},
+		{Name: "name", Type: "varchar(50)"},
+	}
+	keysetPage := &models.TableRowsRequest{SortBy: "name", Keyset: true}
+	offsetPage := &models.TableRowsRequest{Offset: 20}
+
+	tests := []struct {
+		name    string
+		d       dialect
+		req     *models.TableRowsRequest
+		after   []any
+		want    string
+		wantErr error
+	}{
+		{name: "postgres keyset", d: postgresDialect{}, req: keysetPage, after: []any{"b", 7},
+			want: `SELECT "id", "name" FROM "dbo"."items" WHERE ("name", "id") > ($1, $2) ORDER BY "name" ASC, "id" ASC LIMIT 11`},
+		{name: "postgres offset", d: postgresDialect{}, req: offsetPage,
+			want: `SELECT "id", "name" FROM "dbo"."items" ORDER BY "id" ASC LIMIT 11 OFFSET 20`},
+		{name: "mssql keyset", d: mssqlDialect{}, req: keysetPage, after: []any{"b", 7},
+			want: `SELECT [id], [name] FROM [dbo].[items] WHERE (([name] > @p1) OR ([name] = @p1 AND [id] > @p2)) ORDER BY [name] ASC, [id] ASC OFFSET 0 ROWS FETCH NEXT 11 ROWS ONLY`},
+		{name: "mssql offset", d: mssqlDialect{}, req: offsetPage,
+			want: `SELECT [id], [name] FROM [dbo].[items] ORDER BY [id] ASC OFFSET 20 ROWS FETCH NEXT 11 ROWS ONLY`},
+		{name: "snowflake keyset over several columns", d: snowflakeDialect{}, req: keysetPage, after: []any{"b", 7}, wantErr: ErrUnsupportedDialect},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := buildTableRowsQuery(tt.d, "dbo", "items", columns, tt.req, tt.after, 10)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("buildTableRowsQuery() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildTableRowsQuery() error = %v", err)
+			}
+			if q.query != tt.want {
+				t.Errorf("buildTableRowsQuery() = %q, want %q", q.query, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPagedQueryDialects(t *testing.T) {
+	cursor := &queryCursor{Offset: 50}
+
+	stmt, _, err := buildPagedQuery(mssqlDialect{}, "SELECT name FROM items", nil, cursor, 25)
+	if err != nil {
+		t.Fatalf("buildPagedQuery() error = %v", err)
+	}
+	want := "SELECT * FROM (\nSELECT name FROM items\n) AS truadmin_page ORDER BY (SELECT NULL) OFFSET 50 ROWS FETCH NEXT 26 ROWS ONLY"
+	if stmt != want {
+		t.Errorf("buildPagedQuery() = %q, want %q", stmt, want)
+	}
+
+	stmt, _, err = buildPagedQuery(postgresDialect{}, "SELECT name FROM items", nil, cursor, 25)
+	if err != nil {
+		t.Fatalf("buildPagedQuery() error = %v", err)
+	}
+	want = "SELECT * FROM (\nSELECT name FROM items\n) AS truadmin_page LIMIT 26 OFFSET 50"
+	if stmt != want {
+		t.Errorf("buildPagedQuery() = %q, want %q", stmt, want)
+	}
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/snowflakedb/gosnowflake"
+
+	"truadmin/internal/models"
+)
+
+// Drivers used for managed databases; they pass everything to the real driver and record the
+// statements run with a context that carries a SQL capture
+const (
+	capturedPostgresDriver  = "postgres-captured"
+	capturedMySQLDriver     = "mysql-captured"
+	capturedMSSQLDriver     = "mssql-captured"
+	capturedSnowflakeDriver = "snowflake-captured"
+)
+
+// sqlCaptureMaxStatements caps the statements kept per capture
+const sqlCaptureMaxStatements = 1000
+
+// sqlCaptureMaxArgLength caps the length of each recorded argument
+const sqlCaptureMaxArgLength = 200
+
+func init() {
+	sql.Register(capturedPostgresDriver, &capturingDriver{parent: &pq.Driver{}})
+	sql.Register(capturedMySQLDriver, &capturingDriver{parent: &mysql.MySQLDriver{}})
+	sql.Register(capturedMSSQLDriver, &capturingDriver{parent: &mssql.Driver{}})
+	sql.Register(capturedSnowflakeDriver, &capturingDriver{parent: &gosnowflake.SnowflakeDriver{}})
+}
+
+// sqlCaptureKey is the context key of the running SQL capture
+type sqlCaptureKey struct{}
+
+// SQLCapture collects the statements sent to managed databases with the context it was started on.
+// Service calls that do not pass the request context on to database/sql are not captured.
+type SQLCapture struct {
+	mu         sync.Mutex
+	stopped    bool
+	statements []models.CapturedSQLStatement
+	count      int
+	total      time.Duration
+}
+
+// StartSQLCapture starts capturing the SQL run with the returned context until Stop is called
+func StartSQLCapture(ctx context.Context) (context.Context, *SQLCapture) {
+	capture := &SQLCapture{statements: []models.CapturedSQLStatement{}}
+	return context.WithValue(ctx, sqlCaptureKey{}, capture), capture
+}
+
+// Stop ends the capture and returns what was captured
+func (c *SQLCapture) Stop() *models.SQLDebugInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	return &models.SQLDebugInfo{
+		Statements:      c.statements,
+		StatementCount:  c.count,
+		TotalDurationMs: float64(c.total.Microseconds()) / 1000,
+		Truncated:       c.count > len(c.statements),
+	}
+}
+
+// sqlCaptureFrom returns the capture carried by a context, if any
+func sqlCaptureFrom(ctx context.Context) *SQLCapture {
+	capture, _ := ctx.Value(sqlCaptureKey{}).(*SQLCapture)
+	return capture
+}
+
+// recordSQL adds a statement to a capture; a nil capture records nothing
+func (c *SQLCapture) recordSQL(statement string, args []driver.NamedValue, started time.Time, err error) {
+	if c == nil {
+		return
+	}
+	duration := time.Since(started)
+
+	entry := models.CapturedSQLStatement{
+		Statement:  statement,
+		StartedAt:  started.UTC(),
+		DurationMs: float64(duration.Microseconds()) / 1000,
+	}
+	for _, arg := range args {
+		value := fmt.Sprint(arg.Value)
+		if b, ok := arg.Value.([]byte); ok {
+			value = string(b)
+		}
+		if len(value) > sqlCaptureMaxArgLength {
+			value = value[:sqlCaptureMaxArgLength] + "..."
+		}
+		entry.Args = append(entry.Args, value)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		return
+	}
+	c.count++
+	c.total += duration
+	if len(c.statements) < sqlCaptureMaxStatements {
+		c.statements = append(c.statements, entry)
+	}
+}
+
+// capturingConnector opens connections of a driver that is not registered through capturingDriver
+type capturingConnector struct {
+	dsn    string
+	driver *capturingDriver
+}
+
+func (c capturingConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c capturingConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// OpenCapturedDB opens a database on a driver that is not registered, recording the statements run
+// with a context that carries a SQL capture as the drivers of managed databases do
+func OpenCapturedDB(parent driver.Driver, dsn string) *sql.DB {
+	return sql.OpenDB(capturingConnector{dsn: dsn, driver: &capturingDriver{parent: parent}})
+}
+
+// capturingDriver wraps a database driver and records the statements run through it
+type capturingDriver struct {
+	parent driver.Driver
+}
+
+// Open opens a connection of the wrapped driver
+func (d *capturingDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.parent.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &capturingConn{parent: conn}, nil
+}
+
+// capturingConn records the statements run on a connection. Optional driver interfaces are
+// passed on when the wrapped connection has them and fall back to the database/sql default
+// otherwise.
+type capturingConn struct {
+	parent driver.Conn
+}
+
+func (c *capturingConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.parent.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &capturingStmt{parent: stmt, query: query}, nil
+}
+
+func (c *capturingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	preparer, ok := c.parent.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+	stmt, err := preparer.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &capturingStmt{parent: stmt, query: query}, nil
+}
+
+func (c *capturingConn) Close() error {
+	return c.parent.Close()
+}
+
+func (c *capturingConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *capturingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	started := time.Now()
+	var tx driver.Tx
+	var err error
+	if beginner, ok := c.parent.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.parent.Begin()
+	}
+	capture := sqlCaptureFrom(ctx)
+	capture.recordSQL("BEGIN", nil, started, err)
+	if err != nil {
+		return nil, err
+	}
+	return &capturingTx{parent: tx, capture: capture}, nil
+}
+
+func (c *capturingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.parent.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	started := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		sqlCaptureFrom(ctx).recordSQL(query, args, started, err)
+	}
+	return rows, err
+}
+
+func (c *capturingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.parent.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	started := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		sqlCaptureFrom(ctx).recordSQL(query, args, started, err)
+	}
+	return result, err
+}
+
+func (c *capturingConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.parent.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *capturingConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.parent.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *capturingConn) IsValid() bool {
+	if validator, ok := c.parent.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *capturingConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.parent.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// capturingStmt records the executions of a prepared statement
+type capturingStmt struct {
+	parent driver.Stmt
+	query  string
+}
+
+func (s *capturingStmt) Close() error {
+	return s.parent.Close()
+}
+
+func (s *capturingStmt) NumInput() int {
+	return s.parent.NumInput()
+}
+
+// Exec and Query are only called by database/sql when the context variants are missing, which
+// capturingStmt always has
+func (s *capturingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.parent.Exec(args)
+}
+
+func (s *capturingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.parent.Query(args)
+}
+
+func (s *capturingStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	started := time.Now()
+	var result driver.Result
+	var err error
+	if execer, ok := s.parent.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.parent.Exec(plainValues(args))
+	}
+	sqlCaptureFrom(ctx).recordSQL(s.query, args, started, err)
+	return result, err
+}
+
+func (s *capturingStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	started := time.Now()
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.parent.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.parent.Query(plainValues(args))
+	}
+	sqlCaptureFrom(ctx).recordSQL(s.query, args, started, err)
+	return rows, err
+}
+
+func (s *capturingStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.parent.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (s *capturingStmt) ColumnConverter(idx int) driver.ValueConverter {
+	if converter, ok := s.parent.(driver.ColumnConverter); ok {
+		return converter.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+// capturingTx records the end of a transaction in the capture that was running when it began
+type capturingTx struct {
+	parent  driver.Tx
+	capture *SQLCapture
+}
+
+func (t *capturingTx) Commit() error {
+	started := time.Now()
+	err := t.parent.Commit()
+	t.capture.recordSQL("COMMIT", nil, started, err)
+	return err
+}
+
+func (t *capturingTx) Rollback() error {
+	started := time.Now()
+	err := t.parent.Rollback()
+	t.capture.recordSQL("ROLLBACK", nil, started, err)
+	return err
+}
+
+// plainValues converts named values to positional values
+func plainValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
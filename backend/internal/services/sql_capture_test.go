@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func newCapturedMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	mockDB, mock, err := sqlmock.NewWithDSN(t.Name(), sqlmock.MonitorPingsOption(false))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	db := OpenCapturedDB(mockDB.Driver(), t.Name())
+	t.Cleanup(func() {
+		db.Close()
+		mockDB.Close()
+	})
+	return db, mock
+}
+
+func TestSQLCaptureFollowsContext(t *testing.T) {
+	db, mock := newCapturedMockDB(t)
+	mock.ExpectExec("UPDATE accounts").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM sessions").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO audit").WithArgs("x").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	ctx, capture := StartSQLCapture(context.Background())
+
+	// Statements run with the request context are captured, even from another goroutine
+	done := make(chan error)
+	go func() {
+		_, err := db.ExecContext(ctx, "UPDATE accounts SET active = true")
+		done <- err
+	}()
+	if err := <-done; err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+	if _, err := db.ExecContext(context.Background(), "DELETE FROM sessions"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO audit VALUES ($1)", "x"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	info := capture.Stop()
+	want := []string{"UPDATE accounts SET active = true", "BEGIN", "INSERT INTO audit VALUES ($1)", "COMMIT"}
+	if info.StatementCount != len(want) || len(info.Statements) != len(want) {
+		t.Fatalf("captured %d statements, want %d: %+v", info.StatementCount, len(want), info.Statements)
+	}
+	for i, statement := range info.Statements {
+		if statement.Statement != want[i] {
+			t.Errorf("statement %d = %q, want %q", i, statement.Statement, want[i])
+		}
+	}
+	if got := info.Statements[2].Args; len(got) != 1 || got[0] != "x" {
+		t.Errorf("statement args = %v, want [x]", got)
+	}
+}
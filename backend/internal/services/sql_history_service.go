@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// SQLHistoryService keeps each user's personal history of the queries they ran
+type SQLHistoryService struct {
+	db    *gorm.DB
+	limit int // Unstarred entries kept per user; zero keeps every entry
+}
+
+// NewSQLHistoryService creates a new SQL history service
+func NewSQLHistoryService(limit int) *SQLHistoryService {
+	return &SQLHistoryService{
+		db:    database.GetDB(),
+		limit: limit,
+	}
+}
+
+// Record adds a query to its user's history and drops the user's oldest unstarred entries beyond
+// the limit. Failures are logged rather than returned, so they never fail the query itself.
+func (s *SQLHistoryService) Record(entry *models.SQLHistoryEntry) {
+	if s == nil || entry.UserID == "" || strings.TrimSpace(entry.Query) == "" {
+		return
+	}
+	entry.ID = uuid.New().String()
+
+	if err := s.db.Create(entry).Error; err != nil {
+		slog.Error("failed to record sql history", "user_id", entry.UserID, "connection_id", entry.ConnectionID, "error", err)
+		return
+	}
+	if err := s.trim(entry.UserID); err != nil {
+		slog.Error("failed to trim sql history", "user_id", entry.UserID, "error", err)
+	}
+}
+
+// trim deletes the unstarred entries of a user older than the newest limit ones
+func (s *SQLHistoryService) trim(userID string) error {
+	if s.limit <= 0 {
+		return nil
+	}
+
+	var oldest []time.Time
+	if err := s.db.Model(&models.SQLHistoryEntry{}).
+		Where("user_id = ? AND starred = ?", userID, false).
+		Order("created_at DESC").
+		Offset(s.limit-1).Limit(1).
+		Pluck("created_at", &oldest).Error; err != nil {
+		return err
+	}
+	if len(oldest) == 0 {
+		return nil
+	}
+
+	return s.db.Where("user_id = ? AND starred = ? AND created_at < ?", userID, false, oldest[0]).
+		Delete(&models.SQLHistoryEntry{}).Error
+}
+
+// GetHistory returns a user's history matching a filter, newest first
+func (s *SQLHistoryService) GetHistory(userID string, filter models.SQLHistoryFilter, page Page) ([]models.SQLHistoryEntry, int64, error) {
+	var entries []models.SQLHistoryEntry
+
+	query := s.db.Where("user_id = ?", userID)
+	if filter.Search != "" {
+		query = query.Where("query ILIKE ?", "%"+escapeLikePattern(filter.Search)+"%")
+	}
+	if filter.ConnectionID != "" {
+		query = query.Where("connection_id = ?", filter.ConnectionID)
+	}
+	if filter.StarredOnly {
+		query = query.Where("starred = ?", true)
+	}
+
+	total, err := findPage(query.Order("created_at DESC"), page, &entries)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get sql history: %w", err)
+	}
+	return models.NonNil(entries), total, nil
+}
+
+// GetEntry returns an entry of a user's history
+func (s *SQLHistoryService) GetEntry(userID, id string) (*models.SQLHistoryEntry, error) {
+	var entry models.SQLHistoryEntry
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("history entry not found")
+		}
+		return nil, fmt.Errorf("failed to get history entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// SetStarred stars or unstars an entry of a user's history
+func (s *SQLHistoryService) SetStarred(userID, id string, starred bool) (*models.SQLHistoryEntry, error) {
+	entry, err := s.GetEntry(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(entry).Update("starred", starred).Error; err != nil {
+		return nil, fmt.Errorf("failed to update history entry: %w", err)
+	}
+	return entry, nil
+}
+
+// DeleteEntry removes an entry from a user's history
+func (s *SQLHistoryService) DeleteEntry(userID, id string) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.SQLHistoryEntry{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete history entry: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("history entry not found")
+	}
+	return nil
+}
+
+// ClearHistory removes the unstarred entries of a user's history
+func (s *SQLHistoryService) ClearHistory(userID string) error {
+	if err := s.db.Where("user_id = ? AND starred = ?", userID, false).Delete(&models.SQLHistoryEntry{}).Error; err != nil {
+		return fmt.Errorf("failed to clear sql history: %w", err)
+	}
+	return nil
+}
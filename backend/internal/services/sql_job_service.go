@@ -0,0 +1,469 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// ErrInvalidSQLJob is returned when a scheduled SQL job fails validation
+var ErrInvalidSQLJob = errors.New("invalid SQL job")
+
+// ErrSQLJobBusy is returned when a job is started while a run of it is still queued or running
+var ErrSQLJobBusy = errors.New("SQL job is already queued or running")
+
+// sqlJobQueueSize is the number of runs waiting for a worker before new runs are refused
+const sqlJobQueueSize = 100
+
+// sqlJobMaxResultRows caps the rows stored with a run
+const sqlJobMaxResultRows = 100
+
+// SQLJobService runs SQL registered by admins on cron schedules. Due jobs are queued by
+// RunDueJobs and executed by a pool of workers; every run is recorded with its result.
+type SQLJobService struct {
+	db                  *gorm.DB
+	databaseService     *DatabaseService
+	notificationService *NotificationService
+	defaultTimeout      time.Duration
+	retention           time.Duration
+	replicaID           string
+	queue               chan *models.SQLJobRun
+	wg                  sync.WaitGroup
+	logger              *slog.Logger
+
+	mu     sync.Mutex
+	active map[string]bool // Jobs with a queued or running run
+	closed bool
+}
+
+// NewSQLJobService creates a new SQL job service and starts its workers. Runs without a timeout
+// of their own are cancelled after defaultTimeout; runs older than retention are removed by PruneRuns.
+// Runs are recorded with replicaID, the replica whose workers execute them.
+func NewSQLJobService(databaseService *DatabaseService, notificationService *NotificationService, replicaID string, workers int, defaultTimeout, retention time.Duration, logger *slog.Logger) *SQLJobService {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	s := &SQLJobService{
+		db:                  database.GetDB(),
+		databaseService:     databaseService,
+		notificationService: notificationService,
+		defaultTimeout:      defaultTimeout,
+		retention:           retention,
+		replicaID:           replicaID,
+		queue:               make(chan *models.SQLJobRun, sqlJobQueueSize),
+		active:              map[string]bool{},
+		logger:              logging.OrDefault(logger),
+	}
+
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+
+	return s
+}
+
+// Close stops accepting runs and waits for the queued ones to finish
+func (s *SQLJobService) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// GetJobs returns all scheduled SQL jobs
+func (s *SQLJobService) GetJobs() ([]models.SQLJob, error) {
+	jobs := []models.SQLJob{}
+	if err := s.db.Order("name").Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get SQL jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// GetJob retrieves a scheduled SQL job by ID
+func (s *SQLJobService) GetJob(id string) (*models.SQLJob, error) {
+	var job models.SQLJob
+	if err := s.db.First(&job, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("SQL job not found")
+		}
+		return nil, fmt.Errorf("failed to get SQL job: %w", err)
+	}
+	return &job, nil
+}
+
+// CreateJob registers a scheduled SQL job
+func (s *SQLJobService) CreateJob(req *models.SQLJobRequest, userID string) (*models.SQLJob, error) {
+	job := &models.SQLJob{
+		ID:        uuid.New().String(),
+		CreatedBy: userID,
+	}
+	if err := s.apply(job, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(job).Error; err != nil {
+		return nil, fmt.Errorf("failed to create SQL job: %w", err)
+	}
+	return job, nil
+}
+
+// UpdateJob replaces the definition of a scheduled SQL job; the next run is recomputed
+func (s *SQLJobService) UpdateJob(id string, req *models.SQLJobRequest) (*models.SQLJob, error) {
+	job, err := s.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(job, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(job).Error; err != nil {
+		return nil, fmt.Errorf("failed to update SQL job: %w", err)
+	}
+	return job, nil
+}
+
+// DeleteJob removes a scheduled SQL job and its run history; a running run is left to finish
+// but no longer recorded
+func (s *SQLJobService) DeleteJob(id string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.SQLJob{}, "id = ?", id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete SQL job: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("SQL job not found")
+		}
+		if err := tx.Where("job_id = ?", id).Delete(&models.SQLJobRun{}).Error; err != nil {
+			return fmt.Errorf("failed to delete SQL job runs: %w", err)
+		}
+		return nil
+	})
+}
+
+// RunJob queues a run of a job now, regardless of its schedule or whether it is enabled
+func (s *SQLJobService) RunJob(id, userID string) (*models.SQLJobRun, error) {
+	job, err := s.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.enqueue(job, models.SQLJobTriggerManual, userID)
+}
+
+// GetRuns returns the runs of a job, newest first
+func (s *SQLJobService) GetRuns(jobID string, page Page) ([]models.SQLJobRun, int64, error) {
+	if _, err := s.GetJob(jobID); err != nil {
+		return nil, 0, err
+	}
+
+	runs := []models.SQLJobRun{}
+	total, err := findPage(s.db.Model(&models.SQLJobRun{}).Where("job_id = ?", jobID).Order("created_at DESC"), page, &runs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get SQL job runs: %w", err)
+	}
+	return runs, total, nil
+}
+
+// GetRun retrieves a run of a job
+func (s *SQLJobService) GetRun(jobID, runID string) (*models.SQLJobRun, error) {
+	var run models.SQLJobRun
+	if err := s.db.First(&run, "id = ? AND job_id = ?", runID, jobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("SQL job run not found")
+		}
+		return nil, fmt.Errorf("failed to get SQL job run: %w", err)
+	}
+	return &run, nil
+}
+
+// RunDueJobs queues every enabled job whose next run time has passed and schedules its next run.
+// Runs missed while the server was down are made up once, not once per missed time.
+func (s *SQLJobService) RunDueJobs() error {
+	now := time.Now().UTC()
+
+	var jobs []models.SQLJob
+	if err := s.db.Where("enabled = ? AND next_run_at <= ?", true, now).Find(&jobs).Error; err != nil {
+		return fmt.Errorf("failed to get due SQL jobs: %w", err)
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+
+		next, err := nextSQLJobRun(job.Schedule, job.Timezone, now)
+		if err != nil {
+			s.logger.Error("SQL job has an invalid schedule", "job_id", job.ID, "error", err)
+		}
+		if err := s.db.Model(job).Update("next_run_at", next).Error; err != nil {
+			s.logger.Error("failed to schedule next SQL job run", "job_id", job.ID, "error", err)
+			continue
+		}
+
+		if _, err := s.enqueue(job, models.SQLJobTriggerSchedule, ""); err != nil {
+			s.logger.Warn("skipping scheduled SQL job run", "job_id", job.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// FailInterrupted marks queued and running runs of replicas that are not in liveReplicas as
+// failed; they were cut off when their server stopped
+func (s *SQLJobService) FailInterrupted(liveReplicas []string) error {
+	if err := s.db.Model(&models.SQLJobRun{}).
+		Where("status IN ? AND replica_id NOT IN ?", []models.SQLJobRunStatus{models.SQLJobRunQueued, models.SQLJobRunRunning}, liveReplicas).
+		Updates(map[string]interface{}{
+			"status":      models.SQLJobRunFailed,
+			"error":       "interrupted by a server restart",
+			"finished_at": time.Now().UTC(),
+		}).Error; err != nil {
+		return fmt.Errorf("failed to update interrupted SQL job runs: %w", err)
+	}
+	return nil
+}
+
+// PruneRuns removes finished runs older than the retention period
+func (s *SQLJobService) PruneRuns() error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	cutoff := time.Now().UTC().Add(-s.retention)
+	if err := s.db.Where("created_at < ? AND status NOT IN ?", cutoff, []models.SQLJobRunStatus{models.SQLJobRunQueued, models.SQLJobRunRunning}).
+		Delete(&models.SQLJobRun{}).Error; err != nil {
+		return fmt.Errorf("failed to prune SQL job runs: %w", err)
+	}
+	return nil
+}
+
+// apply validates a request and copies it onto a job
+func (s *SQLJobService) apply(job *models.SQLJob, req *models.SQLJobRequest) error {
+	if strings.TrimSpace(req.Query) == "" {
+		return fmt.Errorf("%w: query is required", ErrInvalidSQLJob)
+	}
+	if req.TimeoutSeconds < 0 {
+		return fmt.Errorf("%w: timeout_seconds must not be negative", ErrInvalidSQLJob)
+	}
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	next, err := nextSQLJobRun(req.Schedule, timezone, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSQLJob, err)
+	}
+	if _, err := s.databaseService.connectionService.GetConnection(req.ConnectionID); err != nil {
+		return err
+	}
+
+	job.Name = req.Name
+	job.Description = req.Description
+	job.ConnectionID = req.ConnectionID
+	job.DatabaseName = req.DatabaseName
+	job.Query = req.Query
+	job.Schedule = strings.TrimSpace(req.Schedule)
+	job.Timezone = timezone
+	job.TimeoutSeconds = req.TimeoutSeconds
+	job.Enabled = req.Enabled == nil || *req.Enabled
+	job.NotifyOnFailure = req.NotifyOnFailure == nil || *req.NotifyOnFailure
+	job.NextRunAt = next
+	return nil
+}
+
+// nextSQLJobRun returns the first run time of a schedule after now, or nil when it never fires
+func nextSQLJobRun(schedule, timezone string, now time.Time) (*time.Time, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", timezone)
+	}
+	cron, err := parseCronSchedule(schedule, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	next := cron.Next(now)
+	if next.IsZero() {
+		return nil, nil
+	}
+	next = next.UTC()
+	return &next, nil
+}
+
+// enqueue records a queued run of a job and hands it to the workers
+func (s *SQLJobService) enqueue(job *models.SQLJob, trigger, userID string) (*models.SQLJobRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, fmt.Errorf("SQL job runner is shutting down")
+	}
+	if s.active[job.ID] {
+		return nil, ErrSQLJobBusy
+	}
+	if len(s.queue) >= cap(s.queue) {
+		return nil, fmt.Errorf("SQL job queue is full")
+	}
+
+	run := &models.SQLJobRun{
+		ID:          uuid.New().String(),
+		JobID:       job.ID,
+		Trigger:     trigger,
+		TriggeredBy: userID,
+		Status:      models.SQLJobRunQueued,
+		ReplicaID:   s.replicaID,
+		Result:      models.SQLJobResult{Columns: []string{}, Rows: []map[string]any{}},
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to create SQL job run: %w", err)
+	}
+
+	// The worker gets its own copy, since the caller returns this one to the client
+	queued := *run
+	s.active[job.ID] = true
+	s.queue <- &queued
+	return run, nil
+}
+
+// worker executes queued runs until the queue is closed
+func (s *SQLJobService) worker() {
+	defer s.wg.Done()
+	for run := range s.queue {
+		s.execute(run)
+	}
+}
+
+// execute runs a queued run and records its outcome
+func (s *SQLJobService) execute(run *models.SQLJobRun) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.active, run.JobID)
+		s.mu.Unlock()
+	}()
+
+	// The job may have been deleted, with its runs, while the run was queued
+	job, err := s.GetJob(run.JobID)
+	if err != nil {
+		if err.Error() != "SQL job not found" {
+			s.logger.Error("failed to load SQL job of run", "run_id", run.ID, "error", err)
+		}
+		return
+	}
+
+	started := time.Now().UTC()
+	if err := s.db.Model(run).Updates(map[string]interface{}{
+		"status":     models.SQLJobRunRunning,
+		"started_at": started,
+	}).Error; err != nil {
+		s.logger.Warn("failed to mark SQL job run as running", "run_id", run.ID, "error", err)
+	}
+
+	result, runErr := s.runSQL(job)
+	finished := time.Now().UTC()
+
+	status := models.SQLJobRunSucceeded
+	updates := map[string]interface{}{
+		"result":      *result,
+		"duration_ms": finished.Sub(started).Milliseconds(),
+		"finished_at": finished,
+	}
+	if runErr != nil {
+		status = models.SQLJobRunFailed
+		updates["error"] = runErr.Error()
+	}
+	updates["status"] = status
+
+	if err := s.db.Model(run).Updates(updates).Error; err != nil {
+		s.logger.Error("failed to record SQL job run outcome", "run_id", run.ID, "error", err)
+	}
+	if err := s.db.Model(job).Updates(map[string]interface{}{
+		"last_run_at": started,
+		"last_status": status,
+	}).Error; err != nil {
+		s.logger.Warn("failed to update SQL job", "job_id", job.ID, "error", err)
+	}
+
+	if runErr != nil && job.NotifyOnFailure {
+		subject := fmt.Sprintf("SQL job %s failed", job.Name)
+		message := fmt.Sprintf("Scheduled SQL job %q on database %s failed after %s: %v",
+			job.Name, job.DatabaseName, finished.Sub(started).Round(time.Millisecond), runErr)
+		if err := s.notificationService.NotifyConnection(job.ConnectionID, models.AlertRuleSQLJobFailed, subject, message); err != nil {
+			s.logger.Warn("failed to send SQL job failure notification", "job_id", job.ID, "error", err)
+		}
+	}
+}
+
+// runSQL executes the statements of a job in one session, stopping at the first error. The rows
+// of the last statement that returns any are kept; the other statements add to rows affected.
+func (s *SQLJobService) runSQL(job *models.SQLJob) (*models.SQLJobResult, error) {
+	result := &models.SQLJobResult{Columns: []string{}, Rows: []map[string]any{}}
+
+	db, d, err := s.databaseService.connect(job.ConnectionID, job.DatabaseName)
+	if err != nil {
+		return result, err
+	}
+
+	timeout := s.defaultTimeout
+	if job.TimeoutSeconds > 0 {
+		timeout = time.Duration(job.TimeoutSeconds) * time.Second
+	}
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var stmtErr error
+	err = withSession(ctx, db, d, func(conn *sql.Conn) error {
+		statements := splitSQLStatements(job.Query)
+		for i, stmt := range statements {
+			if returnsRows(stmt) {
+				rows, err := conn.QueryContext(ctx, stmt)
+				qr := readQueryResult(rows, err, sqlJobMaxResultRows)
+				if qr.Error != "" {
+					stmtErr = fmt.Errorf("statement %d: %s", i+1, qr.Error)
+					return nil
+				}
+				result.Columns = qr.Columns
+				result.Rows = qr.Rows
+				result.Truncated = qr.Truncated
+				continue
+			}
+
+			res, err := conn.ExecContext(ctx, stmt)
+			if err != nil {
+				stmtErr = fmt.Errorf("statement %d: %w", i+1, err)
+				return nil
+			}
+			if affected, err := res.RowsAffected(); err == nil {
+				result.RowsAffected += affected
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	if stmtErr != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return result, fmt.Errorf("timed out after %s: %w", timeout, stmtErr)
+	}
+	return result, stmtErr
+}
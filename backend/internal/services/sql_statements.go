@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode"
+)
+
+// splitSQLStatements splits a script into individual statements on top-level semicolons.
+// Quoted strings, quoted identifiers, dollar-quoted bodies and comments are kept intact.
+func splitSQLStatements(script string) []string {
+	statements := []string{}
+	var current strings.Builder
+
+	flush := func() {
+		stmt := strings.TrimSpace(current.String())
+		if stmt != "" && stripSQLComments(stmt) != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); {
+		if end := skipSQLQuoted(runes, i); end > i {
+			current.WriteString(string(runes[i:end]))
+			i = end
+			continue
+		}
+		if runes[i] == ';' {
+			flush()
+		} else {
+			current.WriteRune(runes[i])
+		}
+		i++
+	}
+	flush()
+
+	return statements
+}
+
+// skipSQLQuoted returns the index just past the quoted literal or identifier, dollar-quoted body or
+// comment starting at runes[i], or i when none starts there. Unterminated spans run to the end.
+func skipSQLQuoted(runes []rune, i int) int {
+	ch := runes[i]
+	switch {
+	case ch == '\'' || ch == '"':
+		// Quoted literal or identifier; doubled quotes are escapes and handled naturally. In escape
+		// strings (E'...') a backslash also escapes the next character, quotes included.
+		escapes := ch == '\'' && isEscapeStringPrefix(runes, i)
+		end := i + 1
+		for end < len(runes) && runes[end] != ch {
+			if escapes && runes[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		return min(end+1, len(runes))
+
+	case ch == '-' && i+1 < len(runes) && runes[i+1] == '-':
+		end := i
+		for end < len(runes) && runes[end] != '\n' {
+			end++
+		}
+		return end
+
+	case ch == '/' && i+1 < len(runes) && runes[i+1] == '*':
+		end := i + 2
+		for end+1 < len(runes) && !(runes[end] == '*' && runes[end+1] == '/') {
+			end++
+		}
+		return min(end+2, len(runes))
+
+	case ch == '$':
+		// Dollar quote: $$ or $tag$ (but not a positional parameter such as $1)
+		tagEnd := i + 1
+		for tagEnd < len(runes) && (unicode.IsLetter(runes[tagEnd]) || unicode.IsDigit(runes[tagEnd]) || runes[tagEnd] == '_') {
+			tagEnd++
+		}
+		if tagEnd >= len(runes) || runes[tagEnd] != '$' || (tagEnd > i+1 && unicode.IsDigit(runes[i+1])) {
+			return i
+		}
+		tag := runes[i : tagEnd+1]
+		end := tagEnd + 1
+		for end < len(runes) && !hasRunePrefix(runes[end:], tag) {
+			end++
+		}
+		return min(end+len(tag), len(runes))
+	}
+	return i
+}
+
+// isEscapeStringPrefix reports whether the quote at runes[i] opens an escape string: it follows an E
+// that is not the end of a longer identifier or keyword
+func isEscapeStringPrefix(runes []rune, i int) bool {
+	if i == 0 || (runes[i-1] != 'E' && runes[i-1] != 'e') {
+		return false
+	}
+	return i == 1 || !isSQLIdentifierRune(runes[i-2])
+}
+
+// isSQLIdentifierRune reports whether r can be part of an unquoted identifier
+func isSQLIdentifierRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$'
+}
+
+// hasRunePrefix reports whether runes starts with prefix
+func hasRunePrefix(runes, prefix []rune) bool {
+	if len(runes) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if runes[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// stripSQLComments removes leading comments and whitespace from a statement
+func stripSQLComments(stmt string) string {
+	for {
+		stmt = strings.TrimSpace(stmt)
+		switch {
+		case strings.HasPrefix(stmt, "--"):
+			idx := strings.Index(stmt, "\n")
+			if idx < 0 {
+				return ""
+			}
+			stmt = stmt[idx+1:]
+		case strings.HasPrefix(stmt, "/*"):
+			idx := strings.Index(stmt, "*/")
+			if idx < 0 {
+				return ""
+			}
+			stmt = stmt[idx+2:]
+		default:
+			return stmt
+		}
+	}
+}
+
+// sqlStatementKeyword returns the upper-cased leading keyword of a statement
+func sqlStatementKeyword(stmt string) string {
+	stmt = stripSQLComments(stmt)
+	end := strings.IndexFunc(stmt, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '_'
+	})
+	if end < 0 {
+		end = len(stmt)
+	}
+	return strings.ToUpper(stmt[:end])
+}
+
+// isTransactionControlStatement reports whether a statement would end or alter the surrounding transaction
+func isTransactionControlStatement(stmt string) bool {
+	switch sqlStatementKeyword(stmt) {
+	case "BEGIN", "START", "COMMIT", "END", "ROLLBACK", "ABORT", "SAVEPOINT", "RELEASE", "PREPARE":
+		return true
+	}
+	return false
+}
+
+// returnsRows reports whether a statement produces a result set rather than a count of affected rows
+func returnsRows(stmt string) bool {
+	switch sqlStatementKeyword(stmt) {
+	case "SELECT", "WITH", "SHOW", "EXPLAIN", "VALUES", "TABLE", "DESCRIBE", "DESC", "FETCH":
+		return true
+	}
+	// INSERT, UPDATE and DELETE return rows with RETURNING
+	for _, word := range strings.Fields(strings.ToUpper(stripSQLComments(stmt))) {
+		if word == "RETURNING" {
+			return true
+		}
+	}
+	return false
+}
+
+// execSingleStatement runs one statement split from user input in tx as a prepared statement.
+// Prepared statements use the extended query protocol, where the server rejects a string holding
+// more than one command, so text the splitter misread can never smuggle a COMMIT or another
+// statement past the checks made on it.
+func execSingleStatement(ctx context.Context, tx *sql.Tx, stmt string) (sql.Result, error) {
+	prepared, err := tx.PrepareContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer prepared.Close()
+	return prepared.ExecContext(ctx)
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitSQLStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "top-level semicolons",
+			script: "SELECT 1; SELECT 2;",
+			want:   []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name:   "semicolon in literal",
+			script: "INSERT INTO t VALUES ('a;b'); SELECT 1",
+			want:   []string{"INSERT INTO t VALUES ('a;b')", "SELECT 1"},
+		},
+		{
+			name:   "doubled quote in literal",
+			script: "SELECT 'it''s; fine'; SELECT 2",
+			want:   []string{"SELECT 'it''s; fine'", "SELECT 2"},
+		},
+		{
+			name:   "backslash ends a standard literal",
+			script: `SELECT 'C:\'; SELECT 2`,
+			want:   []string{`SELECT 'C:\'`, "SELECT 2"},
+		},
+		{
+			name:   "escaped quote in escape string hides nothing",
+			script: `UPDATE t SET a = E'\'' ; COMMIT; DELETE FROM t; --'`,
+			want:   []string{`UPDATE t SET a = E'\''`, "COMMIT", "DELETE FROM t"},
+		},
+		{
+			name:   "lower-case escape string",
+			script: `SELECT e'a\';b'; SELECT 2`,
+			want:   []string{`SELECT e'a\';b'`, "SELECT 2"},
+		},
+		{
+			name:   "escaped backslash before closing quote",
+			script: `SELECT E'a\\'; COMMIT`,
+			want:   []string{`SELECT E'a\\'`, "COMMIT"},
+		},
+		{
+			name:   "identifier ending in e does not start an escape string",
+			script: `SELECT value'a\'; COMMIT`,
+			want:   []string{`SELECT value'a\'`, "COMMIT"},
+		},
+		{
+			name:   "quoted identifier",
+			script: `SELECT "a;b" FROM t; SELECT 2`,
+			want:   []string{`SELECT "a;b" FROM t`, "SELECT 2"},
+		},
+		{
+			name:   "dollar-quoted body",
+			script: "DO $fn$ BEGIN PERFORM 1; END $fn$; SELECT 2",
+			want:   []string{"DO $fn$ BEGIN PERFORM 1; END $fn$", "SELECT 2"},
+		},
+		{
+			name:   "positional parameter is not a dollar quote",
+			script: "SELECT $1; SELECT 2",
+			want:   []string{"SELECT $1", "SELECT 2"},
+		},
+		{
+			name:   "comments",
+			script: "-- first; still comment\nSELECT 1; /* a; b */ SELECT 2; -- trailing",
+			want:   []string{"-- first; still comment\nSELECT 1", "/* a; b */ SELECT 2"},
+		},
+		{
+			name:   "unterminated literal runs to the end",
+			script: "SELECT 'abc; COMMIT",
+			want:   []string{"SELECT 'abc; COMMIT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitSQLStatements(tt.script)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitSQLStatements(%q) = %q, want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTransactionControlStatement(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want bool
+	}{
+		{"COMMIT", true},
+		{"commit", true},
+		{"  -- note\nROLLBACK", true},
+		{"/* x */ BEGIN", true},
+		{"START TRANSACTION", true},
+		{"END", true},
+		{"SAVEPOINT a", true},
+		{"PREPARE TRANSACTION 'x'", true},
+		{`UPDATE t SET a = E'\''`, false},
+		{"SELECT 1", false},
+	}
+
+	for _, tt := range tests {
+		if got := isTransactionControlStatement(tt.stmt); got != tt.want {
+			t.Errorf("isTransactionControlStatement(%q) = %v, want %v", tt.stmt, got, tt.want)
+		}
+	}
+}
+
+// TestSplitSQLStatementsSurfacesHiddenCommit checks that a transaction control statement hidden
+// behind an escaped quote is found by the sandbox check
+func TestSplitSQLStatementsSurfacesHiddenCommit(t *testing.T) {
+	script := `UPDATE t SET a = E'\'' ; COMMIT; DELETE FROM t; --'`
+	found := false
+	for _, stmt := range splitSQLStatements(script) {
+		if isTransactionControlStatement(stmt) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("no transaction control statement found in %q", script)
+	}
+}
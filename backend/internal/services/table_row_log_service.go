@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// TableRowLogService handles the log of rows changed through the table row editor
+type TableRowLogService struct {
+	db    *gorm.DB
+	audit *AuditService
+}
+
+// NewTableRowLogService creates a new table row log service; edits are also shipped to the audit sinks
+func NewTableRowLogService(audit *AuditService) *TableRowLogService {
+	return &TableRowLogService{
+		db:    database.GetDB(),
+		audit: audit,
+	}
+}
+
+// LogEdit stores a row edit with the row before and after it
+func (s *TableRowLogService) LogEdit(ctx context.Context, entry *models.TableRowEditLog) error {
+	entry.RequestID = logging.RequestID(ctx)
+
+	s.audit.Record(models.AuditEvent{
+		Source:       "table_rows",
+		Action:       entry.Operation,
+		Status:       entry.Status,
+		ActorID:      entry.UserID,
+		ConnectionID: entry.ConnectionID,
+		TargetID:     entry.DatabaseName + "." + entry.SchemaName + "." + entry.Table,
+		Message:      entry.ErrorMessage,
+		RequestID:    entry.RequestID,
+	})
+
+	if err := s.db.Create(entry).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to log table row edit", "connection_id", entry.ConnectionID, "table", entry.SchemaName+"."+entry.Table, "operation", entry.Operation, "user_id", entry.UserID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetLogs retrieves the row edits of a table, newest first
+func (s *TableRowLogService) GetLogs(connectionID, dbName, schemaName, tableName string, page Page) ([]models.TableRowEditLog, int64, error) {
+	var logs []models.TableRowEditLog
+
+	query := s.db.Where("connection_id = ? AND database_name = ? AND schema_name = ? AND table_name = ?", connectionID, dbName, schemaName, tableName).
+		Order("created_at DESC")
+
+	total, err := findPage(query, page, &logs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return models.NonNil(logs), total, nil
+}
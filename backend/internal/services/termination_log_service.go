@@ -0,0 +1,77 @@
+package services
+
+import (
+	"log/slog"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// TerminationLogService handles the log of terminated backend processes
+type TerminationLogService struct {
+	db     *gorm.DB
+	audit  *AuditService
+	logger *slog.Logger
+}
+
+// NewTerminationLogService creates a new termination log service; terminations are also shipped to the audit sinks
+func NewTerminationLogService(audit *AuditService, logger *slog.Logger) *TerminationLogService {
+	return &TerminationLogService{
+		db:     database.GetDB(),
+		audit:  audit,
+		logger: logging.OrDefault(logger),
+	}
+}
+
+// LogTermination stores a terminated process together with who terminated it and why
+func (s *TerminationLogService) LogTermination(entry *models.QueryTerminationLog) error {
+	status := models.AuditEventStatusSuccess
+	if entry.ErrorMessage != "" {
+		status = models.AuditEventStatusError
+	}
+
+	message := entry.Reason
+	if entry.ErrorMessage != "" {
+		message = entry.ErrorMessage
+	}
+
+	s.audit.Record(models.AuditEvent{
+		Source:       "monitoring",
+		Action:       "terminate_query",
+		Status:       status,
+		ActorID:      entry.UserID,
+		ConnectionID: entry.ConnectionID,
+		TargetID:     strconv.Itoa(entry.PID),
+		Message:      message,
+	})
+
+	if err := s.db.Create(entry).Error; err != nil {
+		s.logger.Error("failed to log query termination", "connection_id", entry.ConnectionID, "database", entry.DatabaseName, "pid", entry.PID, "user_id", entry.UserID, "error", err)
+		return err
+	}
+
+	s.logger.Info("logged query termination", "connection_id", entry.ConnectionID, "database", entry.DatabaseName, "pid", entry.PID, "user_id", entry.UserID)
+	return nil
+}
+
+// GetLogs retrieves termination logs for a connection, optionally limited to one database
+func (s *TerminationLogService) GetLogs(connectionID, dbName string, page Page) ([]models.QueryTerminationLog, int64, error) {
+	var logs []models.QueryTerminationLog
+
+	query := s.db.Where("connection_id = ?", connectionID)
+	if dbName != "" {
+		query = query.Where("database_name = ?", dbName)
+	}
+	query = query.Order("created_at DESC")
+
+	total, err := findPage(query, page, &logs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
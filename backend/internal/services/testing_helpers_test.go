@@ -0,0 +1,88 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"truadmin/internal/database"
+	"truadmin/internal/models"
+)
+
+// newTestInternalDB points database.DB at an in-memory SQLite database holding the given models,
+// restoring the previous handle when the test ends
+func newTestInternalDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open internal database: %v", err)
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("failed to migrate internal database: %v", err)
+	}
+
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// newMockPostgres routes openPostgres to a go-sqlmock database for the rest of the test. Pings are
+// expected to be made by openConnection and are not asserted.
+func newMockPostgres(t *testing.T) sqlmock.Sqlmock {
+	t.Helper()
+
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(false))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+
+	previous := openPostgres
+	openPostgres = func(dsn string) (*sql.DB, error) { return db, nil }
+	t.Cleanup(func() {
+		openPostgres = previous
+		db.Close()
+	})
+	return mock
+}
+
+// newTestDatabaseService returns a DatabaseService over a saved PostgreSQL connection whose
+// server is a go-sqlmock database
+func newTestDatabaseService(t *testing.T) (*DatabaseService, *models.Connection, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db := newTestInternalDB(t, &models.Connection{})
+	mock := newMockPostgres(t)
+
+	conn := &models.Connection{
+		ID:       "conn-1",
+		Name:     "primary",
+		Type:     "postgres",
+		Host:     "localhost",
+		Port:     5432,
+		Database: "app",
+		Username: "admin",
+		Password: "secret",
+		SSLMode:  "disable",
+	}
+	if err := db.Create(conn).Error; err != nil {
+		t.Fatalf("failed to save connection: %v", err)
+	}
+
+	// go-sqlmock serves a single driver connection, so the pool must keep it
+	pools := NewConnectionPoolManager(PoolConfig{MaxOpenConns: 1, MaxIdleConns: 1}, nil)
+	t.Cleanup(pools.Close)
+	return NewDatabaseService(NewConnectionService(pools, nil), QueryLimits{}), conn, mock
+}
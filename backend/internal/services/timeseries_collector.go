@@ -0,0 +1,168 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// RecordSnapshots samples activity, size and statement metrics of the monitored databases (every
+// database on every PostgreSQL connection while none are registered) and writes them as raw points;
+// it is registered as the metrics_sampling job type
+func (s *TimeSeriesService) RecordSnapshots() error {
+	connections, err := s.connectionService.GetAllConnections()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	targets, err := s.monitored.dueTargets(models.MonitoringCollectorMetrics, now)
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	points := []models.MetricPoint{}
+	for _, conn := range connections {
+		if conn.Type != "postgres" || !targets.includesConnection(conn.ID) {
+			continue
+		}
+		wg.Add(1)
+		go func(conn *models.Connection) {
+			defer wg.Done()
+			connPoints, err := s.sampleConnection(conn.ID, now)
+			if err != nil {
+				s.logger.Warn("failed to sample connection metrics", "connection", conn.Name, "error", err)
+				return
+			}
+			mu.Lock()
+			for _, point := range connPoints {
+				if targets.includes(conn.ID, point.DatabaseName) {
+					points = append(points, point)
+				}
+			}
+			mu.Unlock()
+		}(conn)
+	}
+	wg.Wait()
+
+	return s.Write(points)
+}
+
+// sampleConnection reads the current metrics of all databases on a connection's server
+func (s *TimeSeriesService) sampleConnection(connectionID string, at time.Time) ([]models.MetricPoint, error) {
+	db, err := s.databaseService.connectToDatabase(connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	points := []models.MetricPoint{}
+	add := func(dbName, metric string, value float64) {
+		points = append(points, models.MetricPoint{
+			ConnectionID: connectionID,
+			DatabaseName: dbName,
+			Metric:       metric,
+			Resolution:   models.MetricResolutionRaw,
+			BucketStart:  at,
+			Value:        value,
+			MinValue:     value,
+			MaxValue:     value,
+			SampleCount:  1,
+		})
+	}
+
+	rows, err := db.Query(`
+		SELECT d.datname, pg_database_size(d.oid), s.numbackends, s.xact_commit, s.xact_rollback,
+			s.blks_read, s.blks_hit, s.deadlocks, s.temp_bytes
+		FROM pg_database d
+		JOIN pg_stat_database s ON s.datid = d.oid
+		WHERE d.datistemplate = false AND d.datallowconn
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database statistics: %w", err)
+	}
+	for rows.Next() {
+		var name string
+		var size, backends, commits, rollbacks, blksRead, blksHit, deadlocks, tempBytes int64
+		if err := rows.Scan(&name, &size, &backends, &commits, &rollbacks, &blksRead, &blksHit, &deadlocks, &tempBytes); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan database statistics: %w", err)
+		}
+		add(name, models.MetricSizeBytes, float64(size))
+		add(name, models.MetricConnections, float64(backends))
+		add(name, models.MetricXactCommit, float64(commits))
+		add(name, models.MetricXactRollback, float64(rollbacks))
+		add(name, models.MetricDeadlocks, float64(deadlocks))
+		add(name, models.MetricTempBytes, float64(tempBytes))
+		if total := blksRead + blksHit; total > 0 {
+			add(name, models.MetricCacheHitRatio, float64(blksHit)/float64(total))
+		}
+	}
+	rows.Close()
+
+	if err := s.sampleActivity(db, add); err != nil {
+		return nil, err
+	}
+
+	// pg_stat_statements is optional; its absence only skips the statement metrics
+	s.sampleStatements(db, add)
+
+	return points, nil
+}
+
+// sampleActivity counts backends per database and state
+func (s *TimeSeriesService) sampleActivity(db *sql.DB, add func(dbName, metric string, value float64)) error {
+	rows, err := db.Query(`
+		SELECT datname, state, COUNT(*)
+		FROM pg_stat_activity
+		WHERE datname IS NOT NULL AND state IN ('active', 'idle', 'idle in transaction')
+		GROUP BY datname, state
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to read activity: %w", err)
+	}
+	defer rows.Close()
+
+	metrics := map[string]string{
+		"active":              models.MetricActivityActive,
+		"idle":                models.MetricActivityIdle,
+		"idle in transaction": models.MetricActivityIdleInTx,
+	}
+	for rows.Next() {
+		var name, state string
+		var count int64
+		if err := rows.Scan(&name, &state, &count); err != nil {
+			return fmt.Errorf("failed to scan activity: %w", err)
+		}
+		add(name, metrics[state], float64(count))
+	}
+	return rows.Err()
+}
+
+// sampleStatements reads cumulative call counts and execution time from pg_stat_statements
+func (s *TimeSeriesService) sampleStatements(db *sql.DB, add func(dbName, metric string, value float64)) {
+	rows, err := db.Query(`
+		SELECT d.datname, SUM(s.calls), SUM(s.total_exec_time)
+		FROM pg_stat_statements s
+		JOIN pg_database d ON d.oid = s.dbid
+		GROUP BY d.datname
+	`)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var calls, execTime float64
+		if err := rows.Scan(&name, &calls, &execTime); err != nil {
+			return
+		}
+		add(name, models.MetricStatementsCalls, calls)
+		add(name, models.MetricStatementsExecTimeMs, execTime)
+	}
+}
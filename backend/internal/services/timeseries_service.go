@@ -0,0 +1,352 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+const (
+	// rawMetricRetention is how long raw points are kept before they are rolled up into hourly points
+	rawMetricRetention = 48 * time.Hour
+	// hourlyMetricRetention is how long hourly points are kept before they are rolled up into daily points
+	hourlyMetricRetention = 30 * 24 * time.Hour
+)
+
+// TimeSeriesService stores monitoring snapshots in the partitioned metric_points table,
+// downsamples them as they age and serves series, heatmaps and forecasts
+type TimeSeriesService struct {
+	db                *gorm.DB
+	connectionService *ConnectionService
+	databaseService   *DatabaseService
+	monitored         *MonitoredDatabaseService
+	retention         time.Duration
+	partitions        sync.Map // Names of monthly partitions known to exist
+	logger            *slog.Logger
+}
+
+// NewTimeSeriesService creates a new time-series service; retention is how long daily points are kept
+func NewTimeSeriesService(connectionService *ConnectionService, databaseService *DatabaseService, monitored *MonitoredDatabaseService, retention time.Duration, logger *slog.Logger) *TimeSeriesService {
+	return &TimeSeriesService{
+		db:                database.GetDB(),
+		connectionService: connectionService,
+		databaseService:   databaseService,
+		monitored:         monitored,
+		retention:         retention,
+		logger:            logging.OrDefault(logger),
+	}
+}
+
+// Write stores metric points, creating the monthly partitions they fall into
+func (s *TimeSeriesService) Write(points []models.MetricPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	for _, point := range points {
+		if err := s.ensurePartition(point.BucketStart); err != nil {
+			return err
+		}
+	}
+
+	if err := s.db.CreateInBatches(points, 500).Error; err != nil {
+		return fmt.Errorf("failed to write metric points: %w", err)
+	}
+	return nil
+}
+
+// Query returns a metric series. Points of finer resolutions inside the range are aggregated
+// on the fly, so recent data that has not been downsampled yet is included.
+func (s *TimeSeriesService) Query(q models.MetricQuery) (*models.MetricSeries, error) {
+	if q.Metric == "" {
+		return nil, fmt.Errorf("metric is required")
+	}
+	if !q.From.Before(q.To) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+
+	resolution := q.Resolution
+	if resolution == models.MetricResolutionAuto {
+		resolution = pickMetricResolution(q.From, q.To, time.Now())
+	}
+
+	var points []models.MetricPoint
+	base := s.db.Model(&models.MetricPoint{}).
+		Where("connection_id = ? AND database_name = ? AND metric = ?", q.ConnectionID, q.DatabaseName, q.Metric).
+		Where("bucket_start >= ? AND bucket_start < ?", q.From, q.To)
+
+	var err error
+	switch resolution {
+	case models.MetricResolutionRaw:
+		err = base.Where("resolution = ?", models.MetricResolutionRaw).Order("bucket_start").Find(&points).Error
+	case models.MetricResolutionHour, models.MetricResolutionDay:
+		unit := "hour"
+		included := []models.MetricResolution{models.MetricResolutionRaw, models.MetricResolutionHour}
+		if resolution == models.MetricResolutionDay {
+			unit = "day"
+			included = append(included, models.MetricResolutionDay)
+		}
+		err = base.Where("resolution IN ?", included).
+			Select(fmt.Sprintf(`date_trunc('%s', bucket_start) AS bucket_start,
+				SUM(value * sample_count) / SUM(sample_count) AS value,
+				MIN(min_value) AS min_value,
+				MAX(max_value) AS max_value,
+				SUM(sample_count) AS sample_count`, unit)).
+			Group("1").Order("1").
+			Scan(&points).Error
+	default:
+		return nil, fmt.Errorf("unsupported resolution: %s", resolution)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metric points: %w", err)
+	}
+
+	points = models.NonNil(points)
+	if q.Delta {
+		points = deltaMetricPoints(points)
+	}
+
+	return &models.MetricSeries{
+		ConnectionID: q.ConnectionID,
+		DatabaseName: q.DatabaseName,
+		Metric:       q.Metric,
+		Resolution:   resolution,
+		Delta:        q.Delta,
+		From:         q.From,
+		To:           q.To,
+		Points:       points,
+	}, nil
+}
+
+// Heatmap folds the hourly series of a metric into a weekday x hour grid (UTC)
+func (s *TimeSeriesService) Heatmap(q models.MetricQuery) (*models.MetricHeatmap, error) {
+	q.Resolution = models.MetricResolutionHour
+	series, err := s.Query(q)
+	if err != nil {
+		return nil, err
+	}
+
+	cells := make([]models.MetricHeatmapCell, 7*24)
+	for i := range cells {
+		cells[i].Weekday = i / 24
+		cells[i].Hour = i % 24
+	}
+	for _, point := range series.Points {
+		t := point.BucketStart.UTC()
+		cell := &cells[int(t.Weekday())*24+t.Hour()]
+		cell.Value += point.Value
+		cell.Samples++
+	}
+	for i := range cells {
+		if cells[i].Samples > 0 {
+			cells[i].Value /= float64(cells[i].Samples)
+		}
+	}
+
+	return &models.MetricHeatmap{
+		ConnectionID: q.ConnectionID,
+		DatabaseName: q.DatabaseName,
+		Metric:       q.Metric,
+		Delta:        q.Delta,
+		From:         q.From,
+		To:           q.To,
+		Cells:        cells,
+	}, nil
+}
+
+// Forecast fits a least-squares line through the daily series of a metric and projects it horizonDays ahead
+func (s *TimeSeriesService) Forecast(q models.MetricQuery, horizonDays int) (*models.MetricForecast, error) {
+	q.Resolution = models.MetricResolutionDay
+	q.Delta = false
+	series, err := s.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	if len(series.Points) < 2 {
+		return nil, fmt.Errorf("not enough data to forecast: need at least 2 days of samples")
+	}
+
+	first := series.Points[0].BucketStart
+	last := series.Points[len(series.Points)-1]
+
+	// Least-squares fit of value against days since the first point
+	var sumX, sumY, sumXY, sumXX float64
+	n := float64(len(series.Points))
+	for _, point := range series.Points {
+		x := point.BucketStart.Sub(first).Hours() / 24
+		sumX += x
+		sumY += point.Value
+		sumXY += x * point.Value
+		sumXX += x * x
+	}
+	slope := 0.0
+	if denominator := n*sumXX - sumX*sumX; denominator != 0 {
+		slope = (n*sumXY - sumX*sumY) / denominator
+	}
+	intercept := (sumY - slope*sumX) / n
+
+	points := make([]models.MetricPoint, 0, horizonDays)
+	for day := 1; day <= horizonDays; day++ {
+		t := last.BucketStart.AddDate(0, 0, day)
+		value := intercept + slope*t.Sub(first).Hours()/24
+		points = append(points, models.MetricPoint{
+			BucketStart: t,
+			Value:       value,
+			MinValue:    value,
+			MaxValue:    value,
+		})
+	}
+
+	return &models.MetricForecast{
+		ConnectionID: q.ConnectionID,
+		DatabaseName: q.DatabaseName,
+		Metric:       q.Metric,
+		FittedFrom:   first,
+		FittedTo:     last.BucketStart,
+		SlopePerDay:  slope,
+		Points:       points,
+	}, nil
+}
+
+// Downsample rolls raw points up into hourly points and hourly points into daily points as they age,
+// drops monthly partitions past the retention period and prepares the partitions for the coming month;
+// it is registered as the metrics_downsampling job type
+func (s *TimeSeriesService) Downsample() error {
+	now := time.Now().UTC()
+
+	if err := s.rollup(models.MetricResolutionRaw, models.MetricResolutionHour, "hour", now.Add(-rawMetricRetention).Truncate(time.Hour)); err != nil {
+		return err
+	}
+
+	dayCutoff := now.Add(-hourlyMetricRetention)
+	dayCutoff = time.Date(dayCutoff.Year(), dayCutoff.Month(), dayCutoff.Day(), 0, 0, 0, 0, time.UTC)
+	if err := s.rollup(models.MetricResolutionHour, models.MetricResolutionDay, "day", dayCutoff); err != nil {
+		return err
+	}
+
+	if err := s.dropPartitionsBefore(now.Add(-s.retention)); err != nil {
+		return err
+	}
+
+	if err := s.ensurePartition(now); err != nil {
+		return err
+	}
+	return s.ensurePartition(now.AddDate(0, 1, 0))
+}
+
+// rollup replaces points of one resolution older than cutoff with their aggregates at a coarser resolution
+func (s *TimeSeriesService) rollup(from, to models.MetricResolution, unit string, cutoff time.Time) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		insert := fmt.Sprintf(`
+			INSERT INTO metric_points (connection_id, database_name, metric, resolution, bucket_start, value, min_value, max_value, sample_count)
+			SELECT connection_id, database_name, metric, ?, date_trunc('%s', bucket_start),
+				SUM(value * sample_count) / SUM(sample_count), MIN(min_value), MAX(max_value), SUM(sample_count)
+			FROM metric_points
+			WHERE resolution = ? AND bucket_start < ?
+			GROUP BY connection_id, database_name, metric, date_trunc('%s', bucket_start)
+		`, unit, unit)
+		if err := tx.Exec(insert, to, from, cutoff).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`DELETE FROM metric_points WHERE resolution = ? AND bucket_start < ?`, from, cutoff).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to downsample %s metric points: %w", from, err)
+	}
+	return nil
+}
+
+// ensurePartition creates the monthly partition containing t if it does not exist yet
+func (s *TimeSeriesService) ensurePartition(t time.Time) error {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	name := metricPartitionName(start)
+	if _, ok := s.partitions.Load(name); ok {
+		return nil
+	}
+
+	statement := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF metric_points FOR VALUES FROM ('%s') TO ('%s')`,
+		name, start.Format(time.RFC3339), start.AddDate(0, 1, 0).Format(time.RFC3339))
+	if err := s.db.Exec(statement).Error; err != nil {
+		return fmt.Errorf("failed to create partition %s: %w", name, err)
+	}
+
+	s.partitions.Store(name, struct{}{})
+	return nil
+}
+
+// dropPartitionsBefore drops the monthly partitions that end before cutoff
+func (s *TimeSeriesService) dropPartitionsBefore(cutoff time.Time) error {
+	var names []string
+	err := s.db.Raw(`
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class p ON p.oid = i.inhparent
+		WHERE p.relname = 'metric_points'
+	`).Scan(&names).Error
+	if err != nil {
+		return fmt.Errorf("failed to list metric partitions: %w", err)
+	}
+
+	for _, name := range names {
+		start, err := time.Parse("200601", strings.TrimPrefix(name, "metric_points_"))
+		if err != nil || start.AddDate(0, 1, 0).After(cutoff) {
+			continue
+		}
+		if err := s.db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", name)).Error; err != nil {
+			return fmt.Errorf("failed to drop partition %s: %w", name, err)
+		}
+		s.partitions.Delete(name)
+	}
+	return nil
+}
+
+// metricPartitionName returns the name of the monthly partition starting at start
+func metricPartitionName(start time.Time) string {
+	return "metric_points_" + start.Format("200601")
+}
+
+// pickMetricResolution chooses the finest resolution that still has data for the whole range
+// and keeps the number of points reasonable
+func pickMetricResolution(from, to, now time.Time) models.MetricResolution {
+	span := to.Sub(from)
+	switch {
+	case span <= rawMetricRetention && !from.Before(now.Add(-rawMetricRetention)):
+		return models.MetricResolutionRaw
+	case span <= hourlyMetricRetention && !from.Before(now.Add(-hourlyMetricRetention)):
+		return models.MetricResolutionHour
+	default:
+		return models.MetricResolutionDay
+	}
+}
+
+// deltaMetricPoints converts a cumulative counter series into increments between consecutive points.
+// Counter resets (a value lower than the previous one) yield the new value as the increment.
+func deltaMetricPoints(points []models.MetricPoint) []models.MetricPoint {
+	if len(points) < 2 {
+		return []models.MetricPoint{}
+	}
+
+	deltas := make([]models.MetricPoint, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		delta := points[i].Value - points[i-1].Value
+		if delta < 0 {
+			delta = points[i].Value
+		}
+		point := points[i]
+		point.Value = delta
+		point.MinValue = delta
+		point.MaxValue = delta
+		deltas = append(deltas, point)
+	}
+	return deltas
+}
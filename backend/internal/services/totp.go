@@ -0,0 +1,328 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+
+	"truadmin/internal/models"
+)
+
+// Parameters of the one-time passwords; authenticator apps assume these when the URI leaves them out
+const (
+	totpDigits       = 6
+	totpPeriod       = 30 * time.Second
+	totpSkew         = 1 // Codes of this many periods before and after the current one are accepted
+	totpSecretSize   = 20
+	totpChallengeTTL = 5 * time.Minute
+)
+
+// totpQRCodeSize is the width and height in pixels of the enrollment QR code
+const totpQRCodeSize = 256
+
+// TOTPPolicy configures two-factor authentication with time-based one-time passwords (RFC 6238)
+type TOTPPolicy struct {
+	Issuer        string            // Account issuer shown by authenticator apps
+	RequiredRoles []models.UserRole // Users of these roles must set up two-factor authentication
+	EncryptionKey string            // Encrypts the secrets stored on users
+}
+
+// totpKeys derives the key encrypting TOTP secrets and the key signing login challenges, so neither
+// can be used as the other or as the JWT secret
+func totpKeys(encryptionKey, jwtSecret string) ([]byte, []byte) {
+	secretMAC := hmac.New(sha256.New, []byte(encryptionKey))
+	secretMAC.Write([]byte("truadmin totp secrets"))
+	challengeMAC := hmac.New(sha256.New, []byte(jwtSecret))
+	challengeMAC.Write([]byte("truadmin totp challenges"))
+	return secretMAC.Sum(nil), challengeMAC.Sum(nil)
+}
+
+// TOTPEnrollmentRequired reports whether the role of a user requires two-factor authentication the
+// user has not set up yet
+func (s *AuthService) TOTPEnrollmentRequired(user *models.User) bool {
+	return !user.TOTPEnabled && slices.Contains(s.totp.RequiredRoles, user.Role)
+}
+
+// StartTOTPEnrollment generates a new secret for a user. It replaces the secret of an unfinished
+// enrollment and only takes effect once confirmed with a code.
+func (s *AuthService) StartTOTPEnrollment(userID string) (*models.TOTPEnrollment, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("two-factor authentication is already enabled")
+	}
+
+	raw := make([]byte, totpSecretSize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+	encrypted, err := s.encryptTOTPSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&models.User{}).Where("id = ?", user.ID).Update("totp_secret", encrypted).Error; err != nil {
+		return nil, fmt.Errorf("failed to save secret: %w", err)
+	}
+
+	provisioningURL := s.totpURL(user.Username, secret)
+	qrCode, err := qrcode.Encode(provisioningURL, qrcode.Medium, totpQRCodeSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render QR code: %w", err)
+	}
+
+	return &models.TOTPEnrollment{
+		Secret: secret,
+		URL:    provisioningURL,
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrCode),
+	}, nil
+}
+
+// ConfirmTOTPEnrollment enables two-factor authentication once the user sent a valid code of the
+// secret of their enrollment
+func (s *AuthService) ConfirmTOTPEnrollment(userID, code string) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabled {
+		return fmt.Errorf("two-factor authentication is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return fmt.Errorf("no two-factor enrollment in progress")
+	}
+	if !s.verifyTOTP(user, code) {
+		return fmt.Errorf("invalid two-factor code")
+	}
+
+	if err := s.db.Model(&models.User{}).Where("id = ?", user.ID).Update("totp_enabled", true).Error; err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+// DisableTOTP turns off two-factor authentication of a user, confirmed with a current code. Users
+// whose role requires it cannot turn it off.
+func (s *AuthService) DisableTOTP(userID, code string) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return fmt.Errorf("two-factor authentication is not enabled")
+	}
+	if slices.Contains(s.totp.RequiredRoles, user.Role) {
+		return fmt.Errorf("two-factor authentication is required for your role")
+	}
+	if !s.verifyTOTP(user, code) {
+		return fmt.Errorf("invalid two-factor code")
+	}
+	return s.ResetTOTP(user.ID)
+}
+
+// ResetTOTP removes the two-factor authentication of a user, e.g. after they lost their device
+func (s *AuthService) ResetTOTP(userID string) error {
+	result := s.db.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"totp_secret": "", "totp_enabled": false})
+	if result.Error != nil {
+		return fmt.Errorf("failed to reset two-factor authentication: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+// LoginWithTOTP completes a login with the challenge returned for the password and a code of the
+// authenticator app. Wrong codes count as failed logins.
+func (s *AuthService) LoginWithTOTP(challenge, code, clientIP, userAgent string) (*models.LoginResponse, error) {
+	userID, err := s.parseTOTPChallenge(challenge)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired challenge")
+	}
+	if err := s.checkLoginLock(user.Username, clientIP); err != nil {
+		return nil, err
+	}
+	if user.IsBlocked {
+		return nil, fmt.Errorf("user account is blocked")
+	}
+
+	if !s.verifyTOTP(user, code) {
+		attempts, lockedFor := s.recordLoginFailure(user.Username, clientIP)
+		return nil, &LoginFailedError{UserID: user.ID, ClientIP: clientIP, Attempts: attempts, LockedFor: lockedFor, TOTP: true}
+	}
+	s.clearLoginFailures(user.Username)
+
+	token, err := s.generateToken(user, clientIP, userAgent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &models.LoginResponse{
+		Token: token,
+		User:  user,
+	}, nil
+}
+
+// totpChallenge signs a short-lived challenge naming the user who passed the password step
+func (s *AuthService) totpChallenge(user *models.User) (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		Subject:   user.ID,
+		ExpiresAt: jwt.NewNumericDate(now.Add(totpChallengeTTL)),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+	challenge, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.totpChallengeKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// parseTOTPChallenge returns the user of a valid challenge
+func (s *AuthService) parseTOTPChallenge(challenge string) (string, error) {
+	var claims jwt.RegisteredClaims
+	token, err := jwt.ParseWithClaims(challenge, &claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.totpChallengeKey, nil
+	})
+	if err != nil || !token.Valid || claims.Subject == "" {
+		return "", fmt.Errorf("invalid or expired challenge")
+	}
+	return claims.Subject, nil
+}
+
+// verifyTOTP checks a code against the secret of a user. A code is accepted once; replaying it
+// within its validity fails on every replica sharing the store.
+func (s *AuthService) verifyTOTP(user *models.User, code string) bool {
+	secret, err := s.decryptTOTPSecret(user.TOTPSecret)
+	if err != nil {
+		s.logger.Warn("failed to read two-factor secret", "user_id", user.ID, "error", err)
+		return false
+	}
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		s.logger.Warn("invalid two-factor secret", "user_id", user.ID, "error", err)
+		return false
+	}
+
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	counter := time.Now().Unix() / int64(totpPeriod.Seconds())
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		step := counter + offset
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, uint64(step))), []byte(code)) != 1 {
+			continue
+		}
+
+		usedKey := fmt.Sprintf("totp:used:%s:%d", user.ID, step)
+		if _, used, err := s.store.Get(usedKey); err == nil && used {
+			return false
+		}
+		if err := s.store.Set(usedKey, []byte("1"), time.Duration(2*totpSkew+1)*totpPeriod); err != nil {
+			s.logger.Warn("failed to record used two-factor code", "user_id", user.ID, "error", err)
+		}
+		return true
+	}
+	return false
+}
+
+// totpCode computes the code of a time step (RFC 4226 with HMAC-SHA1)
+func totpCode(key []byte, step uint64) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], step)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%modulo)
+}
+
+// totpURL returns the otpauth:// provisioning URI of a secret
+func (s *AuthService) totpURL(username, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", s.totp.Issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+	label := url.PathEscape(s.totp.Issuer + ":" + username)
+	// Authenticator apps expect spaces as %20 in the parameters too
+	return "otpauth://totp/" + label + "?" + strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+// encryptTOTPSecret encrypts a secret with AES-GCM for storage on the user
+func (s *AuthService) encryptTOTPSecret(secret string) (string, error) {
+	gcm, err := s.totpCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptTOTPSecret decrypts a secret stored on a user
+func (s *AuthService) decryptTOTPSecret(encrypted string) (string, error) {
+	if encrypted == "" {
+		return "", fmt.Errorf("no secret")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret: %w", err)
+	}
+	gcm, err := s.totpCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("secret is too short")
+	}
+	secret, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(secret), nil
+}
+
+// totpCipher returns the AES-GCM cipher of the TOTP secrets
+func (s *AuthService) totpCipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.totpSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"truadmin/internal/models"
+)
+
+func TestStartTOTPEnrollmentRendersQRCode(t *testing.T) {
+	newTestInternalDB(t, &models.User{}, &models.UserSession{})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	auth := NewAuthService("test-secret", NewMemoryStore(), LoginLockoutPolicy{}, TOTPPolicy{Issuer: "TruAdmin", EncryptionKey: "test-key"}, logger)
+	if err := auth.InitialSetup("admin-password"); err != nil {
+		t.Fatalf("InitialSetup() error = %v", err)
+	}
+	login, err := auth.Login("admin", "admin-password", "192.0.2.1", "test")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	enrollment, err := auth.StartTOTPEnrollment(login.User.ID)
+	if err != nil {
+		t.Fatalf("StartTOTPEnrollment() error = %v", err)
+	}
+	if !strings.HasPrefix(enrollment.URL, "otpauth://totp/TruAdmin:admin?") {
+		t.Errorf("otpauth URL = %q", enrollment.URL)
+	}
+
+	// The QR code is a PNG data URI the enrollment page shows as is
+	encoded, ok := strings.CutPrefix(enrollment.QRCode, "data:image/png;base64,")
+	if !ok {
+		t.Fatalf("QR code = %.40q..., want a PNG data URI", enrollment.QRCode)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("failed to decode QR code: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("QR code is not a PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != totpQRCodeSize || size.Y != totpQRCodeSize {
+		t.Errorf("QR code size = %v, want %dx%d", size, totpQRCodeSize, totpQRCodeSize)
+	}
+}
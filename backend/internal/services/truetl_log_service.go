@@ -1,29 +1,34 @@
 package services
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"time"
 
 	"gorm.io/gorm"
 
 	"truadmin/internal/database"
+	"truadmin/internal/logging"
 	"truadmin/internal/models"
 )
 
 // TruETLLogService handles logging of TruETL save operations
 type TruETLLogService struct {
-	db *gorm.DB
+	db    *gorm.DB
+	audit *AuditService
 }
 
-// NewTruETLLogService creates a new TruETL log service
-func NewTruETLLogService() *TruETLLogService {
+// NewTruETLLogService creates a new TruETL log service; operations are also shipped to the audit sinks
+func NewTruETLLogService(audit *AuditService) *TruETLLogService {
 	return &TruETLLogService{
-		db: database.GetDB(),
+		db:    database.GetDB(),
+		audit: audit,
 	}
 }
 
 // LogSaveOperation logs a save operation
 func (s *TruETLLogService) LogSaveOperation(
+	ctx context.Context,
 	truetlDatabaseID string,
 	userID string,
 	status models.TruETLSaveLogStatus,
@@ -40,34 +45,51 @@ func (s *TruETLLogService) LogSaveOperation(
 		SQLScript:        sqlScript,
 		ErrorMessage:     errorMessage,
 		ExecutionTimeMs:  executionTimeMs,
+		RequestID:        logging.RequestID(ctx),
 		CreatedAt:        time.Now(),
 	}
 
+	s.audit.Record(truETLAuditEvent(&logEntry))
+
 	if err := s.db.Create(&logEntry).Error; err != nil {
-		log.Printf("ERROR: Failed to log TruETL save operation: %v", err)
-		log.Printf("  truetlDatabaseID: %s, userID: %s, status: %s", truetlDatabaseID, userID, status)
+		slog.ErrorContext(ctx, "failed to log TruETL save operation", "truetl_database_id", truetlDatabaseID, "user_id", userID, "status", status, "error", err)
 		return err
 	}
 
-	log.Printf("✅ Logged TruETL save operation: truetlDatabaseID=%s, userID=%s, status=%s, executionTime=%dms",
-		truetlDatabaseID, userID, status, executionTimeMs)
+	slog.InfoContext(ctx, "logged TruETL save operation", "truetl_database_id", truetlDatabaseID, "user_id", userID, "status", status, "execution_time_ms", executionTimeMs)
 	return nil
 }
 
+// truETLAuditEvent converts a TruETL save log entry to the audit event shipped for it
+func truETLAuditEvent(entry *models.TruETLSaveLog) models.AuditEvent {
+	return models.AuditEvent{
+		Timestamp: entry.CreatedAt.UTC(),
+		Source:    "truetl",
+		Action:    "save",
+		Status:    models.AuditEventStatus(entry.Status),
+		ActorID:   entry.UserID,
+		TargetID:  entry.TruETLDatabaseID,
+		Message:   entry.ErrorMessage,
+		RequestID: entry.RequestID,
+	}
+}
+
 // GetSaveLogs retrieves save logs for a specific TruETL database
-func (s *TruETLLogService) GetSaveLogs(truetlDatabaseID string, limit int) ([]models.TruETLSaveLog, error) {
+func (s *TruETLLogService) GetSaveLogs(truetlDatabaseID string, page Page) ([]models.TruETLSaveLog, int64, error) {
 	var logs []models.TruETLSaveLog
 
 	query := s.db.Where("truetl_database_id = ?", truetlDatabaseID).
 		Order("created_at DESC")
 
-	if limit > 0 {
-		query = query.Limit(limit)
+	total, err := findPage(query, page, &logs)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if err := query.Find(&logs).Error; err != nil {
-		return nil, err
-	}
+	return logs, total, nil
+}
 
-	return logs, nil
+// ExportSaveLogs streams the save logs of a TruETL database matching the filter, oldest first
+func (s *TruETLLogService) ExportSaveLogs(truetlDatabaseID string, filter models.LogExportFilter, fn func(*models.TruETLSaveLog) error) error {
+	return streamLogs(s.db.Where("truetl_database_id = ?", truetlDatabaseID), filter, "", fn)
 }
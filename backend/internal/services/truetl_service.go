@@ -1,9 +1,10 @@
 package services
 
 import (
-	"database/sql"
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +13,7 @@ import (
 	"gorm.io/gorm"
 
 	"truadmin/internal/database"
+	"truadmin/internal/logging"
 	"truadmin/internal/models"
 )
 
@@ -19,18 +21,20 @@ import (
 type TruETLService struct {
 	db                *gorm.DB
 	connectionService *ConnectionService
+	logger            *slog.Logger
 }
 
-// NewTruETLService creates a new TruETL service
-func NewTruETLService(connectionService *ConnectionService) *TruETLService {
+// NewTruETLService creates a new TruETL service; logger defaults to the default logger when nil
+func NewTruETLService(connectionService *ConnectionService, logger *slog.Logger) *TruETLService {
 	return &TruETLService{
 		db:                database.GetDB(),
 		connectionService: connectionService,
+		logger:            logging.OrDefault(logger),
 	}
 }
 
 // GetEligibleDatabases returns databases that have meta schema and dms_tables table
-func (s *TruETLService) GetEligibleDatabases(connectionID string) ([]models.Database, error) {
+func (s *TruETLService) GetEligibleDatabases(ctx context.Context, connectionID string) ([]models.Database, error) {
 	// Get connection
 	conn, err := s.connectionService.GetConnection(connectionID)
 	if err != nil {
@@ -43,14 +47,10 @@ func (s *TruETLService) GetEligibleDatabases(connectionID string) ([]models.Data
 	}
 
 	// Connect to the database
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		conn.Host, conn.Port, conn.Username, conn.Password, conn.Database, conn.SSLMode)
-
-	db, err := sql.Open("postgres", connStr)
+	db, _, err := s.connectionService.pools.get(conn, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
-	defer db.Close()
 
 	// Get list of all databases
 	query := `
@@ -61,7 +61,7 @@ func (s *TruETLService) GetEligibleDatabases(connectionID string) ([]models.Data
 		ORDER BY datname
 	`
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get databases: %w", err)
 	}
@@ -77,25 +77,14 @@ func (s *TruETLService) GetEligibleDatabases(connectionID string) ([]models.Data
 			continue
 		}
 
-		fmt.Printf("Checking database: %s\n", dbName)
+		s.logger.DebugContext(ctx, "checking database", "database", dbName)
 
 		// Connect to each database to check for meta.dms_tables
-		dbConnStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-			conn.Host, conn.Port, conn.Username, conn.Password, dbName, conn.SSLMode)
-
-		dbConn, err := sql.Open("postgres", dbConnStr)
+		dbConn, _, err := s.connectionService.pools.get(conn, dbName)
 		if err != nil {
-			fmt.Printf("Failed to open connection to database %s: %v\n", dbName, err)
-			continue
-		}
-
-		// Actually establish the connection
-		if err := dbConn.Ping(); err != nil {
-			fmt.Printf("Failed to ping database %s: %v\n", dbName, err)
-			dbConn.Close()
+			s.logger.WarnContext(ctx, "failed to connect to database", "database", dbName, "error", err)
 			continue
 		}
-		fmt.Printf("Successfully connected to database: %s\n", dbName)
 
 		// Check if meta schema and dms_tables table exist (case-insensitive)
 		checkQuery := `
@@ -108,30 +97,24 @@ func (s *TruETLService) GetEligibleDatabases(connectionID string) ([]models.Data
 		`
 
 		var exists bool
-		if err := dbConn.QueryRow(checkQuery).Scan(&exists); err != nil {
-			// Log error for debugging
-			fmt.Printf("Error checking database %s: %v\n", dbName, err)
-			dbConn.Close()
+		if err := dbConn.QueryRowContext(ctx, checkQuery).Scan(&exists); err != nil {
+			s.logger.WarnContext(ctx, "failed to check database", "database", dbName, "error", err)
 			continue
 		}
 
-		fmt.Printf("Database %s - meta.dms_tables exists: %v\n", dbName, exists)
+		s.logger.DebugContext(ctx, "checked database for meta.dms_tables", "database", dbName, "eligible", exists)
 
 		if !exists {
-			dbConn.Close()
 			continue
 		}
 
-		dbConn.Close()
-
 		// Add to eligible databases
-		fmt.Printf("✓ Adding database %s to eligible list\n", dbName)
 		eligibleDatabases = append(eligibleDatabases, models.Database{
 			Name: dbName,
 		})
 	}
 
-	fmt.Printf("Total eligible databases found: %d\n", len(eligibleDatabases))
+	s.logger.DebugContext(ctx, "found eligible databases", "connection_id", connectionID, "count", len(eligibleDatabases))
 	return eligibleDatabases, nil
 }
 
@@ -182,6 +165,86 @@ func (s *TruETLService) AddDatabase(req *models.TruETLDatabaseRequest) (*models.
 	return truETLDB, nil
 }
 
+// InitializeSchema creates the meta schema with its tables and functions in a database of a
+// connection, which makes the database eligible for TruETL
+func (s *TruETLService) InitializeSchema(ctx context.Context, connectionID string, req *models.SchemaBootstrapRequest, userID string) (*models.SchemaBootstrapLog, error) {
+	conn, err := s.connectionService.GetConnection(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	if conn.Type != "postgres" {
+		return nil, fmt.Errorf("only PostgreSQL databases are supported for TruETL")
+	}
+
+	return s.connectionService.initializeModuleSchema(ctx, truETLSchema, conn, req, userID)
+}
+
+// GetSchemaLogs retrieves the meta schema initializations on a connection
+func (s *TruETLService) GetSchemaLogs(connectionID string, page Page) ([]models.SchemaBootstrapLog, int64, error) {
+	return s.connectionService.GetSchemaBootstrapLogs(connectionID, truETLSchema.module, page)
+}
+
+// AddEligibleDatabases adds the selected eligible databases of a connection to TruETL, or all of them
+// when none are selected. Databases already registered, by name or as the alias of a re-pointed
+// registration, are skipped; the other databases are added one by one and fail on their own.
+func (s *TruETLService) AddEligibleDatabases(ctx context.Context, req *models.BulkDatabaseRegistrationRequest) (*models.BulkDatabaseRegistrationResult, error) {
+	eligible, err := s.GetEligibleDatabases(ctx, req.ConnectionID)
+	if err != nil {
+		return nil, err
+	}
+	isEligible := make(map[string]bool, len(eligible))
+	allEligible := []string{}
+	for _, database := range eligible {
+		isEligible[database.Name] = true
+		allEligible = append(allEligible, database.Name)
+	}
+	names := req.Databases
+	if len(names) == 0 {
+		names = allEligible
+	}
+
+	var registered []models.TruETLDatabase
+	if err := s.db.Where("connection_id = ?", req.ConnectionID).Find(&registered).Error; err != nil {
+		return nil, fmt.Errorf("failed to get TruETL databases: %w", err)
+	}
+	taken := map[string]bool{}
+	for _, truETLDB := range registered {
+		taken[truETLDB.DatabaseName] = true
+		taken[truETLDB.ConnectedDatabase()] = true
+	}
+
+	result := &models.BulkDatabaseRegistrationResult{Items: []models.BulkItemResult{}}
+	for _, name := range names {
+		item := models.BulkItemResult{Item: name}
+		switch {
+		case taken[name]:
+			item.Status = "skipped"
+			item.Error = "database already added to TruETL"
+			result.Skipped++
+		case !isEligible[name]:
+			item.Status = "error"
+			item.Error = "database is not eligible for TruETL"
+			result.Failed++
+		default:
+			added, err := s.AddDatabase(&models.TruETLDatabaseRequest{ConnectionID: req.ConnectionID, DatabaseName: name})
+			if err != nil {
+				item.Status = "error"
+				item.Error = err.Error()
+				result.Failed++
+				break
+			}
+			item.Status = "success"
+			item.Result = added
+			result.Added++
+		}
+		taken[name] = true
+		result.Items = append(result.Items, item)
+	}
+
+	s.logger.InfoContext(ctx, "bulk added TruETL databases", "connection_id", req.ConnectionID, "added", result.Added, "skipped", result.Skipped, "failed", result.Failed)
+	return result, nil
+}
+
 // GetDatabases retrieves all TruETL databases with connection details
 func (s *TruETLService) GetDatabases() ([]models.TruETLDatabaseWithConnection, error) {
 	var truETLDatabases []models.TruETLDatabase
@@ -270,6 +333,11 @@ func (s *TruETLService) UpdateDatabase(id string, req *models.TruETLDatabaseRequ
 		displayName = req.DatabaseName
 	}
 
+	// A new database name or connection replaces any alias set by re-pointing
+	if req.ConnectionID != truETLDB.ConnectionID || req.DatabaseName != truETLDB.DatabaseName {
+		truETLDB.DatabaseAlias = ""
+	}
+
 	// Update fields
 	truETLDB.ConnectionID = req.ConnectionID
 	truETLDB.DatabaseName = req.DatabaseName
@@ -296,8 +364,61 @@ func (s *TruETLService) DeleteDatabase(id string) error {
 	return nil
 }
 
+// RepointDatabase switches a TruETL database to another database, for clusters that name databases
+// per environment. The registration keeps its database name, with the new database as its alias,
+// and only switches when the new database has the meta schema of the database it replaces.
+func (s *TruETLService) RepointDatabase(ctx context.Context, id string, req *models.RepointDatabaseRequest) (*models.TruETLDatabase, error) {
+	var truETLDB models.TruETLDatabase
+	if err := s.db.First(&truETLDB, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("TruETL database not found")
+		}
+		return nil, fmt.Errorf("failed to get TruETL database: %w", err)
+	}
+
+	connectionID := req.ConnectionID
+	if connectionID == "" {
+		connectionID = truETLDB.ConnectionID
+	}
+	conn, err := s.connectionService.GetConnection(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	if conn.Type != "postgres" {
+		return nil, fmt.Errorf("only PostgreSQL databases are supported for TruETL")
+	}
+
+	// Check if another database already uses the new database
+	var existing models.TruETLDatabase
+	if err := s.db.Where("connection_id = ? AND (database_name = ? OR database_alias = ?) AND id != ?", connectionID, req.DatabaseName, req.DatabaseName, id).First(&existing).Error; err == nil {
+		return nil, fmt.Errorf("database already added to TruETL")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check existing database: %w", err)
+	}
+
+	// The current connection may be gone; the check then falls back to the required tables
+	current, _ := s.connectionService.GetConnection(truETLDB.ConnectionID)
+	if err := s.connectionService.checkRepointTarget(ctx, truETLSchema, current, truETLDB.ConnectedDatabase(), conn, req.DatabaseName); err != nil {
+		return nil, err
+	}
+
+	alias := req.DatabaseName
+	if alias == truETLDB.DatabaseName {
+		alias = ""
+	}
+	truETLDB.ConnectionID = connectionID
+	truETLDB.DatabaseAlias = alias
+	truETLDB.UpdatedAt = time.Now()
+	if err := s.db.Save(&truETLDB).Error; err != nil {
+		return nil, fmt.Errorf("failed to update TruETL database: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "re-pointed TruETL database", "id", id, "database", truETLDB.DatabaseName, "connected_database", truETLDB.ConnectedDatabase())
+	return &truETLDB, nil
+}
+
 // GetDMSTables retrieves all tables from meta.dms_tables for a TruETL database
-func (s *TruETLService) GetDMSTables(truetlDatabaseID string) ([]map[string]interface{}, error) {
+func (s *TruETLService) GetDMSTables(ctx context.Context, truetlDatabaseID string) ([]models.DMSTable, error) {
 	// Get TruETL database info
 	truETLDB, err := s.GetDatabase(truetlDatabaseID)
 	if err != nil {
@@ -311,20 +432,16 @@ func (s *TruETLService) GetDMSTables(truetlDatabaseID string) ([]map[string]inte
 	}
 
 	// Connect to the specific database
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		conn.Host, conn.Port, conn.Username, conn.Password, truETLDB.DatabaseName, conn.SSLMode)
-
-	db, err := sql.Open("postgres", connStr)
+	db, _, err := s.connectionService.pools.get(conn, truETLDB.ConnectedDatabase())
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
-	defer db.Close()
 
 	// Query meta.dms_tables
 	// Note: Using SELECT * to get all columns dynamically, so we can't safely ORDER BY
 	// specific columns without knowing the schema. Frontend can sort if needed.
 	query := "SELECT * FROM meta.dms_tables"
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query meta.dms_tables: %w", err)
 	}
@@ -336,49 +453,30 @@ func (s *TruETLService) GetDMSTables(truetlDatabaseID string) ([]map[string]inte
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
 
-	// Log column names for debugging
-	fmt.Printf("meta.dms_tables columns: %v\n", columns)
+	s.logger.DebugContext(ctx, "read meta.dms_tables", "database_id", truetlDatabaseID, "columns", columns)
 
-	// Scan rows into maps
-	var results []map[string]interface{}
+	// Scan rows into typed rows, keeping unknown columns in Extra
+	results := []models.DMSTable{}
 	for rows.Next() {
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		rowMap := make(map[string]interface{})
-		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				rowMap[col] = string(b)
-			} else {
-				rowMap[col] = val
-			}
+		table, err := scanDMSTable(rows, columns)
+		if err != nil {
+			return nil, err
 		}
-		results = append(results, rowMap)
+		results = append(results, table)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	fmt.Printf("GetDMSFields returning %d fields\n", len(results))
+	s.logger.DebugContext(ctx, "loaded DMS tables", "database_id", truetlDatabaseID, "count", len(results))
 	return results, nil
 }
 
 // GetDMSFields retrieves all fields from meta.dms_fields for given table IDs
-func (s *TruETLService) GetDMSFields(truetlDatabaseID string, tableIDs []int) ([]map[string]interface{}, error) {
-	fmt.Printf("GetDMSFields called with database ID: %s, table IDs: %v\n", truetlDatabaseID, tableIDs)
-	
+func (s *TruETLService) GetDMSFields(ctx context.Context, truetlDatabaseID string, tableIDs []int) ([]models.DMSField, error) {
 	if len(tableIDs) == 0 {
-		fmt.Println("No table IDs provided, returning empty result")
-		return []map[string]interface{}{}, nil
+		return []models.DMSField{}, nil
 	}
 
 	// Get TruETL database info
@@ -394,19 +492,15 @@ func (s *TruETLService) GetDMSFields(truetlDatabaseID string, tableIDs []int) ([
 	}
 
 	// Connect to the specific database
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		conn.Host, conn.Port, conn.Username, conn.Password, truETLDB.DatabaseName, conn.SSLMode)
-
-	db, err := sql.Open("postgres", connStr)
+	db, _, err := s.connectionService.pools.get(conn, truETLDB.ConnectedDatabase())
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
-	defer db.Close()
 
 	// Build query with table IDs
 	query := "SELECT * FROM meta.dms_fields WHERE table_id = ANY($1) ORDER BY table_id, row_order"
-	fmt.Printf("Executing query: %s with table IDs: %v\n", query, tableIDs)
-	rows, err := db.Query(query, pq.Array(tableIDs))
+	s.logger.DebugContext(ctx, "querying meta.dms_fields", "database_id", truetlDatabaseID, "table_ids", tableIDs)
+	rows, err := db.QueryContext(ctx, query, pq.Array(tableIDs))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query meta.dms_fields: %w", err)
 	}
@@ -417,37 +511,22 @@ func (s *TruETLService) GetDMSFields(truetlDatabaseID string, tableIDs []int) ([
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
-	fmt.Printf("meta.dms_fields columns: %v\n", columns)
+	s.logger.DebugContext(ctx, "read meta.dms_fields", "database_id", truetlDatabaseID, "columns", columns)
 
-	// Scan rows into maps
-	var results []map[string]interface{}
+	// Scan rows into typed rows, keeping unknown columns in Extra
+	results := []models.DMSField{}
 	for rows.Next() {
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		rowMap := make(map[string]interface{})
-		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				rowMap[col] = string(b)
-			} else {
-				rowMap[col] = val
-			}
+		field, err := scanDMSField(rows, columns)
+		if err != nil {
+			return nil, err
 		}
-		results = append(results, rowMap)
+		results = append(results, field)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	fmt.Printf("GetDMSFields returning %d fields\n", len(results))
+	s.logger.DebugContext(ctx, "loaded DMS fields", "database_id", truetlDatabaseID, "count", len(results))
 	return results, nil
 }
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// scanDMSTable scans the current row of SELECT * FROM meta.dms_tables
+func scanDMSTable(rows *sql.Rows, columns []string) (models.DMSTable, error) {
+	table := models.DMSTable{Extra: map[string]interface{}{}}
+
+	values, err := scanRowValues(rows, len(columns))
+	if err != nil {
+		return table, err
+	}
+
+	for i, col := range columns {
+		value := values[i]
+		var err error
+		switch strings.ToLower(col) {
+		case "id":
+			table.ID, err = dmsInt(value)
+		case "service_name":
+			table.ServiceName = dmsString(value)
+		case "source_db_name":
+			table.SourceDbName = dmsString(value)
+		case "source_db_type":
+			table.SourceDbType = dmsString(value)
+		case "source_schema_name":
+			table.SourceSchemaName = dmsString(value)
+		case "source_table_name":
+			table.SourceTableName = dmsString(value)
+		case "source_field_name":
+			table.SourceFieldName = dmsString(value)
+		case "source_field_type":
+			table.SourceFieldType = dmsString(value)
+		case "target_db_name":
+			table.TargetDbName = dmsString(value)
+		case "target_db_type":
+			table.TargetDbType = dmsString(value)
+		case "target_schema_name":
+			table.TargetSchemaName = dmsString(value)
+		case "target_table_name":
+			table.TargetTableName = dmsString(value)
+		case "target_field_name":
+			table.TargetFieldName = dmsString(value)
+		case "target_field_type":
+			table.TargetFieldType = dmsString(value)
+		case "target_field_value":
+			table.TargetFieldValue = dmsString(value)
+		case "is_id":
+			table.IsID, err = dmsInt(value)
+		case "row_num":
+			table.RowNum, err = dmsInt(value)
+		default:
+			table.Extra[col] = value
+		}
+		if err != nil {
+			return table, fmt.Errorf("invalid value in column %s: %w", col, err)
+		}
+	}
+
+	return table, nil
+}
+
+// scanDMSField scans the current row of SELECT * FROM meta.dms_fields
+func scanDMSField(rows *sql.Rows, columns []string) (models.DMSField, error) {
+	field := models.DMSField{Extra: map[string]interface{}{}}
+
+	values, err := scanRowValues(rows, len(columns))
+	if err != nil {
+		return field, err
+	}
+
+	for i, col := range columns {
+		value := values[i]
+		var err error
+		switch strings.ToLower(col) {
+		case "id":
+			field.ID, err = dmsInt(value)
+		case "table_id":
+			field.TableID, err = dmsInt(value)
+		case "source_field":
+			field.SourceField = dmsString(value)
+		case "source_type":
+			field.SourceType = dmsString(value)
+		case "target_field":
+			field.TargetField = dmsString(value)
+		case "target_type":
+			field.TargetType = dmsString(value)
+		case "target_value":
+			field.TargetValue = dmsString(value)
+		case "is_primary_key":
+			field.IsPrimaryKey, err = dmsBool(value)
+		case "row_order":
+			field.RowOrder, err = dmsInt(value)
+		default:
+			field.Extra[col] = value
+		}
+		if err != nil {
+			return field, fmt.Errorf("invalid value in column %s: %w", col, err)
+		}
+	}
+
+	return field, nil
+}
+
+// scanRowValues scans the current row into generic values, converting []byte to string
+func scanRowValues(rows *sql.Rows, count int) ([]interface{}, error) {
+	values := make([]interface{}, count)
+	valuePtrs := make([]interface{}, count)
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	for i, value := range values {
+		if b, ok := value.([]byte); ok {
+			values[i] = string(b)
+		}
+	}
+	return values, nil
+}
+
+// dmsString converts a scanned value to a string; NULL becomes ""
+func dmsString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// dmsInt converts a scanned value to an int; NULL becomes 0
+func dmsInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		return int(v), nil
+	case int32:
+		return int(v), nil
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.Atoi(strings.TrimSpace(v))
+	default:
+		return 0, fmt.Errorf("unexpected type %T", value)
+	}
+}
+
+// dmsBool converts a scanned value to a bool; NULL becomes false
+func dmsBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case int64:
+		return v != 0, nil
+	case string:
+		return strconv.ParseBool(strings.TrimSpace(v))
+	default:
+		return false, fmt.Errorf("unexpected type %T", value)
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"truadmin/internal/models"
+)
+
+// lineageNodeID returns the id of a column node; ids are lower case so that mappings spelling the
+// same column differently meet in one node
+func lineageNodeID(dbName, schemaName, tableName, columnName string) string {
+	return strings.ToLower(strings.Join([]string{dbName, schemaName, tableName, columnName}, "."))
+}
+
+// GetLineage builds the column lineage graph of the mappings in meta.dms_tables, optionally
+// limited to one service. Mappings without a source or target field have no edge.
+func (s *TruETLService) GetLineage(ctx context.Context, truetlDatabaseID, serviceName string) (*models.LineageGraph, error) {
+	if _, err := s.GetDatabase(truetlDatabaseID); err != nil {
+		return nil, err
+	}
+
+	tables, err := s.GetDMSTables(ctx, truetlDatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &models.LineageGraph{
+		Nodes: []models.LineageNode{},
+		Edges: []models.LineageEdge{},
+	}
+	seen := map[string]bool{}
+	addNode := func(node models.LineageNode) {
+		if !seen[node.ID] {
+			seen[node.ID] = true
+			graph.Nodes = append(graph.Nodes, node)
+		}
+	}
+
+	for _, t := range tables {
+		if serviceName != "" && t.ServiceName != serviceName {
+			continue
+		}
+		if t.SourceFieldName == "" || t.TargetFieldName == "" {
+			continue
+		}
+
+		source := models.LineageNode{
+			ID:         lineageNodeID(t.SourceDbName, t.SourceSchemaName, t.SourceTableName, t.SourceFieldName),
+			DbType:     t.SourceDbType,
+			DbName:     t.SourceDbName,
+			SchemaName: t.SourceSchemaName,
+			TableName:  t.SourceTableName,
+			ColumnName: t.SourceFieldName,
+			ColumnType: t.SourceFieldType,
+		}
+		target := models.LineageNode{
+			ID:         lineageNodeID(t.TargetDbName, t.TargetSchemaName, t.TargetTableName, t.TargetFieldName),
+			DbType:     t.TargetDbType,
+			DbName:     t.TargetDbName,
+			SchemaName: t.TargetSchemaName,
+			TableName:  t.TargetTableName,
+			ColumnName: t.TargetFieldName,
+			ColumnType: t.TargetFieldType,
+		}
+		addNode(source)
+		addNode(target)
+
+		graph.Edges = append(graph.Edges, models.LineageEdge{
+			From:        source.ID,
+			To:          target.ID,
+			ServiceName: t.ServiceName,
+			MappingID:   t.ID,
+			TargetValue: t.TargetFieldValue,
+		})
+	}
+
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	return graph, nil
+}
+
+// GetLineageImpact lists the target columns that break when a column is dropped: the columns it is
+// mapped to, and further the columns those targets are mapped to when they feed other mappings
+func (s *TruETLService) GetLineageImpact(ctx context.Context, truetlDatabaseID, dbName, schemaName, tableName, columnName string) (*models.LineageImpact, error) {
+	graph, err := s.GetLineage(ctx, truetlDatabaseID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := map[string]models.LineageNode{}
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+	outgoing := map[string][]models.LineageEdge{}
+	for _, edge := range graph.Edges {
+		outgoing[edge.From] = append(outgoing[edge.From], edge)
+	}
+
+	start := lineageNodeID(dbName, schemaName, tableName, columnName)
+	column, ok := nodes[start]
+	if !ok || len(outgoing[start]) == 0 {
+		return nil, fmt.Errorf("column not found in lineage")
+	}
+
+	impact := &models.LineageImpact{
+		Column:   column,
+		Affected: []models.LineageImpactEntry{},
+		Tables:   []string{},
+		Services: []string{},
+	}
+
+	// Breadth first, so every target is reported at its shortest depth; visited guards against cycles
+	visited := map[string]bool{start: true}
+	tables := map[string]bool{}
+	services := map[string]bool{}
+	queue := []string{start}
+	for depth := 1; len(queue) > 0; depth++ {
+		next := []string{}
+		for _, from := range queue {
+			for _, edge := range outgoing[from] {
+				services[edge.ServiceName] = true
+				if visited[edge.To] {
+					continue
+				}
+				visited[edge.To] = true
+
+				target := nodes[edge.To]
+				tables[strings.Join([]string{target.DbName, target.SchemaName, target.TableName}, ".")] = true
+				impact.Affected = append(impact.Affected, models.LineageImpactEntry{
+					Node:        target,
+					Depth:       depth,
+					ServiceName: edge.ServiceName,
+					Via:         from,
+				})
+				next = append(next, edge.To)
+			}
+		}
+		queue = next
+	}
+
+	for table := range tables {
+		impact.Tables = append(impact.Tables, table)
+	}
+	for service := range services {
+		impact.Services = append(impact.Services, service)
+	}
+	sort.Strings(impact.Tables)
+	sort.Strings(impact.Services)
+
+	return impact, nil
+}
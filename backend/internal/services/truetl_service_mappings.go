@@ -0,0 +1,345 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"truadmin/internal/models"
+)
+
+// ErrInvalidMappingDocument is returned for mapping documents that cannot be parsed or imported
+var ErrInvalidMappingDocument = errors.New("invalid mapping document")
+
+// mappingColumns are the meta.dms_tables columns written by a mapping import, in argument order
+const mappingColumns = `service_name, source_db_name, source_db_type, source_schema_name, source_table_name,
+	source_field_name, source_field_type,
+	target_db_name, target_db_type, target_schema_name, target_table_name,
+	target_field_name, target_field_type, target_field_value,
+	is_id, row_num`
+
+// ExportMappings returns the whole meta.dms_tables mapping of a TruETL database as a document
+func (s *TruETLService) ExportMappings(ctx context.Context, truetlDatabaseID string) (*models.TruETLMappingDocument, error) {
+	truETLDB, err := s.GetDatabase(truetlDatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := s.GetDMSTables(ctx, truetlDatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	doc := &models.TruETLMappingDocument{
+		Version:    models.TruETLMappingDocumentVersion,
+		Database:   truETLDB.DatabaseName,
+		ExportedAt: &now,
+		Mappings:   make([]models.TruETLMapping, 0, len(tables)),
+	}
+	for _, t := range tables {
+		doc.Mappings = append(doc.Mappings, mappingOf(t))
+	}
+	sortMappings(doc.Mappings)
+	return doc, nil
+}
+
+// EncodeMappingDocument writes a mapping document as json or yaml
+func EncodeMappingDocument(doc *models.TruETLMappingDocument, format string) ([]byte, error) {
+	if format == "yaml" {
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to encode mapping document: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// ParseMappingDocument reads a json or yaml mapping document; unknown keys are rejected so typos
+// do not silently import empty values
+func ParseMappingDocument(data []byte, format string) (*models.TruETLMappingDocument, error) {
+	var doc models.TruETLMappingDocument
+	if format == "yaml" {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMappingDocument, err)
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMappingDocument, err)
+		}
+	}
+
+	if doc.Version != models.TruETLMappingDocumentVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d, expected %d", ErrInvalidMappingDocument, doc.Version, models.TruETLMappingDocumentVersion)
+	}
+	seen := map[string]bool{}
+	for i, m := range doc.Mappings {
+		if m.ServiceName == "" || m.SourceDbName == "" || m.SourceTableName == "" {
+			return nil, fmt.Errorf("%w: mapping %d has no service_name, source_db_name or source_table_name", ErrInvalidMappingDocument, i+1)
+		}
+		key := mappingKey(m)
+		if seen[key] {
+			return nil, fmt.Errorf("%w: mapping %d repeats %s.%s.%s field %s -> %s", ErrInvalidMappingDocument, i+1,
+				m.ServiceName, m.SourceDbName, m.SourceTableName, m.SourceFieldName, m.TargetFieldName)
+		}
+		seen[key] = true
+	}
+	return &doc, nil
+}
+
+// ImportMappings makes meta.dms_tables of a TruETL database match a mapping document: rows of the
+// document are added or updated and rows missing from it are deleted, in one transaction. A dry
+// run only reports the changes. Applied imports are recorded in the TruETL save log.
+func (s *TruETLService) ImportMappings(ctx context.Context, truetlDatabaseID, userID, mode string, doc *models.TruETLMappingDocument, logService *TruETLLogService) (*models.TruETLMappingImportResult, error) {
+	if mode != models.MappingImportDryRun && mode != models.MappingImportApply {
+		return nil, fmt.Errorf("%w: mode must be %s or %s", ErrInvalidMappingDocument, models.MappingImportDryRun, models.MappingImportApply)
+	}
+
+	if _, err := s.GetDatabase(truetlDatabaseID); err != nil {
+		return nil, err
+	}
+	tables, err := s.GetDMSTables(ctx, truetlDatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	result := diffMappings(tables, doc.Mappings)
+	result.Mode = mode
+	if mode == models.MappingImportDryRun || len(result.Changes) == 0 {
+		return result, nil
+	}
+
+	startTime := time.Now()
+	var summary models.ChangesSummary
+	summary.Fields.Added = result.Added
+	summary.Fields.Updated = result.Updated
+	summary.Fields.Deleted = result.Deleted
+
+	script, err := s.applyMappingChanges(ctx, truetlDatabaseID, result.Changes)
+	if logService != nil {
+		status, message := models.SaveStatusSuccess, ""
+		if err != nil {
+			status, message = models.SaveStatusError, err.Error()
+		}
+		logService.LogSaveOperation(ctx, truetlDatabaseID, userID, status, summary, script, message, int(time.Since(startTime).Milliseconds()))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyMappingChanges writes the changes of an import in one transaction and returns the SQL script run
+func (s *TruETLService) applyMappingChanges(ctx context.Context, truetlDatabaseID string, changes []models.TruETLMappingChange) (string, error) {
+	truETLDB, err := s.GetDatabase(truetlDatabaseID)
+	if err != nil {
+		return "", err
+	}
+	conn, err := s.connectionService.GetConnection(truETLDB.ConnectionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get connection: %w", err)
+	}
+	db, _, err := s.connectionService.pools.get(conn, truETLDB.ConnectedDatabase())
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	sqlQueries := []string{"BEGIN;"}
+	exec := func(query string, args ...interface{}) error {
+		sqlQueries = append(sqlQueries, formatSQLWithArgs(query, args...))
+		_, err := tx.ExecContext(ctx, query, args...)
+		return err
+	}
+
+	for _, change := range changes {
+		var err error
+		switch change.Action {
+		case "delete":
+			err = exec("DELETE FROM meta.dms_tables WHERE id = $1", change.ID)
+		case "update":
+			err = exec(fmt.Sprintf("UPDATE meta.dms_tables SET (%s) = (%s) WHERE id = $17", mappingColumns, mappingPlaceholders()),
+				append(mappingArgs(change.Mapping), change.ID)...)
+		case "add":
+			err = exec(fmt.Sprintf("INSERT INTO meta.dms_tables (%s) VALUES (%s)", mappingColumns, mappingPlaceholders()),
+				mappingArgs(change.Mapping)...)
+		}
+		if err != nil {
+			return strings.Join(sqlQueries, "\n\n"), fmt.Errorf("failed to %s mapping %s.%s field %s: %w", change.Action,
+				change.Mapping.ServiceName, change.Mapping.SourceTableName, change.Mapping.SourceFieldName, err)
+		}
+	}
+
+	sqlQueries = append(sqlQueries, "COMMIT;")
+	if err := tx.Commit(); err != nil {
+		return strings.Join(sqlQueries, "\n\n"), fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return strings.Join(sqlQueries, "\n\n"), nil
+}
+
+// diffMappings compares the stored rows with the imported mappings. Stored rows are matched by
+// their key; stored duplicates of a key beyond the first are deleted.
+func diffMappings(tables []models.DMSTable, mappings []models.TruETLMapping) *models.TruETLMappingImportResult {
+	result := &models.TruETLMappingImportResult{Changes: []models.TruETLMappingChange{}}
+
+	existing := map[string]models.DMSTable{}
+	for _, t := range tables {
+		key := mappingKey(mappingOf(t))
+		if _, ok := existing[key]; ok {
+			result.Changes = append(result.Changes, models.TruETLMappingChange{Action: "delete", ID: t.ID, Mapping: mappingOf(t)})
+			result.Deleted++
+			continue
+		}
+		existing[key] = t
+	}
+
+	imported := map[string]bool{}
+	for _, m := range mappings {
+		key := mappingKey(m)
+		imported[key] = true
+
+		t, ok := existing[key]
+		if !ok {
+			result.Changes = append(result.Changes, models.TruETLMappingChange{Action: "add", Mapping: m})
+			result.Added++
+			continue
+		}
+		if changed := changedMappingFields(mappingOf(t), m); len(changed) > 0 {
+			result.Changes = append(result.Changes, models.TruETLMappingChange{Action: "update", ID: t.ID, Mapping: m, Changed: changed})
+			result.Updated++
+		} else {
+			result.Unchanged++
+		}
+	}
+
+	for key, t := range existing {
+		if !imported[key] {
+			result.Changes = append(result.Changes, models.TruETLMappingChange{Action: "delete", ID: t.ID, Mapping: mappingOf(t)})
+			result.Deleted++
+		}
+	}
+
+	sort.SliceStable(result.Changes, func(i, j int) bool {
+		return mappingLess(result.Changes[i].Mapping, result.Changes[j].Mapping)
+	})
+	return result
+}
+
+// changedMappingFields returns the names of the non-key fields that differ between two mappings
+func changedMappingFields(old, updated models.TruETLMapping) []string {
+	fields := []struct {
+		name       string
+		old, value interface{}
+	}{
+		{"source_db_type", old.SourceDbType, updated.SourceDbType},
+		{"source_schema_name", old.SourceSchemaName, updated.SourceSchemaName},
+		{"source_field_type", old.SourceFieldType, updated.SourceFieldType},
+		{"target_db_name", old.TargetDbName, updated.TargetDbName},
+		{"target_db_type", old.TargetDbType, updated.TargetDbType},
+		{"target_schema_name", old.TargetSchemaName, updated.TargetSchemaName},
+		{"target_table_name", old.TargetTableName, updated.TargetTableName},
+		{"target_field_type", old.TargetFieldType, updated.TargetFieldType},
+		{"target_field_value", old.TargetFieldValue, updated.TargetFieldValue},
+		{"is_id", old.IsID, updated.IsID},
+		{"row_num", old.RowNum, updated.RowNum},
+	}
+
+	var changed []string
+	for _, f := range fields {
+		if f.old != f.value {
+			changed = append(changed, f.name)
+		}
+	}
+	return changed
+}
+
+// mappingOf returns the document row of a meta.dms_tables row
+func mappingOf(t models.DMSTable) models.TruETLMapping {
+	return models.TruETLMapping{
+		ServiceName:      t.ServiceName,
+		SourceDbName:     t.SourceDbName,
+		SourceDbType:     t.SourceDbType,
+		SourceSchemaName: t.SourceSchemaName,
+		SourceTableName:  t.SourceTableName,
+		SourceFieldName:  t.SourceFieldName,
+		SourceFieldType:  t.SourceFieldType,
+		TargetDbName:     t.TargetDbName,
+		TargetDbType:     t.TargetDbType,
+		TargetSchemaName: t.TargetSchemaName,
+		TargetTableName:  t.TargetTableName,
+		TargetFieldName:  t.TargetFieldName,
+		TargetFieldType:  t.TargetFieldType,
+		TargetFieldValue: t.TargetFieldValue,
+		IsID:             t.IsID,
+		RowNum:           t.RowNum,
+	}
+}
+
+// mappingKey identifies a mapping row across environments
+func mappingKey(m models.TruETLMapping) string {
+	return strings.Join([]string{m.ServiceName, m.SourceDbName, m.SourceTableName, m.SourceFieldName, m.TargetFieldName}, "\x00")
+}
+
+// mappingArgs returns the values of a mapping in mappingColumns order
+func mappingArgs(m models.TruETLMapping) []interface{} {
+	return []interface{}{
+		m.ServiceName, m.SourceDbName, m.SourceDbType, m.SourceSchemaName, m.SourceTableName,
+		m.SourceFieldName, m.SourceFieldType,
+		m.TargetDbName, m.TargetDbType, m.TargetSchemaName, m.TargetTableName,
+		m.TargetFieldName, m.TargetFieldType, m.TargetFieldValue,
+		m.IsID, m.RowNum,
+	}
+}
+
+// mappingPlaceholders returns $1..$16 for the mappingColumns
+func mappingPlaceholders() string {
+	placeholders := make([]string, 16)
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+// sortMappings orders mappings by service, source table and row number
+func sortMappings(mappings []models.TruETLMapping) {
+	sort.SliceStable(mappings, func(i, j int) bool {
+		return mappingLess(mappings[i], mappings[j])
+	})
+}
+
+// mappingLess orders mappings by service, source database, source table, row number and field
+func mappingLess(a, b models.TruETLMapping) bool {
+	if a.ServiceName != b.ServiceName {
+		return a.ServiceName < b.ServiceName
+	}
+	if a.SourceDbName != b.SourceDbName {
+		return a.SourceDbName < b.SourceDbName
+	}
+	if a.SourceTableName != b.SourceTableName {
+		return a.SourceTableName < b.SourceTableName
+	}
+	if a.RowNum != b.RowNum {
+		return a.RowNum < b.RowNum
+	}
+	if a.SourceFieldName != b.SourceFieldName {
+		return a.SourceFieldName < b.SourceFieldName
+	}
+	return a.TargetFieldName < b.TargetFieldName
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// columnTypeAliases maps type spellings to one canonical name so mapped and actual types can be compared
+var columnTypeAliases = map[string]string{
+	"int":         "integer",
+	"int4":        "integer",
+	"serial":      "integer",
+	"serial4":     "integer",
+	"int2":        "smallint",
+	"smallserial": "smallint",
+	"int8":        "bigint",
+	"serial8":     "bigint",
+	"bigserial":   "bigint",
+	"float4":      "real",
+	"float8":      "double precision",
+	"double":      "double precision",
+	"float":       "double precision",
+	"decimal":     "numeric",
+	"bool":        "boolean",
+	"varchar":     "character varying",
+	"char":        "character",
+	"bpchar":      "character",
+	"timestamp":   "timestamp without time zone",
+	"timestamptz": "timestamp with time zone",
+	"time":        "time without time zone",
+	"timetz":      "time with time zone",
+	"datetime":    "timestamp without time zone",
+	"varbinary":   "bytea",
+	"blob":        "bytea",
+	"longtext":    "text",
+	"mediumtext":  "text",
+}
+
+// targetColumn is a column of a TruETL target table
+type targetColumn struct {
+	name      string
+	dataType  string
+	length    sql.NullInt64
+	precision sql.NullInt64
+	scale     sql.NullInt64
+	nullable  bool
+}
+
+// CheckTargetReadiness verifies that the target of a mapping table exists on its target connection
+// with a column of a matching type for every mapped field. The target connection is routed by the
+// mapping's target_db_type and target_db_name tags unless the request names it.
+func (s *TruETLService) CheckTargetReadiness(ctx context.Context, truetlDatabaseID string, req *models.TruETLReadinessRequest) (*models.TruETLReadinessReport, error) {
+	tables, err := s.GetDMSTables(ctx, truetlDatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings []models.DMSTable
+	for _, t := range tables {
+		if t.ServiceName == req.ServiceName && t.SourceDbName == req.SourceDbName && t.SourceTableName == req.SourceTableName {
+			mappings = append(mappings, t)
+		}
+	}
+	if len(mappings) == 0 {
+		return nil, fmt.Errorf("mapping table not found")
+	}
+
+	first := mappings[0]
+	report := &models.TruETLReadinessReport{
+		ServiceName:      req.ServiceName,
+		SourceDbName:     req.SourceDbName,
+		SourceTableName:  req.SourceTableName,
+		TargetDbType:     first.TargetDbType,
+		TargetDbName:     first.TargetDbName,
+		TargetSchemaName: first.TargetSchemaName,
+		TargetTableName:  first.TargetTableName,
+		Fields:           []models.TruETLFieldReadiness{},
+		ExtraColumns:     []string{},
+		Issues:           []string{},
+		CheckedAt:        time.Now().UTC(),
+	}
+	for _, m := range mappings[1:] {
+		if m.TargetDbName != first.TargetDbName || m.TargetSchemaName != first.TargetSchemaName || m.TargetTableName != first.TargetTableName {
+			report.Issues = append(report.Issues, fmt.Sprintf("field %s maps to %s.%s.%s instead of %s.%s.%s",
+				m.SourceFieldName, m.TargetDbName, m.TargetSchemaName, m.TargetTableName,
+				first.TargetDbName, first.TargetSchemaName, first.TargetTableName))
+		}
+	}
+	if report.TargetDbName == "" || report.TargetTableName == "" {
+		report.Issues = append(report.Issues, "mapping has no target database or table")
+		return report, nil
+	}
+
+	conn, routedBy, err := s.routeTarget(ctx, req.TargetConnectionID, first.TargetDbType, first.TargetDbName)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		report.Issues = append(report.Issues, fmt.Sprintf("no saved connection has database %s; pass target_connection_id", first.TargetDbName))
+		return report, nil
+	}
+	report.TargetConnectionID = conn.ID
+	report.TargetConnectionName = conn.Name
+	report.RoutedBy = routedBy
+
+	db, d, err := s.connectionService.pools.get(conn, first.TargetDbName)
+	if err != nil {
+		report.Issues = append(report.Issues, fmt.Sprintf("target database %s is not reachable: %v", first.TargetDbName, err))
+		return report, nil
+	}
+	report.DatabaseExists = true
+
+	schemaName := first.TargetSchemaName
+	if schemaName == "" {
+		schemaName = d.defaultSchema(first.TargetDbName)
+		report.TargetSchemaName = schemaName
+	}
+
+	// Names are matched following the identifier case policy, naming close matches when missing
+	query, args := d.schemasQuery(first.TargetDbName)
+	schemas, err := readCatalogNames(ctx, db, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schemas: %w", err)
+	}
+	if schemaName, err = lookupName("schema", first.TargetDbName, schemas, schemaName); err != nil {
+		report.Issues = append(report.Issues, err.Error())
+		return report, nil
+	}
+	report.SchemaExists = true
+
+	query, args = d.tablesQuery(first.TargetDbName, schemaName)
+	tableNames, err := readCatalogNames(ctx, db, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tables: %w", err)
+	}
+	tableName, err := lookupName("table", schemaName, tableNames, first.TargetTableName)
+	if err != nil {
+		report.Issues = append(report.Issues, err.Error())
+		return report, nil
+	}
+	report.TableExists = true
+
+	columns, err := readTargetColumns(ctx, db, d, first.TargetDbName, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	mapped := map[string]bool{}
+	for _, m := range mappings {
+		if m.TargetFieldName == "" {
+			continue
+		}
+		key := strings.ToLower(m.TargetFieldName)
+		mapped[key] = true
+
+		field := models.TruETLFieldReadiness{TargetFieldName: m.TargetFieldName, MappedType: m.TargetFieldType}
+		column, ok := columns[key]
+		switch {
+		case !ok:
+			field.Status = models.ReadinessMissing
+			report.Issues = append(report.Issues, fmt.Sprintf("column %s does not exist", m.TargetFieldName))
+		default:
+			nullable := column.nullable
+			field.ActualType = column.displayType()
+			field.Nullable = &nullable
+			switch {
+			case strings.TrimSpace(m.TargetFieldType) == "":
+				field.Status = models.ReadinessUnchecked
+			case columnTypesMatch(m.TargetFieldType, column):
+				field.Status = models.ReadinessOK
+			default:
+				field.Status = models.ReadinessTypeMismatch
+				report.Issues = append(report.Issues, fmt.Sprintf("column %s is %s, mapped as %s", m.TargetFieldName, field.ActualType, m.TargetFieldType))
+			}
+		}
+		report.Fields = append(report.Fields, field)
+	}
+
+	for key, column := range columns {
+		if !mapped[key] {
+			report.ExtraColumns = append(report.ExtraColumns, column.name)
+		}
+	}
+	sort.Strings(report.ExtraColumns)
+
+	report.Ready = len(report.Issues) == 0
+	return report, nil
+}
+
+// routeTarget picks the connection that hosts a target database. An explicit connection wins;
+// otherwise connections of the tagged engine are tried, first by their default database and then
+// by listing their databases. A nil connection means no saved connection has the database.
+func (s *TruETLService) routeTarget(ctx context.Context, connectionID, dbType, dbName string) (*models.Connection, string, error) {
+	if connectionID != "" {
+		conn, err := s.connectionService.GetConnection(connectionID)
+		if err != nil {
+			return nil, "", err
+		}
+		return conn, "explicit", nil
+	}
+
+	connections, err := s.connectionService.GetAllConnections()
+	if err != nil {
+		return nil, "", err
+	}
+
+	connType := dialectForTag(dbType)
+	candidates := []*models.Connection{}
+	for _, conn := range connections {
+		if _, err := dialectFor(conn.Type); err != nil {
+			continue
+		}
+		if connType == "" || conn.Type == connType {
+			candidates = append(candidates, conn)
+		}
+	}
+
+	for _, conn := range candidates {
+		if conn.Database == dbName {
+			return conn, "default_database", nil
+		}
+	}
+
+	for _, conn := range candidates {
+		db, d, err := s.connectionService.pools.get(conn, "")
+		if err != nil {
+			continue
+		}
+		query, args := d.databasesQuery()
+		found, err := queryHasName(ctx, db, query, args, dbName)
+		if err == nil && found {
+			return conn, "database_listing", nil
+		}
+	}
+
+	return nil, "", nil
+}
+
+// queryHasName reports whether the first column of a metadata query contains name
+func queryHasName(ctx context.Context, db *sql.DB, query string, args []any, name string) (bool, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return false, err
+	}
+
+	for rows.Next() {
+		values, err := scanRowValues(rows, len(columns))
+		if err != nil {
+			return false, err
+		}
+		if dmsString(values[0]) == name {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// readTargetColumns returns the columns of a table keyed by lower-case name
+func readTargetColumns(ctx context.Context, db *sql.DB, d dialect, dbName, schemaName, tableName string) (map[string]targetColumn, error) {
+	query, args := d.columnsQuery(dbName, schemaName, tableName)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+	defer rows.Close()
+
+	columns := map[string]targetColumn{}
+	for rows.Next() {
+		var column targetColumn
+		if err := rows.Scan(&column.name, &column.dataType, &column.length, &column.precision, &column.scale, &column.nullable); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		columns[strings.ToLower(column.name)] = column
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating columns: %w", err)
+	}
+	return columns, nil
+}
+
+// displayType returns the column type with its length or precision, e.g. character varying(255)
+func (c targetColumn) displayType() string {
+	base := strings.ToLower(c.dataType)
+	switch {
+	case c.length.Valid && (base == "character varying" || base == "character" || base == "varchar" || base == "char"):
+		return fmt.Sprintf("%s(%d)", base, c.length.Int64)
+	case c.precision.Valid && (base == "numeric" || base == "decimal"):
+		if c.scale.Valid && c.scale.Int64 > 0 {
+			return fmt.Sprintf("%s(%d,%d)", base, c.precision.Int64, c.scale.Int64)
+		}
+		return fmt.Sprintf("%s(%d)", base, c.precision.Int64)
+	default:
+		return base
+	}
+}
+
+// columnTypesMatch reports whether a mapped type matches an actual column. Lengths and precision
+// are only compared when the mapping specifies them.
+func columnTypesMatch(mappedType string, column targetColumn) bool {
+	mappedBase, mappedParams := splitColumnType(mappedType)
+	actualBase, actualParams := splitColumnType(column.displayType())
+	if mappedBase != actualBase {
+		return false
+	}
+	return mappedParams == "" || mappedParams == actualParams
+}
+
+// splitColumnType returns the canonical base name of a type and its parameters without spaces,
+// e.g. "VARCHAR( 255 )" becomes ("character varying", "255")
+func splitColumnType(columnType string) (string, string) {
+	t := strings.Join(strings.Fields(strings.ToLower(columnType)), " ")
+	params := ""
+	if open := strings.Index(t, "("); open >= 0 {
+		if end := strings.Index(t[open:], ")"); end >= 0 {
+			params = strings.ReplaceAll(t[open+1:open+end], " ", "")
+			t = strings.TrimSpace(t[:open] + t[open+end+1:])
+		}
+	}
+	if alias, ok := columnTypeAliases[t]; ok {
+		t = alias
+	}
+	return t, params
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/models"
+)
+
+// ErrTruETLRunTableMismatch is returned when a run_id is reported again for a different table
+var ErrTruETLRunTableMismatch = errors.New("run was reported for a different table")
+
+// truETLTableKey identifies a source table of a TruETL pipeline
+type truETLTableKey struct {
+	serviceName     string
+	sourceDbName    string
+	sourceTableName string
+}
+
+// ReportRun records a pipeline run of a TruETL database. Reporting a known run_id again updates the
+// run, so pipelines can report a run when it starts and again when it finishes.
+func (s *TruETLService) ReportRun(truetlDatabaseID string, req *models.TruETLRunRequest, userID string) (*models.TruETLRun, bool, error) {
+	if _, err := s.GetDatabase(truetlDatabaseID); err != nil {
+		return nil, false, err
+	}
+
+	run := &models.TruETLRun{}
+	created := true
+	if req.RunID != "" {
+		err := s.db.Where("truetl_database_id = ? AND run_id = ?", truetlDatabaseID, req.RunID).First(run).Error
+		if err == nil {
+			created = false
+			if run.ServiceName != req.ServiceName || run.SourceDbName != req.SourceDbName || run.SourceTableName != req.SourceTableName {
+				return nil, false, ErrTruETLRunTableMismatch
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, fmt.Errorf("failed to get TruETL run: %w", err)
+		}
+	}
+
+	if created {
+		run.ID = uuid.New().String()
+		run.TruETLDatabaseID = truetlDatabaseID
+		run.RunID = req.RunID
+		if run.RunID == "" {
+			run.RunID = run.ID
+		}
+		run.ServiceName = req.ServiceName
+		run.SourceDbName = req.SourceDbName
+		run.SourceTableName = req.SourceTableName
+		run.StartedAt = time.Now().UTC()
+	}
+	if req.StartedAt != nil {
+		run.StartedAt = req.StartedAt.UTC()
+	}
+	if req.FinishedAt != nil {
+		finishedAt := req.FinishedAt.UTC()
+		run.FinishedAt = &finishedAt
+	}
+	run.Status = req.Status
+	run.RowsMoved = req.RowsMoved
+	run.ErrorMessage = req.ErrorMessage
+	run.DurationMs = req.DurationMs
+	if run.DurationMs == 0 && run.FinishedAt != nil && run.FinishedAt.After(run.StartedAt) {
+		run.DurationMs = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+	}
+	run.ReportedBy = userID
+
+	if err := s.db.Save(run).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to save TruETL run: %w", err)
+	}
+
+	return run, created, nil
+}
+
+// GetRuns returns a page of the run history of a TruETL database, newest first
+func (s *TruETLService) GetRuns(truetlDatabaseID string, filter models.TruETLRunFilter, page Page) ([]models.TruETLRun, int64, error) {
+	query := s.db.Where("truetl_database_id = ?", truetlDatabaseID)
+	if filter.ServiceName != "" {
+		query = query.Where("service_name = ?", filter.ServiceName)
+	}
+	if filter.SourceDbName != "" {
+		query = query.Where("source_db_name = ?", filter.SourceDbName)
+	}
+	if filter.SourceTableName != "" {
+		query = query.Where("source_table_name = ?", filter.SourceTableName)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	runs := []models.TruETLRun{}
+	total, err := findPage(query.Order("started_at DESC"), page, &runs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get TruETL runs: %w", err)
+	}
+	return runs, total, nil
+}
+
+// GetRunBoard returns the status of every mapping table of a TruETL database: its last run, last
+// success and the run counts since the given time. Tables that runs were reported for without a
+// mapping are listed as unmapped.
+func (s *TruETLService) GetRunBoard(ctx context.Context, truetlDatabaseID string, since time.Time) (*models.TruETLRunBoard, error) {
+	if _, err := s.GetDatabase(truetlDatabaseID); err != nil {
+		return nil, err
+	}
+
+	tables, err := s.GetDMSTables(ctx, truetlDatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := map[truETLTableKey]*models.TruETLBoardEntry{}
+	for _, t := range tables {
+		key := truETLTableKey{t.ServiceName, t.SourceDbName, t.SourceTableName}
+		if _, ok := entries[key]; ok {
+			continue
+		}
+		entries[key] = &models.TruETLBoardEntry{
+			ServiceName:      t.ServiceName,
+			SourceDbName:     t.SourceDbName,
+			SourceTableName:  t.SourceTableName,
+			TargetDbName:     t.TargetDbName,
+			TargetSchemaName: t.TargetSchemaName,
+			TargetTableName:  t.TargetTableName,
+			Mapped:           true,
+			Status:           models.TruETLRunNeverRun,
+		}
+	}
+
+	entry := func(key truETLTableKey) *models.TruETLBoardEntry {
+		e, ok := entries[key]
+		if !ok {
+			e = &models.TruETLBoardEntry{
+				ServiceName:     key.serviceName,
+				SourceDbName:    key.sourceDbName,
+				SourceTableName: key.sourceTableName,
+				Status:          models.TruETLRunNeverRun,
+			}
+			entries[key] = e
+		}
+		return e
+	}
+
+	var stats []struct {
+		ServiceName     string
+		SourceDbName    string
+		SourceTableName string
+		LastSuccessAt   *time.Time
+		Runs            int64
+		Failures        int64
+		RowsMoved       int64
+		AvgDurationMs   int64
+	}
+	err = s.db.Model(&models.TruETLRun{}).
+		Select(`service_name, source_db_name, source_table_name,
+			MAX(CASE WHEN status = ? THEN COALESCE(finished_at, started_at) END) AS last_success_at,
+			COUNT(CASE WHEN started_at >= ? THEN 1 END) AS runs,
+			COUNT(CASE WHEN started_at >= ? AND status = ? THEN 1 END) AS failures,
+			COALESCE(SUM(CASE WHEN started_at >= ? THEN rows_moved END), 0) AS rows_moved,
+			CAST(COALESCE(AVG(CASE WHEN started_at >= ? AND status = ? THEN duration_ms END), 0) AS BIGINT) AS avg_duration_ms`,
+			models.TruETLRunSuccess, since, since, models.TruETLRunFailed, since, since, models.TruETLRunSuccess).
+		Where("truetl_database_id = ?", truetlDatabaseID).
+		Group("service_name, source_db_name, source_table_name").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TruETL run stats: %w", err)
+	}
+	for _, st := range stats {
+		e := entry(truETLTableKey{st.ServiceName, st.SourceDbName, st.SourceTableName})
+		e.LastSuccessAt = st.LastSuccessAt
+		e.Runs = st.Runs
+		e.Failures = st.Failures
+		e.RowsMoved = st.RowsMoved
+		e.AvgDurationMs = st.AvgDurationMs
+	}
+
+	var lastRuns []models.TruETLRun
+	err = s.db.Raw(`
+		SELECT DISTINCT ON (service_name, source_db_name, source_table_name) *
+		FROM truetl_runs
+		WHERE truetl_database_id = ?
+		ORDER BY service_name, source_db_name, source_table_name, started_at DESC
+	`, truetlDatabaseID).Scan(&lastRuns).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last TruETL runs: %w", err)
+	}
+	for i := range lastRuns {
+		e := entry(truETLTableKey{lastRuns[i].ServiceName, lastRuns[i].SourceDbName, lastRuns[i].SourceTableName})
+		e.LastRun = &lastRuns[i]
+		e.Status = string(lastRuns[i].Status)
+	}
+
+	board := &models.TruETLRunBoard{
+		TruETLDatabaseID: truetlDatabaseID,
+		Since:            since,
+		StatusCounts:     map[string]int{},
+		Entries:          make([]models.TruETLBoardEntry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		board.Entries = append(board.Entries, *e)
+		board.StatusCounts[e.Status]++
+	}
+	sort.Slice(board.Entries, func(i, j int) bool {
+		a, b := board.Entries[i], board.Entries[j]
+		if a.ServiceName != b.ServiceName {
+			return a.ServiceName < b.ServiceName
+		}
+		if a.SourceDbName != b.SourceDbName {
+			return a.SourceDbName < b.SourceDbName
+		}
+		return a.SourceTableName < b.SourceTableName
+	})
+
+	return board, nil
+}
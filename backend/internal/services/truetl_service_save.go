@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -8,7 +9,7 @@ import (
 )
 
 // SaveDMSFields saves field changes to meta.dms_tables
-func (s *TruETLService) SaveDMSFields(truetlDatabaseID string, tableKey string, changes []map[string]interface{}) error {
+func (s *TruETLService) SaveDMSFields(ctx context.Context, truetlDatabaseID string, tableKey string, changes []map[string]interface{}) error {
 	// Get TruETL database info
 	truETLDB, err := s.GetDatabase(truetlDatabaseID)
 	if err != nil {
@@ -22,17 +23,13 @@ func (s *TruETLService) SaveDMSFields(truetlDatabaseID string, tableKey string,
 	}
 
 	// Connect to the specific database
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		conn.Host, conn.Port, conn.Username, conn.Password, truETLDB.DatabaseName, conn.SSLMode)
-
-	db, err := sql.Open("postgres", connStr)
+	db, _, err := s.connectionService.pools.get(conn, truETLDB.ConnectedDatabase())
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
-	defer db.Close()
 
 	// Start transaction
-	tx, err := db.Begin()
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -65,7 +62,7 @@ func (s *TruETLService) SaveDMSFields(truetlDatabaseID string, tableKey string,
 			// Get max id first
 			var maxID sql.NullInt64
 			maxIDQuery := `SELECT MAX(id) FROM meta.dms_tables`
-			err := tx.QueryRow(maxIDQuery).Scan(&maxID)
+			err := tx.QueryRowContext(ctx, maxIDQuery).Scan(&maxID)
 			nextID := 1
 			if err == nil && maxID.Valid {
 				nextID = int(maxID.Int64) + 1
@@ -83,7 +80,7 @@ func (s *TruETLService) SaveDMSFields(truetlDatabaseID string, tableKey string,
 			`
 			
 			var newID int
-			err = tx.QueryRow(insertQuery,
+			err = tx.QueryRowContext(ctx, insertQuery,
 				nextID,
 				getStringValue(change["service_name"]),
 				getStringValue(change["source_db_name"]),
@@ -105,7 +102,7 @@ func (s *TruETLService) SaveDMSFields(truetlDatabaseID string, tableKey string,
 			if err != nil {
 				return fmt.Errorf("failed to insert field: %w", err)
 			}
-			fmt.Printf("Inserted new field with id: %d\n", newID)
+			s.logger.DebugContext(ctx, "inserted DMS field", "database_id", truetlDatabaseID, "field_id", newID)
 
 		case "modified":
 			// Update existing record by id
@@ -142,7 +139,7 @@ func (s *TruETLService) SaveDMSFields(truetlDatabaseID string, tableKey string,
 				rowOrder = ro
 			}
 			
-			_, err := tx.Exec(updateQuery,
+			_, err := tx.ExecContext(ctx, updateQuery,
 				getStringValue(change["source_field"]),
 				getStringValue(change["source_type"]),
 				getStringValue(change["target_field"]),
@@ -168,7 +165,7 @@ func (s *TruETLService) SaveDMSFields(truetlDatabaseID string, tableKey string,
 			}
 			
 			deleteQuery := `DELETE FROM meta.dms_tables WHERE id = $1`
-			_, err := tx.Exec(deleteQuery, id)
+			_, err := tx.ExecContext(ctx, deleteQuery, id)
 			if err != nil {
 				return fmt.Errorf("failed to delete field: %w", err)
 			}
@@ -180,7 +177,7 @@ func (s *TruETLService) SaveDMSFields(truetlDatabaseID string, tableKey string,
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	fmt.Printf("Successfully saved %d field changes\n", len(changes))
+	s.logger.InfoContext(ctx, "saved DMS field changes", "database_id", truetlDatabaseID, "table_key", tableKey, "changes", len(changes))
 	return nil
 }
 
@@ -1,7 +1,7 @@
 package services
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -75,7 +75,9 @@ type SaveAllChangesRequest struct {
 // formatSQLWithArgs formats a SQL query with arguments for logging
 func formatSQLWithArgs(query string, args ...interface{}) string {
 	result := query
-	for i, arg := range args {
+	// Replace from the last placeholder so $1 does not match the start of $10
+	for i := len(args) - 1; i >= 0; i-- {
+		arg := args[i]
 		placeholder := fmt.Sprintf("$%d", i+1)
 		var value string
 		switch v := arg.(type) {
@@ -94,7 +96,10 @@ func formatSQLWithArgs(query string, args ...interface{}) string {
 }
 
 // SaveAllChanges saves all changes (services, databases, tables, fields) to meta.dms_tables in one transaction
-func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, req *SaveAllChangesRequest, logService *TruETLLogService) error {
+//
+// Statements wait at most truETLSaveLockTimeout for locks; a save that times out returns a
+// *SaveBlockedError naming the sessions that held them.
+func (s *TruETLService) SaveAllChanges(ctx context.Context, truetlDatabaseID string, userID string, req *SaveAllChangesRequest, logService *TruETLLogService) (retErr error) {
 	startTime := time.Now()
 	
 	// Prepare changes summary for logging
@@ -139,6 +144,7 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 		executionTime := int(time.Since(startTime).Milliseconds())
 		if logService != nil {
 			logService.LogSaveOperation(
+				ctx,
 				truetlDatabaseID,
 				userID,
 				models.SaveStatusError,
@@ -157,6 +163,7 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 		executionTime := int(time.Since(startTime).Milliseconds())
 		if logService != nil {
 			logService.LogSaveOperation(
+				ctx,
 				truetlDatabaseID,
 				userID,
 				models.SaveStatusError,
@@ -170,14 +177,12 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 	}
 
 	// Connect to the specific database
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		conn.Host, conn.Port, conn.Username, conn.Password, truETLDB.DatabaseName, conn.SSLMode)
-
-	db, err := sql.Open("postgres", connStr)
+	db, _, err := s.connectionService.pools.get(conn, truETLDB.ConnectedDatabase())
 	if err != nil {
 		executionTime := int(time.Since(startTime).Milliseconds())
 		if logService != nil {
 			logService.LogSaveOperation(
+				ctx,
 				truetlDatabaseID,
 				userID,
 				models.SaveStatusError,
@@ -189,14 +194,14 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 		}
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
-	defer db.Close()
 
 	// Start transaction
-	tx, err := db.Begin()
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		executionTime := int(time.Since(startTime).Milliseconds())
 		if logService != nil {
 			logService.LogSaveOperation(
+				ctx,
 				truetlDatabaseID,
 				userID,
 				models.SaveStatusError,
@@ -210,6 +215,36 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 	}
 	defer tx.Rollback()
 
+	// Bound lock waits and watch who the save waits for
+	var pid int
+	err = tx.QueryRowContext(ctx, "SELECT pg_backend_pid()").Scan(&pid)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL lock_timeout = '%dms'", truETLSaveLockTimeout.Milliseconds()))
+	}
+	if err != nil {
+		executionTime := int(time.Since(startTime).Milliseconds())
+		if logService != nil {
+			logService.LogSaveOperation(
+				ctx,
+				truetlDatabaseID,
+				userID,
+				models.SaveStatusError,
+				changesSummary,
+				"",
+				fmt.Sprintf("failed to set lock timeout: %v", err),
+				executionTime,
+			)
+		}
+		return fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+	watcher := watchLockConflicts(db, pid, truETLSavePollInterval)
+	defer func() {
+		blockers := watcher.stop()
+		if isLockTimeout(retErr) {
+			retErr = &SaveBlockedError{Blockers: blockers, Err: retErr}
+		}
+	}()
+
 	// Add BEGIN TRANSACTION to SQL log
 	sqlQueries = append(sqlQueries, "BEGIN;")
 
@@ -223,12 +258,13 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 		}
 		query := fmt.Sprintf("DELETE FROM meta.dms_tables WHERE service_name IN (%s)", strings.Join(placeholders, ", "))
 		sqlQueries = append(sqlQueries, formatSQLWithArgs(query, args...))
-		_, err = tx.Exec(query, args...)
+		_, err = tx.ExecContext(ctx, query, args...)
 		if err != nil {
 			executionTime := int(time.Since(startTime).Milliseconds())
 			sqlScript := strings.Join(sqlQueries, "\n\n")
 			if logService != nil {
 				logService.LogSaveOperation(
+					ctx,
 					truetlDatabaseID,
 					userID,
 					models.SaveStatusError,
@@ -250,12 +286,13 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 			WHERE service_name = $3
 		`
 		sqlQueries = append(sqlQueries, formatSQLWithArgs(query, service.ServiceName, service.TargetDbType, service.ServiceNameOriginal))
-		_, err = tx.Exec(query, service.ServiceName, service.TargetDbType, service.ServiceNameOriginal)
+		_, err = tx.ExecContext(ctx, query, service.ServiceName, service.TargetDbType, service.ServiceNameOriginal)
 		if err != nil {
 			executionTime := int(time.Since(startTime).Milliseconds())
 			sqlScript := strings.Join(sqlQueries, "\n\n")
 			if logService != nil {
 				logService.LogSaveOperation(
+					ctx,
 					truetlDatabaseID,
 					userID,
 					models.SaveStatusError,
@@ -279,12 +316,13 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 		}
 		query := fmt.Sprintf("DELETE FROM meta.dms_tables WHERE source_db_name IN (%s)", strings.Join(placeholders, ", "))
 		sqlQueries = append(sqlQueries, formatSQLWithArgs(query, args...))
-		_, err = tx.Exec(query, args...)
+		_, err = tx.ExecContext(ctx, query, args...)
 		if err != nil {
 			executionTime := int(time.Since(startTime).Milliseconds())
 			sqlScript := strings.Join(sqlQueries, "\n\n")
 			if logService != nil {
 				logService.LogSaveOperation(
+					ctx,
 					truetlDatabaseID,
 					userID,
 					models.SaveStatusError,
@@ -318,7 +356,7 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 			database.SourceDbType,
 			database.SourceDbNameOriginal,
 		))
-		_, err = tx.Exec(query,
+		_, err = tx.ExecContext(ctx, query,
 			database.SourceDbName,
 			database.SourceSchemaName,
 			database.TargetDbName,
@@ -331,6 +369,7 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 			sqlScript := strings.Join(sqlQueries, "\n\n")
 			if logService != nil {
 				logService.LogSaveOperation(
+					ctx,
 					truetlDatabaseID,
 					userID,
 					models.SaveStatusError,
@@ -354,12 +393,13 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 		}
 		query := fmt.Sprintf("DELETE FROM meta.dms_tables WHERE source_table_name IN (%s)", strings.Join(placeholders, ", "))
 		sqlQueries = append(sqlQueries, formatSQLWithArgs(query, args...))
-		_, err = tx.Exec(query, args...)
+		_, err = tx.ExecContext(ctx, query, args...)
 		if err != nil {
 			executionTime := int(time.Since(startTime).Milliseconds())
 			sqlScript := strings.Join(sqlQueries, "\n\n")
 			if logService != nil {
 				logService.LogSaveOperation(
+					ctx,
 					truetlDatabaseID,
 					userID,
 					models.SaveStatusError,
@@ -383,12 +423,13 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 			WHERE source_table_name = $3
 		`
 		sqlQueries = append(sqlQueries, formatSQLWithArgs(query, table.SourceTableName, table.TargetTableName, table.SourceTableNameOriginal))
-		_, err = tx.Exec(query, table.SourceTableName, table.TargetTableName, table.SourceTableNameOriginal)
+		_, err = tx.ExecContext(ctx, query, table.SourceTableName, table.TargetTableName, table.SourceTableNameOriginal)
 		if err != nil {
 			executionTime := int(time.Since(startTime).Milliseconds())
 			sqlScript := strings.Join(sqlQueries, "\n\n")
 			if logService != nil {
 				logService.LogSaveOperation(
+					ctx,
 					truetlDatabaseID,
 					userID,
 					models.SaveStatusError,
@@ -412,12 +453,13 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 		}
 		query := fmt.Sprintf("DELETE FROM meta.dms_tables WHERE id IN (%s)", strings.Join(placeholders, ", "))
 		sqlQueries = append(sqlQueries, formatSQLWithArgs(query, args...))
-		_, err = tx.Exec(query, args...)
+		_, err = tx.ExecContext(ctx, query, args...)
 		if err != nil {
 			executionTime := int(time.Since(startTime).Milliseconds())
 			sqlScript := strings.Join(sqlQueries, "\n\n")
 			if logService != nil {
 				logService.LogSaveOperation(
+					ctx,
 					truetlDatabaseID,
 					userID,
 					models.SaveStatusError,
@@ -455,7 +497,7 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 			field.RowNum,
 			field.ID,
 		))
-		_, err = tx.Exec(query,
+		_, err = tx.ExecContext(ctx, query,
 			field.SourceFieldName,
 			field.SourceFieldType,
 			field.TargetFieldName,
@@ -470,6 +512,7 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 			sqlScript := strings.Join(sqlQueries, "\n\n")
 			if logService != nil {
 				logService.LogSaveOperation(
+					ctx,
 					truetlDatabaseID,
 					userID,
 					models.SaveStatusError,
@@ -529,12 +572,13 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 		`, strings.Join(values, ", "))
 
 		sqlQueries = append(sqlQueries, formatSQLWithArgs(insertQuery, args...))
-		_, err = tx.Exec(insertQuery, args...)
+		_, err = tx.ExecContext(ctx, insertQuery, args...)
 		if err != nil {
 			executionTime := int(time.Since(startTime).Milliseconds())
 			sqlScript := strings.Join(sqlQueries, "\n\n")
 			if logService != nil {
 				logService.LogSaveOperation(
+					ctx,
 					truetlDatabaseID,
 					userID,
 					models.SaveStatusError,
@@ -558,6 +602,7 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 		// Log error
 		if logService != nil {
 			logService.LogSaveOperation(
+				ctx,
 				truetlDatabaseID,
 				userID,
 				models.SaveStatusError,
@@ -575,6 +620,7 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 	sqlScript := strings.Join(sqlQueries, "\n\n")
 	if logService != nil {
 		if err := logService.LogSaveOperation(
+			ctx,
 			truetlDatabaseID,
 			userID,
 			models.SaveStatusSuccess,
@@ -584,10 +630,10 @@ func (s *TruETLService) SaveAllChanges(truetlDatabaseID string, userID string, r
 			executionTime,
 		); err != nil {
 			// Log error but don't fail the save operation
-			fmt.Printf("WARNING: Failed to log save operation: %v\n", err)
+			s.logger.WarnContext(ctx, "failed to log save operation", "database_id", truetlDatabaseID, "error", err)
 		}
 	} else {
-		fmt.Printf("WARNING: logService is nil, cannot log save operation\n")
+		s.logger.WarnContext(ctx, "cannot log save operation without a log service", "database_id", truetlDatabaseID)
 	}
 
 	return nil
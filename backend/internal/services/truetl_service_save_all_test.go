@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+
+	"truadmin/internal/models"
+)
+
+// newTestTruETLService returns a TruETLService with a registered database whose server is a
+// go-sqlmock database, and a log service recording saves in the internal database
+func newTestTruETLService(t *testing.T) (*TruETLService, *TruETLLogService, *gorm.DB, string, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db := newTestInternalDB(t, &models.Connection{}, &models.TruETLDatabase{}, &models.TruETLSaveLog{})
+	mock := newMockPostgres(t)
+
+	conn := &models.Connection{ID: "conn-1", Name: "primary", Type: "postgres", Host: "localhost", Port: 5432, Database: "app", Username: "admin", Password: "secret", SSLMode: "disable"}
+	registration := &models.TruETLDatabase{ID: "truetl-1", ConnectionID: conn.ID, DatabaseName: "etl", DisplayName: "ETL"}
+	for _, record := range []interface{}{conn, registration} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("failed to seed %T: %v", record, err)
+		}
+	}
+
+	// go-sqlmock serves a single driver connection, so the pool must keep it
+	pools := NewConnectionPoolManager(PoolConfig{MaxOpenConns: 1, MaxIdleConns: 1}, nil)
+	t.Cleanup(pools.Close)
+	return NewTruETLService(NewConnectionService(pools, nil), nil), NewTruETLLogService(nil), db, registration.ID, mock
+}
+
+// saveAllChangesRequest decodes a SaveAllChangesRequest as the handler receives it
+func saveAllChangesRequest(t *testing.T, body string) *SaveAllChangesRequest {
+	t.Helper()
+
+	var req SaveAllChangesRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+	return &req
+}
+
+// expectSaveTransaction expects the transaction SaveAllChanges opens before applying the changes
+func expectSaveTransaction(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_backend_pid\(\)`).WillReturnRows(sqlmock.NewRows([]string{"pg_backend_pid"}).AddRow(4242))
+	mock.ExpectExec(`SET LOCAL lock_timeout`).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+// onlySaveLog returns the single save log entry written for a TruETL database
+func onlySaveLog(t *testing.T, db *gorm.DB, truetlDatabaseID string) models.TruETLSaveLog {
+	t.Helper()
+
+	var logs []models.TruETLSaveLog
+	if err := db.Where("truetl_database_id = ?", truetlDatabaseID).Find(&logs).Error; err != nil {
+		t.Fatalf("failed to read save logs: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("save logs = %d, want 1", len(logs))
+	}
+	return logs[0]
+}
+
+func TestSaveAllChangesCommitsEveryChange(t *testing.T) {
+	svc, logService, db, id, mock := newTestTruETLService(t)
+	req := saveAllChangesRequest(t, `{
+		"services": {"deleted": ["legacy"]},
+		"fields": {
+			"updated": [{"id": 7, "source_field_name": "name", "target_field_name": "full_name", "row_num": 1}],
+			"added": [{"service_name": "crm", "source_db_name": "src", "source_table_name": "people", "source_field_name": "email", "target_db_name": "dst", "target_table_name": "contacts", "target_field_name": "email", "row_num": 2}]
+		}
+	}`)
+
+	expectSaveTransaction(mock)
+	mock.ExpectExec(`DELETE FROM meta.dms_tables WHERE service_name IN \(\$1\)`).
+		WithArgs("legacy").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`UPDATE meta.dms_tables\s+SET\s+source_field_name = \$1`).
+		WithArgs("name", "", "full_name", "", "", 0, 1, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO meta.dms_tables`).
+		WithArgs("crm", "src", "", "", "people", "email", "", "dst", "", "", "contacts", "email", "", "", 0, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := svc.SaveAllChanges(context.Background(), id, "user-1", req, logService); err != nil {
+		t.Fatalf("SaveAllChanges() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	entry := onlySaveLog(t, db, id)
+	if entry.Status != models.SaveStatusSuccess {
+		t.Errorf("save log status = %q, want %q", entry.Status, models.SaveStatusSuccess)
+	}
+	if entry.ChangesSummary.Services.Deleted != 1 || entry.ChangesSummary.Fields.Updated != 1 || entry.ChangesSummary.Fields.Added != 1 {
+		t.Errorf("save log summary = %+v", entry.ChangesSummary)
+	}
+	if !strings.HasPrefix(entry.SQLScript, "BEGIN;") || !strings.HasSuffix(entry.SQLScript, "COMMIT;") ||
+		!strings.Contains(entry.SQLScript, "WHERE service_name IN ('legacy')") {
+		t.Errorf("save log script = %q", entry.SQLScript)
+	}
+}
+
+func TestSaveAllChangesRollsBackOnFailure(t *testing.T) {
+	svc, logService, db, id, mock := newTestTruETLService(t)
+	req := saveAllChangesRequest(t, `{
+		"services": {"updated": [{"service_name_original": "crm", "service_name": "sales", "target_db_type": "postgres"}]},
+		"tables": {"deleted": ["people"]},
+		"fields": {"deleted": [7]}
+	}`)
+
+	expectSaveTransaction(mock)
+	mock.ExpectExec(`UPDATE meta.dms_tables\s+SET service_name = \$1`).
+		WithArgs("sales", "postgres", "crm").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(`DELETE FROM meta.dms_tables WHERE source_table_name IN \(\$1\)`).
+		WithArgs("people").
+		WillReturnError(errors.New("permission denied for table dms_tables"))
+	// The field deletion after the failed statement never runs, and nothing is committed
+	mock.ExpectRollback()
+
+	err := svc.SaveAllChanges(context.Background(), id, "user-1", req, logService)
+	if err == nil || !strings.Contains(err.Error(), "failed to delete tables") {
+		t.Fatalf("SaveAllChanges() error = %v, want the table deletion failure", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	entry := onlySaveLog(t, db, id)
+	if entry.Status != models.SaveStatusError {
+		t.Errorf("save log status = %q, want %q", entry.Status, models.SaveStatusError)
+	}
+	if !strings.Contains(entry.ErrorMessage, "permission denied") {
+		t.Errorf("save log error = %q", entry.ErrorMessage)
+	}
+	if strings.Contains(entry.SQLScript, "COMMIT;") || strings.Contains(entry.SQLScript, "WHERE id IN") {
+		t.Errorf("save log script = %q, want it to stop at the failed statement", entry.SQLScript)
+	}
+}
+
+func TestSaveAllChangesWithNoChanges(t *testing.T) {
+	svc, logService, db, id, mock := newTestTruETLService(t)
+
+	expectSaveTransaction(mock)
+	mock.ExpectCommit()
+
+	if err := svc.SaveAllChanges(context.Background(), id, "user-1", saveAllChangesRequest(t, `{}`), logService); err != nil {
+		t.Fatalf("SaveAllChanges() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	entry := onlySaveLog(t, db, id)
+	if entry.Status != models.SaveStatusSuccess {
+		t.Errorf("save log status = %q, want %q", entry.Status, models.SaveStatusSuccess)
+	}
+	if entry.SQLScript != "BEGIN;\n\nCOMMIT;" {
+		t.Errorf("save log script = %q, want an empty transaction", entry.SQLScript)
+	}
+}
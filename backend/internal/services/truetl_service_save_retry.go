@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"truadmin/internal/models"
+)
+
+const (
+	// truETLSaveLockTimeout is how long a save statement waits for a lock on meta.dms_tables before the save fails as blocked
+	truETLSaveLockTimeout = 5 * time.Second
+	// truETLSavePollInterval is how often a running save is inspected for blocking sessions
+	truETLSavePollInterval = 250 * time.Millisecond
+)
+
+// KillAndRetrySaveRequest names the blocking sessions to terminate before the save is retried
+type KillAndRetrySaveRequest struct {
+	PIDs    []int                 `json:"pids" binding:"required"` // Blockers reported by the failed save
+	Reason  string                `json:"reason"`
+	Changes SaveAllChangesRequest `json:"changes"`
+}
+
+// ErrNoBlockersToKill is returned when a kill-and-retry names no session that still blocks meta.dms_tables
+var ErrNoBlockersToKill = errors.New("none of the given sessions holds locks on meta.dms_tables")
+
+// SaveBlockedError is returned by SaveAllChanges when a statement timed out waiting for locks held
+// by other sessions. Blockers may be empty when they ended before they could be read.
+type SaveBlockedError struct {
+	Blockers []models.LockConflict
+	Err      error
+}
+
+func (e *SaveBlockedError) Error() string {
+	if len(e.Blockers) == 0 {
+		return fmt.Sprintf("save blocked by locks on meta.dms_tables: %v", e.Err)
+	}
+	pids := make([]string, len(e.Blockers))
+	for i, blocker := range e.Blockers {
+		pids[i] = fmt.Sprint(blocker.PID)
+	}
+	return fmt.Sprintf("save blocked by sessions %s holding locks on meta.dms_tables: %v", strings.Join(pids, ", "), e.Err)
+}
+
+func (e *SaveBlockedError) Unwrap() error { return e.Err }
+
+// isLockTimeout reports whether err is a statement that gave up waiting for a lock
+func isLockTimeout(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && string(pqErr.Code) == pqLockNotAvailable
+}
+
+// KillBlockersAndRetrySave terminates the given sessions and runs the save again. Only sessions that
+// still hold locks on meta.dms_tables other than plain reads are terminated, so a stale list cannot
+// end unrelated sessions; the rest are skipped. One termination entry is returned per terminated
+// session, also when the retry fails.
+func (s *TruETLService) KillBlockersAndRetrySave(ctx context.Context, truetlDatabaseID, userID string, pids []int, req *SaveAllChangesRequest, logService *TruETLLogService) ([]*models.QueryTerminationLog, error) {
+	truETLDB, err := s.GetDatabase(truetlDatabaseID)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := s.connectionService.GetConnection(truETLDB.ConnectionID)
+	if err != nil {
+		return nil, err
+	}
+	db, d, err := s.connectionService.pools.get(conn, truETLDB.ConnectedDatabase())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	holders, err := readDMSTablesLockHolders(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*models.QueryTerminationLog
+	for _, pid := range pids {
+		holder, ok := holders[pid]
+		if !ok {
+			continue
+		}
+		entry := &models.QueryTerminationLog{
+			ConnectionID: truETLDB.ConnectionID,
+			DatabaseName: truETLDB.ConnectedDatabase(),
+			PID:          pid,
+			Query:        holder.Query,
+			DBUsername:   holder.Username,
+			UserID:       userID,
+		}
+		entries = append(entries, entry)
+		if entry.Terminated, err = d.terminateSession(ctx, db, pid); err != nil {
+			entry.ErrorMessage = err.Error()
+			return entries, fmt.Errorf("failed to terminate PID %d: %w", pid, err)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, ErrNoBlockersToKill
+	}
+
+	return entries, s.SaveAllChanges(ctx, truetlDatabaseID, userID, req, logService)
+}
+
+// readDMSTablesLockHolders returns the other sessions holding or queued for locks on meta.dms_tables
+// that conflict with writes, keyed by PID
+func readDMSTablesLockHolders(ctx context.Context, db *sql.DB) (map[int]models.LockConflict, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT a.pid,
+			COALESCE(a.usename, ''),
+			COALESCE(a.application_name, ''),
+			COALESCE(a.client_addr::text, ''),
+			COALESCE(a.state, ''),
+			COALESCE(a.query, ''),
+			a.xact_start,
+			string_agg(DISTINCT l.mode, ', ')
+		FROM pg_locks l
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.relation = 'meta.dms_tables'::regclass
+			AND l.mode <> 'AccessShareLock'
+			AND l.pid <> pg_backend_pid()
+		GROUP BY a.pid, a.usename, a.application_name, a.client_addr, a.state, a.query, a.xact_start
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read locks on meta.dms_tables: %w", err)
+	}
+	defer rows.Close()
+
+	holders := map[int]models.LockConflict{}
+	for rows.Next() {
+		holder := models.LockConflict{Relations: "meta.dms_tables"}
+		var xactStart sql.NullTime
+		if err := rows.Scan(&holder.PID, &holder.Username, &holder.ApplicationName, &holder.ClientAddr,
+			&holder.State, &holder.Query, &xactStart, &holder.LockModes); err != nil {
+			return nil, fmt.Errorf("failed to scan lock row: %w", err)
+		}
+		if xactStart.Valid {
+			holder.XactStart = &xactStart.Time
+		}
+		holders[holder.PID] = holder
+	}
+	return holders, rows.Err()
+}
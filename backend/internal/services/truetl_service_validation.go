@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"truadmin/internal/models"
+)
+
+// validationDatabase is a source or target database of the mappings, opened once per validation.
+// issue is set when the database has no saved connection or cannot be opened.
+type validationDatabase struct {
+	db    *sql.DB
+	d     dialect
+	issue *models.TruETLValidationIssue
+}
+
+// validationTableKey identifies a table of a mapped database
+type validationTableKey struct {
+	dbType, dbName, schemaName, tableName string
+}
+
+// mappingValidator caches the routed databases and table columns read during one validation
+type mappingValidator struct {
+	s         *TruETLService
+	databases map[string]*validationDatabase
+	tables    map[validationTableKey]map[string]targetColumn
+	unknown   map[validationTableKey]string // Tables missing from the catalog, with the reason
+}
+
+// ValidateMappings cross-checks the rows of meta.dms_tables against their source and target
+// databases: tables and columns must exist with the mapped types, no two rows may map to the same
+// target field and every mapping table needs an is_id row. Databases are routed by their
+// db_type and db_name tags like the readiness check does.
+func (s *TruETLService) ValidateMappings(ctx context.Context, truetlDatabaseID string, req *models.TruETLValidationRequest) (*models.TruETLValidationReport, error) {
+	tables, err := s.GetDMSTables(ctx, truetlDatabaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.TruETLValidationReport{
+		ServiceName: req.ServiceName,
+		Rows:        []models.TruETLRowValidation{},
+		Tables:      []models.TruETLTableValidation{},
+		CheckedAt:   time.Now().UTC(),
+	}
+	v := &mappingValidator{
+		s:         s,
+		databases: map[string]*validationDatabase{},
+		tables:    map[validationTableKey]map[string]targetColumn{},
+		unknown:   map[validationTableKey]string{},
+	}
+
+	var mappings []models.DMSTable
+	targetFields := map[string][]int{}
+	for _, t := range tables {
+		if req.ServiceName != "" && t.ServiceName != req.ServiceName {
+			continue
+		}
+		mappings = append(mappings, t)
+		if t.TargetFieldName != "" {
+			targetFields[targetFieldKey(t)] = append(targetFields[targetFieldKey(t)], t.ID)
+		}
+	}
+
+	hasID := map[string]bool{}
+	var mappingTables []models.TruETLTableValidation
+	for _, t := range mappings {
+		tableKey := t.ServiceName + "\x00" + t.SourceDbName + "\x00" + t.SourceTableName
+		if _, seen := hasID[tableKey]; !seen {
+			hasID[tableKey] = false
+			mappingTables = append(mappingTables, models.TruETLTableValidation{
+				ServiceName:     t.ServiceName,
+				SourceDbName:    t.SourceDbName,
+				SourceTableName: t.SourceTableName,
+			})
+		}
+		if t.IsID != 0 {
+			hasID[tableKey] = true
+		}
+
+		row := models.TruETLRowValidation{
+			ID:              t.ID,
+			ServiceName:     t.ServiceName,
+			SourceDbName:    t.SourceDbName,
+			SourceTableName: t.SourceTableName,
+			SourceFieldName: t.SourceFieldName,
+			TargetTableName: t.TargetTableName,
+			TargetFieldName: t.TargetFieldName,
+			Issues:          []models.TruETLValidationIssue{},
+		}
+
+		// A row without a source field writes its constant target_field_value
+		if t.SourceFieldName != "" || t.TargetFieldValue == "" {
+			issues, err := v.checkSide(ctx, "source", t.SourceDbType, t.SourceDbName, t.SourceSchemaName, t.SourceTableName, t.SourceFieldName, t.SourceFieldType)
+			if err != nil {
+				return nil, err
+			}
+			row.Issues = append(row.Issues, issues...)
+		}
+		issues, err := v.checkSide(ctx, "target", t.TargetDbType, t.TargetDbName, t.TargetSchemaName, t.TargetTableName, t.TargetFieldName, t.TargetFieldType)
+		if err != nil {
+			return nil, err
+		}
+		row.Issues = append(row.Issues, issues...)
+
+		if t.TargetFieldName != "" {
+			if ids := targetFields[targetFieldKey(t)]; len(ids) > 1 {
+				others := []string{}
+				for _, id := range ids {
+					if id != t.ID {
+						others = append(others, fmt.Sprint(id))
+					}
+				}
+				row.Issues = append(row.Issues, models.TruETLValidationIssue{
+					Code:     models.ValidationDuplicateTarget,
+					Severity: models.ValidationError,
+					Side:     "target",
+					Message:  fmt.Sprintf("target field %s is also mapped by rows %s", t.TargetFieldName, strings.Join(others, ", ")),
+				})
+			}
+		}
+
+		row.Valid = true
+		for _, issue := range row.Issues {
+			countValidationIssue(report, issue)
+			if issue.Severity == models.ValidationError {
+				row.Valid = false
+			}
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	for _, table := range mappingTables {
+		if hasID[table.ServiceName+"\x00"+table.SourceDbName+"\x00"+table.SourceTableName] {
+			continue
+		}
+		issue := models.TruETLValidationIssue{
+			Code:     models.ValidationMissingID,
+			Severity: models.ValidationError,
+			Message:  "no field is marked is_id, so target rows cannot be matched for updates",
+		}
+		countValidationIssue(report, issue)
+		table.Issues = []models.TruETLValidationIssue{issue}
+		report.Tables = append(report.Tables, table)
+	}
+	sort.SliceStable(report.Tables, func(i, j int) bool {
+		a, b := report.Tables[i], report.Tables[j]
+		if a.ServiceName != b.ServiceName {
+			return a.ServiceName < b.ServiceName
+		}
+		if a.SourceDbName != b.SourceDbName {
+			return a.SourceDbName < b.SourceDbName
+		}
+		return a.SourceTableName < b.SourceTableName
+	})
+
+	report.RowCount = len(report.Rows)
+	report.Valid = report.ErrorCount == 0
+	return report, nil
+}
+
+// countValidationIssue adds an issue to the report totals
+func countValidationIssue(report *models.TruETLValidationReport, issue models.TruETLValidationIssue) {
+	if issue.Severity == models.ValidationError {
+		report.ErrorCount++
+	} else {
+		report.WarningCount++
+	}
+}
+
+// targetFieldKey identifies the target field of a mapping row, ignoring case
+func targetFieldKey(t models.DMSTable) string {
+	return strings.ToLower(strings.Join([]string{t.TargetDbName, t.TargetSchemaName, t.TargetTableName, t.TargetFieldName}, "."))
+}
+
+// checkSide checks the source or target part of a mapping row against its database
+func (v *mappingValidator) checkSide(ctx context.Context, side, dbType, dbName, schemaName, tableName, fieldName, fieldType string) ([]models.TruETLValidationIssue, error) {
+	issue := func(code, format string, args ...any) []models.TruETLValidationIssue {
+		return []models.TruETLValidationIssue{{Code: code, Severity: models.ValidationError, Side: side, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	if dbName == "" || tableName == "" || fieldName == "" {
+		return issue(models.ValidationIncomplete, "%s database, table or field name is empty", side), nil
+	}
+
+	vdb, err := v.database(ctx, dbType, dbName)
+	if err != nil {
+		return nil, err
+	}
+	if vdb.issue != nil {
+		found := *vdb.issue
+		found.Side = side
+		return []models.TruETLValidationIssue{found}, nil
+	}
+
+	if schemaName == "" {
+		schemaName = vdb.d.defaultSchema(dbName)
+	}
+	key := validationTableKey{dbType: dialectForTag(dbType), dbName: dbName, schemaName: schemaName, tableName: tableName}
+	if reason, ok := v.unknown[key]; ok {
+		return issue(models.ValidationTableMissing, "%s %s", side, reason), nil
+	}
+	columns, ok := v.tables[key]
+	if !ok {
+		resolvedSchema, resolvedTable, err := resolveTable(ctx, vdb.db, vdb.d, dbName, schemaName, tableName)
+		if errors.Is(err, ErrUnknownIdentifier) {
+			v.unknown[key] = err.Error()
+			return issue(models.ValidationTableMissing, "%s %s", side, err.Error()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if columns, err = readTargetColumns(ctx, vdb.db, vdb.d, dbName, resolvedSchema, resolvedTable); err != nil {
+			return nil, err
+		}
+		v.tables[key] = columns
+	}
+	if len(columns) == 0 {
+		return issue(models.ValidationTableMissing, "%s table %s.%s does not exist in %s", side, schemaName, tableName, dbName), nil
+	}
+
+	column, ok := columns[strings.ToLower(fieldName)]
+	if !ok {
+		return issue(models.ValidationColumnMissing, "%s column %s does not exist in %s.%s", side, fieldName, schemaName, tableName), nil
+	}
+	if strings.TrimSpace(fieldType) != "" && !columnTypesMatch(fieldType, column) {
+		mismatch := issue(models.ValidationTypeMismatch, "%s column %s is %s, mapped as %s", side, fieldName, column.displayType(), fieldType)
+		// Source types are often recorded in the target's notation; the load converts them
+		if side == "source" {
+			mismatch[0].Severity = models.ValidationWarning
+		}
+		return mismatch, nil
+	}
+	return nil, nil
+}
+
+// database routes and opens a mapped database once per validation
+func (v *mappingValidator) database(ctx context.Context, dbType, dbName string) (*validationDatabase, error) {
+	key := dialectForTag(dbType) + "\x00" + dbName
+	if vdb, ok := v.databases[key]; ok {
+		return vdb, nil
+	}
+
+	vdb := &validationDatabase{}
+	conn, _, err := v.s.routeTarget(ctx, "", dbType, dbName)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		vdb.issue = &models.TruETLValidationIssue{
+			Code:     models.ValidationDatabaseUnrouted,
+			Severity: models.ValidationError,
+			Message:  fmt.Sprintf("no saved connection has database %s", dbName),
+		}
+	} else if vdb.db, vdb.d, err = v.s.connectionService.pools.get(conn, dbName); err != nil {
+		vdb.issue = &models.TruETLValidationIssue{
+			Code:     models.ValidationDatabaseUnreachable,
+			Severity: models.ValidationError,
+			Message:  fmt.Sprintf("database %s on %s is not reachable: %v", dbName, conn.Name, err),
+		}
+	}
+	v.databases[key] = vdb
+	return vdb, nil
+}
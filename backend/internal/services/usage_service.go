@@ -0,0 +1,377 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+// usageTopEndpoints is the number of endpoints listed per module in a usage report
+const usageTopEndpoints = 5
+
+// usageCounterKey identifies a counter of one endpoint on one day
+type usageCounterKey struct {
+	day      string
+	module   string
+	endpoint string
+}
+
+// usageUserKey identifies the counter of one user and module on one day
+type usageUserKey struct {
+	day    string
+	module string
+	userID string
+}
+
+// usageCounts accumulates the calls of an endpoint between flushes
+type usageCounts struct {
+	requests   int64
+	errors     int64
+	durationMs int64
+}
+
+// UsageService meters API usage per module, endpoint and day. Calls are counted in memory and
+// added to the counters in the internal database periodically, so metering costs no query per request.
+type UsageService struct {
+	db        *gorm.DB
+	retention time.Duration
+	logger    *slog.Logger
+
+	mu       sync.Mutex
+	counters map[usageCounterKey]*usageCounts
+	users    map[usageUserKey]int64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// NewUsageService creates a new usage service that flushes its counters every flushInterval.
+// Counters older than retention are removed by Prune.
+func NewUsageService(flushInterval, retention time.Duration, logger *slog.Logger) *UsageService {
+	s := &UsageService{
+		db:        database.GetDB(),
+		retention: retention,
+		counters:  map[usageCounterKey]*usageCounts{},
+		users:     map[usageUserKey]int64{},
+		stop:      make(chan struct{}),
+		logger:    logging.OrDefault(logger),
+	}
+
+	if flushInterval <= 0 {
+		flushInterval = time.Minute
+	}
+	s.wg.Add(1)
+	go s.run(flushInterval)
+
+	return s
+}
+
+// Record counts one call of an endpoint ("METHOD /api/v1/route/:param"); userID is empty for public endpoints
+func (s *UsageService) Record(endpoint, userID string, status int, duration time.Duration) {
+	if s == nil || s.db == nil {
+		return
+	}
+
+	day := time.Now().UTC().Format("2006-01-02")
+	module := usageModule(endpoint)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := usageCounterKey{day: day, module: module, endpoint: endpoint}
+	counts, ok := s.counters[key]
+	if !ok {
+		counts = &usageCounts{}
+		s.counters[key] = counts
+	}
+	counts.requests++
+	if status >= 500 {
+		counts.errors++
+	}
+	counts.durationMs += duration.Milliseconds()
+
+	if userID != "" {
+		s.users[usageUserKey{day: day, module: module, userID: userID}]++
+	}
+}
+
+// Close flushes the remaining counts and stops the flusher
+func (s *UsageService) Close() {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		close(s.stop)
+		s.wg.Wait()
+	})
+}
+
+// run flushes the counts every interval until the service is closed
+func (s *UsageService) run(interval time.Duration) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			s.flush()
+			return
+		}
+	}
+}
+
+// flush adds the counts collected since the last flush to the stored counters
+func (s *UsageService) flush() {
+	s.mu.Lock()
+	counters, users := s.counters, s.users
+	s.counters = map[usageCounterKey]*usageCounts{}
+	s.users = map[usageUserKey]int64{}
+	s.mu.Unlock()
+
+	if s.db == nil || !database.IsConnected() {
+		return
+	}
+
+	for key, counts := range counters {
+		err := s.db.Exec(`INSERT INTO usage_counters (day, module, endpoint, requests, errors, duration_ms)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (day, module, endpoint) DO UPDATE SET
+				requests = usage_counters.requests + EXCLUDED.requests,
+				errors = usage_counters.errors + EXCLUDED.errors,
+				duration_ms = usage_counters.duration_ms + EXCLUDED.duration_ms`,
+			key.day, key.module, key.endpoint, counts.requests, counts.errors, counts.durationMs).Error
+		if err != nil {
+			s.logger.Error("failed to write endpoint usage", "endpoint", key.endpoint, "error", err)
+		}
+	}
+
+	for key, requests := range users {
+		err := s.db.Exec(`INSERT INTO usage_users (day, module, user_id, requests)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT (day, module, user_id) DO UPDATE SET
+				requests = usage_users.requests + EXCLUDED.requests`,
+			key.day, key.module, key.userID, requests).Error
+		if err != nil {
+			s.logger.Error("failed to write module usage", "module", key.module, "error", err)
+		}
+	}
+}
+
+// GetReport returns module usage over the last days, most used modules first
+func (s *UsageService) GetReport(days int) (*models.UsageReport, error) {
+	from, to := usageWindow(days)
+	report := &models.UsageReport{
+		From:    from,
+		To:      to,
+		Modules: []models.ModuleUsage{},
+		Daily:   []models.DailyModuleUsage{},
+	}
+
+	var endpoints []struct {
+		Module     string
+		Endpoint   string
+		Requests   int64
+		Errors     int64
+		DurationMs int64
+	}
+	err := s.db.Model(&models.UsageCounter{}).
+		Select("module, endpoint, SUM(requests) AS requests, SUM(errors) AS errors, SUM(duration_ms) AS duration_ms").
+		Where("day >= ? AND day <= ?", from, to).
+		Group("module, endpoint").
+		Order("requests DESC").
+		Scan(&endpoints).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get endpoint usage: %w", err)
+	}
+
+	var activeUsers []struct {
+		Module      string
+		ActiveUsers int64
+	}
+	err = s.db.Model(&models.UsageUser{}).
+		Select("module, COUNT(DISTINCT user_id) AS active_users").
+		Where("day >= ? AND day <= ?", from, to).
+		Group("module").
+		Scan(&activeUsers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active users: %w", err)
+	}
+
+	modules := map[string]*models.ModuleUsage{}
+	durations := map[string]int64{}
+	for _, e := range endpoints {
+		module, ok := modules[e.Module]
+		if !ok {
+			module = &models.ModuleUsage{Module: e.Module, TopEndpoints: []models.EndpointUsage{}}
+			modules[e.Module] = module
+		}
+		module.Requests += e.Requests
+		module.Errors += e.Errors
+		durations[e.Module] += e.DurationMs
+		if len(module.TopEndpoints) < usageTopEndpoints {
+			module.TopEndpoints = append(module.TopEndpoints, models.EndpointUsage{
+				Endpoint:      e.Endpoint,
+				Requests:      e.Requests,
+				Errors:        e.Errors,
+				AvgDurationMs: averageMs(e.DurationMs, e.Requests),
+			})
+		}
+	}
+	for _, u := range activeUsers {
+		if module, ok := modules[u.Module]; ok {
+			module.ActiveUsers = u.ActiveUsers
+		}
+	}
+	for name, module := range modules {
+		module.AvgDurationMs = averageMs(durations[name], module.Requests)
+		report.Modules = append(report.Modules, *module)
+	}
+	sort.Slice(report.Modules, func(i, j int) bool {
+		if report.Modules[i].Requests != report.Modules[j].Requests {
+			return report.Modules[i].Requests > report.Modules[j].Requests
+		}
+		return report.Modules[i].Module < report.Modules[j].Module
+	})
+
+	err = s.db.Raw(`SELECT c.day, c.module, c.requests, COALESCE(u.active_users, 0) AS active_users
+		FROM (
+			SELECT day, module, SUM(requests) AS requests FROM usage_counters
+			WHERE day >= ? AND day <= ? GROUP BY day, module
+		) c
+		LEFT JOIN (
+			SELECT day, module, COUNT(*) AS active_users FROM usage_users
+			WHERE day >= ? AND day <= ? GROUP BY day, module
+		) u ON u.day = c.day AND u.module = c.module
+		ORDER BY c.day, c.module`, from, to, from, to).
+		Scan(&report.Daily).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily usage: %w", err)
+	}
+
+	return report, nil
+}
+
+// Export returns the raw counters of the last days. When anonymized, usernames are replaced by
+// pseudonyms derived from a key generated for this export, so users can be told apart but not identified.
+func (s *UsageService) Export(days int, anonymized bool) (*models.UsageExport, error) {
+	from, to := usageWindow(days)
+	export := &models.UsageExport{
+		GeneratedAt: time.Now().UTC(),
+		From:        from,
+		To:          to,
+		Anonymized:  anonymized,
+		Counters:    []models.UsageCounter{},
+		Users:       []models.UsageExportUser{},
+	}
+
+	if err := s.db.Where("day >= ? AND day <= ?", from, to).Order("day, module, endpoint").Find(&export.Counters).Error; err != nil {
+		return nil, fmt.Errorf("failed to get usage counters: %w", err)
+	}
+
+	var users []struct {
+		Day      time.Time
+		Module   string
+		UserID   string
+		Username string
+		Requests int64
+	}
+	err := s.db.Table("usage_users uu").
+		Select("uu.day, uu.module, uu.user_id, COALESCE(u.username, '') AS username, uu.requests").
+		Joins("LEFT JOIN users u ON u.id = uu.user_id").
+		Where("uu.day >= ? AND uu.day <= ?", from, to).
+		Order("uu.day, uu.module, uu.user_id").
+		Scan(&users).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage per user: %w", err)
+	}
+
+	var key []byte
+	if anonymized {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate pseudonym key: %w", err)
+		}
+	}
+	for _, u := range users {
+		name := u.Username
+		if name == "" {
+			name = u.UserID // Deleted user
+		}
+		if anonymized {
+			mac := hmac.New(sha256.New, key)
+			mac.Write([]byte(u.UserID))
+			name = "user-" + hex.EncodeToString(mac.Sum(nil))[:12]
+		}
+		export.Users = append(export.Users, models.UsageExportUser{Day: u.Day, Module: u.Module, User: name, Requests: u.Requests})
+	}
+
+	return export, nil
+}
+
+// Prune removes counters older than the retention period; it is registered as the usage_pruning job type
+func (s *UsageService) Prune() error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	cutoff := time.Now().UTC().Add(-s.retention).Format("2006-01-02")
+	if err := s.db.Where("day < ?", cutoff).Delete(&models.UsageCounter{}).Error; err != nil {
+		return fmt.Errorf("failed to prune usage counters: %w", err)
+	}
+	if err := s.db.Where("day < ?", cutoff).Delete(&models.UsageUser{}).Error; err != nil {
+		return fmt.Errorf("failed to prune usage counters: %w", err)
+	}
+	return nil
+}
+
+// usageModule derives the module of an endpoint from its route: the first path segment after
+// /api/v1, or for routes on a connection the first segment after /connections/:id
+// (e.g. "GET /api/v1/connections/:id/databases/:dbName/query" belongs to "databases")
+func usageModule(endpoint string) string {
+	route := endpoint
+	if idx := strings.Index(route, " "); idx >= 0 {
+		route = route[idx+1:]
+	}
+	route = strings.TrimPrefix(route, "/api/v1/")
+	segments := strings.Split(route, "/")
+
+	if segments[0] == "connections" && len(segments) > 2 && strings.HasPrefix(segments[1], ":") {
+		return segments[2]
+	}
+	if segments[0] == "" {
+		return "other"
+	}
+	return segments[0]
+}
+
+// usageWindow returns the first and last day of a report over the last days, including today
+func usageWindow(days int) (time.Time, time.Time) {
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	return to.AddDate(0, 0, -(days - 1)), to
+}
+
+// averageMs divides a total duration by a count, rounded to a tenth of a millisecond
+func averageMs(totalMs, count int64) float64 {
+	if count == 0 {
+		return 0
+	}
+	return float64(totalMs*10/count) / 10
+}
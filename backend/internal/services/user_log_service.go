@@ -1,32 +1,37 @@
 package services
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"time"
 
 	"gorm.io/gorm"
 
 	"truadmin/internal/database"
+	"truadmin/internal/logging"
 	"truadmin/internal/models"
 )
 
 // UserLogService handles logging of User operations
 type UserLogService struct {
-	db *gorm.DB
+	db    *gorm.DB
+	audit *AuditService
 }
 
-// NewUserLogService creates a new User log service
-func NewUserLogService() *UserLogService {
+// NewUserLogService creates a new User log service; operations are also shipped to the audit sinks
+func NewUserLogService(audit *AuditService) *UserLogService {
 	return &UserLogService{
-		db: database.GetDB(),
+		db:    database.GetDB(),
+		audit: audit,
 	}
 }
 
 // LogOperation logs a user operation
 func (s *UserLogService) LogOperation(
+	ctx context.Context,
 	userID string,
 	changedByID string,
-	operation string, // "create", "delete", "change_password", "block", "unblock"
+	operation string, // "create", "delete", "change_password", "block", "unblock", "login_failed", "lockout", "unlock"
 	status models.UserSaveLogStatus,
 	errorMessage string,
 ) error {
@@ -36,52 +41,64 @@ func (s *UserLogService) LogOperation(
 		Operation:    operation,
 		Status:       status,
 		ErrorMessage: errorMessage,
+		RequestID:    logging.RequestID(ctx),
 		CreatedAt:    time.Now(),
 	}
 
+	s.audit.Record(models.AuditEvent{
+		Source:    "user",
+		Action:    operation,
+		Status:    models.AuditEventStatus(status),
+		ActorID:   changedByID,
+		TargetID:  userID,
+		Message:   errorMessage,
+		RequestID: logging.RequestID(ctx),
+	})
+
 	if err := s.db.Create(&logEntry).Error; err != nil {
-		log.Printf("ERROR: Failed to log User operation: %v", err)
-		log.Printf("  userID: %s, changedByID: %s, operation: %s, status: %s", userID, changedByID, operation, status)
+		slog.ErrorContext(ctx, "failed to log user operation", "user_id", userID, "changed_by_id", changedByID, "operation", operation, "status", status, "error", err)
 		return err
 	}
 
-	log.Printf("✅ Logged User operation: userID=%s, changedByID=%s, operation=%s, status=%s",
-		userID, changedByID, operation, status)
+	slog.InfoContext(ctx, "logged user operation", "user_id", userID, "changed_by_id", changedByID, "operation", operation, "status", status)
 	return nil
 }
 
 // GetLogs retrieves logs for users
-func (s *UserLogService) GetLogs(limit int) ([]models.UserSaveLog, error) {
+func (s *UserLogService) GetLogs(page Page) ([]models.UserSaveLog, int64, error) {
 	var logs []models.UserSaveLog
 
 	query := s.db.Order("created_at DESC")
 
-	if limit > 0 {
-		query = query.Limit(limit)
-	}
-
-	if err := query.Find(&logs).Error; err != nil {
-		return nil, err
+	total, err := findPage(query, page, &logs)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return logs, nil
+	return logs, total, nil
 }
 
 // GetLogsByUser retrieves logs for a specific user
-func (s *UserLogService) GetLogsByUser(userID string, limit int) ([]models.UserSaveLog, error) {
+func (s *UserLogService) GetLogsByUser(userID string, page Page) ([]models.UserSaveLog, int64, error) {
 	var logs []models.UserSaveLog
 
 	query := s.db.Where("user_id = ?", userID).
 		Order("created_at DESC")
 
-	if limit > 0 {
-		query = query.Limit(limit)
+	total, err := findPage(query, page, &logs)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if err := query.Find(&logs).Error; err != nil {
-		return nil, err
-	}
-
-	return logs, nil
+	return logs, total, nil
 }
 
+// ExportLogs streams the user logs matching the filter, optionally of changes made by one user, oldest first.
+// The filter's user is the user that was changed.
+func (s *UserLogService) ExportLogs(changedByID string, filter models.LogExportFilter, fn func(*models.UserSaveLog) error) error {
+	query := s.db
+	if changedByID != "" {
+		query = query.Where("changed_by_id = ?", changedByID)
+	}
+	return streamLogs(query, filter, "operation", fn)
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"truadmin/internal/database"
+	"truadmin/internal/logging"
+	"truadmin/internal/models"
+)
+
+var (
+	// ErrInvalidWidgetToken is returned when a widget token request is invalid
+	ErrInvalidWidgetToken = errors.New("invalid widget token")
+	// ErrWidgetUnauthorized is returned for unknown, revoked or expired widget tokens and sessions
+	ErrWidgetUnauthorized = errors.New("widget token or session is not valid")
+	// ErrWidgetOriginNotAllowed is returned when the widget is embedded by a page outside the allowed origins
+	ErrWidgetOriginNotAllowed = errors.New("widget is not allowed on this site")
+	// ErrWidgetRateLimited is returned when a widget token or visitor exceeds its rate limit
+	ErrWidgetRateLimited = errors.New("too many address checks, try again in a minute")
+)
+
+// widgetTokenPrefix marks widget tokens so they are recognizable when pasted or leaked
+const widgetTokenPrefix = "wgt_"
+
+// widgetRateWindow is the window of the widget rate limits
+const widgetRateWindow = time.Minute
+
+// WidgetService issues widget tokens and serves address checks from the embeddable widget.
+// Loading the widget page with a token opens a short-lived signed session; checks are only
+// accepted with a session, rate limited per token and per visitor IP.
+type WidgetService struct {
+	db                *gorm.DB
+	hohAddressService *HohAddressService
+	secret            []byte
+	sessionTTL        time.Duration
+	defaultRateLimit  int
+	ipRateLimit       int
+	store             SharedStore // Rate limit counters, shared by the replicas when it is Redis
+	logger            *slog.Logger
+}
+
+// NewWidgetService creates a new widget service. Sessions are signed with a key derived from secret.
+// defaultRateLimit applies to tokens without their own limit and ipRateLimit to each visitor IP;
+// both count checks per minute, and zero disables the limit.
+func NewWidgetService(hohAddressService *HohAddressService, store SharedStore, secret string, sessionTTL time.Duration, defaultRateLimit, ipRateLimit int, logger *slog.Logger) *WidgetService {
+	if sessionTTL <= 0 {
+		sessionTTL = 30 * time.Minute
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("truadmin widget sessions"))
+	return &WidgetService{
+		db:                database.GetDB(),
+		hohAddressService: hohAddressService,
+		secret:            mac.Sum(nil),
+		sessionTTL:        sessionTTL,
+		defaultRateLimit:  defaultRateLimit,
+		ipRateLimit:       ipRateLimit,
+		store:             store,
+		logger:            logging.OrDefault(logger),
+	}
+}
+
+// GetTokens returns all widget tokens, newest first
+func (s *WidgetService) GetTokens() ([]models.WidgetToken, error) {
+	tokens := []models.WidgetToken{}
+	if err := s.db.Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, fmt.Errorf("failed to get widget tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// CreateToken issues a widget token; the token is only returned here
+func (s *WidgetService) CreateToken(req *models.WidgetTokenRequest, userID string) (*models.IssuedWidgetToken, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidWidgetToken)
+	}
+	if req.RateLimitPerMinute < 0 {
+		return nil, fmt.Errorf("%w: rate_limit_per_minute must not be negative", ErrInvalidWidgetToken)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidWidgetToken)
+	}
+
+	origins := models.StringList{}
+	for _, raw := range req.AllowedOrigins {
+		origin, err := normalizeWidgetOrigin(raw)
+		if err != nil {
+			return nil, err
+		}
+		origins = append(origins, origin)
+	}
+
+	if _, err := s.hohAddressService.GetDatabase(req.HohAddressDatabaseID); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate widget token: %w", err)
+	}
+	token := widgetTokenPrefix + hex.EncodeToString(secret)
+
+	widgetToken := models.WidgetToken{
+		ID:                   uuid.New().String(),
+		Name:                 name,
+		HohAddressDatabaseID: req.HohAddressDatabaseID,
+		TokenHash:            hashWidgetToken(token),
+		TokenPrefix:          token[:len(widgetTokenPrefix)+8],
+		AllowedOrigins:       origins,
+		RateLimitPerMinute:   req.RateLimitPerMinute,
+		CreatedBy:            userID,
+		ExpiresAt:            req.ExpiresAt,
+	}
+	if err := s.db.Create(&widgetToken).Error; err != nil {
+		return nil, fmt.Errorf("failed to create widget token: %w", err)
+	}
+
+	return &models.IssuedWidgetToken{
+		WidgetToken: widgetToken,
+		Token:       token,
+		EmbedPath:   "/api/v1/widget/check-address?token=" + url.QueryEscape(token),
+	}, nil
+}
+
+// RevokeToken revokes a widget token; open sessions stop working with their next check
+func (s *WidgetService) RevokeToken(id string) error {
+	result := s.db.Model(&models.WidgetToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke widget token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := s.db.Model(&models.WidgetToken{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to revoke widget token: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("widget token not found")
+		}
+	}
+	return nil
+}
+
+// OpenSession checks a widget token when the widget page loads and opens a session for its checks.
+// referer is the page embedding the widget as reported by the browser; when present, its origin
+// must be allowed. Browsers also enforce the allowed origins through the page's frame-ancestors.
+func (s *WidgetService) OpenSession(token, referer, clientIP string) (*models.WidgetSession, error) {
+	if !s.allow("ip:"+clientIP, s.ipRateLimit, time.Now()) {
+		return nil, ErrWidgetRateLimited
+	}
+
+	var widgetToken models.WidgetToken
+	if err := s.db.First(&widgetToken, "token_hash = ?", hashWidgetToken(token)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWidgetUnauthorized
+		}
+		return nil, fmt.Errorf("failed to get widget token: %w", err)
+	}
+	if !widgetTokenActive(&widgetToken) {
+		return nil, ErrWidgetUnauthorized
+	}
+
+	if referer != "" {
+		if origin, err := normalizeWidgetOrigin(referer); err == nil && !widgetOriginAllowed(&widgetToken, origin) {
+			return nil, ErrWidgetOriginNotAllowed
+		}
+	}
+
+	now := time.Now().UTC()
+	s.db.Model(&widgetToken).UpdateColumn("last_used_at", now)
+
+	expiresAt := now.Add(s.sessionTTL)
+	return &models.WidgetSession{
+		Token:     &widgetToken,
+		Session:   s.signSession(widgetToken.ID, expiresAt),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Check runs an address check for a widget session against the token's HohAddress database
+func (s *WidgetService) Check(ctx context.Context, session, clientIP string, req *models.WidgetCheckRequest) (*AddressCheckResult, error) {
+	tokenID, err := s.verifySession(session, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var widgetToken models.WidgetToken
+	if err := s.db.First(&widgetToken, "id = ?", tokenID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWidgetUnauthorized
+		}
+		return nil, fmt.Errorf("failed to get widget token: %w", err)
+	}
+	if !widgetTokenActive(&widgetToken) {
+		return nil, ErrWidgetUnauthorized
+	}
+
+	now := time.Now()
+	if !s.allow("ip:"+clientIP, s.ipRateLimit, now) {
+		return nil, ErrWidgetRateLimited
+	}
+	limit := widgetToken.RateLimitPerMinute
+	if limit == 0 {
+		limit = s.defaultRateLimit
+	}
+	if !s.allow("token:"+widgetToken.ID, limit, now) {
+		return nil, ErrWidgetRateLimited
+	}
+
+	return s.hohAddressService.CheckAddressStatus(ctx, widgetToken.HohAddressDatabaseID, req.Address1, req.Address2, req.City, req.State, req.Zip, req.ProgramType)
+}
+
+// signSession creates a session for a token: "<token id>.<expiry unix>.<signature>"
+func (s *WidgetService) signSession(tokenID string, expiresAt time.Time) string {
+	payload := tokenID + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifySession checks the signature and expiry of a session and returns its token ID
+func (s *WidgetService) verifySession(session string, now time.Time) (string, error) {
+	parts := strings.Split(session, ".")
+	if len(parts) != 3 {
+		return "", ErrWidgetUnauthorized
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", ErrWidgetUnauthorized
+	}
+	expected := s.signSession(parts[0], time.Unix(expires, 0))
+	if !hmac.Equal([]byte(session), []byte(expected)) || now.Unix() >= expires {
+		return "", ErrWidgetUnauthorized
+	}
+	return parts[0], nil
+}
+
+// hashWidgetToken returns the stored form of a widget token
+func hashWidgetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// widgetTokenActive reports whether a widget token is neither revoked nor expired
+func widgetTokenActive(token *models.WidgetToken) bool {
+	if token.RevokedAt != nil {
+		return false
+	}
+	return token.ExpiresAt == nil || token.ExpiresAt.After(time.Now())
+}
+
+// widgetOriginAllowed reports whether an origin may embed the widget of a token
+func widgetOriginAllowed(token *models.WidgetToken, origin string) bool {
+	for _, allowed := range token.AllowedOrigins {
+		if allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeWidgetOrigin reduces a URL to its origin (scheme://host[:port]) and rejects anything
+// that is not an http or https URL
+func normalizeWidgetOrigin(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return "", fmt.Errorf("%w: %q is not an http(s) origin", ErrInvalidWidgetToken, raw)
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
+
+// allow counts an event for key in the current one-minute window and reports whether it is within
+// limit; a limit of zero allows everything. When the store fails the event is allowed.
+func (s *WidgetService) allow(key string, limit int, now time.Time) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	window := now.Truncate(widgetRateWindow).Unix()
+	count, err := s.store.Incr(fmt.Sprintf("ratelimit:widget:%s:%d", key, window), widgetRateWindow)
+	if err != nil {
+		s.logger.Warn("widget rate limit unavailable", "key", key, "error", err)
+		return true
+	}
+	return count <= int64(limit)
+}
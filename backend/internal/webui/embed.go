@@ -0,0 +1,25 @@
+//go:build embedfrontend
+
+package webui
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// build holds the frontend build copied to internal/webui/build before compiling:
+//
+//	cp -r ../frontend/build internal/webui/build
+//	go build -tags embedfrontend ./cmd/server
+//
+//go:embed all:build
+var build embed.FS
+
+// embeddedBuild returns the embedded frontend build
+func embeddedBuild() fs.FS {
+	sub, err := fs.Sub(build, "build")
+	if err != nil {
+		return nil
+	}
+	return sub
+}
@@ -0,0 +1,10 @@
+//go:build !embedfrontend
+
+package webui
+
+import "io/fs"
+
+// embeddedBuild returns nil because the binary was built without the embedfrontend tag
+func embeddedBuild() fs.FS {
+	return nil
+}
@@ -0,0 +1,42 @@
+// Package webui provides the frontend build served by the backend, either
+// embedded into the binary or read from a directory on disk.
+package webui
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DefaultBuildPath is the frontend build directory used in development (relative to the backend directory)
+var DefaultBuildPath = filepath.Join("..", "frontend", "build")
+
+// Frontend is a resolved frontend build
+type Frontend struct {
+	FS     fs.FS
+	Source string // "embedded" or the directory path
+}
+
+// Resolve selects the frontend build to serve.
+// An explicit overridePath always wins; otherwise the embedded build is used when the binary
+// was built with the embedfrontend tag, falling back to DefaultBuildPath.
+func Resolve(overridePath string) *Frontend {
+	if overridePath != "" {
+		return &Frontend{FS: os.DirFS(overridePath), Source: overridePath}
+	}
+	if embedded := embeddedBuild(); embedded != nil {
+		return &Frontend{FS: embedded, Source: "embedded"}
+	}
+	return &Frontend{FS: os.DirFS(DefaultBuildPath), Source: DefaultBuildPath}
+}
+
+// Available reports whether the build contains index.html
+func (f *Frontend) Available() bool {
+	_, err := fs.Stat(f.FS, "index.html")
+	return err == nil
+}
+
+// Index returns the contents of index.html
+func (f *Frontend) Index() ([]byte, error) {
+	return fs.ReadFile(f.FS, "index.html")
+}
@@ -1,6 +1,6 @@
 import React, { useState, useEffect, useCallback } from 'react';
 import { FiCheckCircle, FiPlay } from 'react-icons/fi';
-import { HohAddressDatabase, apiService } from '../../services/api';
+import { FilterExpression, HohAddressDatabase, apiService } from '../../services/api';
 import SearchBar from './SearchBar';
 import WhereConstructor from '../WhereConstructor/WhereConstructor';
 import AddressCheckModal from './AddressCheckModal';
@@ -18,7 +18,7 @@ const StatusListTable: React.FC<StatusListTableProps> = ({ hohAddressDatabase })
   const [columns, setColumns] = useState<string[]>([]);
   const [total, setTotal] = useState(0);
   const [search, setSearch] = useState<string>('');
-  const [where, setWhere] = useState<string>('');
+  const [where, setWhere] = useState<FilterExpression | undefined>(undefined);
   const [showConstructor, setShowConstructor] = useState(false);
   const [page, setPage] = useState(1);
   const [showCheckModal, setShowCheckModal] = useState(false);
@@ -41,7 +41,7 @@ const StatusListTable: React.FC<StatusListTableProps> = ({ hohAddressDatabase })
 
   // Load data
   const loadData = useCallback(
-    async (customWhere?: string, customSearch?: string) => {
+    async (customWhere?: FilterExpression | null, customSearch?: string) => {
       try {
         setLoading(true);
         setError(null);
@@ -57,16 +57,15 @@ const StatusListTable: React.FC<StatusListTableProps> = ({ hohAddressDatabase })
         const searchValue = customSearch !== undefined ? customSearch : search;
         const filters = buildFilters(searchValue, columnsList);
 
-        // Use customWhere if provided, otherwise use where from state
-        const whereClause = (customWhere !== undefined ? customWhere : where).trim() || undefined;
-        console.log('Loading status list with WHERE:', whereClause, 'and search:', searchValue);
+        // Use customWhere if provided (null clears it), otherwise use where from state
+        const filter = customWhere !== undefined ? customWhere || undefined : where;
 
         const result = await apiService.getHohAddressStatusList(
           hohAddressDatabase.id,
           filters,
           pageSize,
           (page - 1) * pageSize,
-          whereClause
+          filter
         );
         setData(result.data);
         setTotal(result.totalCount);
@@ -138,7 +137,7 @@ const StatusListTable: React.FC<StatusListTableProps> = ({ hohAddressDatabase })
           setWhere(whereValue);
           setPage(1);
           // Pass the value directly to avoid state update delay
-          loadData(whereValue);
+          loadData(whereValue || null);
         }}
         showConstructor={showConstructor}
         onToggleConstructor={setShowConstructor}
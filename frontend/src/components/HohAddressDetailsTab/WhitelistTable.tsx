@@ -1,6 +1,6 @@
 import React, { useState, useEffect, useCallback } from 'react';
 import { FiEdit, FiTrash2, FiArrowUp, FiArrowDown, FiPlus } from 'react-icons/fi';
-import { HohAddressDatabase, apiService } from '../../services/api';
+import { FilterExpression, HohAddressDatabase, apiService } from '../../services/api';
 import SearchBar from './SearchBar';
 import WhereConstructor from '../WhereConstructor/WhereConstructor';
 import RowEditModal from './RowEditModal';
@@ -17,7 +17,7 @@ const WhitelistTable: React.FC<WhitelistTableProps> = ({ hohAddressDatabase }) =
   const [columns, setColumns] = useState<string[]>([]);
   const [total, setTotal] = useState(0);
   const [search, setSearch] = useState<string>('');
-  const [where, setWhere] = useState<string>('');
+  const [where, setWhere] = useState<FilterExpression | undefined>(undefined);
   const [showConstructor, setShowConstructor] = useState(false);
   const [sortBy, setSortBy] = useState<string>('');
   const [sortOrder, setSortOrder] = useState<'ASC' | 'DESC'>('ASC');
@@ -48,7 +48,7 @@ const WhitelistTable: React.FC<WhitelistTableProps> = ({ hohAddressDatabase }) =
 
   // Load data
   const loadData = useCallback(
-    async (customWhere?: string, customSearch?: string) => {
+    async (customWhere?: FilterExpression | null, customSearch?: string) => {
       try {
         setLoading(true);
         setError(null);
@@ -64,9 +64,8 @@ const WhitelistTable: React.FC<WhitelistTableProps> = ({ hohAddressDatabase }) =
         const searchValue = customSearch !== undefined ? customSearch : search;
         const filters = buildFilters(searchValue, columnsList);
 
-        // Use customWhere if provided, otherwise use where from state
-        const whereClause = (customWhere !== undefined ? customWhere : where).trim() || undefined;
-        console.log('Loading whitelist with WHERE:', whereClause, 'and search:', searchValue);
+        // Use customWhere if provided (null clears it), otherwise use where from state
+        const filter = customWhere !== undefined ? customWhere || undefined : where;
 
         const result = await apiService.getHohAddressWhitelist(
           hohAddressDatabase.id,
@@ -75,7 +74,7 @@ const WhitelistTable: React.FC<WhitelistTableProps> = ({ hohAddressDatabase }) =
           sortOrder,
           pageSize,
           (page - 1) * pageSize,
-          whereClause
+          filter
         );
         setData(result.data || []);
         setTotal(result.totalCount || 0);
@@ -166,7 +165,7 @@ const WhitelistTable: React.FC<WhitelistTableProps> = ({ hohAddressDatabase }) =
             setWhere(whereValue);
             setPage(1);
             // Pass the value directly to avoid state update delay
-            loadData(whereValue);
+            loadData(whereValue || null);
           }}
           showConstructor={showConstructor}
           onToggleConstructor={setShowConstructor}
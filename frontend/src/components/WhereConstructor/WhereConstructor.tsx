@@ -1,15 +1,64 @@
-import React, { useState, useEffect, useRef } from 'react';
+import React, { useState, useEffect } from 'react';
+import { FilterExpression, FilterOperator } from '../../services/api';
 import './WhereConstructor.css';
 
 interface WhereConstructorProps {
   columns: string[];
-  value: string;
-  onChange: (value: string) => void;
-  onApply: (whereValue: string) => void;
+  value?: FilterExpression;
+  onChange: (value?: FilterExpression) => void;
+  onApply: (whereValue?: FilterExpression) => void;
   showConstructor: boolean;
   onToggleConstructor: (show: boolean) => void;
 }
 
+interface ConditionRow {
+  field: string;
+  op: FilterOperator;
+  value: string;
+}
+
+const operators: { value: FilterOperator; label: string }[] = [
+  { value: 'eq', label: '=' },
+  { value: 'ne', label: '<>' },
+  { value: 'contains', label: 'contains' },
+  { value: 'starts_with', label: 'starts with' },
+  { value: 'ends_with', label: 'ends with' },
+  { value: 'gt', label: '>' },
+  { value: 'gte', label: '>=' },
+  { value: 'lt', label: '<' },
+  { value: 'lte', label: '<=' },
+  { value: 'in', label: 'in list' },
+  { value: 'not_in', label: 'not in list' },
+  { value: 'is_null', label: 'is empty' },
+  { value: 'is_not_null', label: 'is not empty' },
+];
+
+const listOperators: FilterOperator[] = ['in', 'not_in'];
+const valuelessOperators: FilterOperator[] = ['is_null', 'is_not_null'];
+
+// Convert a filter expression built by this constructor back into condition rows
+const toRows = (
+  expression?: FilterExpression
+): { match: 'and' | 'or'; rows: ConditionRow[] } => {
+  if (!expression) {
+    return { match: 'and', rows: [] };
+  }
+  const match = expression.or ? 'or' : 'and';
+  const conditions = expression.or || expression.and || [expression];
+  const rows = conditions
+    .filter((condition) => condition.field && condition.op)
+    .map((condition) => ({
+      field: condition.field as string,
+      op: condition.op as FilterOperator,
+      value: Array.isArray(condition.value)
+        ? condition.value.join(', ')
+        : condition.value !== undefined
+          ? String(condition.value)
+          : '',
+    }));
+  return { match, rows };
+};
+
 const WhereConstructor: React.FC<WhereConstructorProps> = ({
   columns,
   value,
@@ -18,203 +67,88 @@ const WhereConstructor: React.FC<WhereConstructorProps> = ({
   showConstructor,
   onToggleConstructor,
 }) => {
-  const [localValue, setLocalValue] = useState(value);
+  const [match, setMatch] = useState<'and' | 'or'>('and');
+  const [rows, setRows] = useState<ConditionRow[]>([]);
   const [validationError, setValidationError] = useState<string>('');
-  const textareaRef = useRef<HTMLTextAreaElement>(null);
-  const validationTimeoutRef = useRef<NodeJS.Timeout | null>(null);
 
-  // Sync local value with prop value when it changes externally
+  // Sync local rows with prop value when it changes externally
   useEffect(() => {
-    setLocalValue(value);
-    validateWhereClause(value);
-
-    // Cleanup timeout on unmount
-    return () => {
-      if (validationTimeoutRef.current) {
-        clearTimeout(validationTimeoutRef.current);
-      }
-    };
+    const parsed = toRows(value);
+    setMatch(parsed.match);
+    setRows(parsed.rows);
+    setValidationError('');
   }, [value]);
 
-  // Validate WHERE clause
-  const validateWhereClause = (whereClause: string): boolean => {
-    const trimmed = whereClause.trim();
-
-    // Empty is valid (will be ignored)
-    if (!trimmed) {
-      setValidationError('');
-      return true;
-    }
-
-    // Check parentheses balance
-    const openParens = (trimmed.match(/\(/g) || []).length;
-    const closeParens = (trimmed.match(/\)/g) || []).length;
-    if (openParens !== closeParens) {
-      setValidationError(`Unbalanced parentheses: ${openParens} opening, ${closeParens} closing`);
-      return false;
-    }
-
-    // Check for SQL injection patterns (basic check)
-    const dangerousPatterns = [
-      /;\s*(drop|delete|truncate|alter|create|insert|update|exec|execute)/i,
-      /--/,
-      /\/\*/,
-      /\*\//,
-      /union\s+select/i,
-    ];
-
-    for (const pattern of dangerousPatterns) {
-      if (pattern.test(trimmed)) {
-        setValidationError('Potentially dangerous SQL detected. Only WHERE conditions are allowed.');
-        return false;
+  // Build the filter expression from the rows; values are sent as parameters, never as SQL
+  const buildExpression = (): FilterExpression | undefined | null => {
+    const conditions: FilterExpression[] = [];
+    for (const row of rows) {
+      if (!row.field) {
+        setValidationError('Select a field for every condition');
+        return null;
       }
-    }
-
-    // Check for valid field names (only allow columns from the list)
-    // First, remove string literals (single and double quotes) to avoid matching inside them
-    let sqlWithoutStrings = trimmed;
-    // Remove single-quoted strings
-    sqlWithoutStrings = sqlWithoutStrings.replace(/'[^']*'/g, '');
-    // Remove double-quoted strings
-    sqlWithoutStrings = sqlWithoutStrings.replace(/"[^"]*"/g, '');
-
-    // Extract field names from the WHERE clause (outside of string literals)
-    const fieldPattern = /\b([a-z_][a-z0-9_]*)\b/gi;
-    const matches = sqlWithoutStrings.matchAll(fieldPattern);
-    const usedFields = new Set<string>();
-
-    for (const match of matches) {
-      const fieldName = match[1].toLowerCase();
-      // Skip SQL keywords and operators
-      const sqlKeywords = [
-        'and',
-        'or',
-        'not',
-        'in',
-        'like',
-        'ilike',
-        'is',
-        'null',
-        'true',
-        'false',
-        'between',
-        'exists',
-        'cast',
-        'as',
-        'text',
-      ];
-      if (!sqlKeywords.includes(fieldName)) {
-        usedFields.add(fieldName);
+      if (valuelessOperators.includes(row.op)) {
+        conditions.push({ field: row.field, op: row.op });
+        continue;
       }
-    }
-
-    // Check if all used fields are in the allowed columns list
-    const allowedFields = new Set(columns.map((col) => col.toLowerCase()));
-    const invalidFields: string[] = [];
-
-    for (const field of usedFields) {
-      if (!allowedFields.has(field)) {
-        invalidFields.push(field);
+      if (listOperators.includes(row.op)) {
+        const values = row.value
+          .split(',')
+          .map((item) => item.trim())
+          .filter((item) => item !== '');
+        if (values.length === 0) {
+          setValidationError(`Enter a comma-separated list of values for ${row.field}`);
+          return null;
+        }
+        conditions.push({ field: row.field, op: row.op, value: values });
+        continue;
       }
-    }
-
-    if (invalidFields.length > 0) {
-      setValidationError(
-        `Invalid field(s): ${invalidFields.join(', ')}. Only columns from the table are allowed.`
-      );
-      return false;
-    }
-
-    // Check for proper operator usage
-    // Operators should not be at the start or end (unless it's a unary operator)
-    const trimmedEnd = trimmed.trim();
-    if (/^(AND|OR)$/i.test(trimmedEnd)) {
-      setValidationError('WHERE clause cannot start or end with AND/OR');
-      return false;
-    }
-
-    // Check for consecutive operators
-    if (/(AND|OR)\s+(AND|OR)/i.test(trimmed)) {
-      setValidationError('Cannot have consecutive AND/OR operators');
-      return false;
-    }
-
-    // Check for operator without value (e.g., "field = " or "field ILIKE ")
-    if (/=\s*$|ILIKE\s*$|LIKE\s*$|>\s*$|<\s*$|>=\s*$|<=\s*$/i.test(trimmed)) {
-      setValidationError('Operator is missing a value');
-      return false;
+      if (row.value === '') {
+        setValidationError(`Operator is missing a value for ${row.field}`);
+        return null;
+      }
+      conditions.push({ field: row.field, op: row.op, value: row.value });
     }
 
     setValidationError('');
-    return true;
+    if (conditions.length === 0) {
+      return undefined;
+    }
+    return match === 'or' ? { or: conditions } : { and: conditions };
   };
 
-  const insertField = (fieldName: string) => {
-    const textarea = textareaRef.current;
-    if (!textarea) return;
-
-    const start = textarea.selectionStart;
-    const end = textarea.selectionEnd;
-    const currentValue = localValue;
-    const newValue = currentValue.substring(0, start) + fieldName + currentValue.substring(end);
-
-    setLocalValue(newValue);
-
-    // Set cursor position after inserted text
-    setTimeout(() => {
-      if (textarea) {
-        const newCursorPos = start + fieldName.length;
-        textarea.focus();
-        textarea.setSelectionRange(newCursorPos, newCursorPos);
-      }
-    }, 0);
+  const updateRow = (index: number, changes: Partial<ConditionRow>) => {
+    setRows((prev) => prev.map((row, i) => (i === index ? { ...row, ...changes } : row)));
   };
 
-  const insertOperator = (operator: string) => {
-    const textarea = textareaRef.current;
-    if (!textarea) return;
-
-    const start = textarea.selectionStart;
-    const end = textarea.selectionEnd;
-    const currentValue = localValue;
-
-    // For parentheses, don't add spaces
-    const insertText = operator === '(' || operator === ')' ? operator : ` ${operator} `;
-
-    const newValue = currentValue.substring(0, start) + insertText + currentValue.substring(end);
-
-    setLocalValue(newValue);
+  const addCondition = (field?: string) => {
+    setRows((prev) => [...prev, { field: field || columns[0] || '', op: 'eq', value: '' }]);
+  };
 
-    setTimeout(() => {
-      if (textarea) {
-        const newCursorPos = start + insertText.length;
-        textarea.focus();
-        textarea.setSelectionRange(newCursorPos, newCursorPos);
-      }
-    }, 0);
+  const removeCondition = (index: number) => {
+    setRows((prev) => prev.filter((_, i) => i !== index));
   };
 
   const handleApply = (e: React.MouseEvent) => {
     e.preventDefault();
     e.stopPropagation();
-    const trimmedValue = localValue.trim();
 
-    // Validate before applying
-    if (!validateWhereClause(trimmedValue)) {
+    const expression = buildExpression();
+    if (expression === null) {
       return; // Don't apply if validation fails
     }
 
-    console.log('WhereConstructor handleApply, value:', trimmedValue);
-    onChange(trimmedValue);
-    onApply(trimmedValue);
+    onChange(expression);
+    onApply(expression);
   };
 
   const handleClear = (e: React.MouseEvent) => {
     e.preventDefault();
     e.stopPropagation();
-    setLocalValue('');
-    onChange('');
-    onApply('');
+    setRows([]);
+    setValidationError('');
+    onChange(undefined);
+    onApply(undefined);
   };
 
   return (
@@ -231,105 +165,83 @@ const WhereConstructor: React.FC<WhereConstructorProps> = ({
         <div className="where-constructor-panel">
           <div className="where-constructor-editor">
             <div className="where-constructor-toolbar">
-              <span className="where-constructor-label">Operators:</span>
+              <span className="where-constructor-label">Match:</span>
               <button
                 type="button"
-                onClick={() => insertOperator('(')}
-                className="btn btn-sm btn-outline-secondary"
-              >
-                (
-              </button>
-              <button
-                type="button"
-                onClick={() => insertOperator(')')}
-                className="btn btn-sm btn-outline-secondary"
-              >
-                )
-              </button>
-              <button
-                type="button"
-                onClick={() => insertOperator('AND')}
-                className="btn btn-sm btn-outline-secondary"
+                onClick={() => setMatch('and')}
+                className={`btn btn-sm ${match === 'and' ? 'btn-primary' : 'btn-outline-secondary'}`}
               >
                 AND
               </button>
               <button
                 type="button"
-                onClick={() => insertOperator('OR')}
-                className="btn btn-sm btn-outline-secondary"
+                onClick={() => setMatch('or')}
+                className={`btn btn-sm ${match === 'or' ? 'btn-primary' : 'btn-outline-secondary'}`}
               >
                 OR
               </button>
               <button
                 type="button"
-                onClick={() => insertOperator('=')}
-                className="btn btn-sm btn-outline-secondary"
-              >
-                =
-              </button>
-              <button
-                type="button"
-                onClick={() => insertOperator('ILIKE')}
+                onClick={() => addCondition()}
                 className="btn btn-sm btn-outline-secondary"
               >
-                ILIKE
-              </button>
-              <button
-                type="button"
-                onClick={() => insertOperator('>')}
-                className="btn btn-sm btn-outline-secondary"
-              >
-                &gt;
-              </button>
-              <button
-                type="button"
-                onClick={() => insertOperator('<')}
-                className="btn btn-sm btn-outline-secondary"
-              >
-                &lt;
-              </button>
-              <button
-                type="button"
-                onClick={() => insertOperator('>=')}
-                className="btn btn-sm btn-outline-secondary"
-              >
-                &gt;=
-              </button>
-              <button
-                type="button"
-                onClick={() => insertOperator('<=')}
-                className="btn btn-sm btn-outline-secondary"
-              >
-                &lt;=
+                + Condition
               </button>
             </div>
-            <textarea
-              ref={textareaRef}
-              value={localValue}
-              onChange={(e) => {
-                const newValue = e.target.value;
-                setLocalValue(newValue);
-                // Clear previous timeout
-                if (validationTimeoutRef.current) {
-                  clearTimeout(validationTimeoutRef.current);
-                }
-                // Validate on change (debounced)
-                validationTimeoutRef.current = setTimeout(() => {
-                  validateWhereClause(newValue);
-                }, 300);
-              }}
-              placeholder="Enter WHERE condition (e.g., state = 'TX' AND city ILIKE '%West%')"
-              className={`where-constructor-textarea ${validationError ? 'where-constructor-textarea--error' : ''}`}
-              rows={4}
-            />
+            <div className="where-constructor-conditions">
+              {rows.length === 0 && (
+                <div className="where-constructor-empty">
+                  No conditions. Add one or click a field.
+                </div>
+              )}
+              {rows.map((row, index) => (
+                <div key={index} className="where-constructor-condition">
+                  <select
+                    value={row.field}
+                    onChange={(e) => updateRow(index, { field: e.target.value })}
+                  >
+                    {columns.map((col) => (
+                      <option key={col} value={col}>
+                        {col}
+                      </option>
+                    ))}
+                  </select>
+                  <select
+                    value={row.op}
+                    onChange={(e) => updateRow(index, { op: e.target.value as FilterOperator })}
+                  >
+                    {operators.map((operator) => (
+                      <option key={operator.value} value={operator.value}>
+                        {operator.label}
+                      </option>
+                    ))}
+                  </select>
+                  {!valuelessOperators.includes(row.op) && (
+                    <input
+                      type="text"
+                      value={row.value}
+                      onChange={(e) => updateRow(index, { value: e.target.value })}
+                      placeholder={listOperators.includes(row.op) ? 'TX, OK, NM' : 'Value'}
+                    />
+                  )}
+                  <button
+                    type="button"
+                    onClick={() => removeCondition(index)}
+                    className="btn btn-sm btn-outline-secondary"
+                    title="Remove condition"
+                  >
+                    ×
+                  </button>
+                </div>
+              ))}
+            </div>
             {validationError && <div className="where-constructor-error">{validationError}</div>}
             <div className="where-constructor-actions">
               <button
                 type="button"
                 onClick={handleApply}
                 className="btn btn-primary btn-sm"
-                disabled={!!validationError}
-                title={validationError || 'Apply WHERE condition'}
+                title="Apply WHERE condition"
               >
                 Apply WHERE
               </button>
@@ -345,9 +257,9 @@ const WhereConstructor: React.FC<WhereConstructorProps> = ({
                 <button
                   key={col}
                   type="button"
-                  onClick={() => insertField(col)}
+                  onClick={() => addCondition(col)}
                   className="where-constructor-field-btn"
-                  title={`Click to insert: ${col}`}
+                  title={`Click to add a condition on: ${col}`}
                 >
                   {col}
                 </button>
@@ -361,4 +273,3 @@ const WhereConstructor: React.FC<WhereConstructorProps> = ({
 };
 
 export default WhereConstructor;
-
@@ -98,6 +98,17 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
   };
 
   const logout = () => {
+    // Revoke the token on the server; the local session ends either way
+    if (token) {
+      axios
+        .post(`${API_URL}/api/v1/auth/logout`, null, {
+          headers: { Authorization: `Bearer ${token}` },
+        })
+        .catch((err) => {
+          console.error('Failed to revoke token:', err);
+        });
+    }
+
     setUser(null);
     setToken(null);
 
@@ -186,6 +186,30 @@ export interface HohAddressDatabase {
   connection_type?: string;
 }
 
+export type FilterOperator =
+  | 'eq'
+  | 'ne'
+  | 'lt'
+  | 'lte'
+  | 'gt'
+  | 'gte'
+  | 'contains'
+  | 'starts_with'
+  | 'ends_with'
+  | 'in'
+  | 'not_in'
+  | 'is_null'
+  | 'is_not_null';
+
+// Filter of a HohAddress table, built into parameterized SQL by the server
+export interface FilterExpression {
+  field?: string;
+  op?: FilterOperator;
+  value?: string | number | boolean | (string | number)[];
+  and?: FilterExpression[];
+  or?: FilterExpression[];
+}
+
 export interface DatabaseStatus {
   connected: boolean;
   message: string;
@@ -648,7 +672,7 @@ class ApiService {
     filters?: Record<string, string>,
     limit?: number,
     offset?: number,
-    where?: string
+    filter?: FilterExpression
   ): Promise<{ data: Record<string, any>[]; totalCount: number }> {
     const params = new URLSearchParams();
     if (filters) {
@@ -656,8 +680,8 @@ class ApiService {
         if (value) params.append(key, value);
       });
     }
-    if (where) {
-      params.append('where', where);
+    if (filter) {
+      params.append('filter', JSON.stringify(filter));
     }
     if (limit) params.append('limit', limit.toString());
     if (offset) params.append('offset', offset.toString());
@@ -674,7 +698,7 @@ class ApiService {
     sortOrder?: 'ASC' | 'DESC',
     limit?: number,
     offset?: number,
-    where?: string
+    filter?: FilterExpression
   ): Promise<{ data: Record<string, any>[]; totalCount: number }> {
     const params = new URLSearchParams();
     if (filters) {
@@ -682,8 +706,8 @@ class ApiService {
         if (value) params.append(key, value);
       });
     }
-    if (where) {
-      params.append('where', where);
+    if (filter) {
+      params.append('filter', JSON.stringify(filter));
     }
     if (sortBy) params.append('sortBy', sortBy);
     if (sortOrder) params.append('sortOrder', sortOrder);
@@ -722,7 +746,7 @@ class ApiService {
     sortOrder?: 'ASC' | 'DESC',
     limit?: number,
     offset?: number,
-    where?: string
+    filter?: FilterExpression
   ): Promise<{ data: Record<string, any>[]; totalCount: number }> {
     const params = new URLSearchParams();
     if (filters) {
@@ -730,8 +754,8 @@ class ApiService {
         if (value) params.append(key, value);
       });
     }
-    if (where) {
-      params.append('where', where);
+    if (filter) {
+      params.append('filter', JSON.stringify(filter));
     }
     if (sortBy) params.append('sortBy', sortBy);
     if (sortOrder) params.append('sortOrder', sortOrder);